@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// selfPort is the port fly-mcp listens on inside its machine
+	selfPort = 8080
+	// selfConfigPath is where the generated config is placed in the image
+	// and the machine
+	selfConfigPath = "/config.yaml"
+	// serverTokenEnv optionally holds a separate token for the deployed
+	// server, instead of the token deploy-self runs with
+	serverTokenEnv = "FLY_MCP_SERVER_TOKEN"
+)
+
+var (
+	selfApp      string
+	selfOrg      string
+	selfRegion   string
+	selfImage    string
+	selfDir      string
+	selfPublic   bool
+	selfMutating bool
+	selfForce    bool
+	selfYes      bool
+)
+
+func init() {
+	deploySelfCmd.Flags().StringVar(&selfApp, "app", "", "app name (default: fly-mcp-<organization>)")
+	deploySelfCmd.Flags().StringVar(&selfOrg, "org", "", "organization to deploy to (default: fly.organization)")
+	deploySelfCmd.Flags().StringVar(&selfRegion, "region", "ord", "region to run the machine in")
+	deploySelfCmd.Flags().StringVar(&selfImage, "image", "", "fly-mcp image to run; without it only the deployment files are written")
+	deploySelfCmd.Flags().StringVar(&selfDir, "dir", "fly-mcp-deploy", "directory for fly.toml, Dockerfile and config.yaml")
+	deploySelfCmd.Flags().BoolVar(&selfPublic, "public", false, "allocate public IPs instead of a private Flycast address")
+	deploySelfCmd.Flags().BoolVar(&selfMutating, "allow-mutating", false, "let the deployed server run tools that change infrastructure")
+	deploySelfCmd.Flags().BoolVarP(&selfForce, "force", "f", false, "overwrite existing files in --dir")
+	deploySelfCmd.Flags().BoolVarP(&selfYes, "yes", "y", false, "deploy without asking for confirmation")
+	rootCmd.AddCommand(deploySelfCmd)
+}
+
+var deploySelfCmd = &cobra.Command{
+	Use:   "deploy-self",
+	Short: "Run fly-mcp itself on Fly.io as a shared MCP endpoint",
+	Long: `Writes a fly.toml, Dockerfile and config.yaml for running fly-mcp on Fly.io,
+then, given --image, creates the app, stores the API token as a secret,
+allocates addresses and starts the machine through the Machines API.
+
+The Machines API cannot build images. Build one from the generated directory
+with "fly deploy --build-only --push" (or any fly-mcp image you already
+publish) and pass it as --image.
+
+Each time the files are written, a new API key is generated for MCP clients;
+config.yaml holds only its digest. By default the app only gets a private
+Flycast address, reachable as http://<app>.flycast/mcp from the
+organization's WireGuard network. --public exposes it to the internet, where
+the API key is all that stands between anyone who finds the URL and its
+token.
+
+The server runs with the token in $FLY_MCP_SERVER_TOKEN if set, otherwise the
+token deploy-self itself uses. A dedicated token ("fly tokens create org",
+or "fly tokens create readonly") is strongly recommended.`,
+	RunE: runDeploySelf,
+}
+
+func runDeploySelf(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	org := selfOrg
+	if org == "" {
+		org = cfg.Fly.Organization
+	}
+	if org == "" {
+		return fmt.Errorf("--org is required when fly.organization is not configured")
+	}
+	app := selfApp
+	if app == "" {
+		app = "fly-mcp-" + org
+	}
+
+	apiKey, err := newAPIKey()
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if err := writeSelfFiles(app, org, apiKeyDigest(apiKey)); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote fly.toml, Dockerfile and config.yaml to %s\n", selfDir)
+	fmt.Fprintf(out, "\nMCP clients identify themselves with this API key, which is not shown again:\n\n  %s\n", apiKey)
+
+	if selfImage == "" {
+		deployCmd := "fly deploy"
+		if !selfPublic {
+			deployCmd += " --no-public-ips"
+		}
+		fmt.Fprintf(out, "\nTo deploy, either run `%s` in %s, or build and push an image:\n\n", deployCmd, selfDir)
+		fmt.Fprintf(out, "  fly apps create %s --org %s\n", app, org)
+		fmt.Fprintf(out, "  cd %s && fly deploy --build-only --push --image-label latest\n", selfDir)
+		fmt.Fprintf(out, "  fly-mcp deploy-self --app %s --image registry.fly.io/%s:latest\n", app, app)
+		return nil
+	}
+
+	serverToken := os.Getenv(serverTokenEnv)
+	if serverToken == "" {
+		serverToken = cfg.Fly.APIToken
+		fmt.Fprintf(out, "\n⚠️  $%s is not set; the server will use your own token.\n", serverTokenEnv)
+	}
+
+	access := "private Flycast address"
+	if selfPublic {
+		access = "PUBLIC IPv4 and IPv6 addresses"
+	}
+	fmt.Fprintf(out, "\nThis will deploy %s to app %s in %s (%s) with a %s.\n", selfImage, app, org, selfRegion, access)
+	if !selfYes && !newPrompter(cmd).confirm("Proceed?", false) {
+		return fmt.Errorf("deployment cancelled")
+	}
+
+	client, err := newCLIClient(&cfg.Fly)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	endpoint, err := deploySelf(ctx, client, app, org, serverToken, out)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\n✅ fly-mcp is running at %s\n", endpoint)
+	fmt.Fprintf(out, "Register it with: fly-mcp install --client claude|cursor|vscode --url %s --api-key <key>\n", endpoint)
+	if !selfPublic {
+		fmt.Fprintln(out, "Clients need a WireGuard connection to the organization (fly wireguard create).")
+	}
+	return nil
+}
+
+// deploySelf creates the app if needed, sets its token secret, allocates
+// addresses and starts or updates its machine, returning the MCP endpoint
+func deploySelf(ctx context.Context, client *fly.Client, app, org, serverToken string, out io.Writer) (string, error) {
+	if _, err := client.GetApp(ctx, app); err != nil {
+		if !fly.IsNotFound(err) {
+			return "", err
+		}
+		fmt.Fprintf(out, "Creating app %s...\n", app)
+		if _, err := client.CreateApp(ctx, app, org, selfRegion); err != nil {
+			return "", err
+		}
+	}
+
+	fmt.Fprintln(out, "Setting the API token secret...")
+	if err := client.SetSecrets(ctx, app, map[string]string{"FLY_MCP_FLY_API_TOKEN": serverToken}); err != nil {
+		return "", err
+	}
+
+	wanted := []string{fly.IPFlycast}
+	if selfPublic {
+		wanted = []string{fly.IPSharedV4, fly.IPv6}
+	}
+	ips, err := client.GetIPAddresses(ctx, app)
+	if err != nil {
+		return "", err
+	}
+	for _, addrType := range wanted {
+		if hasIPType(ips, addrType) {
+			continue
+		}
+		fmt.Fprintf(out, "Allocating %s address...\n", addrType)
+		if _, err := client.AllocateIPAddress(ctx, app, addrType); err != nil {
+			return "", err
+		}
+	}
+
+	machines, err := client.ListMachines(ctx, app)
+	if err != nil {
+		return "", err
+	}
+	if len(machines) == 0 {
+		configData, err := os.ReadFile(filepath.Join(selfDir, "config.yaml"))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintln(out, "Starting the machine and waiting for its health check...")
+		_, err = client.CreateServiceMachine(ctx, app, fly.ServiceMachineRequest{
+			Image:         selfImage,
+			Region:        selfRegion,
+			Env:           map[string]string{"FLY_MCP_FLY_ORGANIZATION": org},
+			InternalPort:  selfPort,
+			HealthPath:    "/health",
+			ForceHTTPS:    selfPublic,
+			Files:         map[string][]byte{selfConfigPath: configData},
+			HealthTimeout: 2 * time.Minute,
+		})
+		if err != nil {
+			return "", err
+		}
+	} else {
+		fmt.Fprintf(out, "Updating %d existing machine(s) to the new image...\n", len(machines))
+		result, err := client.Deploy(ctx, app, fly.DeployRequest{
+			Image:         selfImage,
+			Strategy:      fly.DeployRolling,
+			HealthTimeout: 2 * time.Minute,
+		})
+		if err != nil {
+			return "", err
+		}
+		if result.Status != fly.DeploySucceeded {
+			return "", fmt.Errorf("deploy %s: %s", result.Status, result.Error)
+		}
+	}
+
+	if selfPublic {
+		return fmt.Sprintf("https://%s.fly.dev/mcp", app), nil
+	}
+	return fmt.Sprintf("http://%s.flycast/mcp", app), nil
+}
+
+// newAPIKey returns a random API key for the deployed server's clients
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return "fmcp_" + hex.EncodeToString(b), nil
+}
+
+// apiKeyDigest returns the hex SHA-256 digest of an API key, as
+// security.api_keys expects it
+func apiKeyDigest(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(digest[:])
+}
+
+// hasIPType reports whether an address of a type is allocated
+func hasIPType(ips []fly.IPAddress, addrType string) bool {
+	for _, ip := range ips {
+		if ip.Type == addrType {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSelfFiles writes the deployment directory. keyDigest is the SHA-256
+// digest of the API key clients use.
+func writeSelfFiles(app, org, keyDigest string) error {
+	if err := os.MkdirAll(selfDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", selfDir, err)
+	}
+
+	files := map[string]string{
+		"fly.toml":    selfFlyToml(app),
+		"Dockerfile":  selfDockerfile(),
+		"config.yaml": selfConfig(org, keyDigest),
+	}
+	for name, content := range files {
+		path := filepath.Join(selfDir, name)
+		if _, err := os.Stat(path); err == nil && !selfForce {
+			return fmt.Errorf("%s already exists; use --force to overwrite it", path)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// selfFlyToml returns the fly.toml of the fly-mcp app
+func selfFlyToml(app string) string {
+	var b strings.Builder
+	b.WriteString("# fly-mcp server, written by fly-mcp deploy-self\n")
+	fmt.Fprintf(&b, "app = %q\n", app)
+	fmt.Fprintf(&b, "primary_region = %q\n\n", selfRegion)
+	b.WriteString("[build]\n  dockerfile = \"Dockerfile\"\n\n")
+	b.WriteString("# The API token is the FLY_MCP_FLY_API_TOKEN secret\n")
+	b.WriteString("[env]\n  FLY_MCP_ENVIRONMENT = \"production\"\n\n")
+
+	b.WriteString("[http_service]\n")
+	fmt.Fprintf(&b, "  internal_port = %d\n", selfPort)
+	fmt.Fprintf(&b, "  force_https = %t\n", selfPublic)
+	b.WriteString("  auto_stop_machines = false\n")
+	b.WriteString("  min_machines_running = 1\n\n")
+	b.WriteString("  [[http_service.checks]]\n")
+	b.WriteString("    grace_period = \"10s\"\n    interval = \"30s\"\n    method = \"GET\"\n    timeout = \"5s\"\n    path = \"/health\"\n\n")
+
+	b.WriteString("[vm]\n  cpu_kind = \"shared\"\n  cpus = 1\n  memory_mb = 256\n")
+	if !selfPublic {
+		b.WriteString("\n# Keep the app private: deploy with --no-public-ips, allocate a Flycast\n# address with\n")
+		b.WriteString("#   fly ips allocate-v6 --private\n")
+		b.WriteString("# and connect over WireGuard to http://" + app + ".flycast/mcp\n")
+	}
+	return b.String()
+}
+
+// selfDockerfile returns a Dockerfile that builds this version of fly-mcp
+func selfDockerfile() string {
+	ref := version
+	if ref == "dev" || ref == "" {
+		ref = "latest"
+	}
+
+	return `# fly-mcp server, written by fly-mcp deploy-self
+FROM golang:1.24-alpine AS builder
+RUN apk add --no-cache git ca-certificates
+RUN CGO_ENABLED=0 go install github.com/brannn/fly-mcp/cmd/fly-mcp@` + ref + `
+
+FROM scratch
+COPY --from=builder /etc/ssl/certs/ca-certificates.crt /etc/ssl/certs/
+COPY --from=builder /go/bin/fly-mcp /fly-mcp
+COPY config.yaml ` + selfConfigPath + `
+EXPOSE ` + fmt.Sprint(selfPort) + `
+ENTRYPOINT ["/fly-mcp"]
+CMD ["--config", "` + selfConfigPath + `"]
+`
+}
+
+// selfConfig returns the server config of the fly-mcp app. The token comes
+// from the FLY_MCP_FLY_API_TOKEN secret, which viper only reads for keys the
+// file mentions, hence the empty api_token. Clients identify as the "team"
+// user with the API key whose digest is keyDigest.
+func selfConfig(org, keyDigest string) string {
+	var b strings.Builder
+	b.WriteString("# fly-mcp server, written by fly-mcp deploy-self\n")
+	b.WriteString("environment: production\n\n")
+	fmt.Fprintf(&b, "server:\n  host: \"0.0.0.0\"\n  port: %d\n\n", selfPort)
+	b.WriteString("fly:\n  # Set by the FLY_MCP_FLY_API_TOKEN secret\n  api_token: \"\"\n")
+	fmt.Fprintf(&b, "  organization: %q\n\n", org)
+
+	b.WriteString("security:\n  audit_log_enabled: true\n")
+	b.WriteString("  # SHA-256 digests of the API keys clients send as bearer tokens\n")
+	fmt.Fprintf(&b, "  api_keys:\n    - user: \"team\"\n      sha256: %q\n", keyDigest)
+	b.WriteString("  permissions:\n    default:\n")
+	if selfMutating {
+		b.WriteString("      - \"*\"\n")
+	} else {
+		b.WriteString("      - \"read:*\"\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("logging:\n  level: info\n  format: json\n  output: stdout\n")
+	return b.String()
+}
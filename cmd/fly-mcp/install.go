@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultEndpoint is the MCP endpoint of a server started with the
+// configuration `fly-mcp init` writes
+const defaultEndpoint = "http://127.0.0.1:8080/mcp"
+
+// mcpClient describes where an MCP client keeps its server configuration
+// and how it expects a fly-mcp entry to look
+type mcpClient struct {
+	title string
+	// path returns the client's configuration file on this OS
+	path func() (string, error)
+	// key is the top-level object holding the servers
+	key string
+	// entry returns the server entry for an endpoint, sending apiKey as a
+	// bearer token when it is set
+	entry func(endpoint, apiKey string) map[string]interface{}
+}
+
+// mcpClients are the clients `fly-mcp install` knows about. The server
+// speaks MCP over HTTP only; Claude Desktop starts servers as commands, so
+// it reaches the endpoint through the mcp-remote bridge.
+var mcpClients = map[string]mcpClient{
+	"claude": {
+		title: "Claude Desktop",
+		path:  userConfigPath("Claude", "claude_desktop_config.json"),
+		key:   "mcpServers",
+		entry: func(endpoint, apiKey string) map[string]interface{} {
+			args := []string{"-y", "mcp-remote", endpoint}
+			if apiKey != "" {
+				args = append(args, "--header", "Authorization: Bearer "+apiKey)
+			}
+			return map[string]interface{}{
+				"command": "npx",
+				"args":    args,
+			}
+		},
+	},
+	"cursor": {
+		title: "Cursor",
+		path:  homePath(".cursor", "mcp.json"),
+		key:   "mcpServers",
+		entry: func(endpoint, apiKey string) map[string]interface{} {
+			return withAuthHeader(map[string]interface{}{"url": endpoint}, apiKey)
+		},
+	},
+	"vscode": {
+		title: "VS Code",
+		path:  userConfigPath("Code", "User", "mcp.json"),
+		key:   "servers",
+		entry: func(endpoint, apiKey string) map[string]interface{} {
+			return withAuthHeader(map[string]interface{}{"type": "http", "url": endpoint}, apiKey)
+		},
+	},
+}
+
+// withAuthHeader adds to a server entry the headers sending apiKey as a
+// bearer token, if it is set
+func withAuthHeader(entry map[string]interface{}, apiKey string) map[string]interface{} {
+	if apiKey != "" {
+		entry["headers"] = map[string]string{"Authorization": "Bearer " + apiKey}
+	}
+	return entry
+}
+
+// userConfigPath builds a path under the OS user config directory:
+// ~/Library/Application Support on macOS, %AppData% on Windows and
+// ~/.config elsewhere
+func userConfigPath(elem ...string) func() (string, error) {
+	return func() (string, error) {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(append([]string{dir}, elem...)...), nil
+	}
+}
+
+// homePath builds a path under the user's home directory
+func homePath(elem ...string) func() (string, error) {
+	return func() (string, error) {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(append([]string{dir}, elem...)...), nil
+	}
+}
+
+// lookupClient returns the client named by --client
+func lookupClient(name string) (mcpClient, error) {
+	client, ok := mcpClients[name]
+	if !ok {
+		names := make([]string, 0, len(mcpClients))
+		for n := range mcpClients {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return mcpClient{}, fmt.Errorf("unknown client %q (expected %s)", name, strings.Join(names, ", "))
+	}
+	return client, nil
+}
+
+var (
+	installClient   string
+	installName     string
+	installEndpoint string
+	installConfig   string
+	installAPIKey   string
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{installCmd, uninstallCmd} {
+		cmd.Flags().StringVar(&installClient, "client", "", "MCP client to configure (claude, cursor, vscode)")
+		cmd.Flags().StringVar(&installName, "name", "fly", "server name in the client configuration")
+		cmd.Flags().StringVar(&installConfig, "client-config", "", "client configuration file (default: the client's standard location)")
+		_ = cmd.MarkFlagRequired("client")
+	}
+	installCmd.Flags().StringVar(&installEndpoint, "url", defaultEndpoint, "fly-mcp endpoint the client connects to")
+	installCmd.Flags().StringVar(&installAPIKey, "api-key", "", "API key the client sends to identify itself (see security.api_keys)")
+
+	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(uninstallCmd)
+}
+
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Register fly-mcp in an MCP client's configuration",
+	Long: `Adds fly-mcp to the MCP configuration file of Claude Desktop, Cursor or
+VS Code, keeping the servers already there. Restart the client afterwards.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := lookupClient(installClient)
+		if err != nil {
+			return err
+		}
+		path, err := clientConfigPath(client)
+		if err != nil {
+			return err
+		}
+
+		doc, mode, err := readClientConfig(path)
+		if err != nil {
+			return err
+		}
+		servers, err := serverSection(doc, client.key, path)
+		if err != nil {
+			return err
+		}
+
+		_, replaced := servers[installName]
+		servers[installName] = client.entry(installEndpoint, installAPIKey)
+		doc[client.key] = servers
+
+		if err := writeClientConfig(path, doc, mode); err != nil {
+			return err
+		}
+
+		action := "Added"
+		if replaced {
+			action = "Updated"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %q in %s (%s). Restart %s to pick it up.\n",
+			action, installName, path, client.title, client.title)
+		return nil
+	},
+}
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove fly-mcp from an MCP client's configuration",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := lookupClient(installClient)
+		if err != nil {
+			return err
+		}
+		path, err := clientConfigPath(client)
+		if err != nil {
+			return err
+		}
+
+		doc, mode, err := readClientConfig(path)
+		if err != nil {
+			return err
+		}
+		servers, err := serverSection(doc, client.key, path)
+		if err != nil {
+			return err
+		}
+		if _, ok := servers[installName]; !ok {
+			fmt.Fprintf(cmd.OutOrStdout(), "%q is not in %s; nothing to do.\n", installName, path)
+			return nil
+		}
+
+		delete(servers, installName)
+		doc[client.key] = servers
+
+		if err := writeClientConfig(path, doc, mode); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %q from %s (%s).\n", installName, path, client.title)
+		return nil
+	},
+}
+
+// clientConfigPath returns --client-config or the client's standard file
+func clientConfigPath(client mcpClient) (string, error) {
+	if installConfig != "" {
+		return installConfig, nil
+	}
+	path, err := client.path()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate %s configuration: %w", client.title, err)
+	}
+	return path, nil
+}
+
+// readClientConfig reads a client configuration file, returning an empty
+// document when it does not exist yet
+func readClientConfig(path string) (map[string]interface{}, os.FileMode, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]interface{}{}, 0o644, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	doc := map[string]interface{}{}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	return doc, info.Mode().Perm(), nil
+}
+
+// serverSection returns the object holding the client's servers
+func serverSection(doc map[string]interface{}, key, path string) (map[string]interface{}, error) {
+	section, ok := doc[key]
+	if !ok || section == nil {
+		return map[string]interface{}{}, nil
+	}
+	servers, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: %q is not an object", path, key)
+	}
+	return servers, nil
+}
+
+// writeClientConfig writes a client configuration file, creating its
+// directory if needed
+func writeClientConfig(path string, doc map[string]interface{}, mode os.FileMode) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -3,15 +3,20 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/brannn/fly-mcp/internal/demo"
 	"github.com/brannn/fly-mcp/internal/logger"
 	"github.com/brannn/fly-mcp/internal/server"
+	"github.com/brannn/fly-mcp/internal/tracing"
 	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/credentials"
 )
 
 var (
@@ -41,14 +46,26 @@ and monitoring your Fly.io infrastructure.`,
 var (
 	configFile string
 	logLevel   string
+	demoMode   bool
+	recordFile string
+	replayFile string
 )
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "config file path")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "", "log level (debug, info, warn, error)")
+	rootCmd.Flags().BoolVar(&demoMode, "demo", false, "serve a simulated Fly.io organization instead of your real one (no token required)")
+	rootCmd.Flags().StringVar(&recordFile, "record", "", "record Fly.io API calls, with tokens and secrets masked, to a cassette file")
+	rootCmd.Flags().StringVar(&replayFile, "replay", "", "answer Fly.io API calls from a cassette file instead of Fly.io (no token required)")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
 	
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(validateCmd)
+	
+	tokenEncryptCmd.Flags().StringVarP(&tokenFile, "output", "o", "fly-mcp.token", "encrypted token file to write")
+	tokenEncryptCmd.Flags().StringVar(&passphraseEnv, "passphrase-env", credentials.DefaultPassphraseEnv, "environment variable holding the passphrase")
+	tokenCmd.AddCommand(tokenEncryptCmd)
+	rootCmd.AddCommand(tokenCmd)
 }
 
 var versionCmd = &cobra.Command{
@@ -72,7 +89,7 @@ var validateCmd = &cobra.Command{
 		
 		fmt.Println("Configuration is valid!")
 		fmt.Printf("Environment: %s\n", cfg.Environment)
-		fmt.Printf("Server: %s:%d\n", cfg.Server.Host, cfg.Server.Port)
+		fmt.Printf("Server: %s\n", cfg.Server.Address())
 		fmt.Printf("Fly.io Organization: %s\n", cfg.Fly.Organization)
 		fmt.Printf("Log Level: %s\n", cfg.Logging.Level)
 		
@@ -80,16 +97,92 @@ var validateCmd = &cobra.Command{
 	},
 }
 
+var (
+	tokenFile     string
+	passphraseEnv string
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage the stored Fly.io API token",
+}
+
+var tokenEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt a Fly.io API token read from stdin into a token file",
+	Long: `Reads a Fly.io API token from stdin and writes it to a file encrypted with
+the passphrase in $FLY_MCP_TOKEN_PASSPHRASE (see --passphrase-env). Point
+fly.token_source at the file to use it:
+
+  fly:
+    token_source:
+      type: encrypted_file
+      file: /path/to/fly-mcp.token`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		passphrase := os.Getenv(passphraseEnv)
+		if passphrase == "" {
+			return fmt.Errorf("%s is not set", passphraseEnv)
+		}
+		
+		input, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("failed to read token: %w", err)
+		}
+		token := strings.TrimSpace(string(input))
+		if token == "" {
+			return fmt.Errorf("no token on stdin")
+		}
+		
+		data, err := credentials.Encrypt(token, passphrase)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(tokenFile, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", tokenFile, err)
+		}
+		
+		fmt.Printf("Encrypted token written to %s\n", tokenFile)
+		return nil
+	},
+}
+
 func runServer(cmd *cobra.Command, args []string) error {
 	// Load configuration
-	cfg, err := loadConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	loader := config.NewLoader(configFile)
+	
+	// Demo mode points every Fly.io API at an in-memory organization
+	if demoMode {
+		backend := demo.Start()
+		defer backend.Close()
+		backend.Configure(loader)
+	}
+	
+	// Record or replay Fly.io API calls
+	switch {
+	case recordFile != "":
+		loader.Set("fly.cassette.mode", config.CassetteRecord)
+		loader.Set("fly.cassette.path", recordFile)
+	case replayFile != "":
+		loader.Set("fly.cassette.mode", config.CassetteReplay)
+		loader.Set("fly.cassette.path", replayFile)
+	}
+	
+	loadWithOverrides := func() (*config.Config, error) {
+		cfg, err := loader.Load()
+		if err != nil {
+			return nil, err
+		}
+		
+		// Override log level if specified
+		if logLevel != "" {
+			cfg.Logging.Level = logLevel
+		}
+		return cfg, nil
 	}
 	
-	// Override log level if specified
-	if logLevel != "" {
-		cfg.Logging.Level = logLevel
+	cfg, err := loadWithOverrides()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
 	}
 	
 	// Initialize logger
@@ -103,6 +196,36 @@ func runServer(cmd *cobra.Command, args []string) error {
 		Str("commit", commit).
 		Str("environment", cfg.Environment).
 		Msg("Starting fly-mcp server")
+	if demoMode {
+		log.Warn().
+			Str("organization", demo.Org).
+			Msg("Demo mode: tools act on a simulated organization, not your Fly.io account")
+	}
+	if cfg.Fly.Cassette.Mode != "" {
+		log.Info().
+			Str("mode", cfg.Fly.Cassette.Mode).
+			Str("path", cfg.Fly.Cassette.Path).
+			Msg("Fly.io API cassette enabled")
+	}
+	
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Logging.Tracing, version)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	defer func() {
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to flush traces")
+		}
+	}()
+	if cfg.Logging.Tracing.Enabled {
+		log.Info().
+			Str("endpoint", cfg.Logging.Tracing.Endpoint).
+			Float64("sample_ratio", cfg.Logging.Tracing.SampleRatio).
+			Msg("OpenTelemetry tracing enabled")
+	}
 	
 	// Create server
 	srv, err := server.New(cfg, log)
@@ -118,6 +241,28 @@ func runServer(cmd *cobra.Command, args []string) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	
+	// Reload configuration on SIGHUP and when the config file changes
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			log.Info().Msg("Received SIGHUP, reloading configuration")
+			srv.Reload("sighup", loadWithOverrides)
+		}
+	}()
+	
+	loader.Watch(func(newCfg *config.Config, err error) {
+		srv.Reload("file:"+loader.ConfigFileUsed(), func() (*config.Config, error) {
+			if err != nil {
+				return nil, err
+			}
+			if logLevel != "" {
+				newCfg.Logging.Level = logLevel
+			}
+			return newCfg, nil
+		})
+	})
+	
 	// Start server in goroutine
 	serverErr := make(chan error, 1)
 	go func() {
@@ -127,8 +272,7 @@ func runServer(cmd *cobra.Command, args []string) error {
 	}()
 	
 	log.Info().
-		Str("host", cfg.Server.Host).
-		Int("port", cfg.Server.Port).
+		Str("address", cfg.Server.Address()).
 		Msg("Server started successfully")
 	
 	// Wait for shutdown signal or server error
@@ -137,8 +281,8 @@ func runServer(cmd *cobra.Command, args []string) error {
 		log.Info().Str("signal", sig.String()).Msg("Received shutdown signal")
 		cancel()
 		
-		// Give server time to shutdown gracefully
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		// Give in-flight tool executions time to finish
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
 		defer shutdownCancel()
 		
 		if err := srv.Shutdown(shutdownCtx); err != nil {
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/credentials"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initOutput string
+	initForce  bool
+)
+
+func init() {
+	initCmd.Flags().StringVarP(&initOutput, "output", "o", "config.yaml", "config file to write")
+	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "overwrite an existing config file")
+	rootCmd.AddCommand(initCmd)
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Interactively create a config file",
+	Long: `Walks through setting up fly-mcp: finds or asks for a Fly.io API token,
+checks it against the API, lets you pick an organization, writes a config
+file and prints MCP client configuration for Claude Desktop and Cursor.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := os.Stat(initOutput); err == nil && !initForce {
+			return fmt.Errorf("%s already exists; use --force to overwrite it", initOutput)
+		}
+		w := &wizard{prompter: newPrompter(cmd)}
+		return w.run(cmd.Context())
+	},
+}
+
+// wizard holds the state of an interactive `fly-mcp init` run
+type wizard struct {
+	*prompter
+
+	token        string
+	tokenSource  credentials.Source
+	organization string
+	mutating     bool
+}
+
+// run walks through the setup steps and writes the config file
+func (w *wizard) run(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	fmt.Fprintln(w.out, "fly-mcp setup")
+	fmt.Fprintln(w.out)
+
+	token, err := w.discoverToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	orgs, err := w.validateToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if err := w.pickOrganization(orgs); err != nil {
+		return err
+	}
+	if w.tokenSource.Type == "" {
+		if err := w.storeToken(ctx, token); err != nil {
+			return err
+		}
+	}
+
+	w.mutating = w.confirm("Allow tools that change infrastructure (deploy, scale, restart, ...)?", false)
+
+	if err := w.writeConfig(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w.out, "\nWrote %s. Start the server with:\n\n  fly-mcp --config %s\n", initOutput, initOutput)
+
+	if w.confirm("\nShow MCP client configuration for Claude Desktop and Cursor?", true) {
+		w.printClientConfig()
+	}
+	return nil
+}
+
+// discoverToken finds a token in the flyctl login or the environment, or
+// asks for one
+func (w *wizard) discoverToken(ctx context.Context) (string, error) {
+	if credentials.HasFlyctlLogin() && w.confirm("Found a flyctl login (fly auth login). Use its token?", true) {
+		source := credentials.Source{Type: credentials.SourceFlyctl}
+		token, err := credentials.Resolve(ctx, source)
+		if err != nil {
+			return "", err
+		}
+		w.tokenSource = source
+		return token, nil
+	}
+
+	for _, name := range []string{"FLY_MCP_FLY_API_TOKEN", "FLY_API_TOKEN"} {
+		if token := os.Getenv(name); token != "" && w.confirm(fmt.Sprintf("Use the token in $%s?", name), true) {
+			return token, nil
+		}
+	}
+
+	fmt.Fprintln(w.out, "Create a token with `fly tokens create org` (or `fly tokens create readonly`) and paste it below.")
+	token := w.ask("Fly.io API token", "")
+	if token == "" {
+		return "", fmt.Errorf("a Fly.io API token is required")
+	}
+	return token, nil
+}
+
+// validateToken checks the token against the Fly.io API and returns the
+// organizations it can access
+func (w *wizard) validateToken(ctx context.Context, token string) ([]fly.Organization, error) {
+	cfg, err := config.Defaults()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Fly.APIToken = token
+
+	fmt.Fprintln(w.out, "Checking the token...")
+	client, err := newCLIClient(&cfg.Fly)
+	if err != nil {
+		return nil, fmt.Errorf("the token does not work: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if user, err := client.GetCurrentUser(ctx); err == nil {
+		fmt.Fprintf(w.out, "✅ Authenticated as %s\n\n", user.Email)
+	} else {
+		fmt.Fprintln(w.out, "✅ Token accepted (it does not belong to a user)")
+		fmt.Fprintln(w.out)
+	}
+
+	orgs, err := client.GetOrganizations(ctx)
+	if err != nil {
+		// Deploy tokens cannot list organizations
+		fmt.Fprintf(w.out, "Could not list organizations: %v\n", err)
+		return nil, nil
+	}
+	return orgs, nil
+}
+
+// pickOrganization lets the user choose one of the token's organizations
+func (w *wizard) pickOrganization(orgs []fly.Organization) error {
+	switch len(orgs) {
+	case 0:
+		w.organization = w.ask("Organization slug", "personal")
+		return nil
+	case 1:
+		w.organization = orgs[0].Slug
+		fmt.Fprintf(w.out, "Using organization %s (%s)\n", orgs[0].Name, orgs[0].Slug)
+		return nil
+	}
+
+	fmt.Fprintln(w.out, "Organizations:")
+	for i, org := range orgs {
+		fmt.Fprintf(w.out, "  %d. %s (%s)\n", i+1, org.Name, org.Slug)
+	}
+	for {
+		answer := w.ask("Organization number", "1")
+		n, err := strconv.Atoi(answer)
+		if err == nil && n >= 1 && n <= len(orgs) {
+			w.organization = orgs[n-1].Slug
+			return nil
+		}
+		fmt.Fprintf(w.out, "Enter a number from 1 to %d.\n", len(orgs))
+	}
+}
+
+// storeToken offers to keep a pasted or environment token in the OS
+// keychain rather than the config file
+func (w *wizard) storeToken(ctx context.Context, token string) error {
+	var source credentials.Source
+	switch {
+	case runtime.GOOS == "darwin":
+		source = credentials.Source{Type: credentials.SourceKeychain}
+	case runtime.GOOS == "linux" && commandExists("secret-tool"):
+		source = credentials.Source{Type: credentials.SourceSecretService}
+	}
+
+	if source.Type != "" && w.confirm("Store the token in the OS keychain instead of the config file?", true) {
+		if err := credentials.Store(ctx, source, token); err != nil {
+			return err
+		}
+		w.tokenSource = source
+		fmt.Fprintln(w.out, "✅ Token stored in the keychain")
+		return nil
+	}
+
+	w.token = token
+	fmt.Fprintf(w.out, "⚠️  The token will be written to %s in plaintext; keep the file private.\n", initOutput)
+	return nil
+}
+
+// writeConfig writes the config file
+func (w *wizard) writeConfig() error {
+	var b strings.Builder
+	b.WriteString("# Written by fly-mcp init\n")
+	b.WriteString("environment: local\n\n")
+	b.WriteString("server:\n  host: \"127.0.0.1\"\n  port: 8080\n\n")
+
+	b.WriteString("fly:\n")
+	fmt.Fprintf(&b, "  organization: %q\n", w.organization)
+	if w.token != "" {
+		fmt.Fprintf(&b, "  api_token: %q\n", w.token)
+	} else {
+		fmt.Fprintf(&b, "  token_source:\n    type: %s\n", w.tokenSource.Type)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("security:\n  permissions:\n    default:\n")
+	if w.mutating {
+		b.WriteString("      - \"*\"\n")
+	} else {
+		b.WriteString("      - \"read:*\"\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("logging:\n  level: info\n  format: text\n")
+
+	mode := os.FileMode(0o644)
+	if w.token != "" {
+		mode = 0o600
+	}
+	if err := os.WriteFile(initOutput, []byte(b.String()), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", initOutput, err)
+	}
+	return nil
+}
+
+// printClientConfig prints MCP client configuration for the local server
+func (w *wizard) printClientConfig() {
+	for _, name := range []string{"claude", "cursor"} {
+		client := mcpClients[name]
+		doc := map[string]interface{}{
+			client.key: map[string]interface{}{
+				"fly": client.entry(defaultEndpoint, ""),
+			},
+		}
+		data, _ := json.MarshalIndent(doc, "", "  ")
+
+		location := "its MCP configuration"
+		if path, err := client.path(); err == nil {
+			location = path
+		}
+		fmt.Fprintf(w.out, "\n%s (%s):\n%s\n", client.title, location, data)
+	}
+	fmt.Fprintln(w.out, "\nOr let fly-mcp edit the file: fly-mcp install --client claude|cursor|vscode")
+}
+
+// prompter asks questions on the command's input and output
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// newPrompter returns a prompter for a command
+func newPrompter(cmd *cobra.Command) *prompter {
+	return &prompter{in: bufio.NewReader(cmd.InOrStdin()), out: cmd.OutOrStdout()}
+}
+
+// ask prompts for a line of input, returning def when the answer is empty
+func (p *prompter) ask(prompt, def string) string {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", prompt, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", prompt)
+	}
+
+	line, _ := p.in.ReadString('\n')
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// confirm asks a yes/no question
+func (p *prompter) confirm(prompt string, def bool) bool {
+	options := "y/N"
+	if def {
+		options = "Y/n"
+	}
+	fmt.Fprintf(p.out, "%s [%s]: ", prompt, options)
+
+	line, _ := p.in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	}
+	return def
+}
+
+// newCLIClient returns a Fly.io client for a command, logging only errors
+// so its output stays readable
+func newCLIClient(cfg *config.FlyConfig) (*fly.Client, error) {
+	log, err := logger.New(config.LoggingConfig{Level: "error", Format: "text", Output: "stderr"})
+	if err != nil {
+		return nil, err
+	}
+	return fly.NewClient(cfg, log)
+}
+
+// commandExists reports whether a command is on the PATH
+func commandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
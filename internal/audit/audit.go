@@ -0,0 +1,263 @@
+// Package audit forwards the audit trail to SIEM systems: as RFC 5424
+// syslog or CEF messages to a syslog receiver, or as JSON Lines posted over
+// HTTPS. Events are sent in batches, in the order they happened, and failed
+// deliveries are retried.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// queueSize bounds the events waiting for each exporter. When a destination
+// is down long enough for it to fill up, new events are dropped rather than
+// blocking tool calls.
+const queueSize = 1024
+
+// Defaults of the batching and retry settings of an exporter
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+	defaultMaxRetries    = 3
+	defaultTimeout       = 10 * time.Second
+	maxBackoff           = 30 * time.Second
+)
+
+// Event is an audit event as exported, with the environment it happened in
+type Event struct {
+	auth.AuditEvent
+	Environment string `json:"environment"`
+}
+
+// sink delivers batches of events to one destination
+type sink interface {
+	// send delivers events in order and returns how many were delivered
+	// and, when not all were, whether the rest are worth retrying
+	send(ctx context.Context, events []Event) (int, bool, error)
+	close()
+}
+
+// Exporter forwards audit events to the configured destinations in the
+// background
+type Exporter struct {
+	exporters   []*exporter
+	environment string
+	redactor    *logger.Redactor
+	logger      *logger.Logger
+	metrics     *metrics.Registry
+}
+
+// exporter batches the events of one destination
+type exporter struct {
+	name          string
+	sink          sink
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	timeout       time.Duration
+
+	// mu guards closed, so events logged after Close are dropped instead
+	// of sent on the closed queue
+	mu     sync.Mutex
+	closed bool
+	queue  chan Event
+	done   chan struct{}
+}
+
+// New creates an exporter for cfg.Audit and starts a delivery worker for
+// each destination. With none configured, Export does nothing.
+func New(cfg *config.Config, log *logger.Logger, registry *metrics.Registry) *Exporter {
+	registry.Register("fly_mcp_audit_exports_total", metrics.KindCounter, "Audit events exported to SIEM systems, by exporter and result")
+
+	e := &Exporter{
+		environment: cfg.Environment,
+		redactor:    logger.NewRedactor(cfg.Logging.RedactKeys),
+		logger:      log,
+		metrics:     registry,
+	}
+
+	for _, exporterCfg := range cfg.Audit.Exporters {
+		x := &exporter{
+			name:          exporterName(exporterCfg),
+			batchSize:     exporterCfg.BatchSize,
+			flushInterval: time.Duration(exporterCfg.FlushInterval) * time.Second,
+			maxRetries:    exporterCfg.MaxRetries,
+			timeout:       time.Duration(exporterCfg.Timeout) * time.Second,
+			queue:         make(chan Event, queueSize),
+			done:          make(chan struct{}),
+		}
+		if x.batchSize <= 0 {
+			x.batchSize = defaultBatchSize
+		}
+		if x.flushInterval <= 0 {
+			x.flushInterval = defaultFlushInterval
+		}
+		if exporterCfg.MaxRetries == 0 {
+			x.maxRetries = defaultMaxRetries
+		}
+		if x.timeout <= 0 {
+			x.timeout = defaultTimeout
+		}
+
+		switch exporterCfg.Format {
+		case "jsonl":
+			x.sink = newHTTPSink(exporterCfg)
+		default:
+			x.sink = newSyslogSink(exporterCfg, cfg.MCP.ServerInfo.Version)
+		}
+
+		e.exporters = append(e.exporters, x)
+		go e.run(x)
+	}
+	return e
+}
+
+// Export queues an event for every destination without waiting for it.
+// Sensitive metadata is masked as it is in the log.
+func (e *Exporter) Export(event auth.AuditEvent) {
+	if len(e.exporters) == 0 {
+		return
+	}
+
+	event.Metadata = e.redactMetadata(event.Metadata)
+	exported := Event{AuditEvent: event, Environment: e.environment}
+
+	for _, x := range e.exporters {
+		x.mu.Lock()
+		if !x.closed {
+			select {
+			case x.queue <- exported:
+			default:
+				e.metrics.Inc("fly_mcp_audit_exports_total", metrics.Labels{"exporter": x.name, "result": "dropped"})
+				e.logger.Warn().
+					Str("exporter", x.name).
+					Str("action", event.Action).
+					Msg("Audit export queue full; dropping event")
+			}
+		}
+		x.mu.Unlock()
+	}
+}
+
+// Close stops accepting events and waits until queued events are delivered
+// or ctx expires
+func (e *Exporter) Close(ctx context.Context) error {
+	for _, x := range e.exporters {
+		x.mu.Lock()
+		if !x.closed {
+			x.closed = true
+			close(x.queue)
+		}
+		x.mu.Unlock()
+	}
+
+	for _, x := range e.exporters {
+		select {
+		case <-x.done:
+		case <-ctx.Done():
+			return fmt.Errorf("audit events still pending for %s: %w", x.name, ctx.Err())
+		}
+	}
+	return nil
+}
+
+// run collects an exporter's events into batches, sent when full or when
+// the flush interval passes, until the queue is closed
+func (e *Exporter) run(x *exporter) {
+	defer close(x.done)
+	defer x.sink.close()
+
+	ticker := time.NewTicker(x.flushInterval)
+	defer ticker.Stop()
+
+	var batch []Event
+	for {
+		select {
+		case event, ok := <-x.queue:
+			if !ok {
+				e.flush(x, batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= x.batchSize {
+				e.flush(x, batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			e.flush(x, batch)
+			batch = nil
+		}
+	}
+}
+
+// flush sends a batch, retrying the events not delivered while that may
+// succeed later, with a delay that doubles each time
+func (e *Exporter) flush(x *exporter, batch []Event) {
+	backoff := time.Second
+	for attempt := 0; len(batch) > 0; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), x.timeout)
+		sent, retry, err := x.sink.send(ctx, batch)
+		cancel()
+
+		if sent > 0 {
+			e.metrics.Add("fly_mcp_audit_exports_total", metrics.Labels{"exporter": x.name, "result": "success"}, float64(sent))
+			batch = batch[sent:]
+		}
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= x.maxRetries {
+			e.metrics.Add("fly_mcp_audit_exports_total", metrics.Labels{"exporter": x.name, "result": "failed"}, float64(len(batch)))
+			e.logger.Warn().
+				Err(err).
+				Str("exporter", x.name).
+				Int("events", len(batch)).
+				Int("attempts", attempt+1).
+				Msg("Failed to export audit events")
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// redactMetadata returns a copy of metadata with sensitive fields masked.
+// Values pass through JSON so typed slices and structs are redacted too.
+func (e *Exporter) redactMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return map[string]interface{}{"error": "metadata could not be encoded"}
+	}
+	var decoded map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		return map[string]interface{}{"error": "metadata could not be encoded"}
+	}
+
+	redacted, _ := e.redactor.Redact(decoded).(map[string]interface{})
+	return redacted
+}
+
+// exporterName identifies an exporter in logs and metrics without exposing
+// its URL, which may embed a secret
+func exporterName(cfg config.AuditExporterConfig) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	return cfg.Format
+}
@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// httpSink posts batches of events as JSON Lines, one event per line, e.g.
+// to a Splunk HEC raw endpoint, Elastic or a log pipeline's HTTP input
+type httpSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// newHTTPSink creates the sink of a jsonl exporter
+func newHTTPSink(cfg config.AuditExporterConfig) *httpSink {
+	return &httpSink{
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		client:  &http.Client{},
+	}
+}
+
+// send implements sink. A batch is delivered whole or not at all; network
+// errors, rate limiting and server errors are worth retrying.
+func (s *httpSink) send(ctx context.Context, events []Event) (int, bool, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return 0, false, fmt.Errorf("failed to encode audit event: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, &body)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", "fly-mcp")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return 0, retry, fmt.Errorf("audit endpoint returned %s", resp.Status)
+	}
+	return len(events), false, nil
+}
+
+// close implements sink
+func (s *httpSink) close() {
+	s.client.CloseIdleConnections()
+}
@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+const (
+	// appName identifies fly-mcp in syslog headers and CEF vendor fields
+	appName = "fly-mcp"
+
+	// sdID names the structured data of RFC 5424 messages; 32473 is the
+	// private enterprise number reserved for documentation (RFC 5612)
+	sdID         = "audit@32473"
+	sdMetadataID = "metadata@32473"
+
+	// defaultFacility is log audit
+	defaultFacility = 13
+)
+
+// Syslog severities of audit events
+const (
+	severityWarning = 4
+	severityNotice  = 5
+)
+
+// syslogSink writes RFC 5424 or CEF messages to a syslog receiver over UDP,
+// TCP or TLS. Stream transports frame messages by octet counting (RFC 6587)
+// and keep their connection open between batches.
+type syslogSink struct {
+	format   string
+	network  string
+	address  string
+	tls      *tls.Config
+	facility int
+	hostname string
+	procID   string
+	version  string
+
+	conn net.Conn
+}
+
+// newSyslogSink creates the sink of a syslog or cef exporter
+func newSyslogSink(cfg config.AuditExporterConfig, version string) *syslogSink {
+	s := &syslogSink{
+		format:   cfg.Format,
+		facility: cfg.Facility,
+		hostname: "-",
+		procID:   strconv.Itoa(os.Getpid()),
+		version:  version,
+	}
+	if s.facility == 0 {
+		s.facility = defaultFacility
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		s.hostname = headerField(hostname, 255)
+	}
+
+	u, _ := url.Parse(cfg.Address)
+	s.network, s.address = u.Scheme, u.Host
+	if s.network == "tls" {
+		s.network = "tcp"
+		s.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+	return s
+}
+
+// send implements sink
+func (s *syslogSink) send(ctx context.Context, events []Event) (int, bool, error) {
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return 0, true, fmt.Errorf("failed to connect to %s: %w", s.address, err)
+		}
+		s.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	}
+
+	for i, event := range events {
+		message := s.message(event)
+		if s.network != "udp" {
+			message = strconv.Itoa(len(message)) + " " + message
+		}
+		if _, err := s.conn.Write([]byte(message)); err != nil {
+			// The connection may be half-written; a new one starts clean
+			s.close()
+			return i, true, fmt.Errorf("failed to write to %s: %w", s.address, err)
+		}
+	}
+	return len(events), false, nil
+}
+
+// dial connects to the syslog receiver
+func (s *syslogSink) dial(ctx context.Context) (net.Conn, error) {
+	if s.tls != nil {
+		dialer := &tls.Dialer{Config: s.tls}
+		return dialer.DialContext(ctx, s.network, s.address)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, s.network, s.address)
+}
+
+// close implements sink
+func (s *syslogSink) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// message renders an event as an RFC 5424 message: the event's fields as
+// structured data, or as a CEF record in the message body
+func (s *syslogSink) message(event Event) string {
+	severity := severityNotice
+	if warningResult(event.Result) {
+		severity = severityWarning
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s %s %s %s",
+		s.facility*8+severity,
+		event.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname, appName, s.procID,
+		headerField(event.Action, 32))
+
+	if s.format == "cef" {
+		return header + " - " + cefRecord(event, s.version, s.hostname)
+	}
+
+	var sd strings.Builder
+	sd.WriteString("[" + sdID)
+	writeParam(&sd, "user", event.UserID)
+	writeParam(&sd, "action", event.Action)
+	writeParam(&sd, "resource", event.Resource)
+	writeParam(&sd, "result", event.Result)
+	writeParam(&sd, "request_id", event.RequestID)
+	writeParam(&sd, "environment", event.Environment)
+	sd.WriteString("]")
+	if len(event.Metadata) > 0 {
+		sd.WriteString("[" + sdMetadataID)
+		for _, key := range slices.Sorted(maps.Keys(event.Metadata)) {
+			writeParam(&sd, paramName(key), metadataValue(event.Metadata[key]))
+		}
+		sd.WriteString("]")
+	}
+
+	return fmt.Sprintf("%s %s %s %s by %s: %s", header, sd.String(), event.Action, event.Resource, event.UserID, event.Result)
+}
+
+// cefRecord renders an event as an ArcSight Common Event Format record
+func cefRecord(event Event, version, hostname string) string {
+	severity := 3
+	if warningResult(event.Result) {
+		severity = 7
+	}
+
+	header := []string{"CEF:0", appName, appName, version, event.Action, event.Action + " " + event.Result, strconv.Itoa(severity)}
+	for i := 1; i < len(header); i++ {
+		header[i] = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ", "\r", " ").Replace(header[i])
+	}
+
+	extension := []string{
+		"rt=" + strconv.FormatInt(event.Timestamp.UnixMilli(), 10),
+		"dvchost=" + cefValue(hostname),
+		"suser=" + cefValue(event.UserID),
+		"act=" + cefValue(event.Action),
+		"outcome=" + cefValue(event.Result),
+		"cs1Label=resource cs1=" + cefValue(event.Resource),
+		"cs2Label=environment cs2=" + cefValue(event.Environment),
+	}
+	if event.RequestID != "" {
+		extension = append(extension, "cs3Label=requestId cs3="+cefValue(event.RequestID))
+	}
+	if len(event.Metadata) > 0 {
+		raw, _ := json.Marshal(event.Metadata)
+		extension = append(extension, "cs4Label=metadata cs4="+cefValue(string(raw)))
+	}
+
+	return strings.Join(header, "|") + "|" + strings.Join(extension, " ")
+}
+
+// cefValue escapes a CEF extension value
+func cefValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\n", `\n`, "\r", `\r`).Replace(value)
+}
+
+// warningResult reports whether an audit result is worth a warning: a
+// failed or refused action
+func warningResult(result string) bool {
+	switch result {
+	case "failed", "denied", "rejected", "error":
+		return true
+	}
+	return false
+}
+
+// writeParam adds an SD-PARAM, escaping its value as RFC 5424 requires
+func writeParam(sd *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "]", `\]`).Replace(value)
+	fmt.Fprintf(sd, ` %s="%s"`, name, value)
+}
+
+// paramName makes a metadata key a valid SD-NAME: at most 32 printable
+// characters without =, space, ] or "
+func paramName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, key)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// headerField makes a value a valid header field: printable ASCII without
+// spaces, at most limit characters, or - when empty
+func headerField(value string, limit int) string {
+	field := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(field) > limit {
+		field = field[:limit]
+	}
+	if field == "" {
+		return "-"
+	}
+	return field
+}
+
+// metadataValue renders a metadata value as a parameter: strings as they
+// are, anything else as JSON
+func metadataValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(raw)
+}
@@ -0,0 +1,219 @@
+package fakefly
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// handlePrometheus answers instant and range queries with one series per
+// machine of the org's apps, all at the value set with SetMetricValue
+func (s *Server) handlePrometheus(w http.ResponseWriter, r *http.Request) {
+	if f, ok := s.record(Request{API: "prometheus", Method: r.Method, Path: r.URL.Path}, r.Method+" "+r.URL.Path); ok {
+		writeError(w, f.status, f.message)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org := r.PathValue("org")
+	if _, ok := s.orgs[org]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("organization %s not found", org))
+		return
+	}
+
+	value := strconv.FormatFloat(s.metric, 'f', -1, 64)
+	now := time.Now()
+	result := []interface{}{}
+	for _, name := range sortedKeys(s.apps) {
+		app := s.apps[name]
+		if app.Org != org {
+			continue
+		}
+		for _, m := range app.Machines {
+			metric := map[string]string{"app": app.Name, "instance": m.ID, "region": m.Region}
+			switch r.PathValue("endpoint") {
+			case "query":
+				result = append(result, map[string]interface{}{
+					"metric": metric,
+					"value":  []interface{}{float64(now.Unix()), value},
+				})
+			case "query_range":
+				var values []interface{}
+				for i := 4; i >= 0; i-- {
+					values = append(values, []interface{}{float64(now.Add(-time.Duration(i) * time.Minute).Unix()), value})
+				}
+				result = append(result, map[string]interface{}{"metric": metric, "values": values})
+			default:
+				writeError(w, http.StatusNotFound, "unknown endpoint")
+				return
+			}
+		}
+	}
+
+	resultType := "vector"
+	if r.PathValue("endpoint") == "query_range" {
+		resultType = "matrix"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": resultType, "result": result},
+	})
+}
+
+// handleTags lists the tags of an app's registry repository
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	if f, ok := s.record(Request{API: "registry", Method: r.Method, Path: r.URL.Path}, r.Method+" "+r.URL.Path); ok {
+		writeError(w, f.status, f.message)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.apps[r.PathValue("app")]
+	if !ok || len(app.ImageTags) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"errors": []interface{}{map[string]string{"code": "NAME_UNKNOWN", "message": "repository name not known to registry"}},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"name": app.Name, "tags": sortedKeys(app.ImageTags)})
+}
+
+// handleManifest returns the manifest of a tagged image, or only its digest
+// for HEAD requests
+func (s *Server) handleManifest(w http.ResponseWriter, r *http.Request) {
+	if f, ok := s.record(Request{API: "registry", Method: r.Method, Path: r.URL.Path}, r.Method+" "+r.URL.Path); ok {
+		w.WriteHeader(f.status)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.apps[r.PathValue("app")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	reference := r.PathValue("tag")
+	d, ok := app.ImageTags[reference]
+	if !ok && slices.Contains(slices.Collect(maps.Values(app.ImageTags)), reference) {
+		d, ok = reference, true
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"errors": []interface{}{map[string]string{"code": "MANIFEST_UNKNOWN", "message": "manifest unknown"}},
+		})
+		return
+	}
+	w.Header().Set("Docker-Content-Digest", d)
+	w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        map[string]interface{}{"mediaType": "application/vnd.oci.image.config.v1+json", "digest": Digest("config:" + d), "size": 1024},
+		"layers": []interface{}{
+			map[string]interface{}{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": Digest("layer:" + d), "size": 32 << 20},
+		},
+	})
+}
+
+// handleBlob returns the config of an image, the only blobs the fake has
+func (s *Server) handleBlob(w http.ResponseWriter, r *http.Request) {
+	if f, ok := s.record(Request{API: "registry", Method: r.Method, Path: r.URL.Path}, r.Method+" "+r.URL.Path); ok {
+		w.WriteHeader(f.status)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.apps[r.PathValue("app")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	for tag, d := range app.ImageTags {
+		if Digest("config:"+d) != r.PathValue("digest") {
+			continue
+		}
+		cfg, ok := app.imageConfigs[tag]
+		if !ok {
+			cfg = DefaultImageConfig()
+		}
+		platformOS, arch, _ := strings.Cut(cfg.Platform, "/")
+		exposed := make(map[string]interface{})
+		for _, port := range cfg.ExposedPorts {
+			exposed[port] = map[string]interface{}{}
+		}
+		config := map[string]interface{}{
+			"os":           platformOS,
+			"architecture": arch,
+			"config":       map[string]interface{}{"ExposedPorts": exposed},
+		}
+		if !cfg.Created.IsZero() {
+			config["created"] = cfg.Created
+		}
+		writeJSON(w, http.StatusOK, config)
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]interface{}{
+		"errors": []interface{}{map[string]string{"code": "BLOB_UNKNOWN", "message": "blob unknown to registry"}},
+	})
+}
+
+// handleLogs returns an app's log lines in the format of the logs API
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if f, ok := s.record(Request{API: "logs", Method: r.Method, Path: r.URL.Path}, r.Method+" "+r.URL.Path); ok {
+		writeError(w, f.status, f.message)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.apps[r.PathValue("app")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "app not found")
+		return
+	}
+
+	// The token is the number of lines already returned, so polling with
+	// it returns the lines added since
+	query := r.URL.Query()
+	after, _ := strconv.Atoi(query.Get("next_token"))
+	data := []interface{}{}
+	for i, entry := range app.Logs {
+		if i < after {
+			continue
+		}
+		if region := query.Get("region"); region != "" && entry.Region != region {
+			continue
+		}
+		if instance := query.Get("instance"); instance != "" && entry.Instance != instance {
+			continue
+		}
+		data = append(data, map[string]interface{}{
+			"id": strconv.Itoa(i),
+			"attributes": map[string]interface{}{
+				"timestamp": entry.Timestamp.UTC().Format(time.RFC3339Nano),
+				"message":   entry.Message,
+				"level":     entry.Level,
+				"instance":  entry.Instance,
+				"region":    entry.Region,
+				"meta":      map[string]interface{}{"instance": entry.Instance, "region": entry.Region},
+			},
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+		"meta": map[string]string{"next_token": strconv.Itoa(len(app.Logs))},
+	})
+}
@@ -0,0 +1,578 @@
+package fakefly
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// graphQLMutations are the GraphQL root fields that change something
+var graphQLMutations = []string{"createApp", "deleteApp", "setSecrets", "allocateIpAddress"}
+
+// object is a resolved GraphQL object. Fields that take arguments are
+// resolvers.
+type object map[string]interface{}
+
+// resolver resolves a field from its arguments
+type resolver func(args map[string]interface{}) (interface{}, error)
+
+// graphQLError is an error reported for a root field
+type graphQLError struct {
+	code    string
+	message string
+}
+
+func (e *graphQLError) Error() string {
+	return e.message
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &graphQLError{code: "NOT_FOUND", message: fmt.Sprintf(format, args...)}
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &graphQLError{code: "INVALID_ARGUMENTS", message: fmt.Sprintf(format, args...)}
+}
+
+// handleGraphQL answers a GraphQL request. Every root field, aliased or not,
+// is resolved on its own, so batched queries get an error per field as the
+// real API reports them.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	doc, err := parser.ParseQuery(&ast.Source{Input: body.Query})
+	if err != nil || len(doc.Operations) == 0 {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"errors": []interface{}{map[string]interface{}{"message": fmt.Sprintf("invalid query: %v", err)}},
+		})
+		return
+	}
+
+	data := make(map[string]interface{})
+	var errs []interface{}
+	fail := func(alias string, e *graphQLError) {
+		data[alias] = nil
+		errs = append(errs, map[string]interface{}{
+			"message":    e.message,
+			"path":       []string{alias},
+			"extensions": map[string]string{"code": e.code},
+		})
+	}
+
+	for _, sel := range doc.Operations[0].SelectionSet {
+		field, ok := sel.(*ast.Field)
+		if !ok {
+			continue
+		}
+		alias := fieldAlias(field)
+
+		req := Request{API: "graphql", Method: r.Method, Path: r.URL.Path, Operation: field.Name}
+		if f, ok := s.record(req, field.Name); ok {
+			fail(alias, &graphQLError{code: graphQLCode(f.status), message: f.message})
+			continue
+		}
+
+		value, err := s.resolveRoot(field, body.Variables)
+		if err != nil {
+			e, ok := err.(*graphQLError)
+			if !ok {
+				e = &graphQLError{code: "SERVER_ERROR", message: err.Error()}
+			}
+			fail(alias, e)
+			continue
+		}
+		data[alias] = value
+	}
+
+	resp := map[string]interface{}{"data": data}
+	if len(errs) > 0 {
+		resp["errors"] = errs
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// resolveRoot resolves a root field and projects it onto its selection
+func (s *Server) resolveRoot(field *ast.Field, vars map[string]interface{}) (interface{}, error) {
+	args, err := fieldArgs(field, vars)
+	if err != nil {
+		return nil, invalid("%v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var value interface{}
+	switch field.Name {
+	case "viewer":
+		value = s.viewerLocked()
+	case "app":
+		value, err = s.appObjectLocked(stringArg(args, "name"))
+	case "apps":
+		value = s.appsLocked(args)
+	case "organization":
+		value, err = s.orgObjectLocked(stringArg(args, "slug"))
+	case "organizations":
+		value = s.orgsLocked()
+	case "createApp":
+		value, err = s.createAppLocked(inputArg(args))
+	case "deleteApp":
+		value, err = s.deleteAppLocked(stringArg(args, "appId"))
+	case "setSecrets":
+		value, err = s.setSecretsLocked(inputArg(args))
+	case "allocateIpAddress":
+		value, err = s.allocateIPAddressLocked(inputArg(args))
+	default:
+		return nil, &graphQLError{code: "UNKNOWN_FIELD", message: fmt.Sprintf("fakefly does not implement %s", field.Name)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return project(value, field.SelectionSet, vars)
+}
+
+func (s *Server) viewerLocked() object {
+	return object{
+		"__typename":      "User",
+		"id":              s.viewer.ID,
+		"email":           s.viewer.Email,
+		"name":            s.viewer.Name,
+		"enablePaidHobby": false,
+	}
+}
+
+func (s *Server) appsLocked(args map[string]interface{}) object {
+	orgID := stringArg(args, "organizationId")
+	nodes := []interface{}{}
+	for _, name := range sortedKeys(s.apps) {
+		app := s.apps[name]
+		if org := s.orgs[app.Org]; orgID != "" && (org == nil || (org.ID != orgID && org.Slug != orgID)) {
+			continue
+		}
+		nodes = append(nodes, s.appLocked(app))
+	}
+	return object{
+		"pageInfo": object{"hasNextPage": false, "endCursor": nil},
+		"nodes":    nodes,
+	}
+}
+
+func (s *Server) orgsLocked() object {
+	nodes := []interface{}{}
+	for _, slug := range sortedKeys(s.orgs) {
+		nodes = append(nodes, orgObject(s.orgs[slug]))
+	}
+	return object{"nodes": nodes}
+}
+
+func (s *Server) appObjectLocked(name string) (object, error) {
+	app, ok := s.apps[name]
+	if !ok {
+		return nil, notFound("Could not find App %q", name)
+	}
+	return s.appLocked(app), nil
+}
+
+func (s *Server) orgObjectLocked(slug string) (object, error) {
+	org, ok := s.orgs[slug]
+	if !ok {
+		return nil, notFound("Could not find Organization %q", slug)
+	}
+	return orgObject(org), nil
+}
+
+// appLocked renders an app as the GraphQL API does
+func (s *Server) appLocked(app *App) object {
+	org := s.orgs[app.Org]
+	if org == nil {
+		org = &Org{ID: app.Org, Slug: app.Org, Name: app.Org, Type: "SHARED"}
+	}
+
+	secrets := []interface{}{}
+	for _, name := range sortedKeys(app.Secrets) {
+		secrets = append(secrets, object{
+			"name":      name,
+			"digest":    strings.TrimPrefix(Digest(app.Secrets[name]), "sha256:")[:16],
+			"createdAt": app.createdAt,
+		})
+	}
+
+	releases := make([]interface{}, 0, len(app.Releases))
+	for i := len(app.Releases) - 1; i >= 0; i-- {
+		releases = append(releases, s.releaseObject(app.Releases[i]))
+	}
+	var currentRelease interface{}
+	if len(releases) > 0 {
+		currentRelease = releases[0]
+	}
+
+	ips := []interface{}{}
+	var shared interface{}
+	for _, ip := range app.IPAddresses {
+		if ip.Type == "shared_v4" {
+			shared = ip.Address
+			continue
+		}
+		ips = append(ips, ipObject(ip, app))
+	}
+
+	certs := []interface{}{}
+	for _, cert := range app.Certificates {
+		certs = append(certs, object{
+			"hostname":              cert.Hostname,
+			"clientStatus":          cert.ClientStatus,
+			"isApex":                cert.IsApex,
+			"dnsValidationHostname": cert.DNSValidationHostname,
+			"dnsValidationTarget":   cert.DNSValidationTarget,
+		})
+	}
+
+	regions := []interface{}{}
+	seen := make(map[string]bool)
+	for _, m := range app.Machines {
+		if !seen[m.Region] {
+			seen[m.Region] = true
+			regions = append(regions, object{"name": m.Region, "code": m.Region})
+		}
+	}
+
+	// The services of the first machine that has any, as fly.toml defines
+	// them for every machine
+	services := []interface{}{}
+	for _, m := range app.Machines {
+		configured, _ := m.Config["services"].([]interface{})
+		if len(configured) == 0 {
+			continue
+		}
+		for _, raw := range configured {
+			svc, _ := raw.(map[string]interface{})
+			ports := []interface{}{}
+			if list, ok := svc["ports"].([]interface{}); ok {
+				for _, p := range list {
+					if port, ok := p.(map[string]interface{}); ok {
+						ports = append(ports, object{"port": port["port"], "handlers": port["handlers"]})
+					}
+				}
+			}
+			services = append(services, object{
+				"description":  "",
+				"protocol":     svc["protocol"],
+				"internalPort": svc["internal_port"],
+				"ports":        ports,
+			})
+		}
+		break
+	}
+
+	return object{
+		"id":              app.Name,
+		"name":            app.Name,
+		"status":          app.Status,
+		"deployed":        app.Deployed,
+		"hostname":        app.Hostname,
+		"appUrl":          "https://" + app.Hostname,
+		"network":         app.Network,
+		"platformVersion": "machines",
+		"createdAt":       app.createdAt,
+		"organization":    orgObject(org),
+		"currentRelease":  currentRelease,
+		"role":            nil,
+		"secrets":         secrets,
+		"releasesUnprocessed": resolver(func(args map[string]interface{}) (interface{}, error) {
+			limit := intArg(args, "first")
+			if limit > 0 && limit < len(releases) {
+				return object{"nodes": releases[:limit]}, nil
+			}
+			return object{"nodes": releases}, nil
+		}),
+		"ipAddresses":     object{"nodes": ips},
+		"sharedIpAddress": shared,
+		"certificates":    object{"nodes": certs},
+		"config":          object{"definition": object{"app": app.Name}},
+		"regions":         regions,
+		"services":        services,
+	}
+}
+
+func (s *Server) releaseObject(r Release) object {
+	return object{
+		"id":           r.ID,
+		"version":      r.Version,
+		"status":       r.Status,
+		"description":  r.Description,
+		"reason":       r.Reason,
+		"imageRef":     r.ImageRef,
+		"stable":       r.Stable,
+		"inProgress":   false,
+		"createdAt":    r.CreatedAt,
+		"evaluationId": "",
+		"user":         object{"id": s.viewer.ID, "email": s.viewer.Email, "name": s.viewer.Name},
+	}
+}
+
+func orgObject(org *Org) object {
+	return object{
+		"id":                  org.ID,
+		"internalNumericId":   strconv.Itoa(len(org.ID)),
+		"slug":                org.Slug,
+		"rawSlug":             org.Slug,
+		"name":                org.Name,
+		"type":                org.Type,
+		"paidPlan":            org.Type != "SHARED",
+		"billable":            true,
+		"viewerRole":          "admin",
+		"limitedAccessTokens": object{"nodes": []interface{}{}},
+	}
+}
+
+func ipObject(ip IPAddress, app *App) object {
+	return object{
+		"id":          ip.ID,
+		"address":     ip.Address,
+		"type":        ip.Type,
+		"region":      ip.Region,
+		"createdAt":   app.createdAt,
+		"serviceName": "",
+		"network": object{
+			"name":         app.Network,
+			"organization": object{"slug": app.Org},
+		},
+	}
+}
+
+func (s *Server) createAppLocked(input map[string]interface{}) (interface{}, error) {
+	name := stringArg(input, "name")
+	if name == "" {
+		return nil, invalid("name is required")
+	}
+	if _, exists := s.apps[name]; exists {
+		return nil, invalid("Name has already been taken")
+	}
+
+	orgID := stringArg(input, "organizationId")
+	var org *Org
+	for _, candidate := range s.orgs {
+		if candidate.ID == orgID || candidate.Slug == orgID {
+			org = candidate
+		}
+	}
+	if org == nil {
+		return nil, notFound("Could not find Organization %q", orgID)
+	}
+
+	app := &App{Name: name, Org: org.Slug, Status: "pending", Network: stringArg(input, "network")}
+	s.addAppLocked(app)
+	return object{"app": s.appLocked(app)}, nil
+}
+
+func (s *Server) deleteAppLocked(name string) (interface{}, error) {
+	app, ok := s.apps[name]
+	if !ok {
+		return nil, notFound("Could not find App %q", name)
+	}
+	delete(s.apps, name)
+
+	org := object{"id": app.Org}
+	if o := s.orgs[app.Org]; o != nil {
+		org = orgObject(o)
+	}
+	return object{"organization": org}, nil
+}
+
+func (s *Server) setSecretsLocked(input map[string]interface{}) (interface{}, error) {
+	name := stringArg(input, "appId")
+	app, ok := s.apps[name]
+	if !ok {
+		return nil, notFound("Could not find App %q", name)
+	}
+
+	secrets, _ := input["secrets"].([]interface{})
+	if len(secrets) == 0 {
+		return nil, invalid("secrets are required")
+	}
+	var keys []string
+	for _, raw := range secrets {
+		secret, _ := raw.(map[string]interface{})
+		key := stringArg(secret, "key")
+		app.Secrets[key] = stringArg(secret, "value")
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	release := Release{
+		ID:          s.newIDLocked("rel"),
+		Version:     len(app.Releases) + 1,
+		Status:      "complete",
+		Description: "Set secrets " + strings.Join(keys, ", "),
+		Reason:      "change_secrets",
+		CreatedAt:   time.Now().UTC(),
+	}
+	if len(app.Releases) > 0 {
+		release.ImageRef = app.Releases[len(app.Releases)-1].ImageRef
+	}
+	app.Releases = append(app.Releases, release)
+	return object{"release": s.releaseObject(release)}, nil
+}
+
+func (s *Server) allocateIPAddressLocked(input map[string]interface{}) (interface{}, error) {
+	name := stringArg(input, "appId")
+	app, ok := s.apps[name]
+	if !ok {
+		return nil, notFound("Could not find App %q", name)
+	}
+
+	ip := IPAddress{
+		ID:     s.newIDLocked("ip"),
+		Type:   stringArg(input, "type"),
+		Region: stringArg(input, "region"),
+	}
+	switch ip.Type {
+	case "shared_v4":
+		ip.Address = "66.241.124.1"
+	case "v4":
+		ip.Address = fmt.Sprintf("137.66.1.%d", s.nextID%250+1)
+	case "v6", "private_v6":
+		ip.Address = fmt.Sprintf("2a09:8280:1::%x", s.nextID)
+	default:
+		return nil, invalid("unknown address type %q", ip.Type)
+	}
+	app.IPAddresses = append(app.IPAddresses, ip)
+
+	return object{
+		"app":       s.appLocked(app),
+		"ipAddress": ipObject(ip, app),
+	}, nil
+}
+
+// project narrows a resolved value to a selection set, honoring aliases and
+// inline fragments
+func project(value interface{}, sel ast.SelectionSet, vars map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case object:
+		if len(sel) == 0 {
+			return v, nil
+		}
+		out := make(map[string]interface{})
+		if err := projectInto(out, v, sel, vars); err != nil {
+			return nil, err
+		}
+		return out, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			projected, err := project(item, sel, vars)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = projected
+		}
+		return list, nil
+	default:
+		return value, nil
+	}
+}
+
+func projectInto(out map[string]interface{}, obj object, sel ast.SelectionSet, vars map[string]interface{}) error {
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *ast.Field:
+			value := obj[s.Name]
+			if fn, ok := value.(resolver); ok {
+				args, err := fieldArgs(s, vars)
+				if err != nil {
+					return invalid("%v", err)
+				}
+				if value, err = fn(args); err != nil {
+					return err
+				}
+			}
+			projected, err := project(value, s.SelectionSet, vars)
+			if err != nil {
+				return err
+			}
+			out[fieldAlias(s)] = projected
+		case *ast.InlineFragment:
+			if s.TypeCondition == "" || s.TypeCondition == obj["__typename"] {
+				if err := projectInto(out, obj, s.SelectionSet, vars); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// fieldAlias returns the key a field is reported under
+func fieldAlias(field *ast.Field) string {
+	if field.Alias != "" {
+		return field.Alias
+	}
+	return field.Name
+}
+
+// fieldArgs evaluates a field's arguments against the request's variables
+func fieldArgs(field *ast.Field, vars map[string]interface{}) (map[string]interface{}, error) {
+	args := make(map[string]interface{}, len(field.Arguments))
+	for _, arg := range field.Arguments {
+		value, err := arg.Value.Value(vars)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", arg.Name, err)
+		}
+		args[arg.Name] = value
+	}
+	return args, nil
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+func intArg(args map[string]interface{}, name string) int {
+	switch value := args[name].(type) {
+	case float64:
+		return int(value)
+	case int64:
+		return int(value)
+	case int:
+		return value
+	}
+	return 0
+}
+
+func inputArg(args map[string]interface{}) map[string]interface{} {
+	input, _ := args["input"].(map[string]interface{})
+	return input
+}
+
+// graphQLCode returns the error code the GraphQL API uses for an HTTP status
+func graphQLCode(status int) string {
+	switch status {
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusUnauthorized:
+		return "UNAUTHORIZED"
+	case http.StatusForbidden:
+		return "FORBIDDEN"
+	case http.StatusUnprocessableEntity, http.StatusBadRequest:
+		return "INVALID_ARGUMENTS"
+	case http.StatusTooManyRequests:
+		return "RATE_LIMITED"
+	case http.StatusServiceUnavailable:
+		return "SERVICE_UNAVAILABLE"
+	}
+	return "SERVER_ERROR"
+}
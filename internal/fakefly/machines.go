@@ -0,0 +1,470 @@
+package fakefly
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+// routeMachines registers the Machines API endpoints
+func (s *Server) routeMachines(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/apps", s.machinesAPI(s.listApps))
+	mux.HandleFunc("GET /v1/apps/{app}/machines", s.machinesAPI(s.listMachines))
+	mux.HandleFunc("POST /v1/apps/{app}/machines", s.machinesAPI(s.createMachine))
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}", s.machinesAPI(s.getMachine))
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}", s.machinesAPI(s.updateMachine))
+	mux.HandleFunc("DELETE /v1/apps/{app}/machines/{id}", s.machinesAPI(s.destroyMachine))
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}/events", s.machinesAPI(s.listEvents))
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/start", s.machinesAPI(s.startMachine))
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/stop", s.machinesAPI(s.stopMachine))
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/exec", s.machinesAPI(s.execMachine))
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}/metadata", s.machinesAPI(s.getMetadata))
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/metadata/{key}", s.machinesAPI(s.setMetadata))
+	mux.HandleFunc("DELETE /v1/apps/{app}/machines/{id}/metadata/{key}", s.machinesAPI(s.deleteMetadata))
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}/lease", s.machinesAPI(s.getLease))
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/lease", s.machinesAPI(s.acquireLease))
+	mux.HandleFunc("DELETE /v1/apps/{app}/machines/{id}/lease", s.machinesAPI(s.releaseLease))
+	mux.HandleFunc("GET /v1/apps/{app}/volumes", s.machinesAPI(s.listVolumes))
+	mux.HandleFunc("POST /v1/apps/{app}/volumes", s.machinesAPI(s.createVolume))
+	mux.HandleFunc("GET /v1/apps/{app}/volumes/{id}/snapshots", s.machinesAPI(s.listSnapshots))
+}
+
+// machinesHandler serves one Machines API endpoint with the lock held. It
+// returns the status and the response body.
+type machinesHandler func(r *http.Request, app *App) (int, interface{})
+
+// machinesAPI records a request, applies injected failures, looks up the
+// app and runs the handler
+func (s *Server) machinesAPI(handle machinesHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if f, ok := s.record(Request{API: "machines", Method: r.Method, Path: r.URL.Path}, r.Method+" "+r.URL.Path); ok {
+			writeError(w, f.status, f.message)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		var app *App
+		if name := r.PathValue("app"); name != "" {
+			var ok bool
+			if app, ok = s.apps[name]; !ok {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("app %s not found", name))
+				return
+			}
+		}
+
+		status, body := handle(r, app)
+		if message, ok := body.(string); ok && status >= 400 {
+			writeError(w, status, message)
+			return
+		}
+		writeJSON(w, status, body)
+	}
+}
+
+func (s *Server) listApps(r *http.Request, _ *App) (int, interface{}) {
+	slug := r.URL.Query().Get("org_slug")
+	apps := []fly.OrgApp{}
+	for _, name := range sortedKeys(s.apps) {
+		app := s.apps[name]
+		if slug != "" && app.Org != slug {
+			continue
+		}
+		apps = append(apps, fly.OrgApp{
+			ID:           app.Name,
+			Name:         app.Name,
+			MachineCount: len(app.Machines),
+			Network:      app.Network,
+		})
+	}
+	return http.StatusOK, map[string]interface{}{"total_apps": len(apps), "apps": apps}
+}
+
+func (s *Server) listMachines(_ *http.Request, app *App) (int, interface{}) {
+	machines := []fly.Machine{}
+	for _, m := range app.Machines {
+		machines = append(machines, *m)
+	}
+	return http.StatusOK, machines
+}
+
+func (s *Server) getMachine(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	return http.StatusOK, m
+}
+
+func (s *Server) createMachine(r *http.Request, app *App) (int, interface{}) {
+	var input fly.CreateMachineRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	if configString(input.Config, "image") == "" {
+		return http.StatusUnprocessableEntity, "image is required"
+	}
+
+	now := time.Now().UTC()
+	m := &fly.Machine{
+		ID:        s.newIDLocked("m"),
+		Name:      input.Name,
+		Region:    input.Region,
+		PrivateIP: fmt.Sprintf("fdaa:0:1:a7b:1::%d", s.nextID),
+		Config:    cloneConfig(input.Config),
+		CreatedAt: now,
+	}
+	if m.Name == "" {
+		m.Name = m.ID
+	}
+	if m.Region == "" {
+		m.Region = "ord"
+	}
+	s.addEventLocked(m, "launch", "created", "user")
+
+	// Scheduled machines wait for their schedule rather than starting
+	if configString(m.Config, "schedule") != "" {
+		s.setStateLocked(m, "stopped", "")
+	} else {
+		s.setStateLocked(m, "started", "start")
+	}
+
+	app.Machines = append(app.Machines, m)
+	return http.StatusOK, m
+}
+
+func (s *Server) updateMachine(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+
+	var input struct {
+		Config     map[string]interface{} `json:"config"`
+		SkipLaunch bool                   `json:"skip_launch"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+
+	m.Config = cloneConfig(input.Config)
+	s.addEventLocked(m, "update", "replaced", "user")
+	if m.State == "started" || !input.SkipLaunch {
+		s.setStateLocked(m, "started", "start")
+	} else {
+		s.setStateLocked(m, m.State, "")
+	}
+	return http.StatusOK, m
+}
+
+func (s *Server) destroyMachine(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	if m.State == "started" && r.URL.Query().Get("force") != "true" {
+		return http.StatusPreconditionFailed, "machine is started; stop it first or force"
+	}
+
+	for i, candidate := range app.Machines {
+		if candidate == m {
+			app.Machines = append(app.Machines[:i], app.Machines[i+1:]...)
+			break
+		}
+	}
+	return http.StatusOK, map[string]bool{"ok": true}
+}
+
+func (s *Server) listEvents(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+
+	// Newest first, as the API returns them
+	events := make([]fly.MachineEvent, 0, len(m.Events))
+	for i := len(m.Events) - 1; i >= 0; i-- {
+		events = append(events, m.Events[i])
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit < len(events) {
+		events = events[:limit]
+	}
+	return http.StatusOK, events
+}
+
+func (s *Server) startMachine(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	s.setStateLocked(m, "started", "start")
+	return http.StatusOK, map[string]string{"previous_state": "stopped"}
+}
+
+func (s *Server) stopMachine(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	s.setStateLocked(m, "stopped", "stop")
+	return http.StatusOK, map[string]bool{"ok": true}
+}
+
+func (s *Server) execMachine(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	if m.State != "started" {
+		return http.StatusPreconditionFailed, "machine is not started"
+	}
+
+	var input struct {
+		Command []string `json:"command"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	return http.StatusOK, s.exec(app.Name, m.ID, input.Command)
+}
+
+// machineMetadata returns a machine's metadata, adding it to the config
+// when it has none
+func machineMetadata(m *fly.Machine) map[string]interface{} {
+	metadata, ok := m.Config["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		m.Config["metadata"] = metadata
+	}
+	return metadata
+}
+
+func (s *Server) getMetadata(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	return http.StatusOK, machineMetadata(m)
+}
+
+func (s *Server) setMetadata(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	var input struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	machineMetadata(m)[r.PathValue("key")] = input.Value
+	return http.StatusOK, map[string]bool{"ok": true}
+}
+
+func (s *Server) deleteMetadata(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	delete(machineMetadata(m), r.PathValue("key"))
+	return http.StatusOK, map[string]bool{"ok": true}
+}
+
+// activeLeaseLocked returns the unexpired lease held on a machine
+func (s *Server) activeLeaseLocked(app *App, machineID string) *fly.MachineLease {
+	lease := app.leases[machineID]
+	if lease == nil || lease.Expires().Before(time.Now()) {
+		return nil
+	}
+	return lease
+}
+
+func (s *Server) getLease(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	lease := s.activeLeaseLocked(app, m.ID)
+	if lease == nil {
+		return http.StatusNotFound, "lease not found"
+	}
+	return http.StatusOK, map[string]interface{}{"status": "success", "data": lease}
+}
+
+func (s *Server) acquireLease(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	var input struct {
+		TTL         int    `json:"ttl"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	if s.activeLeaseLocked(app, m.ID) != nil {
+		return http.StatusConflict, "machine is leased by someone else"
+	}
+
+	lease := &fly.MachineLease{
+		Nonce:       s.newIDLocked("nonce"),
+		ExpiresAt:   time.Now().Add(time.Duration(input.TTL) * time.Second).Unix(),
+		Owner:       "dev@example.com",
+		Description: input.Description,
+	}
+	if app.leases == nil {
+		app.leases = make(map[string]*fly.MachineLease)
+	}
+	app.leases[m.ID] = lease
+	return http.StatusOK, map[string]interface{}{"status": "success", "data": lease}
+}
+
+func (s *Server) releaseLease(r *http.Request, app *App) (int, interface{}) {
+	m, status, message := findMachine(r, app)
+	if m == nil {
+		return status, message
+	}
+	lease := s.activeLeaseLocked(app, m.ID)
+	if lease == nil || lease.Nonce != r.Header.Get("fly-machine-lease-nonce") {
+		return http.StatusConflict, "lease nonce does not match"
+	}
+	delete(app.leases, m.ID)
+	return http.StatusOK, map[string]bool{"ok": true}
+}
+
+// Lease leases a machine, as a concurrent deploy would, and returns the
+// lease's nonce
+func (s *Server) Lease(appName, machineID, owner string, ttl time.Duration) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := s.mustAppLocked(appName)
+	if app.leases == nil {
+		app.leases = make(map[string]*fly.MachineLease)
+	}
+	lease := &fly.MachineLease{
+		Nonce:     s.newIDLocked("nonce"),
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+		Owner:     owner,
+	}
+	app.leases[machineID] = lease
+	return lease.Nonce
+}
+
+func (s *Server) listVolumes(_ *http.Request, app *App) (int, interface{}) {
+	volumes := []fly.MachineVolume{}
+	for _, v := range app.Volumes {
+		volumes = append(volumes, v.MachineVolume)
+	}
+	return http.StatusOK, volumes
+}
+
+func (s *Server) createVolume(r *http.Request, app *App) (int, interface{}) {
+	var input fly.CreateVolumeRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	if input.Name == "" || input.Region == "" {
+		return http.StatusUnprocessableEntity, "name and region are required"
+	}
+	if input.SnapshotID != "" && !s.hasSnapshotLocked(app, input.SnapshotID) {
+		return http.StatusNotFound, fmt.Sprintf("snapshot %s not found", input.SnapshotID)
+	}
+
+	v := &Volume{MachineVolume: fly.MachineVolume{
+		ID:        s.newIDLocked("vol"),
+		Name:      input.Name,
+		State:     "created",
+		SizeGB:    max(input.SizeGB, 1),
+		Region:    input.Region,
+		Encrypted: input.Encrypted,
+		CreatedAt: time.Now().UTC(),
+	}}
+	app.Volumes = append(app.Volumes, v)
+	return http.StatusOK, v.MachineVolume
+}
+
+func (s *Server) listSnapshots(r *http.Request, app *App) (int, interface{}) {
+	id := r.PathValue("id")
+	for _, v := range app.Volumes {
+		if v.ID == id {
+			snapshots := append([]fly.VolumeSnapshot{}, v.Snapshots...)
+			return http.StatusOK, snapshots
+		}
+	}
+	return http.StatusNotFound, fmt.Sprintf("volume %s not found", id)
+}
+
+func (s *Server) hasSnapshotLocked(app *App, id string) bool {
+	for _, v := range app.Volumes {
+		for _, snapshot := range v.Snapshots {
+			if snapshot.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findMachine returns the machine a request names, or the error to answer
+func findMachine(r *http.Request, app *App) (*fly.Machine, int, string) {
+	id := r.PathValue("id")
+	for _, m := range app.Machines {
+		if m.ID == id {
+			return m, 0, ""
+		}
+	}
+	return nil, http.StatusNotFound, fmt.Sprintf("machine %s not found", id)
+}
+
+// setStateLocked moves a machine to a state, recording an event of
+// eventType unless it is empty. A started machine runs its config's image
+// and reports its health checks afresh.
+func (s *Server) setStateLocked(m *fly.Machine, state, eventType string) {
+	now := time.Now().UTC()
+	m.State = state
+	m.UpdatedAt = now
+	if eventType != "" {
+		s.addEventLocked(m, eventType, state, "user")
+	}
+	if state != "started" {
+		return
+	}
+
+	if image := configString(m.Config, "image"); image != "" {
+		m.ImageRef = imageRef(image)
+	}
+
+	checks, _ := m.Config["checks"].(map[string]interface{})
+	previous := make(map[string]string, len(m.Checks))
+	for _, check := range m.Checks {
+		previous[check.Name] = check.Status
+	}
+	m.Checks = m.Checks[:0]
+	for _, name := range sortedKeys(checks) {
+		status := previous[name]
+		if status == "" || !strings.EqualFold(status, "critical") {
+			status = "passing"
+		}
+		m.Checks = append(m.Checks, fly.MachineCheck{Name: name, Status: status, UpdatedAt: now})
+	}
+}
+
+// addEventLocked records a machine event
+func (s *Server) addEventLocked(m *fly.Machine, eventType, status, source string) {
+	m.Events = append(m.Events, fly.MachineEvent{
+		ID:        s.newIDLocked("ev"),
+		Type:      eventType,
+		Status:    status,
+		Source:    source,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
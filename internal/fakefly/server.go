@@ -0,0 +1,681 @@
+// Package fakefly runs an in-memory stand-in for the Fly.io APIs fly-mcp
+// talks to: the GraphQL API, the Machines API, hosted Prometheus, the image
+// registry and app logs. Apps, machines and volumes are seeded by its user
+// and change as tools act on them. flytest drives tools against it in tests
+// and the demo mode serves it as a simulated organization; it imports
+// nothing test-only, so it can be linked into the binary.
+package fakefly
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+// Token is the API token the fake accepts
+const Token = "fo1_fakefly"
+
+// User is the owner of the token
+type User struct {
+	ID    string
+	Email string
+	Name  string
+}
+
+// Org is an organization
+type Org struct {
+	ID   string
+	Slug string
+	Name string
+	Type string
+}
+
+// App is an application and everything the fake knows about it
+type App struct {
+	Name     string
+	Org      string // organization slug
+	Status   string
+	Deployed bool
+	Hostname string
+	Network  string
+
+	Machines     []*fly.Machine
+	Volumes      []*Volume
+	Secrets      map[string]string
+	Releases     []Release
+	IPAddresses  []IPAddress
+	Certificates []Certificate
+	// ImageTags maps tags pushed to the app's registry repository to their
+	// digests
+	ImageTags map[string]string
+	Logs      []LogEntry
+
+	// leases are the leases held on machines, by machine ID
+	leases map[string]*fly.MachineLease
+	// imageConfigs are the configs of pushed images, by tag; images
+	// without one have DefaultImageConfig
+	imageConfigs map[string]ImageConfig
+	createdAt    time.Time
+}
+
+// Volume is a volume with its snapshots
+type Volume struct {
+	fly.MachineVolume
+	Snapshots []fly.VolumeSnapshot
+}
+
+// ImageConfig is the config of an image pushed to an app's registry
+// repository
+type ImageConfig struct {
+	Platform     string   // e.g. linux/amd64
+	ExposedPorts []string // e.g. 8080/tcp
+	Created      time.Time
+}
+
+// DefaultImageConfig returns the config of images that were not given one:
+// a linux/amd64 image exposing the port DefaultMachineConfig's service uses
+func DefaultImageConfig() ImageConfig {
+	return ImageConfig{Platform: "linux/amd64", ExposedPorts: []string{"8080/tcp"}}
+}
+
+// Release is a release of an app
+type Release struct {
+	ID          string
+	Version     int
+	Status      string
+	Description string
+	Reason      string
+	ImageRef    string
+	Stable      bool
+	CreatedAt   time.Time
+}
+
+// LogEntry is a line an app logged
+type LogEntry struct {
+	Timestamp time.Time
+	Level     string
+	Message   string
+	Instance  string
+	Region    string
+}
+
+// IPAddress is an address allocated to an app
+type IPAddress struct {
+	ID      string
+	Address string
+	Type    string
+	Region  string
+}
+
+// Certificate is a TLS certificate for a custom domain
+type Certificate struct {
+	Hostname              string
+	ClientStatus          string
+	IsApex                bool
+	DNSValidationHostname string
+	DNSValidationTarget   string
+}
+
+// Request is an API request the fake received
+type Request struct {
+	API    string // graphql, machines, prometheus, registry or logs
+	Method string
+	Path   string
+	// Operation is the root field of a GraphQL request, e.g. setSecrets
+	Operation string
+}
+
+// String describes a request, e.g. in test failures
+func (r Request) String() string {
+	if r.API == "graphql" {
+		return "graphql " + r.Operation
+	}
+	return fmt.Sprintf("%s %s", r.Method, r.Path)
+}
+
+// ExecFunc answers a command run in a machine
+type ExecFunc func(appName, machineID string, command []string) fly.ExecResult
+
+// Server is a fake Fly.io API server
+type Server struct {
+	// URL is the base URL the APIs are served on, e.g. http://127.0.0.1:41234
+	URL string
+
+	http *http.Server
+
+	mu       sync.Mutex
+	viewer   User
+	orgs     map[string]*Org
+	apps     map[string]*App
+	requests []Request
+	failures map[string]failure
+	exec     ExecFunc
+	metric   float64
+	nextID   int
+}
+
+// failure is an error injected for a route or GraphQL field
+type failure struct {
+	status  int
+	message string
+}
+
+// Start starts an empty fake on a loopback port. Close stops it.
+func Start() *Server {
+	s := &Server{
+		viewer:   User{ID: "user_1", Email: "dev@example.com", Name: "Dev"},
+		orgs:     make(map[string]*Org),
+		apps:     make(map[string]*App),
+		failures: make(map[string]failure),
+		metric:   42,
+		exec: func(appName, machineID string, command []string) fly.ExecResult {
+			return fly.ExecResult{Stdout: strings.Join(command, " ") + "\n"}
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /graphql", s.handleGraphQL)
+	s.routeMachines(mux)
+	mux.HandleFunc("GET /prometheus/{org}/api/v1/{endpoint}", s.handlePrometheus)
+	mux.HandleFunc("GET /v2/{app}/tags/list", s.handleTags)
+	mux.HandleFunc("GET /v2/{app}/manifests/{tag}", s.handleManifest)
+	mux.HandleFunc("GET /v2/{app}/blobs/{digest}", s.handleBlob)
+	mux.HandleFunc("GET /api/v1/apps/{app}/logs", s.handleLogs)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("fakefly: failed to listen on a loopback port: %v", err))
+	}
+	s.URL = "http://" + listener.Addr().String()
+	s.http = &http.Server{Handler: s.authenticate(mux)}
+	go s.http.Serve(listener)
+	return s
+}
+
+// Close stops the fake and closes its connections
+func (s *Server) Close() {
+	s.http.Close()
+}
+
+// Configure points a Fly.io configuration at the fake
+func (s *Server) Configure(cfg *config.FlyConfig) {
+	cfg.APIToken = Token
+	cfg.APIURL = s.URL
+	cfg.BaseURL = s.URL
+	cfg.PrometheusURL = s.URL + "/prometheus"
+	cfg.RegistryURL = s.URL
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10
+	}
+}
+
+// authenticate rejects requests without the fake's token and records the
+// others
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The registry takes the token as the basic auth password
+		_, password, basic := r.BasicAuth()
+		if !strings.HasSuffix(r.Header.Get("Authorization"), Token) && !(basic && password == Token) {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetViewer sets the user the token belongs to
+func (s *Server) SetViewer(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.viewer = user
+}
+
+// AddOrg adds an organization
+func (s *Server) AddOrg(org Org) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if org.ID == "" {
+		org.ID = s.newIDLocked("org")
+	}
+	if org.Name == "" {
+		org.Name = org.Slug
+	}
+	if org.Type == "" {
+		org.Type = "SHARED"
+	}
+	s.orgs[org.Slug] = &org
+}
+
+// AddApp adds an application. Unset fields get the values Fly.io would
+// give a new app.
+func (s *Server) AddApp(app App) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addAppLocked(&app)
+}
+
+func (s *Server) addAppLocked(app *App) {
+	if app.Status == "" {
+		app.Status = "deployed"
+		app.Deployed = true
+	}
+	if app.Hostname == "" {
+		app.Hostname = app.Name + ".fly.dev"
+	}
+	if app.Network == "" {
+		app.Network = "default"
+	}
+	if app.Secrets == nil {
+		app.Secrets = make(map[string]string)
+	}
+	if app.ImageTags == nil {
+		app.ImageTags = make(map[string]string)
+	}
+	app.createdAt = time.Now().UTC()
+	s.apps[app.Name] = app
+}
+
+// AddMachine adds a machine to an app and returns its ID. Unset fields get
+// defaults: a started machine in ord running the app's image, with a
+// passing health check.
+func (s *Server) AddMachine(appName string, m fly.Machine) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := s.mustAppLocked(appName)
+	now := time.Now().UTC()
+	if m.ID == "" {
+		m.ID = s.newIDLocked("m")
+	}
+	if m.Name == "" {
+		m.Name = m.ID
+	}
+	if m.State == "" {
+		m.State = "started"
+	}
+	if m.Region == "" {
+		m.Region = "ord"
+	}
+	if m.PrivateIP == "" {
+		m.PrivateIP = fmt.Sprintf("fdaa:0:1:a7b:1::%d", s.nextID)
+	}
+	if m.Config == nil {
+		m.Config = DefaultMachineConfig(appName)
+	}
+	if m.ImageRef.Repository == "" {
+		m.ImageRef = imageRef(configString(m.Config, "image"))
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+	m.UpdatedAt = now
+	if m.Checks == nil && m.Config["checks"] != nil {
+		for name := range m.Config["checks"].(map[string]interface{}) {
+			m.Checks = append(m.Checks, fly.MachineCheck{Name: name, Status: "passing", UpdatedAt: now})
+		}
+	}
+	if m.Events == nil {
+		m.Events = []fly.MachineEvent{
+			{ID: s.newIDLocked("ev"), Type: "launch", Status: "created", Source: "user", Timestamp: now.Add(-time.Minute).UnixMilli()},
+			{ID: s.newIDLocked("ev"), Type: "start", Status: "started", Source: "flyd", Timestamp: now.UnixMilli()},
+		}
+	}
+
+	app.Machines = append(app.Machines, &m)
+	return m.ID
+}
+
+// AddVolume adds a volume, with its snapshots, to an app and returns its ID
+func (s *Server) AddVolume(appName string, v fly.MachineVolume, snapshots ...fly.VolumeSnapshot) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := s.mustAppLocked(appName)
+	if v.ID == "" {
+		v.ID = s.newIDLocked("vol")
+	}
+	if v.State == "" {
+		v.State = "created"
+	}
+	if v.Region == "" {
+		v.Region = "ord"
+	}
+	if v.SizeGB == 0 {
+		v.SizeGB = 1
+	}
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now().UTC()
+	}
+	for i := range snapshots {
+		if snapshots[i].ID == "" {
+			snapshots[i].ID = s.newIDLocked("vs")
+		}
+		if snapshots[i].Status == "" {
+			snapshots[i].Status = "created"
+		}
+		if snapshots[i].CreatedAt.IsZero() {
+			snapshots[i].CreatedAt = time.Now().UTC().Add(-time.Duration(len(snapshots)-i) * 24 * time.Hour)
+		}
+	}
+
+	app.Volumes = append(app.Volumes, &Volume{MachineVolume: v, Snapshots: snapshots})
+	return v.ID
+}
+
+// SetSecret sets a secret of an app
+func (s *Server) SetSecret(appName, name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mustAppLocked(appName).Secrets[name] = value
+}
+
+// AddRelease adds a release to an app
+func (s *Server) AddRelease(appName string, r Release) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := s.mustAppLocked(appName)
+	if r.ID == "" {
+		r.ID = s.newIDLocked("rel")
+	}
+	if r.Version == 0 {
+		r.Version = len(app.Releases) + 1
+	}
+	if r.Status == "" {
+		r.Status = "complete"
+	}
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now().UTC()
+	}
+	app.Releases = append(app.Releases, r)
+}
+
+// AddIPAddress allocates an address to an app
+func (s *Server) AddIPAddress(appName string, ip IPAddress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ip.ID == "" {
+		ip.ID = s.newIDLocked("ip")
+	}
+	app := s.mustAppLocked(appName)
+	app.IPAddresses = append(app.IPAddresses, ip)
+}
+
+// AddCertificate adds a certificate for a custom domain to an app
+func (s *Server) AddCertificate(appName string, cert Certificate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := s.mustAppLocked(appName)
+	app.Certificates = append(app.Certificates, cert)
+}
+
+// AddImageTag records an image pushed to an app's registry repository. An
+// empty digest is derived from the image name.
+func (s *Server) AddImageTag(appName, tag, imageDigest string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if imageDigest == "" {
+		imageDigest = Digest("registry.fly.io/" + appName + ":" + tag)
+	}
+	s.mustAppLocked(appName).ImageTags[tag] = imageDigest
+}
+
+// SetImageConfig sets the config of an image pushed with AddImageTag
+func (s *Server) SetImageConfig(appName, tag string, cfg ImageConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := s.mustAppLocked(appName)
+	if app.imageConfigs == nil {
+		app.imageConfigs = make(map[string]ImageConfig)
+	}
+	app.imageConfigs[tag] = cfg
+}
+
+// AddLog adds a log line to an app
+func (s *Server) AddLog(appName string, entry LogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := s.mustAppLocked(appName)
+	app.Logs = append(app.Logs, entry)
+}
+
+// SetCheckStatus sets the status of every health check of a machine, e.g.
+// "critical" to make deploys and health gates fail
+func (s *Server) SetCheckStatus(appName, machineID, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.mustMachineLocked(appName, machineID)
+	for i := range m.Checks {
+		m.Checks[i].Status = status
+		m.Checks[i].UpdatedAt = time.Now().UTC()
+	}
+}
+
+// SetExec sets how commands run in machines are answered
+func (s *Server) SetExec(fn ExecFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exec = fn
+}
+
+// SetMetricValue sets the value every Prometheus query returns
+func (s *Server) SetMetricValue(value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metric = value
+}
+
+// Fail makes requests fail with status and message. key is a route, e.g.
+// "POST /v1/apps/web/machines", or a GraphQL root field, e.g. "setSecrets".
+func (s *Server) Fail(key string, status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[key] = failure{status: status, message: message}
+}
+
+// ClearFailures removes the failures injected with Fail
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.failures)
+}
+
+// App returns a copy of an app's current state
+func (s *Server) App(name string) (App, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.apps[name]
+	if !ok {
+		return App{}, false
+	}
+	cp := *app
+	cp.Machines = make([]*fly.Machine, len(app.Machines))
+	for i, m := range app.Machines {
+		mc := *m
+		mc.Config = cloneConfig(m.Config)
+		cp.Machines[i] = &mc
+	}
+	cp.Secrets = maps.Clone(app.Secrets)
+	cp.ImageTags = maps.Clone(app.ImageTags)
+	cp.Volumes = slices.Clone(app.Volumes)
+	return cp, true
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// Mutations returns the requests received so far that change something
+func (s *Server) Mutations() []Request {
+	var mutations []Request
+	for _, r := range s.Requests() {
+		if r.API == "graphql" {
+			if slices.Contains(graphQLMutations, r.Operation) {
+				mutations = append(mutations, r)
+			}
+			continue
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			mutations = append(mutations, r)
+		}
+	}
+	return mutations
+}
+
+// record notes a request and returns the failure injected for it, if any
+func (s *Server) record(req Request, key string) (failure, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req)
+	f, ok := s.failures[key]
+	return f, ok
+}
+
+// mustAppLocked returns an app that a seeding call names
+func (s *Server) mustAppLocked(name string) *App {
+	app, ok := s.apps[name]
+	if !ok {
+		panic(fmt.Sprintf("fakefly: unknown app %s", name))
+	}
+	return app
+}
+
+func (s *Server) mustMachineLocked(appName, machineID string) *fly.Machine {
+	for _, m := range s.mustAppLocked(appName).Machines {
+		if m.ID == machineID {
+			return m
+		}
+	}
+	panic(fmt.Sprintf("fakefly: unknown machine %s of app %s", machineID, appName))
+}
+
+// newIDLocked returns a new ID with a prefix
+func (s *Server) newIDLocked(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s_%04d", prefix, s.nextID)
+}
+
+// DefaultMachineConfig returns the config of a typical web machine: one
+// HTTP service on port 8080 with autostop, a health check and a guest of
+// the smallest size
+func DefaultMachineConfig(appName string) map[string]interface{} {
+	return map[string]interface{}{
+		"image": "registry.fly.io/" + appName + ":deployment-01",
+		"env":   map[string]interface{}{"PORT": "8080"},
+		"guest": map[string]interface{}{
+			"cpu_kind":  "shared",
+			"cpus":      float64(1),
+			"memory_mb": float64(256),
+		},
+		"services": []interface{}{
+			map[string]interface{}{
+				"protocol":             "tcp",
+				"internal_port":        float64(8080),
+				"autostop":             "stop",
+				"autostart":            true,
+				"min_machines_running": float64(0),
+				"ports": []interface{}{
+					map[string]interface{}{"port": float64(80), "handlers": []interface{}{"http"}, "force_https": true},
+					map[string]interface{}{"port": float64(443), "handlers": []interface{}{"tls", "http"}},
+				},
+			},
+		},
+		"checks": map[string]interface{}{
+			"health": map[string]interface{}{
+				"type":     "http",
+				"port":     float64(8080),
+				"method":   "GET",
+				"path":     "/health",
+				"interval": "15s",
+				"timeout":  "2s",
+			},
+		},
+		"metadata": map[string]interface{}{
+			"fly_platform_version": "v2",
+			"fly_process_group":    "app",
+		},
+		"restart": map[string]interface{}{"policy": "on-failure"},
+	}
+}
+
+// imageRef splits an image name as the Machines API reports it
+func imageRef(image string) fly.ImageRef {
+	ref := fly.ImageRef{Digest: Digest(image)}
+	if i := strings.Index(image, "/"); i > 0 && strings.ContainsAny(image[:i], ".:") {
+		ref.Registry, image = image[:i], image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > 0 {
+		image, ref.Tag = image[:i], image[i+1:]
+	}
+	ref.Repository = image
+	return ref
+}
+
+// Digest returns a stable fake digest for a value, e.g. an image reference
+func Digest(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// configString returns a string field of a machine config
+func configString(machineConfig map[string]interface{}, key string) string {
+	value, _ := machineConfig[key].(string)
+	return value
+}
+
+// cloneConfig deep-copies a machine config through JSON, as the API would
+func cloneConfig(machineConfig map[string]interface{}) map[string]interface{} {
+	if machineConfig == nil {
+		return nil
+	}
+	data, _ := json.Marshal(machineConfig)
+	var cp map[string]interface{}
+	json.Unmarshal(data, &cp)
+	return cp
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error as the Machines API does
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
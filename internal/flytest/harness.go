@@ -0,0 +1,599 @@
+package flytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/fakefly"
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/mcp"
+	"github.com/brannn/fly-mcp/pkg/session"
+	"github.com/brannn/fly-mcp/pkg/tools"
+)
+
+// Harness drives tools through the MCP handler against a fake backend
+type Harness struct {
+	Server  *Server
+	Handler *mcp.Handler
+	Config  *config.Config
+
+	session string
+	nextID  atomic.Int64
+}
+
+// NewHarness starts a fake backend, seeds it with Seed and creates an MCP
+// handler talking to it. The caller may do anything; rate limits are off and
+// fly_ssh_exec may run any command. configure, if given, adjusts the
+// configuration before the handler is created.
+func NewHarness(t testing.TB, configure ...func(*config.Config)) *Harness {
+	t.Helper()
+
+	cfg, err := config.Defaults()
+	if err != nil {
+		t.Fatalf("failed to load default configuration: %v", err)
+	}
+
+	s := NewServer(t)
+	Seed(s)
+	s.Configure(&cfg.Fly)
+	cfg.Fly.Organization = SeedOrg
+	cfg.Security.RateLimitEnabled = false
+	cfg.Security.Permissions = map[string][]string{"default": {"*"}}
+	cfg.Security.ExecAllowedCommands = []string{"*"}
+	cfg.Logging.Level = "error"
+	cfg.Logging.Output = "stderr"
+
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	handler, err := mcp.NewHandler(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create MCP handler: %v", err)
+	}
+
+	h := &Harness{Server: s, Handler: handler, Config: cfg}
+	h.initialize(t)
+	return h
+}
+
+// initialize opens the MCP session the harness's calls run in
+func (h *Harness) initialize(t testing.TB) {
+	t.Helper()
+
+	rec := h.post(t, "initialize", map[string]interface{}{
+		"protocolVersion": h.Config.MCP.Version,
+		"clientInfo":      map[string]interface{}{"name": "flytest", "version": "0.1.0"},
+	})
+	h.session = rec.Header().Get(session.HeaderName)
+}
+
+// CallTool calls a tool and returns its result. JSON-RPC errors, such as an
+// unknown tool, fail the test.
+func (h *Harness) CallTool(t testing.TB, name string, args map[string]interface{}) *interfaces.ToolResult {
+	t.Helper()
+
+	// The handler consumes the arguments it is given
+	params := map[string]interface{}{"name": name, "arguments": cloneArgs(args)}
+	rec := h.post(t, "tools/call", params)
+
+	var resp struct {
+		Result *interfaces.ToolResult `json:"result"`
+		Error  *mcp.MCPError          `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: failed to decode response: %v", name, err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("%s: %s (%v)", name, resp.Error.Message, resp.Error.Data)
+	}
+	if resp.Result == nil {
+		t.Fatalf("%s: response has no result", name)
+	}
+	return resp.Result
+}
+
+// CallConfirmed calls a tool that asks for confirmation, then approves the
+// preview by calling it again with the token and extra, e.g. the
+// confirm_name fly_app_delete wants
+func (h *Harness) CallConfirmed(t testing.TB, name string, args, extra map[string]interface{}) *interfaces.ToolResult {
+	t.Helper()
+
+	preview := h.CallTool(t, name, args)
+	if !tools.IsConfirmationPreview(preview) {
+		t.Fatalf("%s: expected a confirmation preview, got: %s", name, Text(preview))
+	}
+
+	confirmed := cloneArgs(args)
+	for k, v := range extra {
+		confirmed[k] = v
+	}
+	confirmed["confirmation_token"] = preview.StructuredContent.(map[string]interface{})["confirmation_token"]
+	return h.CallTool(t, name, confirmed)
+}
+
+// post sends a JSON-RPC request to the handler
+func (h *Harness) post(t testing.TB, method string, params interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(mcp.MCPRequest{
+		JSONRPC: "2.0",
+		ID:      h.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode %s request: %v", method, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if h.session != "" {
+		req.Header.Set(session.HeaderName, h.session)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := h.Handler.HandleRequest(rec, req); err != nil {
+		t.Fatalf("%s failed: %v", method, err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: unexpected status %d: %s", method, rec.Code, rec.Body.String())
+	}
+	return rec
+}
+
+// Text returns the text content of a result
+func Text(result *interfaces.ToolResult) string {
+	var parts []string
+	for _, block := range result.Content {
+		if block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func cloneArgs(args map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		cp[k] = v
+	}
+	return cp
+}
+
+// ToolCase is one tool call checked end to end
+type ToolCase struct {
+	Name string
+	Tool string
+	Args map[string]interface{}
+	// Setup prepares the fake before the call, e.g. injecting failures
+	Setup func(s *Server)
+	// Configure adjusts the configuration of the case's handler, after the
+	// configure functions passed to Run
+	Configure func(cfg *config.Config)
+	// Confirm approves the confirmation preview and checks the confirmed
+	// call instead; ConfirmArgs are added to the confirmed call
+	Confirm     bool
+	ConfirmArgs map[string]interface{}
+	// WantError expects an error result
+	WantError bool
+	// Contains lists text the result must include
+	Contains []string
+	// Check inspects the result and the fake's state further
+	Check func(t testing.TB, h *Harness, result *interfaces.ToolResult)
+}
+
+// Run runs each case as a subtest against its own harness, so cases do not
+// see each other's changes
+func Run(t *testing.T, cases []ToolCase, configure ...func(*config.Config)) {
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			h := NewHarness(t, caseConfig(configure, tc.Configure)...)
+			if tc.Setup != nil {
+				tc.Setup(h.Server)
+			}
+
+			var result *interfaces.ToolResult
+			if tc.Confirm {
+				result = h.CallConfirmed(t, tc.Tool, tc.Args, tc.ConfirmArgs)
+			} else {
+				result = h.CallTool(t, tc.Tool, tc.Args)
+			}
+
+			text := Text(result)
+			if result.IsError != tc.WantError {
+				t.Fatalf("IsError = %v, want %v: %s", result.IsError, tc.WantError, text)
+			}
+			for _, want := range tc.Contains {
+				if !strings.Contains(text, want) {
+					t.Errorf("result does not contain %q: %s", want, text)
+				}
+			}
+			if tc.Check != nil {
+				tc.Check(t, h, result)
+			}
+		})
+	}
+}
+
+// caseConfig appends a case's configure function, if any, to Run's
+func caseConfig(configure []func(*config.Config), extra func(*config.Config)) []func(*config.Config) {
+	if extra == nil {
+		return configure
+	}
+	return append(slices.Clone(configure), extra)
+}
+
+// freezeChanges adds a policy rule freezing every change for the next hour
+func freezeChanges(cfg *config.Config) {
+	now := time.Now().UTC()
+	cfg.Security.Policies = append(cfg.Security.Policies, config.PolicyRule{
+		Name:    "test-freeze",
+		Effect:  "deny",
+		Actions: []string{"mutating"},
+		Freezes: []config.ChangeFreeze{{
+			Start:  now.Add(-time.Hour).Format(time.RFC3339),
+			End:    now.Add(time.Hour).Format(time.RFC3339),
+			Reason: "test freeze",
+		}},
+	})
+}
+
+// The fixture Seed creates
+const (
+	SeedOrg       = "test-org"
+	SeedApp       = "web"
+	SeedOtherApp  = "api"
+	SeedMachine   = "m_web_1"
+	SeedMachine2  = "m_web_2"
+	SeedVolume    = "vol_web_data"
+	SeedSnapshot  = "vs_web_data_1"
+	SeedImage     = "registry.fly.io/web:deployment-01"
+	SeedImageTag  = "deployment-02"
+	SeedNewImage  = "registry.fly.io/web:deployment-02"
+	SeedSecretKey = "DATABASE_URL"
+)
+
+// Seed fills a fake with a small organization: a web app with two machines
+// in two regions, a volume with snapshots, secrets, releases, addresses, a
+// certificate, images and logs, and an api app with one machine
+func Seed(s *Server) {
+	now := time.Now().UTC()
+
+	s.AddOrg(Org{ID: "org_test", Slug: SeedOrg, Name: "Test Org"})
+
+	s.AddApp(App{Name: SeedApp, Org: SeedOrg})
+	s.AddMachine(SeedApp, fly.Machine{ID: SeedMachine, Region: "ord"})
+	s.AddMachine(SeedApp, fly.Machine{ID: SeedMachine2, Region: "ams"})
+	s.AddVolume(SeedApp, fly.MachineVolume{ID: SeedVolume, Name: "data", SizeGB: 10, AttachedMachineID: SeedMachine},
+		fly.VolumeSnapshot{ID: SeedSnapshot, Size: 1 << 30, Digest: fakefly.Digest("snapshot-1")},
+		fly.VolumeSnapshot{Size: 1 << 30, Digest: fakefly.Digest("snapshot-2")},
+	)
+	s.SetSecret(SeedApp, SeedSecretKey, "postgres://db.internal/web")
+	s.AddRelease(SeedApp, Release{Description: "Deploy image", Reason: "deploy", ImageRef: SeedImage, Stable: true, CreatedAt: now.Add(-48 * time.Hour)})
+	s.AddRelease(SeedApp, Release{Description: "Deploy image", Reason: "deploy", ImageRef: SeedImage, Stable: true, CreatedAt: now.Add(-time.Hour)})
+	s.AddIPAddress(SeedApp, IPAddress{Address: "66.241.124.10", Type: "shared_v4"})
+	s.AddIPAddress(SeedApp, IPAddress{Address: "2a09:8280:1::10", Type: "v6", Region: "global"})
+	s.AddCertificate(SeedApp, Certificate{Hostname: "www.example.com", ClientStatus: "Ready"})
+	s.AddImageTag(SeedApp, "deployment-01", fakefly.Digest(SeedImage))
+	s.AddImageTag(SeedApp, SeedImageTag, fakefly.Digest(SeedNewImage))
+	s.AddLog(SeedApp, LogEntry{Timestamp: now.Add(-time.Minute), Level: "info", Message: "Listening on 0.0.0.0:8080", Instance: SeedMachine, Region: "ord"})
+	s.AddLog(SeedApp, LogEntry{Timestamp: now, Level: "error", Message: "GET /broken 500", Instance: SeedMachine2, Region: "ams"})
+
+	s.AddApp(App{Name: SeedOtherApp, Org: SeedOrg})
+	s.AddMachine(SeedOtherApp, fly.Machine{Region: "ord"})
+	s.SetSecret(SeedOtherApp, SeedSecretKey, "postgres://db.internal/api")
+}
+
+// DefaultCases calls every built-in tool against the Seed fixture, including
+// the confirmed path of each mutating one
+func DefaultCases() []ToolCase {
+	return []ToolCase{
+		{Name: "ping", Tool: "ping", Args: map[string]interface{}{"message": "hi"}, Contains: []string{"hi"}},
+		{Name: "whoami", Tool: "fly_whoami", Contains: []string{"dev@example.com"}},
+		{Name: "list apps", Tool: "fly_list_apps", Contains: []string{SeedApp, SeedOtherApp}},
+		{Name: "list apps with details", Tool: "fly_list_apps", Args: map[string]interface{}{"include_details": true, "organization": SeedOrg}, Contains: []string{SeedApp}},
+		{Name: "app info", Tool: "fly_app_info", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{SeedApp, "## Current Release", "## Services"}},
+		{Name: "app info of unknown app", Tool: "fly_app_info", Args: map[string]interface{}{"app_name": "missing"}, WantError: true},
+		{
+			Name: "checks when the Machines API is down", Tool: "fly_checks", WantError: true,
+			Args: map[string]interface{}{"app_name": SeedApp},
+			Setup: func(s *Server) {
+				s.Fail("GET /v1/apps/"+SeedApp+"/machines", http.StatusServiceUnavailable, "service unavailable")
+			},
+		},
+		{Name: "status", Tool: "fly_status", Args: map[string]interface{}{"app_name": SeedApp, "detailed": true}, Contains: []string{"Total Machines**: 2"}},
+		{Name: "checks", Tool: "fly_checks", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{"health"}},
+		{Name: "machine events", Tool: "fly_machine_events", Args: map[string]interface{}{"app_name": SeedApp, "machine_id": SeedMachine}, Contains: []string{"start"}},
+		{Name: "metrics", Tool: "fly_metrics", Args: map[string]interface{}{"app_name": SeedApp, "organization": SeedOrg, "metrics": []interface{}{"cpu"}}},
+		{Name: "costs", Tool: "fly_costs", Args: map[string]interface{}{"app_name": SeedApp}},
+		{Name: "scale status", Tool: "fly_scale", Args: map[string]interface{}{"app_name": SeedApp}},
+		{Name: "scale recommendation", Tool: "fly_scale", Args: map[string]interface{}{"app_name": SeedApp, "action": "recommend", "target_count": 3}},
+		{Name: "scale sizing", Tool: "fly_scale", Args: map[string]interface{}{"app_name": SeedApp, "action": "recommend", "range": "24h"}, Contains: []string{"Sizing Recommendations"}},
+		{
+			Name: "scale apply", Tool: "fly_scale", Confirm: true,
+			Args: map[string]interface{}{"app_name": SeedApp, "action": "apply", "target_count": 3, "health_timeout_seconds": 10},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				if app, _ := h.Server.App(SeedApp); len(app.Machines) != 3 {
+					t.Errorf("machines = %d, want 3", len(app.Machines))
+				}
+			},
+		},
+		{
+			Name: "scale in", Tool: "fly_scale", Confirm: true,
+			Args:     map[string]interface{}{"app_name": SeedApp, "action": "apply", "target_count": 1},
+			Contains: []string{"Journaled as", "fly_undo"},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				if app, _ := h.Server.App(SeedApp); len(app.Machines) != 1 {
+					t.Errorf("machines = %d, want 1", len(app.Machines))
+				}
+			},
+		},
+		{
+			Name: "machine clone", Tool: "fly_machine_clone", Confirm: true,
+			Args: map[string]interface{}{"app_name": SeedApp, "machine_id": SeedMachine2, "region": "syd", "health_timeout_seconds": 10},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedApp)
+				for _, m := range app.Machines {
+					if m.Region == "syd" {
+						return
+					}
+				}
+				t.Errorf("no machine was created in syd")
+			},
+		},
+		{Name: "machine metadata", Tool: "fly_machine_metadata", Args: map[string]interface{}{"app_name": SeedApp, "machine_id": SeedMachine}, Contains: []string{"No lease is held"}},
+		{
+			Name: "machine metadata for a viewer", Tool: "fly_machine_metadata",
+			Configure: func(cfg *config.Config) { cfg.Security.Permissions = map[string][]string{"default": {"read:*"}} },
+			Args:      map[string]interface{}{"app_name": SeedApp, "machine_id": SeedMachine},
+			Contains:  []string{"No lease is held"},
+		},
+		{
+			Name: "machine metadata set", Tool: "fly_machine_metadata",
+			Args: map[string]interface{}{"app_name": SeedApp, "machine_id": SeedMachine, "action": "set", "key": "owner", "value": "platform"},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedApp)
+				for _, m := range app.Machines {
+					if m.ID == SeedMachine && m.Config["metadata"].(map[string]interface{})["owner"] != "platform" {
+						t.Errorf("metadata = %v", m.Config["metadata"])
+					}
+				}
+			},
+		},
+		{Name: "machine lease acquire", Tool: "fly_machine_metadata", Args: map[string]interface{}{"app_name": SeedApp, "machine_id": SeedMachine, "action": "acquire_lease", "ttl_seconds": 60}, Contains: []string{"Lease Acquired"}},
+		{
+			Name: "machine lease held by a deploy", Tool: "fly_machine_metadata", WantError: true,
+			Args: map[string]interface{}{"app_name": SeedApp, "machine_id": SeedMachine, "action": "acquire_lease"},
+			Setup: func(s *Server) {
+				s.Lease(SeedApp, SeedMachine, "ci@example.com", time.Minute)
+			},
+		},
+		{Name: "autoscale status", Tool: "fly_autoscale", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{"stop"}},
+		{
+			Name: "autoscale update", Tool: "fly_autoscale", Confirm: true,
+			Args: map[string]interface{}{"app_name": SeedApp, "action": "update", "auto_stop": "off"},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedApp)
+				for _, m := range app.Machines {
+					service := m.Config["services"].([]interface{})[0].(map[string]interface{})
+					if service["autostop"] != "off" {
+						t.Errorf("machine %s autostop = %v, want off", m.ID, service["autostop"])
+					}
+				}
+			},
+		},
+		{Name: "env list", Tool: "fly_env", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{"PORT"}},
+		{Name: "env list in a freeze", Tool: "fly_env", Configure: freezeChanges, Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{"PORT"}},
+		{
+			Name: "env set in a freeze", Tool: "fly_env", Configure: freezeChanges, WantError: true,
+			Args:     map[string]interface{}{"app_name": SeedApp, "action": "set", "env": map[string]interface{}{"LOG_LEVEL": "debug"}},
+			Contains: []string{"Policy denied", "test-freeze"},
+		},
+		{
+			Name: "env set", Tool: "fly_env", Confirm: true,
+			Args: map[string]interface{}{"app_name": SeedApp, "action": "set", "env": map[string]interface{}{"LOG_LEVEL": "debug"}},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedApp)
+				for _, m := range app.Machines {
+					if env := m.Config["env"].(map[string]interface{}); env["LOG_LEVEL"] != "debug" {
+						t.Errorf("machine %s env = %v, want LOG_LEVEL=debug", m.ID, env)
+					}
+				}
+			},
+		},
+		{
+			Name: "env unset", Tool: "fly_env", Confirm: true,
+			Args: map[string]interface{}{"app_name": SeedApp, "action": "unset", "names": []interface{}{"PORT"}},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedApp)
+				for _, m := range app.Machines {
+					if _, ok := m.Config["env"].(map[string]interface{})["PORT"]; ok {
+						t.Errorf("machine %s still has PORT", m.ID)
+					}
+				}
+			},
+		},
+		{Name: "snapshots list", Tool: "fly_snapshots", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{SeedSnapshot}},
+		{
+			Name: "snapshot restore", Tool: "fly_snapshots", Confirm: true,
+			Args: map[string]interface{}{"app_name": SeedApp, "action": "restore", "volume_id": SeedVolume, "snapshot_id": SeedSnapshot},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				if app, _ := h.Server.App(SeedApp); len(app.Volumes) != 2 {
+					t.Errorf("volumes = %d, want 2", len(app.Volumes))
+				}
+			},
+		},
+		{Name: "scheduled tasks list", Tool: "fly_scheduled_tasks", Args: map[string]interface{}{"app_name": SeedApp}},
+		{
+			Name: "scheduled task create", Tool: "fly_scheduled_tasks",
+			Args: map[string]interface{}{"app_name": SeedApp, "action": "create", "name": "nightly-cleanup", "schedule": "daily", "command": []interface{}{"bin/cleanup"}},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedApp)
+				for _, m := range app.Machines {
+					if m.Name == "nightly-cleanup" && m.Config["schedule"] == "daily" {
+						return
+					}
+				}
+				t.Errorf("no daily machine named nightly-cleanup")
+			},
+		},
+		{
+			Name: "restart", Tool: "fly_restart", Confirm: true,
+			Args: map[string]interface{}{"app_name": SeedOtherApp},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				if !hasRequest(h.Server.Mutations(), "POST", "/stop") || !hasRequest(h.Server.Mutations(), "POST", "/start") {
+					t.Errorf("restart did not stop and start the machine: %v", h.Server.Mutations())
+				}
+			},
+		},
+		{
+			Name: "suspend", Tool: "fly_suspend", Confirm: true,
+			Args: map[string]interface{}{"app_name": SeedOtherApp, "resume_after_minutes": 60},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedOtherApp)
+				for _, m := range app.Machines {
+					if m.State != "stopped" {
+						t.Errorf("machine %s is %s, want stopped", m.ID, m.State)
+					}
+					if metadata, _ := m.Config["metadata"].(map[string]interface{}); metadata["fly_mcp_suspension"] == nil {
+						t.Errorf("machine %s does not record the suspension: %v", m.ID, m.Config["metadata"])
+					}
+				}
+			},
+		},
+		{
+			Name: "resume", Tool: "fly_resume",
+			Args: map[string]interface{}{"app_name": SeedOtherApp},
+			Setup: func(s *Server) {
+				s.AddMachine(SeedOtherApp, fly.Machine{ID: "m_api_stopped", State: "stopped"})
+			},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedOtherApp)
+				for _, m := range app.Machines {
+					if m.State != "started" {
+						t.Errorf("machine %s is %s, want started", m.ID, m.State)
+					}
+				}
+			},
+		},
+		{
+			Name: "canary rolled back by failing checks", Tool: "fly_deploy", Confirm: true, WantError: true,
+			Args: map[string]interface{}{"app_name": SeedApp, "image": SeedNewImage, "strategy": "canary", "health_timeout_seconds": 10},
+			Setup: func(s *Server) {
+				s.SetCheckStatus(SeedApp, SeedMachine, "critical")
+			},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedApp)
+				for _, m := range app.Machines {
+					if image, _ := m.Config["image"].(string); image == SeedNewImage {
+						t.Errorf("machine %s still runs %s", m.ID, image)
+					}
+				}
+			},
+		},
+		{
+			Name: "deploy", Tool: "fly_deploy", Confirm: true,
+			Args: map[string]interface{}{"app_name": SeedOtherApp, "image": "registry.fly.io/api:deployment-02", "strategy": "immediate"},
+			Setup: func(s *Server) {
+				s.AddImageTag(SeedOtherApp, "deployment-02", "")
+			},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				app, _ := h.Server.App(SeedOtherApp)
+				for _, m := range app.Machines {
+					if image, _ := m.Config["image"].(string); image != "registry.fly.io/api:deployment-02" {
+						t.Errorf("machine %s runs %s", m.ID, image)
+					}
+				}
+			},
+		},
+		{Name: "deploy of a missing image", Tool: "fly_deploy", Args: map[string]interface{}{"app_name": SeedApp, "image": "registry.fly.io/web:deployment-99"}, WantError: true},
+		{
+			Name: "deploy preview checks the image", Tool: "fly_deploy",
+			Args: map[string]interface{}{"app_name": SeedApp, "image": SeedNewImage},
+			Setup: func(s *Server) {
+				s.SetImageConfig(SeedApp, SeedImageTag, ImageConfig{Platform: "linux/arm64", ExposedPorts: []string{"3000/tcp"}})
+			},
+			Contains: []string{"linux/arm64", "port 8080"},
+		},
+		{Name: "ssh exec", Tool: "fly_ssh_exec", Args: map[string]interface{}{"app_name": SeedApp, "command": []interface{}{"uptime"}}, Contains: []string{"uptime"}},
+		{Name: "images", Tool: "fly_images", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{SeedImageTag}},
+		{Name: "network", Tool: "fly_network", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{"default"}},
+		{Name: "dns", Tool: "fly_dns", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{"www.example.com"}},
+		{Name: "proxy check", Tool: "fly_proxy_check", Args: map[string]interface{}{"app_name": SeedApp, "timeout_seconds": 1, "regions": []interface{}{"ord"}}},
+		{Name: "doctor", Tool: "fly_doctor", Args: map[string]interface{}{"app_name": SeedApp}},
+		{Name: "crashes", Tool: "fly_crashes", Args: map[string]interface{}{"app_name": SeedApp, "range": "1h"}, Contains: []string{"Crashes: " + SeedApp}},
+		{Name: "wait", Tool: "fly_wait", Args: map[string]interface{}{"app_name": SeedApp, "condition": "machine_state", "machine_id": SeedMachine, "state": "started", "timeout_seconds": 1}, Contains: []string{"machine_state met"}},
+		{Name: "undo history", Tool: "fly_undo", Args: map[string]interface{}{"app_name": SeedApp, "action": "history"}, Contains: []string{"Change Journal: " + SeedApp}},
+		{Name: "undo nothing", Tool: "fly_undo", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{"Nothing to undo"}},
+		{Name: "alerts", Tool: "fly_alerts", Args: map[string]interface{}{}, Contains: []string{"No alert rules are configured"}},
+		{Name: "logs", Tool: "fly_logs", Args: map[string]interface{}{"app_name": SeedApp, "level": "error"}, Contains: []string{"GET /broken 500"}},
+		{Name: "logs pattern", Tool: "fly_logs", Args: map[string]interface{}{"app_name": SeedApp, "pattern": `^GET /\w+ 5\d\d$`}, Contains: []string{"GET /broken 500"}},
+		{Name: "logs search", Tool: "fly_logs_search", Args: map[string]interface{}{"app_name": SeedApp, "since": "1h", "search": "broken", "context": 1}, Contains: []string{"> ", "Listening on"}},
+		{Name: "diff apps", Tool: "fly_diff_apps", Args: map[string]interface{}{"app_name": SeedApp, "other_app": SeedOtherApp}},
+		{Name: "drift", Tool: "fly_drift", Args: map[string]interface{}{"app_name": SeedApp, "fly_toml": "app = \"web\"\n[env]\nPORT = \"8080\"\n[http_service]\ninternal_port = 8080\nforce_https = true\nauto_stop_machines = \"stop\"\nauto_start_machines = true\n[checks.health]\ntype = \"http\"\nport = 8080\nmethod = \"GET\"\npath = \"/health\"\ninterval = \"15s\"\ntimeout = \"2s\"\n[[vm]]\nsize = \"shared-cpu-1x\"\n"}, Contains: []string{"No drift"}},
+		{
+			Name: "drift from a machine spec", Tool: "fly_drift",
+			Args:     map[string]interface{}{"app_name": SeedApp, "machine_spec": map[string]interface{}{"guest": map[string]interface{}{"memory_mb": float64(512)}}},
+			Contains: []string{"guest.memory_mb", "`512`", "`256`"},
+		},
+		{Name: "search", Tool: "fly_search", Args: map[string]interface{}{"query": SeedImage}, Contains: []string{SeedApp, "2 machines"}},
+		{Name: "fleet status", Tool: "fly_fleet_status", Args: map[string]interface{}{}, Contains: []string{"Fleet Status", SeedApp}},
+		{Name: "export inventory", Tool: "fly_export_inventory", Args: map[string]interface{}{"export_format": "csv"}, Contains: []string{"machines.csv"}},
+		{Name: "topology", Tool: "fly_topology", Args: map[string]interface{}{"app_name": SeedApp}, Contains: []string{SeedApp}},
+		{Name: "config generate", Tool: "fly_config_generate", Args: map[string]interface{}{"app_name": "new-app", "image": "nginx:latest", "primary_region": "ord"}, Contains: []string{"new-app"}},
+		{Name: "config validate", Tool: "fly_config_validate", Args: map[string]interface{}{"content": "app = \"web\"\nprimary_region = \"ord\"\n"}},
+		{Name: "session", Tool: "fly_session", Args: map[string]interface{}{"action": "set", "app_name": SeedApp}, Contains: []string{SeedApp}},
+		{
+			Name: "batch secret set", Tool: "fly_batch", Confirm: true,
+			Args: map[string]interface{}{"operation": "secret_set", "apps": []interface{}{SeedApp, SeedOtherApp}, "secrets": map[string]interface{}{"FEATURE_FLAG": "on"}},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				for _, name := range []string{SeedApp, SeedOtherApp} {
+					if app, _ := h.Server.App(name); app.Secrets["FEATURE_FLAG"] != "on" {
+						t.Errorf("%s: FEATURE_FLAG not set", name)
+					}
+				}
+			},
+		},
+		{Name: "batch status", Tool: "fly_batch", Args: map[string]interface{}{"operation": "status", "pattern": "*"}, Contains: []string{SeedApp, SeedOtherApp}},
+		{
+			Name: "app create", Tool: "fly_app_create",
+			Args: map[string]interface{}{"app_name": "new-app", "organization": SeedOrg, "region": "ord"},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				if _, ok := h.Server.App("new-app"); !ok {
+					t.Errorf("new-app was not created")
+				}
+			},
+		},
+		{
+			Name: "app delete", Tool: "fly_app_delete", Confirm: true,
+			Args:        map[string]interface{}{"app_name": SeedOtherApp},
+			ConfirmArgs: map[string]interface{}{"confirm_name": SeedOtherApp},
+			Check: func(t testing.TB, h *Harness, _ *interfaces.ToolResult) {
+				if _, ok := h.Server.App(SeedOtherApp); ok {
+					t.Errorf("%s was not deleted", SeedOtherApp)
+				}
+			},
+		},
+	}
+}
+
+// hasRequest reports whether a request with a method and path suffix was
+// received
+func hasRequest(requests []Request, method, suffix string) bool {
+	for _, r := range requests {
+		if r.Method == method && strings.HasSuffix(r.Path, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+package flytest
+
+import "testing"
+
+func TestDefaultCases(t *testing.T) {
+	Run(t, DefaultCases())
+}
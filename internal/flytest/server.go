@@ -0,0 +1,38 @@
+// Package flytest exercises tools end to end through the MCP handler
+// against fakefly, the in-memory stand-in for the Fly.io APIs, without a
+// Fly.io account. It is only linked into tests.
+package flytest
+
+import (
+	"testing"
+
+	"github.com/brannn/fly-mcp/internal/fakefly"
+)
+
+// Token is the API token the fake accepts
+const Token = fakefly.Token
+
+// The fake's types, so tests can seed and inspect it through this package
+type (
+	Server      = fakefly.Server
+	User        = fakefly.User
+	Org         = fakefly.Org
+	App         = fakefly.App
+	Volume      = fakefly.Volume
+	ImageConfig = fakefly.ImageConfig
+	Release     = fakefly.Release
+	LogEntry    = fakefly.LogEntry
+	IPAddress   = fakefly.IPAddress
+	Certificate = fakefly.Certificate
+	Request     = fakefly.Request
+	ExecFunc    = fakefly.ExecFunc
+)
+
+// NewServer starts an empty fake, closed when the test ends
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := fakefly.Start()
+	t.Cleanup(s.Close)
+	return s
+}
@@ -51,15 +51,25 @@ func New(cfg config.LoggingConfig) (*Logger, error) {
 			TimeFormat: time.RFC3339,
 			NoColor:    cfg.Output != "stdout" && cfg.Output != "stderr",
 		}
-		logger = zerolog.New(output).With().Timestamp().Logger()
-	} else {
-		// JSON format for production
-		logger = zerolog.New(output).With().Timestamp().Logger()
 	}
 	
+	// Mask sensitive fields before anything is formatted or written
+	output = &redactWriter{out: output, redactor: NewRedactor(cfg.RedactKeys)}
+	logger = zerolog.New(output).With().Timestamp().Logger()
+	
 	return &Logger{Logger: &logger}, nil
 }
 
+// SetLevel changes the global log level at runtime
+func SetLevel(level string) error {
+	parsed, err := parseLogLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	zerolog.SetGlobalLevel(parsed)
+	return nil
+}
+
 // parseLogLevel converts string log level to zerolog.Level
 func parseLogLevel(level string) (zerolog.Level, error) {
 	switch strings.ToLower(level) {
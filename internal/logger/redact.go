@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces sensitive values in log output
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are the field names always masked in log output
+var DefaultRedactKeys = []string{
+	"api_token",
+	"token",
+	"access_token",
+	"authorization",
+	"password",
+	"secret",
+	"secrets",
+	"private_key",
+}
+
+// Credentials embedded in free-form strings: bearer credentials, Fly.io API
+// tokens, which carry an fo1_/fm1_/fm2_ style prefix, and GitHub tokens
+var (
+	bearerPattern      = regexp.MustCompile(`(?i)\b(bearer)\s+[\w\-.~+/=]+`)
+	flyTokenPattern    = regexp.MustCompile(`\b(fo1|fm1[ar]?|fm2)_[\w\-.~+/=,]+`)
+	githubTokenPattern = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_\w{20,})`)
+)
+
+// Redactor masks sensitive fields in structured log entries
+type Redactor struct {
+	keys map[string]bool
+}
+
+// NewRedactor creates a redactor for the default keys plus any extra keys.
+// Keys are matched case-insensitively, with "-" treated as "_", against the
+// full field name or its last "_"-separated suffix (so "fly_api_token"
+// matches "api_token").
+func NewRedactor(extra []string) *Redactor {
+	r := &Redactor{keys: make(map[string]bool)}
+	for _, key := range append(append([]string{}, DefaultRedactKeys...), extra...) {
+		if key = normalizeKey(key); key != "" {
+			r.keys[key] = true
+		}
+	}
+	return r
+}
+
+// IsSensitive reports whether a field with this name should be masked
+func (r *Redactor) IsSensitive(key string) bool {
+	key = normalizeKey(key)
+	if r.keys[key] {
+		return true
+	}
+	for k := range r.keys {
+		if strings.HasSuffix(key, "_"+k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of value with sensitive fields masked. Maps and
+// slices are walked recursively; strings have embedded credentials removed.
+func (r *Redactor) Redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			if r.IsSensitive(key) {
+				out[key] = RedactedValue
+			} else {
+				out[key] = r.Redact(val)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = r.Redact(val)
+		}
+		return out
+	case string:
+		return r.RedactString(v)
+	}
+	return value
+}
+
+// RedactString masks bearer tokens, Fly.io API tokens and GitHub tokens in a
+// string
+func (r *Redactor) RedactString(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "$1 "+RedactedValue)
+	s = flyTokenPattern.ReplaceAllString(s, RedactedValue)
+	return githubTokenPattern.ReplaceAllString(s, RedactedValue)
+}
+
+// mightContainSecret is a cheap pre-check so entries without any sensitive
+// key or token prefix are written untouched
+func (r *Redactor) mightContainSecret(line []byte) bool {
+	lower := bytes.ToLower(line)
+	for key := range r.keys {
+		if bytes.Contains(lower, []byte(key)) {
+			return true
+		}
+	}
+	return bytes.Contains(lower, []byte("bearer")) ||
+		bytes.Contains(lower, []byte("fo1_")) ||
+		bytes.Contains(lower, []byte("fm1")) ||
+		bytes.Contains(lower, []byte("fm2_")) ||
+		githubTokenPattern.Match(line)
+}
+
+// redactWriter sits between zerolog and the real output, masking each JSON
+// log entry before it is formatted or written
+type redactWriter struct {
+	out      io.Writer
+	redactor *Redactor
+}
+
+// Write implements io.Writer. zerolog writes exactly one entry per call.
+func (w *redactWriter) Write(p []byte) (int, error) {
+	if !w.redactor.mightContainSecret(p) {
+		return w.out.Write(p)
+	}
+
+	var entry map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&entry); err != nil {
+		// Not a JSON entry; mask what can be found in the raw text
+		if _, err := io.WriteString(w.out, w.redactor.RedactString(string(p))); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	redacted, err := json.Marshal(w.redactor.Redact(entry))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.out.Write(append(redacted, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// normalizeKey lowercases a field name and treats "-" as "_"
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+}
@@ -0,0 +1,212 @@
+// Package metrics provides a small in-process registry of counters and gauges
+// rendered in the Prometheus text exposition format, and optionally pushed
+// to a Prometheus remote-write endpoint.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind is the Prometheus metric type
+type Kind string
+
+const (
+	KindCounter Kind = "counter"
+	KindGauge   Kind = "gauge"
+)
+
+// Labels are the label pairs identifying a series
+type Labels map[string]string
+
+// family holds every series of one metric name
+type family struct {
+	kind   Kind
+	help   string
+	series map[string]*series
+	// collect, if set, is called at scrape time to refresh gauge values
+	collect func(set func(labels Labels, value float64))
+}
+
+type series struct {
+	labels Labels
+	value  float64
+}
+
+// Registry stores metric families
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Register declares a metric family. Registering an existing name is a no-op.
+func (r *Registry) Register(name string, kind Kind, help string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.families[name]; !ok {
+		r.families[name] = &family{kind: kind, help: help, series: make(map[string]*series)}
+	}
+}
+
+// RegisterGaugeFunc declares a gauge whose series are produced by collect on
+// every scrape
+func (r *Registry) RegisterGaugeFunc(name, help string, collect func(set func(labels Labels, value float64))) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.families[name] = &family{kind: KindGauge, help: help, series: make(map[string]*series), collect: collect}
+}
+
+// Inc increments a counter by one
+func (r *Registry) Inc(name string, labels Labels) {
+	r.Add(name, labels, 1)
+}
+
+// Add adds delta to a counter or gauge
+func (r *Registry) Add(name string, labels Labels, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seriesFor(name, labels).value += delta
+}
+
+// Set sets a gauge to value
+func (r *Registry) Set(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seriesFor(name, labels).value = value
+}
+
+// Value returns the current value of a series, or 0 if it does not exist
+func (r *Registry) Value(name string, labels Labels) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		return 0
+	}
+	if s, ok := f.series[labelKey(labels)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// seriesFor returns the series for name and labels, creating an untyped
+// gauge family if the name was never registered. Callers must hold r.mu.
+func (r *Registry) seriesFor(name string, labels Labels) *series {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{kind: KindGauge, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+
+	key := labelKey(labels)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: labels}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Family is a metric family and the values of its series at one moment
+type Family struct {
+	Name   string
+	Kind   Kind
+	Help   string
+	Series []Series
+}
+
+// Series is one labelled value of a family
+type Series struct {
+	Labels Labels
+	Value  float64
+}
+
+// Snapshot returns every metric family, sorted by name, with its series
+// sorted by labels. Gauge functions are collected first, and counters that
+// were never incremented have a single series of 0.
+func (r *Registry) Snapshot() []Family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name, f := range r.families {
+		names = append(names, name)
+		if f.collect != nil {
+			f.series = make(map[string]*series)
+			f.collect(func(labels Labels, value float64) {
+				f.series[labelKey(labels)] = &series{labels: labels, value: value}
+			})
+		}
+	}
+	sort.Strings(names)
+
+	families := make([]Family, 0, len(names))
+	for _, name := range names {
+		f := r.families[name]
+		family := Family{Name: name, Kind: f.kind, Help: f.help}
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		if len(keys) == 0 && f.kind == KindCounter && f.collect == nil {
+			family.Series = append(family.Series, Series{Value: 0})
+		}
+		for _, key := range keys {
+			family.Series = append(family.Series, Series{Labels: f.series[key].labels, Value: f.series[key].value})
+		}
+		families = append(families, family)
+	}
+	return families
+}
+
+// WritePrometheus writes all metrics in the Prometheus text format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	for _, f := range r.Snapshot() {
+		if f.Help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", f.Name, f.Help)
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.Name, f.Kind)
+		for _, s := range f.Series {
+			fmt.Fprintf(&b, "%s%s %g\n", f.Name, labelKey(s.Labels), s.Value)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labelKey renders labels in sorted exposition form, e.g. {a="1",b="2"}
+func labelKey(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/config"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// RemoteWriter pushes a registry's metrics to a Prometheus remote-write
+// endpoint at an interval, for servers no scraper can reach, such as a
+// local process that runs only while an assistant is open
+type RemoteWriter struct {
+	cfg      config.RemoteWriteConfig
+	registry *Registry
+	labels   Labels
+	client   *http.Client
+	logger   *logger.Logger
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRemoteWriter creates a remote writer for registry and starts pushing.
+// The first push happens one interval after it starts.
+func NewRemoteWriter(cfg config.RemoteWriteConfig, registry *Registry, log *logger.Logger) *RemoteWriter {
+	registry.Register("fly_mcp_remote_writes_total", KindCounter, "Pushes of metrics to the remote-write endpoint, by result")
+
+	labels := Labels{"job": "fly-mcp"}
+	if hostname, err := os.Hostname(); err == nil {
+		labels["instance"] = hostname
+	}
+	maps.Copy(labels, cfg.Labels)
+
+	w := &RemoteWriter{
+		cfg:      cfg,
+		registry: registry,
+		labels:   labels,
+		client:   &http.Client{},
+		logger:   log,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Close stops pushing after a last push, so a process that exits does not
+// lose what happened since the previous one
+func (w *RemoteWriter) Close(ctx context.Context) error {
+	select {
+	case <-w.stop:
+	default:
+		close(w.stop)
+	}
+
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		return fmt.Errorf("last metrics push still pending: %w", ctx.Err())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(w.cfg.Timeout)*time.Second)
+	defer cancel()
+	return w.Push(ctx)
+}
+
+// run pushes every interval until Close
+func (w *RemoteWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(time.Duration(w.cfg.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(w.cfg.Timeout)*time.Second)
+			if err := w.Push(ctx); err != nil {
+				w.logger.Warn().
+					Err(err).
+					Msg("Failed to push metrics to the remote-write endpoint")
+			}
+			cancel()
+		}
+	}
+}
+
+// Push sends the current value of every series. Counters are cumulative, so
+// a failed push is not retried; the next one carries its changes.
+func (w *RemoteWriter) Push(ctx context.Context) error {
+	body := snappyBlock(w.writeRequest(w.registry.Snapshot(), time.Now()))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "fly-mcp")
+	switch {
+	case w.cfg.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+w.cfg.BearerToken)
+	case w.cfg.Username != "" || w.cfg.Password != "":
+		req.SetBasicAuth(w.cfg.Username, w.cfg.Password)
+	}
+	for key, value := range w.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		w.registry.Inc("fly_mcp_remote_writes_total", Labels{"result": "failed"})
+		return err
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		w.registry.Inc("fly_mcp_remote_writes_total", Labels{"result": "failed"})
+		return fmt.Errorf("remote-write endpoint returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	w.registry.Inc("fly_mcp_remote_writes_total", Labels{"result": "success"})
+	return nil
+}
+
+// Metric types of remote-write metadata
+const (
+	metadataCounter = 1
+	metadataGauge   = 2
+)
+
+// writeRequest encodes families as a remote-write WriteRequest protobuf:
+// one time series with a single sample per series, and the type and help
+// of each family as metadata
+func (w *RemoteWriter) writeRequest(families []Family, now time.Time) []byte {
+	timestamp := now.UnixMilli()
+
+	var buf []byte
+	for _, f := range families {
+		for _, s := range f.Series {
+			labels := maps.Clone(w.labels)
+			maps.Copy(labels, s.Labels)
+			labels["__name__"] = f.Name
+
+			var series []byte
+			for _, name := range slices.Sorted(maps.Keys(labels)) {
+				var label []byte
+				label = protowire.AppendTag(label, 1, protowire.BytesType)
+				label = protowire.AppendString(label, name)
+				label = protowire.AppendTag(label, 2, protowire.BytesType)
+				label = protowire.AppendString(label, labels[name])
+
+				series = protowire.AppendTag(series, 1, protowire.BytesType)
+				series = protowire.AppendBytes(series, label)
+			}
+
+			var sample []byte
+			sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(s.Value))
+			sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(timestamp))
+
+			series = protowire.AppendTag(series, 2, protowire.BytesType)
+			series = protowire.AppendBytes(series, sample)
+
+			buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+			buf = protowire.AppendBytes(buf, series)
+		}
+
+		kind := uint64(metadataGauge)
+		if f.Kind == KindCounter {
+			kind = metadataCounter
+		}
+		var metadata []byte
+		metadata = protowire.AppendTag(metadata, 1, protowire.VarintType)
+		metadata = protowire.AppendVarint(metadata, kind)
+		metadata = protowire.AppendTag(metadata, 2, protowire.BytesType)
+		metadata = protowire.AppendString(metadata, f.Name)
+		if f.Help != "" {
+			metadata = protowire.AppendTag(metadata, 4, protowire.BytesType)
+			metadata = protowire.AppendString(metadata, f.Help)
+		}
+
+		buf = protowire.AppendTag(buf, 3, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, metadata)
+	}
+	return buf
+}
+
+// snappyBlock encodes data in the Snappy block format remote write expects.
+// It emits literals only, which every Snappy decoder reads; the payloads are
+// a few kilobytes, so compressing them is not worth a dependency.
+func snappyBlock(data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := min(len(data), 1<<16)
+		switch length := n - 1; {
+		case length < 60:
+			out = append(out, byte(length)<<2)
+		case length < 1<<8:
+			out = append(out, 60<<2, byte(length))
+		default:
+			out = append(out, 61<<2, byte(length), byte(length>>8))
+		}
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return out
+}
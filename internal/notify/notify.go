@@ -0,0 +1,283 @@
+// Package notify posts mutating tool calls to webhooks, so the people
+// responsible for an environment see what was changed as it happens.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// queueSize bounds the events waiting for delivery. When webhooks are slow
+// enough for it to fill up, new events are dropped rather than blocking
+// tool calls.
+const queueSize = 256
+
+// Event describes a finished mutating tool call, one awaiting approval, a
+// scheduled status report or an alert
+type Event struct {
+	Tool        string    `json:"tool"`
+	Action      string    `json:"action"`
+	Resource    string    `json:"resource"`
+	AppName     string    `json:"app_name,omitempty"`
+	User        string    `json:"user"`
+	Result      string    `json:"result"` // success, failed, cancelled, pending_approval, report, alert or resolved
+	Message     string    `json:"message,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	Environment string    `json:"environment"`
+	Timestamp   time.Time `json:"timestamp"`
+	RequestID   string    `json:"request_id,omitempty"`
+
+	// Calls awaiting approval carry the request and, when links are
+	// configured, signed links deciding it
+	ApprovalID string `json:"approval_id,omitempty"`
+	ApproveURL string `json:"approve_url,omitempty"`
+	DenyURL    string `json:"deny_url,omitempty"`
+
+	// Report is the snapshot sent with a scheduled status report
+	Report interface{} `json:"report,omitempty"`
+}
+
+// Notifier delivers events to the configured webhooks in the background, in
+// the order they happened
+type Notifier struct {
+	webhooks    []config.WebhookConfig
+	environment string
+	client      *http.Client
+	logger      *logger.Logger
+	metrics     *metrics.Registry
+
+	// mu guards closed, so events from calls still running after Close are
+	// dropped instead of sent on the closed queue
+	mu     sync.Mutex
+	closed bool
+	queue  chan Event
+	done   chan struct{}
+}
+
+// New creates a notifier and starts its delivery worker. With no webhooks
+// configured, Notify does nothing.
+func New(cfg config.NotificationsConfig, environment string, log *logger.Logger, registry *metrics.Registry) *Notifier {
+	registry.Register("fly_mcp_webhook_deliveries_total", metrics.KindCounter, "Webhook notification deliveries by outcome")
+
+	n := &Notifier{
+		webhooks:    cfg.Webhooks,
+		environment: environment,
+		client:      &http.Client{},
+		logger:      log,
+		metrics:     registry,
+		queue:       make(chan Event, queueSize),
+		done:        make(chan struct{}),
+	}
+
+	go n.run()
+	return n
+}
+
+// Notify queues an event for delivery without waiting for it
+func (n *Notifier) Notify(event Event) {
+	if len(n.webhooks) == 0 {
+		return
+	}
+
+	event.Environment = n.environment
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return
+	}
+
+	select {
+	case n.queue <- event:
+	default:
+		n.metrics.Inc("fly_mcp_webhook_deliveries_total", metrics.Labels{"webhook": "*", "result": "dropped"})
+		n.logger.Warn().
+			Str("tool", event.Tool).
+			Msg("Notification queue full; dropping event")
+	}
+}
+
+// Close stops accepting events and waits until queued events are delivered
+// or ctx expires
+func (n *Notifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("notifications still pending: %w", ctx.Err())
+	}
+}
+
+// run delivers queued events until the queue is closed
+func (n *Notifier) run() {
+	defer close(n.done)
+
+	for event := range n.queue {
+		for _, webhook := range n.webhooks {
+			if len(webhook.Results) > 0 && !slices.Contains(webhook.Results, event.Result) {
+				continue
+			}
+
+			result := "success"
+			if err := n.deliver(webhook, event); err != nil {
+				result = "failed"
+				n.logger.Warn().
+					Err(err).
+					Str("webhook", webhookName(webhook)).
+					Str("tool", event.Tool).
+					Msg("Failed to deliver notification")
+			}
+			n.metrics.Inc("fly_mcp_webhook_deliveries_total", metrics.Labels{"webhook": webhookName(webhook), "result": result})
+		}
+	}
+}
+
+// deliver posts an event to one webhook, retrying once on network errors
+// and server errors
+func (n *Notifier) deliver(webhook config.WebhookConfig, event Event) error {
+	var payload interface{} = event
+	if webhook.Type == "slack" {
+		payload = map[string]string{"text": slackText(event)}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	timeout := time.Duration(webhook.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	retry, err := n.post(webhook, body, timeout)
+	if err != nil && retry {
+		time.Sleep(time.Second)
+		_, err = n.post(webhook, body, timeout)
+	}
+	return err
+}
+
+// post sends one delivery attempt. It reports whether a failed attempt is
+// worth retrying.
+func (n *Notifier) post(webhook config.WebhookConfig, body []byte, timeout time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "fly-mcp")
+	for key, value := range webhook.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return false, nil
+}
+
+// slackText renders an event as a one-line Slack message
+func slackText(event Event) string {
+	switch event.Result {
+	case "pending_approval":
+		return slackApprovalText(event)
+	case "report":
+		return slackReportText(event)
+	case "alert":
+		return fmt.Sprintf("🚨 %s (%s)", event.Message, event.Environment)
+	case "resolved":
+		return fmt.Sprintf("✅ %s (%s)", event.Message, event.Environment)
+	}
+
+	icon, outcome := "✅", "succeeded"
+	switch event.Result {
+	case "failed":
+		icon, outcome = "❌", "failed"
+	case "cancelled":
+		icon, outcome = "⏹️", "was cancelled"
+	}
+
+	target := ""
+	if event.AppName != "" {
+		target = fmt.Sprintf(" on `%s`", event.AppName)
+	}
+
+	duration := time.Duration(event.DurationMS) * time.Millisecond
+	text := fmt.Sprintf("%s *%s*%s by %s %s after %s (%s)", icon, event.Tool, target, event.User, outcome, duration, event.Environment)
+	if event.Message != "" {
+		text += "\n> " + event.Message
+	}
+	return text
+}
+
+// slackApprovalText renders a call awaiting approval as a Slack message with
+// links deciding it
+func slackApprovalText(event Event) string {
+	target := ""
+	if event.AppName != "" {
+		target = fmt.Sprintf(" on `%s`", event.AppName)
+	}
+
+	text := fmt.Sprintf("⏳ *%s*%s by %s awaits approval (%s, request `%s`)", event.Tool, target, event.User, event.Environment, event.ApprovalID)
+	if event.Message != "" {
+		text += "\n> " + strings.ReplaceAll(event.Message, "\n", "\n> ")
+	}
+	if event.ApproveURL != "" {
+		text += fmt.Sprintf("\n<%s|Approve> · <%s|Deny>", event.ApproveURL, event.DenyURL)
+	}
+	return text
+}
+
+// slackReportText renders a scheduled status report as a Slack message
+func slackReportText(event Event) string {
+	text := fmt.Sprintf("📋 *Status report* (%s)", event.Environment)
+	if event.Message != "" {
+		text += "\n> " + strings.ReplaceAll(event.Message, "\n", "\n> ")
+	}
+	return text
+}
+
+// webhookName identifies a webhook in logs and metrics without exposing its
+// URL, which often embeds a secret
+func webhookName(webhook config.WebhookConfig) string {
+	if webhook.Name != "" {
+		return webhook.Name
+	}
+	if webhook.Type != "" {
+		return webhook.Type
+	}
+	return "generic"
+}
@@ -0,0 +1,108 @@
+// Package ratelimit provides token-bucket rate limiting keyed by caller.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleTTL is how long an unused limiter is kept before it is discarded
+const idleTTL = 10 * time.Minute
+
+// KeyedLimiter keeps an independent token bucket per key
+type KeyedLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	limiters  map[string]*entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewKeyedLimiter creates a limiter allowing rps requests per second per key
+// with the given burst. A non-positive rps disables limiting.
+func NewKeyedLimiter(rps float64, burst int) *KeyedLimiter {
+	limit := rate.Limit(rps)
+	if rps <= 0 {
+		limit = rate.Inf
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &KeyedLimiter{
+		limit:     limit,
+		burst:     burst,
+		limiters:  make(map[string]*entry),
+		lastSweep: time.Now(),
+	}
+}
+
+// SetLimit changes the rate and burst for existing and future keys
+func (k *KeyedLimiter) SetLimit(rps float64, burst int) {
+	limit := rate.Limit(rps)
+	if rps <= 0 {
+		limit = rate.Inf
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.limit = limit
+	k.burst = burst
+	for _, e := range k.limiters {
+		e.limiter.SetLimit(limit)
+		e.limiter.SetBurst(burst)
+	}
+}
+
+// Allow reports whether a request for key may proceed now
+func (k *KeyedLimiter) Allow(key string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.limit == rate.Inf {
+		return true
+	}
+
+	now := time.Now()
+	if now.Sub(k.lastSweep) > idleTTL {
+		k.sweep(now)
+	}
+
+	e, ok := k.limiters[key]
+	if !ok {
+		e = &entry{limiter: rate.NewLimiter(k.limit, k.burst)}
+		k.limiters[key] = e
+	}
+	e.lastSeen = now
+
+	return e.limiter.AllowN(now, 1)
+}
+
+// Len returns the number of keys currently tracked
+func (k *KeyedLimiter) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return len(k.limiters)
+}
+
+// sweep drops limiters that have not been used recently. Callers must hold k.mu.
+func (k *KeyedLimiter) sweep(now time.Time) {
+	for key, e := range k.limiters {
+		if now.Sub(e.lastSeen) > idleTTL {
+			delete(k.limiters, key)
+		}
+	}
+	k.lastSweep = now
+}
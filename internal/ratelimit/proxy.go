@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// clientIPHeader is where Fly's proxy reports the original client
+const clientIPHeader = "Fly-Client-IP"
+
+// privateNetwork is Fly.io's private IPv6 network (6PN). Machines of the
+// organization reach the server over it directly, not through the proxy, so
+// their Fly-Client-IP is whatever they send.
+var privateNetwork = &net.IPNet{IP: net.ParseIP("fdaa::"), Mask: net.CIDRMask(16, 128)}
+
+// ProxyTrust decides whose Fly-Client-IP header is believed. Any client can
+// send the header, so it only names the caller when the request came
+// through Fly's proxy or a configured trusted proxy.
+type ProxyTrust struct {
+	flyProxy bool
+	trusted  []*net.IPNet
+}
+
+// NewProxyTrust creates the trust for the server's proxies. flyProxy
+// trusts Fly's proxy, for servers running on Fly.io; trusted lists the
+// addresses or CIDR ranges of other proxies.
+func NewProxyTrust(flyProxy bool, trusted []string) (*ProxyTrust, error) {
+	p := &ProxyTrust{flyProxy: flyProxy}
+	for _, entry := range trusted {
+		network, err := parseNetwork(entry)
+		if err != nil {
+			return nil, err
+		}
+		p.trusted = append(p.trusted, network)
+	}
+	return p, nil
+}
+
+// parseNetwork parses an address or CIDR range
+func parseNetwork(entry string) (*net.IPNet, error) {
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		return network, nil
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid trusted proxy %q: not an IP address or CIDR range", entry)
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// ClientIP returns the address identifying the caller of r: the original
+// client a trusted proxy reports in Fly-Client-IP, otherwise the
+// connection's remote host
+func (p *ProxyTrust) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if client := r.Header.Get(clientIPHeader); client != "" && p.trusts(net.ParseIP(host)) {
+		if ip := net.ParseIP(strings.TrimSpace(client)); ip != nil {
+			return ip.String()
+		}
+	}
+	return host
+}
+
+// trusts reports whether a connection from remote comes through a trusted
+// proxy
+func (p *ProxyTrust) trusts(remote net.IP) bool {
+	if p == nil || remote == nil {
+		return false
+	}
+	if p.flyProxy && !privateNetwork.Contains(remote) {
+		return true
+	}
+	for _, network := range p.trusted {
+		if network.Contains(remote) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/brannn/fly-mcp/pkg/auth"
+)
+
+// approvalApprover is the approver recorded for decisions made with a
+// signed link, whose holder is not identified
+const approvalApprover = "signed link"
+
+// approvalPage asks for confirmation before a signed link decides a
+// request, so chat apps unfurling the link do not decide it
+var approvalPage = template.Must(template.New("approval").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Verb}} {{.Approval.Tool}}</title></head>
+<body>
+<h1>{{.Verb}} {{.Approval.Tool}}{{if .Approval.AppName}} on {{.Approval.AppName}}{{end}}?</h1>
+<p>Requested by {{.Approval.RequestedBy}} at {{.Approval.RequestedAt.UTC.Format "2006-01-02 15:04:05 UTC"}}</p>
+<pre>{{.Approval.Summary}}</pre>
+<form method="post">
+<label>Reason <input name="reason"></label>
+<button type="submit">{{.Verb}}</button>
+</form>
+</body>
+</html>
+`))
+
+// handleListApprovals lists the approval requests that have not expired, to
+// callers allowed to decide them
+func (s *Server) handleListApprovals(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.identifyApprover(w, r); !ok {
+		return
+	}
+
+	if err := writeData(w, r, http.StatusOK, map[string]interface{}{
+		"approvals": s.mcpHandler.AuthManager().Approvals(),
+	}); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to write approvals response")
+	}
+}
+
+// handleApprovalPage shows what a signed approval link decides, with a form
+// submitting the decision
+func (s *Server) handleApprovalPage(w http.ResponseWriter, r *http.Request) {
+	authManager := s.mcpHandler.AuthManager()
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	if err := authManager.VerifyApprovalLink(vars["id"], vars["decision"], query.Get("expires"), query.Get("sig")); err != nil {
+		writeError(w, r, http.StatusForbidden, "forbidden", err.Error(), nil)
+		return
+	}
+	approval, ok := authManager.GetApproval(vars["id"])
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not_found", auth.ErrApprovalUnknown.Error(), nil)
+		return
+	}
+
+	verb := "Approve"
+	if vars["decision"] == "deny" {
+		verb = "Deny"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := approvalPage.Execute(w, map[string]interface{}{"Verb": verb, "Approval": approval}); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to write approval page")
+	}
+}
+
+// handleDecideApproval approves or denies a request. The decision is made
+// with a signed link, or by an identified caller holding approve:change.
+func (s *Server) handleDecideApproval(w http.ResponseWriter, r *http.Request) {
+	authManager := s.mcpHandler.AuthManager()
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	approver := approvalApprover
+	if query.Has("sig") {
+		if err := authManager.VerifyApprovalLink(vars["id"], vars["decision"], query.Get("expires"), query.Get("sig")); err != nil {
+			writeError(w, r, http.StatusForbidden, "forbidden", err.Error(), nil)
+			return
+		}
+	} else {
+		var ok bool
+		if approver, ok = s.identifyApprover(w, r); !ok {
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.Server.Limits.MaxBodyBytes)
+	var reason string
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid_request", "failed to parse request body: "+err.Error(), nil)
+			return
+		}
+		reason = body.Reason
+	} else {
+		reason = r.PostFormValue("reason")
+	}
+
+	ctx := authManager.CreateAuditContext(r.Context(), approver, requestID(w, r))
+	approval, err := authManager.DecideApproval(ctx, vars["id"], approver, vars["decision"] == "approve", strings.TrimSpace(reason))
+	switch {
+	case errors.Is(err, auth.ErrApprovalUnknown):
+		writeError(w, r, http.StatusNotFound, "not_found", err.Error(), nil)
+	case errors.Is(err, auth.ErrApprovalDecided):
+		writeError(w, r, http.StatusConflict, "conflict", err.Error(), approval)
+	case errors.Is(err, auth.ErrSelfApproval):
+		writeError(w, r, http.StatusForbidden, "forbidden", err.Error(), nil)
+	case err != nil:
+		writeError(w, r, http.StatusInternalServerError, "internal", err.Error(), nil)
+	default:
+		s.logger.Info().
+			Str("approval_id", approval.ID).
+			Str("status", approval.Status).
+			Str("decided_by", approver).
+			Msg("Approval request decided")
+		writeData(w, r, http.StatusOK, approval)
+	}
+}
+
+// identifyApprover returns who sent r, answering 401 or 403 when the caller
+// is anonymous, cannot be identified or may not decide approval requests
+func (s *Server) identifyApprover(w http.ResponseWriter, r *http.Request) (string, bool) {
+	authManager := s.mcpHandler.AuthManager()
+
+	userID, _, err := authManager.IdentifyRequest(r)
+	if err == nil && userID == auth.AnonymousUser {
+		err = auth.ErrUnauthenticated
+	}
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="fly-mcp"`)
+		writeError(w, r, http.StatusUnauthorized, "unauthorized", err.Error(), nil)
+		return "", false
+	}
+
+	action, resource, _ := strings.Cut(auth.ApprovePermission, ":")
+	if !authManager.IsAllowed(userID, action, resource) {
+		writeError(w, r, http.StatusForbidden, "forbidden", "permission "+auth.ApprovePermission+" is required to decide approval requests", nil)
+		return "", false
+	}
+	return userID, true
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/auth"
+)
+
+// setupDebugRoutes adds the runtime diagnostics endpoints: the Go profiler
+// under /debug/pprof and a status snapshot at /debug/status
+func (s *Server) setupDebugRoutes() {
+	s.router.HandleFunc("/debug/status", s.requireDebug(s.handleDebugStatus)).Methods("GET")
+
+	s.router.HandleFunc("/debug/pprof/cmdline", s.requireDebug(pprof.Cmdline)).Methods("GET")
+	s.router.HandleFunc("/debug/pprof/profile", s.requireDebug(pprof.Profile)).Methods("GET")
+	s.router.HandleFunc("/debug/pprof/symbol", s.requireDebug(pprof.Symbol)).Methods("GET", "POST")
+	s.router.HandleFunc("/debug/pprof/trace", s.requireDebug(pprof.Trace)).Methods("GET")
+	s.router.PathPrefix("/debug/pprof/").HandlerFunc(s.requireDebug(pprof.Index)).Methods("GET")
+}
+
+// requireDebug lets only identified callers holding debug:server reach a
+// diagnostics endpoint. Profiles take longer than the server's write
+// timeout allows, so it is lifted for them.
+func (s *Server) requireDebug(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authManager := s.mcpHandler.AuthManager()
+
+		userID, _, err := authManager.IdentifyRequest(r)
+		if err == nil && userID == auth.AnonymousUser {
+			err = auth.ErrUnauthenticated
+		}
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="fly-mcp"`)
+			writeError(w, r, http.StatusUnauthorized, "unauthorized", err.Error(), nil)
+			return
+		}
+
+		action, resource, _ := strings.Cut(auth.DebugPermission, ":")
+		if !authManager.IsAllowed(userID, action, resource) {
+			writeError(w, r, http.StatusForbidden, "forbidden", "permission "+auth.DebugPermission+" is required for diagnostics", nil)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		rc.SetWriteDeadline(time.Time{})
+
+		next(w, r)
+	}
+}
+
+// handleDebugStatus reports goroutines, memory, running calls, the sizes of
+// in-memory state and the open Fly.io API connections
+func (s *Server) handleDebugStatus(w http.ResponseWriter, r *http.Request) {
+	if err := writeData(w, r, http.StatusOK, s.mcpHandler.Diagnostics()); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to write debug status response")
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/brannn/fly-mcp/pkg/pipeline"
+)
+
+// ciUser is who CI events are audited as
+const ciUser = "ci"
+
+// handleCIHook stores a build, test or deploy event posted by a CI system.
+// The system authenticates with integrations.ci.secret as a bearer token.
+func (s *Server) handleCIHook(w http.ResponseWriter, r *http.Request) {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Integrations.CI.Secret)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="fly-mcp"`)
+		writeError(w, r, http.StatusUnauthorized, "unauthorized", "a valid CI secret is required", nil)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.Server.Limits.MaxBodyBytes)
+	var event pipeline.Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "failed to parse request body: "+err.Error(), nil)
+		return
+	}
+
+	ctx := s.mcpHandler.AuthManager().CreateAuditContext(r.Context(), ciUser, requestID(w, r))
+	event, err := s.mcpHandler.RecordCIEvent(ctx, event)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", err.Error(), nil)
+		return
+	}
+
+	s.logger.Info().
+		Str("event_id", event.ID).
+		Str("app_name", event.AppName).
+		Str("kind", event.Kind).
+		Str("status", event.Status).
+		Str("source", event.Source).
+		Msg("CI event received")
+	writeData(w, r, http.StatusAccepted, event)
+}
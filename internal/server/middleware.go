@@ -5,9 +5,40 @@ import (
 	"strings"
 	"time"
 
-	"golang.org/x/time/rate"
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracingMiddleware starts a server span for each HTTP request, continuing
+// the caller's trace when the request carries W3C trace context headers
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", r.RemoteAddr),
+			),
+		)
+		defer span.End()
+		
+		wrapper := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapper, r.WithContext(ctx))
+		
+		span.SetAttributes(attribute.Int("http.response.status_code", wrapper.statusCode))
+		if wrapper.statusCode >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(wrapper.statusCode))
+		}
+	})
+}
+
 // loggingMiddleware logs HTTP requests
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -20,7 +51,9 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(wrapper, r)
 		
 		// Log the request
+		requestID, _ := r.Context().Value("request_id").(string)
 		s.logger.Info().
+			Str("request_id", requestID).
 			Str("method", r.Method).
 			Str("path", r.URL.Path).
 			Str("remote_addr", r.RemoteAddr).
@@ -42,7 +75,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 		}
 		
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Request-ID, Mcp-Session-Id, MCP-Protocol-Version, traceparent, tracestate, baggage")
+		w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id, X-Request-ID")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 		
@@ -56,22 +90,20 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// rateLimitMiddleware implements rate limiting
+// rateLimitMiddleware implements per-client rate limiting
 func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
-	// Create a rate limiter
-	limiter := rate.NewLimiter(rate.Limit(s.config.Security.RateLimitRPS), s.config.Security.RateLimitRPS*2)
-	
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check rate limit
-		if !limiter.Allow() {
+		// Check rate limit for this client address
+		client := s.mcpHandler.ClientIP(r)
+		if !s.limiter.Allow(client) {
+			s.metrics.Inc("fly_mcp_rate_limit_rejected_total", metrics.Labels{"scope": "http"})
 			s.logger.Warn().
 				Str("remote_addr", r.RemoteAddr).
+				Str("client", client).
 				Str("path", r.URL.Path).
 				Msg("Rate limit exceeded")
 			
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusTooManyRequests)
-			w.Write([]byte(`{"error": "rate limit exceeded"}`))
+			writeError(w, r, http.StatusTooManyRequests, "rate_limited", "rate limit exceeded", nil)
 			return
 		}
 		
@@ -85,7 +117,7 @@ func (s *Server) isOriginAllowed(origin string) bool {
 		return true // Allow requests without origin (e.g., from curl)
 	}
 	
-	for _, allowed := range s.config.Security.AllowedOrigins {
+	for _, allowed := range s.allowedOrigins() {
 		if allowed == "*" {
 			return true
 		}
@@ -116,3 +148,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap exposes the underlying writer so handlers can flush streamed
+// responses through http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
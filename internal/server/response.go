@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// RequestIDHeader carries the request ID, taken from the caller when it
+// sends one and echoed on every response
+const RequestIDHeader = "X-Request-ID"
+
+// Envelope statuses
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Response is the envelope of every HTTP response outside the MCP endpoint
+type Response struct {
+	Status    string         `json:"status"`
+	Data      interface{}    `json:"data,omitempty"`
+	Error     *ResponseError `json:"error,omitempty"`
+	RequestID string         `json:"request_id"`
+}
+
+// ResponseError describes why a request failed
+type ResponseError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// requestIDMiddleware gives each request an ID, stored in the context
+// under "request_id" so Fly.io API call logs carry it too
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "request_id", id)))
+	})
+}
+
+// requestID returns the ID of a request, creating one for requests that
+// did not pass through requestIDMiddleware, such as unmatched routes
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	if id, ok := r.Context().Value("request_id").(string); ok && id != "" {
+		return id
+	}
+	id := newRequestID()
+	w.Header().Set(RequestIDHeader, id)
+	return id
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// writeData writes a successful response
+func writeData(w http.ResponseWriter, r *http.Request, code int, data interface{}) error {
+	return writeJSON(w, code, Response{
+		Status:    StatusOK,
+		Data:      data,
+		RequestID: requestID(w, r),
+	})
+}
+
+// writeError writes a failed response. data may carry details, such as the
+// components of a failed readiness check.
+func writeError(w http.ResponseWriter, r *http.Request, code int, errCode, message string, data interface{}) error {
+	return writeJSON(w, code, Response{
+		Status:    StatusError,
+		Data:      data,
+		Error:     &ResponseError{Code: errCode, Message: message},
+		RequestID: requestID(w, r),
+	})
+}
+
+// writeJSON writes a JSON response with a status code
+func writeJSON(w http.ResponseWriter, code int, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"status":"error","error":{"code":"internal","message":"failed to encode response"}}` + "\n"))
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
@@ -3,11 +3,17 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/internal/ratelimit"
 	"github.com/brannn/fly-mcp/pkg/config"
 	"github.com/brannn/fly-mcp/pkg/mcp"
 )
@@ -19,12 +25,25 @@ type Server struct {
 	mcpHandler *mcp.Handler
 	httpServer *http.Server
 	router     *mux.Router
+	metrics    *metrics.Registry
+	limiter    *ratelimit.KeyedLimiter
+	certs      *certReloader
+
+	// live holds the most recently applied configuration; only the
+	// hot-reloadable settings in it are read after startup
+	mu   sync.RWMutex
+	live *config.Config
 }
 
 // New creates a new server instance
 func New(cfg *config.Config, log *logger.Logger) (*Server, error) {
+	// Create metrics registry shared by the server and MCP handler
+	registry := metrics.NewRegistry()
+	registry.Register("fly_mcp_requests_total", metrics.KindCounter, "Total number of MCP requests")
+	registry.Register("fly_mcp_rate_limit_rejected_total", metrics.KindCounter, "Requests rejected by rate limiting")
+	
 	// Create MCP handler
-	mcpHandler, err := mcp.NewHandler(cfg, log)
+	mcpHandler, err := mcp.NewHandler(cfg, log, registry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MCP handler: %w", err)
 	}
@@ -39,6 +58,12 @@ func New(cfg *config.Config, log *logger.Logger) (*Server, error) {
 		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
 		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout) * time.Second,
+		// Slow or oversized headers are cut off before a handler runs
+		ReadHeaderTimeout: time.Duration(cfg.Server.Limits.ReadHeaderTimeout) * time.Second,
+		MaxHeaderBytes:    cfg.Server.Limits.MaxHeaderBytes,
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: cfg.Server.Limits.MaxConcurrentStreams,
+		},
 	}
 	
 	server := &Server{
@@ -47,6 +72,25 @@ func New(cfg *config.Config, log *logger.Logger) (*Server, error) {
 		mcpHandler: mcpHandler,
 		httpServer: httpServer,
 		router:     router,
+		metrics:    registry,
+		live:       cfg,
+	}
+	
+	if cfg.Security.RateLimitEnabled {
+		server.limiter = ratelimit.NewKeyedLimiter(float64(cfg.Security.RateLimitRPS), cfg.Security.RateLimitRPS*2)
+		registry.RegisterGaugeFunc("fly_mcp_http_rate_limit_clients", "Client addresses currently tracked by the HTTP rate limiter", func(set func(metrics.Labels, float64)) {
+			set(nil, float64(server.limiter.Len()))
+		})
+	}
+	
+	// Load TLS certificates if HTTPS is enabled
+	if cfg.Server.TLS.Enabled {
+		certs, err := newCertReloader(cfg.Server.TLS, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		server.certs = certs
+		httpServer.TLSConfig = certs.TLSConfig()
 	}
 	
 	// Setup routes
@@ -57,14 +101,27 @@ func New(cfg *config.Config, log *logger.Logger) (*Server, error) {
 
 // Start starts the server
 func (s *Server) Start(ctx context.Context) error {
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+	
 	s.logger.Info().
-		Str("address", s.httpServer.Addr).
+		Str("address", s.config.Server.Address()).
+		Bool("tls", s.certs != nil).
 		Msg("Starting HTTP server")
 	
 	// Start server in goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.certs != nil {
+			// Certificates come from TLSConfig, so no files are passed here
+			err = s.httpServer.ServeTLS(listener, "", "")
+		} else {
+			err = s.httpServer.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			errChan <- err
 		}
 	}()
@@ -78,29 +135,191 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 }
 
+// listen opens the listener for the configured address: the Unix socket in
+// server.listen, or host and port. The socket is removed again when the
+// server shuts down.
+func (s *Server) listen() (net.Listener, error) {
+	socket := s.config.Server.Listen.UnixSocket
+	if socket == "" {
+		listener, err := net.Listen("tcp", s.httpServer.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", s.httpServer.Addr, err)
+		}
+		return listener, nil
+	}
+	
+	// A socket left behind by a server that did not shut down cleanly is
+	// replaced, but not one another server still answers on
+	if info, err := os.Lstat(socket); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", socket)
+		}
+		if conn, err := net.Dial("unix", socket); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another server is listening on %s", socket)
+		}
+		if err := os.Remove(socket); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", socket, err)
+		}
+	}
+	
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+	mode, err := s.config.Server.Listen.FileMode()
+	if err == nil {
+		err = os.Chmod(socket, mode)
+	}
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set permissions of %s: %w", socket, err)
+	}
+	return listener, nil
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info().Msg("Shutting down server")
 	
+	// Stop accepting tool calls and let mutating operations finish first
+	drainErr := s.mcpHandler.Drain(ctx)
+	
 	if err := s.httpServer.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server shutdown failed: %w", err)
 	}
 	
+	// Deliver notifications about the calls that just finished
+	if err := s.mcpHandler.Close(ctx); err != nil {
+		s.logger.Warn().Err(err).Msg("Some webhook notifications, audit events or metrics were not delivered")
+	}
+	
+	if s.certs != nil {
+		s.certs.Close()
+	}
+	
+	if drainErr != nil {
+		return fmt.Errorf("server shutdown incomplete: %w", drainErr)
+	}
+	
 	return nil
 }
 
+// Reload loads a new configuration and applies the settings that can change
+// while running: permissions, rate limits, log level and allowed origins.
+// If loading or validation fails the current configuration stays active.
+func (s *Server) Reload(source string, load func() (*config.Config, error)) error {
+	newCfg, err := load()
+	if err != nil {
+		s.logger.Error().
+			Err(err).
+			Str("source", source).
+			Msg("Configuration reload rejected, keeping current configuration")
+		s.mcpHandler.AuditConfigReload(source, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return err
+	}
+	
+	s.mu.Lock()
+	old := s.live
+	s.live = newCfg
+	s.mu.Unlock()
+	
+	var changed []string
+	
+	if old.Logging.Level != newCfg.Logging.Level {
+		if err := logger.SetLevel(newCfg.Logging.Level); err == nil {
+			changed = append(changed, "logging.level")
+		}
+	}
+	
+	if !reflect.DeepEqual(old.Security.AllowedOrigins, newCfg.Security.AllowedOrigins) {
+		changed = append(changed, "security.allowed_origins")
+	}
+	
+	if old.Security.RateLimitRPS != newCfg.Security.RateLimitRPS && s.limiter != nil {
+		s.limiter.SetLimit(float64(newCfg.Security.RateLimitRPS), newCfg.Security.RateLimitRPS*2)
+		changed = append(changed, "security.rate_limit_rps")
+	}
+	
+	if old.Security.RateLimitEnabled != newCfg.Security.RateLimitEnabled {
+		s.logger.Warn().Msg("Changing security.rate_limit_enabled requires a restart")
+	}
+	
+	if !reflect.DeepEqual(old.MCP.Tools, newCfg.MCP.Tools) {
+		s.logger.Warn().Msg("Changing mcp.tools requires a restart")
+	}
+	
+	changed = append(changed, s.mcpHandler.ApplyConfig(old, newCfg)...)
+	
+	s.logger.Info().
+		Str("source", source).
+		Strs("changed", changed).
+		Msg("Configuration reloaded")
+	s.mcpHandler.AuditConfigReload(source, "success", map[string]interface{}{
+		"changed": changed,
+	})
+	
+	return nil
+}
+
+// allowedOrigins returns the currently configured CORS origins
+func (s *Server) allowedOrigins() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.live.Security.AllowedOrigins
+}
+
 // setupRoutes configures the HTTP routes
 func (s *Server) setupRoutes() {
-	// Health check endpoint
+	// Health check endpoints: liveness only says the process is serving,
+	// readiness probes the Fly.io API
 	s.router.HandleFunc("/health", s.handleHealth).Methods("GET")
+	s.router.HandleFunc("/health/live", s.handleHealth).Methods("GET")
+	s.router.HandleFunc("/health/ready", s.handleReady).Methods("GET")
 	
 	// Metrics endpoint (if enabled)
 	s.router.HandleFunc("/metrics", s.handleMetrics).Methods("GET")
 	
 	// MCP endpoint - this is where MCP clients will connect
 	s.router.HandleFunc("/mcp", s.handleMCP).Methods("POST")
+	s.router.HandleFunc("/mcp", s.mcpHandler.StreamNotifications).Methods("GET")
+	s.router.HandleFunc("/mcp", s.mcpHandler.EndSession).Methods("DELETE")
+	
+	// Approval requests of high-risk tool calls, decided by approvers or
+	// with signed links sent to webhooks
+	s.router.HandleFunc("/approvals", s.handleListApprovals).Methods("GET")
+	s.router.HandleFunc("/approvals/{id}/{decision:approve|deny}", s.handleApprovalPage).Methods("GET")
+	s.router.HandleFunc("/approvals/{id}/{decision:approve|deny}", s.handleDecideApproval).Methods("POST")
+	
+	// Build and deploy events from CI systems, served as each app's
+	// pipeline resource
+	if s.config.Integrations.CI.Enabled {
+		s.router.HandleFunc("/hooks/ci", s.handleCIHook).Methods("POST")
+	}
+	
+	// Slack slash commands running tools without an assistant
+	if s.config.Integrations.Slack.Enabled {
+		s.router.HandleFunc("/slack", s.handleSlackCommand).Methods("POST")
+	}
+	
+	// Profiler and runtime status for administrators
+	if s.config.Server.Debug.Enabled {
+		s.setupDebugRoutes()
+	}
+	
+	// Unknown routes get the same JSON envelope as the other endpoints
+	s.router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, "not_found", "no such endpoint", nil)
+	})
+	s.router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed on "+r.URL.Path, nil)
+	})
 	
 	// Add middleware
+	s.router.Use(s.requestIDMiddleware)
+	s.router.Use(s.tracingMiddleware)
 	s.router.Use(s.loggingMiddleware)
 	s.router.Use(s.corsMiddleware)
 	
@@ -111,9 +330,6 @@ func (s *Server) setupRoutes() {
 
 // handleHealth handles health check requests
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	
 	response := map[string]interface{}{
 		"status":      "healthy",
 		"timestamp":   time.Now().UTC(),
@@ -121,27 +337,53 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		"environment": s.config.Environment,
 	}
 	
-	if err := writeJSON(w, response); err != nil {
+	if err := writeData(w, r, http.StatusOK, response); err != nil {
 		s.logger.Error().Err(err).Msg("Failed to write health check response")
 	}
 }
 
+// handleReady handles readiness requests, answering 503 while the Fly.io
+// API, the token or the organization fails
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	readiness := s.mcpHandler.Readiness(r.Context())
+	
+	response := map[string]interface{}{
+		"status":      "ready",
+		"timestamp":   time.Now().UTC(),
+		"checked_at":  readiness.CheckedAt,
+		"version":     s.config.MCP.ServerInfo.Version,
+		"environment": s.config.Environment,
+		"components":  readiness.Components,
+	}
+	
+	var err error
+	if readiness.Ready {
+		err = writeData(w, r, http.StatusOK, response)
+	} else {
+		response["status"] = "not_ready"
+		err = writeError(w, r, http.StatusServiceUnavailable, "not_ready", "a dependency of the server is failing", response)
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to write readiness response")
+	}
+}
+
 // handleMetrics handles metrics requests
 func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
 	w.WriteHeader(http.StatusOK)
 	
-	// TODO: Implement actual metrics collection
-	fmt.Fprintf(w, "# HELP fly_mcp_requests_total Total number of MCP requests\n")
-	fmt.Fprintf(w, "# TYPE fly_mcp_requests_total counter\n")
-	fmt.Fprintf(w, "fly_mcp_requests_total 0\n")
+	if err := s.metrics.WritePrometheus(w); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to write metrics response")
+	}
 }
 
 // handleMCP handles MCP protocol requests
 func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
+	s.metrics.Inc("fly_mcp_requests_total", nil)
 	
-	// Handle the MCP request
+	// Handle the MCP request; the handler bounds the body size
 	if err := s.mcpHandler.HandleRequest(w, r); err != nil {
 		s.logger.Error().
 			Err(err).
@@ -156,10 +398,3 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 		Dur("duration", time.Since(start)).
 		Msg("MCP request completed")
 }
-
-// writeJSON writes a JSON response
-func writeJSON(w http.ResponseWriter, data interface{}) error {
-	w.Header().Set("Content-Type", "application/json")
-	// TODO: Implement JSON encoding
-	return nil
-}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/slack"
+)
+
+// slackAckTimeout is how long a command may run before the reply says it is
+// still running and the result follows at the response URL; Slack gives up
+// on replies after three seconds
+const slackAckTimeout = 2500 * time.Millisecond
+
+// slackClient posts the results of slow commands to their response URLs
+var slackClient = &http.Client{Timeout: 10 * time.Second}
+
+// handleSlackCommand runs the tool a Slack slash command names, e.g.
+// /fly status my-app, as the fly-mcp user the Slack user is mapped to. The
+// call goes through the same permissions, policies, rate limits,
+// confirmations and audit log as an MCP tools/call.
+func (s *Server) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	cfg := s.config.Integrations.Slack
+	authManager := s.mcpHandler.AuthManager()
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.Server.Limits.MaxBodyBytes)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "failed to read request body: "+err.Error(), nil)
+		return
+	}
+
+	if err := slack.Verify(cfg.SigningSecret, r.Header, body, time.Now()); err != nil {
+		authManager.LogSecurityEvent(r.Context(), "authentication_failed", "unknown", "slack", false, map[string]interface{}{
+			"client": s.mcpHandler.ClientIP(r),
+			"error":  err.Error(),
+		})
+		writeError(w, r, http.StatusUnauthorized, "unauthorized", err.Error(), nil)
+		return
+	}
+
+	cmd, err := slack.ParseCommand(body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", err.Error(), nil)
+		return
+	}
+
+	userID, ok := slackUser(cfg.Users, cmd.UserID)
+	if !ok {
+		authManager.LogSecurityEvent(r.Context(), "slack_user_unknown", "unknown", "slack", false, map[string]interface{}{
+			"slack_user": cmd.UserID,
+			"slack_team": cmd.TeamID,
+		})
+		replySlack(w, fmt.Sprintf("Your Slack user (%s) is not linked to a fly-mcp user. Ask an administrator to add it to integrations.slack.users.", cmd.UserID))
+		return
+	}
+	ctx := authManager.CreateAuditContext(r.Context(), userID, requestID(w, r))
+	r = r.WithContext(ctx)
+
+	if cmd.Text == "" || cmd.Text == "help" {
+		replySlack(w, s.slackHelp(ctx, cmd.Command))
+		return
+	}
+
+	inv, err := slack.Parse(cmd.Text)
+	if err != nil {
+		replySlack(w, fmt.Sprintf("Could not read the command: %v. Run `%s help` for usage.", err, cmd.Command))
+		return
+	}
+	tool, ok := s.slackTool(inv.Tool)
+	if !ok {
+		replySlack(w, fmt.Sprintf("Unknown tool `%s`. Run `%s help` to list the tools.", inv.Tool, cmd.Command))
+		return
+	}
+	args, err := inv.Arguments(tool.InputSchema())
+	if err != nil {
+		replySlack(w, fmt.Sprintf("Could not read the command: %v.", err))
+		return
+	}
+
+	// The call outlives the request when it runs longer than Slack waits
+	done := make(chan slack.Message, 1)
+	go func() {
+		done <- s.runSlackCommand(r.WithContext(context.WithoutCancel(ctx)), cmd, userID, tool, args)
+	}()
+
+	select {
+	case message := <-done:
+		writeJSON(w, http.StatusOK, message)
+	case <-time.After(slackAckTimeout):
+		writeJSON(w, http.StatusOK, slack.Message{
+			ResponseType: slack.Ephemeral,
+			Text:         fmt.Sprintf("⏳ Running `%s`…", tool.Name()),
+		})
+		go func() {
+			message := <-done
+			message.ReplaceOriginal = true
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := slack.Respond(ctx, slackClient, cmd.ResponseURL, message); err != nil {
+				s.logger.Warn().
+					Err(err).
+					Str("tool", tool.Name()).
+					Str("slack_user", cmd.UserID).
+					Msg("Failed to post Slack command result")
+			}
+		}()
+	}
+}
+
+// runSlackCommand runs a command's tool and renders the result as a reply
+func (s *Server) runSlackCommand(r *http.Request, cmd *slack.Command, userID string, tool interfaces.Tool, args map[string]interface{}) slack.Message {
+	result, err := s.mcpHandler.RunCommand(r, tool.Name(), args)
+
+	outcome := "success"
+	var text string
+	switch {
+	case err != nil:
+		outcome = "rejected"
+		text = fmt.Sprintf("❌ `%s` was not run: %v", tool.Name(), err)
+	default:
+		if result.IsError {
+			outcome = "failed"
+		}
+		var parts []string
+		for _, block := range result.Content {
+			if block.Type == "text" {
+				parts = append(parts, block.Text)
+			}
+		}
+		text = strings.Join(parts, "\n\n")
+
+		// Previews of changes are confirmed by running the command again
+		// with the token, as an assistant would call the tool again
+		if data, ok := result.StructuredContent.(map[string]interface{}); ok && data["confirmation_required"] == true {
+			outcome = "confirmation_required"
+			text += fmt.Sprintf("\n\n*To proceed from Slack*, run:\n```%s %s confirmation_token=%s```", cmd.Command, cmd.Text, data["confirmation_token"])
+		}
+	}
+
+	s.mcpHandler.AuthManager().AuditLog(r.Context(), userID, "slack_command", tool.Name(), outcome, map[string]interface{}{
+		"slack_user":      cmd.UserID,
+		"slack_user_name": cmd.UserName,
+		"slack_team":      cmd.TeamID,
+		"channel":         cmd.ChannelID,
+	})
+
+	return slack.Message{ResponseType: slack.Ephemeral, Text: slack.Markdown(text)}
+}
+
+// slackTool finds the tool a command names, with or without its fly_
+// prefix: status, fly_status and machine-events all work
+func (s *Server) slackTool(name string) (interfaces.Tool, bool) {
+	name = strings.ReplaceAll(strings.ToLower(name), "-", "_")
+	tools := s.mcpHandler.Tools()
+	if tool, ok := tools.Get(name); ok {
+		return tool, true
+	}
+	return tools.Get("fly_" + name)
+}
+
+// slackHelp lists the tools the user may run
+func (s *Server) slackHelp(ctx context.Context, command string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: `%s <tool> [app] [name=value …]`, e.g. `%s status my-app` or `%s logs my-app lines=50`. Changes show a preview first, with the command that carries them out.\n\n", command, command, command)
+	for _, tool := range s.mcpHandler.CommandTools(ctx) {
+		description, _, _ := strings.Cut(tool.Description(), "\n")
+		if before, _, ok := strings.Cut(description, ". "); ok {
+			description = before
+		}
+		fmt.Fprintf(&b, "• `%s` %s\n", strings.TrimPrefix(tool.Name(), "fly_"), strings.TrimSuffix(description, "."))
+	}
+	return b.String()
+}
+
+// slackUser returns the fly-mcp user a Slack user is mapped to. Slack user
+// IDs are upper case, but configuration keys are read in lower case.
+func slackUser(users map[string]string, slackID string) (string, bool) {
+	if slackID == "" {
+		return "", false
+	}
+	if user, ok := users[slackID]; ok && user != "" {
+		return user, true
+	}
+	user, ok := users[strings.ToLower(slackID)]
+	return user, ok && user != ""
+}
+
+// replySlack answers a command with a message only its user sees
+func replySlack(w http.ResponseWriter, text string) {
+	writeJSON(w, http.StatusOK, slack.Message{ResponseType: slack.Ephemeral, Text: slack.Markdown(text)})
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce collapses the burst of events produced by a certificate
+// rotation (write, chmod, rename) into one reload
+const reloadDebounce = 500 * time.Millisecond
+
+// certReloader serves the current TLS certificate and client CA pool and
+// reloads them when the files change on disk
+type certReloader struct {
+	cfg    config.TLSConfig
+	logger *logger.Logger
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// newCertReloader loads the configured certificate and starts watching it
+func newCertReloader(cfg config.TLSConfig, log *logger.Logger) (*certReloader, error) {
+	r := &certReloader{
+		cfg:    cfg,
+		logger: log,
+		done:   make(chan struct{}),
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate watcher: %w", err)
+	}
+
+	// Watch directories rather than files so atomic replacements (rename,
+	// symlink swaps as used by Kubernetes secrets) are noticed
+	dirs := map[string]bool{}
+	for _, file := range []string{cfg.CertFile, cfg.KeyFile, cfg.ClientCAFile} {
+		if file != "" {
+			dirs[filepath.Dir(file)] = true
+		}
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+	r.watcher = watcher
+
+	go r.watch()
+
+	return r, nil
+}
+
+// TLSConfig returns a server TLS configuration backed by the reloader
+func (r *certReloader) TLSConfig() *tls.Config {
+	base := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.Certificates = []tls.Certificate{*r.cert}
+		if r.clientCA != nil {
+			cfg.ClientCAs = r.clientCA
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			if r.cfg.ClientAuthOptional {
+				cfg.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+		}
+		return cfg, nil
+	}
+
+	return base
+}
+
+// Close stops watching for certificate changes
+func (r *certReloader) Close() error {
+	close(r.done)
+	return r.watcher.Close()
+}
+
+// reload reads the certificate, key and client CA from disk. On failure the
+// previously loaded material stays in use.
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCA = pool
+	r.mu.Unlock()
+
+	event := r.logger.Info().
+		Str("cert_file", r.cfg.CertFile).
+		Bool("mtls", pool != nil)
+	if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+		event = event.Time("not_after", leaf.NotAfter)
+	}
+	event.Msg("Loaded TLS certificate")
+
+	return nil
+}
+
+// watch reloads the certificate whenever one of the watched files changes
+func (r *certReloader) watch() {
+	var timer *time.Timer
+	watched := map[string]bool{}
+	for _, file := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if file != "" {
+			watched[filepath.Clean(file)] = true
+		}
+	}
+
+	for {
+		select {
+		case <-r.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			// Kubernetes swaps a ..data symlink, so accept any change in the
+			// directory that is not obviously unrelated
+			if !watched[filepath.Clean(event.Name)] && filepath.Base(event.Name) != "..data" {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(reloadDebounce, func() {
+				if err := r.reload(); err != nil {
+					r.logger.Error().Err(err).Msg("Failed to reload TLS certificate, keeping previous certificate")
+				}
+			})
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			r.logger.Warn().Err(err).Msg("Certificate watcher error")
+		}
+	}
+}
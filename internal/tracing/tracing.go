@@ -0,0 +1,83 @@
+// Package tracing configures OpenTelemetry tracing for MCP requests, tool
+// executions and Fly.io API calls.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by fly-mcp
+const instrumentationName = "github.com/brannn/fly-mcp"
+
+// Tracer returns the tracer used for all fly-mcp spans. Until Setup installs
+// a provider it returns a no-op tracer, so instrumented code never needs to
+// check whether tracing is enabled.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs the global tracer provider and W3C trace context
+// propagation. It returns a function that flushes pending spans and must be
+// called on shutdown. When tracing is disabled nothing is exported.
+func Setup(ctx context.Context, cfg config.TracingConfig, version string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// Without an endpoint the exporter falls back to the standard
+	// OTEL_EXPORTER_OTLP_* environment variables
+	opts := []otlptracehttp.Option{}
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("service.version", version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// RecordError marks a span as failed
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
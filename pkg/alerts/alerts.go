@@ -0,0 +1,306 @@
+// Package alerts evaluates the alert rules of the configuration against the
+// machine watcher's states and changes, and tracks the alerts they raise
+// from pending through firing to resolved.
+package alerts
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/watcher"
+)
+
+// Alert states
+const (
+	// StatePending is an alert whose condition holds, but not yet for as
+	// long as its rule requires
+	StatePending  = "pending"
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Alert is a rule's condition holding for an app, machine or check
+type Alert struct {
+	ID        string `json:"id"`
+	Rule      string `json:"rule"`
+	Condition string `json:"condition"`
+	Severity  string `json:"severity"`
+	AppName   string `json:"appName"`
+	MachineID string `json:"machineId,omitempty"`
+	Check     string `json:"check,omitempty"`
+	State     string `json:"state"`
+	Message   string `json:"message"`
+	// Since is when the condition was first seen holding
+	Since      time.Time  `json:"since"`
+	FiredAt    *time.Time `json:"firedAt,omitempty"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+
+	// lastSeen is when the condition last held
+	lastSeen time.Time
+}
+
+// Engine evaluates alert rules after every watcher poll
+type Engine struct {
+	rules   []config.AlertRule
+	history int
+	notify  func(Alert)
+
+	mu       sync.Mutex
+	active   map[string]*Alert
+	resolved []Alert
+}
+
+// New creates an engine for the configured rules. notify is called when an
+// alert fires and when a firing alert resolves.
+func New(cfg config.AlertsConfig, notify func(Alert)) *Engine {
+	return &Engine{
+		rules:   cfg.Rules,
+		history: cfg.History,
+		notify:  notify,
+		active:  make(map[string]*Alert),
+	}
+}
+
+// Rules returns the configured rules
+func (e *Engine) Rules() []config.AlertRule {
+	return e.rules
+}
+
+// Evaluate applies the rules to the watcher's states after a poll and the
+// transitions the poll found
+func (e *Engine) Evaluate(snapshot *watcher.Snapshot, transitions []watcher.Transition, now time.Time) {
+	var fired, resolved []Alert
+
+	e.mu.Lock()
+	for _, rule := range e.rules {
+		holding := make(map[string]Alert)
+		for _, alert := range match(rule, snapshot, transitions) {
+			holding[alert.ID] = alert
+		}
+		forDuration := time.Duration(rule.For) * time.Second
+		event := isEventCondition(rule.Condition)
+
+		for id, candidate := range holding {
+			alert, ok := e.active[id]
+			if !ok {
+				candidate.State = StatePending
+				candidate.Since = now
+				alert = &candidate
+				e.active[id] = alert
+			}
+			alert.Message = candidate.Message
+			alert.lastSeen = now
+
+			// Exits fire at once; states once they held long enough
+			if alert.State == StatePending && (event || now.Sub(alert.Since) >= forDuration) {
+				alert.State = StateFiring
+				firedAt := now
+				alert.FiredAt = &firedAt
+				fired = append(fired, *alert)
+			}
+		}
+
+		for id, alert := range e.active {
+			if alert.Rule != rule.Name {
+				continue
+			}
+			if _, ok := holding[id]; ok {
+				continue
+			}
+			// Exits resolve once none recurred within the rule's duration
+			if event && now.Sub(alert.lastSeen) < forDuration {
+				continue
+			}
+			delete(e.active, id)
+			if alert.State != StateFiring {
+				continue
+			}
+			alert.State = StateResolved
+			resolvedAt := now
+			alert.ResolvedAt = &resolvedAt
+			resolved = append(resolved, *alert)
+			e.resolved = append([]Alert{*alert}, e.resolved...)
+		}
+	}
+	if len(e.resolved) > e.history {
+		e.resolved = e.resolved[:e.history]
+	}
+	e.mu.Unlock()
+
+	if e.notify != nil {
+		for _, alert := range append(fired, resolved...) {
+			e.notify(alert)
+		}
+	}
+}
+
+// Active returns the pending and firing alerts of the apps include accepts,
+// firing and most severe first
+func (e *Engine) Active(include func(appName string) bool) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := []Alert{}
+	for _, alert := range e.active {
+		if include(alert.AppName) {
+			alerts = append(alerts, *alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		a, b := alerts[i], alerts[j]
+		if a.State != b.State {
+			return a.State == StateFiring
+		}
+		if severityRank(a.Severity) != severityRank(b.Severity) {
+			return severityRank(a.Severity) < severityRank(b.Severity)
+		}
+		if !a.Since.Equal(b.Since) {
+			return a.Since.Before(b.Since)
+		}
+		return a.ID < b.ID
+	})
+	return alerts
+}
+
+// Resolved returns the recently resolved alerts of the apps include
+// accepts, newest first
+func (e *Engine) Resolved(include func(appName string) bool) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := []Alert{}
+	for _, alert := range e.resolved {
+		if include(alert.AppName) {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// match returns an alert for every app, machine or check the rule's
+// condition holds for
+func match(rule config.AlertRule, snapshot *watcher.Snapshot, transitions []watcher.Transition) []Alert {
+	severity := rule.Severity
+	if severity == "" {
+		severity = "critical"
+	}
+	newAlert := func(appName, machineID, check, message string) Alert {
+		id := strings.Join(append([]string{rule.Name, appName}, nonEmpty(machineID, check)...), "/")
+		return Alert{
+			ID:        id,
+			Rule:      rule.Name,
+			Condition: rule.Condition,
+			Severity:  severity,
+			AppName:   appName,
+			MachineID: machineID,
+			Check:     check,
+			Message:   message,
+		}
+	}
+
+	var alerts []Alert
+	if isEventCondition(rule.Condition) {
+		kind := watcher.KindCrash
+		if rule.Condition == "oom_killed" {
+			kind = watcher.KindOOM
+		}
+		for _, t := range transitions {
+			if t.Kind == kind && appliesTo(rule, t.AppName) {
+				alerts = append(alerts, newAlert(t.AppName, t.MachineID, "", t.Message))
+			}
+		}
+		return alerts
+	}
+
+	for _, appName := range snapshot.Apps {
+		if !appliesTo(rule, appName) {
+			continue
+		}
+		machines := snapshot.AppMachines(appName)
+		started := 0
+		for _, m := range machines {
+			if m.State == "started" {
+				started++
+			}
+		}
+
+		switch rule.Condition {
+		case "no_started_machines":
+			if started == 0 {
+				alerts = append(alerts, newAlert(appName, "", "", fmt.Sprintf("%s has no started machines (%d machine(s) in total)", appName, len(machines))))
+			}
+		case "started_below":
+			if started < rule.Threshold {
+				alerts = append(alerts, newAlert(appName, "", "", fmt.Sprintf("%s has %d started machine(s), fewer than %d", appName, started, rule.Threshold)))
+			}
+		case "machine_state":
+			for _, m := range machines {
+				if m.State == rule.State {
+					alerts = append(alerts, newAlert(appName, m.MachineID, "", fmt.Sprintf("Machine %s of %s is %s", m.MachineID, appName, m.State)))
+				}
+			}
+		case "check_critical":
+			for _, m := range machines {
+				for name, status := range m.Checks {
+					if status == "critical" {
+						alerts = append(alerts, newAlert(appName, m.MachineID, name, fmt.Sprintf("Check %s on machine %s of %s is critical", name, m.MachineID, appName)))
+					}
+				}
+			}
+		case "check_flapping":
+			for _, m := range machines {
+				for _, name := range m.Flapping {
+					alerts = append(alerts, newAlert(appName, m.MachineID, name, fmt.Sprintf("Check %s on machine %s of %s is flapping", name, m.MachineID, appName)))
+				}
+			}
+		}
+	}
+	return alerts
+}
+
+// isEventCondition reports whether a condition is a machine exit rather
+// than a state that holds over time
+func isEventCondition(condition string) bool {
+	return condition == "oom_killed" || condition == "crashed"
+}
+
+// appliesTo reports whether a rule covers an app
+func appliesTo(rule config.AlertRule, appName string) bool {
+	if len(rule.Apps) == 0 {
+		return true
+	}
+	for _, pattern := range rule.Apps {
+		if ok, _ := path.Match(pattern, appName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// severityRank orders severities from most to least urgent
+func severityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 0
+	case "warning":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// nonEmpty returns the values that are set
+func nonEmpty(values ...string) []string {
+	var set []string
+	for _, v := range values {
+		if v != "" {
+			set = append(set, v)
+		}
+	}
+	return set
+}
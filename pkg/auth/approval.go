@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned by the approval workflow
+var (
+	ErrApprovalPending  = errors.New("operation is awaiting approval")
+	ErrApprovalDenied   = errors.New("operation was denied by an approver")
+	ErrApprovalUnknown  = errors.New("approval request is unknown or expired")
+	ErrApprovalDecided  = errors.New("approval request was already decided")
+	ErrSelfApproval     = errors.New("callers cannot approve their own operations")
+	ErrApprovalLinkSign = errors.New("approval link signature is invalid or expired")
+)
+
+// ApprovalPendingError reports an operation still awaiting approval
+type ApprovalPendingError struct {
+	ID string
+}
+
+func (e *ApprovalPendingError) Error() string {
+	return fmt.Sprintf("%v (request %s)", ErrApprovalPending, e.ID)
+}
+
+// Is makes the error match ErrApprovalPending
+func (e *ApprovalPendingError) Is(target error) bool {
+	return target == ErrApprovalPending
+}
+
+// Approval statuses
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalDenied   = "denied"
+)
+
+// ApprovePermission is the permission needed to decide approval requests
+const ApprovePermission = "approve:change"
+
+// Approval is a high-risk operation that waits for someone other than the
+// caller to approve it. It is attached to the confirmation token of the
+// operation's preview, which only works once the operation is approved.
+type Approval struct {
+	ID          string     `json:"id"`
+	Tool        string     `json:"tool"`
+	AppName     string     `json:"appName,omitempty"`
+	Summary     string     `json:"summary"`
+	RequestedBy string     `json:"requestedBy"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	Status      string     `json:"status"`
+	DecidedBy   string     `json:"decidedBy,omitempty"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+}
+
+// SetApprovalNotifier sets the function told about new approval requests,
+// e.g. to send them to webhooks
+func (m *Manager) SetApprovalNotifier(notify func(Approval)) {
+	m.mu.Lock()
+	m.approvalNotifier = notify
+	m.mu.Unlock()
+}
+
+// RequiresApproval reports whether a call of a tool on an application, ""
+// for none, matches a rule of security.approvals
+func (m *Manager) RequiresApproval(toolName, appName string) bool {
+	for _, rule := range m.config.Security.Approvals.Rules {
+		if len(rule.Tools) > 0 && !slices.Contains(rule.Tools, toolName) {
+			continue
+		}
+		if len(rule.Apps) > 0 && (appName == "" || !slices.ContainsFunc(rule.Apps, func(pattern string) bool {
+			matched, _ := path.Match(pattern, appName)
+			return matched
+		})) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// RequestApproval makes an issued confirmation token wait for approval. The
+// token then stays valid for security.approvals.ttl seconds, and
+// ConsumeConfirmation refuses it until an approver decides.
+func (m *Manager) RequestApproval(ctx context.Context, token, toolName, appName, summary string) (*Approval, error) {
+	userID, err := m.ExtractUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate approval ID: %w", err)
+	}
+
+	now := time.Now()
+	approval := &Approval{
+		ID:          "appr_" + hex.EncodeToString(b),
+		Tool:        toolName,
+		AppName:     appName,
+		Summary:     summary,
+		RequestedBy: userID,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(time.Duration(m.config.Security.Approvals.TTL) * time.Second),
+		Status:      ApprovalPending,
+	}
+
+	store := m.confirmations
+	store.mu.Lock()
+	p, ok := store.pending[token]
+	if !ok {
+		store.mu.Unlock()
+		return nil, ErrConfirmationInvalid
+	}
+	for id, a := range store.approvals {
+		if now.After(a.ExpiresAt) {
+			delete(store.approvals, id)
+		}
+	}
+	p.approvalID = approval.ID
+	p.expiresAt = approval.ExpiresAt
+	store.pending[token] = p
+	store.approvals[approval.ID] = approval
+	store.mu.Unlock()
+
+	m.AuditLog(ctx, userID, "approval_requested", toolName, "pending", map[string]interface{}{
+		"approval_id": approval.ID,
+		"app_name":    appName,
+	})
+
+	m.mu.RLock()
+	notify := m.approvalNotifier
+	m.mu.RUnlock()
+	if notify != nil {
+		notify(*approval)
+	}
+
+	return approval, nil
+}
+
+// Approvals returns the approval requests that have not expired, oldest
+// first
+func (m *Manager) Approvals() []Approval {
+	store := m.confirmations
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	approvals := make([]Approval, 0, len(store.approvals))
+	for _, a := range store.approvals {
+		if now.Before(a.ExpiresAt) {
+			approvals = append(approvals, *a)
+		}
+	}
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].RequestedAt.Before(approvals[j].RequestedAt)
+	})
+	return approvals
+}
+
+// GetApproval returns an approval request that has not expired
+func (m *Manager) GetApproval(id string) (Approval, bool) {
+	store := m.confirmations
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	a, ok := store.approvals[id]
+	if !ok || time.Now().After(a.ExpiresAt) {
+		return Approval{}, false
+	}
+	return *a, true
+}
+
+// DecideApproval approves or denies a pending request on behalf of
+// approver. Callers cannot decide their own requests.
+func (m *Manager) DecideApproval(ctx context.Context, id, approver string, approve bool, reason string) (Approval, error) {
+	store := m.confirmations
+	store.mu.Lock()
+	a, ok := store.approvals[id]
+	now := time.Now()
+	switch {
+	case !ok || now.After(a.ExpiresAt):
+		store.mu.Unlock()
+		return Approval{}, ErrApprovalUnknown
+	case a.Status != ApprovalPending:
+		store.mu.Unlock()
+		return *a, ErrApprovalDecided
+	case a.RequestedBy == approver:
+		store.mu.Unlock()
+		m.LogSecurityEvent(ctx, "self_approval_denied", approver, a.Tool, false, map[string]interface{}{
+			"approval_id": id,
+		})
+		return *a, ErrSelfApproval
+	}
+
+	a.Status = ApprovalDenied
+	if approve {
+		a.Status = ApprovalApproved
+	}
+	a.DecidedBy = approver
+	a.DecidedAt = &now
+	a.Reason = reason
+	decided := *a
+	store.mu.Unlock()
+
+	m.AuditLog(ctx, approver, "approval_"+decided.Status, decided.Tool, "success", map[string]interface{}{
+		"approval_id":  id,
+		"app_name":     decided.AppName,
+		"requested_by": decided.RequestedBy,
+		"reason":       reason,
+	})
+
+	return decided, nil
+}
+
+// approvalError returns why an operation awaiting approval id may not run
+// yet, or nil once it is approved. The store's lock must be held.
+func (s *confirmationStore) approvalError(id string) error {
+	a, ok := s.approvals[id]
+	switch {
+	case !ok:
+		return ErrApprovalUnknown
+	case a.Status == ApprovalPending:
+		return &ApprovalPendingError{ID: id}
+	case a.Status == ApprovalDenied && a.Reason != "":
+		return fmt.Errorf("%w: %s", ErrApprovalDenied, a.Reason)
+	case a.Status == ApprovalDenied:
+		return ErrApprovalDenied
+	}
+	return nil
+}
+
+// ApprovalLink returns a signed link that approves or denies a request
+// without logging in, valid until the request expires. It reports false
+// when security.approvals.link_secret or public_url is not set.
+func (m *Manager) ApprovalLink(a Approval, decision string) (string, bool) {
+	cfg := m.config.Security.Approvals
+	if cfg.LinkSecret == "" || cfg.PublicURL == "" {
+		return "", false
+	}
+
+	expires := strconv.FormatInt(a.ExpiresAt.Unix(), 10)
+	query := url.Values{
+		"expires": {expires},
+		"sig":     {m.signApprovalLink(a.ID, decision, expires)},
+	}
+	return fmt.Sprintf("%s/approvals/%s/%s?%s", strings.TrimSuffix(cfg.PublicURL, "/"), url.PathEscape(a.ID), decision, query.Encode()), true
+}
+
+// VerifyApprovalLink checks the signature and expiry of an approval link
+func (m *Manager) VerifyApprovalLink(id, decision, expires, sig string) error {
+	if m.config.Security.Approvals.LinkSecret == "" {
+		return ErrApprovalLinkSign
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().After(time.Unix(unix, 0)) {
+		return ErrApprovalLinkSign
+	}
+
+	want := m.signApprovalLink(id, decision, expires)
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return ErrApprovalLinkSign
+	}
+	return nil
+}
+
+// signApprovalLink returns the HMAC of an approval link's parameters
+func (m *Manager) signApprovalLink(id, decision, expires string) string {
+	mac := hmac.New(sha256.New, []byte(m.config.Security.Approvals.LinkSecret))
+	mac.Write([]byte(id + "\n" + decision + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Errors returned when a confirmation token cannot be used
+var (
+	ErrConfirmationInvalid  = errors.New("confirmation token is unknown or already used")
+	ErrConfirmationExpired  = errors.New("confirmation token has expired")
+	ErrConfirmationMismatch = errors.New("confirmation token was issued for a different operation")
+)
+
+// Confirmation approves one destructive operation. It is issued when the
+// operation is previewed and must be presented, unchanged, to execute it.
+type Confirmation struct {
+	Token     string    `json:"token"`
+	Summary   string    `json:"summary"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// pendingConfirmation is an issued token that has not been used yet
+type pendingConfirmation struct {
+	userID    string
+	profile   string
+	operation string
+	scope     string
+	expiresAt time.Time
+
+	// approvalID names the approval the operation waits for, if any
+	approvalID string
+}
+
+// confirmationStore holds issued tokens until they are used or expire, and
+// the approval requests some of them wait for
+type confirmationStore struct {
+	mu        sync.Mutex
+	pending   map[string]pendingConfirmation
+	approvals map[string]*Approval
+}
+
+// newConfirmationStore creates an empty token store
+func newConfirmationStore() *confirmationStore {
+	return &confirmationStore{
+		pending:   make(map[string]pendingConfirmation),
+		approvals: make(map[string]*Approval),
+	}
+}
+
+// IssueConfirmation returns a single-use token approving operation on the
+// given scope, e.g. the app and settings a restart or update applies to. The
+// token only works for the same caller, token profile, operation and scope,
+// and expires after security.confirmation_ttl seconds.
+func (m *Manager) IssueConfirmation(ctx context.Context, operation string, scope map[string]interface{}, summary string) (*Confirmation, error) {
+	userID, err := m.ExtractUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scopeHash, err := hashScope(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+
+	now := time.Now()
+	confirmation := &Confirmation{
+		Token:     "confirm_" + hex.EncodeToString(b),
+		Summary:   summary,
+		ExpiresAt: now.Add(m.confirmationTTL()),
+	}
+
+	store := m.confirmations
+	store.mu.Lock()
+	for token, p := range store.pending {
+		if now.After(p.expiresAt) {
+			delete(store.pending, token)
+		}
+	}
+	store.pending[confirmation.Token] = pendingConfirmation{
+		userID:    userID,
+		profile:   ProfileFromContext(ctx),
+		operation: operation,
+		scope:     scopeHash,
+		expiresAt: confirmation.ExpiresAt,
+	}
+	store.mu.Unlock()
+
+	m.logger.Debug().
+		Str("user_id", userID).
+		Str("operation", operation).
+		Time("expires_at", confirmation.ExpiresAt).
+		Msg("Issued confirmation token")
+
+	return confirmation, nil
+}
+
+// ConsumeConfirmation checks a token against the operation about to run and
+// invalidates it. A token is spent by any attempt to use it, so a mismatched
+// or replayed token always requires a fresh preview. The exception is an
+// operation still awaiting approval: checking on it with the right token
+// returns ErrApprovalPending and keeps the token.
+func (m *Manager) ConsumeConfirmation(ctx context.Context, token, operation string, scope map[string]interface{}) error {
+	userID, err := m.ExtractUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	scopeHash, err := hashScope(scope)
+	if err != nil {
+		return err
+	}
+
+	store := m.confirmations
+	store.mu.Lock()
+	p, ok := store.pending[token]
+	switch {
+	case !ok:
+		err = ErrConfirmationInvalid
+	case time.Now().After(p.expiresAt):
+		err = ErrConfirmationExpired
+	case p.userID != userID || p.profile != ProfileFromContext(ctx) || p.operation != operation ||
+		subtle.ConstantTimeCompare([]byte(p.scope), []byte(scopeHash)) != 1:
+		err = ErrConfirmationMismatch
+	case p.approvalID != "":
+		err = store.approvalError(p.approvalID)
+	}
+	pending := errors.Is(err, ErrApprovalPending)
+	if !pending {
+		delete(store.pending, token)
+	}
+	store.mu.Unlock()
+
+	if err != nil && !pending {
+		m.LogSecurityEvent(ctx, "confirmation_rejected", userID, operation, false, map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	return err
+}
+
+// confirmationTTL returns how long issued tokens stay valid
+func (m *Manager) confirmationTTL() time.Duration {
+	if m.config.Security.ConfirmationTTL <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(m.config.Security.ConfirmationTTL) * time.Second
+}
+
+// hashScope reduces a scope to a stable digest. Map keys are encoded in
+// sorted order, so equal scopes always hash the same.
+func hashScope(scope map[string]interface{}) (string, error) {
+	data, err := json.Marshal(scope)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode confirmation scope: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Ways a caller's identity is established
+const (
+	IdentitySourceCertificate = "client_certificate"
+	IdentitySourceAPIKey      = "api_key"
+	IdentitySourceHeader      = "identity_header"
+	IdentitySourceNone        = "none"
+)
+
+// AnonymousUser is the user of unidentified callers outside production
+const AnonymousUser = "anonymous"
+
+// ErrUnauthenticated is returned for callers that cannot be identified where
+// anonymous callers are not allowed
+var ErrUnauthenticated = errors.New("caller is not authenticated")
+
+// errInvalidAPIKey is returned for a bearer token matching no API key
+var errInvalidAPIKey = errors.New("invalid API key")
+
+// IdentifyRequest returns who sent r and how that was established. It tries,
+// in order, the verified mTLS client certificate, whose common name is the
+// user, an API key sent as a bearer token, and the identity header set by an
+// authenticating proxy. An unknown API key is an error. Callers without
+// credentials are AnonymousUser, except in production, where they get
+// ErrUnauthenticated.
+func (m *Manager) IdentifyRequest(r *http.Request) (string, string, error) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		if cn := r.TLS.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+			return cn, IdentitySourceCertificate, nil
+		}
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && len(m.config.Security.APIKeys) > 0 {
+		userID, ok := m.lookupAPIKey(strings.TrimSpace(token))
+		if !ok {
+			return "", IdentitySourceAPIKey, errInvalidAPIKey
+		}
+		return userID, IdentitySourceAPIKey, nil
+	}
+
+	if header := m.config.Security.IdentityHeader; header != "" {
+		if userID := strings.TrimSpace(r.Header.Get(header)); userID != "" {
+			return userID, IdentitySourceHeader, nil
+		}
+	}
+
+	if m.config.IsProduction() {
+		return "", IdentitySourceNone, ErrUnauthenticated
+	}
+	return AnonymousUser, IdentitySourceNone, nil
+}
+
+// lookupAPIKey returns the user of an API key. Every configured key is
+// compared, in constant time, so the time taken reveals nothing about them.
+func (m *Manager) lookupAPIKey(key string) (string, bool) {
+	digest := sha256.Sum256([]byte(key))
+
+	var userID string
+	for _, apiKey := range m.config.Security.APIKeys {
+		want, err := hex.DecodeString(apiKey.SHA256)
+		if err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare(digest[:], want) == 1 {
+			userID = apiKey.User
+		}
+	}
+	return userID, userID != ""
+}
@@ -2,25 +2,63 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/brannn/fly-mcp/internal/logger"
 	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
 )
 
 // Manager handles authentication and authorization
 type Manager struct {
 	config *config.Config
 	logger *logger.Logger
+
+	// permissions, roles, the exec allowlist and policies can be replaced
+	// at runtime by a config reload
+	mu            sync.RWMutex
+	permissions   map[string][]string
+	roles         map[string][]string
+	roleBindings  []config.RoleBinding
+	execAllowlist []string
+	policies      []config.PolicyRule
+
+	// approvalNotifier is told about new approval requests
+	approvalNotifier func(Approval)
+
+	// auditExporter is given every audit event
+	auditExporter func(AuditEvent)
+
+	// Tokens issued by destructive tools awaiting their second call
+	confirmations *confirmationStore
+
+	// What each Fly.io token may do, by token profile; "" is the
+	// configured api_token
+	tokenScopes map[string]*TokenScope
 }
 
 // NewManager creates a new authentication manager
 func NewManager(cfg *config.Config, log *logger.Logger) *Manager {
+	tokenScopes := map[string]*TokenScope{"": ParseTokenScope(cfg.Fly.APIToken)}
+	for name, profile := range cfg.Fly.Profiles {
+		tokenScopes[name] = ParseTokenScope(profile.APIToken)
+	}
+
 	return &Manager{
-		config: cfg,
-		logger: log,
+		config:        cfg,
+		logger:        log,
+		permissions:   cfg.Security.Permissions,
+		roles:         cfg.Security.Roles,
+		roleBindings:  cfg.Security.RoleBindings,
+		execAllowlist: cfg.Security.ExecAllowedCommands,
+		policies:      cfg.Security.Policies,
+		confirmations: newConfirmationStore(),
+		tokenScopes:   tokenScopes,
 	}
 }
 
@@ -31,7 +69,9 @@ func (m *Manager) ValidateAPIToken(token string) error {
 	}
 	
 	// Fly.io tokens typically start with "fo1_" for personal access tokens
-	if !strings.HasPrefix(token, "fo1_") && !strings.HasPrefix(token, "fly_") {
+	// and "fm2_" for macaroons such as deploy tokens
+	tokenType := TokenType(token)
+	if tokenType == TokenTypeLegacy && !strings.HasPrefix(token, "fly_") {
 		m.logger.Warn().
 			Str("token_prefix", getTokenPrefix(token)).
 			Msg("API token does not match expected Fly.io format")
@@ -45,35 +85,30 @@ func (m *Manager) ValidateAPIToken(token string) error {
 	m.logger.Debug().
 		Str("token_prefix", getTokenPrefix(token)).
 		Int("token_length", len(token)).
+		Str("token_type", tokenType).
 		Msg("API token format validation passed")
 	
 	return nil
 }
 
-// ValidatePermissions checks if a user has permission to perform an action
+// ValidatePermissions checks if a user has permission to perform an action,
+// on the application recorded with WithTargetApp if the call targets one
 func (m *Manager) ValidatePermissions(ctx context.Context, userID, action, resource string) error {
-	// Get user permissions from config
-	permissions, exists := m.config.Security.Permissions[userID]
-	if !exists {
-		// Check default permissions
-		permissions, exists = m.config.Security.Permissions["default"]
-		if !exists {
-			return fmt.Errorf("no permissions configured for user %s", userID)
-		}
+	// Get user permissions from config and roles
+	permissions := m.PermissionsOn(userID, TargetAppFromContext(ctx))
+	if len(permissions) == 0 {
+		return fmt.Errorf("no permissions configured for user %s", userID)
 	}
 	
 	// Check if user has the required permission
-	requiredPermission := fmt.Sprintf("%s:%s", action, resource)
-	for _, permission := range permissions {
-		if permission == requiredPermission || permission == fmt.Sprintf("%s:*", action) || permission == "*" {
-			m.logger.Debug().
-				Str("user_id", userID).
-				Str("action", action).
-				Str("resource", resource).
-				Str("permission", permission).
-				Msg("Permission granted")
-			return nil
-		}
+	if permission, ok := matchPermission(permissions, action, resource); ok {
+		m.logger.Debug().
+			Str("user_id", userID).
+			Str("action", action).
+			Str("resource", resource).
+			Str("permission", permission).
+			Msg("Permission granted")
+		return nil
 	}
 	
 	m.logger.Warn().
@@ -86,6 +121,114 @@ func (m *Manager) ValidatePermissions(ctx context.Context, userID, action, resou
 	return fmt.Errorf("insufficient permissions: user %s cannot %s on %s", userID, action, resource)
 }
 
+// EffectivePermissions returns the permissions that apply to a user on every
+// app and where they come from: the user's own entry or the "default" entry,
+// either extended by the roles granted on every app, or "role" when only
+// roles grant any, or "" if nothing does. Roles granted on some apps only
+// are left out; see PermissionsOn.
+func (m *Manager) EffectivePermissions(userID string) ([]string, string) {
+	m.mu.RLock()
+	permissions, source := m.permissions[userID], "user"
+	if _, exists := m.permissions[userID]; !exists {
+		permissions, source = m.permissions["default"], "default"
+		if _, exists := m.permissions["default"]; !exists {
+			source = ""
+		}
+	}
+	m.mu.RUnlock()
+
+	var granted []string
+	for _, grant := range m.Roles(userID) {
+		if len(grant.Apps) == 0 {
+			granted = append(granted, m.rolePermissions(grant.Role)...)
+		}
+	}
+	if len(granted) == 0 {
+		return permissions, source
+	}
+	if source == "" {
+		source = "role"
+	}
+	return append(slices.Clone(permissions), granted...), source
+}
+
+// SetPermissions replaces the permission table, e.g. after a config reload
+func (m *Manager) SetPermissions(permissions map[string][]string) {
+	m.mu.Lock()
+	m.permissions = permissions
+	m.mu.Unlock()
+}
+
+// SetExecAllowlist replaces the commands fly_ssh_exec may run
+func (m *Manager) SetExecAllowlist(commands []string) {
+	m.mu.Lock()
+	m.execAllowlist = commands
+	m.mu.Unlock()
+}
+
+// ExecAllowlist returns the commands fly_ssh_exec may run
+func (m *Manager) ExecAllowlist() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.execAllowlist
+}
+
+// AllowCommand returns the allowlist entry permitting command, if any. An
+// entry must match the command word for word, except that a trailing "*"
+// matches any remaining arguments: "ls *" allows "ls -la /data", "df -h"
+// allows only "df -h", and "*" alone allows any command.
+func (m *Manager) AllowCommand(command []string) (string, bool) {
+	for _, entry := range m.ExecAllowlist() {
+		words := strings.Fields(entry)
+		if len(words) == 0 {
+			continue
+		}
+
+		wildcard := words[len(words)-1] == "*"
+		if wildcard {
+			words = words[:len(words)-1]
+		}
+		if len(command) < len(words) || (!wildcard && len(command) != len(words)) {
+			continue
+		}
+
+		matched := true
+		for i, word := range words {
+			if command[i] != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+// IsAllowed reports whether a user may perform an action on a resource.
+// Unlike ValidatePermissions it does not log, so it is safe for introspection.
+func (m *Manager) IsAllowed(userID, action, resource string) bool {
+	permissions, _ := m.EffectivePermissions(userID)
+	_, ok := matchPermission(permissions, action, resource)
+	return ok
+}
+
+// DebugPermission is the permission needed for the /debug endpoints
+const DebugPermission = "debug:server"
+
+// matchPermission returns the first permission granting action on resource
+func matchPermission(permissions []string, action, resource string) (string, bool) {
+	requiredPermission := fmt.Sprintf("%s:%s", action, resource)
+	for _, permission := range permissions {
+		if permission == requiredPermission || permission == fmt.Sprintf("%s:*", action) || permission == "*" {
+			return permission, true
+		}
+	}
+	return "", false
+}
+
 // LogSecurityEvent logs a security-related event
 func (m *Manager) LogSecurityEvent(ctx context.Context, eventType, userID, resource string, allowed bool, details map[string]interface{}) {
 	event := m.logger.Warn()
@@ -100,6 +243,9 @@ func (m *Manager) LogSecurityEvent(ctx context.Context, eventType, userID, resou
 		Bool("allowed", allowed).
 		Str("action", "security_event")
 	
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		logEvent = logEvent.Str("request_id", requestID)
+	}
 	if details != nil {
 		logEvent = logEvent.Interface("details", details)
 	}
@@ -109,33 +255,76 @@ func (m *Manager) LogSecurityEvent(ctx context.Context, eventType, userID, resou
 
 // AuditLog logs an audit trail event
 func (m *Manager) AuditLog(ctx context.Context, userID, action, resource, result string, metadata map[string]interface{}) {
+	// A failure caused by the client aborting the call is recorded as such
+	if result == "failed" && errors.Is(context.Cause(ctx), interfaces.ErrCancelled) {
+		result = "cancelled"
+	}
+	
+	now := time.Now()
 	logEvent := m.logger.Info().
 		Str("user_id", userID).
 		Str("action", action).
 		Str("resource", resource).
 		Str("result", result).
 		Str("event_type", "audit").
-		Time("timestamp", time.Now())
+		Time("timestamp", now)
 	
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		logEvent = logEvent.Str("request_id", requestID)
+	}
 	if metadata != nil {
 		logEvent = logEvent.Interface("metadata", metadata)
 	}
 	
 	logEvent.Msg("Audit event")
+	
+	m.mu.RLock()
+	export := m.auditExporter
+	m.mu.RUnlock()
+	if export != nil {
+		export(AuditEvent{
+			Timestamp: now,
+			UserID:    userID,
+			Action:    action,
+			Resource:  resource,
+			Result:    result,
+			RequestID: requestIDFromContext(ctx),
+			Metadata:  metadata,
+		})
+	}
 }
 
-// ExtractUserFromContext extracts user information from request context
+// AuditEvent is one entry of the audit trail
+type AuditEvent struct {
+	Timestamp time.Time              `json:"timestamp"`
+	UserID    string                 `json:"user_id"`
+	Action    string                 `json:"action"`
+	Resource  string                 `json:"resource"`
+	Result    string                 `json:"result"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// SetAuditExporter sets the function given every audit event, e.g. to
+// forward them to SIEM systems
+func (m *Manager) SetAuditExporter(export func(AuditEvent)) {
+	m.mu.Lock()
+	m.auditExporter = export
+	m.mu.Unlock()
+}
+
+// ExtractUserFromContext returns the user CreateAuditContext recorded for the
+// call, as identified by IdentifyRequest
 func (m *Manager) ExtractUserFromContext(ctx context.Context) (string, error) {
-	// In a real implementation, this would extract user info from JWT token,
-	// session, or other authentication mechanism
-	
-	// For now, we'll use a simple approach with a context value
 	if userID, ok := ctx.Value("user_id").(string); ok && userID != "" {
 		return userID, nil
 	}
 	
-	// Default to anonymous user for development
-	return "anonymous", nil
+	// Calls that were never identified are anonymous only outside production
+	if m.config.IsProduction() {
+		return "", ErrUnauthenticated
+	}
+	return AnonymousUser, nil
 }
 
 // ValidateRequest performs comprehensive request validation
@@ -158,6 +347,15 @@ func (m *Manager) ValidateRequest(ctx context.Context, action, resource string)
 		return err
 	}
 	
+	// Refuse calls the Fly.io token cannot make before they reach the API
+	if err := m.TokenScope(ctx).Permits(action, resource); err != nil {
+		m.LogSecurityEvent(ctx, "token_scope_denied", userID, resource, false, map[string]interface{}{
+			"action": action,
+			"error":  err.Error(),
+		})
+		return err
+	}
+	
 	m.LogSecurityEvent(ctx, "request_authorized", userID, resource, true, map[string]interface{}{
 		"action": action,
 	})
@@ -173,6 +371,13 @@ func (m *Manager) CreateAuditContext(ctx context.Context, userID, requestID stri
 	return ctx
 }
 
+// requestIDFromContext returns the request ID set by CreateAuditContext, if
+// any
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value("request_id").(string)
+	return requestID
+}
+
 // getTokenPrefix safely extracts the first few characters of a token for logging
 func getTokenPrefix(token string) string {
 	if len(token) < 8 {
@@ -181,9 +386,15 @@ func getTokenPrefix(token string) string {
 	return token[:8] + "***"
 }
 
+// TokenScope returns what the Fly.io token of the call's token profile may do
+func (m *Manager) TokenScope(ctx context.Context) *TokenScope {
+	return m.tokenScopes[ProfileFromContext(ctx)]
+}
+
 // TokenInfo represents information about an API token
 type TokenInfo struct {
 	Prefix    string    `json:"prefix"`
+	Type      string    `json:"type"`
 	Length    int       `json:"length"`
 	Valid     bool      `json:"valid"`
 	ExpiresAt time.Time `json:"expiresAt,omitempty"`
@@ -193,9 +404,13 @@ type TokenInfo struct {
 func (m *Manager) GetTokenInfo(token string) *TokenInfo {
 	info := &TokenInfo{
 		Prefix: getTokenPrefix(token),
+		Type:   TokenType(token),
 		Length: len(token),
 		Valid:  m.ValidateAPIToken(token) == nil,
 	}
+	if info.Type == TokenTypeMacaroon {
+		info.ExpiresAt = ParseTokenScope(token).ExpiresAt
+	}
 	
 	return info
 }
@@ -220,12 +435,9 @@ const (
 
 // HasPermission checks if a user has a specific permission
 func (m *Manager) HasPermission(userID string, permission Permission) bool {
-	permissions, exists := m.config.Security.Permissions[userID]
-	if !exists {
-		permissions, exists = m.config.Security.Permissions["default"]
-		if !exists {
-			return false
-		}
+	permissions, source := m.EffectivePermissions(userID)
+	if source == "" {
+		return false
 	}
 	
 	permStr := string(permission)
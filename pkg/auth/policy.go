@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// PolicyError reports a call denied by a policy rule
+type PolicyError struct {
+	Rule    string
+	Action  string
+	AppName string
+	Reason  string
+
+	// Detail explains the condition that made the rule apply, such as the
+	// maintenance windows or change freeze in effect
+	Detail string
+}
+
+// Error describes the denial, including the rule's reason if it has one
+func (e *PolicyError) Error() string {
+	target := "this call"
+	if e.AppName != "" {
+		target = "app " + e.AppName
+	}
+
+	msg := fmt.Sprintf("policy %q does not allow %s on %s", e.Rule, e.Action, target)
+	if e.Detail != "" {
+		msg += " " + e.Detail
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// SetPolicies replaces the policy rules, e.g. after a config reload
+func (m *Manager) SetPolicies(rules []config.PolicyRule) {
+	m.mu.Lock()
+	m.policies = rules
+	m.mu.Unlock()
+}
+
+// Policies returns the policy rules
+func (m *Manager) Policies() []config.PolicyRule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.policies
+}
+
+// EvaluatePolicy checks a call against the policy rules. action is the
+// permission action of the tool, and appName the application the call
+// targets, or "" when it targets none. The first matching rule decides;
+// calls no rule matches are allowed. An elevate rule lets the call proceed
+// only if the caller holds the rule's permission.
+func (m *Manager) EvaluatePolicy(ctx context.Context, action, appName string) error {
+	userID, err := m.ExtractUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i, rule := range m.Policies() {
+		if !policyRuleMatches(rule, userID, action, appName, m.config.Environment) {
+			continue
+		}
+		active, detail := policyRuleActive(rule, now)
+		if !active {
+			continue
+		}
+
+		switch rule.Effect {
+		case "allow":
+			return nil
+		case "elevate":
+			permAction, permResource, _ := strings.Cut(rule.Permission, ":")
+			if m.IsAllowedOn(userID, permAction, permResource, appName) {
+				m.LogSecurityEvent(ctx, "policy_elevated", userID, appName, true, map[string]interface{}{
+					"action":     action,
+					"rule":       policyRuleName(rule, i),
+					"permission": rule.Permission,
+				})
+				return nil
+			}
+			detail = strings.TrimSpace(detail + fmt.Sprintf(" without the %s permission", rule.Permission))
+		}
+
+		name := policyRuleName(rule, i)
+		m.LogSecurityEvent(ctx, "policy_denied", userID, appName, false, map[string]interface{}{
+			"action": action,
+			"rule":   name,
+		})
+
+		return &PolicyError{Rule: name, Action: action, AppName: appName, Reason: rule.Reason, Detail: detail}
+	}
+	return nil
+}
+
+// policyRuleName returns a rule's name, or its position if it has none
+func policyRuleName(rule config.PolicyRule, index int) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// policyRuleActive checks a rule's time conditions at now. When they hold,
+// it also describes them so a denial can tell the caller when changes are
+// possible again.
+func policyRuleActive(rule config.PolicyRule, now time.Time) (bool, string) {
+	var details []string
+
+	if len(rule.Freezes) > 0 {
+		var active *config.ChangeFreeze
+		for i := range rule.Freezes {
+			if rule.Freezes[i].Active(now) {
+				active = &rule.Freezes[i]
+				break
+			}
+		}
+		if active == nil {
+			return false, ""
+		}
+
+		detail := fmt.Sprintf("during the change freeze until %s", active.Ends().UTC().Format(time.RFC3339))
+		if active.Reason != "" {
+			detail += fmt.Sprintf(" (%s)", active.Reason)
+		}
+		details = append(details, detail)
+	}
+
+	if len(rule.OutsideWindows) > 0 {
+		var windows []string
+		var next time.Time
+		for _, window := range rule.OutsideWindows {
+			if window.Contains(now) {
+				return false, ""
+			}
+			windows = append(windows, window.String())
+			if start := window.NextStart(now); !start.IsZero() && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+
+		detail := fmt.Sprintf("outside the maintenance windows (%s)", strings.Join(windows, "; "))
+		if !next.IsZero() {
+			detail += fmt.Sprintf("; the next window opens at %s", next.Format(time.RFC3339))
+		}
+		details = append(details, detail)
+	}
+
+	return true, strings.Join(details, " and ")
+}
+
+// policyRuleMatches reports whether a rule applies to a call. A rule that
+// names apps never matches calls that target no application.
+func policyRuleMatches(rule config.PolicyRule, userID, action, appName, environment string) bool {
+	if len(rule.Actions) > 0 && !slices.Contains(rule.Actions, action) && !slices.Contains(rule.Actions, "*") &&
+		!(action != "read" && slices.Contains(rule.Actions, "mutating")) {
+		return false
+	}
+	if len(rule.Users) > 0 && !slices.Contains(rule.Users, userID) && !slices.Contains(rule.Users, "*") {
+		return false
+	}
+	if len(rule.Environments) > 0 && !slices.Contains(rule.Environments, environment) {
+		return false
+	}
+	if len(rule.Apps) > 0 {
+		if appName == "" {
+			return false
+		}
+		return slices.ContainsFunc(rule.Apps, func(pattern string) bool {
+			matched, _ := path.Match(pattern, appName)
+			return matched
+		})
+	}
+	return true
+}
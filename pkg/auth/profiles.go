@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// profileKey carries the token profile a call acts with
+type profileKey struct{}
+
+// WithProfile records the token profile a call acts with
+func WithProfile(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, profileKey{}, name)
+}
+
+// ProfileFromContext returns the token profile a call acts with, or "" when
+// it uses the configured fly.api_token
+func ProfileFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(profileKey{}).(string)
+	return name
+}
+
+// assignedProfile returns the profile user_profiles assigns a caller, or ""
+func (m *Manager) assignedProfile(userID string) string {
+	if name, ok := m.config.Fly.UserProfiles[userID]; ok {
+		return name
+	}
+	return m.config.Fly.UserProfiles["default"]
+}
+
+// mayUseProfile reports whether a caller may act with a profile
+func (m *Manager) mayUseProfile(userID, name string) bool {
+	profile, ok := m.config.Fly.Profiles[name]
+	if !ok {
+		return false
+	}
+	return m.assignedProfile(userID) == name || slices.Contains(profile.Users, userID) || slices.Contains(profile.Users, "*")
+}
+
+// ResolveProfile returns the token profile a call acts with: requested, if
+// the caller may use it, or else the profile assigned to the caller. ""
+// stands for the configured fly.api_token.
+func (m *Manager) ResolveProfile(ctx context.Context, requested string) (string, error) {
+	userID, err := m.ExtractUserFromContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract user from context: %w", err)
+	}
+
+	if requested == "" {
+		return m.assignedProfile(userID), nil
+	}
+	if _, ok := m.config.Fly.Profiles[requested]; !ok {
+		return "", fmt.Errorf("unknown token profile %q", requested)
+	}
+	if !m.mayUseProfile(userID, requested) {
+		m.LogSecurityEvent(ctx, "profile_denied", userID, requested, false, nil)
+		return "", fmt.Errorf("user %s may not use token profile %q", userID, requested)
+	}
+	return requested, nil
+}
+
+// AvailableProfiles returns the token profiles the caller may select
+func (m *Manager) AvailableProfiles(ctx context.Context) []string {
+	userID, _ := m.ExtractUserFromContext(ctx)
+
+	var names []string
+	for name := range m.config.Fly.Profiles {
+		if m.mayUseProfile(userID, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileToken returns the API token of a profile, or "" for the configured
+// fly.api_token
+func (m *Manager) ProfileToken(name string) string {
+	return m.config.Fly.Profiles[name].APIToken
+}
+
+// ProfileNames returns the names of the configured token profiles
+func (m *Manager) ProfileNames() []string {
+	names := make([]string, 0, len(m.config.Fly.Profiles))
+	for name := range m.config.Fly.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
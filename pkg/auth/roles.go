@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"path"
+	"slices"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// RoleGrant is a role a user holds, on every app or only on the apps
+// matching Apps
+type RoleGrant struct {
+	Role string   `json:"role"`
+	Apps []string `json:"apps,omitempty"`
+}
+
+// targetAppKey carries the application a call acts on
+type targetAppKey struct{}
+
+// WithTargetApp records the application a call acts on, so roles granted
+// only on some apps apply to it
+func WithTargetApp(ctx context.Context, appName string) context.Context {
+	return context.WithValue(ctx, targetAppKey{}, appName)
+}
+
+// TargetAppFromContext returns the application a call acts on, or "" when
+// it targets none
+func TargetAppFromContext(ctx context.Context) string {
+	appName, _ := ctx.Value(targetAppKey{}).(string)
+	return appName
+}
+
+// SetRoles replaces the role definitions and bindings, e.g. after a config
+// reload
+func (m *Manager) SetRoles(roles map[string][]string, bindings []config.RoleBinding) {
+	m.mu.Lock()
+	m.roles = roles
+	m.roleBindings = bindings
+	m.mu.Unlock()
+}
+
+// Roles returns the roles a user holds: from the role bindings naming the
+// user or "*", and from the user's API keys
+func (m *Manager) Roles(userID string) []RoleGrant {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var grants []RoleGrant
+	for _, binding := range m.roleBindings {
+		if slices.Contains(binding.Users, userID) || slices.Contains(binding.Users, "*") {
+			grants = append(grants, RoleGrant{Role: binding.Role, Apps: binding.Apps})
+		}
+	}
+	for _, key := range m.config.Security.APIKeys {
+		if key.User != userID {
+			continue
+		}
+		for _, role := range key.Roles {
+			grants = append(grants, RoleGrant{Role: role})
+		}
+	}
+	return grants
+}
+
+// rolePermissions returns the permissions of a role. Configured roles
+// replace built-in ones of the same name.
+func (m *Manager) rolePermissions(role string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if permissions, ok := m.roles[role]; ok {
+		return permissions
+	}
+	return config.BuiltinRoles[role]
+}
+
+// PermissionsOn returns the permissions a user holds on an application:
+// those EffectivePermissions lists plus those of the roles granted only on
+// apps matching appName. With appName "" it equals EffectivePermissions.
+func (m *Manager) PermissionsOn(userID, appName string) []string {
+	permissions, _ := m.EffectivePermissions(userID)
+	if appName == "" {
+		return permissions
+	}
+
+	permissions = slices.Clone(permissions)
+	for _, grant := range m.Roles(userID) {
+		if len(grant.Apps) > 0 && grant.AppliesTo(appName) {
+			permissions = append(permissions, m.rolePermissions(grant.Role)...)
+		}
+	}
+	return permissions
+}
+
+// IsAllowedOn reports whether a user may perform an action on a resource of
+// an application, counting roles granted only on some apps. Like IsAllowed
+// it does not log.
+func (m *Manager) IsAllowedOn(userID, action, resource, appName string) bool {
+	_, ok := matchPermission(m.PermissionsOn(userID, appName), action, resource)
+	return ok
+}
+
+// AppliesTo reports whether a grant covers an application. Grants without
+// app patterns cover every app.
+func (g RoleGrant) AppliesTo(appName string) bool {
+	if len(g.Apps) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(g.Apps, func(pattern string) bool {
+		matched, _ := path.Match(pattern, appName)
+		return matched
+	})
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/superfly/fly-go/tokens"
+	"github.com/superfly/macaroon"
+	"github.com/superfly/macaroon/flyio"
+	"github.com/superfly/macaroon/resset"
+)
+
+// Kinds of Fly.io API token
+const (
+	// TokenTypeOAuth is a personal access token (fo1_) acting as a user
+	TokenTypeOAuth = "oauth"
+	// TokenTypeMacaroon is a FlyV1 macaroon token (fm1r_, fm2_), such as an
+	// org, deploy or read-only token, whose caveats limit what it may do
+	TokenTypeMacaroon = "macaroon"
+	// TokenTypeLegacy is any other token, e.g. an older fly_ token
+	TokenTypeLegacy = "legacy"
+)
+
+// TokenScope describes what the configured Fly.io token may do. The caveats
+// of a macaroon are read without verifying its signature, which only the
+// Fly.io API can do, so the scope is a hint for degrading gracefully rather
+// than a security boundary.
+type TokenScope struct {
+	Type string `json:"type"`
+	// ReadOnly tokens cannot change anything
+	ReadOnly bool `json:"readOnly"`
+	// AppRestricted tokens only reach specific apps, Apps of them, so they
+	// cannot list or create apps in the organization
+	AppRestricted bool      `json:"appRestricted"`
+	Apps          int       `json:"apps,omitempty"`
+	ExpiresAt     time.Time `json:"expiresAt,omitempty"`
+	// Caveats names the restrictions found in a macaroon
+	Caveats []string `json:"caveats,omitempty"`
+	// Error is set when a macaroon could not be decoded, in which case it
+	// is treated as unrestricted
+	Error string `json:"error,omitempty"`
+}
+
+// TokenError reports a call that the configured Fly.io token cannot make
+type TokenError struct {
+	Action   string
+	Resource string
+	Reason   string
+}
+
+// Error describes the denial
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("the configured Fly.io token cannot %s on %s: %s", e.Action, e.Resource, e.Reason)
+}
+
+// TokenType returns the kind of a Fly.io API token. A token string may hold
+// several comma-separated tokens; any macaroon among them makes it a
+// macaroon token.
+func TokenType(token string) string {
+	switch {
+	case len(tokens.Parse(token).GetMacaroonTokens()) > 0:
+		return TokenTypeMacaroon
+	case strings.HasPrefix(tokens.StripAuthorizationScheme(token), "fo1_"):
+		return TokenTypeOAuth
+	}
+	return TokenTypeLegacy
+}
+
+// ParseTokenScope works out what a Fly.io API token may do from its type
+// and, for macaroons, the caveats of its permission tokens
+func ParseTokenScope(token string) *TokenScope {
+	scope := &TokenScope{Type: TokenType(token)}
+	if scope.Type != TokenTypeMacaroon {
+		return scope
+	}
+
+	raw, err := macaroon.Parse(token)
+	if err != nil {
+		scope.Error = err.Error()
+		return scope
+	}
+
+	readOnly := false
+	seen := make(map[string]bool)
+	for _, buf := range raw {
+		m, err := macaroon.Decode(buf)
+		if err != nil {
+			scope.Error = err.Error()
+			return scope
+		}
+		// Discharge tokens carry the caveats of third parties such as the
+		// authentication service, not permissions
+		if m.Location != flyio.LocationPermission {
+			continue
+		}
+
+		if expires := m.Expiration(); scope.ExpiresAt.IsZero() || expires.Before(scope.ExpiresAt) {
+			scope.ExpiresAt = expires
+		}
+
+		for _, caveat := range m.UnsafeCaveats.Caveats {
+			if !seen[caveat.Name()] {
+				seen[caveat.Name()] = true
+				scope.Caveats = append(scope.Caveats, caveat.Name())
+			}
+
+			switch c := caveat.(type) {
+			case *flyio.Organization:
+				readOnly = readOnly || onlyReads(c.Mask)
+			case *resset.Action:
+				readOnly = readOnly || onlyReads(*c)
+			case *flyio.Apps:
+				scope.AppRestricted = true
+				scope.Apps = len(c.Apps)
+				writable := false
+				for _, mask := range c.Apps {
+					writable = writable || !onlyReads(mask)
+				}
+				readOnly = readOnly || !writable
+			case *resset.IfPresent:
+				// Deploy tokens allow everything on their apps and only
+				// reads elsewhere
+				for _, inner := range c.Ifs.Caveats {
+					if apps, ok := inner.(*flyio.Apps); ok && onlyReads(c.Else) {
+						scope.AppRestricted = true
+						scope.Apps = len(apps.Apps)
+					}
+				}
+			}
+		}
+	}
+
+	// A token that never expires has no validity window
+	if scope.ExpiresAt.Year() > 9999 {
+		scope.ExpiresAt = time.Time{}
+	}
+	scope.ReadOnly = readOnly
+
+	return scope
+}
+
+// onlyReads reports whether an action mask allows nothing but reads
+func onlyReads(mask resset.Action) bool {
+	return resset.IsSubsetOf(mask, resset.ActionRead)
+}
+
+// Permits reports whether the token can perform an action on a resource.
+// Read-only tokens can only read, and app-restricted tokens cannot work
+// across the organization's apps or create new ones.
+func (s *TokenScope) Permits(action, resource string) error {
+	if s == nil {
+		return nil
+	}
+	if s.ReadOnly && action != "read" {
+		return &TokenError{Action: action, Resource: resource, Reason: "the token is read-only"}
+	}
+	if s.AppRestricted && (resource == "apps" || (action == "create" && resource == "app")) {
+		return &TokenError{Action: action, Resource: resource, Reason: fmt.Sprintf("the token is restricted to %d app(s)", s.Apps)}
+	}
+	return nil
+}
+
+// Describe summarizes the scope in a short phrase
+func (s *TokenScope) Describe() string {
+	var parts []string
+	switch s.Type {
+	case TokenTypeOAuth:
+		parts = append(parts, "personal access token")
+	case TokenTypeMacaroon:
+		parts = append(parts, "macaroon token")
+	default:
+		parts = append(parts, "legacy token")
+	}
+	if s.ReadOnly {
+		parts = append(parts, "read-only")
+	}
+	if s.AppRestricted {
+		parts = append(parts, fmt.Sprintf("restricted to %d app(s)", s.Apps))
+	}
+	if !s.ExpiresAt.IsZero() {
+		parts = append(parts, "expires "+s.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,10 +1,19 @@
 package config
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/brannn/fly-mcp/pkg/credentials"
 	"github.com/spf13/viper"
 )
 
@@ -25,6 +34,18 @@ type Config struct {
 	// Logging configuration
 	Logging LoggingConfig `mapstructure:"logging"`
 	
+	// Notifications configuration
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	
+	// Audit log export to SIEM systems
+	Audit AuditConfig `mapstructure:"audit"`
+	
+	// Metrics configuration
+	Metrics MetricsConfig `mapstructure:"metrics"`
+	
+	// Integrations with services other than Fly.io
+	Integrations IntegrationsConfig `mapstructure:"integrations"`
+	
 	// Environment (local, staging, production)
 	Environment string `mapstructure:"environment"`
 }
@@ -36,21 +57,431 @@ type ServerConfig struct {
 	ReadTimeout  int    `mapstructure:"read_timeout"`
 	WriteTimeout int    `mapstructure:"write_timeout"`
 	IdleTimeout  int    `mapstructure:"idle_timeout"`
+
+	// Seconds to wait for in-flight tool executions on shutdown
+	ShutdownTimeout int `mapstructure:"shutdown_timeout"`
+
+	// TLS termination, optional
+	TLS TLSConfig `mapstructure:"tls"`
+
+	// Listen on a Unix domain socket instead of host and port, optional
+	Listen ListenConfig `mapstructure:"listen"`
+
+	// Limits protecting the server from malformed or abusive clients
+	Limits ServerLimitsConfig `mapstructure:"limits"`
+
+	// Proxies whose Fly-Client-IP header names the client, for rate limits
+	// and audit events
+	Proxy ProxyConfig `mapstructure:"proxy"`
+
+	// Runtime diagnostics for administrators, optional
+	Debug DebugConfig `mapstructure:"debug"`
+}
+
+// DebugConfig enables /debug/pprof and /debug/status, which only identified
+// callers holding the debug:server permission may use
+type DebugConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// ProxyConfig says which proxies in front of the server are trusted to
+// report the original client in Fly-Client-IP. The header of other
+// requests is ignored, since any client can send it.
+type ProxyConfig struct {
+	Fly     bool     `mapstructure:"fly"`     // trust Fly's proxy; on by default when running on Fly.io
+	Trusted []string `mapstructure:"trusted"` // addresses or CIDR ranges of other trusted proxies
+}
+
+// ServerLimitsConfig bounds what one client can make the server hold on to
+type ServerLimitsConfig struct {
+	MaxBodyBytes         int64 `mapstructure:"max_body_bytes"`         // largest MCP request body; larger ones get 413
+	MaxHeaderBytes       int   `mapstructure:"max_header_bytes"`       // largest request header block
+	ReadHeaderTimeout    int   `mapstructure:"read_header_timeout"`    // seconds a client has to send the request headers
+	MaxConcurrentStreams int   `mapstructure:"max_concurrent_streams"` // requests one HTTP/2 connection may have in flight
+}
+
+// Address returns where the server listens, e.g. for logs
+func (s ServerConfig) Address() string {
+	if s.Listen.UnixSocket != "" {
+		return "unix:" + s.Listen.UnixSocket
+	}
+	return fmt.Sprintf("%s:%d", s.Host, s.Port)
+}
+
+// ListenConfig moves the server from TCP to a Unix domain socket, so local
+// deployments need no network port. Host and port are ignored when a
+// socket is set.
+type ListenConfig struct {
+	UnixSocket string `mapstructure:"unix_socket"` // socket path, created on start and removed on shutdown
+	SocketMode string `mapstructure:"socket_mode"` // octal permissions of the socket, e.g. "0600"
+}
+
+// FileMode returns the permissions of the socket
+func (l ListenConfig) FileMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(l.SocketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q", l.SocketMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// TLSConfig contains HTTPS and mutual TLS settings. Certificate files are
+// reloaded automatically when they change on disk.
+type TLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	ClientCAFile       string `mapstructure:"client_ca_file"`       // enables mTLS when set
+	ClientAuthOptional bool   `mapstructure:"client_auth_optional"` // verify client certs only if presented
 }
 
 // FlyConfig contains Fly.io API settings
 type FlyConfig struct {
-	APIToken     string `mapstructure:"api_token"`
-	Organization string `mapstructure:"organization"`
-	BaseURL      string `mapstructure:"base_url"`
-	Timeout      int    `mapstructure:"timeout"`
+	APIToken      string `mapstructure:"api_token"`
+	Organization  string `mapstructure:"organization"`
+	BaseURL       string `mapstructure:"base_url"`       // Machines API
+	APIURL        string `mapstructure:"api_url"`        // GraphQL API
+	PrometheusURL string `mapstructure:"prometheus_url"` // Hosted metrics API
+	RegistryURL   string `mapstructure:"registry_url"`   // Image registry
+	Timeout       int    `mapstructure:"timeout"`
+
+	// TokenSource reads the API token from the OS keychain, an encrypted
+	// file or a command when api_token is not set
+	TokenSource credentials.Source `mapstructure:"token_source"`
+
+	// Pricing used for cost estimates
+	Pricing PricingConfig `mapstructure:"pricing"`
+
+	// Profiles are named tokens that calls can act with instead of
+	// api_token, e.g. a read-only token for production and an admin token
+	// for staging
+	Profiles map[string]TokenProfile `mapstructure:"profiles"`
+
+	// UserProfiles maps a caller to the profile their calls use unless they
+	// select another; the "default" entry applies to callers without one.
+	// Callers with no entry use api_token.
+	UserProfiles map[string]string `mapstructure:"user_profiles"`
+
+	// Cassette records Fly.io API calls to a file, or answers them from an
+	// earlier recording instead of calling Fly.io
+	Cassette CassetteConfig `mapstructure:"cassette"`
+
+	// HTTP tunes the connections the Fly.io API clients share
+	HTTP HTTPConfig `mapstructure:"http"`
 }
 
+// HTTPConfig tunes the connection pool shared by the Fly.io API clients.
+// Zero pool settings use Go's defaults for that setting.
+type HTTPConfig struct {
+	MaxIdleConns        int `mapstructure:"max_idle_conns"`          // idle connections kept across all hosts
+	MaxIdleConnsPerHost int `mapstructure:"max_idle_conns_per_host"` // idle connections kept per host
+	MaxConnsPerHost     int `mapstructure:"max_conns_per_host"`      // 0 for no limit
+	IdleConnTimeout     int `mapstructure:"idle_conn_timeout"`       // seconds an idle connection is kept
+	// RequestTimeout bounds each attempt of a request in seconds, so that a
+	// stalled attempt is retried within the overall timeout; 0 for none
+	RequestTimeout int `mapstructure:"request_timeout"`
+	// DNSCacheTTL is how many seconds resolved API addresses are reused;
+	// 0 resolves on every new connection
+	DNSCacheTTL  int  `mapstructure:"dns_cache_ttl"`
+	DisableHTTP2 bool `mapstructure:"disable_http2"`
+}
+
+// Cassette modes
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+// CassetteConfig selects recording or replay of Fly.io API calls. Recorded
+// calls have tokens and secret values masked, so a cassette can be attached
+// to a bug report.
+type CassetteConfig struct {
+	Mode string `mapstructure:"mode"` // record, replay, or empty for neither
+	Path string `mapstructure:"path"`
+}
+
+// TokenProfile is a named Fly.io API token
+type TokenProfile struct {
+	APIToken    string             `mapstructure:"api_token"`
+	TokenSource credentials.Source `mapstructure:"token_source"`
+	// Users lists the callers that may select the profile; "*" allows
+	// every caller. Callers may always use the profile user_profiles
+	// assigns them.
+	Users []string `mapstructure:"users"`
+}
+
+// PricingConfig contains the monthly prices (USD) used to estimate app costs.
+// Defaults track Fly.io's published pricing and can be overridden per deployment.
+type PricingConfig struct {
+	SharedCPU           float64 `mapstructure:"shared_cpu"`            // per shared vCPU, includes shared_memory_mb
+	SharedMemoryMB      int     `mapstructure:"shared_memory_mb"`      // memory included per shared vCPU
+	PerformanceCPU      float64 `mapstructure:"performance_cpu"`       // per performance vCPU, includes performance_memory_mb
+	PerformanceMemoryMB int     `mapstructure:"performance_memory_mb"` // memory included per performance vCPU
+	MemoryGB            float64 `mapstructure:"memory_gb"`             // per GB of additional memory
+	VolumeGB            float64 `mapstructure:"volume_gb"`             // per GB of provisioned volume
+	DedicatedIPv4       float64 `mapstructure:"dedicated_ipv4"`        // per dedicated IPv4 address
+}
+
+// ProtocolVersions are the MCP protocol revisions the server speaks, oldest
+// first
+var ProtocolVersions = []string{"2024-11-05", "2025-03-26", "2025-06-18"}
+
 // MCPConfig contains MCP protocol settings
 type MCPConfig struct {
+	// Version is the newest protocol revision offered. Clients asking for
+	// an older revision in ProtocolVersions are served that one.
 	Version     string            `mapstructure:"version"`
 	ServerInfo  MCPServerInfo     `mapstructure:"server_info"`
 	Capabilities MCPCapabilities `mapstructure:"capabilities"`
+	
+	// PageSize is the number of items returned per page by tools/list and
+	// resources/list
+	PageSize int `mapstructure:"page_size"`
+
+	// SessionTimeout is how long, in seconds, an idle MCP session keeps its
+	// context before it expires
+	SessionTimeout int `mapstructure:"session_timeout"`
+
+	// Tools selects which tools the server offers
+	Tools MCPToolsConfig `mapstructure:"tools"`
+
+	// OutputFormat is the format of tool output when a call does not pass
+	// one: text, json or table
+	OutputFormat string `mapstructure:"output_format"`
+
+	// OutputStyle is the decoration of text output: rich markdown with
+	// emoji, plain ASCII text, or minimal text without advisory sections
+	OutputStyle string `mapstructure:"output_style"`
+
+	// ResponseLimits bounds the size of tool responses
+	ResponseLimits ResponseLimitsConfig `mapstructure:"response_limits"`
+
+	// Idempotency keeps the results of mutating calls made with an
+	// idempotency key, to answer retries of them
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+
+	// Journal records the changes tools make, for fly_undo
+	Journal JournalConfig `mapstructure:"journal"`
+
+	// Batch bounds JSON-RPC batch requests
+	Batch BatchConfig `mapstructure:"batch"`
+
+	// Elicitation lets tool calls ask the user for missing arguments
+	Elicitation ElicitationConfig `mapstructure:"elicitation"`
+
+	// Sampling lets tools run prompts on the client's model, e.g. to
+	// summarize large outputs
+	Sampling SamplingConfig `mapstructure:"sampling"`
+
+	// LogTail bounds how long fly_logs follows an app's logs
+	LogTail LogTailConfig `mapstructure:"log_tail"`
+
+	// Wait bounds how long fly_wait waits for a condition
+	Wait WaitConfig `mapstructure:"wait"`
+
+	// ToolTimeouts bounds how long a tool call may run
+	ToolTimeouts ToolTimeoutsConfig `mapstructure:"tool_timeouts"`
+
+	// Reports snapshots the organization's health in the background
+	Reports ReportsConfig `mapstructure:"reports"`
+
+	// Watcher polls machine states in the background and reports changes
+	Watcher WatcherConfig `mapstructure:"watcher"`
+
+	// Alerts are rules evaluated on every watcher poll
+	Alerts AlertsConfig `mapstructure:"alerts"`
+}
+
+// AlertsConfig holds the alert rules evaluated against the machine watcher's
+// states. Alerts are posted to the notification webhooks when they fire and
+// when they resolve, and listed by fly_alerts.
+type AlertsConfig struct {
+	Rules []AlertRule `mapstructure:"rules"`
+	// History is how many resolved alerts fly_alerts keeps
+	History int `mapstructure:"history"`
+}
+
+// AlertRule describes when an alert fires
+type AlertRule struct {
+	Name string `mapstructure:"name"`
+	// Apps limits the rule to these app name patterns; empty means every
+	// watched app
+	Apps []string `mapstructure:"apps"`
+	// Condition is one of no_started_machines, started_below,
+	// machine_state, check_critical, check_flapping, oom_killed or crashed
+	Condition string `mapstructure:"condition"`
+	// Threshold is the started machine count below which started_below
+	// holds
+	Threshold int `mapstructure:"threshold"`
+	// State is the machine state machine_state looks for, e.g. stopped
+	State string `mapstructure:"state"`
+	// For is how long, in seconds, a condition must hold before the alert
+	// fires. oom_killed and crashed fire on the exit and resolve after For
+	// seconds without another one.
+	For int `mapstructure:"for"`
+	// Severity is critical, warning or info
+	Severity string `mapstructure:"severity"`
+}
+
+// AlertConditions lists the conditions an alert rule can use
+var AlertConditions = []string{"no_started_machines", "started_below", "machine_state", "check_critical", "check_flapping", "oom_killed", "crashed"}
+
+// WatcherConfig controls the machine watcher, which polls the machines of
+// selected apps and reports state changes and flapping health checks to
+// resource subscribers and webhooks
+type WatcherConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Apps lists the apps to watch, as names or patterns ("api-*"); empty
+	// means every app
+	Apps []string `mapstructure:"apps"`
+	// Interval is the time between polls in seconds
+	Interval int `mapstructure:"interval"`
+	// A health check that changes status FlapThreshold times within
+	// FlapWindow seconds is flapping
+	FlapThreshold int `mapstructure:"flap_threshold"`
+	FlapWindow    int `mapstructure:"flap_window"`
+	// Webhook posts changes to the notification webhooks as alerts
+	Webhook bool `mapstructure:"webhook"`
+}
+
+// ReportsConfig controls scheduled status reports, served as the
+// fly://reports/latest resource so assistants can start from an overview
+type ReportsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is the time between reports in seconds
+	Interval int `mapstructure:"interval"`
+	// Webhook also posts each report to the notification webhooks
+	Webhook bool `mapstructure:"webhook"`
+}
+
+// LogTailConfig controls the follow mode of fly_logs, which streams new log
+// lines as they arrive
+type LogTailConfig struct {
+	// MaxDuration is the longest, in seconds, a call may follow the logs
+	MaxDuration int `mapstructure:"max_duration"`
+	// PollInterval is how often, in seconds, new lines are fetched
+	PollInterval int `mapstructure:"poll_interval"`
+}
+
+// WaitConfig controls fly_wait, which polls an app until a condition holds
+type WaitConfig struct {
+	// MaxTimeout is the longest, in seconds, a call may wait
+	MaxTimeout int `mapstructure:"max_timeout"`
+	// PollInterval is how often, in seconds, the condition is checked
+	PollInterval int `mapstructure:"poll_interval"`
+}
+
+// ToolTimeoutsConfig holds the time budgets of tool calls. A call that runs
+// out of budget is stopped and answers with what it has so far. Tools that
+// keep their response open on purpose, such as fly_wait, get as long as they
+// ask for.
+type ToolTimeoutsConfig struct {
+	// Read is the budget, in seconds, of calls that only read; 0 for none
+	Read int `mapstructure:"read"`
+	// Mutating is the budget of calls that change infrastructure. It is
+	// none (0) by default, since a stopped rollout is left half applied.
+	Mutating int `mapstructure:"mutating"`
+	// Tools overrides the budget of individual tools by name; 0 for none
+	Tools map[string]int `mapstructure:"tools"`
+}
+
+// SamplingConfig controls the prompts tools run on the client's model, on
+// clients that support sampling
+type SamplingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Timeout is how long, in seconds, a call waits for the model's answer
+	Timeout int `mapstructure:"timeout"`
+	// MaxTokens bounds the length of the model's answers
+	MaxTokens int `mapstructure:"max_tokens"`
+}
+
+// ElicitationConfig controls how tool calls missing required arguments ask
+// the user for them, on clients that support elicitation
+type ElicitationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Timeout is how long, in seconds, a call waits for the user's answer
+	// before it runs without it
+	Timeout int `mapstructure:"timeout"`
+}
+
+// BatchConfig bounds JSON-RPC batch requests, which carry several requests
+// in one HTTP POST
+type BatchConfig struct {
+	// MaxRequests is the most requests one batch may carry
+	MaxRequests int `mapstructure:"max_requests"`
+	// Concurrency is how many requests of a batch are handled at once
+	Concurrency int `mapstructure:"concurrency"`
+}
+
+// MinResponseBytes is the smallest response limit allowed: a truncated
+// response needs room for a useful part of the text besides the note on
+// how to fetch the rest
+const MinResponseBytes = 4096
+
+// ResponseLimitsConfig bounds the size of tool responses. Longer responses
+// are truncated, and the rest is fetched by calling the tool again with the
+// continuation the truncated response ends with.
+type ResponseLimitsConfig struct {
+	// MaxBytes is the largest text response sent at once, at least
+	// MinResponseBytes; 0 disables the limit
+	MaxBytes int `mapstructure:"max_bytes"`
+	// Tools overrides MaxBytes for individual tools
+	Tools map[string]int `mapstructure:"tools"`
+	// ContinuationTTL is how long, in seconds, the rest of a truncated
+	// response can be fetched
+	ContinuationTTL int `mapstructure:"continuation_ttl"`
+}
+
+// Limit returns the largest response a tool may send at once, or 0 if its
+// responses are not limited
+func (l ResponseLimitsConfig) Limit(tool string) int {
+	if limit, ok := l.Tools[tool]; ok {
+		return limit
+	}
+	return l.MaxBytes
+}
+
+// IdempotencyConfig controls the idempotency_key argument of mutating tools.
+// A retry with the same key and arguments gets the result of the completed
+// call instead of running it again.
+type IdempotencyConfig struct {
+	// TTL is how long, in seconds, the result of a call is kept for retries
+	TTL int `mapstructure:"ttl"`
+}
+
+// JournalConfig controls the operation journal, which records the changes
+// fly_env, fly_deploy and fly_autoscale make so that fly_undo can revert
+// them. It is kept in memory and starts empty when the server restarts.
+type JournalConfig struct {
+	// Size is how many operations are kept, across all apps
+	Size int `mapstructure:"size"`
+}
+
+// MCPToolsConfig enables and disables tools by name. Names may use path
+// patterns, e.g. "fly_*". Tools left out are neither listed nor callable.
+type MCPToolsConfig struct {
+	// Enabled, when not empty, lists the only tools offered
+	Enabled []string `mapstructure:"enabled"`
+	// Disabled tools are never offered, even when enabled
+	Disabled []string `mapstructure:"disabled"`
+}
+
+// Allows reports whether a tool is offered
+func (t MCPToolsConfig) Allows(name string) bool {
+	if len(t.Enabled) > 0 && !matchesAny(t.Enabled, name) {
+		return false
+	}
+	return !matchesAny(t.Disabled, name)
+}
+
+// matchesAny reports whether a name matches one of a list of patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
 }
 
 // MCPServerInfo contains server identification
@@ -82,10 +513,159 @@ type MCPPromptsCapability struct {
 // SecurityConfig contains security settings
 type SecurityConfig struct {
 	RateLimitEnabled bool              `mapstructure:"rate_limit_enabled"`
-	RateLimitRPS     int               `mapstructure:"rate_limit_rps"`
+	RateLimitRPS     int               `mapstructure:"rate_limit_rps"` // per client address
+	ToolRateLimits   ToolRateLimitConfig `mapstructure:"tool_rate_limits"`
 	AuditLogEnabled  bool              `mapstructure:"audit_log_enabled"`
 	AllowedOrigins   []string          `mapstructure:"allowed_origins"`
 	Permissions      map[string][]string `mapstructure:"permissions"`
+	
+	// ExecAllowedCommands lists the commands fly_ssh_exec may run. Entries
+	// match word for word; a trailing "*" matches any remaining arguments.
+	// When empty, fly_ssh_exec refuses every command.
+	ExecAllowedCommands []string `mapstructure:"exec_allowed_commands"`
+	
+	// ConfirmationTTL is how long, in seconds, a confirmation token issued
+	// by a destructive tool's preview stays valid
+	ConfirmationTTL int `mapstructure:"confirmation_ttl"`
+	
+	// Approvals make high-risk tool calls wait until someone other than
+	// the caller approves them
+	Approvals ApprovalConfig `mapstructure:"approvals"`
+	
+	// Policies restrict which applications callers may act on, on top of
+	// permissions. Rules are evaluated in order and the first match decides;
+	// calls no rule matches are allowed.
+	Policies []PolicyRule `mapstructure:"policies"`
+	
+	// Roles defines named permission sets in addition to BuiltinRoles; a
+	// definition with a built-in role's name replaces it
+	Roles map[string][]string `mapstructure:"roles"`
+	
+	// RoleBindings grant roles to users, on top of their permissions entry
+	RoleBindings []RoleBinding `mapstructure:"role_bindings"`
+	
+	// APIKeys identify callers that send "Authorization: Bearer <key>"
+	APIKeys []APIKey `mapstructure:"api_keys"`
+	
+	// IdentityHeader names a header in which an authenticating proxy in
+	// front of the server, such as an OAuth2 proxy, passes the caller's
+	// user or email claim. Only set it when clients cannot reach the server
+	// except through that proxy.
+	IdentityHeader string `mapstructure:"identity_header"`
+}
+
+// APIKey identifies the callers presenting a key as User. Only the key's
+// SHA-256 digest is configured, so the config file holds no secret.
+type APIKey struct {
+	User   string `mapstructure:"user"`
+	SHA256 string `mapstructure:"sha256"` // hex encoded
+	
+	// Roles are granted to the key's user on every app
+	Roles []string `mapstructure:"roles"`
+}
+
+// ApprovalConfig selects the tool calls that need approval before they run.
+// Only tools that preview their changes and ask for confirmation take part.
+type ApprovalConfig struct {
+	// Rules select the calls; a call matching any rule needs approval
+	Rules []ApprovalRule `mapstructure:"rules"`
+	
+	// TTL is how long, in seconds, a request waits for a decision, and an
+	// approved call stays ready to run
+	TTL int `mapstructure:"ttl"`
+	
+	// LinkSecret signs the approve and deny links sent to webhooks. Links
+	// are only sent when it and PublicURL are set.
+	LinkSecret string `mapstructure:"link_secret"`
+	
+	// PublicURL is the base URL approvers reach the server at, e.g.
+	// https://fly-mcp.example.com
+	PublicURL string `mapstructure:"public_url"`
+}
+
+// ApprovalRule selects tool calls by tool name and app name pattern. Empty
+// lists match everything; a rule that names apps never matches calls that
+// target no application.
+type ApprovalRule struct {
+	Tools []string `mapstructure:"tools"`
+	Apps  []string `mapstructure:"apps"`
+}
+
+// BuiltinRoles are the roles available without defining them, from least
+// to most privileged
+var BuiltinRoles = map[string][]string{
+	"viewer":   {"read:*"},
+	"operator": {"read:*", "restart:app", "scale:app", "undo:app"},
+	"deployer": {"read:*", "restart:app", "scale:app", "undo:app", "deploy:app", "create:app", "set:env", "schedule:machine", "batch:apps"},
+	"admin":    {"*"},
+}
+
+// RoleBinding grants a role to users, on every app or only on the apps
+// matching Apps. Calls that target no app, such as listing apps, need a
+// grant on every app.
+type RoleBinding struct {
+	Role  string   `mapstructure:"role"`
+	Users []string `mapstructure:"users"` // user IDs, or "*" for every caller
+	Apps  []string `mapstructure:"apps"`  // app name patterns, e.g. "staging-*"
+}
+
+// HasRole reports whether a role is built in or defined in security.roles
+func (s *SecurityConfig) HasRole(name string) bool {
+	if _, ok := s.Roles[name]; ok {
+		return true
+	}
+	_, ok := BuiltinRoles[name]
+	return ok
+}
+
+// HasIdentitySource reports whether callers can be identified: by API key,
+// by a proxy's identity header or by mTLS client certificate
+func (c *Config) HasIdentitySource() bool {
+	return len(c.Security.APIKeys) > 0 || c.Security.IdentityHeader != "" || c.Server.TLS.ClientCAFile != ""
+}
+
+// PolicyRule allows or denies actions on matching applications. Empty lists
+// match everything.
+type PolicyRule struct {
+	Name   string `mapstructure:"name"`
+	Effect string `mapstructure:"effect"` // allow, deny or elevate
+	
+	// Permission is required by elevate rules: matching calls proceed only
+	// for callers that hold it, e.g. "override:freeze"
+	Permission string `mapstructure:"permission"`
+	
+	// Apps lists application name patterns, e.g. "prod-*" or "staging-?"
+	Apps []string `mapstructure:"apps"`
+	
+	// Actions lists the permission actions the rule covers, e.g. restart,
+	// scale, delete or read; "mutating" covers every action except read
+	Actions []string `mapstructure:"actions"`
+	
+	// Users lists the callers the rule applies to
+	Users []string `mapstructure:"users"`
+	
+	// Environments lists the server environments (local, production) in
+	// which the rule applies
+	Environments []string `mapstructure:"environments"`
+	
+	// OutsideWindows limits the rule to times outside all of these
+	// maintenance windows
+	OutsideWindows []MaintenanceWindow `mapstructure:"outside_windows"`
+	
+	// Freezes limits the rule to times inside one of these change freezes
+	Freezes []ChangeFreeze `mapstructure:"freezes"`
+	
+	// Reason is shown to the caller when the rule denies a call
+	Reason string `mapstructure:"reason"`
+}
+
+// ToolRateLimitConfig contains per-client limits for tool calls. Mutating
+// tools (restart, scale, delete, ...) are limited separately from reads.
+type ToolRateLimitConfig struct {
+	ReadRPS       float64 `mapstructure:"read_rps"`
+	ReadBurst     int     `mapstructure:"read_burst"`
+	MutatingRPS   float64 `mapstructure:"mutating_rps"`
+	MutatingBurst int     `mapstructure:"mutating_burst"`
 }
 
 // LoggingConfig contains logging settings
@@ -94,45 +674,187 @@ type LoggingConfig struct {
 	Format     string `mapstructure:"format"` // json or text
 	Output     string `mapstructure:"output"` // stdout, stderr, or file path
 	Structured bool   `mapstructure:"structured"`
+	
+	// RedactKeys lists extra field names whose values are masked in log
+	// output, in addition to api_token, authorization, secrets and similar
+	RedactKeys []string `mapstructure:"redact_keys"`
+	
+	// Tracing configures OpenTelemetry spans for MCP requests, tool
+	// executions and Fly.io API calls
+	Tracing TracingConfig `mapstructure:"tracing"`
 }
 
-// Load loads configuration from various sources
-func Load() (*Config, error) {
-	v := viper.New()
+// NotificationsConfig contains the webhooks told about mutating tool calls
+type NotificationsConfig struct {
+	Webhooks []WebhookConfig `mapstructure:"webhooks"`
+}
+
+// WebhookConfig describes one notification endpoint
+type WebhookConfig struct {
+	Name    string            `mapstructure:"name"`
+	URL     string            `mapstructure:"url"`
+	Type    string            `mapstructure:"type"` // slack or generic
+	Headers map[string]string `mapstructure:"headers"`
 	
-	// Set defaults
-	setDefaults(v)
+	// Results limits notifications to these outcomes (success, failed,
+	// cancelled, pending_approval, report, alert, resolved); empty means all
+	Results []string `mapstructure:"results"`
 	
-	// Set config name and paths
-	v.SetConfigName("config")
-	v.SetConfigType("yaml")
-	v.AddConfigPath(".")
-	v.AddConfigPath("./configs")
-	v.AddConfigPath("/etc/fly-mcp")
+	// Timeout is the delivery timeout in seconds
+	Timeout int `mapstructure:"timeout"`
+}
+
+// MetricsConfig contains where the server's own metrics go besides the
+// /metrics scrape endpoint
+type MetricsConfig struct {
+	RemoteWrite RemoteWriteConfig `mapstructure:"remote_write"`
+}
+
+// RemoteWriteConfig configures pushing the server's metrics to a Prometheus
+// remote-write endpoint, such as Grafana Cloud, Mimir or a Prometheus
+// started with --web.enable-remote-write-receiver
+type RemoteWriteConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	URL     string `mapstructure:"url"`
 	
-	// Environment variable support
-	v.SetEnvPrefix("FLY_MCP")
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	v.AutomaticEnv()
+	// Username and Password authenticate with basic auth, e.g. a Grafana
+	// Cloud instance ID and API key; BearerToken with a bearer token
+	Username    string            `mapstructure:"username"`
+	Password    string            `mapstructure:"password"`
+	BearerToken string            `mapstructure:"bearer_token"`
+	Headers     map[string]string `mapstructure:"headers"`
 	
-	// Try to read config file
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("error reading config file: %w", err)
-		}
-		// Config file not found is OK, we'll use defaults and env vars
-	}
+	// Interval is how many seconds pass between pushes, and Timeout how
+	// long a push may take
+	Interval int `mapstructure:"interval"`
+	Timeout  int `mapstructure:"timeout"`
 	
+	// Labels are added to every series; job is fly-mcp and instance the
+	// host name unless set here
+	Labels map[string]string `mapstructure:"labels"`
+}
+
+// labelNamePattern matches valid Prometheus label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// AuditConfig contains where audit events are exported to besides the log
+type AuditConfig struct {
+	Exporters []AuditExporterConfig `mapstructure:"exporters"`
+}
+
+// AuditExporterConfig describes one SIEM destination audit events are
+// forwarded to
+type AuditExporterConfig struct {
+	Name string `mapstructure:"name"`
+	
+	// Format is syslog (RFC 5424), cef (ArcSight CEF in syslog messages)
+	// or jsonl (JSON Lines posted over HTTPS)
+	Format string `mapstructure:"format"`
+	
+	// Address is the syslog receiver of syslog and cef exporters, as
+	// udp://, tcp:// or tls://host:port
+	Address string `mapstructure:"address"`
+	
+	// URL and Headers are where jsonl exporters post batches, e.g. a
+	// Splunk HEC or Elastic endpoint and its authorization header
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"`
+	
+	// Facility is the syslog facility code, 1 to 23; 13 (log audit) when
+	// unset
+	Facility int `mapstructure:"facility"`
+	
+	// BatchSize is the most events sent at once, 100 when unset, and
+	// FlushInterval how many seconds events wait for a batch to fill, 5
+	// when unset
+	BatchSize     int `mapstructure:"batch_size"`
+	FlushInterval int `mapstructure:"flush_interval"`
+	
+	// MaxRetries is how often a failed delivery is retried, with growing
+	// delays, before its events are dropped; 3 when unset
+	MaxRetries int `mapstructure:"max_retries"`
+	
+	// Timeout is the delivery timeout in seconds, 10 when unset
+	Timeout int `mapstructure:"timeout"`
+}
+
+// IntegrationsConfig contains the services fly-mcp works with besides
+// Fly.io
+type IntegrationsConfig struct {
+	GitHub GitHubConfig `mapstructure:"github"`
+	CI     CIConfig     `mapstructure:"ci"`
+	Slack  SlackConfig  `mapstructure:"slack"`
+}
+
+// SlackConfig configures the /slack endpoint that runs tools for a Slack
+// slash command, e.g. /fly status my-app
+type SlackConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SigningSecret is the Slack app's signing secret, which requests are
+	// verified with
+	SigningSecret string `mapstructure:"signing_secret"`
+	// Users maps Slack user IDs to the fly-mcp users their commands run as;
+	// commands from other Slack users are refused
+	Users map[string]string `mapstructure:"users"`
+}
+
+// CIConfig configures the /hooks/ci endpoint CI systems post build and
+// deploy events to, served as each app's fly://apps/{name}/pipeline resource
+type CIConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Secret is the bearer token CI systems authenticate with
+	Secret string `mapstructure:"secret"`
+	// History is how many events are kept per app
+	History int `mapstructure:"history"`
+}
+
+// GitHubConfig configures deploying from GitHub repositories with fly_deploy
+type GitHubConfig struct {
+	// Token is a GitHub token that can read the repositories' contents and
+	// run their Actions workflows; the integration is off without one
+	Token  string `mapstructure:"token"`
+	APIURL string `mapstructure:"api_url"`
+	
+	// Workflow is the workflow file that builds and pushes an image with
+	// Fly.io's remote builders when dispatched with app, image_label and
+	// sha inputs
+	Workflow string `mapstructure:"workflow"`
+	
+	// BuildTimeout is how long a build may take, in seconds, and
+	// PollInterval how often its run is checked
+	BuildTimeout int `mapstructure:"build_timeout"`
+	PollInterval int `mapstructure:"poll_interval"`
+	
+	// Repositories limits deploys to repositories matching these owner/name
+	// patterns, e.g. my-org/*; empty allows any the token can access
+	Repositories []string `mapstructure:"repositories"`
+}
+
+// TracingConfig represents OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Enabled     bool              `mapstructure:"enabled"`
+	Endpoint    string            `mapstructure:"endpoint"` // OTLP/HTTP collector URL, e.g. http://localhost:4318
+	Insecure    bool              `mapstructure:"insecure"` // send spans over plain HTTP
+	Headers     map[string]string `mapstructure:"headers"`  // extra exporter headers, e.g. an API key
+	SampleRatio float64           `mapstructure:"sample_ratio"`
+	ServiceName string            `mapstructure:"service_name"`
+}
+
+// Load loads configuration from various sources
+func Load() (*Config, error) {
+	return NewLoader("").Load()
+}
+
+// Defaults returns the configuration made of default values only, without
+// validating it, e.g. as the starting point of `fly-mcp init`
+func Defaults() (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+
 	var config Config
 	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
-	
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
-	}
-	
 	return &config, nil
 }
 
@@ -144,31 +866,125 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.read_timeout", 30)
 	v.SetDefault("server.write_timeout", 30)
 	v.SetDefault("server.idle_timeout", 120)
+	v.SetDefault("server.shutdown_timeout", 30)
+	v.SetDefault("server.tls.enabled", false)
+	v.SetDefault("server.limits.max_body_bytes", 4<<20)
+	v.SetDefault("server.limits.max_header_bytes", 64<<10)
+	v.SetDefault("server.limits.read_header_timeout", 10)
+	v.SetDefault("server.limits.max_concurrent_streams", 16)
+	v.SetDefault("server.proxy.fly", os.Getenv("FLY_APP_NAME") != "")
+	v.SetDefault("server.proxy.trusted", []string{})
+	v.SetDefault("server.listen.unix_socket", "")
+	v.SetDefault("server.listen.socket_mode", "0600")
+	v.SetDefault("server.debug.enabled", false)
 	
 	// Fly.io defaults
 	v.SetDefault("fly.base_url", "https://api.machines.dev")
+	v.SetDefault("fly.api_url", "https://api.fly.io")
+	v.SetDefault("fly.prometheus_url", "https://api.fly.io/prometheus")
+	v.SetDefault("fly.registry_url", "https://registry.fly.io")
 	v.SetDefault("fly.timeout", 30)
+	v.SetDefault("fly.http.max_idle_conns", 100)
+	v.SetDefault("fly.http.max_idle_conns_per_host", 16)
+	v.SetDefault("fly.http.max_conns_per_host", 0)
+	v.SetDefault("fly.http.idle_conn_timeout", 90)
+	v.SetDefault("fly.http.request_timeout", 20)
+	v.SetDefault("fly.http.dns_cache_ttl", 60)
+	v.SetDefault("fly.http.disable_http2", false)
+	v.SetDefault("fly.pricing.shared_cpu", 1.94)
+	v.SetDefault("fly.pricing.shared_memory_mb", 256)
+	v.SetDefault("fly.pricing.performance_cpu", 31.00)
+	v.SetDefault("fly.pricing.performance_memory_mb", 2048)
+	v.SetDefault("fly.pricing.memory_gb", 5.00)
+	v.SetDefault("fly.pricing.volume_gb", 0.15)
+	v.SetDefault("fly.pricing.dedicated_ipv4", 2.00)
 	
 	// MCP defaults
-	v.SetDefault("mcp.version", "2024-11-05")
+	v.SetDefault("mcp.version", "2025-06-18")
 	v.SetDefault("mcp.server_info.name", "fly-mcp")
 	v.SetDefault("mcp.server_info.version", "0.1.0")
 	v.SetDefault("mcp.capabilities.tools.list_changed", true)
 	v.SetDefault("mcp.capabilities.resources.subscribe", false)
 	v.SetDefault("mcp.capabilities.resources.list_changed", true)
 	v.SetDefault("mcp.capabilities.prompts.list_changed", false)
+	v.SetDefault("mcp.page_size", 50)
+	v.SetDefault("mcp.session_timeout", 3600)
+	v.SetDefault("mcp.output_format", "text")
+	v.SetDefault("mcp.output_style", "rich")
+	v.SetDefault("mcp.response_limits.max_bytes", 100000)
+	v.SetDefault("mcp.response_limits.continuation_ttl", 600)
+	v.SetDefault("mcp.idempotency.ttl", 600)
+	v.SetDefault("mcp.journal.size", 200)
+	v.SetDefault("mcp.batch.max_requests", 50)
+	v.SetDefault("mcp.batch.concurrency", 4)
+	v.SetDefault("mcp.elicitation.enabled", true)
+	v.SetDefault("mcp.elicitation.timeout", 120)
+	v.SetDefault("mcp.sampling.enabled", true)
+	v.SetDefault("mcp.sampling.timeout", 120)
+	v.SetDefault("mcp.sampling.max_tokens", 1024)
+	v.SetDefault("mcp.log_tail.max_duration", 300)
+	v.SetDefault("mcp.log_tail.poll_interval", 2)
+	v.SetDefault("mcp.wait.max_timeout", 900)
+	v.SetDefault("mcp.wait.poll_interval", 5)
+	v.SetDefault("mcp.tool_timeouts.read", 120)
+	v.SetDefault("mcp.tool_timeouts.mutating", 0)
+	v.SetDefault("mcp.reports.enabled", false)
+	v.SetDefault("mcp.reports.interval", 900)
+	v.SetDefault("mcp.reports.webhook", false)
+	v.SetDefault("mcp.watcher.enabled", false)
+	v.SetDefault("mcp.watcher.apps", []string{})
+	v.SetDefault("mcp.watcher.interval", 30)
+	v.SetDefault("mcp.watcher.flap_threshold", 3)
+	v.SetDefault("mcp.watcher.flap_window", 600)
+	v.SetDefault("mcp.watcher.webhook", false)
+	v.SetDefault("mcp.alerts.history", 100)
 	
 	// Security defaults
 	v.SetDefault("security.rate_limit_enabled", true)
 	v.SetDefault("security.rate_limit_rps", 10)
+	v.SetDefault("security.tool_rate_limits.read_rps", 5)
+	v.SetDefault("security.tool_rate_limits.read_burst", 10)
+	v.SetDefault("security.tool_rate_limits.mutating_rps", 0.2)
+	v.SetDefault("security.tool_rate_limits.mutating_burst", 3)
 	v.SetDefault("security.audit_log_enabled", true)
+	v.SetDefault("security.confirmation_ttl", 300)
+	v.SetDefault("security.approvals.ttl", 3600)
+	v.SetDefault("security.approvals.link_secret", "")
+	v.SetDefault("security.approvals.public_url", "")
 	v.SetDefault("security.allowed_origins", []string{"*"})
+	v.SetDefault("security.identity_header", "")
+	
+	// Integration defaults
+	v.SetDefault("integrations.github.token", "")
+	v.SetDefault("integrations.github.api_url", "https://api.github.com")
+	v.SetDefault("integrations.github.workflow", "fly-build.yml")
+	v.SetDefault("integrations.github.build_timeout", 1800)
+	v.SetDefault("integrations.github.poll_interval", 10)
+	v.SetDefault("integrations.github.repositories", []string{})
+	v.SetDefault("integrations.ci.enabled", false)
+	v.SetDefault("integrations.ci.secret", "")
+	v.SetDefault("integrations.ci.history", 50)
+	v.SetDefault("integrations.slack.enabled", false)
+	v.SetDefault("integrations.slack.signing_secret", "")
+	v.SetDefault("integrations.slack.users", map[string]string{})
+	
+	// Metrics defaults
+	v.SetDefault("metrics.remote_write.enabled", false)
+	v.SetDefault("metrics.remote_write.url", "")
+	v.SetDefault("metrics.remote_write.username", "")
+	v.SetDefault("metrics.remote_write.password", "")
+	v.SetDefault("metrics.remote_write.bearer_token", "")
+	v.SetDefault("metrics.remote_write.interval", 30)
+	v.SetDefault("metrics.remote_write.timeout", 10)
 	
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
 	v.SetDefault("logging.output", "stdout")
 	v.SetDefault("logging.structured", true)
+	v.SetDefault("logging.tracing.enabled", false)
+	v.SetDefault("logging.tracing.sample_ratio", 1.0)
+	v.SetDefault("logging.tracing.service_name", "fly-mcp")
 	
 	// Environment default
 	v.SetDefault("environment", getEnvironment())
@@ -188,14 +1004,59 @@ func getEnvironment() string {
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	// Validate Fly.io configuration
-	if c.Fly.APIToken == "" {
-		return fmt.Errorf("fly.api_token is required")
+	// A replay answers every call from the cassette, so it needs no token
+	if c.Fly.APIToken == "" && c.Fly.Cassette.Mode != CassetteReplay {
+		return fmt.Errorf("fly.api_token or fly.token_source is required, or log in with `fly auth login`")
+	}
+	if c.Fly.Cassette.Mode != "" && c.Fly.Cassette.Mode != CassetteRecord && c.Fly.Cassette.Mode != CassetteReplay {
+		return fmt.Errorf("fly.cassette.mode must be record or replay")
+	}
+	if c.Fly.Cassette.Mode != "" && c.Fly.Cassette.Path == "" {
+		return fmt.Errorf("fly.cassette.path is required when fly.cassette.mode is set")
+	}
+	
+	pool := c.Fly.HTTP
+	if pool.MaxIdleConns < 0 || pool.MaxIdleConnsPerHost < 0 || pool.MaxConnsPerHost < 0 || pool.IdleConnTimeout < 0 || pool.RequestTimeout < 0 || pool.DNSCacheTTL < 0 {
+		return fmt.Errorf("fly.http settings must not be negative")
+	}
+	
+	for name, profile := range c.Fly.Profiles {
+		if profile.APIToken == "" {
+			return fmt.Errorf("fly.profiles.%s.api_token or token_source is required", name)
+		}
+	}
+	for user, name := range c.Fly.UserProfiles {
+		if _, ok := c.Fly.Profiles[name]; !ok {
+			return fmt.Errorf("fly.user_profiles.%s: unknown profile %q", user, name)
+		}
 	}
 	
 	// Validate server configuration
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("server.port must be between 1 and 65535")
 	}
+	limits := c.Server.Limits
+	if limits.MaxBodyBytes <= 0 || limits.MaxHeaderBytes <= 0 || limits.ReadHeaderTimeout <= 0 || limits.MaxConcurrentStreams <= 0 {
+		return fmt.Errorf("server.limits.max_body_bytes, max_header_bytes, read_header_timeout and max_concurrent_streams must be positive")
+	}
+	for _, proxy := range c.Server.Proxy.Trusted {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("server.proxy.trusted entry %q must be an IP address or CIDR range", proxy)
+		}
+	}
+	if c.Server.Listen.UnixSocket != "" {
+		if _, err := c.Server.Listen.FileMode(); err != nil {
+			return fmt.Errorf("server.listen.socket_mode must be octal permissions such as 0600")
+		}
+	}
+	
+	// Validate TLS configuration
+	if c.Server.TLS.Enabled && (c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "") {
+		return fmt.Errorf("server.tls.cert_file and server.tls.key_file are required when TLS is enabled")
+	}
+	if !c.Server.TLS.Enabled && c.Server.TLS.ClientCAFile != "" {
+		return fmt.Errorf("server.tls.client_ca_file requires server.tls.enabled")
+	}
 	
 	// Validate logging configuration
 	validLevels := []string{"debug", "info", "warn", "error"}
@@ -208,6 +1069,340 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("logging.format must be one of: %v", validFormats)
 	}
 	
+	if c.Logging.Tracing.SampleRatio < 0 || c.Logging.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("logging.tracing.sample_ratio must be between 0 and 1")
+	}
+	
+	// Validate webhooks
+	for i, webhook := range c.Notifications.Webhooks {
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notifications.webhooks[%d].url must be an http or https URL", i)
+		}
+		if webhook.Type != "" && webhook.Type != "slack" && webhook.Type != "generic" {
+			return fmt.Errorf("notifications.webhooks[%d].type must be slack or generic", i)
+		}
+		for _, result := range webhook.Results {
+			if !contains([]string{"success", "failed", "cancelled", "pending_approval", "report", "alert", "resolved"}, result) {
+				return fmt.Errorf("notifications.webhooks[%d].results: unknown result %q", i, result)
+			}
+		}
+	}
+	
+	// Validate metrics remote write
+	if rw := c.Metrics.RemoteWrite; rw.Enabled {
+		u, err := url.Parse(rw.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("metrics.remote_write.url must be an http or https URL when metrics.remote_write is enabled")
+		}
+		if rw.BearerToken != "" && (rw.Username != "" || rw.Password != "") {
+			return fmt.Errorf("metrics.remote_write takes either username and password or bearer_token, not both")
+		}
+		if rw.Interval < 1 || rw.Timeout < 1 {
+			return fmt.Errorf("metrics.remote_write.interval and timeout must be at least 1 second")
+		}
+		for name := range rw.Labels {
+			if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+				return fmt.Errorf("metrics.remote_write.labels: invalid label name %q", name)
+			}
+		}
+	}
+	
+	// Validate audit exporters
+	for i, exporter := range c.Audit.Exporters {
+		switch exporter.Format {
+		case "syslog", "cef":
+			u, err := url.Parse(exporter.Address)
+			if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp" && u.Scheme != "tls") || u.Port() == "" {
+				return fmt.Errorf("audit.exporters[%d].address must be udp://, tcp:// or tls://host:port", i)
+			}
+		case "jsonl":
+			u, err := url.Parse(exporter.URL)
+			if err != nil || u.Scheme != "https" || u.Host == "" {
+				return fmt.Errorf("audit.exporters[%d].url must be an https URL", i)
+			}
+		default:
+			return fmt.Errorf("audit.exporters[%d].format must be syslog, cef or jsonl", i)
+		}
+		if exporter.Facility < 0 || exporter.Facility > 23 {
+			return fmt.Errorf("audit.exporters[%d].facility must be between 1 and 23", i)
+		}
+		if exporter.BatchSize < 0 || exporter.FlushInterval < 0 || exporter.MaxRetries < 0 || exporter.Timeout < 0 {
+			return fmt.Errorf("audit.exporters[%d]: batch_size, flush_interval, max_retries and timeout must not be negative", i)
+		}
+	}
+	
+	// Validate policy rules
+	for i, rule := range c.Security.Policies {
+		if rule.Effect != "allow" && rule.Effect != "deny" && rule.Effect != "elevate" {
+			return fmt.Errorf("security.policies[%d].effect must be allow, deny or elevate", i)
+		}
+		if rule.Effect == "elevate" && !strings.Contains(rule.Permission, ":") {
+			return fmt.Errorf("security.policies[%d].permission must be an action:resource permission for elevate rules", i)
+		}
+		for j, window := range rule.OutsideWindows {
+			if err := window.Validate(); err != nil {
+				return fmt.Errorf("security.policies[%d].outside_windows[%d]: %w", i, j, err)
+			}
+		}
+		for j, freeze := range rule.Freezes {
+			if err := freeze.Validate(); err != nil {
+				return fmt.Errorf("security.policies[%d].freezes[%d]: %w", i, j, err)
+			}
+		}
+		for _, pattern := range rule.Apps {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("security.policies[%d].apps: invalid pattern %q", i, pattern)
+			}
+		}
+	}
+	
+	// Validate approvals
+	approvals := c.Security.Approvals
+	if approvals.TTL <= 0 {
+		return fmt.Errorf("security.approvals.ttl must be positive")
+	}
+	for i, rule := range approvals.Rules {
+		for _, pattern := range rule.Apps {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("security.approvals.rules[%d].apps: invalid pattern %q", i, pattern)
+			}
+		}
+	}
+	if approvals.LinkSecret != "" && len(approvals.LinkSecret) < 16 {
+		return fmt.Errorf("security.approvals.link_secret must be at least 16 characters")
+	}
+	if approvals.PublicURL != "" {
+		if u, err := url.Parse(approvals.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("security.approvals.public_url must be an http or https URL")
+		}
+	}
+	
+	// Validate roles
+	for i, binding := range c.Security.RoleBindings {
+		if !c.Security.HasRole(binding.Role) {
+			return fmt.Errorf("security.role_bindings[%d].role: unknown role %q", i, binding.Role)
+		}
+		if len(binding.Users) == 0 {
+			return fmt.Errorf("security.role_bindings[%d].users is required", i)
+		}
+		for _, pattern := range binding.Apps {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("security.role_bindings[%d].apps: invalid pattern %q", i, pattern)
+			}
+		}
+	}
+	
+	// Validate identity sources; production refuses anonymous callers, so
+	// it needs a way to tell who they are
+	for i, key := range c.Security.APIKeys {
+		if key.User == "" {
+			return fmt.Errorf("security.api_keys[%d].user is required", i)
+		}
+		if digest, err := hex.DecodeString(key.SHA256); err != nil || len(digest) != sha256.Size {
+			return fmt.Errorf("security.api_keys[%d].sha256 must be the hex SHA-256 digest of the key", i)
+		}
+		for _, role := range key.Roles {
+			if !c.Security.HasRole(role) {
+				return fmt.Errorf("security.api_keys[%d].roles: unknown role %q", i, role)
+			}
+		}
+	}
+	if c.IsProduction() && !c.HasIdentitySource() {
+		return fmt.Errorf("production requires security.api_keys, security.identity_header or server.tls.client_ca_file to identify callers")
+	}
+	if c.Server.Debug.Enabled && !c.HasIdentitySource() {
+		return fmt.Errorf("server.debug requires security.api_keys, security.identity_header or server.tls.client_ca_file to identify administrators")
+	}
+	
+	if !contains(ProtocolVersions, c.MCP.Version) {
+		return fmt.Errorf("mcp.version must be one of %s", strings.Join(ProtocolVersions, ", "))
+	}
+
+	if c.MCP.OutputFormat != "" && !contains([]string{"text", "json", "table"}, c.MCP.OutputFormat) {
+		return fmt.Errorf("mcp.output_format must be text, json or table")
+	}
+
+	if c.MCP.OutputStyle != "" && !contains([]string{"rich", "plain", "minimal"}, c.MCP.OutputStyle) {
+		return fmt.Errorf("mcp.output_style must be rich, plain or minimal")
+	}
+
+	if limit := c.MCP.ResponseLimits.MaxBytes; limit < 0 || (limit > 0 && limit < MinResponseBytes) {
+		return fmt.Errorf("mcp.response_limits.max_bytes must be 0 or at least %d", MinResponseBytes)
+	}
+	for tool, limit := range c.MCP.ResponseLimits.Tools {
+		if limit < 0 || (limit > 0 && limit < MinResponseBytes) {
+			return fmt.Errorf("mcp.response_limits.tools.%s must be 0 or at least %d", tool, MinResponseBytes)
+		}
+	}
+
+	if c.MCP.Batch.MaxRequests < 1 {
+		return fmt.Errorf("mcp.batch.max_requests must be at least 1")
+	}
+	if c.MCP.Batch.Concurrency < 1 {
+		return fmt.Errorf("mcp.batch.concurrency must be at least 1")
+	}
+	if c.MCP.Elicitation.Timeout <= 0 {
+		return fmt.Errorf("mcp.elicitation.timeout must be positive")
+	}
+	if c.MCP.Sampling.Timeout <= 0 {
+		return fmt.Errorf("mcp.sampling.timeout must be positive")
+	}
+	if c.MCP.Sampling.MaxTokens < 1 {
+		return fmt.Errorf("mcp.sampling.max_tokens must be at least 1")
+	}
+	if c.MCP.LogTail.MaxDuration < 1 {
+		return fmt.Errorf("mcp.log_tail.max_duration must be at least 1")
+	}
+	if c.MCP.LogTail.PollInterval < 1 || c.MCP.LogTail.PollInterval > c.MCP.LogTail.MaxDuration {
+		return fmt.Errorf("mcp.log_tail.poll_interval must be between 1 and mcp.log_tail.max_duration")
+	}
+	if c.MCP.Journal.Size < 1 {
+		return fmt.Errorf("mcp.journal.size must be at least 1")
+	}
+	if c.MCP.Wait.MaxTimeout < 1 {
+		return fmt.Errorf("mcp.wait.max_timeout must be at least 1")
+	}
+	if c.MCP.Wait.PollInterval < 1 || c.MCP.Wait.PollInterval > c.MCP.Wait.MaxTimeout {
+		return fmt.Errorf("mcp.wait.poll_interval must be between 1 and mcp.wait.max_timeout")
+	}
+	if c.MCP.ToolTimeouts.Read < 0 || c.MCP.ToolTimeouts.Mutating < 0 {
+		return fmt.Errorf("mcp.tool_timeouts must not be negative")
+	}
+	for name, timeout := range c.MCP.ToolTimeouts.Tools {
+		if timeout < 0 {
+			return fmt.Errorf("mcp.tool_timeouts.tools.%s must not be negative", name)
+		}
+	}
+	if c.MCP.Reports.Enabled && c.MCP.Reports.Interval < 60 {
+		return fmt.Errorf("mcp.reports.interval must be at least 60")
+	}
+	if c.MCP.Watcher.Enabled {
+		if c.MCP.Watcher.Interval < 5 {
+			return fmt.Errorf("mcp.watcher.interval must be at least 5")
+		}
+		if c.MCP.Watcher.FlapThreshold < 2 {
+			return fmt.Errorf("mcp.watcher.flap_threshold must be at least 2")
+		}
+		if c.MCP.Watcher.FlapWindow < c.MCP.Watcher.Interval {
+			return fmt.Errorf("mcp.watcher.flap_window must be at least mcp.watcher.interval")
+		}
+		for _, pattern := range c.MCP.Watcher.Apps {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("mcp.watcher.apps: invalid pattern %q", pattern)
+			}
+		}
+	}
+	if len(c.MCP.Alerts.Rules) > 0 && !c.MCP.Watcher.Enabled {
+		return fmt.Errorf("mcp.alerts.rules need mcp.watcher.enabled")
+	}
+	if c.MCP.Alerts.History < 0 {
+		return fmt.Errorf("mcp.alerts.history cannot be negative")
+	}
+	alertNames := make(map[string]bool)
+	for i, rule := range c.MCP.Alerts.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("mcp.alerts.rules[%d].name is required", i)
+		}
+		if alertNames[rule.Name] {
+			return fmt.Errorf("mcp.alerts.rules[%d]: duplicate name %q", i, rule.Name)
+		}
+		alertNames[rule.Name] = true
+		if !contains(AlertConditions, rule.Condition) {
+			return fmt.Errorf("mcp.alerts.rules[%d].condition must be one of %s", i, strings.Join(AlertConditions, ", "))
+		}
+		if rule.Condition == "started_below" && rule.Threshold < 1 {
+			return fmt.Errorf("mcp.alerts.rules[%d].threshold must be at least 1", i)
+		}
+		if rule.Condition == "machine_state" && rule.State == "" {
+			return fmt.Errorf("mcp.alerts.rules[%d].state is required", i)
+		}
+		if rule.For < 0 {
+			return fmt.Errorf("mcp.alerts.rules[%d].for cannot be negative", i)
+		}
+		if rule.Severity != "" && !contains([]string{"critical", "warning", "info"}, rule.Severity) {
+			return fmt.Errorf("mcp.alerts.rules[%d].severity must be critical, warning or info", i)
+		}
+		for _, pattern := range rule.Apps {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("mcp.alerts.rules[%d].apps: invalid pattern %q", i, pattern)
+			}
+		}
+	}
+	
+	github := c.Integrations.GitHub
+	if github.Token != "" {
+		if github.Workflow == "" {
+			return fmt.Errorf("integrations.github.workflow is required when integrations.github.token is set")
+		}
+		if github.BuildTimeout <= 0 || github.PollInterval <= 0 {
+			return fmt.Errorf("integrations.github.build_timeout and poll_interval must be positive")
+		}
+	}
+	for _, pattern := range github.Repositories {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("integrations.github.repositories: invalid pattern %q", pattern)
+		}
+	}
+	if ci := c.Integrations.CI; ci.Enabled {
+		if len(ci.Secret) < 16 {
+			return fmt.Errorf("integrations.ci.secret must be at least 16 characters when integrations.ci is enabled")
+		}
+		if ci.History < 1 {
+			return fmt.Errorf("integrations.ci.history must be at least 1")
+		}
+	}
+	if slack := c.Integrations.Slack; slack.Enabled {
+		if slack.SigningSecret == "" {
+			return fmt.Errorf("integrations.slack.signing_secret is required when integrations.slack is enabled")
+		}
+		if len(slack.Users) == 0 {
+			return fmt.Errorf("integrations.slack.users must map at least one Slack user to a fly-mcp user")
+		}
+	}
+	
+	for _, pattern := range c.MCP.Tools.Enabled {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("mcp.tools.enabled: invalid pattern %q", pattern)
+		}
+	}
+	for _, pattern := range c.MCP.Tools.Disabled {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("mcp.tools.disabled: invalid pattern %q", pattern)
+		}
+	}
+	
+	return nil
+}
+
+// ResolveTokens reads the API tokens that come from a token source rather
+// than the config itself. A token set directly, e.g. through
+// FLY_MCP_FLY_API_TOKEN, takes precedence over the source. With neither, the
+// token flyctl stored at `fly auth login` is used if there is one.
+func (c *Config) ResolveTokens(ctx context.Context) error {
+	source, setting := c.Fly.TokenSource, "fly.token_source"
+	if !source.IsSet() && credentials.HasFlyctlLogin() {
+		source, setting = credentials.Source{Type: credentials.SourceFlyctl}, "flyctl login"
+	}
+	if c.Fly.APIToken == "" && source.IsSet() {
+		token, err := credentials.Resolve(ctx, source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", setting, err)
+		}
+		c.Fly.APIToken = token
+	}
+
+	for name, profile := range c.Fly.Profiles {
+		if profile.APIToken != "" || !profile.TokenSource.IsSet() {
+			continue
+		}
+		token, err := credentials.Resolve(ctx, profile.TokenSource)
+		if err != nil {
+			return fmt.Errorf("fly.profiles.%s.token_source: %w", name, err)
+		}
+		profile.APIToken = token
+		c.Fly.Profiles[name] = profile
+	}
 	return nil
 }
 
@@ -223,35 +1418,7 @@ func (c *Config) IsProduction() bool {
 
 // LoadFromFile loads configuration from a specific file
 func LoadFromFile(configFile string) (*Config, error) {
-	v := viper.New()
-
-	// Set defaults
-	setDefaults(v)
-
-	// Set config file
-	v.SetConfigFile(configFile)
-
-	// Environment variable support
-	v.SetEnvPrefix("FLY_MCP")
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	v.AutomaticEnv()
-
-	// Read config file
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("error reading config file %s: %w", configFile, err)
-	}
-
-	var config Config
-	if err := v.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("error unmarshaling config: %w", err)
-	}
-
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
-	}
-
-	return &config, nil
+	return NewLoader(configFile).Load()
 }
 
 // contains checks if a slice contains a string
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Loader reads configuration and can re-read it from the same source, so the
+// running server can pick up changes without restarting
+type Loader struct {
+	mu         sync.Mutex
+	v          *viper.Viper
+	configFile string
+}
+
+// NewLoader creates a loader for configFile, or for the standard config
+// locations when configFile is empty
+func NewLoader(configFile string) *Loader {
+	v := viper.New()
+
+	// Set defaults
+	setDefaults(v)
+
+	if configFile != "" {
+		// Set config file
+		v.SetConfigFile(configFile)
+	} else {
+		// Set config name and paths
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath(".")
+		v.AddConfigPath("./configs")
+		v.AddConfigPath("/etc/fly-mcp")
+	}
+
+	// Environment variable support
+	v.SetEnvPrefix("FLY_MCP")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	return &Loader{v: v, configFile: configFile}
+}
+
+// Load reads and validates the configuration. A missing config file is only
+// an error when a specific file was requested.
+func (l *Loader) Load() (*Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.v.ReadInConfig(); err != nil {
+		if l.configFile != "" {
+			return nil, fmt.Errorf("error reading config file %s: %w", l.configFile, err)
+		}
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+		// Config file not found is OK, we'll use defaults and env vars
+	}
+
+	return l.unmarshal()
+}
+
+// unmarshal decodes and validates the values viper currently holds.
+// Callers must hold l.mu.
+func (l *Loader) unmarshal() (*Config, error) {
+	var config Config
+	if err := l.v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+
+	// Read tokens kept outside the config, e.g. in the OS keychain
+	if err := config.ResolveTokens(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return &config, nil
+}
+
+// Set overrides a setting regardless of the config file and environment,
+// e.g. for a command-line flag. Overrides survive reloads.
+func (l *Loader) Set(key string, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.v.Set(key, value)
+}
+
+// SetDefault changes the value a setting has when neither the config file
+// nor the environment sets it
+func (l *Loader) SetDefault(key string, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.v.SetDefault(key, value)
+}
+
+// ConfigFileUsed returns the path of the config file in use, if any
+func (l *Loader) ConfigFileUsed() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.v.ConfigFileUsed()
+}
+
+// Watch calls onChange with the newly loaded configuration, or the error that
+// prevented loading it, whenever the config file changes on disk. It does
+// nothing when no config file is in use.
+func (l *Loader) Watch(onChange func(*Config, error)) {
+	if l.ConfigFileUsed() == "" {
+		return
+	}
+
+	l.v.OnConfigChange(func(fsnotify.Event) {
+		l.mu.Lock()
+		cfg, err := l.unmarshal()
+		l.mu.Unlock()
+		onChange(cfg, err)
+	})
+	l.v.WatchConfig()
+}
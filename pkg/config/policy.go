@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is a recurring period in which changes are expected,
+// e.g. weekdays 22:00-02:00 in a given timezone
+type MaintenanceWindow struct {
+	// Days lists the weekdays the window starts on (mon, tue, ...); empty
+	// means every day
+	Days []string `mapstructure:"days"`
+
+	// Start and End are "HH:MM" times; a window whose end is before its
+	// start runs past midnight, and equal times cover the whole day
+	Start string `mapstructure:"start"`
+	End   string `mapstructure:"end"`
+
+	// Timezone is an IANA zone name such as "Europe/Berlin"; default UTC
+	Timezone string `mapstructure:"timezone"`
+}
+
+// ChangeFreeze is a fixed period in which changes are not expected
+type ChangeFreeze struct {
+	Start  string `mapstructure:"start"` // RFC 3339
+	End    string `mapstructure:"end"`   // RFC 3339
+	Reason string `mapstructure:"reason"`
+}
+
+// weekdays maps the accepted day names to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Validate checks the window's days, times and timezone
+func (w MaintenanceWindow) Validate() error {
+	for _, day := range w.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("invalid day %q (expected mon, tue, wed, thu, fri, sat or sun)", day)
+		}
+	}
+	if _, err := parseClock(w.Start); err != nil {
+		return fmt.Errorf("invalid start: %w", err)
+	}
+	if _, err := parseClock(w.End); err != nil {
+		return fmt.Errorf("invalid end: %w", err)
+	}
+	if _, err := w.location(); err != nil {
+		return fmt.Errorf("invalid timezone %q", w.Timezone)
+	}
+	return nil
+}
+
+// Contains reports whether t falls inside the window. Invalid windows never
+// contain anything.
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	start, end, loc, err := w.parse()
+	if err != nil {
+		return false
+	}
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	switch {
+	case start == end:
+		return w.onDay(local.Weekday())
+	case start < end:
+		return w.onDay(local.Weekday()) && minute >= start && minute < end
+	default:
+		// Past midnight the window belongs to the previous day
+		yesterday := (local.Weekday() + 6) % 7
+		return (w.onDay(local.Weekday()) && minute >= start) || (w.onDay(yesterday) && minute < end)
+	}
+}
+
+// NextStart returns when the window next opens after t, or the zero time if
+// the window is invalid
+func (w MaintenanceWindow) NextStart(t time.Time) time.Time {
+	start, _, loc, err := w.parse()
+	if err != nil {
+		return time.Time{}
+	}
+
+	local := t.In(loc)
+	for day := 0; day <= 7; day++ {
+		date := local.AddDate(0, 0, day)
+		candidate := time.Date(date.Year(), date.Month(), date.Day(), start/60, start%60, 0, 0, loc)
+		if candidate.After(t) && w.onDay(candidate.Weekday()) {
+			return candidate
+		}
+	}
+	return time.Time{}
+}
+
+// String describes the window, e.g. "sat,sun 02:00-06:00 Europe/Berlin"
+func (w MaintenanceWindow) String() string {
+	days := "daily"
+	if len(w.Days) > 0 {
+		days = strings.ToLower(strings.Join(w.Days, ","))
+	}
+
+	timezone := w.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	return fmt.Sprintf("%s %s-%s %s", days, w.Start, w.End, timezone)
+}
+
+// onDay reports whether the window starts on the given weekday
+func (w MaintenanceWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if weekdays[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parse returns the window's start and end in minutes after midnight and
+// its location
+func (w MaintenanceWindow) parse() (int, int, *time.Location, error) {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	loc, err := w.location()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return start, end, loc, nil
+}
+
+// location returns the window's timezone
+func (w MaintenanceWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(w.Timezone)
+}
+
+// parseClock parses an "HH:MM" time into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an HH:MM time", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate checks that the freeze has a valid start before its end
+func (f ChangeFreeze) Validate() error {
+	start, end, err := f.parse()
+	if err != nil {
+		return err
+	}
+	if !end.After(start) {
+		return fmt.Errorf("end must be after start")
+	}
+	return nil
+}
+
+// Active reports whether t falls inside the freeze
+func (f ChangeFreeze) Active(t time.Time) bool {
+	start, end, err := f.parse()
+	if err != nil {
+		return false
+	}
+	return !t.Before(start) && t.Before(end)
+}
+
+// Ends returns when the freeze is lifted
+func (f ChangeFreeze) Ends() time.Time {
+	_, end, _ := f.parse()
+	return end
+}
+
+// parse returns the freeze's start and end times
+func (f ChangeFreeze) parse() (time.Time, time.Time, error) {
+	start, err := time.Parse(time.RFC3339, f.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start %q: expected an RFC 3339 time", f.Start)
+	}
+	end, err := time.Parse(time.RFC3339, f.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end %q: expected an RFC 3339 time", f.End)
+	}
+	return start, end, nil
+}
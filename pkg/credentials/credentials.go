@@ -0,0 +1,226 @@
+// Package credentials reads the Fly.io API token from somewhere safer than
+// plaintext config: the macOS Keychain, the Linux Secret Service, a
+// passphrase-encrypted file, an external command such as a password manager
+// CLI, or the login flyctl already stored.
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Kinds of token source
+const (
+	SourceKeychain      = "keychain"
+	SourceSecretService = "secret_service"
+	SourceEncryptedFile = "encrypted_file"
+	SourceCommand       = "command"
+	SourceFlyctl        = "flyctl"
+)
+
+const (
+	// DefaultService and DefaultAccount identify the token in the keychain
+	// or Secret Service when the source does not name them
+	DefaultService = "fly-mcp"
+	DefaultAccount = "api_token"
+
+	// DefaultPassphraseEnv holds the passphrase of an encrypted token file
+	// when the source does not name another variable
+	DefaultPassphraseEnv = "FLY_MCP_TOKEN_PASSPHRASE"
+
+	// commandTimeout bounds keychain lookups and token commands, which may
+	// wait for the user to unlock a keyring
+	commandTimeout = 60 * time.Second
+)
+
+// Source configures where a token is read from
+type Source struct {
+	Type string `mapstructure:"type"`
+
+	// Service and Account identify a keychain or Secret Service item
+	Service string `mapstructure:"service"`
+	Account string `mapstructure:"account"`
+
+	// File is an encrypted token file written by `fly-mcp token encrypt`,
+	// and PassphraseEnv the environment variable holding its passphrase.
+	// For flyctl, File overrides the location of flyctl's config.yml.
+	File          string `mapstructure:"file"`
+	PassphraseEnv string `mapstructure:"passphrase_env"`
+
+	// Command prints the token on stdout, e.g. ["op", "read", "op://ops/fly/token"]
+	Command []string `mapstructure:"command"`
+}
+
+// IsSet reports whether a source is configured
+func (s Source) IsSet() bool {
+	return s.Type != ""
+}
+
+// Provider reads a token from a source
+type Provider interface {
+	// Token returns the token
+	Token(ctx context.Context) (string, error)
+	// Describe names the source for logs and errors, without secrets
+	Describe() string
+}
+
+// New returns the provider for a source
+func New(s Source) (Provider, error) {
+	switch s.Type {
+	case SourceKeychain:
+		service, account := s.item()
+		return &commandProvider{
+			description: fmt.Sprintf("macOS Keychain item %s/%s", service, account),
+			command:     []string{"security", "find-generic-password", "-s", service, "-a", account, "-w"},
+		}, nil
+	case SourceSecretService:
+		service, account := s.item()
+		return &commandProvider{
+			description: fmt.Sprintf("Secret Service item %s/%s", service, account),
+			command:     []string{"secret-tool", "lookup", "service", service, "account", account},
+		}, nil
+	case SourceEncryptedFile:
+		if s.File == "" {
+			return nil, fmt.Errorf("token source %s requires file", s.Type)
+		}
+		passphraseEnv := s.PassphraseEnv
+		if passphraseEnv == "" {
+			passphraseEnv = DefaultPassphraseEnv
+		}
+		return &encryptedFileProvider{file: s.File, passphraseEnv: passphraseEnv}, nil
+	case SourceCommand:
+		if len(s.Command) == 0 {
+			return nil, fmt.Errorf("token source %s requires command", s.Type)
+		}
+		return &commandProvider{
+			description: fmt.Sprintf("command %s", s.Command[0]),
+			command:     s.Command,
+		}, nil
+	case SourceFlyctl:
+		return &flyctlProvider{file: s.File}, nil
+	}
+	return nil, fmt.Errorf("unknown token source %q (expected %s, %s, %s, %s or %s)",
+		s.Type, SourceKeychain, SourceSecretService, SourceEncryptedFile, SourceCommand, SourceFlyctl)
+}
+
+// Resolve reads the token from a source
+func Resolve(ctx context.Context, s Source) (string, error) {
+	provider, err := New(s)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := provider.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token from %s: %w", provider.Describe(), err)
+	}
+	if token == "" {
+		return "", fmt.Errorf("%s returned an empty token", provider.Describe())
+	}
+	return token, nil
+}
+
+// Store saves a token in the keychain or Secret Service item of a source,
+// replacing any token already there. Other sources are read-only.
+func Store(ctx context.Context, s Source, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	service, account := s.item()
+	var cmd *exec.Cmd
+	switch s.Type {
+	case SourceKeychain:
+		cmd = exec.CommandContext(ctx, "security", "add-generic-password", "-U", "-s", service, "-a", account, "-w", token)
+	case SourceSecretService:
+		// secret-tool reads the secret from stdin
+		cmd = exec.CommandContext(ctx, "secret-tool", "store", "--label", service, "service", service, "account", account)
+		cmd.Stdin = strings.NewReader(token)
+	default:
+		return fmt.Errorf("cannot store tokens in a %s source", s.Type)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to store token: %w: %s", err, msg)
+		}
+		return fmt.Errorf("failed to store token: %w", err)
+	}
+	return nil
+}
+
+// item returns the keychain service and account, with defaults applied
+func (s Source) item() (service, account string) {
+	service, account = s.Service, s.Account
+	if service == "" {
+		service = DefaultService
+	}
+	if account == "" {
+		account = DefaultAccount
+	}
+	return service, account
+}
+
+// commandProvider reads the token from a command's output
+type commandProvider struct {
+	description string
+	command     []string
+}
+
+// Token runs the command and returns its trimmed output
+func (p *commandProvider) Token(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%s is not installed", p.command[0])
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Describe names the command
+func (p *commandProvider) Describe() string {
+	return p.description
+}
+
+// encryptedFileProvider reads the token from a passphrase-encrypted file
+type encryptedFileProvider struct {
+	file          string
+	passphraseEnv string
+}
+
+// Token decrypts the file with the passphrase from the environment
+func (p *encryptedFileProvider) Token(ctx context.Context) (string, error) {
+	passphrase := os.Getenv(p.passphraseEnv)
+	if passphrase == "" {
+		return "", fmt.Errorf("%s is not set", p.passphraseEnv)
+	}
+
+	data, err := os.ReadFile(p.file)
+	if err != nil {
+		return "", err
+	}
+	return Decrypt(data, passphrase)
+}
+
+// Describe names the file
+func (p *encryptedFileProvider) Describe() string {
+	return fmt.Sprintf("encrypted file %s", p.file)
+}
@@ -0,0 +1,97 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// fileHeader starts every encrypted token file
+	fileHeader = "fly-mcp-token-v1"
+
+	// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-SHA256
+	pbkdf2Iterations = 600000
+	saltSize         = 16
+	keySize          = 32
+)
+
+// ErrWrongPassphrase is returned when a token file does not decrypt, which
+// almost always means the passphrase is wrong
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted token file")
+
+// Encrypt seals a token with a passphrase, using AES-256-GCM with a key
+// derived by PBKDF2-SHA256. The result is the content of a token file: a
+// header line followed by the base64 encoded salt, nonce and ciphertext.
+func Encrypt(token, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase cannot be empty")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := fileCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nil, nonce, []byte(token), []byte(fileHeader))
+	payload := append(append(salt, nonce...), sealed...)
+	return []byte(fileHeader + "\n" + base64.StdEncoding.EncodeToString(payload) + "\n"), nil
+}
+
+// Decrypt opens a token file sealed by Encrypt
+func Decrypt(data []byte, passphrase string) (string, error) {
+	header, body, ok := strings.Cut(string(data), "\n")
+	if !ok || header != fileHeader {
+		return "", fmt.Errorf("not a fly-mcp token file")
+	}
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimSpace(body))
+	if err != nil {
+		return "", fmt.Errorf("malformed token file: %w", err)
+	}
+	if len(payload) < saltSize {
+		return "", ErrWrongPassphrase
+	}
+
+	salt := payload[:saltSize]
+	gcm, err := fileCipher(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	rest := payload[saltSize:]
+	if len(rest) < gcm.NonceSize() {
+		return "", ErrWrongPassphrase
+	}
+
+	token, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], []byte(fileHeader))
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(token), nil
+}
+
+// fileCipher returns the AES-GCM cipher for a passphrase and salt
+func fileCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
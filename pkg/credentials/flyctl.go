@@ -0,0 +1,76 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// flyctlConfig is the part of flyctl's config.yml holding the login token
+type flyctlConfig struct {
+	AccessToken string `yaml:"access_token"`
+}
+
+// FlyctlConfigPath returns where flyctl keeps its config, ~/.fly/config.yml
+func FlyctlConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".fly", "config.yml"), nil
+}
+
+// HasFlyctlLogin reports whether flyctl's config exists, i.e. the user has
+// probably run `fly auth login` on this machine
+func HasFlyctlLogin() bool {
+	path, err := FlyctlConfigPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// flyctlProvider reads the token flyctl stored at `fly auth login`
+type flyctlProvider struct {
+	file string
+}
+
+// Token returns the access token from flyctl's config
+func (p *flyctlProvider) Token(ctx context.Context) (string, error) {
+	file := p.file
+	if file == "" {
+		var err error
+		if file, err = FlyctlConfigPath(); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s not found; run `fly auth login` first", file)
+		}
+		return "", err
+	}
+
+	var cfg flyctlConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	if cfg.AccessToken == "" {
+		return "", fmt.Errorf("%s has no access token; run `fly auth login` first", file)
+	}
+	return cfg.AccessToken, nil
+}
+
+// Describe names the config file
+func (p *flyctlProvider) Describe() string {
+	if p.file != "" {
+		return fmt.Sprintf("flyctl config %s", p.file)
+	}
+	return "flyctl config"
+}
@@ -0,0 +1,513 @@
+package doctor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+const (
+	// crashLoopThreshold is the number of crashes within the window that
+	// makes a machine count as crash looping
+	crashLoopThreshold = 3
+
+	// stuckReleaseAge is how long a release may stay in progress before it
+	// is reported as stuck
+	stuckReleaseAge = 15 * time.Minute
+
+	// errorLogWarning is the number of error lines in the recent logs from
+	// which they are reported as a warning rather than for information
+	errorLogWarning = 10
+
+	// maxLogSamples is the number of error lines quoted in a finding
+	maxLogSamples = 3
+)
+
+// check is one analysis over the collected data. source names the data it
+// depends on beyond the app and its machines, if any.
+type check struct {
+	source string
+	run    func(Input) []Finding
+}
+
+// checks are run in order; their findings are then sorted by severity
+var checks = []check{
+	{run: checkNoMachines},
+	{run: checkRegions},
+	{run: checkHealthChecks},
+	{run: checkImages},
+	{source: "events", run: checkCrashes},
+	{source: "events", run: checkOOM},
+	{source: "events", run: checkRestartStorms},
+	{source: "releases", run: checkReleases},
+	{source: "releases", run: checkReleaseImage},
+	{source: "logs", run: checkErrorLogs},
+}
+
+// activeMachines returns the machines that have not been destroyed
+func activeMachines(machines []fly.Machine) []fly.Machine {
+	var active []fly.Machine
+	for _, m := range machines {
+		if m.State != "destroyed" && m.State != "destroying" {
+			active = append(active, m)
+		}
+	}
+	return active
+}
+
+// isHealthy reports whether a machine is running with no critical checks
+func isHealthy(m fly.Machine) bool {
+	if m.State != "started" {
+		return false
+	}
+	for _, c := range m.Checks {
+		if c.Status == "critical" {
+			return false
+		}
+	}
+	return true
+}
+
+// checkNoMachines reports an application with nothing to run it
+func checkNoMachines(in Input) []Finding {
+	if len(activeMachines(in.Machines)) > 0 {
+		return nil
+	}
+	return []Finding{{
+		Check:          "no_machines",
+		Severity:       SeverityCritical,
+		Title:          "The app has no machines",
+		Detail:         "Nothing is running this application, so it cannot serve requests.",
+		Recommendation: "Deploy the app, or use `fly_scale` to add machines.",
+	}}
+}
+
+// checkRegions reports regions where none of the machines is healthy, and
+// escalates when that is true of every region
+func checkRegions(in Input) []Finding {
+	machines := activeMachines(in.Machines)
+	if len(machines) == 0 {
+		return nil
+	}
+
+	byRegion := make(map[string][]fly.Machine)
+	for _, m := range machines {
+		byRegion[m.Region] = append(byRegion[m.Region], m)
+	}
+
+	var unhealthy []string
+	var affected []string
+	stopped := 0
+	for region, regionMachines := range byRegion {
+		healthy := false
+		for _, m := range regionMachines {
+			if isHealthy(m) {
+				healthy = true
+			}
+			if m.State == "stopped" || m.State == "suspended" {
+				stopped++
+			}
+		}
+		if !healthy {
+			unhealthy = append(unhealthy, region)
+			for _, m := range regionMachines {
+				affected = append(affected, m.ID)
+			}
+		}
+	}
+	sort.Strings(unhealthy)
+	sort.Strings(affected)
+
+	if len(unhealthy) == 0 {
+		return nil
+	}
+
+	// Apps that stop idle machines are expected to have none running
+	if stopped == len(machines) {
+		return []Finding{{
+			Check:          "all_stopped",
+			Severity:       SeverityInfo,
+			Title:          "All machines are stopped",
+			Detail:         fmt.Sprintf("All %d machine(s) are stopped or suspended. This is expected when auto stop is enabled and there is no traffic.", len(machines)),
+			Recommendation: "If the app should be serving traffic, check that auto start is enabled or start the machines.",
+		}}
+	}
+
+	if len(unhealthy) == len(byRegion) {
+		return []Finding{{
+			Check:          "no_healthy_region",
+			Severity:       SeverityCritical,
+			Title:          "No region has a healthy machine",
+			Detail:         fmt.Sprintf("None of the machines in %s is started with passing health checks.", strings.Join(unhealthy, ", ")),
+			Machines:       affected,
+			Recommendation: "Look at the crash, OOM and health check findings, then use `fly_restart` once the cause is fixed.",
+		}}
+	}
+
+	return []Finding{{
+		Check:          "unhealthy_region",
+		Severity:       SeverityWarning,
+		Title:          fmt.Sprintf("No healthy machine in %s", strings.Join(unhealthy, ", ")),
+		Detail:         "Traffic to these regions is served from other regions, with added latency.",
+		Machines:       affected,
+		Recommendation: "Use `fly_checks` and `fly_machine_events` on the affected machines to find out why they are unhealthy.",
+	}}
+}
+
+// checkHealthChecks reports machines with failing health checks
+func checkHealthChecks(in Input) []Finding {
+	var findings []Finding
+
+	failing := make(map[string][]string)
+	critical := make(map[string]bool)
+	var names []string
+	for _, m := range activeMachines(in.Machines) {
+		for _, c := range m.Checks {
+			if c.Status != "critical" && c.Status != "warning" {
+				continue
+			}
+			if _, seen := failing[c.Name]; !seen {
+				names = append(names, c.Name)
+			}
+			failing[c.Name] = append(failing[c.Name], m.ID)
+			if c.Status == "critical" {
+				critical[c.Name] = true
+			}
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		machineIDs := failing[name]
+		severity := SeverityWarning
+		if critical[name] {
+			severity = SeverityCritical
+		}
+
+		detail := fmt.Sprintf("Failing on %d machine(s).", len(machineIDs))
+		if output := checkOutput(in.Machines, name); output != "" {
+			detail += fmt.Sprintf(" Last output: %s", output)
+		}
+
+		findings = append(findings, Finding{
+			Check:          "failing_health_check",
+			Severity:       severity,
+			Title:          fmt.Sprintf("Health check %s is failing", name),
+			Detail:         detail,
+			Machines:       machineIDs,
+			Recommendation: "Use `fly_checks` for the check history, and `fly_ssh_exec` to probe the service from inside the machine.",
+		})
+	}
+
+	return findings
+}
+
+// checkOutput returns the output of the first failing run of a check
+func checkOutput(machines []fly.Machine, name string) string {
+	for _, m := range machines {
+		for _, c := range m.Checks {
+			if c.Name == name && !c.IsPassing() && c.Output != "" {
+				return truncate(c.Output, 200)
+			}
+		}
+	}
+	return ""
+}
+
+// checkImages reports machines running different images
+func checkImages(in Input) []Finding {
+	byDigest := make(map[string][]string)
+	for _, m := range activeMachines(in.Machines) {
+		if m.ImageRef.Digest == "" {
+			continue
+		}
+		byDigest[m.ImageRef.Digest] = append(byDigest[m.ImageRef.Digest], m.ID)
+	}
+	if len(byDigest) < 2 {
+		return nil
+	}
+
+	var groups []string
+	var machineIDs []string
+	for digest, ids := range byDigest {
+		groups = append(groups, fmt.Sprintf("%s on %d machine(s)", shortDigest(digest), len(ids)))
+		machineIDs = append(machineIDs, ids...)
+	}
+	sort.Strings(groups)
+	sort.Strings(machineIDs)
+
+	return []Finding{{
+		Check:          "image_mismatch",
+		Severity:       SeverityWarning,
+		Title:          fmt.Sprintf("Machines run %d different images", len(byDigest)),
+		Detail:         strings.Join(groups, "; ") + ". This usually means a deploy failed part way or machines were updated individually.",
+		Machines:       machineIDs,
+		Recommendation: "Use `fly_images` to see which machines lag behind, then redeploy.",
+	}}
+}
+
+// checkReleaseImage reports machines that do not run the image of the latest
+// successful release
+func checkReleaseImage(in Input) []Finding {
+	var latest *fly.Release
+	for i := range in.Releases {
+		if in.Releases[i].Status == "complete" || in.Releases[i].Status == "succeeded" {
+			latest = &in.Releases[i]
+			break
+		}
+	}
+	if latest == nil || latest.ImageRef == "" {
+		return nil
+	}
+
+	_, releaseTag, found := strings.Cut(latest.ImageRef[strings.LastIndex(latest.ImageRef, "/")+1:], ":")
+	if !found {
+		return nil
+	}
+
+	var behind []string
+	for _, m := range activeMachines(in.Machines) {
+		if m.ImageRef.Tag != "" && m.ImageRef.Tag != releaseTag {
+			behind = append(behind, m.ID)
+		}
+	}
+	if len(behind) == 0 {
+		return nil
+	}
+	sort.Strings(behind)
+
+	return []Finding{{
+		Check:          "release_image_mismatch",
+		Severity:       SeverityWarning,
+		Title:          fmt.Sprintf("%d machine(s) do not run the image of release v%d", len(behind), latest.Version),
+		Detail:         fmt.Sprintf("Release v%d deployed %s.", latest.Version, latest.ImageRef),
+		Machines:       behind,
+		Recommendation: "Redeploy to bring every machine onto the released image.",
+	}}
+}
+
+// checkCrashes reports machines that exited unexpectedly within the window,
+// as crash looping when they did so repeatedly
+func checkCrashes(in Input) []Finding {
+	analysis := AnalyzeCrashes(in.Machines, in.Events, in.Since, in.Now)
+
+	var findings []Finding
+	if looping := analysis.Affected(PatternCrashLoop); len(looping) > 0 {
+		var details []string
+		var machineIDs []string
+		ongoing := 0
+		for _, m := range looping {
+			detail := fmt.Sprintf("%s crashed %d times (%s)", m.MachineID, m.Crashes, DescribeExits(m.Exits))
+			if m.Ongoing {
+				detail += ", still crashing"
+				ongoing++
+			}
+			details = append(details, detail)
+			machineIDs = append(machineIDs, m.MachineID)
+		}
+		sort.Strings(machineIDs)
+
+		severity := SeverityCritical
+		if ongoing == 0 {
+			severity = SeverityWarning
+		}
+		findings = append(findings, Finding{
+			Check:          "crash_loop",
+			Severity:       severity,
+			Title:          fmt.Sprintf("%d machine(s) are crash looping", len(looping)),
+			Detail:         strings.Join(details, "; ") + ".",
+			Machines:       machineIDs,
+			Recommendation: "Use `fly_crashes` for the per-machine breakdown and check the error logs for the cause. A non-zero exit on start usually means a bad release or missing secret; consider rolling back.",
+		})
+	}
+	if crashed := analysis.Affected(PatternCrashed); len(crashed) > 0 {
+		machineIDs := make([]string, 0, len(crashed))
+		for _, m := range crashed {
+			machineIDs = append(machineIDs, m.MachineID)
+		}
+		sort.Strings(machineIDs)
+
+		findings = append(findings, Finding{
+			Check:          "crash",
+			Severity:       SeverityWarning,
+			Title:          fmt.Sprintf("%d machine(s) exited unexpectedly", len(crashed)),
+			Detail:         fmt.Sprintf("Unexpected exits since %s.", in.Since.UTC().Format("2006-01-02 15:04 UTC")),
+			Machines:       machineIDs,
+			Recommendation: "Use `fly_crashes` to see the exit codes.",
+		})
+	}
+	return findings
+}
+
+// exitDescription describes how a machine exited
+func exitDescription(e fly.TimelineEvent) string {
+	switch {
+	case e.OOMKilled:
+		return "an out of memory kill"
+	case e.Signal != 0:
+		return fmt.Sprintf("signal %d", e.Signal)
+	case e.ExitCode != nil:
+		return fmt.Sprintf("exit code %d", *e.ExitCode)
+	default:
+		return "an unknown exit"
+	}
+}
+
+// checkOOM reports machines killed for running out of memory
+func checkOOM(in Input) []Finding {
+	analysis := AnalyzeCrashes(in.Machines, in.Events, in.Since, in.Now)
+	killed := analysis.Affected(PatternOOM)
+	if len(killed) == 0 {
+		return nil
+	}
+
+	machineIDs := make([]string, 0, len(killed))
+	for _, m := range killed {
+		machineIDs = append(machineIDs, m.MachineID)
+	}
+	sort.Strings(machineIDs)
+
+	return []Finding{{
+		Check:          "oom",
+		Severity:       SeverityCritical,
+		Title:          "Machines ran out of memory",
+		Detail:         fmt.Sprintf("%d out of memory kill(s) on %d machine(s) since %s.", analysis.OOMKills, len(killed), in.Since.UTC().Format("2006-01-02 15:04 UTC")),
+		Machines:       machineIDs,
+		Recommendation: "Use `fly_metrics` with the memory metric to size the machines, then give them more memory or reduce the app's usage.",
+	}}
+}
+
+// checkRestartStorms reports machines started many times in a short span,
+// whether by crashes, health checks or repeated restarts
+func checkRestartStorms(in Input) []Finding {
+	analysis := AnalyzeCrashes(in.Machines, in.Events, in.Since, in.Now)
+	storming := analysis.Affected(PatternRestartStorm)
+	if len(storming) == 0 {
+		return nil
+	}
+
+	var details []string
+	machineIDs := make([]string, 0, len(storming))
+	for _, m := range storming {
+		details = append(details, fmt.Sprintf("%s started %d times within %s", m.MachineID, m.PeakStarts, formatWindow(restartStormSpan)))
+		machineIDs = append(machineIDs, m.MachineID)
+	}
+	sort.Strings(machineIDs)
+
+	return []Finding{{
+		Check:          "restart_storm",
+		Severity:       SeverityWarning,
+		Title:          fmt.Sprintf("%d machine(s) are restarting repeatedly", len(storming)),
+		Detail:         strings.Join(details, "; ") + ".",
+		Machines:       machineIDs,
+		Recommendation: "Use `fly_machine_events` to see what triggers the restarts: crashes, failing health checks or restarts requested by a script.",
+	}}
+}
+
+// checkReleases reports a latest release that failed or is stuck
+func checkReleases(in Input) []Finding {
+	if len(in.Releases) == 0 {
+		return nil
+	}
+	latest := in.Releases[0]
+
+	switch {
+	case latest.Status == "failed":
+		return []Finding{{
+			Check:          "failed_release",
+			Severity:       SeverityWarning,
+			Title:          fmt.Sprintf("The latest release v%d failed", latest.Version),
+			Detail:         releaseDetail(latest),
+			Recommendation: "Machines may still run the previous release. Fix the deploy and try again.",
+		}}
+	case latest.InProgress && in.Now.Sub(latest.CreatedAt) > stuckReleaseAge:
+		return []Finding{{
+			Check:          "stuck_release",
+			Severity:       SeverityWarning,
+			Title:          fmt.Sprintf("Release v%d has been in progress for %s", latest.Version, in.Now.Sub(latest.CreatedAt).Round(time.Minute)),
+			Detail:         releaseDetail(latest),
+			Recommendation: "Check whether the deploy is still running; machines may be stuck waiting for health checks.",
+		}}
+	}
+	return nil
+}
+
+// releaseDetail describes who created a release and when
+func releaseDetail(r fly.Release) string {
+	detail := fmt.Sprintf("Created %s", r.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"))
+	if r.User != "" {
+		detail += " by " + r.User
+	}
+	if r.Description != "" {
+		detail += ": " + r.Description
+	}
+	return detail + "."
+}
+
+// checkErrorLogs reports error lines in the recent logs
+func checkErrorLogs(in Input) []Finding {
+	var errors []fly.LogEntry
+	instances := make(map[string]bool)
+	for _, entry := range in.Logs {
+		level := strings.ToLower(entry.Level)
+		if level == "error" || level == "fatal" || level == "panic" {
+			errors = append(errors, entry)
+			if entry.Instance != "" {
+				instances[entry.Instance] = true
+			}
+		}
+	}
+	if len(errors) == 0 {
+		return nil
+	}
+
+	severity := SeverityInfo
+	if len(errors) >= errorLogWarning {
+		severity = SeverityWarning
+	}
+
+	// Quote the most recent lines
+	samples := errors
+	if len(samples) > maxLogSamples {
+		samples = samples[len(samples)-maxLogSamples:]
+	}
+	var quoted []string
+	for _, entry := range samples {
+		quoted = append(quoted, fmt.Sprintf("%q", truncate(entry.Message, 200)))
+	}
+
+	machineIDs := make([]string, 0, len(instances))
+	for id := range instances {
+		machineIDs = append(machineIDs, id)
+	}
+	sort.Strings(machineIDs)
+
+	return []Finding{{
+		Check:          "error_logs",
+		Severity:       severity,
+		Title:          fmt.Sprintf("%d error line(s) in the recent logs", len(errors)),
+		Detail:         "Most recent: " + strings.Join(quoted, ", "),
+		Machines:       machineIDs,
+		Recommendation: "Read the full logs with `flyctl logs` around the time of the errors.",
+	}}
+}
+
+// truncate shortens text for quoting in a finding
+func truncate(text string, max int) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= max {
+		return text
+	}
+	return text[:max] + "…"
+}
+
+// shortDigest abbreviates an image digest for display
+func shortDigest(digest string) string {
+	if len(digest) > 19 {
+		return digest[:19]
+	}
+	return digest
+}
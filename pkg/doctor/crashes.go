@@ -0,0 +1,240 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+const (
+	// restartStormThreshold is the number of starts within
+	// restartStormSpan that makes a machine count as in a restart storm
+	restartStormThreshold = 5
+
+	// restartStormSpan is the span restarts are counted in
+	restartStormSpan = 10 * time.Minute
+
+	// activeLoopAge is how recent a machine's last crash must be for a
+	// crash loop to count as ongoing
+	activeLoopAge = 15 * time.Minute
+)
+
+// Crash patterns a machine can show
+const (
+	PatternCrashLoop    = "crash_loop"
+	PatternRestartStorm = "restart_storm"
+	PatternOOM          = "oom"
+	PatternCrashed      = "crashed"
+)
+
+// ExitCount counts the exits with one cause, e.g. exit code 1 or an out of
+// memory kill
+type ExitCount struct {
+	Cause string `json:"cause"`
+	Count int    `json:"count"`
+}
+
+// MachineCrashes is the crash history of one machine within the window
+type MachineCrashes struct {
+	MachineID string `json:"machineId"`
+	Region    string `json:"region,omitempty"`
+	State     string `json:"state,omitempty"`
+	// Crashes counts exits the machine did not ask for, OOM kills included
+	Crashes  int `json:"crashes"`
+	OOMKills int `json:"oomKills"`
+	// Starts counts every start, and PeakStarts the most within
+	// restartStormSpan
+	Starts     int         `json:"starts"`
+	PeakStarts int         `json:"peakStarts"`
+	Exits      []ExitCount `json:"exits,omitempty"`
+	FirstCrash *time.Time  `json:"firstCrash,omitempty"`
+	LastCrash  *time.Time  `json:"lastCrash,omitempty"`
+	// Patterns lists the crash patterns detected, most severe first
+	Patterns []string `json:"patterns,omitempty"`
+	// Ongoing is set for a crash loop whose last crash is recent
+	Ongoing bool `json:"ongoing,omitempty"`
+}
+
+// Has reports whether the machine shows a crash pattern
+func (m MachineCrashes) Has(pattern string) bool {
+	for _, p := range m.Patterns {
+		if p == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// CrashAnalysis is the crash history of an application's machines within a
+// window
+type CrashAnalysis struct {
+	AppName string    `json:"appName"`
+	Window  string    `json:"window"`
+	Since   time.Time `json:"since"`
+	// Machines holds every machine of the app, those with crashes first
+	Machines []MachineCrashes `json:"machines"`
+	Crashes  int              `json:"crashes"`
+	OOMKills int              `json:"oomKills"`
+}
+
+// Affected returns the machines showing a pattern
+func (a *CrashAnalysis) Affected(pattern string) []MachineCrashes {
+	var affected []MachineCrashes
+	for _, m := range a.Machines {
+		if m.Has(pattern) {
+			affected = append(affected, m)
+		}
+	}
+	return affected
+}
+
+// CollectCrashes reads an application's machines and their events within
+// the window, or only those of one machine when machineID is set, and
+// analyzes them for crashes
+func CollectCrashes(ctx context.Context, client *fly.Client, appName, machineID string, window time.Duration) (*CrashAnalysis, error) {
+	machines, err := client.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	since := now.Add(-window)
+	events, err := client.GetMachineEvents(ctx, appName, fly.EventFilter{MachineID: machineID, Since: since})
+	if err != nil {
+		return nil, err
+	}
+
+	if machineID != "" {
+		for _, m := range machines {
+			if m.ID == machineID {
+				machines = []fly.Machine{m}
+				break
+			}
+		}
+	}
+
+	analysis := AnalyzeCrashes(machines, events, since, now)
+	analysis.AppName = appName
+	analysis.Window = formatWindow(window)
+	return analysis, nil
+}
+
+// AnalyzeCrashes breaks down the exits and starts in events, a timeline
+// since the given time, per machine and detects crash loops, restart storms
+// and OOM kills
+func AnalyzeCrashes(machines []fly.Machine, events []fly.TimelineEvent, since, now time.Time) *CrashAnalysis {
+	analysis := &CrashAnalysis{Since: since, Machines: []MachineCrashes{}}
+
+	byMachine := make(map[string][]fly.TimelineEvent)
+	for _, e := range events {
+		byMachine[e.MachineID] = append(byMachine[e.MachineID], e)
+	}
+
+	seen := make(map[string]bool)
+	add := func(id, region, state string) {
+		seen[id] = true
+		m := analyzeMachine(byMachine[id], now)
+		m.MachineID, m.Region, m.State = id, region, state
+		analysis.Crashes += m.Crashes
+		analysis.OOMKills += m.OOMKills
+		analysis.Machines = append(analysis.Machines, m)
+	}
+	for _, m := range machines {
+		add(m.ID, m.Region, m.State)
+	}
+	// Machines destroyed since their events were read
+	for id, machineEvents := range byMachine {
+		if !seen[id] {
+			add(id, machineEvents[0].Region, "")
+		}
+	}
+
+	sort.SliceStable(analysis.Machines, func(i, j int) bool {
+		a, b := analysis.Machines[i], analysis.Machines[j]
+		if a.Crashes != b.Crashes {
+			return a.Crashes > b.Crashes
+		}
+		if a.PeakStarts != b.PeakStarts {
+			return a.PeakStarts > b.PeakStarts
+		}
+		return a.MachineID < b.MachineID
+	})
+
+	return analysis
+}
+
+// analyzeMachine summarizes the chronological events of one machine
+func analyzeMachine(events []fly.TimelineEvent, now time.Time) MachineCrashes {
+	var m MachineCrashes
+	exits := make(map[string]int)
+	var starts []time.Time
+
+	for _, e := range events {
+		switch {
+		case e.IsCrash():
+			m.Crashes++
+			if e.OOMKilled {
+				m.OOMKills++
+			}
+			exits[exitDescription(e)]++
+			t := e.Time
+			if m.FirstCrash == nil {
+				m.FirstCrash = &t
+			}
+			m.LastCrash = &t
+		case e.Type == "start" || e.Type == "restart":
+			starts = append(starts, e.Time)
+		}
+	}
+
+	m.Starts = len(starts)
+	for i := range starts {
+		n := 1
+		for j := i + 1; j < len(starts) && starts[j].Sub(starts[i]) <= restartStormSpan; j++ {
+			n++
+		}
+		m.PeakStarts = max(m.PeakStarts, n)
+	}
+
+	for cause, count := range exits {
+		m.Exits = append(m.Exits, ExitCount{Cause: cause, Count: count})
+	}
+	sort.Slice(m.Exits, func(i, j int) bool {
+		if m.Exits[i].Count != m.Exits[j].Count {
+			return m.Exits[i].Count > m.Exits[j].Count
+		}
+		return m.Exits[i].Cause < m.Exits[j].Cause
+	})
+
+	if m.Crashes >= crashLoopThreshold {
+		m.Patterns = append(m.Patterns, PatternCrashLoop)
+		m.Ongoing = now.Sub(*m.LastCrash) <= activeLoopAge
+	}
+	if m.OOMKills > 0 {
+		m.Patterns = append(m.Patterns, PatternOOM)
+	}
+	if m.PeakStarts >= restartStormThreshold {
+		m.Patterns = append(m.Patterns, PatternRestartStorm)
+	}
+	if m.Crashes > 0 && m.Crashes < crashLoopThreshold {
+		m.Patterns = append(m.Patterns, PatternCrashed)
+	}
+
+	return m
+}
+
+// DescribeExits lists a machine's exit causes, e.g. "exit code 1 ×3, an out
+// of memory kill ×1"
+func DescribeExits(exits []ExitCount) string {
+	var text string
+	for i, exit := range exits {
+		if i > 0 {
+			text += ", "
+		}
+		text += fmt.Sprintf("%s ×%d", exit.Cause, exit.Count)
+	}
+	return text
+}
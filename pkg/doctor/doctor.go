@@ -0,0 +1,190 @@
+// Package doctor diagnoses a Fly.io application by combining its machines,
+// health checks, machine events, logs and releases into a list of findings.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+// releaseHistory is the number of releases inspected
+const releaseHistory = 5
+
+// Severity describes how urgently a finding needs attention
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+)
+
+// rank orders severities from most to least urgent
+func (s Severity) rank() int {
+	switch s {
+	case SeverityCritical:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Finding is one problem, or notable condition, found in an application
+type Finding struct {
+	Check          string   `json:"check"`
+	Severity       Severity `json:"severity"`
+	Title          string   `json:"title"`
+	Detail         string   `json:"detail,omitempty"`
+	Machines       []string `json:"machines,omitempty"`
+	Recommendation string   `json:"recommendation,omitempty"`
+}
+
+// Report is the outcome of diagnosing an application
+type Report struct {
+	AppName   string    `json:"appName"`
+	Status    string    `json:"status"`
+	Machines  int       `json:"machines"`
+	Window    string    `json:"window"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Findings  []Finding `json:"findings"`
+
+	// Unavailable lists the data sources that could not be read, with the
+	// reason. Checks that depend on them were skipped.
+	Unavailable map[string]string `json:"unavailable,omitempty"`
+}
+
+// Count returns the number of findings with the given severity
+func (r *Report) Count(severity Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Healthy reports whether the diagnosis found nothing that needs attention
+func (r *Report) Healthy() bool {
+	return r.Count(SeverityCritical) == 0 && r.Count(SeverityWarning) == 0
+}
+
+// Input is the data an application is diagnosed from
+type Input struct {
+	App      *fly.App
+	Machines []fly.Machine
+	Events   []fly.TimelineEvent
+	Logs     []fly.LogEntry
+	Releases []fly.Release
+
+	// Since is the start of the window events are considered in
+	Since time.Time
+	Now   time.Time
+}
+
+// Diagnose collects an application's state and analyzes it. Only failing to
+// read the app or its machines is an error; other sources that cannot be read
+// are recorded in the report's Unavailable and their checks skipped.
+func Diagnose(ctx context.Context, client *fly.Client, appName string, window time.Duration) (*Report, error) {
+	app, err := client.GetApp(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := client.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	in := Input{
+		App:      app,
+		Machines: machines,
+		Since:    now.Add(-window),
+		Now:      now,
+	}
+	unavailable := make(map[string]string)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	collect := func(source string, fetch func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fetch(); err != nil {
+				mu.Lock()
+				unavailable[source] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+
+	collect("events", func() (err error) {
+		in.Events, err = client.GetMachineEvents(ctx, appName, fly.EventFilter{Since: in.Since})
+		return err
+	})
+	collect("logs", func() (err error) {
+		in.Logs, err = client.GetRecentLogs(ctx, appName)
+		return err
+	})
+	collect("releases", func() (err error) {
+		in.Releases, err = client.GetReleases(ctx, appName, releaseHistory)
+		return err
+	})
+	wg.Wait()
+
+	report := &Report{
+		AppName:   appName,
+		Status:    app.Status,
+		Machines:  len(machines),
+		Window:    formatWindow(window),
+		CheckedAt: now,
+		Findings:  Analyze(in, unavailable),
+	}
+	if len(unavailable) > 0 {
+		report.Unavailable = unavailable
+	}
+
+	return report, nil
+}
+
+// Analyze runs every check over the input and returns the findings, most
+// urgent first. Checks whose source is listed in unavailable are skipped.
+func Analyze(in Input, unavailable map[string]string) []Finding {
+	findings := []Finding{}
+
+	for _, check := range checks {
+		if check.source != "" {
+			if _, missing := unavailable[check.source]; missing {
+				continue
+			}
+		}
+		findings = append(findings, check.run(in)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity.rank() < findings[j].Severity.rank()
+	})
+
+	return findings
+}
+
+// formatWindow renders a window the way it is usually given, e.g. 30m, 6h
+// or 7d
+func formatWindow(window time.Duration) string {
+	switch {
+	case window > 24*time.Hour && window%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	}
+}
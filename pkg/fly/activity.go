@@ -0,0 +1,179 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kinds of activity in the organization feed
+const (
+	ActivityRelease = "release"
+	ActivityMachine = "machine"
+	ActivitySecret  = "secret"
+)
+
+const (
+	// maxActivityItems caps the organization feed
+	maxActivityItems = 500
+	// activityConcurrency is how many apps are inspected at once
+	activityConcurrency = 8
+	// activityReleases is how many releases are fetched per app
+	activityReleases = 10
+)
+
+// ActivityItem is one change in the organization feed
+type ActivityItem struct {
+	Time      time.Time `json:"time"`
+	AppName   string    `json:"appName"`
+	Kind      string    `json:"kind"`
+	Summary   string    `json:"summary"`
+	MachineID string    `json:"machineId,omitempty"`
+	User      string    `json:"user,omitempty"`
+}
+
+// OrgActivity is the recent activity across an organization's apps
+type OrgActivity struct {
+	Organization string         `json:"organization,omitempty"`
+	Since        time.Time      `json:"since"`
+	Apps         int            `json:"apps"`
+	Counts       map[string]int `json:"counts"`
+	Items        []ActivityItem `json:"items"`
+	// Truncated is set when older items were dropped to fit the feed
+	Truncated bool     `json:"truncated,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// GetOrgActivity collects the releases, machine events and secret changes
+// since a point in time across the apps of the configured organization,
+// newest first. include decides which apps are visible to the caller; apps
+// whose activity cannot be read are reported as warnings.
+func (c *Client) GetOrgActivity(ctx context.Context, since time.Time, include func(appName string) bool) (*OrgActivity, error) {
+	apps, err := c.GetApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := &OrgActivity{
+		Organization: c.config.Organization,
+		Since:        since,
+		Counts:       map[string]int{ActivityRelease: 0, ActivityMachine: 0, ActivitySecret: 0},
+		Items:        []ActivityItem{},
+	}
+
+	var names []string
+	for _, app := range apps {
+		if include == nil || include(app.Name) {
+			names = append(names, app.Name)
+		}
+	}
+	activity.Apps = len(names)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, activityConcurrency)
+	for _, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			items, warnings := c.appActivity(ctx, name, since)
+
+			mu.Lock()
+			defer mu.Unlock()
+			activity.Items = append(activity.Items, items...)
+			activity.Warnings = append(activity.Warnings, warnings...)
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(activity.Items, func(i, j int) bool {
+		return activity.Items[i].Time.After(activity.Items[j].Time)
+	})
+	sort.Strings(activity.Warnings)
+	for _, item := range activity.Items {
+		activity.Counts[item.Kind]++
+	}
+	if len(activity.Items) > maxActivityItems {
+		activity.Items = activity.Items[:maxActivityItems]
+		activity.Truncated = true
+	}
+
+	c.logger.Debug().
+		Int("apps", activity.Apps).
+		Int("items", len(activity.Items)).
+		Msg("Collected organization activity")
+
+	return activity, nil
+}
+
+// appActivity collects one app's activity since a point in time
+func (c *Client) appActivity(ctx context.Context, appName string, since time.Time) ([]ActivityItem, []string) {
+	var items []ActivityItem
+	var warnings []string
+
+	releases, err := c.GetReleases(ctx, appName, activityReleases)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s: releases unavailable", appName))
+	}
+	for _, r := range releases {
+		if r.CreatedAt.Before(since) {
+			continue
+		}
+		summary := fmt.Sprintf("Release v%d %s", r.Version, r.Status)
+		if r.Description != "" {
+			summary += ": " + r.Description
+		}
+		items = append(items, ActivityItem{Time: r.CreatedAt, AppName: appName, Kind: ActivityRelease, Summary: summary, User: r.User})
+	}
+
+	// The machine list carries each machine's most recent events, which
+	// avoids a request per machine
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s: machines unavailable", appName))
+	}
+	for _, m := range machines {
+		for _, e := range m.Events {
+			at := time.UnixMilli(e.Timestamp).UTC()
+			if at.Before(since) {
+				continue
+			}
+			summary := fmt.Sprintf("Machine %s (%s) %s", m.ID, m.Region, e.Type)
+			if e.Status != "" && e.Status != e.Type {
+				summary += " → " + e.Status
+			}
+			if e.Request != nil && e.Request.ExitEvent != nil {
+				exit := e.Request.ExitEvent
+				switch {
+				case exit.OOMKilled:
+					summary += " (out of memory)"
+				case !exit.RequestedStop:
+					summary += fmt.Sprintf(" (exit code %d)", exit.ExitCode)
+				}
+			}
+			items = append(items, ActivityItem{Time: at, AppName: appName, Kind: ActivityMachine, Summary: summary, MachineID: m.ID})
+		}
+	}
+
+	secrets, err := c.GetSecrets(ctx, appName)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s: secrets unavailable", appName))
+	}
+	for _, s := range secrets {
+		changed := s.CreatedAt
+		if s.UpdatedAt.After(changed) {
+			changed = s.UpdatedAt
+		}
+		if changed.Before(since) {
+			continue
+		}
+		items = append(items, ActivityItem{Time: changed, AppName: appName, Kind: ActivitySecret, Summary: fmt.Sprintf("Secret %s set", s.Name)})
+	}
+
+	return items, warnings
+}
@@ -0,0 +1,180 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// Values accepted for a service's autostop setting
+const (
+	AutoStopOff     = "off"
+	AutoStopStop    = "stop"
+	AutoStopSuspend = "suspend"
+)
+
+// ServiceAutoscale is the Fly Proxy autostart/autostop configuration of one
+// service on one machine
+type ServiceAutoscale struct {
+	MachineID          string `json:"machineId"`
+	Region             string `json:"region"`
+	State              string `json:"state"`
+	Protocol           string `json:"protocol"`
+	InternalPort       int    `json:"internalPort"`
+	AutoStop           string `json:"autoStop"`
+	AutoStart          bool   `json:"autoStart"`
+	MinMachinesRunning int    `json:"minMachinesRunning"`
+}
+
+// AutoscalePolicy summarizes how the Fly Proxy scales an application
+type AutoscalePolicy struct {
+	AppName         string             `json:"appName"`
+	MachineCount    int                `json:"machineCount"`
+	RunningMachines int                `json:"runningMachines"`
+	Services        []ServiceAutoscale `json:"services"`
+	// MachinesWithoutServices are never stopped or started by the proxy
+	MachinesWithoutServices []string `json:"machinesWithoutServices,omitempty"`
+}
+
+// AutoscaleUpdate lists the autoscaling settings to change; nil fields are
+// left as they are
+type AutoscaleUpdate struct {
+	AutoStop           *string `json:"autoStop,omitempty"`
+	AutoStart          *bool   `json:"autoStart,omitempty"`
+	MinMachinesRunning *int    `json:"minMachinesRunning,omitempty"`
+}
+
+// IsEmpty reports whether the update changes nothing
+func (u AutoscaleUpdate) IsEmpty() bool {
+	return u.AutoStop == nil && u.AutoStart == nil && u.MinMachinesRunning == nil
+}
+
+// machineServices returns the services in a machine config
+func machineServices(machineConfig map[string]interface{}) []map[string]interface{} {
+	raw, ok := machineConfig["services"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	services := make([]map[string]interface{}, 0, len(raw))
+	for _, s := range raw {
+		if service, ok := s.(map[string]interface{}); ok {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// serviceAutoscale reads the autoscaling settings of a machine service
+func serviceAutoscale(machine Machine, service map[string]interface{}) ServiceAutoscale {
+	sa := ServiceAutoscale{
+		MachineID: machine.ID,
+		Region:    machine.Region,
+		State:     machine.State,
+		AutoStop:  AutoStopOff,
+	}
+
+	sa.Protocol, _ = service["protocol"].(string)
+	if port, ok := service["internal_port"].(float64); ok {
+		sa.InternalPort = int(port)
+	}
+
+	// Older machines store autostop as a boolean
+	switch v := service["autostop"].(type) {
+	case bool:
+		if v {
+			sa.AutoStop = AutoStopStop
+		}
+	case string:
+		if v != "" {
+			sa.AutoStop = v
+		}
+	}
+
+	sa.AutoStart, _ = service["autostart"].(bool)
+	if minRunning, ok := service["min_machines_running"].(float64); ok {
+		sa.MinMachinesRunning = int(minRunning)
+	}
+
+	return sa
+}
+
+// GetAutoscalePolicy reads the autostart/autostop settings of every service
+// on an application's machines
+func (c *Client) GetAutoscalePolicy(ctx context.Context, appName string) (*AutoscalePolicy, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	policy := &AutoscalePolicy{
+		AppName:      appName,
+		MachineCount: len(machines),
+		Services:     []ServiceAutoscale{},
+	}
+
+	for _, m := range machines {
+		if m.State == "started" {
+			policy.RunningMachines++
+		}
+
+		services := machineServices(m.Config)
+		if len(services) == 0 {
+			policy.MachinesWithoutServices = append(policy.MachinesWithoutServices, m.ID)
+			continue
+		}
+		for _, service := range services {
+			policy.Services = append(policy.Services, serviceAutoscale(m, service))
+		}
+	}
+
+	return policy, nil
+}
+
+// UpdateAutoscalePolicy applies update to every service on the application's
+// machines and returns the IDs of the machines that were changed. Machines
+// without services are skipped since the proxy never scales them.
+func (c *Client) UpdateAutoscalePolicy(ctx context.Context, appName string, update AutoscaleUpdate) ([]string, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	var targets []Machine
+	for _, m := range machines {
+		if len(machineServices(m.Config)) > 0 {
+			targets = append(targets, m)
+		}
+	}
+
+	var updated []string
+	for _, m := range targets {
+		for _, service := range machineServices(m.Config) {
+			if update.AutoStop != nil {
+				service["autostop"] = *update.AutoStop
+			}
+			if update.AutoStart != nil {
+				service["autostart"] = *update.AutoStart
+			}
+			if update.MinMachinesRunning != nil {
+				service["min_machines_running"] = *update.MinMachinesRunning
+			}
+		}
+
+		// Services are maps shared with m.Config, so the config now holds
+		// the new settings
+		if _, err := c.machinesClient.UpdateMachineConfig(ctx, appName, m.ID, m.Config, m.State != "started"); err != nil {
+			return updated, fmt.Errorf("failed to update machine %s (%d of %d machines updated): %w", m.ID, len(updated), len(targets), err)
+		}
+		updated = append(updated, m.ID)
+		interfaces.ReportProgress(ctx, float64(len(updated)), float64(len(targets)), fmt.Sprintf("updated %d/%d machines", len(updated), len(targets)))
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Int("machines_updated", len(updated)).
+		Msg("Updated autoscaling settings")
+
+	return updated, nil
+}
@@ -0,0 +1,351 @@
+package fly
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// cassetteVersion is the version of the cassette file format
+const cassetteVersion = 1
+
+// ErrNotRecorded is returned when replaying a call the cassette has no
+// recording of
+var ErrNotRecorded = errors.New("call not recorded in cassette")
+
+// recordedHeaders are the response headers kept in a cassette; the rest,
+// cookies included, are dropped
+var recordedHeaders = []string{"Content-Type", "Docker-Content-Digest", "Link", "Retry-After", requestIDHeader}
+
+// Interaction is one recorded Fly.io API call
+type Interaction struct {
+	API    string `json:"api"`
+	Method string `json:"method"`
+	URL    string `json:"url"` // path and query
+	Body   string `json:"body,omitempty"`
+
+	Status   int               `json:"status,omitempty"`
+	Header   map[string]string `json:"header,omitempty"`
+	Response string            `json:"response,omitempty"`
+	// Error is set instead of a response when the call failed in transit
+	Error string `json:"error,omitempty"`
+}
+
+// cassetteFile is the on-disk format of a cassette
+type cassetteFile struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Cassette is a file of Fly.io API calls, recorded from live traffic or
+// replayed in place of it. Request bodies, URLs and responses are stored
+// with tokens and sensitive fields masked.
+type Cassette struct {
+	mu           sync.Mutex
+	mode         string
+	path         string
+	opened       bool
+	interactions []Interaction
+	replayed     []bool
+}
+
+var (
+	cassettesMu sync.Mutex
+	cassettes   = make(map[string]*Cassette)
+)
+
+// Redactors for recorded bodies. Request bodies also mask "value" fields,
+// which carry secret values in setSecrets mutations.
+var (
+	responseRedactor = logger.NewRedactor(nil)
+	requestRedactor  = logger.NewRedactor([]string{"value"})
+)
+
+// cassetteFor returns the cassette a config selects, or nil if it selects
+// none. Every client of a config shares one cassette, which also survives
+// configuration reloads, so a recording holds all calls in order.
+func cassetteFor(cfg *config.FlyConfig) *Cassette {
+	if cfg.Cassette.Mode == "" {
+		return nil
+	}
+
+	cassettesMu.Lock()
+	defer cassettesMu.Unlock()
+
+	key := cfg.Cassette.Mode + ":" + cfg.Cassette.Path
+	c, ok := cassettes[key]
+	if !ok {
+		c = &Cassette{mode: cfg.Cassette.Mode, path: cfg.Cassette.Path}
+		cassettes[key] = c
+	}
+	return c
+}
+
+// open reads a cassette to replay, or starts a new recording. Later calls
+// do nothing.
+func (c *Cassette) open() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.opened {
+		return nil
+	}
+	if c.mode == config.CassetteRecord {
+		if err := c.saveLocked(); err != nil {
+			return err
+		}
+		c.opened = true
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse cassette %s: %w", c.path, err)
+	}
+	if file.Version != cassetteVersion {
+		return fmt.Errorf("cassette %s has unsupported version %d", c.path, file.Version)
+	}
+	c.interactions = file.Interactions
+	c.replayed = make([]bool, len(file.Interactions))
+	c.opened = true
+	return nil
+}
+
+// record appends a call to the cassette and saves it, so a recording is
+// complete up to the last call even if the server is killed
+func (c *Cassette) record(in Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, in)
+	return c.saveLocked()
+}
+
+// saveLocked writes the cassette through a temporary file, so readers never
+// see a partial one
+func (c *Cassette) saveLocked() error {
+	data, err := json.MarshalIndent(cassetteFile{Version: cassetteVersion, Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// replay finds the recorded answer to a call. Recordings are used in order:
+// the first unused one with the same request wins, then the first unused
+// one for the same method and path (bodies and queries can carry
+// timestamps), and finally the last one with the same request, so polling
+// loops settle on the final recorded state.
+func (c *Cassette) replay(in Interaction) (Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sameRequest := func(r Interaction) bool {
+		return r.API == in.API && r.Method == in.Method && r.URL == in.URL && r.Body == in.Body
+	}
+	samePath := func(r Interaction) bool {
+		return r.API == in.API && r.Method == in.Method && urlPath(r.URL) == urlPath(in.URL)
+	}
+
+	for _, match := range []func(Interaction) bool{sameRequest, samePath} {
+		for i, r := range c.interactions {
+			if !c.replayed[i] && match(r) {
+				c.replayed[i] = true
+				return r, nil
+			}
+		}
+	}
+	for i := len(c.interactions) - 1; i >= 0; i-- {
+		if sameRequest(c.interactions[i]) {
+			return c.interactions[i], nil
+		}
+	}
+	return Interaction{}, fmt.Errorf("%w: %s %s", ErrNotRecorded, in.Method, in.URL)
+}
+
+// cassetteTransport records calls to a cassette or answers them from it.
+// It sits below apiTransport, so each retry attempt is a call of its own.
+type cassetteTransport struct {
+	cassette *Cassette
+	api      string
+	base     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.cassette.open(); err != nil {
+		return nil, err
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	call := Interaction{
+		API:    t.api,
+		Method: req.Method,
+		URL:    responseRedactor.RedactString(req.URL.RequestURI()),
+		Body:   sanitizeBody(requestRedactor, body),
+	}
+
+	if t.cassette.mode == config.CassetteReplay {
+		recorded, err := t.cassette.replay(call)
+		if err != nil {
+			return nil, err
+		}
+		if recorded.Error != "" {
+			return nil, errors.New(recorded.Error)
+		}
+		return recordedResponse(req, recorded), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		call.Error = err.Error()
+	} else {
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+
+		call.Status = resp.StatusCode
+		call.Header = make(map[string]string)
+		for _, name := range recordedHeaders {
+			if value := resp.Header.Get(name); value != "" {
+				call.Header[name] = value
+			}
+		}
+		call.Response = sanitizeBody(responseRedactor, data)
+	}
+
+	if recordErr := t.cassette.record(call); recordErr != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, recordErr
+	}
+	return resp, err
+}
+
+// readRequestBody returns a request's body, leaving the request readable
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// recordedResponse builds the response to a replayed call
+func recordedResponse(req *http.Request, recorded Interaction) *http.Response {
+	header := make(http.Header)
+	for name, value := range recorded.Header {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Response)),
+		ContentLength: int64(len(recorded.Response)),
+		Request:       req,
+	}
+}
+
+// sanitizeBody masks credentials in a body. JSON bodies keep their shape,
+// so they still decode when replayed: only string values of sensitive
+// fields are replaced.
+func sanitizeBody(r *logger.Redactor, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return r.RedactString(string(body))
+	}
+	data, err := json.Marshal(redactStrings(r, value))
+	if err != nil {
+		return r.RedactString(string(body))
+	}
+	return string(data)
+}
+
+// redactStrings masks the string values of sensitive fields and any
+// credentials embedded in other strings
+func redactStrings(r *logger.Redactor, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if _, ok := val.(string); ok && r.IsSensitive(key) {
+				v[key] = logger.RedactedValue
+				continue
+			}
+			v[key] = redactStrings(r, val)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactStrings(r, v[i])
+		}
+	case string:
+		return r.RedactString(v)
+	}
+	return value
+}
+
+// urlPath strips the query from a recorded URL
+func urlPath(url string) string {
+	path, _, _ := strings.Cut(url, "?")
+	return path
+}
@@ -3,43 +3,73 @@ package fly
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/tokens"
 	"github.com/brannn/fly-mcp/internal/logger"
 	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
 )
 
 // Client wraps the Fly.io API client with additional functionality
 type Client struct {
-	flyClient      *fly.Client
-	machinesClient *MachinesClient
-	logger         *logger.Logger
-	config         *config.FlyConfig
+	flyClient        *fly.Client
+	machinesClient   *MachinesClient
+	prometheusClient *PrometheusClient
+	registryClient   *RegistryClient
+	graphqlHTTP      *http.Client
+	logger           *logger.Logger
+	config           *config.FlyConfig
 }
 
 // NewClient creates a new Fly.io API client
 func NewClient(cfg *config.FlyConfig, log *logger.Logger) (*Client, error) {
-	if cfg.APIToken == "" {
+	// A replay answers every call from the cassette, so it needs no token
+	if cfg.APIToken == "" && cfg.Cassette.Mode != config.CassetteReplay {
 		return nil, fmt.Errorf("Fly.io API token is required")
 	}
+	if cassette := cassetteFor(cfg); cassette != nil {
+		if err := cassette.open(); err != nil {
+			return nil, fmt.Errorf("failed to open cassette: %w", err)
+		}
+	}
+
+	// fly-go reads app logs from its package-level base URL rather than the
+	// client's
+	fly.SetBaseURL(cfg.APIURL)
 
-	// Create Fly.io client
+	// Create Fly.io client. fly-go authenticates and retries GraphQL requests
+	// itself, so the shared transport only adds request logging here, and
+	// swaps in a profile's token for calls that select one.
 	flyClient := fly.NewClientFromOptions(fly.ClientOptions{
 		AccessToken: cfg.APIToken,
-		BaseURL:     cfg.BaseURL,
+		BaseURL:     cfg.APIURL,
 		Name:        "fly-mcp",
 		Version:     "0.1.0",
+		Transport: &fly.Transport{
+			UnderlyingTransport: &apiTransport{
+				base:    baseTransport("graphql", cfg),
+				api:     "graphql",
+				auth:    tokenAuth,
+				logger:  log,
+				timeout: requestTimeout(cfg),
+			},
+		},
 	})
 
 	// Create Machines API client
 	machinesClient := NewMachinesClient(cfg, log)
 
 	client := &Client{
-		flyClient:      flyClient,
-		machinesClient: machinesClient,
-		logger:         log,
-		config:         cfg,
+		flyClient:        flyClient,
+		machinesClient:   machinesClient,
+		prometheusClient: NewPrometheusClient(cfg, log),
+		registryClient:   NewRegistryClient(cfg, log),
+		graphqlHTTP:      newAPIHTTPClient("graphql", cfg, tokenAuth, log),
+		logger:           log,
+		config:           cfg,
 	}
 
 	// Validate the client by checking authentication
@@ -61,10 +91,21 @@ func (c *Client) validateAuth(ctx context.Context) error {
 	
 	// Try to get the current user to validate the token
 	_, err := c.flyClient.GetCurrentUser(ctx)
+	err = parseGraphQLError(err)
 	duration := time.Since(start)
 	
 	c.logger.LogFlyAPICall("/user", "GET", getStatusCode(err), duration)
 	
+	// Macaroon tokens such as deploy tokens need not belong to a user, so
+	// failing to look one up does not make the token invalid. Calls the
+	// token cannot make fail individually instead.
+	if err != nil && len(tokens.Parse(c.config.APIToken).GetMacaroonTokens()) > 0 {
+		c.logger.Warn().
+			Err(err).
+			Msg("Macaroon token has no user; continuing with the token's own scope")
+		return nil
+	}
+	
 	if err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
@@ -84,6 +125,7 @@ func (c *Client) GetApps(ctx context.Context) ([]App, error) {
 	} else {
 		apps, err = c.flyClient.GetApps(ctx, nil)
 	}
+	err = parseGraphQLError(err)
 
 	duration := time.Since(start)
 	c.logger.LogFlyAPICall("/apps", "GET", getStatusCode(err), duration)
@@ -102,7 +144,17 @@ func (c *Client) GetApps(ctx context.Context) ([]App, error) {
 			Deployed: app.Deployed,
 			Hostname: app.Hostname,
 			AppURL:   app.AppURL,
-			// Note: Organization and timestamps may not be available in all API responses
+		}
+		if app.Organization.Slug != "" {
+			result[i].Organization = &fly.OrganizationBasic{
+				ID:   app.Organization.ID,
+				Name: app.Organization.Name,
+				Slug: app.Organization.Slug,
+			}
+		}
+		if app.CurrentRelease != nil && !app.CurrentRelease.CreatedAt.IsZero() {
+			updated := app.CurrentRelease.CreatedAt
+			result[i].UpdatedAt = &updated
 		}
 	}
 
@@ -114,11 +166,58 @@ func (c *Client) GetApps(ctx context.Context) ([]App, error) {
 	return result, nil
 }
 
-// GetApp retrieves detailed information about a specific application
+// GetApp retrieves detailed information about a specific application: its
+// organization, timestamps, regions, current release and services
 func (c *Client) GetApp(ctx context.Context, appName string) (*App, error) {
 	start := time.Now()
 
-	app, err := c.flyClient.GetAppCompact(ctx, appName)
+	var app struct {
+		ID              string     `json:"id"`
+		Name            string     `json:"name"`
+		Status          string     `json:"status"`
+		Deployed        bool       `json:"deployed"`
+		Hostname        string     `json:"hostname"`
+		AppURL          string     `json:"appUrl"`
+		Network         string     `json:"network"`
+		PlatformVersion string     `json:"platformVersion"`
+		CreatedAt       *time.Time `json:"createdAt"`
+		Organization    *struct {
+			ID       string `json:"id"`
+			Slug     string `json:"slug"`
+			Name     string `json:"name"`
+			PaidPlan bool   `json:"paidPlan"`
+		} `json:"organization"`
+		CurrentRelease *struct {
+			Version     int       `json:"version"`
+			Status      string    `json:"status"`
+			Description string    `json:"description"`
+			Reason      string    `json:"reason"`
+			ImageRef    string    `json:"imageRef"`
+			Stable      bool      `json:"stable"`
+			InProgress  bool      `json:"inProgress"`
+			CreatedAt   time.Time `json:"createdAt"`
+			User        *struct {
+				Email string `json:"email"`
+			} `json:"user"`
+		} `json:"currentRelease"`
+		Regions []struct {
+			Code string `json:"code"`
+		} `json:"regions"`
+		Services []AppService `json:"services"`
+	}
+
+	batch := c.newGraphQLBatch()
+	batch.Add("app", `app(name: $appName) {
+		id name status deployed hostname appUrl network platformVersion createdAt
+		organization { id slug name paidPlan }
+		currentRelease { version status description reason imageRef stable inProgress createdAt user { email } }
+		regions { code }
+		services { description protocol internalPort ports { port handlers } }
+	}`, map[string]batchVar{"appName": {Type: "String!", Value: appName}}, &app)
+	err := batch.Run(ctx)
+	if err == nil {
+		err = batch.Err("app")
+	}
 	duration := time.Since(start)
 
 	c.logger.LogFlyAPICall(fmt.Sprintf("/apps/%s", appName), "GET", getStatusCode(err), duration)
@@ -128,18 +227,51 @@ func (c *Client) GetApp(ctx context.Context, appName string) (*App, error) {
 	}
 
 	result := &App{
-		ID:       app.ID,
-		Name:     app.Name,
-		Status:   app.Status,
-		Deployed: app.Deployed,
-		Hostname: app.Hostname,
-		AppURL:   app.AppURL,
-		// Note: Organization and timestamps may not be available in AppCompact
+		ID:              app.ID,
+		Name:            app.Name,
+		Status:          app.Status,
+		Deployed:        app.Deployed,
+		Hostname:        app.Hostname,
+		AppURL:          app.AppURL,
+		Network:         app.Network,
+		PlatformVersion: app.PlatformVersion,
+		CreatedAt:       app.CreatedAt,
+		Services:        app.Services,
+	}
+	if app.Organization != nil {
+		result.Organization = &fly.OrganizationBasic{
+			ID:       app.Organization.ID,
+			Name:     app.Organization.Name,
+			Slug:     app.Organization.Slug,
+			PaidPlan: app.Organization.PaidPlan,
+		}
+	}
+	if r := app.CurrentRelease; r != nil {
+		result.CurrentRelease = &Release{
+			Version:     r.Version,
+			Status:      r.Status,
+			Description: r.Description,
+			Reason:      r.Reason,
+			ImageRef:    r.ImageRef,
+			Stable:      r.Stable,
+			InProgress:  r.InProgress,
+			CreatedAt:   r.CreatedAt,
+		}
+		if r.User != nil {
+			result.CurrentRelease.User = r.User.Email
+		}
+		if !r.CreatedAt.IsZero() {
+			updated := r.CreatedAt
+			result.UpdatedAt = &updated
+		}
+	}
+	for _, region := range app.Regions {
+		result.Regions = append(result.Regions, region.Code)
 	}
 
 	c.logger.Debug().
 		Str("app_name", appName).
-		Str("status", app.Status).
+		Str("status", result.Status).
 		Msg("Retrieved app details from Fly.io")
 
 	return result, nil
@@ -151,6 +283,7 @@ func (c *Client) GetAppStatus(ctx context.Context, appName string) (*AppStatus,
 
 	// Get basic app info from GraphQL API
 	app, err := c.flyClient.GetAppCompact(ctx, appName)
+	err = parseGraphQLError(err)
 	if err != nil {
 		duration := time.Since(start)
 		c.logger.LogFlyAPICall(fmt.Sprintf("/apps/%s", appName), "GET", getStatusCode(err), duration)
@@ -203,6 +336,38 @@ func (c *Client) GetAppStatus(ctx context.Context, appName string) (*AppStatus,
 	return status, nil
 }
 
+// ListMachines retrieves all machines for an application, including their
+// health check results
+func (c *Client) ListMachines(ctx context.Context, appName string) ([]Machine, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+	return machines, nil
+}
+
+// ExecMachine runs a command inside one of an application's machines
+func (c *Client) ExecMachine(ctx context.Context, appName, machineID string, command []string, timeout time.Duration) (*ExecResult, error) {
+	result, err := c.machinesClient.ExecMachine(ctx, appName, machineID, command, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exec on machine %s: %w", machineID, err)
+	}
+	return result, nil
+}
+
+// QueryMetrics runs a PromQL range query against the organization's hosted
+// Prometheus metrics. If orgSlug is empty the configured organization is used.
+func (c *Client) QueryMetrics(ctx context.Context, orgSlug, query string, start, end time.Time, step time.Duration) ([]MetricSeries, error) {
+	if orgSlug == "" {
+		orgSlug = c.config.Organization
+	}
+	series, err := c.prometheusClient.QueryRange(ctx, orgSlug, query, start, end, step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	return series, nil
+}
+
 // RestartApp restarts an application by restarting all its machines
 func (c *Client) RestartApp(ctx context.Context, appName string) error {
 	start := time.Now()
@@ -224,6 +389,16 @@ func (c *Client) RestartApp(ctx context.Context, appName string) error {
 	successCount := 0
 
 	for _, machine := range machines {
+		// Stop before the next machine if the request was cancelled
+		if err := ctx.Err(); err != nil {
+			c.logger.Warn().
+				Str("app_name", appName).
+				Int("success_count", successCount).
+				Int("machine_count", len(machines)).
+				Msg("Restart cancelled")
+			return fmt.Errorf("restart cancelled after %d of %d machines: %w", successCount, len(machines), context.Cause(ctx))
+		}
+
 		if err := c.machinesClient.RestartMachine(ctx, appName, machine.ID); err != nil {
 			c.logger.Error().
 				Str("app_name", appName).
@@ -234,6 +409,13 @@ func (c *Client) RestartApp(ctx context.Context, appName string) error {
 		} else {
 			successCount++
 		}
+
+		done := successCount + len(restartErrors)
+		message := fmt.Sprintf("restarted %d/%d machines", successCount, len(machines))
+		if len(restartErrors) > 0 {
+			message += fmt.Sprintf(" (%d failed)", len(restartErrors))
+		}
+		interfaces.ReportProgress(ctx, float64(done), float64(len(machines)), message)
 	}
 
 	duration := time.Since(start)
@@ -260,14 +442,220 @@ func (c *Client) RestartApp(ctx context.Context, appName string) error {
 	return nil
 }
 
+// CreateApp creates a new application in the given organization.
+// If orgSlug is empty the configured organization is used.
+func (c *Client) CreateApp(ctx context.Context, appName, orgSlug, region string) (*App, error) {
+	start := time.Now()
+
+	if orgSlug == "" {
+		orgSlug = c.config.Organization
+	}
+	if orgSlug == "" {
+		return nil, fmt.Errorf("organization is required to create an app")
+	}
+
+	org, err := c.flyClient.GetOrganizationBySlug(ctx, orgSlug)
+	err = parseGraphQLError(err)
+	if err != nil {
+		c.logger.LogFlyAPICall(fmt.Sprintf("/orgs/%s", orgSlug), "GET", getStatusCode(err), time.Since(start))
+		return nil, fmt.Errorf("failed to resolve organization %s: %w", orgSlug, err)
+	}
+
+	input := fly.CreateAppInput{
+		OrganizationID: org.ID,
+		Name:           appName,
+		Machines:       true,
+	}
+	if region != "" {
+		input.PreferredRegion = &region
+	}
+
+	app, err := c.flyClient.CreateApp(ctx, input)
+	err = parseGraphQLError(err)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall("/graphql/createApp", "POST", getStatusCode(err), duration)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app %s: %w", appName, err)
+	}
+
+	result := &App{
+		ID:       app.ID,
+		Name:     app.Name,
+		Status:   app.Status,
+		Deployed: app.Deployed,
+		Hostname: app.Hostname,
+		AppURL:   app.AppURL,
+		Organization: &fly.OrganizationBasic{
+			ID:   org.ID,
+			Name: org.Name,
+			Slug: org.Slug,
+		},
+	}
+
+	c.logger.Info().
+		Str("app_name", app.Name).
+		Str("organization", orgSlug).
+		Str("region", region).
+		Msg("Created app on Fly.io")
+
+	return result, nil
+}
+
+// DeleteApp permanently deletes an application and all of its resources
+func (c *Client) DeleteApp(ctx context.Context, appName string) error {
+	start := time.Now()
+
+	err := parseGraphQLError(c.flyClient.DeleteApp(ctx, appName))
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall("/graphql/deleteApp", "POST", getStatusCode(err), duration)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete app %s: %w", appName, err)
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Msg("Deleted app on Fly.io")
+
+	return nil
+}
+
+// GetCurrentUser returns the Fly.io user the API token belongs to
+func (c *Client) GetCurrentUser(ctx context.Context) (*User, error) {
+	start := time.Now()
+
+	user, err := c.flyClient.GetCurrentUser(ctx)
+	err = parseGraphQLError(err)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall("/user", "GET", getStatusCode(err), duration)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	return &User{
+		ID:    user.ID,
+		Email: user.Email,
+		Name:  user.Name,
+	}, nil
+}
+
+// GetOrganization retrieves an organization by slug.
+// If orgSlug is empty the configured organization is used.
+func (c *Client) GetOrganization(ctx context.Context, orgSlug string) (*Organization, error) {
+	start := time.Now()
+
+	if orgSlug == "" {
+		orgSlug = c.config.Organization
+	}
+	if orgSlug == "" {
+		return nil, fmt.Errorf("no organization configured")
+	}
+
+	org, err := c.flyClient.GetOrganizationBySlug(ctx, orgSlug)
+	err = parseGraphQLError(err)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall(fmt.Sprintf("/orgs/%s", orgSlug), "GET", getStatusCode(err), duration)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization %s: %w", orgSlug, err)
+	}
+
+	return &Organization{
+		ID:   org.ID,
+		Name: org.Name,
+		Slug: org.Slug,
+		Type: org.Type,
+	}, nil
+}
+
+// GetOrganizations lists the organizations the API token can access
+func (c *Client) GetOrganizations(ctx context.Context) ([]Organization, error) {
+	start := time.Now()
+
+	orgs, err := c.flyClient.GetOrganizations(ctx)
+	err = parseGraphQLError(err)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall("/orgs", "GET", getStatusCode(err), duration)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organizations: %w", err)
+	}
+
+	result := make([]Organization, len(orgs))
+	for i, org := range orgs {
+		result[i] = Organization{
+			ID:   org.ID,
+			Name: org.Name,
+			Slug: org.Slug,
+			Type: org.Type,
+		}
+	}
+	return result, nil
+}
+
+// Identity is the Fly.io user behind the API token and the configured
+// organization. Either half may fail on its own.
+type Identity struct {
+	User            *User
+	UserErr         error
+	Organization    *Organization
+	OrganizationErr error
+}
+
+// GetIdentity fetches the current user and the configured organization in
+// a single batched GraphQL request
+func (c *Client) GetIdentity(ctx context.Context) (*Identity, error) {
+	identity := &Identity{}
+
+	var viewer User
+	var org Organization
+
+	batch := c.newGraphQLBatch()
+	batch.Add("viewer", `viewer { ... on User { id email name } ... on Macaroon { email } }`, nil, &viewer)
+	if c.config.Organization != "" {
+		batch.Add("organization", `organization(slug: $slug) { id name slug type }`, map[string]batchVar{
+			"slug": {Type: "String!", Value: c.config.Organization},
+		}, &org)
+	} else {
+		identity.OrganizationErr = fmt.Errorf("no organization configured")
+	}
+
+	if err := batch.Run(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	if err := batch.Err("viewer"); err != nil {
+		identity.UserErr = fmt.Errorf("failed to get current user: %w", err)
+	} else {
+		identity.User = &viewer
+	}
+
+	if c.config.Organization != "" {
+		if err := batch.Err("organization"); err != nil {
+			identity.OrganizationErr = fmt.Errorf("failed to get organization %s: %w", c.config.Organization, err)
+		} else {
+			identity.Organization = &org
+		}
+	}
+
+	return identity, nil
+}
+
 // getStatusCode extracts HTTP status code from error or returns 200 for success
 func getStatusCode(err error) int {
 	if err == nil {
 		return 200
 	}
-	
-	// Try to extract status code from error
-	// This is a simplified approach - in a real implementation,
-	// you might want to parse the error more carefully
+
+	if flyErr, ok := AsFlyError(err); ok && flyErr.StatusCode != 0 {
+		return flyErr.StatusCode
+	}
 	return 500
 }
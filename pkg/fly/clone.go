@@ -0,0 +1,404 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// CloneRequest describes a copy of an existing machine
+type CloneRequest struct {
+	// MachineID is the machine whose config is copied
+	MachineID string
+	// Region places the clone; the source machine's region when empty
+	Region string
+	// Name names the clone; Fly.io picks a name when empty
+	Name string
+	// HealthTimeout is how long to wait for the clone to pass its health
+	// checks; zero does not wait
+	HealthTimeout time.Duration
+}
+
+// ClonedMachine is a machine created as a copy of another
+type ClonedMachine struct {
+	SourceID  string `json:"sourceId"`
+	MachineID string `json:"machineId,omitempty"`
+	Region    string `json:"region"`
+	Image     string `json:"image,omitempty"`
+	// Volumes are the new, empty volumes created for the source machine's
+	// mounts
+	Volumes []string `json:"volumes,omitempty"`
+	Healthy bool     `json:"healthy"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// CloneMachine creates a new machine with the config of an existing one,
+// optionally in another region. A new empty volume of the same size is
+// created for each volume the source machine mounts, since a volume can
+// only be attached to one machine. The clone is returned along with an
+// error when it does not become healthy within the health timeout.
+func (c *Client) CloneMachine(ctx context.Context, appName string, req CloneRequest) (*ClonedMachine, error) {
+	source, err := c.machinesClient.GetMachine(ctx, appName, req.MachineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine %s: %w", req.MachineID, err)
+	}
+	return c.cloneMachine(ctx, appName, *source, req)
+}
+
+// cloneMachine copies source into a new machine
+func (c *Client) cloneMachine(ctx context.Context, appName string, source Machine, req CloneRequest) (*ClonedMachine, error) {
+	if machineSchedule(source) != "" {
+		return nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("machine %s is a scheduled task; use fly_scheduled_tasks to create another", source.ID),
+		}
+	}
+
+	region := req.Region
+	if region == "" {
+		region = source.Region
+	}
+	clone := &ClonedMachine{
+		SourceID: source.ID,
+		Region:   region,
+		Image:    configImage(source),
+	}
+
+	machineConfig := maps.Clone(source.Config)
+	if machineConfig == nil {
+		machineConfig = make(map[string]interface{})
+	}
+	mounts, err := c.cloneMounts(ctx, appName, source, region)
+	if err != nil {
+		return nil, err
+	}
+	if len(mounts) > 0 {
+		machineConfig["mounts"] = mounts
+		for _, mount := range mounts {
+			clone.Volumes = append(clone.Volumes, mount["volume"].(string))
+		}
+	}
+
+	started := time.Now()
+	machine, err := c.machinesClient.CreateMachine(ctx, appName, CreateMachineRequest{
+		Name:   req.Name,
+		Region: region,
+		Config: machineConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone machine %s: %w", source.ID, err)
+	}
+	clone.MachineID = machine.ID
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("source_id", source.ID).
+		Str("machine_id", machine.ID).
+		Str("region", region).
+		Msg("Cloned machine")
+
+	if req.HealthTimeout > 0 {
+		if err := c.waitForHealthy(ctx, appName, machine.ID, started, req.HealthTimeout); err != nil {
+			clone.Error = err.Error()
+			return clone, err
+		}
+	}
+	clone.Healthy = true
+	return clone, nil
+}
+
+// cloneMounts creates an empty volume in region for each volume source
+// mounts and returns the mounts of the clone
+func (c *Client) cloneMounts(ctx context.Context, appName string, source Machine, region string) ([]map[string]interface{}, error) {
+	mounts, _ := source.Config["mounts"].([]interface{})
+	if len(mounts) == 0 {
+		return nil, nil
+	}
+
+	volumes, err := c.machinesClient.ListVolumes(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes for app %s: %w", appName, err)
+	}
+
+	var cloned []map[string]interface{}
+	for _, raw := range mounts {
+		mount, _ := raw.(map[string]interface{})
+		volumeID, _ := mount["volume"].(string)
+		i := slices.IndexFunc(volumes, func(v MachineVolume) bool { return v.ID == volumeID })
+		if i < 0 {
+			return nil, fmt.Errorf("volume %s mounted by machine %s not found", volumeID, source.ID)
+		}
+		volume := volumes[i]
+
+		created, err := c.machinesClient.CreateVolume(ctx, appName, CreateVolumeRequest{
+			Name:      volume.Name,
+			Region:    region,
+			SizeGB:    volume.SizeGB,
+			Encrypted: volume.Encrypted,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create a volume like %s for the clone: %w", volume.ID, err)
+		}
+
+		mount = maps.Clone(mount)
+		mount["volume"] = created.ID
+		cloned = append(cloned, mount)
+	}
+	return cloned, nil
+}
+
+// ScalePlan describes how an app's machine count is changed
+type ScalePlan struct {
+	AppName string `json:"appName"`
+	// Region limits the count to one region when set
+	Region  string `json:"region,omitempty"`
+	Current int    `json:"current"`
+	Target  int    `json:"target"`
+	// Source is the machine cloned to scale out
+	Source string `json:"source,omitempty"`
+	// Destroy are the machines destroyed to scale in
+	Destroy []string `json:"destroy,omitempty"`
+}
+
+// Create returns the number of machines the plan clones
+func (p *ScalePlan) Create() int {
+	return max(p.Target-p.Current, 0)
+}
+
+// ScaleResult is the outcome of changing an app's machine count
+type ScaleResult struct {
+	*ScalePlan
+	Created   []ClonedMachine `json:"created,omitempty"`
+	Destroyed []string        `json:"destroyed,omitempty"`
+	Duration  string          `json:"duration"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// PlanScale plans changing the number of an app's machines, optionally in
+// one region, to count. Scheduled tasks are not counted. Scaling out clones
+// the newest started machine, preferring one in the region; scaling in
+// destroys stopped machines before started ones, newest first, and never
+// destroys machines with volumes, whose data would be lost.
+func (c *Client) PlanScale(ctx context.Context, appName string, count int, region string) (*ScalePlan, error) {
+	all, counted, err := c.scaledMachines(ctx, appName, region)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ScalePlan{
+		AppName: appName,
+		Region:  region,
+		Current: len(counted),
+		Target:  count,
+	}
+
+	switch {
+	case count > len(counted):
+		source := newestMachine(counted)
+		if source == nil {
+			source = newestMachine(all)
+		}
+		if source == nil {
+			return nil, &FlyError{
+				StatusCode: http.StatusBadRequest,
+				Code:       ErrorCodeInvalid,
+				Message:    fmt.Sprintf("app %s has no machine to clone; deploy it first", appName),
+			}
+		}
+		plan.Source = source.ID
+	case count < len(counted):
+		candidates := slices.Clone(counted)
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if stoppedI, stoppedJ := candidates[i].State != "started", candidates[j].State != "started"; stoppedI != stoppedJ {
+				return stoppedI
+			}
+			return candidates[i].CreatedAt.After(candidates[j].CreatedAt)
+		})
+		for _, m := range candidates {
+			if len(plan.Destroy) == len(counted)-count {
+				break
+			}
+			if mounts, _ := m.Config["mounts"].([]interface{}); len(mounts) > 0 {
+				continue
+			}
+			plan.Destroy = append(plan.Destroy, m.ID)
+		}
+		if len(plan.Destroy) < len(counted)-count {
+			return nil, &FlyError{
+				StatusCode: http.StatusBadRequest,
+				Code:       ErrorCodeInvalid,
+				Message:    fmt.Sprintf("app %s can only scale in to %d machines without destroying machines with volumes", appName, len(counted)-len(plan.Destroy)),
+			}
+		}
+	}
+	return plan, nil
+}
+
+// PlanScaleIn plans destroying the given machines of an app, e.g. the ones
+// an earlier scale out created. Machines already gone are left out.
+func (c *Client) PlanScaleIn(ctx context.Context, appName, region string, machineIDs []string) (*ScalePlan, error) {
+	_, counted, err := c.scaledMachines(ctx, appName, region)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ScalePlan{
+		AppName: appName,
+		Region:  region,
+		Current: len(counted),
+	}
+	for _, m := range counted {
+		if slices.Contains(machineIDs, m.ID) {
+			plan.Destroy = append(plan.Destroy, m.ID)
+		}
+	}
+	plan.Target = plan.Current - len(plan.Destroy)
+	return plan, nil
+}
+
+// scaledMachines returns the machines of an app a scale counts, all of them
+// and those in region, leaving out scheduled tasks
+func (c *Client) scaledMachines(ctx context.Context, appName, region string) ([]Machine, []Machine, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	var all, counted []Machine
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" || machineSchedule(m) != "" {
+			continue
+		}
+		all = append(all, m)
+		if region == "" || m.Region == region {
+			counted = append(counted, m)
+		}
+	}
+	return all, counted, nil
+}
+
+// newestMachine returns the most recently created started machine, or the
+// most recently created one if none is started
+func newestMachine(machines []Machine) *Machine {
+	var newest *Machine
+	for i, m := range machines {
+		switch {
+		case newest == nil:
+			newest = &machines[i]
+		case (m.State == "started") != (newest.State == "started"):
+			if m.State == "started" {
+				newest = &machines[i]
+			}
+		case m.CreatedAt.After(newest.CreatedAt):
+			newest = &machines[i]
+		}
+	}
+	return newest
+}
+
+// ApplyScale carries out a scale plan: it clones the source machine into the
+// plan's region, or the source's own, until the target is reached, waiting
+// up to healthTimeout for each clone to become healthy, or stops and then
+// destroys the planned machines. It stops at the first failure, which is
+// reported in the result rather than as an error.
+func (c *Client) ApplyScale(ctx context.Context, plan *ScalePlan, healthTimeout time.Duration) *ScaleResult {
+	start := time.Now()
+	result := &ScaleResult{ScalePlan: plan}
+
+	if create := plan.Create(); create > 0 {
+		source, err := c.machinesClient.GetMachine(ctx, plan.AppName, plan.Source)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to get machine %s: %v", plan.Source, err)
+		}
+		for i := 0; source != nil && i < create; i++ {
+			clone, err := c.cloneMachine(ctx, plan.AppName, *source, CloneRequest{
+				Region:        plan.Region,
+				HealthTimeout: healthTimeout,
+			})
+			if clone != nil {
+				result.Created = append(result.Created, *clone)
+			}
+			if err != nil {
+				result.Error = fmt.Sprintf("clone %d of %d failed: %v", i+1, create, err)
+				break
+			}
+			interfaces.ReportProgress(ctx, float64(i+1), float64(create), fmt.Sprintf("created %d/%d machines", i+1, create))
+		}
+	}
+
+	for i, machineID := range plan.Destroy {
+		if err := c.stopAndDestroy(ctx, plan.AppName, machineID); err != nil {
+			result.Error = fmt.Sprintf("failed to destroy machine %s: %v", machineID, err)
+			break
+		}
+		result.Destroyed = append(result.Destroyed, machineID)
+		interfaces.ReportProgress(ctx, float64(i+1), float64(len(plan.Destroy)), fmt.Sprintf("destroyed %d/%d machines", i+1, len(plan.Destroy)))
+	}
+
+	result.Duration = time.Since(start).Round(time.Second).String()
+
+	c.logger.Info().
+		Str("app_name", plan.AppName).
+		Int("current", plan.Current).
+		Int("target", plan.Target).
+		Int("created", len(result.Created)).
+		Int("destroyed", len(result.Destroyed)).
+		Str("error", result.Error).
+		Msg("Scaled app machines")
+
+	return result
+}
+
+// stopTimeout bounds how long a machine may take to stop before it is
+// destroyed
+const stopTimeout = time.Minute
+
+// stopAndDestroy stops a machine, so its process shuts down gracefully,
+// waits until it is stopped and destroys it without forcing
+func (c *Client) stopAndDestroy(ctx context.Context, appName, machineID string) error {
+	m, err := c.machinesClient.GetMachine(ctx, appName, machineID)
+	if err != nil {
+		return err
+	}
+	if m.State != "stopped" && m.State != "suspended" && m.State != "created" {
+		if err := c.machinesClient.StopMachine(ctx, appName, machineID); err != nil {
+			return fmt.Errorf("failed to stop it: %w", err)
+		}
+		if err := c.waitForStopped(ctx, appName, machineID); err != nil {
+			return err
+		}
+	}
+	return c.machinesClient.DestroyMachine(ctx, appName, machineID, false)
+}
+
+// waitForStopped waits up to stopTimeout for a machine to stop
+func (c *Client) waitForStopped(ctx context.Context, appName, machineID string) error {
+	deadline := time.Now().Add(stopTimeout)
+	lastState := "unknown"
+	for {
+		m, err := c.machinesClient.GetMachine(ctx, appName, machineID)
+		if err == nil {
+			lastState = m.State
+			if m.State == "stopped" || m.State == "suspended" {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("machine %s did not stop within %s (last state: %s)", machineID, stopTimeout, lastState)
+		}
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(healthPollInterval):
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// MachineCost is the estimated monthly cost of a single machine
+type MachineCost struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	Region  string       `json:"region"`
+	State   string       `json:"state"`
+	Guest   MachineGuest `json:"guest"`
+	Monthly float64      `json:"monthly"`
+	Billed  bool         `json:"billed"`
+}
+
+// VolumeCost is the estimated monthly cost of a single volume
+type VolumeCost struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Region  string  `json:"region"`
+	SizeGB  int     `json:"sizeGb"`
+	Monthly float64 `json:"monthly"`
+}
+
+// CostEstimate is the estimated monthly cost of an application
+type CostEstimate struct {
+	AppName       string        `json:"appName"`
+	Machines      []MachineCost `json:"machines"`
+	Volumes       []VolumeCost  `json:"volumes"`
+	DedicatedIPv4 int           `json:"dedicatedIpv4"`
+	ComputeTotal  float64       `json:"computeTotal"`
+	VolumeTotal   float64       `json:"volumeTotal"`
+	IPTotal       float64       `json:"ipTotal"`
+	Total         float64       `json:"total"`
+	Warnings      []string      `json:"warnings,omitempty"`
+}
+
+// AverageMachineCost returns the mean monthly cost of the app's machines as if
+// all of them were running, or the cost of the smallest machine if there are none
+func (e *CostEstimate) AverageMachineCost(pricing config.PricingConfig) float64 {
+	if len(e.Machines) == 0 {
+		return EstimateMachineCost(pricing, defaultGuest())
+	}
+
+	var total float64
+	for _, m := range e.Machines {
+		total += EstimateMachineCost(pricing, m.Guest)
+	}
+	return total / float64(len(e.Machines))
+}
+
+// Guest returns the machine's CPU and memory configuration
+func (m *Machine) Guest() MachineGuest {
+	guest := defaultGuest()
+
+	raw, ok := m.Config["guest"].(map[string]interface{})
+	if !ok {
+		return guest
+	}
+	if kind, ok := raw["cpu_kind"].(string); ok && kind != "" {
+		guest.CPUKind = kind
+	}
+	if cpus, ok := raw["cpus"].(float64); ok && cpus > 0 {
+		guest.CPUs = int(cpus)
+	}
+	if mem, ok := raw["memory_mb"].(float64); ok && mem > 0 {
+		guest.MemoryMB = int(mem)
+	}
+	return guest
+}
+
+// defaultGuest is the smallest machine size Fly.io offers
+func defaultGuest() MachineGuest {
+	return MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256}
+}
+
+// EstimateMachineCost returns the monthly cost of a running machine of the given size
+func EstimateMachineCost(pricing config.PricingConfig, guest MachineGuest) float64 {
+	perCPU, includedMB := pricing.SharedCPU, pricing.SharedMemoryMB
+	if guest.CPUKind == "performance" {
+		perCPU, includedMB = pricing.PerformanceCPU, pricing.PerformanceMemoryMB
+	}
+
+	cost := perCPU * float64(guest.CPUs)
+	if extraMB := guest.MemoryMB - includedMB*guest.CPUs; extraMB > 0 {
+		cost += pricing.MemoryGB * float64(extraMB) / 1024
+	}
+	return cost
+}
+
+// Pricing returns the pricing table used for cost estimates
+func (c *Client) Pricing() config.PricingConfig {
+	return c.config.Pricing
+}
+
+// EstimateAppCost estimates the monthly cost of an application from its
+// machine sizes, volumes and dedicated IP addresses. Stopped machines are not
+// billed for compute.
+func (c *Client) EstimateAppCost(ctx context.Context, appName string) (*CostEstimate, error) {
+	pricing := c.config.Pricing
+
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	estimate := &CostEstimate{AppName: appName}
+
+	for _, m := range machines {
+		guest := m.Guest()
+		mc := MachineCost{
+			ID:     m.ID,
+			Name:   m.Name,
+			Region: m.Region,
+			State:  m.State,
+			Guest:  guest,
+			Billed: m.State != "stopped" && m.State != "suspended" && m.State != "destroyed",
+		}
+		if mc.Billed {
+			mc.Monthly = EstimateMachineCost(pricing, guest)
+			estimate.ComputeTotal += mc.Monthly
+		}
+		estimate.Machines = append(estimate.Machines, mc)
+	}
+
+	volumes, err := c.machinesClient.ListVolumes(ctx, appName)
+	if err != nil {
+		c.logger.Warn().
+			Str("app_name", appName).
+			Err(err).
+			Msg("Failed to list volumes, excluding them from cost estimate")
+		estimate.Warnings = append(estimate.Warnings, "volumes could not be listed and are not included")
+	}
+	for _, v := range volumes {
+		if v.State == "destroyed" || v.State == "pending_destroy" {
+			continue
+		}
+		vc := VolumeCost{
+			ID:      v.ID,
+			Name:    v.Name,
+			Region:  v.Region,
+			SizeGB:  v.SizeGB,
+			Monthly: pricing.VolumeGB * float64(v.SizeGB),
+		}
+		estimate.VolumeTotal += vc.Monthly
+		estimate.Volumes = append(estimate.Volumes, vc)
+	}
+
+	start := time.Now()
+	ips, err := c.flyClient.GetIPAddresses(ctx, appName)
+	err = parseGraphQLError(err)
+	c.logger.LogFlyAPICall(fmt.Sprintf("/apps/%s/ip_addresses", appName), "GET", getStatusCode(err), time.Since(start))
+	if err != nil {
+		c.logger.Warn().
+			Str("app_name", appName).
+			Err(err).
+			Msg("Failed to list IP addresses, excluding them from cost estimate")
+		estimate.Warnings = append(estimate.Warnings, "IP addresses could not be listed and are not included")
+	}
+	for _, ip := range ips {
+		if ip.Type == "v4" {
+			estimate.DedicatedIPv4++
+		}
+	}
+	estimate.IPTotal = pricing.DedicatedIPv4 * float64(estimate.DedicatedIPv4)
+
+	estimate.Total = estimate.ComputeTotal + estimate.VolumeTotal + estimate.IPTotal
+
+	c.logger.Debug().
+		Str("app_name", appName).
+		Float64("monthly_total", estimate.Total).
+		Msg("Estimated app cost")
+
+	return estimate, nil
+}
@@ -0,0 +1,512 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// Deploy strategies, matching the strategy names of fly.toml
+const (
+	DeployRolling   = "rolling"
+	DeployImmediate = "immediate"
+	DeployCanary    = "canary"
+	DeployBlueGreen = "bluegreen"
+)
+
+// DeployStrategies lists the supported deploy strategies
+var DeployStrategies = []string{DeployRolling, DeployImmediate, DeployCanary, DeployBlueGreen}
+
+// Deploy outcomes
+const (
+	DeploySucceeded  = "succeeded"
+	DeployFailed     = "failed"
+	DeployRolledBack = "rolled_back"
+)
+
+// healthPollInterval is how often a machine is checked while waiting for it
+// to become healthy
+const healthPollInterval = 2 * time.Second
+
+// DeployRequest describes a deploy of an image to an app's machines
+type DeployRequest struct {
+	Image    string
+	Strategy string
+	// HealthTimeout is how long each machine may take to start and pass its
+	// health checks before the deploy is stopped
+	HealthTimeout time.Duration
+	// Gate, if set, watches the deployed machines once the rollout is done
+	// and rolls them back to their previous images if they turn unhealthy
+	Gate *HealthGate
+}
+
+// DeployTarget is a machine a deploy will replace or update
+type DeployTarget struct {
+	MachineID string `json:"machineId"`
+	Region    string `json:"region"`
+	State     string `json:"state"`
+	Image     string `json:"image"`
+	HasVolume bool   `json:"hasVolume,omitempty"`
+}
+
+// DeployPlan lists the machines a deploy will change
+type DeployPlan struct {
+	AppName  string         `json:"appName"`
+	Image    string         `json:"image"`
+	Strategy string         `json:"strategy"`
+	Machines []DeployTarget `json:"machines"`
+	// Skipped machines, such as scheduled tasks, keep their image
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// MachineIDs returns the IDs of the machines the deploy changes
+func (p *DeployPlan) MachineIDs() []string {
+	ids := make([]string, len(p.Machines))
+	for i, m := range p.Machines {
+		ids[i] = m.MachineID
+	}
+	return ids
+}
+
+// DeployedMachine records what a deploy did to one machine
+type DeployedMachine struct {
+	MachineID string `json:"machineId"`
+	Region    string `json:"region"`
+	// Role is canary, blue or green for the strategies that use them
+	Role   string `json:"role,omitempty"`
+	Action string `json:"action"`
+	// PreviousImage is the image the machine, or the machine it replaced,
+	// ran before the deploy
+	PreviousImage string `json:"previousImage,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// DeployResult is the outcome of a deploy
+type DeployResult struct {
+	AppName  string            `json:"appName"`
+	Image    string            `json:"image"`
+	Strategy string            `json:"strategy"`
+	Status   string            `json:"status"`
+	Machines []DeployedMachine `json:"machines"`
+	Duration string            `json:"duration"`
+	Error    string            `json:"error,omitempty"`
+	// HealthGate is the outcome of the post-deploy health gate, if one ran
+	HealthGate *HealthGateResult `json:"healthGate,omitempty"`
+}
+
+// PlanDeploy validates a deploy request and lists the machines it will
+// change. Scheduled tasks are left alone: they run their own image and are
+// not serving traffic.
+func (c *Client) PlanDeploy(ctx context.Context, appName string, req DeployRequest) (*DeployPlan, error) {
+	plan, _, err := c.planDeploy(ctx, appName, req)
+	return plan, err
+}
+
+// planDeploy builds the plan for a deploy and returns the machines it targets
+func (c *Client) planDeploy(ctx context.Context, appName string, req DeployRequest) (*DeployPlan, []Machine, error) {
+	if req.Image == "" {
+		return nil, nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    "an image is required to deploy",
+		}
+	}
+	if !slices.Contains(DeployStrategies, req.Strategy) {
+		return nil, nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("invalid strategy '%s' (expected %s)", req.Strategy, strings.Join(DeployStrategies, ", ")),
+		}
+	}
+
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	plan := &DeployPlan{
+		AppName:  appName,
+		Image:    req.Image,
+		Strategy: req.Strategy,
+		Machines: []DeployTarget{},
+	}
+	var targets []Machine
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" {
+			continue
+		}
+		if machineSchedule(m) != "" {
+			plan.Skipped = append(plan.Skipped, m.ID)
+			continue
+		}
+		mounts, _ := m.Config["mounts"].([]interface{})
+		targets = append(targets, m)
+		plan.Machines = append(plan.Machines, DeployTarget{
+			MachineID: m.ID,
+			Region:    m.Region,
+			State:     m.State,
+			Image:     m.ImageRef.String(),
+			HasVolume: len(mounts) > 0,
+		})
+	}
+
+	if len(plan.Machines) == 0 {
+		return nil, nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("app %s has no machines to deploy to", appName),
+		}
+	}
+
+	// A volume can only be attached to one machine, so green machines
+	// cannot take over the blue machines' volumes
+	if req.Strategy == DeployBlueGreen {
+		for _, t := range plan.Machines {
+			if t.HasVolume {
+				return nil, nil, &FlyError{
+					StatusCode: http.StatusBadRequest,
+					Code:       ErrorCodeInvalid,
+					Message:    fmt.Sprintf("machine %s has a volume; apps with volumes cannot use bluegreen deploys, use rolling or canary instead", t.MachineID),
+				}
+			}
+		}
+	}
+
+	return plan, targets, nil
+}
+
+// Deploy rolls an image out to an app's machines with the requested
+// strategy:
+//
+//   - rolling updates one machine at a time, waiting for each to pass its
+//     health checks before moving on
+//   - immediate updates every machine at once without waiting
+//   - canary updates one machine first and rolls it back if it does not
+//     become healthy, then continues like rolling
+//   - bluegreen creates a new machine next to each existing one and only
+//     destroys the old machines once every new one is healthy
+//
+// A failed deploy stops where it is and is reported in the result rather
+// than as an error, so the caller can see which machines changed.
+func (c *Client) Deploy(ctx context.Context, appName string, req DeployRequest) (*DeployResult, error) {
+	_, targets, err := c.planDeploy(ctx, appName, req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result := &DeployResult{
+		AppName:  appName,
+		Image:    req.Image,
+		Strategy: req.Strategy,
+		Status:   DeploySucceeded,
+		Machines: []DeployedMachine{},
+	}
+
+	switch req.Strategy {
+	case DeployImmediate:
+		err = c.deployImmediate(ctx, appName, req, targets, result)
+	case DeployCanary:
+		err = c.deployCanary(ctx, appName, req, targets, result)
+	case DeployBlueGreen:
+		err = c.deployBlueGreen(ctx, appName, req, targets, result)
+	default:
+		err = c.deployRolling(ctx, appName, req, targets, result)
+	}
+	if err != nil {
+		result.Status = DeployFailed
+		result.Error = err.Error()
+	}
+
+	if req.Gate != nil && result.Status == DeploySucceeded {
+		// Machines that were stopped stay stopped, so only running ones
+		// are watched
+		stopped := make(map[string]bool)
+		for _, m := range targets {
+			stopped[m.ID] = m.State != "started"
+		}
+		var machineIDs []string
+		previous := make(map[string]string)
+		for _, m := range result.Machines {
+			if m.Action != "created" && m.Action != "updated" {
+				continue
+			}
+			previous[m.MachineID] = m.PreviousImage
+			if !stopped[m.MachineID] {
+				machineIDs = append(machineIDs, m.MachineID)
+			}
+		}
+		result.HealthGate = c.RunHealthGate(ctx, appName, machineIDs, *req.Gate, previous)
+		switch result.HealthGate.Decision {
+		case GateRolledBack:
+			result.Status = DeployRolledBack
+			result.Error = result.HealthGate.Reason
+		case GateFailed:
+			result.Status = DeployFailed
+			result.Error = result.HealthGate.Reason
+		}
+	}
+	result.Duration = time.Since(start).Round(time.Second).String()
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("strategy", req.Strategy).
+		Str("status", result.Status).
+		Int("machines", len(targets)).
+		Msg("Deploy finished")
+
+	return result, nil
+}
+
+// deployRolling updates machines one at a time behind a health gate
+func (c *Client) deployRolling(ctx context.Context, appName string, req DeployRequest, targets []Machine, result *DeployResult) error {
+	for i, m := range targets {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("deploy cancelled after %d of %d machines: %w", i, len(targets), context.Cause(ctx))
+		}
+
+		if err := c.updateMachineImage(ctx, appName, m, req); err != nil {
+			result.Machines = append(result.Machines, DeployedMachine{MachineID: m.ID, Region: m.Region, Action: "failed", Error: err.Error()})
+			return fmt.Errorf("machine %s failed after %d of %d machines were updated: %w", m.ID, i, len(targets), err)
+		}
+		result.Machines = append(result.Machines, DeployedMachine{MachineID: m.ID, Region: m.Region, Action: "updated", PreviousImage: configImage(m)})
+		interfaces.ReportProgress(ctx, float64(i+1), float64(len(targets)), fmt.Sprintf("updated %d/%d machines", i+1, len(targets)))
+	}
+	return nil
+}
+
+// deployImmediate updates every machine concurrently without health gates
+func (c *Client) deployImmediate(ctx context.Context, appName string, req DeployRequest, targets []Machine, result *DeployResult) error {
+	deployed := make([]DeployedMachine, len(targets))
+	var wg sync.WaitGroup
+	for i, m := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deployed[i] = DeployedMachine{MachineID: m.ID, Region: m.Region, Action: "updated", PreviousImage: configImage(m)}
+			if _, err := c.machinesClient.UpdateMachineConfig(ctx, appName, m.ID, withImage(m.Config, req.Image), m.State != "started"); err != nil {
+				deployed[i].Action = "failed"
+				deployed[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	result.Machines = append(result.Machines, deployed...)
+
+	failed := 0
+	for _, d := range deployed {
+		if d.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d machines failed to update", failed, len(targets))
+	}
+	return nil
+}
+
+// deployCanary updates one started machine first and rolls it back if it
+// does not become healthy, then rolls the image out to the rest
+func (c *Client) deployCanary(ctx context.Context, appName string, req DeployRequest, targets []Machine, result *DeployResult) error {
+	canary := 0
+	for i, m := range targets {
+		if m.State == "started" {
+			canary = i
+			break
+		}
+	}
+	m := targets[canary]
+
+	if err := c.updateMachineImage(ctx, appName, m, req); err != nil {
+		deployed := DeployedMachine{MachineID: m.ID, Region: m.Region, Role: "canary", Action: "rolled_back", Error: err.Error()}
+		if _, rollbackErr := c.machinesClient.UpdateMachineConfig(ctx, appName, m.ID, m.Config, m.State != "started"); rollbackErr != nil {
+			deployed.Action = "failed"
+			deployed.Error += "; rollback failed: " + rollbackErr.Error()
+		}
+		result.Machines = append(result.Machines, deployed)
+		return fmt.Errorf("canary %s did not become healthy, no other machines were changed: %w", m.ID, err)
+	}
+	result.Machines = append(result.Machines, DeployedMachine{MachineID: m.ID, Region: m.Region, Role: "canary", Action: "updated", PreviousImage: configImage(m)})
+	interfaces.ReportProgress(ctx, 1, float64(len(targets)), "canary healthy")
+
+	rest := slices.Delete(slices.Clone(targets), canary, canary+1)
+	for i, m := range rest {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("deploy cancelled after %d of %d machines: %w", i+1, len(targets), context.Cause(ctx))
+		}
+
+		if err := c.updateMachineImage(ctx, appName, m, req); err != nil {
+			result.Machines = append(result.Machines, DeployedMachine{MachineID: m.ID, Region: m.Region, Action: "failed", Error: err.Error()})
+			return fmt.Errorf("machine %s failed after %d of %d machines were updated: %w", m.ID, i+1, len(targets), err)
+		}
+		result.Machines = append(result.Machines, DeployedMachine{MachineID: m.ID, Region: m.Region, Action: "updated", PreviousImage: configImage(m)})
+		interfaces.ReportProgress(ctx, float64(i+2), float64(len(targets)), fmt.Sprintf("updated %d/%d machines", i+2, len(targets)))
+	}
+	return nil
+}
+
+// deployBlueGreen creates a green machine for each blue one, waits for all
+// greens to become healthy and then destroys the blues. If any green fails,
+// the greens are destroyed and the blue machines keep serving.
+func (c *Client) deployBlueGreen(ctx context.Context, appName string, req DeployRequest, targets []Machine, result *DeployResult) error {
+	greens := make([]*Machine, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, blue := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			green, err := c.machinesClient.CreateMachine(ctx, appName, CreateMachineRequest{
+				Region: blue.Region,
+				Config: withImage(blue.Config, req.Image),
+			})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			greens[i] = green
+			errs[i] = c.waitForHealthy(ctx, appName, green.ID, time.Now(), req.HealthTimeout)
+		}()
+	}
+	wg.Wait()
+
+	var failure error
+	for i, err := range errs {
+		if err != nil && failure == nil {
+			failure = fmt.Errorf("green machine for %s failed: %w", targets[i].ID, err)
+		}
+	}
+
+	if failure != nil {
+		for i, green := range greens {
+			if green == nil {
+				result.Machines = append(result.Machines, DeployedMachine{MachineID: targets[i].ID, Region: targets[i].Region, Role: "green", Action: "failed", Error: errs[i].Error()})
+				continue
+			}
+			deployed := DeployedMachine{MachineID: green.ID, Region: green.Region, Role: "green", Action: "destroyed"}
+			if errs[i] != nil {
+				deployed.Error = errs[i].Error()
+			}
+			// The cancelled request context must not stop the cleanup
+			if err := c.machinesClient.DestroyMachine(context.WithoutCancel(ctx), appName, green.ID, true); err != nil {
+				deployed.Action = "failed"
+				deployed.Error = "could not destroy green machine: " + err.Error()
+			}
+			result.Machines = append(result.Machines, deployed)
+		}
+		return fmt.Errorf("%w; the existing machines were left unchanged", failure)
+	}
+
+	for i, green := range greens {
+		result.Machines = append(result.Machines, DeployedMachine{MachineID: green.ID, Region: green.Region, Role: "green", Action: "created", PreviousImage: configImage(targets[i])})
+	}
+	interfaces.ReportProgress(ctx, float64(len(targets)), float64(2*len(targets)), "green machines healthy")
+
+	// Every green is serving, so the blues can go
+	var destroyErrors []string
+	for i, blue := range targets {
+		deployed := DeployedMachine{MachineID: blue.ID, Region: blue.Region, Role: "blue", Action: "destroyed"}
+		if err := c.machinesClient.DestroyMachine(context.WithoutCancel(ctx), appName, blue.ID, true); err != nil {
+			deployed.Action = "failed"
+			deployed.Error = err.Error()
+			destroyErrors = append(destroyErrors, blue.ID)
+		}
+		result.Machines = append(result.Machines, deployed)
+		interfaces.ReportProgress(ctx, float64(len(targets)+i+1), float64(2*len(targets)), fmt.Sprintf("destroyed %d/%d blue machines", i+1, len(targets)))
+	}
+	if len(destroyErrors) > 0 {
+		return fmt.Errorf("the new machines are serving but blue machines %s could not be destroyed", strings.Join(destroyErrors, ", "))
+	}
+	return nil
+}
+
+// updateMachineImage updates a machine to the new image and, if it was
+// running, waits for it to become healthy again. Stopped machines are updated
+// without being started.
+func (c *Client) updateMachineImage(ctx context.Context, appName string, m Machine, req DeployRequest) error {
+	return c.updateMachine(ctx, appName, m, withImage(m.Config, req.Image), req.HealthTimeout)
+}
+
+// updateMachine replaces a machine's config and, if it was running, waits
+// for it to become healthy again
+func (c *Client) updateMachine(ctx context.Context, appName string, m Machine, machineConfig map[string]interface{}, healthTimeout time.Duration) error {
+	started := time.Now()
+	stopped := m.State != "started"
+	if _, err := c.machinesClient.UpdateMachineConfig(ctx, appName, m.ID, machineConfig, stopped); err != nil {
+		return err
+	}
+	if stopped {
+		return nil
+	}
+	return c.waitForHealthy(ctx, appName, m.ID, started, healthTimeout)
+}
+
+// waitForHealthy polls a machine until it is started and every health check
+// reported since since passes, or the timeout expires
+func (c *Client) waitForHealthy(ctx context.Context, appName, machineID string, since time.Time, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	lastState := "unknown"
+	for {
+		m, err := c.machinesClient.GetMachine(ctx, appName, machineID)
+		if err == nil {
+			lastState = m.State
+			switch m.State {
+			case "started":
+				failing := failingChecks(m.Checks, since)
+				if len(failing) == 0 {
+					return nil
+				}
+				lastState = "started, failing " + strings.Join(failing, ", ")
+			case "destroyed", "failed":
+				return fmt.Errorf("machine %s is %s", machineID, m.State)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("machine %s was not healthy within %s (last state: %s)", machineID, timeout, lastState)
+		}
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(healthPollInterval):
+		}
+	}
+}
+
+// failingChecks returns the checks that are not passing, treating results
+// from before since as not yet reported
+func failingChecks(checks []MachineCheck, since time.Time) []string {
+	var failing []string
+	for _, check := range checks {
+		if check.Status != "passing" || (!check.UpdatedAt.IsZero() && check.UpdatedAt.Before(since)) {
+			failing = append(failing, check.Name)
+		}
+	}
+	return failing
+}
+
+// configImage returns the image a machine's config asks for
+func configImage(m Machine) string {
+	if image, ok := m.Config["image"].(string); ok {
+		return image
+	}
+	return m.ImageRef.String()
+}
+
+// withImage returns a copy of a machine config that runs image
+func withImage(machineConfig map[string]interface{}, image string) map[string]interface{} {
+	updated := maps.Clone(machineConfig)
+	if updated == nil {
+		updated = make(map[string]interface{})
+	}
+	updated["image"] = image
+	return updated
+}
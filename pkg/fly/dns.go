@@ -0,0 +1,267 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Domain check statuses
+const (
+	DomainOK            = "ok"
+	DomainMisconfigured = "misconfigured"
+	DomainUnresolved    = "unresolved"
+)
+
+// DNSRecord is a record to create at the domain's DNS provider
+type DNSRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// DomainCheck is the result of resolving one hostname and comparing it with
+// the app's addresses
+type DomainCheck struct {
+	Hostname string `json:"hostname"`
+	// Custom is false for the app's own fly.dev hostname
+	Custom bool     `json:"custom"`
+	CNAME  string   `json:"cname,omitempty"`
+	IPv4   []string `json:"ipv4,omitempty"`
+	IPv6   []string `json:"ipv6,omitempty"`
+	Status string   `json:"status"`
+	// CertificateStatus is the status of the app's certificate for the
+	// hostname, if it has one
+	CertificateStatus string      `json:"certificateStatus,omitempty"`
+	Problems          []string    `json:"problems,omitempty"`
+	Records           []DNSRecord `json:"records,omitempty"`
+}
+
+// DNSReport describes how an app's hostnames resolve
+type DNSReport struct {
+	AppName  string        `json:"appName"`
+	Hostname string        `json:"hostname"`
+	IPv4     []string      `json:"ipv4"`
+	IPv6     []string      `json:"ipv6"`
+	Shared   bool          `json:"sharedIpv4"`
+	Domains  []DomainCheck `json:"domains"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+// appCertificate is a certificate the app holds for a custom domain
+type appCertificate struct {
+	Hostname              string `json:"hostname"`
+	ClientStatus          string `json:"clientStatus"`
+	IsApex                bool   `json:"isApex"`
+	DNSValidationHostname string `json:"dnsValidationHostname"`
+	DNSValidationTarget   string `json:"dnsValidationTarget"`
+}
+
+// CheckAppDNS resolves an app's fly.dev hostname, the custom domains it has
+// certificates for and any extra domains, and reports the records that are
+// missing or point somewhere other than the app
+func (c *Client) CheckAppDNS(ctx context.Context, appName string, domains []string) (*DNSReport, error) {
+	var app struct {
+		Hostname        string `json:"hostname"`
+		SharedIPAddress string `json:"sharedIpAddress"`
+		IPAddresses     struct {
+			Nodes []struct {
+				Address string `json:"address"`
+				Type    string `json:"type"`
+			} `json:"nodes"`
+		} `json:"ipAddresses"`
+	}
+	var certs struct {
+		Certificates struct {
+			Nodes []appCertificate `json:"nodes"`
+		} `json:"certificates"`
+	}
+
+	appVars := map[string]batchVar{"appName": {Type: "String!", Value: appName}}
+	batch := c.newGraphQLBatch()
+	batch.Add("app", `app(name: $appName) { hostname sharedIpAddress ipAddresses { nodes { address type } } }`, appVars, &app)
+	batch.Add("certs", `app(name: $appName) { certificates { nodes { hostname clientStatus isApex dnsValidationHostname dnsValidationTarget } } }`, appVars, &certs)
+	if err := batch.Run(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get app %s: %w", appName, err)
+	}
+	if err := batch.Err("app"); err != nil {
+		return nil, fmt.Errorf("failed to get app %s: %w", appName, err)
+	}
+
+	report := &DNSReport{
+		AppName:  appName,
+		Hostname: app.Hostname,
+		IPv4:     []string{},
+		IPv6:     []string{},
+		Domains:  []DomainCheck{},
+	}
+	for _, ip := range app.IPAddresses.Nodes {
+		switch ip.Type {
+		case "v4", "shared_v4":
+			report.IPv4 = append(report.IPv4, ip.Address)
+		case "v6":
+			report.IPv6 = append(report.IPv6, ip.Address)
+		}
+	}
+	if len(report.IPv4) == 0 && app.SharedIPAddress != "" {
+		report.IPv4 = append(report.IPv4, app.SharedIPAddress)
+		report.Shared = true
+	}
+	if len(report.IPv4) == 0 && len(report.IPv6) == 0 {
+		report.Warnings = append(report.Warnings, "The app has no public IP addresses; allocate them before pointing domains at it")
+	}
+
+	if batch.Err("certs") != nil {
+		report.Warnings = append(report.Warnings, "Certificates could not be listed, so only the app hostname and the given domains were checked")
+	}
+
+	byHost := make(map[string]appCertificate)
+	var hostnames []string
+	for _, cert := range certs.Certificates.Nodes {
+		host := strings.ToLower(cert.Hostname)
+		byHost[host] = cert
+		hostnames = append(hostnames, host)
+	}
+	for _, d := range domains {
+		host := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d), "."))
+		if host != "" && !slices.Contains(hostnames, host) {
+			hostnames = append(hostnames, host)
+		}
+	}
+	sort.Strings(hostnames)
+	if app.Hostname != "" {
+		hostnames = append([]string{strings.ToLower(app.Hostname)}, slices.DeleteFunc(hostnames, func(h string) bool {
+			return h == strings.ToLower(app.Hostname)
+		})...)
+	}
+
+	report.Domains = make([]DomainCheck, len(hostnames))
+	var wg sync.WaitGroup
+	for i, host := range hostnames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var cert *appCertificate
+			if found, ok := byHost[host]; ok {
+				cert = &found
+			}
+			report.Domains[i] = checkDomain(ctx, report, host, cert)
+		}()
+	}
+	wg.Wait()
+
+	c.logger.Debug().
+		Str("app_name", appName).
+		Int("domains", len(report.Domains)).
+		Msg("Checked app DNS")
+
+	return report, nil
+}
+
+// checkDomain resolves a hostname and compares its records with the app's
+// addresses
+func checkDomain(ctx context.Context, report *DNSReport, host string, cert *appCertificate) DomainCheck {
+	check := DomainCheck{
+		Hostname: host,
+		Custom:   !strings.EqualFold(host, report.Hostname),
+		Status:   DomainOK,
+	}
+	if cert != nil {
+		check.CertificateStatus = cert.ClientStatus
+	}
+
+	resolver := net.DefaultResolver
+	if cname, err := resolver.LookupCNAME(ctx, host); err == nil {
+		cname = strings.TrimSuffix(cname, ".")
+		if !strings.EqualFold(cname, host) {
+			check.CNAME = cname
+		}
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		check.Status = DomainUnresolved
+		check.Problems = append(check.Problems, fmt.Sprintf("%s does not resolve: %v", host, err))
+	}
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			check.IPv4 = append(check.IPv4, addr.IP.String())
+		} else {
+			check.IPv6 = append(check.IPv6, addr.IP.String())
+		}
+	}
+
+	if !check.Custom {
+		// The fly.dev name is managed by Fly.io, so only resolution matters
+		return check
+	}
+
+	// A CNAME to the app's hostname always follows its addresses
+	pointsAtApp := check.CNAME != "" && strings.EqualFold(check.CNAME, report.Hostname)
+	if check.CNAME != "" && !pointsAtApp {
+		check.Problems = append(check.Problems, fmt.Sprintf("CNAME points at %s instead of %s", check.CNAME, report.Hostname))
+	}
+
+	if !pointsAtApp {
+		for _, ip := range check.IPv4 {
+			if !slices.Contains(report.IPv4, ip) {
+				check.Problems = append(check.Problems, fmt.Sprintf("A record %s is not one of the app's IPv4 addresses", ip))
+			}
+		}
+		for _, ip := range check.IPv6 {
+			if !slices.Contains(report.IPv6, ip) {
+				check.Problems = append(check.Problems, fmt.Sprintf("AAAA record %s is not one of the app's IPv6 addresses", ip))
+			}
+		}
+		if err == nil && len(check.IPv4) == 0 && len(report.IPv4) > 0 {
+			check.Problems = append(check.Problems, "No A record points at the app, so IPv4-only clients cannot reach it")
+		}
+	}
+
+	if len(check.Problems) > 0 || check.Status == DomainUnresolved {
+		if check.Status == DomainOK {
+			check.Status = DomainMisconfigured
+		}
+		check.Records = expectedRecords(report, host, cert)
+	}
+
+	// Certificates that are not issued yet need the ACME challenge record
+	if cert != nil && cert.DNSValidationTarget != "" && !strings.EqualFold(cert.ClientStatus, "Ready") {
+		target, err := resolver.LookupCNAME(ctx, cert.DNSValidationHostname)
+		if err != nil || !strings.EqualFold(strings.TrimSuffix(target, "."), strings.TrimSuffix(cert.DNSValidationTarget, ".")) {
+			check.Problems = append(check.Problems, fmt.Sprintf("The certificate is %s and %s does not point at %s", cert.ClientStatus, cert.DNSValidationHostname, cert.DNSValidationTarget))
+			check.Records = append(check.Records, DNSRecord{Type: "CNAME", Name: cert.DNSValidationHostname, Value: cert.DNSValidationTarget})
+			if check.Status == DomainOK {
+				check.Status = DomainMisconfigured
+			}
+		}
+	}
+
+	return check
+}
+
+// expectedRecords returns the records that point a hostname at the app.
+// Apex domains cannot have a CNAME, so they get A and AAAA records.
+func expectedRecords(report *DNSReport, host string, cert *appCertificate) []DNSRecord {
+	apex := strings.Count(host, ".") == 1
+	if cert != nil {
+		apex = cert.IsApex
+	}
+
+	if !apex && report.Hostname != "" {
+		return []DNSRecord{{Type: "CNAME", Name: host, Value: report.Hostname}}
+	}
+
+	var records []DNSRecord
+	for _, ip := range report.IPv4 {
+		records = append(records, DNSRecord{Type: "A", Name: host, Value: ip})
+	}
+	for _, ip := range report.IPv6 {
+		records = append(records, DNSRecord{Type: "AAAA", Name: host, Value: ip})
+	}
+	return records
+}
@@ -0,0 +1,380 @@
+package fly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// platformEnv are the environment variables Fly.io sets on machines itself,
+// which are never drift
+var platformEnv = []string{"FLY_PROCESS_GROUP", "PRIMARY_REGION"}
+
+// DriftOptions narrows a drift check
+type DriftOptions struct {
+	// ProcessGroup limits the check to machines of one process group
+	ProcessGroup string
+	// Ignore lists fields not to report, e.g. env.DEPLOYED_AT or
+	// services[*].concurrency, where * matches any part of a name but a
+	// dot; a pattern also ignores the fields below it
+	Ignore []string
+}
+
+// DriftValue is a value a field has on some machines
+type DriftValue struct {
+	Value    interface{} `json:"value"`
+	Machines []string    `json:"machines"`
+}
+
+// Drift is a field whose live value differs from the desired one
+type Drift struct {
+	Field string `json:"field"`
+	// Kind is changed, missing (desired but not set) or unexpected (set
+	// but not desired)
+	Kind    string       `json:"kind"`
+	Desired interface{}  `json:"desired,omitempty"`
+	Live    []DriftValue `json:"live"`
+}
+
+// DriftReport compares an app's machines with the configuration they
+// should have
+type DriftReport struct {
+	AppName string `json:"appName"`
+	// Machines lists the machines compared, and Skipped those that were
+	// not, with why
+	Machines []string          `json:"machines"`
+	Skipped  map[string]string `json:"skipped,omitempty"`
+	// Fields is how many desired fields each machine was checked for
+	Fields   int      `json:"fields"`
+	Drift    []Drift  `json:"drift"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// DetectDrift compares the config of an app's machines with the desired
+// config of their process group, field by field. desired maps process
+// groups to machine configs in the Machines API's shape; the "" entry
+// applies to groups without one of their own. Every field a desired config
+// sets must match; env variables, services, checks and mounts a desired
+// config lists must also not have live extras. Env values are compared by
+// digest and never returned.
+func (c *Client) DetectDrift(ctx context.Context, appName string, desired map[string]map[string]interface{}, opts DriftOptions) (*DriftReport, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	report := &DriftReport{AppName: appName, Machines: []string{}, Drift: []Drift{}}
+	drift := make(map[string]*Drift)
+	var order []string
+	record := func(field, kind string, want, live interface{}, machineID string) {
+		if ignoredField(opts.Ignore, field) {
+			return
+		}
+		d, ok := drift[field]
+		if !ok {
+			d = &Drift{Field: field, Kind: kind, Desired: want}
+			drift[field] = d
+			order = append(order, field)
+		}
+		for i := range d.Live {
+			if reflect.DeepEqual(d.Live[i].Value, live) {
+				d.Live[i].Machines = append(d.Live[i].Machines, machineID)
+				return
+			}
+		}
+		d.Live = append(d.Live, DriftValue{Value: live, Machines: []string{machineID}})
+	}
+
+	fields := 0
+	for _, m := range machines {
+		group := machineProcessGroup(m)
+		switch {
+		case m.State == "destroyed" || m.State == "destroying":
+			continue
+		case opts.ProcessGroup != "" && group != opts.ProcessGroup:
+			continue
+		case machineSchedule(m) != "":
+			if report.Skipped == nil {
+				report.Skipped = make(map[string]string)
+			}
+			report.Skipped[m.ID] = "scheduled task machine, created outside fly.toml"
+			continue
+		}
+
+		want, ok := desired[group]
+		if !ok {
+			want, ok = desired[""]
+		}
+		if !ok {
+			report.Machines = append(report.Machines, m.ID)
+			record("metadata.fly_process_group", "unexpected", nil, group, m.ID)
+			continue
+		}
+		report.Machines = append(report.Machines, m.ID)
+
+		wantFields := flattenConfig(want)
+		liveFields := flattenConfig(m.Config)
+		fields = max(fields, len(wantFields))
+
+		// Entries of collections, such as a service, are reported whole
+		// when they are missing or unexpected
+		reported := make(map[string]bool)
+		for _, field := range slices.Sorted(maps.Keys(wantFields)) {
+			live, set := liveFields[field]
+			if sameValue(wantFields[field], live, set) {
+				continue
+			}
+			if entry, listed := authoritativeEntry(field, want); listed && entry != field && !hasPrefix(liveFields, entry) {
+				if !reported[entry] {
+					reported[entry] = true
+					record(entry, "missing", "present", nil, m.ID)
+				}
+				continue
+			}
+			kind := "changed"
+			if !set {
+				kind = "missing"
+			}
+			record(field, kind, redactEnv(field, wantFields[field]), redactEnv(field, live), m.ID)
+		}
+
+		// Collections the desired config lists must not have extras
+		for _, field := range slices.Sorted(maps.Keys(liveFields)) {
+			entry, listed := authoritativeEntry(field, want)
+			if !listed || reported[entry] {
+				continue
+			}
+			if _, ok := wantFields[field]; ok || hasPrefix(wantFields, entry) {
+				continue
+			}
+			if name, ok := strings.CutPrefix(entry, "env."); ok && slices.Contains(platformEnv, name) {
+				continue
+			}
+			reported[entry] = true
+			if entry == field {
+				record(field, "unexpected", nil, redactEnv(field, liveFields[field]), m.ID)
+			} else {
+				record(entry, "unexpected", nil, "present", m.ID)
+			}
+		}
+	}
+	report.Fields = fields
+
+	slices.Sort(order)
+	for _, field := range order {
+		d := drift[field]
+		for i := range d.Live {
+			slices.Sort(d.Live[i].Machines)
+		}
+		report.Drift = append(report.Drift, *d)
+	}
+	if len(report.Machines) == 0 {
+		report.Warnings = append(report.Warnings, "No machines to compare; the app has none in this process group")
+	}
+	return report, nil
+}
+
+// machineProcessGroup returns the process group a machine belongs to
+func machineProcessGroup(m Machine) string {
+	if metadata, ok := m.Config["metadata"].(map[string]interface{}); ok {
+		if group, ok := metadata["fly_process_group"].(string); ok && group != "" {
+			return group
+		}
+	}
+	return "app"
+}
+
+// flattenConfig flattens a machine config to its leaf fields, named like
+// services[tcp/8080].ports[443].handlers. Services, ports and mounts are
+// keyed by what identifies them, so their order does not matter; lists of
+// values, such as handlers, are compared whole.
+func flattenConfig(value interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	var walk func(name string, v interface{})
+	walk = func(name string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if len(val) == 0 && name != "" {
+				return
+			}
+			for key, child := range val {
+				walk(joinField(name, key), child)
+			}
+		case []interface{}:
+			entries := len(val) > 0
+			for _, item := range val {
+				if _, ok := item.(map[string]interface{}); !ok {
+					entries = false
+				}
+			}
+			if !entries {
+				fields[name] = normalizeValue(val)
+				return
+			}
+			for i, item := range val {
+				walk(name+"["+entryKey(item.(map[string]interface{}), i)+"]", item)
+			}
+		default:
+			fields[name] = normalizeValue(val)
+		}
+	}
+	walk("", value)
+	return fields
+}
+
+// entryKey returns what identifies an entry of a list: the protocol and
+// port of a service, the port of a service port, the path of a mount, or
+// else its position
+func entryKey(entry map[string]interface{}, i int) string {
+	if port, ok := entry["internal_port"]; ok {
+		protocol, _ := entry["protocol"].(string)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		return fmt.Sprintf("%s/%v", protocol, port)
+	}
+	if port, ok := entry["port"]; ok {
+		return fmt.Sprint(port)
+	}
+	if mountPath, ok := entry["path"].(string); ok && entry["type"] == nil {
+		return mountPath
+	}
+	return fmt.Sprint(i)
+}
+
+// joinField names a field below another
+func joinField(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// normalizeValue makes live and desired values comparable: JSON numbers of
+// any type are float64, and autostop may be a boolean in older configs
+func normalizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case []interface{}:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			list[i] = normalizeValue(item)
+		}
+		return list
+	case map[string]interface{}, string, bool, float64, nil:
+		return val
+	}
+	// Anything else, e.g. a struct from a JSON spec, compares as JSON
+	raw, _ := json.Marshal(v)
+	var decoded interface{}
+	json.Unmarshal(raw, &decoded)
+	return decoded
+}
+
+// sameValue reports whether a live field matches the desired value. An
+// unset live field matches a desired zero value, which the Machines API
+// leaves out.
+func sameValue(want, live interface{}, set bool) bool {
+	if !set {
+		switch w := want.(type) {
+		case bool:
+			return !w
+		case float64:
+			return w == 0
+		case string:
+			return w == ""
+		case []interface{}:
+			return len(w) == 0
+		}
+		return want == nil
+	}
+	if autoStop, ok := live.(bool); ok {
+		if _, isString := want.(string); isString {
+			live = map[bool]string{true: "stop", false: "off"}[autoStop]
+		}
+	}
+	return reflect.DeepEqual(want, live)
+}
+
+// authoritativeEntry returns the entry of a collection a live field belongs
+// to, e.g. env.LOG_LEVEL or services[tcp/9090], when the desired config
+// lists that collection and so rules out extras
+func authoritativeEntry(field string, want map[string]interface{}) (string, bool) {
+	for _, collection := range []string{"env", "checks", "services", "mounts"} {
+		if _, listed := want[collection]; !listed {
+			continue
+		}
+		rest, ok := strings.CutPrefix(field, collection)
+		if !ok || rest == "" {
+			continue
+		}
+		switch rest[0] {
+		case '.':
+			name, _, _ := strings.Cut(rest[1:], ".")
+			return collection + "." + name, true
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				continue
+			}
+			return collection + rest[:end+1], true
+		}
+	}
+	return "", false
+}
+
+// hasPrefix reports whether any field is at or below entry
+func hasPrefix(fields map[string]interface{}, entry string) bool {
+	for field := range fields {
+		if field == entry || strings.HasPrefix(field, entry+".") || strings.HasPrefix(field, entry+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredField reports whether a field, or one above it, matches an ignore
+// pattern. A * in a pattern matches any part of a name but a dot.
+func ignoredField(patterns []string, field string) bool {
+	for _, pattern := range patterns {
+		expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, `[^.]*`) + "$"
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			continue
+		}
+		for name := field; name != ""; name = parentField(name) {
+			if re.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parentField returns the field a field is below, or "" at the top
+func parentField(field string) string {
+	if i := strings.LastIndexAny(field, ".["); i > 0 {
+		return field[:i]
+	}
+	return ""
+}
+
+// redactEnv replaces the value of an env field with a digest, so drift in
+// values shows without revealing them
+func redactEnv(field string, value interface{}) interface{} {
+	if !strings.HasPrefix(field, "env.") || value == nil {
+		return value
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,305 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// envNamePattern matches valid environment variable names
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Kinds of environment variable change
+const (
+	EnvAdded   = "added"
+	EnvChanged = "changed"
+	EnvRemoved = "removed"
+)
+
+// EnvVar is an environment variable set in machine configs
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Machines is how many of the app's machines set the variable; when it
+	// is less than the app's machine count, or machines disagree on the
+	// value, the variable is inconsistent
+	Machines     int  `json:"machines"`
+	Inconsistent bool `json:"inconsistent,omitempty"`
+}
+
+// AppEnv lists the non-secret environment of an app's machines
+type AppEnv struct {
+	AppName  string   `json:"appName"`
+	Machines int      `json:"machines"`
+	Vars     []EnvVar `json:"vars"`
+	// Secrets that have the same name as a variable override it
+	ShadowedBySecrets []string `json:"shadowedBySecrets,omitempty"`
+}
+
+// EnvUpdate lists variables to set and remove
+type EnvUpdate struct {
+	Set   map[string]string
+	Unset []string
+}
+
+// EnvDiffEntry is one variable an update changes
+type EnvDiffEntry struct {
+	Name     string `json:"name"`
+	Change   string `json:"change"`
+	OldValue string `json:"oldValue,omitempty"`
+	NewValue string `json:"newValue,omitempty"`
+}
+
+// EnvPlan is the diff an update will apply
+type EnvPlan struct {
+	AppName  string         `json:"appName"`
+	Machines int            `json:"machines"`
+	Changes  []EnvDiffEntry `json:"changes"`
+	// ShadowedBySecrets are variables being set that a secret overrides
+	ShadowedBySecrets []string `json:"shadowedBySecrets,omitempty"`
+}
+
+// EnvUpdateResult is the outcome of an environment update
+type EnvUpdateResult struct {
+	AppName  string            `json:"appName"`
+	Status   string            `json:"status"`
+	Changes  []EnvDiffEntry    `json:"changes"`
+	Machines []DeployedMachine `json:"machines"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// envMachines returns the machines whose environment fly_env manages:
+// every machine except destroyed ones and scheduled tasks
+func (c *Client) envMachines(ctx context.Context, appName string) ([]Machine, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	var result []Machine
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" || machineSchedule(m) != "" {
+			continue
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// machineEnv returns the environment in a machine config
+func machineEnv(m Machine) map[string]string {
+	env := make(map[string]string)
+	raw, _ := m.Config["env"].(map[string]interface{})
+	for name, value := range raw {
+		if s, ok := value.(string); ok {
+			env[name] = s
+		}
+	}
+	return env
+}
+
+// GetAppEnv lists the environment variables set in an app's machine configs.
+// Secrets are not included; see GetSecrets.
+func (c *Client) GetAppEnv(ctx context.Context, appName string) (*AppEnv, error) {
+	machines, err := c.envMachines(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	env := summarizeEnv(appName, machines)
+
+	names := make([]string, 0, len(env.Vars))
+	for _, v := range env.Vars {
+		names = append(names, v.Name)
+	}
+	env.ShadowedBySecrets = c.shadowedBySecrets(ctx, appName, names)
+
+	return env, nil
+}
+
+// summarizeEnv combines the environments of an app's machines
+func summarizeEnv(appName string, machines []Machine) *AppEnv {
+	env := &AppEnv{AppName: appName, Machines: len(machines), Vars: []EnvVar{}}
+
+	values := make(map[string]map[string]int)
+	for _, m := range machines {
+		for name, value := range machineEnv(m) {
+			if values[name] == nil {
+				values[name] = make(map[string]int)
+			}
+			values[name][value]++
+		}
+	}
+
+	for name, byValue := range values {
+		v := EnvVar{Name: name, Inconsistent: len(byValue) > 1}
+		// Show the value most machines use
+		best := -1
+		for value, count := range byValue {
+			v.Machines += count
+			if count > best || (count == best && value < v.Value) {
+				v.Value, best = value, count
+			}
+		}
+		if v.Machines < len(machines) {
+			v.Inconsistent = true
+		}
+		env.Vars = append(env.Vars, v)
+	}
+	sort.Slice(env.Vars, func(i, j int) bool {
+		return env.Vars[i].Name < env.Vars[j].Name
+	})
+
+	return env
+}
+
+// PlanEnvUpdate validates an update and returns the changes it makes
+// compared with the app's current environment
+func (c *Client) PlanEnvUpdate(ctx context.Context, appName string, update EnvUpdate) (*EnvPlan, error) {
+	plan, _, err := c.planEnvUpdate(ctx, appName, update)
+	return plan, err
+}
+
+// planEnvUpdate builds the plan for an update and returns the machines it
+// will change
+func (c *Client) planEnvUpdate(ctx context.Context, appName string, update EnvUpdate) (*EnvPlan, []Machine, error) {
+	for name := range update.Set {
+		if !envNamePattern.MatchString(name) {
+			return nil, nil, &FlyError{
+				StatusCode: http.StatusBadRequest,
+				Code:       ErrorCodeInvalid,
+				Message:    fmt.Sprintf("'%s' is not a valid environment variable name", name),
+			}
+		}
+	}
+
+	machines, err := c.envMachines(ctx, appName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(machines) == 0 {
+		return nil, nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("app %s has no machines to update", appName),
+		}
+	}
+
+	current := summarizeEnv(appName, machines)
+	existing := make(map[string]EnvVar, len(current.Vars))
+	for _, v := range current.Vars {
+		existing[v.Name] = v
+	}
+
+	plan := &EnvPlan{AppName: appName, Machines: len(machines), Changes: []EnvDiffEntry{}}
+	var setNames []string
+	for name, value := range update.Set {
+		old, ok := existing[name]
+		switch {
+		case !ok:
+			plan.Changes = append(plan.Changes, EnvDiffEntry{Name: name, Change: EnvAdded, NewValue: value})
+		case old.Value != value || old.Inconsistent:
+			plan.Changes = append(plan.Changes, EnvDiffEntry{Name: name, Change: EnvChanged, OldValue: old.Value, NewValue: value})
+		}
+		setNames = append(setNames, name)
+	}
+	for _, name := range update.Unset {
+		if old, ok := existing[name]; ok {
+			plan.Changes = append(plan.Changes, EnvDiffEntry{Name: name, Change: EnvRemoved, OldValue: old.Value})
+		}
+	}
+	sort.Slice(plan.Changes, func(i, j int) bool {
+		return plan.Changes[i].Name < plan.Changes[j].Name
+	})
+
+	plan.ShadowedBySecrets = c.shadowedBySecrets(ctx, appName, setNames)
+
+	return plan, machines, nil
+}
+
+// UpdateAppEnv applies an environment update to an app's machines one at a
+// time, waiting for each running machine to become healthy before moving on.
+// An update that changes nothing leaves the machines alone. A machine that
+// fails stops the update, which is reported in the result rather than as an
+// error.
+func (c *Client) UpdateAppEnv(ctx context.Context, appName string, update EnvUpdate, healthTimeout time.Duration) (*EnvUpdateResult, error) {
+	plan, machines, err := c.planEnvUpdate(ctx, appName, update)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EnvUpdateResult{
+		AppName:  appName,
+		Status:   DeploySucceeded,
+		Changes:  plan.Changes,
+		Machines: []DeployedMachine{},
+	}
+	if len(plan.Changes) == 0 {
+		return result, nil
+	}
+
+	for i, m := range machines {
+		if err := ctx.Err(); err != nil {
+			result.Status = DeployFailed
+			result.Error = fmt.Sprintf("update cancelled after %d of %d machines: %v", i, len(machines), context.Cause(ctx))
+			break
+		}
+
+		env := machineEnv(m)
+		maps.Copy(env, update.Set)
+		for _, name := range update.Unset {
+			delete(env, name)
+		}
+		machineConfig := maps.Clone(m.Config)
+		machineConfig["env"] = env
+
+		if err := c.updateMachine(ctx, appName, m, machineConfig, healthTimeout); err != nil {
+			result.Machines = append(result.Machines, DeployedMachine{MachineID: m.ID, Region: m.Region, Action: "failed", Error: err.Error()})
+			result.Status = DeployFailed
+			result.Error = fmt.Sprintf("machine %s failed after %d of %d machines were updated: %v", m.ID, i, len(machines), err)
+			break
+		}
+		result.Machines = append(result.Machines, DeployedMachine{MachineID: m.ID, Region: m.Region, Action: "updated"})
+		interfaces.ReportProgress(ctx, float64(i+1), float64(len(machines)), fmt.Sprintf("updated %d/%d machines", i+1, len(machines)))
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Int("changes", len(plan.Changes)).
+		Int("machines_updated", len(result.Machines)).
+		Str("status", result.Status).
+		Msg("Updated app environment")
+
+	return result, nil
+}
+
+// shadowedBySecrets returns the names that are also secrets of the app.
+// Failing to list secrets only loses the warning, so errors are ignored.
+func (c *Client) shadowedBySecrets(ctx context.Context, appName string, names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	secrets, err := c.GetSecrets(ctx, appName)
+	if err != nil {
+		return nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var shadowed []string
+	for _, s := range secrets {
+		if wanted[s.Name] {
+			shadowed = append(shadowed, s.Name)
+		}
+	}
+	sort.Strings(shadowed)
+	return shadowed
+}
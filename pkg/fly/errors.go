@@ -0,0 +1,262 @@
+package fly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorCode classifies a Fly.io API failure
+type ErrorCode string
+
+const (
+	ErrorCodeNotFound     ErrorCode = "not_found"
+	ErrorCodeUnauthorized ErrorCode = "unauthorized"
+	ErrorCodeForbidden    ErrorCode = "forbidden"
+	ErrorCodeInvalid      ErrorCode = "invalid"
+	ErrorCodeConflict     ErrorCode = "conflict"
+	ErrorCodeRateLimited  ErrorCode = "rate_limited"
+	ErrorCodeUnavailable  ErrorCode = "unavailable"
+	ErrorCodeServer       ErrorCode = "server_error"
+	ErrorCodeNetwork      ErrorCode = "network"
+	ErrorCodeUnknown      ErrorCode = "unknown"
+)
+
+// requestIDHeader is the response header carrying Fly's request ID
+const requestIDHeader = "Fly-Request-Id"
+
+// non200Pattern extracts the status code from GraphQL transport errors
+var non200Pattern = regexp.MustCompile(`(?:non-200 status code: |returned error )(\d{3})`)
+
+// FlyError is a typed failure from the GraphQL, Machines or metrics API
+type FlyError struct {
+	StatusCode int       `json:"statusCode"`
+	Code       ErrorCode `json:"code"`
+	APICode    string    `json:"apiCode,omitempty"`
+	Message    string    `json:"message"`
+	RequestID  string    `json:"requestId,omitempty"`
+	Retryable  bool      `json:"retryable"`
+	Err        error     `json:"-"`
+}
+
+// Error implements the error interface
+func (e *FlyError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = string(e.Code)
+	}
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s (status %d)", msg, e.StatusCode)
+	}
+	if e.RequestID != "" {
+		msg = fmt.Sprintf("%s [request %s]", msg, e.RequestID)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *FlyError) Unwrap() error {
+	return e.Err
+}
+
+// Hint returns an actionable suggestion for resolving the error
+func (e *FlyError) Hint() string {
+	switch e.Code {
+	case ErrorCodeNotFound:
+		return "Check the name for typos and use `fly_list_apps` to see the apps this token can access."
+	case ErrorCodeUnauthorized:
+		return "The server's Fly.io API token is missing, invalid or expired. Create a new token with `fly tokens create` and update the server configuration."
+	case ErrorCodeForbidden:
+		return "The server's Fly.io API token is not allowed to perform this action. Use a token scoped to the app's organization."
+	case ErrorCodeInvalid:
+		return "Fly.io rejected the request parameters. Review the arguments and try again."
+	case ErrorCodeConflict:
+		return "The resource is being changed by another operation. Wait a moment and try again."
+	case ErrorCodeRateLimited:
+		return "Fly.io is rate limiting requests. Wait a few seconds before retrying."
+	case ErrorCodeUnavailable, ErrorCodeServer:
+		return "Fly.io is having trouble handling the request. Retry shortly and check https://status.flyio.net if it persists."
+	case ErrorCodeNetwork:
+		return "The Fly.io API could not be reached. Check network connectivity and retry."
+	}
+	return ""
+}
+
+// AsFlyError returns the FlyError in err's chain, if any
+func AsFlyError(err error) (*FlyError, bool) {
+	var flyErr *FlyError
+	if errors.As(err, &flyErr) {
+		return flyErr, true
+	}
+	return nil, false
+}
+
+// IsNotFound reports whether err is a not found error from the Fly.io API
+func IsNotFound(err error) bool {
+	flyErr, ok := AsFlyError(err)
+	return ok && flyErr.Code == ErrorCodeNotFound
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying
+func IsRetryable(err error) bool {
+	flyErr, ok := AsFlyError(err)
+	return ok && flyErr.Retryable
+}
+
+// newHTTPError builds a FlyError from a non-2xx REST response. Only the
+// error message field of the body is kept so raw payloads never reach users.
+func newHTTPError(resp *http.Response, body []byte) *FlyError {
+	flyErr := &FlyError{
+		StatusCode: resp.StatusCode,
+		Code:       codeFromStatus(resp.StatusCode),
+		RequestID:  resp.Header.Get(requestIDHeader),
+	}
+	flyErr.Retryable = isRetryableCode(flyErr.Code)
+
+	var payload struct {
+		Error     string `json:"error"`
+		ErrorType string `json:"errorType"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		flyErr.APICode = payload.ErrorType
+		flyErr.Message = payload.Error
+		if flyErr.Message == "" {
+			flyErr.Message = payload.Message
+		}
+	}
+	if flyErr.Message == "" {
+		flyErr.Message = strings.ToLower(http.StatusText(resp.StatusCode))
+	}
+
+	return flyErr
+}
+
+// newNetworkError wraps a transport failure that produced no response
+func newNetworkError(err error) *FlyError {
+	flyErr := &FlyError{
+		Code:      ErrorCodeNetwork,
+		Message:   fmt.Sprintf("failed to make request: %v", err),
+		Retryable: true,
+		Err:       err,
+	}
+	if errors.Is(err, context.Canceled) {
+		flyErr.Message = "request was cancelled"
+		flyErr.Retryable = false
+	}
+	return flyErr
+}
+
+// parseGraphQLError converts an error returned by fly-go into a FlyError.
+// It returns nil for a nil error.
+func parseGraphQLError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := AsFlyError(err); ok {
+		return err
+	}
+
+	flyErr := &FlyError{Code: ErrorCodeUnknown, Message: err.Error(), Err: err}
+
+	var gqlErr *graphql.GraphQLError
+	var gqlList gqlerror.List
+	var apiErr *fly.ApiError
+
+	switch {
+	case errors.As(err, &gqlErr) && gqlErr.Extensions.Code != "":
+		flyErr.APICode = gqlErr.Extensions.Code
+		flyErr.Message = gqlErr.Message
+	case errors.As(err, &gqlList) && len(gqlList) > 0:
+		flyErr.Message = gqlList[0].Message
+		if code, ok := gqlList[0].Extensions["code"].(string); ok {
+			flyErr.APICode = code
+		}
+	case errors.As(err, &apiErr):
+		flyErr.StatusCode = apiErr.Status
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+		return newNetworkError(err)
+	}
+
+	if flyErr.StatusCode == 0 {
+		if m := non200Pattern.FindStringSubmatch(err.Error()); m != nil {
+			flyErr.StatusCode, _ = strconv.Atoi(m[1])
+			flyErr.Message = strings.ToLower(http.StatusText(flyErr.StatusCode))
+		}
+	}
+
+	switch {
+	case flyErr.APICode != "":
+		flyErr.Code, flyErr.StatusCode = codeFromGraphQL(flyErr.APICode)
+	case flyErr.StatusCode != 0:
+		flyErr.Code = codeFromStatus(flyErr.StatusCode)
+	case strings.Contains(strings.ToLower(flyErr.Message), "could not find"):
+		flyErr.Code, flyErr.StatusCode = ErrorCodeNotFound, http.StatusNotFound
+	}
+	flyErr.Retryable = isRetryableCode(flyErr.Code)
+
+	return flyErr
+}
+
+// codeFromStatus maps an HTTP status code to an ErrorCode
+func codeFromStatus(status int) ErrorCode {
+	switch {
+	case status == http.StatusNotFound:
+		return ErrorCodeNotFound
+	case status == http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case status == http.StatusForbidden:
+		return ErrorCodeForbidden
+	case status == http.StatusConflict || status == http.StatusPreconditionFailed:
+		return ErrorCodeConflict
+	case status == http.StatusTooManyRequests:
+		return ErrorCodeRateLimited
+	case status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout || status == http.StatusBadGateway:
+		return ErrorCodeUnavailable
+	case status >= 500:
+		return ErrorCodeServer
+	case status >= 400:
+		return ErrorCodeInvalid
+	}
+	return ErrorCodeUnknown
+}
+
+// codeFromGraphQL maps a GraphQL extensions code to an ErrorCode and the
+// equivalent HTTP status
+func codeFromGraphQL(code string) (ErrorCode, int) {
+	switch code {
+	case "NOT_FOUND":
+		return ErrorCodeNotFound, http.StatusNotFound
+	case "UNAUTHORIZED", "UNAUTHENTICATED":
+		return ErrorCodeUnauthorized, http.StatusUnauthorized
+	case "FORBIDDEN":
+		return ErrorCodeForbidden, http.StatusForbidden
+	case "INVALID", "INVALID_ARGUMENTS", "UNPROCESSABLE", "BAD_USER_INPUT":
+		return ErrorCodeInvalid, http.StatusUnprocessableEntity
+	case "RATE_LIMITED":
+		return ErrorCodeRateLimited, http.StatusTooManyRequests
+	case "MAINTENANCE", "SERVICE_UNAVAILABLE":
+		return ErrorCodeUnavailable, http.StatusServiceUnavailable
+	case "SERVER_ERROR", "INTERNAL_SERVER_ERROR":
+		return ErrorCodeServer, http.StatusInternalServerError
+	}
+	return ErrorCodeUnknown, http.StatusInternalServerError
+}
+
+// isRetryableCode reports whether failures with this code are transient
+func isRetryableCode(code ErrorCode) bool {
+	switch code {
+	case ErrorCodeRateLimited, ErrorCodeUnavailable, ErrorCodeServer, ErrorCodeNetwork, ErrorCodeConflict:
+		return true
+	}
+	return false
+}
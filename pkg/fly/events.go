@@ -0,0 +1,122 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// maxEventsPerMachine caps the history fetched for each machine
+const maxEventsPerMachine = 200
+
+// TimelineEvent is a machine event in an app-wide timeline
+type TimelineEvent struct {
+	MachineID string    `json:"machineId"`
+	Region    string    `json:"region"`
+	Type      string    `json:"type"`
+	Status    string    `json:"status"`
+	Source    string    `json:"source"`
+	Time      time.Time `json:"time"`
+	ExitCode  *int      `json:"exitCode,omitempty"`
+	Signal    int       `json:"signal,omitempty"`
+	OOMKilled bool      `json:"oomKilled,omitempty"`
+	// RequestedStop is set when the exit followed a stop request rather
+	// than the process ending on its own
+	RequestedStop bool `json:"requestedStop,omitempty"`
+}
+
+// IsCrash reports whether the event is an exit the machine did not ask for
+func (e TimelineEvent) IsCrash() bool {
+	if e.Type != "exit" || e.RequestedStop {
+		return false
+	}
+	return e.OOMKilled || (e.ExitCode != nil && *e.ExitCode != 0)
+}
+
+// EventFilter selects the events included in a timeline
+type EventFilter struct {
+	MachineID string
+	Since     time.Time
+	Types     []string
+}
+
+// matches reports whether an event passes the filter
+func (f EventFilter) matches(e TimelineEvent) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if e.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMachineEvents builds a chronological event timeline for one machine or
+// every machine of an application
+func (c *Client) GetMachineEvents(ctx context.Context, appName string, filter EventFilter) ([]TimelineEvent, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	if filter.MachineID != "" {
+		var selected []Machine
+		for _, m := range machines {
+			if m.ID == filter.MachineID {
+				selected = append(selected, m)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, &FlyError{
+				StatusCode: http.StatusNotFound,
+				Code:       ErrorCodeNotFound,
+				Message:    fmt.Sprintf("machine %s not found in app %s", filter.MachineID, appName),
+			}
+		}
+		machines = selected
+	}
+
+	timeline := []TimelineEvent{}
+	for _, m := range machines {
+		events, err := c.machinesClient.ListMachineEvents(ctx, appName, m.ID, maxEventsPerMachine)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get events for machine %s: %w", m.ID, err)
+		}
+
+		for _, e := range events {
+			event := TimelineEvent{
+				MachineID: m.ID,
+				Region:    m.Region,
+				Type:      e.Type,
+				Status:    e.Status,
+				Source:    e.Source,
+				Time:      time.UnixMilli(e.Timestamp).UTC(),
+			}
+			if e.Request != nil && e.Request.ExitEvent != nil {
+				exit := e.Request.ExitEvent
+				code := exit.ExitCode
+				event.ExitCode = &code
+				event.Signal = exit.Signal
+				event.OOMKilled = exit.OOMKilled
+				event.RequestedStop = exit.RequestedStop
+			}
+
+			if filter.matches(event) {
+				timeline = append(timeline, event)
+			}
+		}
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time.Before(timeline[j].Time)
+	})
+
+	return timeline, nil
+}
@@ -0,0 +1,202 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fleetConcurrency is how many apps' machines are listed at once
+const fleetConcurrency = 8
+
+// Fleet health states, from most to least in need of attention
+const (
+	FleetUnhealthy = "unhealthy"
+	FleetUnknown   = "unknown"
+	FleetDegraded  = "degraded"
+	FleetStopped   = "stopped"
+	FleetEmpty     = "empty"
+	FleetSuspended = "suspended"
+	FleetHealthy   = "healthy"
+)
+
+// fleetOrder ranks the health states for sorting, most urgent first
+var fleetOrder = map[string]int{
+	FleetUnhealthy: 0,
+	FleetUnknown:   1,
+	FleetDegraded:  2,
+	FleetStopped:   3,
+	FleetEmpty:     4,
+	FleetSuspended: 5,
+	FleetHealthy:   6,
+}
+
+// FleetStatus is the status of many apps, the least healthy first
+type FleetStatus struct {
+	Organization string         `json:"organization,omitempty"`
+	GeneratedAt  time.Time      `json:"generatedAt"`
+	Counts       map[string]int `json:"counts"`
+	Apps         []FleetApp     `json:"apps"`
+}
+
+// FleetApp is the status of one app of a fleet
+type FleetApp struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Deployed bool   `json:"deployed"`
+	// Health is unhealthy, unknown, degraded, stopped, empty, suspended or
+	// healthy
+	Health   string   `json:"health"`
+	Machines int      `json:"machines"`
+	Started  int      `json:"started"`
+	Regions  []string `json:"regions"`
+	// Problems explains an app's health when it is not healthy
+	Problems []string `json:"problems,omitempty"`
+}
+
+// GetFleetStatus lists the machines of many apps concurrently and rates the
+// health of each. include decides which of the organization's apps are
+// covered; apps whose machines cannot be listed are rated unknown.
+func (c *Client) GetFleetStatus(ctx context.Context, include func(appName string) bool) (*FleetStatus, error) {
+	apps, err := c.GetApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fleet := &FleetStatus{
+		Organization: c.config.Organization,
+		GeneratedAt:  time.Now().UTC(),
+		Counts:       make(map[string]int),
+		Apps:         []FleetApp{},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fleetConcurrency)
+	for _, app := range apps {
+		if include != nil && !include(app.Name) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			entry := c.fleetApp(ctx, app)
+
+			mu.Lock()
+			defer mu.Unlock()
+			fleet.Apps = append(fleet.Apps, entry)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(fleet.Apps, func(i, j int) bool {
+		a, b := fleet.Apps[i], fleet.Apps[j]
+		if fleetOrder[a.Health] != fleetOrder[b.Health] {
+			return fleetOrder[a.Health] < fleetOrder[b.Health]
+		}
+		return a.Name < b.Name
+	})
+	for _, app := range fleet.Apps {
+		fleet.Counts[app.Health]++
+	}
+
+	c.logger.Debug().
+		Int("apps", len(fleet.Apps)).
+		Int("unhealthy", fleet.Counts[FleetUnhealthy]).
+		Msg("Collected fleet status")
+
+	return fleet, nil
+}
+
+// fleetApp lists one app's machines and rates its health
+func (c *Client) fleetApp(ctx context.Context, app App) FleetApp {
+	entry := FleetApp{
+		Name:     app.Name,
+		Status:   app.Status,
+		Deployed: app.Deployed,
+		Regions:  []string{},
+	}
+	if app.Status == "suspended" {
+		entry.Health = FleetSuspended
+		return entry
+	}
+
+	machines, err := c.machinesClient.ListMachines(ctx, app.Name)
+	if err != nil {
+		entry.Health = FleetUnknown
+		entry.Problems = append(entry.Problems, fmt.Sprintf("machines unavailable: %v", err))
+		return entry
+	}
+
+	seen := make(map[string]bool)
+	var critical, warning, failed []string
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" {
+			continue
+		}
+		entry.Machines++
+		if !seen[m.Region] {
+			seen[m.Region] = true
+			entry.Regions = append(entry.Regions, m.Region)
+		}
+		switch m.State {
+		case "started":
+			entry.Started++
+		case "failed":
+			failed = append(failed, m.ID)
+		}
+		if m.State != "started" {
+			continue
+		}
+		for _, check := range m.Checks {
+			switch check.Status {
+			case "critical":
+				critical = append(critical, fmt.Sprintf("%s on %s", check.Name, m.ID))
+			case "warning":
+				warning = append(warning, fmt.Sprintf("%s on %s", check.Name, m.ID))
+			}
+		}
+	}
+	sort.Strings(entry.Regions)
+
+	if len(failed) > 0 {
+		entry.Problems = append(entry.Problems, fmt.Sprintf("%d failed machine(s): %s", len(failed), joinLimited(failed, 3)))
+	}
+	if len(critical) > 0 {
+		entry.Problems = append(entry.Problems, fmt.Sprintf("%d critical check(s): %s", len(critical), joinLimited(critical, 3)))
+	}
+	if len(warning) > 0 {
+		entry.Problems = append(entry.Problems, fmt.Sprintf("%d check(s) warning: %s", len(warning), joinLimited(warning, 3)))
+	}
+
+	switch {
+	case len(failed) > 0 || len(critical) > 0:
+		entry.Health = FleetUnhealthy
+	case len(warning) > 0:
+		entry.Health = FleetDegraded
+	case entry.Machines == 0:
+		entry.Health = FleetEmpty
+		entry.Problems = append(entry.Problems, "no machines")
+	case entry.Started == 0:
+		// Apps that stop idle machines are expected to have none running
+		entry.Health = FleetStopped
+		entry.Problems = append(entry.Problems, fmt.Sprintf("all %d machine(s) stopped", entry.Machines))
+	default:
+		entry.Health = FleetHealthy
+	}
+	return entry
+}
+
+// joinLimited joins the first n items and counts the rest
+func joinLimited(items []string, n int) string {
+	if len(items) <= n {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(items[:n], ", "), len(items)-n)
+}
@@ -0,0 +1,196 @@
+package fly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// graphQLVarPattern matches variable references in a GraphQL selection
+var graphQLVarPattern = regexp.MustCompile(`\$(\w+)`)
+
+// batchVar is a variable used by a batched GraphQL field
+type batchVar struct {
+	Type  string
+	Value interface{}
+}
+
+// batchField is one aliased root field of a batched query
+type batchField struct {
+	alias     string
+	selection string
+	target    interface{}
+	err       error
+}
+
+// graphQLBatch combines independent read-only GraphQL queries into a single
+// request. Each query becomes an aliased root field, so one tool call costs
+// one round trip instead of one per query, and a field that fails does not
+// fail the others.
+type graphQLBatch struct {
+	client *Client
+	fields []*batchField
+	vars   map[string]batchVar
+}
+
+// newGraphQLBatch starts an empty batch
+func (c *Client) newGraphQLBatch() *graphQLBatch {
+	return &graphQLBatch{
+		client: c,
+		vars:   make(map[string]batchVar),
+	}
+}
+
+// Add queues a root field. selection is the field with its arguments and
+// sub-selection, e.g. `app(name: $appName) { network }`; its variables are
+// prefixed with the alias so fields cannot collide. The field's result is
+// decoded into target.
+func (b *graphQLBatch) Add(alias, selection string, vars map[string]batchVar, target interface{}) {
+	selection = graphQLVarPattern.ReplaceAllStringFunc(selection, func(ref string) string {
+		name := ref[1:]
+		if _, ok := vars[name]; !ok {
+			return ref
+		}
+		return "$" + alias + "_" + name
+	})
+	for name, v := range vars {
+		b.vars[alias+"_"+name] = v
+	}
+
+	b.fields = append(b.fields, &batchField{alias: alias, selection: selection, target: target})
+}
+
+// Err returns the error reported for a field after Run
+func (b *graphQLBatch) Err(alias string) error {
+	for _, f := range b.fields {
+		if f.alias == alias {
+			return f.err
+		}
+	}
+	return nil
+}
+
+// query renders the batch as a single GraphQL document
+func (b *graphQLBatch) query() string {
+	names := make([]string, 0, len(b.vars))
+	for name := range b.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("query Batch")
+	if len(names) > 0 {
+		decls := make([]string, 0, len(names))
+		for _, name := range names {
+			decls = append(decls, fmt.Sprintf("$%s: %s", name, b.vars[name].Type))
+		}
+		sb.WriteString("(" + strings.Join(decls, ", ") + ")")
+	}
+	sb.WriteString(" {")
+	for _, f := range b.fields {
+		sb.WriteString(" " + f.alias + ": " + f.selection)
+	}
+	sb.WriteString(" }")
+
+	return sb.String()
+}
+
+// Run sends the batch. The returned error covers the request as a whole;
+// errors for individual fields are available from Err.
+func (b *graphQLBatch) Run(ctx context.Context) error {
+	if len(b.fields) == 0 {
+		return nil
+	}
+
+	variables := make(map[string]interface{}, len(b.vars))
+	for name, v := range b.vars {
+		variables[name] = v.Value
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     b.query(),
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	// Batches only read, so they can be retried like GET requests
+	req, err := http.NewRequestWithContext(withIdempotent(ctx), "POST", b.client.config.APIURL+"/graphql", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.graphqlHTTP.Do(req)
+	if err != nil {
+		return newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPError(resp, respBody)
+	}
+
+	var result struct {
+		Data   map[string]json.RawMessage `json:"data"`
+		Errors []struct {
+			Message    string                 `json:"message"`
+			Path       []interface{}          `json:"path"`
+			Extensions map[string]interface{} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	fieldErrors := make(map[string]gqlerror.List)
+	for _, e := range result.Errors {
+		alias := ""
+		if len(e.Path) > 0 {
+			alias, _ = e.Path[0].(string)
+		}
+		fieldErrors[alias] = append(fieldErrors[alias], &gqlerror.Error{
+			Message:    e.Message,
+			Extensions: e.Extensions,
+		})
+	}
+
+	for _, f := range b.fields {
+		switch {
+		case len(fieldErrors[f.alias]) > 0:
+			f.err = parseGraphQLError(fieldErrors[f.alias])
+		case len(fieldErrors[""]) > 0:
+			// Errors without a path apply to the whole document
+			f.err = parseGraphQLError(fieldErrors[""])
+		default:
+			if raw, ok := result.Data[f.alias]; ok && f.target != nil {
+				if err := json.Unmarshal(raw, f.target); err != nil {
+					f.err = fmt.Errorf("failed to decode %s: %w", f.alias, err)
+				}
+			}
+		}
+	}
+
+	b.client.logger.Debug().
+		Int("fields", len(b.fields)).
+		Int("errors", len(result.Errors)).
+		Msg("Ran batched GraphQL query")
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// Health gate decisions
+const (
+	GatePassed     = "passed"
+	GateRolledBack = "rolled_back"
+	// GateFailed means the threshold was exceeded but the machines were not
+	// rolled back, because rollback was disabled or not possible
+	GateFailed = "failed"
+)
+
+// gatePollInterval is how often machines are sampled while a health gate
+// watches them
+const gatePollInterval = 5 * time.Second
+
+// transitionalStates are machine states that are not sampled, since a
+// machine passes through them on its way to started
+var transitionalStates = map[string]bool{
+	"created":   true,
+	"starting":  true,
+	"replacing": true,
+	"updating":  true,
+}
+
+// HealthGate watches machines after a change and rolls them back when too
+// many health samples fail
+type HealthGate struct {
+	Window time.Duration
+	// Threshold is the fraction of failing samples, between 0 and 1, above
+	// which the gate fails
+	Threshold float64
+	Rollback  bool
+}
+
+// MachineHealthSamples summarizes the samples taken of one machine
+type MachineHealthSamples struct {
+	MachineID     string   `json:"machineId"`
+	Samples       int      `json:"samples"`
+	Failures      int      `json:"failures"`
+	LastState     string   `json:"lastState"`
+	FailingChecks []string `json:"failingChecks,omitempty"`
+}
+
+// HealthGateResult is the outcome of a health gate
+type HealthGateResult struct {
+	Window      string                 `json:"window"`
+	Threshold   float64                `json:"threshold"`
+	Samples     int                    `json:"samples"`
+	Failures    int                    `json:"failures"`
+	FailureRate float64                `json:"failureRate"`
+	Decision    string                 `json:"decision"`
+	Reason      string                 `json:"reason"`
+	Machines    []MachineHealthSamples `json:"machines"`
+	// RollbackImage is the image machines were rolled back to
+	RollbackImage string            `json:"rollbackImage,omitempty"`
+	Rollback      []DeployedMachine `json:"rollback,omitempty"`
+}
+
+// RunHealthGate samples the health of machines for the gate's window. If
+// the share of failing samples exceeds the threshold and rollback is enabled,
+// every machine in previous is returned to its image there, which may include
+// stopped machines that were not watched. With no previous images, as after a
+// restart, the watched machines go back to the image of the newest release
+// they are not already running.
+func (c *Client) RunHealthGate(ctx context.Context, appName string, machineIDs []string, gate HealthGate, previous map[string]string) *HealthGateResult {
+	result := &HealthGateResult{
+		Window:    gate.Window.String(),
+		Threshold: gate.Threshold,
+		Decision:  GatePassed,
+		Machines:  make([]MachineHealthSamples, len(machineIDs)),
+	}
+	for i, id := range machineIDs {
+		result.Machines[i].MachineID = id
+	}
+	if len(machineIDs) == 0 {
+		result.Reason = "no running machines to watch"
+		return result
+	}
+
+	deadline := time.Now().Add(gate.Window)
+	for {
+		for i := range result.Machines {
+			s := &result.Machines[i]
+			m, err := c.machinesClient.GetMachine(ctx, appName, s.MachineID)
+			if err != nil {
+				// An unreachable API says nothing about the machine
+				continue
+			}
+			s.LastState = m.State
+			if transitionalStates[m.State] {
+				// A machine still starting has not failed yet
+				continue
+			}
+
+			s.Samples++
+			result.Samples++
+			s.FailingChecks = criticalChecks(m.Checks)
+			if m.State != "started" || len(s.FailingChecks) > 0 {
+				s.Failures++
+				result.Failures++
+			}
+		}
+
+		elapsed := gate.Window - time.Until(deadline)
+		interfaces.ReportProgress(ctx, elapsed.Seconds(), gate.Window.Seconds(),
+			fmt.Sprintf("health gate: %d of %d samples failing", result.Failures, result.Samples))
+
+		if time.Now().Add(gatePollInterval).After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			result.Decision = GateFailed
+			result.Reason = fmt.Sprintf("the health gate was cancelled: %v", context.Cause(ctx))
+			return result
+		case <-time.After(gatePollInterval):
+		}
+	}
+
+	if result.Samples > 0 {
+		result.FailureRate = float64(result.Failures) / float64(result.Samples)
+	}
+	if result.FailureRate <= gate.Threshold {
+		result.Reason = fmt.Sprintf("%.0f%% of health samples failed, within the %.0f%% threshold", result.FailureRate*100, gate.Threshold*100)
+		return result
+	}
+
+	result.Decision = GateFailed
+	result.Reason = fmt.Sprintf("%.0f%% of health samples failed, above the %.0f%% threshold", result.FailureRate*100, gate.Threshold*100)
+	if !gate.Rollback {
+		result.Reason += "; automatic rollback is disabled"
+		return result
+	}
+
+	// Roll back even if the request was cancelled while the gate ran
+	ctx = context.WithoutCancel(ctx)
+	if previous == nil {
+		image, err := c.previousReleaseImage(ctx, appName, machineIDs)
+		if err != nil {
+			result.Reason += fmt.Sprintf("; no image to roll back to: %v", err)
+			return result
+		}
+		previous = make(map[string]string, len(machineIDs))
+		for _, id := range machineIDs {
+			previous[id] = image
+		}
+		result.RollbackImage = image
+	}
+
+	rollbackIDs := make([]string, 0, len(previous))
+	for id := range previous {
+		rollbackIDs = append(rollbackIDs, id)
+	}
+	sort.Strings(rollbackIDs)
+
+	rolledBack := 0
+	for _, id := range rollbackIDs {
+		image := previous[id]
+		deployed := DeployedMachine{MachineID: id, Action: "rolled_back"}
+		m, err := c.machinesClient.GetMachine(ctx, appName, id)
+		if err == nil {
+			deployed.Region = m.Region
+			_, err = c.machinesClient.UpdateMachineConfig(ctx, appName, id, withImage(m.Config, image), m.State != "started")
+		}
+		if err != nil {
+			deployed.Action = "failed"
+			deployed.Error = err.Error()
+		} else {
+			rolledBack++
+		}
+		result.Rollback = append(result.Rollback, deployed)
+	}
+
+	if rolledBack > 0 {
+		result.Decision = GateRolledBack
+		result.Reason += fmt.Sprintf("; rolled back %d of %d machine(s)", rolledBack, len(rollbackIDs))
+	} else {
+		result.Reason += "; the rollback failed"
+	}
+
+	c.logger.Warn().
+		Str("app_name", appName).
+		Float64("failure_rate", result.FailureRate).
+		Str("decision", result.Decision).
+		Msg("Health gate failed")
+
+	return result
+}
+
+// previousReleaseImage returns the image of the newest release that the
+// machines are not running now
+func (c *Client) previousReleaseImage(ctx context.Context, appName string, machineIDs []string) (string, error) {
+	current := make(map[string]bool)
+	for _, id := range machineIDs {
+		if m, err := c.machinesClient.GetMachine(ctx, appName, id); err == nil {
+			if image, ok := m.Config["image"].(string); ok {
+				current[image] = true
+			}
+		}
+	}
+
+	releases, err := c.GetReleases(ctx, appName, 10)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range releases {
+		if r.ImageRef != "" && !current[r.ImageRef] {
+			return r.ImageRef, nil
+		}
+	}
+
+	return "", &FlyError{
+		StatusCode: http.StatusNotFound,
+		Code:       ErrorCodeNotFound,
+		Message:    fmt.Sprintf("app %s has no earlier release with a different image", appName),
+	}
+}
+
+// criticalChecks returns the names of checks that are failing
+func criticalChecks(checks []MachineCheck) []string {
+	var failing []string
+	for _, check := range checks {
+		if check.Status == "critical" {
+			failing = append(failing, check.Name)
+		}
+	}
+	return failing
+}
@@ -0,0 +1,282 @@
+package fly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// machinePlatform is the platform Fly.io machines run images on
+const machinePlatform = "linux/amd64"
+
+// repositoryPattern matches the repository part of an image name
+var repositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:[._-]+[a-z0-9]+)*(?:/[a-z0-9]+(?:[._-]+[a-z0-9]+)*)*$`)
+
+// imageReference is an image reference split into its parts
+type imageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// reference returns what the registry looks the image up by
+func (n imageReference) reference() string {
+	if n.Digest != "" {
+		return n.Digest
+	}
+	return n.Tag
+}
+
+// parseImageReference splits an image reference such as
+// registry.fly.io/my-app:deployment-01H, ghcr.io/org/repo@sha256:… or nginx
+// as docker pull would
+func parseImageReference(image string) (imageReference, error) {
+	var n imageReference
+	rest := image
+	if before, digest, ok := strings.Cut(rest, "@"); ok {
+		rest, n.Digest = before, digest
+	}
+
+	if i := strings.Index(rest, "/"); i > 0 && (strings.ContainsAny(rest[:i], ".:") || rest[:i] == "localhost") {
+		n.Registry, rest = rest[:i], rest[i+1:]
+	} else {
+		n.Registry = "docker.io"
+	}
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		rest, n.Tag = rest[:i], rest[i+1:]
+	}
+	if n.Tag == "" && n.Digest == "" {
+		n.Tag = "latest"
+	}
+	if n.Registry == "docker.io" && !strings.Contains(rest, "/") {
+		rest = "library/" + rest
+	}
+	n.Repository = rest
+
+	if !repositoryPattern.MatchString(n.Repository) {
+		return n, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("invalid image reference %q", image),
+		}
+	}
+	return n, nil
+}
+
+// ImageInspection is what the registry says about an image
+type ImageInspection struct {
+	Image  string `json:"image"`
+	Exists bool   `json:"exists"`
+	// Digest is the digest the reference resolves to; for a multi-platform
+	// image, ManifestDigest is the digest of its linux/amd64 manifest
+	Digest         string    `json:"digest,omitempty"`
+	ManifestDigest string    `json:"manifestDigest,omitempty"`
+	Platforms      []string  `json:"platforms,omitempty"`
+	ExposedPorts   []string  `json:"exposedPorts,omitempty"`
+	Created        time.Time `json:"created,omitempty"`
+	// Size is the compressed size of the image's layers, in bytes
+	Size     int64    `json:"size,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Ports returns the TCP and UDP port numbers the image exposes
+func (i *ImageInspection) Ports() []int {
+	var ports []int
+	for _, exposed := range i.ExposedPorts {
+		number, _, _ := strings.Cut(exposed, "/")
+		if port, err := strconv.Atoi(number); err == nil && !slices.Contains(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// imageConfig is the part of an image config blob fly-mcp reads
+type imageConfig struct {
+	Created      time.Time `json:"created"`
+	OS           string    `json:"os"`
+	Architecture string    `json:"architecture"`
+	Variant      string    `json:"variant"`
+	Config       struct {
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	} `json:"config"`
+}
+
+// registryHostFor returns the host to pull an image from, or "" for
+// Fly.io's registry, which is reached through the configured registry URL
+func (c *Client) registryHostFor(n imageReference) string {
+	switch n.Registry {
+	case "registry.fly.io", registryHost(c.config.RegistryURL):
+		return ""
+	case "docker.io":
+		return "registry-1.docker.io"
+	}
+	return n.Registry
+}
+
+// InspectImage looks an image up in its registry: whether it exists, its
+// digest, the platforms it was built for, the ports it exposes and when it
+// was built. Images outside Fly.io's registry are pulled anonymously, so
+// private ones fail to inspect. A missing image is not an error; Exists is
+// false.
+func (c *Client) InspectImage(ctx context.Context, image string) (*ImageInspection, error) {
+	n, err := parseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+	host := c.registryHostFor(n)
+
+	inspection := &ImageInspection{Image: image}
+	m, digest, err := c.registryClient.getManifest(ctx, host, n.Repository, n.reference())
+	if err != nil {
+		if IsNotFound(err) {
+			return inspection, nil
+		}
+		return nil, fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	inspection.Exists = true
+	inspection.Digest = digest
+	if inspection.Digest == "" {
+		inspection.Digest = n.Digest
+	}
+
+	if len(m.Manifests) > 0 {
+		var chosen *descriptor
+		for i, d := range m.Manifests {
+			// Attestations are listed as unknown/unknown
+			if d.Platform == nil || d.Platform.OS == "unknown" {
+				continue
+			}
+			platform := d.Platform.OS + "/" + d.Platform.Architecture
+			if d.Platform.Variant != "" {
+				platform += "/" + d.Platform.Variant
+			}
+			inspection.Platforms = append(inspection.Platforms, platform)
+			if platform == machinePlatform && chosen == nil {
+				chosen = &m.Manifests[i]
+			}
+		}
+		if chosen == nil {
+			inspection.Warnings = append(inspection.Warnings, fmt.Sprintf("The image is built for %s only; Fly.io machines run %s images, so it will not start", strings.Join(inspection.Platforms, ", "), machinePlatform))
+			return inspection, nil
+		}
+
+		inspection.ManifestDigest = chosen.Digest
+		if m, _, err = c.registryClient.getManifest(ctx, host, n.Repository, chosen.Digest); err != nil {
+			return nil, fmt.Errorf("failed to inspect the %s manifest of image %s: %w", machinePlatform, image, err)
+		}
+	}
+	if m.Config == nil {
+		return inspection, nil
+	}
+
+	for _, layer := range m.Layers {
+		inspection.Size += layer.Size
+	}
+
+	blob, err := c.registryClient.getBlob(ctx, host, n.Repository, m.Config.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config of image %s: %w", image, err)
+	}
+	var config imageConfig
+	if err := json.Unmarshal(blob, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode the config of image %s: %w", image, err)
+	}
+
+	inspection.Created = config.Created
+	for port := range config.Config.ExposedPorts {
+		inspection.ExposedPorts = append(inspection.ExposedPorts, port)
+	}
+	slices.Sort(inspection.ExposedPorts)
+
+	if len(inspection.Platforms) == 0 && config.OS != "" {
+		platform := config.OS + "/" + config.Architecture
+		if config.Variant != "" {
+			platform += "/" + config.Variant
+		}
+		inspection.Platforms = []string{platform}
+		if platform != machinePlatform {
+			inspection.Warnings = append(inspection.Warnings, fmt.Sprintf("The image is built for %s; Fly.io machines run %s images, so it will not start", platform, machinePlatform))
+		}
+	}
+
+	return inspection, nil
+}
+
+// InspectDeployImage inspects the image a deploy would roll out to an app
+// and warns about signs that it is the wrong one: it does not expose the
+// ports the app's services send traffic to, it is what the machines already
+// run, it is in another app's repository or it was built before the image
+// the machines run
+func (c *Client) InspectDeployImage(ctx context.Context, appName, image string) (*ImageInspection, error) {
+	inspection, err := c.InspectImage(ctx, image)
+	if err != nil || !inspection.Exists {
+		return inspection, err
+	}
+
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	var servicePorts []int
+	var current []string
+	unchanged := 0
+	targets := 0
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" || machineSchedule(m) != "" {
+			continue
+		}
+		targets++
+		for _, service := range machineServices(m.Config) {
+			if port, ok := service["internal_port"].(float64); ok && !slices.Contains(servicePorts, int(port)) {
+				servicePorts = append(servicePorts, int(port))
+			}
+		}
+		if img := configImage(m); img != "" && !slices.Contains(current, img) {
+			current = append(current, img)
+		}
+		if d := m.ImageRef.Digest; d != "" && (d == inspection.Digest || d == inspection.ManifestDigest) {
+			unchanged++
+		}
+	}
+	slices.Sort(servicePorts)
+
+	if exposed := inspection.Ports(); len(exposed) > 0 {
+		var missing []string
+		for _, port := range servicePorts {
+			if !slices.Contains(exposed, port) {
+				missing = append(missing, strconv.Itoa(port))
+			}
+		}
+		if len(missing) > 0 {
+			inspection.Warnings = append(inspection.Warnings, fmt.Sprintf("The app's services send traffic to port %s, but the image only exposes %s; check the tag, or the service's internal_port", strings.Join(missing, ", "), strings.Join(inspection.ExposedPorts, ", ")))
+		}
+	}
+
+	if targets > 0 && unchanged == targets {
+		inspection.Warnings = append(inspection.Warnings, "Every machine already runs this image; the deploy only restarts them")
+	}
+
+	if n, _ := parseImageReference(image); c.registryHostFor(n) == "" && n.Repository != appName {
+		inspection.Warnings = append(inspection.Warnings, fmt.Sprintf("The image was pushed for app '%s', not '%s'", n.Repository, appName))
+	}
+
+	// A tag built before the running image is usually an old one
+	if len(current) == 1 && current[0] != image && !inspection.Created.IsZero() {
+		running, err := c.InspectImage(ctx, current[0])
+		if err == nil && running.Exists && running.Created.After(inspection.Created) {
+			inspection.Warnings = append(inspection.Warnings, fmt.Sprintf("The image was built %s, before the image the machines run (%s, built %s); check that this is not an old tag",
+				inspection.Created.UTC().Format(time.RFC3339), current[0], running.Created.UTC().Format(time.RFC3339)))
+		}
+	}
+
+	return inspection, nil
+}
@@ -0,0 +1,203 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// inventoryConcurrency is how many apps are inventoried at once
+const inventoryConcurrency = 8
+
+// Inventory is a snapshot of an organization's infrastructure
+type Inventory struct {
+	Organization string          `json:"organization,omitempty"`
+	GeneratedAt  time.Time       `json:"generatedAt"`
+	Totals       InventoryTotals `json:"totals"`
+	Apps         []InventoryApp  `json:"apps"`
+	Warnings     []string        `json:"warnings,omitempty"`
+}
+
+// InventoryTotals counts the resources in an inventory
+type InventoryTotals struct {
+	Apps         int `json:"apps"`
+	Machines     int `json:"machines"`
+	Volumes      int `json:"volumes"`
+	VolumeGB     int `json:"volumeGb"`
+	IPAddresses  int `json:"ipAddresses"`
+	Certificates int `json:"certificates"`
+}
+
+// InventoryApp is an application and the resources it holds
+type InventoryApp struct {
+	Name         string                 `json:"name"`
+	Status       string                 `json:"status"`
+	Deployed     bool                   `json:"deployed"`
+	Hostname     string                 `json:"hostname"`
+	Machines     []InventoryMachine     `json:"machines"`
+	Volumes      []MachineVolume        `json:"volumes"`
+	IPAddresses  []IPAddress            `json:"ipAddresses"`
+	Certificates []InventoryCertificate `json:"certificates"`
+}
+
+// InventoryMachine is a machine in an inventory
+type InventoryMachine struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	Region    string    `json:"region"`
+	CPUKind   string    `json:"cpuKind"`
+	CPUs      int       `json:"cpus"`
+	MemoryMB  int       `json:"memoryMb"`
+	Image     string    `json:"image"`
+	PrivateIP string    `json:"privateIp"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// InventoryCertificate is a certificate an app holds for a custom domain
+type InventoryCertificate struct {
+	Hostname     string `json:"hostname"`
+	ClientStatus string `json:"clientStatus"`
+	IsApex       bool   `json:"isApex"`
+}
+
+// GetInventory collects the apps of the configured organization with their
+// machines, volumes, IP addresses and certificates. include decides which
+// apps are visible to the caller; resources that cannot be listed are
+// reported as warnings and left out. Destroyed machines are left out too.
+func (c *Client) GetInventory(ctx context.Context, include func(appName string) bool) (*Inventory, error) {
+	apps, err := c.GetApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := &Inventory{
+		Organization: c.config.Organization,
+		GeneratedAt:  time.Now().UTC(),
+		Apps:         []InventoryApp{},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, inventoryConcurrency)
+	for _, app := range apps {
+		if include != nil && !include(app.Name) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			entry, warnings := c.inventoryApp(ctx, app)
+
+			mu.Lock()
+			defer mu.Unlock()
+			inventory.Apps = append(inventory.Apps, entry)
+			inventory.Warnings = append(inventory.Warnings, warnings...)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(inventory.Apps, func(i, j int) bool {
+		return inventory.Apps[i].Name < inventory.Apps[j].Name
+	})
+	sort.Strings(inventory.Warnings)
+	for _, app := range inventory.Apps {
+		inventory.Totals.Apps++
+		inventory.Totals.Machines += len(app.Machines)
+		inventory.Totals.Volumes += len(app.Volumes)
+		for _, v := range app.Volumes {
+			inventory.Totals.VolumeGB += v.SizeGB
+		}
+		inventory.Totals.IPAddresses += len(app.IPAddresses)
+		inventory.Totals.Certificates += len(app.Certificates)
+	}
+
+	c.logger.Debug().
+		Int("apps", inventory.Totals.Apps).
+		Int("machines", inventory.Totals.Machines).
+		Msg("Collected organization inventory")
+
+	return inventory, nil
+}
+
+// inventoryApp collects one app's resources
+func (c *Client) inventoryApp(ctx context.Context, app App) (InventoryApp, []string) {
+	entry := InventoryApp{
+		Name:         app.Name,
+		Status:       app.Status,
+		Deployed:     app.Deployed,
+		Hostname:     app.Hostname,
+		Machines:     []InventoryMachine{},
+		Volumes:      []MachineVolume{},
+		IPAddresses:  []IPAddress{},
+		Certificates: []InventoryCertificate{},
+	}
+	var warnings []string
+
+	machines, err := c.machinesClient.ListMachines(ctx, app.Name)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s: machines unavailable", app.Name))
+	}
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" {
+			continue
+		}
+		guest := m.Guest()
+		entry.Machines = append(entry.Machines, InventoryMachine{
+			ID:        m.ID,
+			Name:      m.Name,
+			State:     m.State,
+			Region:    m.Region,
+			CPUKind:   guest.CPUKind,
+			CPUs:      guest.CPUs,
+			MemoryMB:  guest.MemoryMB,
+			Image:     imageName(m.ImageRef),
+			PrivateIP: m.PrivateIP,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+
+	volumes, err := c.machinesClient.ListVolumes(ctx, app.Name)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s: volumes unavailable", app.Name))
+	}
+	for _, v := range volumes {
+		if v.State == "destroyed" || v.State == "pending_destroy" {
+			continue
+		}
+		entry.Volumes = append(entry.Volumes, v)
+	}
+
+	// Addresses and certificates come from one GraphQL request
+	var network struct {
+		SharedIPAddress string `json:"sharedIpAddress"`
+		IPAddresses     struct {
+			Nodes []IPAddress `json:"nodes"`
+		} `json:"ipAddresses"`
+		Certificates struct {
+			Nodes []InventoryCertificate `json:"nodes"`
+		} `json:"certificates"`
+	}
+	batch := c.newGraphQLBatch()
+	batch.Add("app", `app(name: $appName) { sharedIpAddress ipAddresses { nodes { address type region } } certificates { nodes { hostname clientStatus isApex } } }`,
+		map[string]batchVar{"appName": {Type: "String!", Value: app.Name}}, &network)
+	err = batch.Run(ctx)
+	if err == nil {
+		err = batch.Err("app")
+	}
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s: IP addresses and certificates unavailable", app.Name))
+	}
+	if network.SharedIPAddress != "" {
+		entry.IPAddresses = append(entry.IPAddresses, IPAddress{Address: network.SharedIPAddress, Type: IPSharedV4})
+	}
+	entry.IPAddresses = append(entry.IPAddresses, network.IPAddresses.Nodes...)
+	entry.Certificates = append(entry.Certificates, network.Certificates.Nodes...)
+
+	return entry, warnings
+}
@@ -7,29 +7,29 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"time"
 
 	"github.com/brannn/fly-mcp/internal/logger"
 	"github.com/brannn/fly-mcp/pkg/config"
 )
 
+// leaseNonceHeader carries the nonce of a machine lease
+const leaseNonceHeader = "fly-machine-lease-nonce"
+
 // MachinesClient handles direct HTTP calls to the Fly.io Machines API
 type MachinesClient struct {
 	httpClient *http.Client
 	baseURL    string
-	apiToken   string
 	logger     *logger.Logger
 }
 
 // NewMachinesClient creates a new Machines API client
 func NewMachinesClient(cfg *config.FlyConfig, log *logger.Logger) *MachinesClient {
 	return &MachinesClient{
-		httpClient: &http.Client{
-			Timeout: time.Duration(cfg.Timeout) * time.Second,
-		},
-		baseURL:  "https://api.machines.dev",
-		apiToken: cfg.APIToken,
-		logger:   log,
+		httpClient: newAPIHTTPClient("machines", cfg, tokenAuth, log),
+		baseURL:    cfg.BaseURL,
+		logger:     log,
 	}
 }
 
@@ -46,6 +46,7 @@ type Machine struct {
 	CreatedAt  time.Time              `json:"created_at"`
 	UpdatedAt  time.Time              `json:"updated_at"`
 	Events     []MachineEvent         `json:"events"`
+	Checks     []MachineCheck         `json:"checks,omitempty"`
 }
 
 // ImageRef represents a container image reference
@@ -59,16 +60,32 @@ type ImageRef struct {
 
 // MachineEvent represents a machine event
 type MachineEvent struct {
-	Type      string `json:"type"`
-	Status    string `json:"status"`
-	Source    string `json:"source"`
-	Timestamp int64  `json:"timestamp"`
+	ID        string               `json:"id,omitempty"`
+	Type      string               `json:"type"`
+	Status    string               `json:"status"`
+	Source    string               `json:"source"`
+	Timestamp int64                `json:"timestamp"`
+	Request   *MachineEventRequest `json:"request,omitempty"`
+}
+
+// MachineEventRequest holds the details attached to some machine events
+type MachineEventRequest struct {
+	ExitEvent *MachineExitEvent `json:"exit_event,omitempty"`
+}
+
+// MachineExitEvent describes how a machine's main process exited
+type MachineExitEvent struct {
+	ExitCode      int    `json:"exit_code"`
+	Signal        int    `json:"signal,omitempty"`
+	OOMKilled     bool   `json:"oom_killed"`
+	RequestedStop bool   `json:"requested_stop"`
+	Restarting    bool   `json:"restarting"`
+	GuestExitCode int    `json:"guest_exit_code,omitempty"`
+	ExitedAt      string `json:"exited_at,omitempty"`
 }
 
 // ListMachines retrieves all machines for an app
 func (c *MachinesClient) ListMachines(ctx context.Context, appName string) ([]Machine, error) {
-	start := time.Now()
-	
 	url := fmt.Sprintf("%s/v1/apps/%s/machines", c.baseURL, appName)
 	
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -76,22 +93,17 @@ func (c *MachinesClient) ListMachines(ctx context.Context, appName string) ([]Ma
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	
-	req.Header.Set("Authorization", "Bearer "+c.apiToken)
 	req.Header.Set("Content-Type", "application/json")
 	
 	resp, err := c.httpClient.Do(req)
-	duration := time.Since(start)
-	
-	c.logger.LogFlyAPICall(fmt.Sprintf("/v1/apps/%s/machines", appName), "GET", getStatusCodeFromResp(resp, err), duration)
-	
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, newNetworkError(err)
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newHTTPError(resp, body)
 	}
 	
 	var machines []Machine
@@ -109,8 +121,6 @@ func (c *MachinesClient) ListMachines(ctx context.Context, appName string) ([]Ma
 
 // GetMachine retrieves a specific machine
 func (c *MachinesClient) GetMachine(ctx context.Context, appName, machineID string) (*Machine, error) {
-	start := time.Now()
-	
 	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s", c.baseURL, appName, machineID)
 	
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -118,22 +128,17 @@ func (c *MachinesClient) GetMachine(ctx context.Context, appName, machineID stri
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	
-	req.Header.Set("Authorization", "Bearer "+c.apiToken)
 	req.Header.Set("Content-Type", "application/json")
 	
 	resp, err := c.httpClient.Do(req)
-	duration := time.Since(start)
-	
-	c.logger.LogFlyAPICall(fmt.Sprintf("/v1/apps/%s/machines/%s", appName, machineID), "GET", getStatusCodeFromResp(resp, err), duration)
-	
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, newNetworkError(err)
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newHTTPError(resp, body)
 	}
 	
 	var machine Machine
@@ -150,10 +155,45 @@ func (c *MachinesClient) GetMachine(ctx context.Context, appName, machineID stri
 	return &machine, nil
 }
 
+// ListMachineEvents retrieves up to limit of a machine's most recent events.
+// This returns more history than the events embedded in ListMachines.
+func (c *MachinesClient) ListMachineEvents(ctx context.Context, appName, machineID string, limit int) ([]MachineEvent, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/events?limit=%d", c.baseURL, appName, machineID, limit)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newHTTPError(resp, body)
+	}
+
+	var events []MachineEvent
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Debug().
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Int("event_count", len(events)).
+		Msg("Retrieved machine events from Fly.io Machines API")
+
+	return events, nil
+}
+
 // StartMachine starts a machine
 func (c *MachinesClient) StartMachine(ctx context.Context, appName, machineID string) error {
-	start := time.Now()
-	
 	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/start", c.baseURL, appName, machineID)
 	
 	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
@@ -161,22 +201,17 @@ func (c *MachinesClient) StartMachine(ctx context.Context, appName, machineID st
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	
-	req.Header.Set("Authorization", "Bearer "+c.apiToken)
 	req.Header.Set("Content-Type", "application/json")
 	
 	resp, err := c.httpClient.Do(req)
-	duration := time.Since(start)
-	
-	c.logger.LogFlyAPICall(fmt.Sprintf("/v1/apps/%s/machines/%s/start", appName, machineID), "POST", getStatusCodeFromResp(resp, err), duration)
-	
 	if err != nil {
-		return fmt.Errorf("failed to make request: %w", err)
+		return newNetworkError(err)
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to start machine: status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("failed to start machine: %w", newHTTPError(resp, body))
 	}
 	
 	c.logger.Info().
@@ -189,8 +224,6 @@ func (c *MachinesClient) StartMachine(ctx context.Context, appName, machineID st
 
 // StopMachine stops a machine
 func (c *MachinesClient) StopMachine(ctx context.Context, appName, machineID string) error {
-	start := time.Now()
-	
 	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/stop", c.baseURL, appName, machineID)
 	
 	// Create request body with default stop configuration
@@ -208,22 +241,17 @@ func (c *MachinesClient) StopMachine(ctx context.Context, appName, machineID str
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	
-	req.Header.Set("Authorization", "Bearer "+c.apiToken)
 	req.Header.Set("Content-Type", "application/json")
 	
 	resp, err := c.httpClient.Do(req)
-	duration := time.Since(start)
-	
-	c.logger.LogFlyAPICall(fmt.Sprintf("/v1/apps/%s/machines/%s/stop", appName, machineID), "POST", getStatusCodeFromResp(resp, err), duration)
-	
 	if err != nil {
-		return fmt.Errorf("failed to make request: %w", err)
+		return newNetworkError(err)
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to stop machine: status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("failed to stop machine: %w", newHTTPError(resp, body))
 	}
 	
 	c.logger.Info().
@@ -246,11 +274,15 @@ func (c *MachinesClient) RestartMachine(ctx context.Context, appName, machineID
 		return fmt.Errorf("failed to stop machine during restart: %w", err)
 	}
 	
+	// Once stopped the machine is always started again, even if the
+	// request is cancelled, so a cancelled restart never leaves it down
+	startCtx := context.WithoutCancel(ctx)
+	
 	// Wait a moment for the machine to fully stop
 	time.Sleep(2 * time.Second)
 	
 	// Start the machine
-	if err := c.StartMachine(ctx, appName, machineID); err != nil {
+	if err := c.StartMachine(startCtx, appName, machineID); err != nil {
 		return fmt.Errorf("failed to start machine during restart: %w", err)
 	}
 	
@@ -262,6 +294,606 @@ func (c *MachinesClient) RestartMachine(ctx context.Context, appName, machineID
 	return nil
 }
 
+// MachineVolume represents a Fly.io volume from the Machines API
+type MachineVolume struct {
+	ID                string    `json:"id"`
+	Name              string    `json:"name"`
+	State             string    `json:"state"`
+	SizeGB            int       `json:"size_gb"`
+	Region            string    `json:"region"`
+	Encrypted         bool      `json:"encrypted"`
+	AttachedMachineID string    `json:"attached_machine_id,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ListVolumes retrieves all volumes for an app
+func (c *MachinesClient) ListVolumes(ctx context.Context, appName string) ([]MachineVolume, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/volumes", c.baseURL, appName)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newHTTPError(resp, body)
+	}
+
+	var volumes []MachineVolume
+	if err := json.NewDecoder(resp.Body).Decode(&volumes); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Debug().
+		Str("app_name", appName).
+		Int("volume_count", len(volumes)).
+		Msg("Retrieved volumes from Fly.io Machines API")
+
+	return volumes, nil
+}
+
+// UpdateMachineConfig replaces a machine's config. Fly.io restarts started
+// machines with the new config; when skipLaunch is set a stopped machine
+// stays stopped.
+func (c *MachinesClient) UpdateMachineConfig(ctx context.Context, appName, machineID string, machineConfig map[string]interface{}, skipLaunch bool) (*Machine, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s", c.baseURL, appName, machineID)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"config":      machineConfig,
+		"skip_launch": skipLaunch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal machine config: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to update machine: %w", newHTTPError(resp, body))
+	}
+
+	var machine Machine
+	if err := json.NewDecoder(resp.Body).Decode(&machine); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Msg("Successfully updated machine config")
+
+	return &machine, nil
+}
+
+// VolumeSnapshot is a point-in-time snapshot of a volume
+type VolumeSnapshot struct {
+	ID            string    `json:"id"`
+	Size          int64     `json:"size"`
+	Digest        string    `json:"digest"`
+	Status        string    `json:"status"`
+	RetentionDays int       `json:"retention_days,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ListVolumeSnapshots retrieves the snapshots of a volume
+func (c *MachinesClient) ListVolumeSnapshots(ctx context.Context, appName, volumeID string) ([]VolumeSnapshot, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/volumes/%s/snapshots", c.baseURL, appName, volumeID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newHTTPError(resp, body)
+	}
+
+	var snapshots []VolumeSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshots); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Debug().
+		Str("app_name", appName).
+		Str("volume_id", volumeID).
+		Int("snapshot_count", len(snapshots)).
+		Msg("Retrieved volume snapshots from Fly.io Machines API")
+
+	return snapshots, nil
+}
+
+// CreateVolumeRequest describes a new volume. When SnapshotID is set the
+// volume is restored from that snapshot.
+type CreateVolumeRequest struct {
+	Name       string `json:"name"`
+	Region     string `json:"region"`
+	SizeGB     int    `json:"size_gb,omitempty"`
+	Encrypted  bool   `json:"encrypted"`
+	SnapshotID string `json:"snapshot_id,omitempty"`
+}
+
+// CreateVolume creates a volume for an app
+func (c *MachinesClient) CreateVolume(ctx context.Context, appName string, input CreateVolumeRequest) (*MachineVolume, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/volumes", c.baseURL, appName)
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal volume request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create volume: %w", newHTTPError(resp, body))
+	}
+
+	var volume MachineVolume
+	if err := json.NewDecoder(resp.Body).Decode(&volume); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("volume_id", volume.ID).
+		Str("snapshot_id", input.SnapshotID).
+		Msg("Successfully created volume")
+
+	return &volume, nil
+}
+
+// CreateMachineRequest describes a new machine
+type CreateMachineRequest struct {
+	Name   string                 `json:"name,omitempty"`
+	Region string                 `json:"region,omitempty"`
+	Config map[string]interface{} `json:"config"`
+}
+
+// CreateMachine creates and launches a machine for an app
+func (c *MachinesClient) CreateMachine(ctx context.Context, appName string, input CreateMachineRequest) (*Machine, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines", c.baseURL, appName)
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal machine request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create machine: %w", newHTTPError(resp, body))
+	}
+
+	var machine Machine
+	if err := json.NewDecoder(resp.Body).Decode(&machine); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("machine_id", machine.ID).
+		Str("region", machine.Region).
+		Msg("Successfully created machine")
+
+	return &machine, nil
+}
+
+// DestroyMachine destroys a machine. With force, a running machine is
+// stopped first instead of the request failing.
+func (c *MachinesClient) DestroyMachine(ctx context.Context, appName, machineID string, force bool) error {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s", c.baseURL, appName, machineID)
+	if force {
+		url += "?force=true"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to destroy machine: %w", newHTTPError(resp, body))
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Msg("Successfully destroyed machine")
+
+	return nil
+}
+
+// GetMachineMetadata retrieves a machine's metadata
+func (c *MachinesClient) GetMachineMetadata(ctx context.Context, appName, machineID string) (map[string]string, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/metadata", c.baseURL, appName, machineID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newHTTPError(resp, body)
+	}
+
+	metadata := map[string]string{}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return metadata, nil
+}
+
+// SetMachineMetadata sets one metadata key of a machine
+func (c *MachinesClient) SetMachineMetadata(ctx context.Context, appName, machineID, key, value string) error {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/metadata/%s", c.baseURL, appName, machineID, neturl.PathEscape(key))
+
+	body, err := json.Marshal(map[string]string{"value": value})
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to set metadata: %w", newHTTPError(resp, body))
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Str("key", key).
+		Msg("Successfully set machine metadata")
+
+	return nil
+}
+
+// DeleteMachineMetadata removes one metadata key from a machine
+func (c *MachinesClient) DeleteMachineMetadata(ctx context.Context, appName, machineID, key string) error {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/metadata/%s", c.baseURL, appName, machineID, neturl.PathEscape(key))
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete metadata: %w", newHTTPError(resp, body))
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Str("key", key).
+		Msg("Successfully deleted machine metadata")
+
+	return nil
+}
+
+// MachineLease is an exclusive lease on a machine. While it is held, changes
+// to the machine must present its nonce.
+type MachineLease struct {
+	Nonce       string `json:"nonce"`
+	ExpiresAt   int64  `json:"expires_at"`
+	Owner       string `json:"owner"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+// Expires returns when the lease expires
+func (l *MachineLease) Expires() time.Time {
+	return time.Unix(l.ExpiresAt, 0)
+}
+
+// leaseResponse is the envelope of the lease endpoints
+type leaseResponse struct {
+	Status string        `json:"status"`
+	Data   *MachineLease `json:"data"`
+}
+
+// GetMachineLease retrieves the lease held on a machine, or nil when there
+// is none
+func (c *MachinesClient) GetMachineLease(ctx context.Context, appName, machineID string) (*MachineLease, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/lease", c.baseURL, appName, machineID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newHTTPError(resp, body)
+	}
+
+	var lease leaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&lease); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return lease.Data, nil
+}
+
+// AcquireMachineLease acquires a lease on a machine for ttl. It fails with a
+// conflict when someone else holds one.
+func (c *MachinesClient) AcquireMachineLease(ctx context.Context, appName, machineID string, ttl time.Duration, description string) (*MachineLease, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/lease", c.baseURL, appName, machineID)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"ttl":         int(ttl.Seconds()),
+		"description": description,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lease request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to acquire lease: %w", newHTTPError(resp, body))
+	}
+
+	var lease leaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&lease); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if lease.Data == nil {
+		return nil, fmt.Errorf("failed to acquire lease: empty response")
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Dur("ttl", ttl).
+		Msg("Successfully acquired machine lease")
+
+	return lease.Data, nil
+}
+
+// ReleaseMachineLease releases the lease with nonce held on a machine
+func (c *MachinesClient) ReleaseMachineLease(ctx context.Context, appName, machineID, nonce string) error {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/lease", c.baseURL, appName, machineID)
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set(leaseNonceHeader, nonce)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to release lease: %w", newHTTPError(resp, body))
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Msg("Successfully released machine lease")
+
+	return nil
+}
+
+// OrgApp is an application as listed by the Machines API
+type OrgApp struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	MachineCount int    `json:"machine_count"`
+	Network      string `json:"network"`
+}
+
+// ListApps retrieves all applications in an organization, including the
+// private network each one is attached to
+func (c *MachinesClient) ListApps(ctx context.Context, orgSlug string) ([]OrgApp, error) {
+	query := neturl.Values{}
+	query.Set("org_slug", orgSlug)
+	url := fmt.Sprintf("%s/v1/apps?%s", c.baseURL, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newHTTPError(resp, body)
+	}
+
+	var result struct {
+		TotalApps int      `json:"total_apps"`
+		Apps      []OrgApp `json:"apps"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Debug().
+		Str("organization", orgSlug).
+		Int("app_count", len(result.Apps)).
+		Msg("Retrieved apps from Fly.io Machines API")
+
+	return result.Apps, nil
+}
+
+// ExecResult is the outcome of a command run inside a machine
+type ExecResult struct {
+	ExitCode   int    `json:"exit_code"`
+	ExitSignal int    `json:"exit_signal,omitempty"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+}
+
+// ExecMachine runs a command inside a machine and waits up to timeout for it
+// to finish. The command is executed directly, not through a shell.
+func (c *MachinesClient) ExecMachine(ctx context.Context, appName, machineID string, command []string, timeout time.Duration) (*ExecResult, error) {
+	url := fmt.Sprintf("%s/v1/apps/%s/machines/%s/exec", c.baseURL, appName, machineID)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"command": command,
+		"timeout": int(timeout.Seconds()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal exec request: %w", err)
+	}
+
+	// The command may legitimately outlive the default API timeouts
+	ctx = withRequestTimeout(ctx, timeout+10*time.Second)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := *c.httpClient
+	if httpClient.Timeout < timeout+10*time.Second {
+		httpClient.Timeout = timeout + 10*time.Second
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to exec command: %w", newHTTPError(resp, body))
+	}
+
+	var result ExecResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Debug().
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Int("exit_code", result.ExitCode).
+		Msg("Executed command on machine")
+
+	return &result, nil
+}
+
 // getStatusCodeFromResp extracts status code from HTTP response or returns 500 for errors
 func getStatusCodeFromResp(resp *http.Response, err error) int {
 	if err != nil {
@@ -0,0 +1,128 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// MaxLeaseTTL is the longest lease fly-mcp acquires
+const MaxLeaseTTL = time.Hour
+
+// MetadataKeyPattern matches the metadata keys the Machines API accepts
+var MetadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// validateMetadataKey rejects keys the Machines API would not accept
+func validateMetadataKey(key string) error {
+	if !MetadataKeyPattern.MatchString(key) {
+		return &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("invalid metadata key %q: use up to 64 letters, digits, '_', '.' or '-'", key),
+		}
+	}
+	return nil
+}
+
+// MachineCoordination is what other tools may have left on a machine: its
+// metadata and the lease held on it, if any
+type MachineCoordination struct {
+	AppName   string            `json:"appName"`
+	MachineID string            `json:"machineId"`
+	Metadata  map[string]string `json:"metadata"`
+	Lease     *MachineLease     `json:"lease,omitempty"`
+}
+
+// GetMachineCoordination retrieves a machine's metadata and lease
+func (c *Client) GetMachineCoordination(ctx context.Context, appName, machineID string) (*MachineCoordination, error) {
+	metadata, err := c.machinesClient.GetMachineMetadata(ctx, appName, machineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata of machine %s: %w", machineID, err)
+	}
+	lease, err := c.machinesClient.GetMachineLease(ctx, appName, machineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease of machine %s: %w", machineID, err)
+	}
+	return &MachineCoordination{
+		AppName:   appName,
+		MachineID: machineID,
+		Metadata:  metadata,
+		Lease:     lease,
+	}, nil
+}
+
+// SetMachineMetadata sets a metadata key of a machine. The machine is not
+// restarted.
+func (c *Client) SetMachineMetadata(ctx context.Context, appName, machineID, key, value string) error {
+	if err := validateMetadataKey(key); err != nil {
+		return err
+	}
+	if err := c.machinesClient.SetMachineMetadata(ctx, appName, machineID, key, value); err != nil {
+		return fmt.Errorf("failed to set metadata of machine %s: %w", machineID, err)
+	}
+	return nil
+}
+
+// DeleteMachineMetadata removes a metadata key from a machine
+func (c *Client) DeleteMachineMetadata(ctx context.Context, appName, machineID, key string) error {
+	if err := validateMetadataKey(key); err != nil {
+		return err
+	}
+	if err := c.machinesClient.DeleteMachineMetadata(ctx, appName, machineID, key); err != nil {
+		return fmt.Errorf("failed to delete metadata of machine %s: %w", machineID, err)
+	}
+	return nil
+}
+
+// AcquireMachineLease acquires a lease on a machine for ttl, so that
+// flyctl, CI deploys and other fly-mcp users cannot change it until the
+// lease is released or expires
+func (c *Client) AcquireMachineLease(ctx context.Context, appName, machineID string, ttl time.Duration, description string) (*MachineLease, error) {
+	if ttl <= 0 || ttl > MaxLeaseTTL {
+		return nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("lease ttl must be between 1s and %s", MaxLeaseTTL),
+		}
+	}
+	lease, err := c.machinesClient.AcquireMachineLease(ctx, appName, machineID, ttl, description)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lease on machine %s: %w", machineID, err)
+	}
+	return lease, nil
+}
+
+// ReleaseMachineLease releases the lease with nonce held on a machine
+func (c *Client) ReleaseMachineLease(ctx context.Context, appName, machineID, nonce string) error {
+	if err := c.machinesClient.ReleaseMachineLease(ctx, appName, machineID, nonce); err != nil {
+		return fmt.Errorf("failed to release lease on machine %s: %w", machineID, err)
+	}
+	return nil
+}
+
+// MachineLeases returns the unexpired leases held on the given machines,
+// keyed by machine ID. Machines whose lease cannot be read are left out, so
+// this is only suitable for warnings.
+func (c *Client) MachineLeases(ctx context.Context, appName string, machineIDs []string) map[string]*MachineLease {
+	leases := make(map[string]*MachineLease)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, machineID := range machineIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lease, err := c.machinesClient.GetMachineLease(ctx, appName, machineID)
+			if err != nil || lease == nil || lease.Expires().Before(time.Now()) {
+				return
+			}
+			mu.Lock()
+			leases[machineID] = lease
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return leases
+}
@@ -0,0 +1,186 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// defaultNetwork is the private network apps join unless created with a
+// custom one
+const defaultNetwork = "default"
+
+// MachineAddress is a machine's address on the private network
+type MachineAddress struct {
+	MachineID string `json:"machineId"`
+	Name      string `json:"name"`
+	Region    string `json:"region"`
+	State     string `json:"state"`
+	PrivateIP string `json:"privateIp"`
+	DNSName   string `json:"dnsName"`
+}
+
+// InternalDNSName is a .internal name that resolves on the private network
+type InternalDNSName struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// NetworkPeer is another application in the same organization
+type NetworkPeer struct {
+	Name         string `json:"name"`
+	Network      string `json:"network"`
+	MachineCount int    `json:"machineCount"`
+}
+
+// AppNetwork describes an application's private (6PN) networking
+type AppNetwork struct {
+	AppName      string            `json:"appName"`
+	Organization string            `json:"organization"`
+	Network      string            `json:"network"`
+	Machines     []MachineAddress  `json:"machines"`
+	FlycastIPs   []string          `json:"flycastIps,omitempty"`
+	DNSNames     []InternalDNSName `json:"dnsNames"`
+	// Reachable apps share the network and can connect to this app
+	Reachable []NetworkPeer `json:"reachable"`
+	// Isolated apps are in the organization but on a different network
+	Isolated []NetworkPeer `json:"isolated,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+// GetAppNetwork collects an application's private addresses, .internal DNS
+// names and the organization apps that can reach it
+func (c *Client) GetAppNetwork(ctx context.Context, appName string) (*AppNetwork, error) {
+	// The app and its IP addresses are independent lookups, so fetch them
+	// in one GraphQL round trip
+	var app struct {
+		Network      string `json:"network"`
+		Organization *struct {
+			Slug string `json:"slug"`
+		} `json:"organization"`
+	}
+	var ips struct {
+		IPAddresses struct {
+			Nodes []struct {
+				Address string `json:"address"`
+				Type    string `json:"type"`
+			} `json:"nodes"`
+		} `json:"ipAddresses"`
+	}
+
+	appVars := map[string]batchVar{"appName": {Type: "String!", Value: appName}}
+	batch := c.newGraphQLBatch()
+	batch.Add("app", `app(name: $appName) { network organization { slug } }`, appVars, &app)
+	batch.Add("ips", `app(name: $appName) { ipAddresses { nodes { address type } } }`, appVars, &ips)
+	if err := batch.Run(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get app %s: %w", appName, err)
+	}
+	if err := batch.Err("app"); err != nil {
+		return nil, fmt.Errorf("failed to get app %s: %w", appName, err)
+	}
+
+	network := &AppNetwork{
+		AppName:   appName,
+		Network:   app.Network,
+		Machines:  []MachineAddress{},
+		Reachable: []NetworkPeer{},
+	}
+	if network.Network == "" {
+		network.Network = defaultNetwork
+	}
+	if app.Organization != nil {
+		network.Organization = app.Organization.Slug
+	}
+
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	regions := make(map[string]bool)
+	for _, m := range machines {
+		network.Machines = append(network.Machines, MachineAddress{
+			MachineID: m.ID,
+			Name:      m.Name,
+			Region:    m.Region,
+			State:     m.State,
+			PrivateIP: m.PrivateIP,
+			DNSName:   fmt.Sprintf("%s.vm.%s.internal", m.ID, appName),
+		})
+		regions[m.Region] = true
+	}
+
+	network.DNSNames = internalDNSNames(appName, regions)
+
+	if batch.Err("ips") != nil {
+		network.Warnings = append(network.Warnings, "Flycast addresses could not be listed")
+	}
+	for _, ip := range ips.IPAddresses.Nodes {
+		if ip.Type == "private_v6" {
+			network.FlycastIPs = append(network.FlycastIPs, ip.Address)
+		}
+	}
+
+	if network.Organization == "" {
+		network.Warnings = append(network.Warnings, "The app's organization is unknown, so reachable apps could not be listed")
+		return network, nil
+	}
+
+	apps, err := c.machinesClient.ListApps(ctx, network.Organization)
+	if err != nil {
+		c.logger.Warn().
+			Str("app_name", appName).
+			Err(err).
+			Msg("Failed to list organization apps for network peers")
+		network.Warnings = append(network.Warnings, "Organization apps could not be listed, so reachable apps are unknown")
+		return network, nil
+	}
+
+	for _, a := range apps {
+		if a.Name == appName {
+			continue
+		}
+		peer := NetworkPeer{Name: a.Name, Network: a.Network, MachineCount: a.MachineCount}
+		if peer.Network == "" {
+			peer.Network = defaultNetwork
+		}
+		if peer.Network == network.Network {
+			network.Reachable = append(network.Reachable, peer)
+		} else {
+			network.Isolated = append(network.Isolated, peer)
+		}
+	}
+
+	c.logger.Debug().
+		Str("app_name", appName).
+		Str("network", network.Network).
+		Int("reachable", len(network.Reachable)).
+		Msg("Retrieved app network")
+
+	return network, nil
+}
+
+// internalDNSNames lists the .internal names Fly.io serves for an app
+func internalDNSNames(appName string, regions map[string]bool) []InternalDNSName {
+	names := []InternalDNSName{
+		{Name: appName + ".internal", Description: "AAAA records for all started machines"},
+		{Name: "top1.nearest.of." + appName + ".internal", Description: "The closest started machine"},
+		{Name: "_apps.internal", Description: "TXT record listing the apps in the organization"},
+		{Name: "regions." + appName + ".internal", Description: "TXT record listing the app's regions"},
+	}
+
+	sorted := make([]string, 0, len(regions))
+	for region := range regions {
+		sorted = append(sorted, region)
+	}
+	sort.Strings(sorted)
+
+	for _, region := range sorted {
+		names = append(names, InternalDNSName{
+			Name:        fmt.Sprintf("%s.%s.internal", region, appName),
+			Description: fmt.Sprintf("Started machines in %s", region),
+		})
+	}
+
+	return names
+}
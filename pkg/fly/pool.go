@@ -0,0 +1,255 @@
+package fly
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// sharedTransports holds one pooled transport per set of connection
+// settings, so that the GraphQL, Machines, metrics and registry clients of
+// every Client reuse each other's connections
+var (
+	sharedTransportsMu sync.Mutex
+	sharedTransports   = make(map[config.HTTPConfig]*http.Transport)
+	sharedDNSCaches    []*dnsCache
+)
+
+// poolConns counts the connections the shared transports open
+var poolConns = &connCounter{open: make(map[string]int)}
+
+// PoolStats describes the connections of the shared Fly.io API pools
+type PoolStats struct {
+	// Open counts the open connections by API host, idle ones included
+	Open map[string]int `json:"open"`
+	// Dialed counts the connections opened since the process started
+	Dialed int64 `json:"dialed"`
+	// DNSCached counts the hosts whose addresses are cached
+	DNSCached int `json:"dns_cached"`
+	// Transports counts the pools, one per set of connection settings
+	Transports int `json:"transports"`
+}
+
+// ConnectionStats returns the state of the shared connection pools
+func ConnectionStats() PoolStats {
+	sharedTransportsMu.Lock()
+	stats := PoolStats{Transports: len(sharedTransports)}
+	caches := slices.Clone(sharedDNSCaches)
+	sharedTransportsMu.Unlock()
+
+	for _, cache := range caches {
+		cache.mu.Lock()
+		stats.DNSCached += len(cache.entries)
+		cache.mu.Unlock()
+	}
+
+	poolConns.mu.Lock()
+	stats.Open = maps.Clone(poolConns.open)
+	stats.Dialed = poolConns.dialed
+	poolConns.mu.Unlock()
+	return stats
+}
+
+// sharedTransport returns the pooled transport for the connection settings,
+// creating it on first use
+func sharedTransport(cfg config.HTTPConfig) *http.Transport {
+	sharedTransportsMu.Lock()
+	defer sharedTransportsMu.Unlock()
+
+	if t, ok := sharedTransports[cfg]; ok {
+		return t
+	}
+	t := newPooledTransport(cfg)
+	sharedTransports[cfg] = t
+	return t
+}
+
+// newPooledTransport returns a transport keeping idle connections to the
+// Fly.io APIs open between calls. Zero pool settings fall back to
+// http.DefaultTransport's.
+func newPooledTransport(cfg config.HTTPConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	dial := dialer.DialContext
+	if cfg.DNSCacheTTL > 0 {
+		cache := &dnsCache{
+			ttl:      time.Duration(cfg.DNSCacheTTL) * time.Second,
+			dialer:   dialer,
+			resolver: net.DefaultResolver,
+			entries:  make(map[string]dnsEntry),
+		}
+		sharedDNSCaches = append(sharedDNSCaches, cache)
+		dial = cache.DialContext
+	}
+	dial = poolConns.track(dial)
+
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle == 0 {
+		maxIdle = 100
+	}
+	idleTimeout := time.Duration(cfg.IdleConnTimeout) * time.Second
+	if idleTimeout == 0 {
+		idleTimeout = 90 * time.Second
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dial,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       idleTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// connCounter counts open connections by the host they were dialed for
+type connCounter struct {
+	mu     sync.Mutex
+	open   map[string]int
+	dialed int64
+}
+
+// track wraps a dial function so the connections it opens are counted
+// until they are closed
+func (c *connCounter) track(dial func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		host, _, _ := net.SplitHostPort(address)
+
+		c.mu.Lock()
+		c.open[host]++
+		c.dialed++
+		c.mu.Unlock()
+		return &trackedConn{Conn: conn, counter: c, host: host}, nil
+	}
+}
+
+// trackedConn is a connection counted as open until its first Close
+type trackedConn struct {
+	net.Conn
+	counter *connCounter
+	host    string
+	once    sync.Once
+}
+
+// Close implements net.Conn
+func (t *trackedConn) Close() error {
+	t.once.Do(func() {
+		t.counter.mu.Lock()
+		if t.counter.open[t.host]--; t.counter.open[t.host] <= 0 {
+			delete(t.counter.open, t.host)
+		}
+		t.counter.mu.Unlock()
+	})
+	return t.Conn.Close()
+}
+
+// dnsEntry is a host's resolved addresses
+type dnsEntry struct {
+	addrs     []string
+	expiresAt time.Time
+}
+
+// dnsCache dials hosts at addresses resolved at most once per ttl. The API
+// hosts are few, so new connections in a burst of calls skip the lookup.
+type dnsCache struct {
+	ttl      time.Duration
+	dialer   *net.Dialer
+	resolver *net.Resolver
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+// DialContext dials an address, resolving its host from the cache. Each of
+// the host's addresses is tried in turn.
+func (d *dnsCache) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, addr := range addrs {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	// The host may have moved: resolve it again on the next dial
+	d.mu.Lock()
+	delete(d.entries, host)
+	d.mu.Unlock()
+	return nil, lastErr
+}
+
+// lookup returns a host's addresses, resolving it when the cached ones have
+// expired
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	d.mu.Lock()
+	entry, ok := d.entries[host]
+	d.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := d.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.entries[host] = dnsEntry{addrs: addrs, expiresAt: time.Now().Add(d.ttl)}
+	d.mu.Unlock()
+	return addrs, nil
+}
+
+// requestTimeoutKey carries a per-attempt timeout that replaces the
+// configured one for requests made with the context
+type requestTimeoutKey struct{}
+
+// withRequestTimeout makes each attempt of requests made with ctx time out
+// after timeout instead of fly.http.request_timeout, for calls that
+// legitimately take longer such as exec
+func withRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+// cancelOnClose releases an attempt's timeout once its response body is
+// closed, so that the timeout covers reading the body as well
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,182 @@
+package fly
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxProbeBody caps the response body read by a probe
+const maxProbeBody = 64 * 1024
+
+// ProbeRequest describes a synthetic check of an app's public endpoint. With
+// no Regions the probe targets the nearest region and each region the app
+// has machines in.
+type ProbeRequest struct {
+	Path    string
+	Regions []string
+	Timeout time.Duration
+}
+
+// ProbeResult is the outcome of one request
+type ProbeResult struct {
+	// Region is the region asked for with Fly-Prefer-Region, or "" when the
+	// request went to the nearest region
+	Region     string `json:"region"`
+	Edge       string `json:"edge,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	LatencyMS  int64  `json:"latencyMs"`
+	Location   string `json:"location,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// OK reports whether the request got a response that is not a server error
+func (r ProbeResult) OK() bool {
+	return r.Error == "" && r.StatusCode > 0 && r.StatusCode < 500
+}
+
+// CertificateInfo describes the TLS certificate served for the app
+type CertificateInfo struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	DNSNames []string  `json:"dnsNames"`
+	NotAfter time.Time `json:"notAfter"`
+	DaysLeft int       `json:"daysLeft"`
+}
+
+// ProbeReport is the outcome of probing an app
+type ProbeReport struct {
+	AppName     string           `json:"appName"`
+	URL         string           `json:"url"`
+	Results     []ProbeResult    `json:"results"`
+	Certificate *CertificateInfo `json:"certificate,omitempty"`
+}
+
+// ProbeApp sends HTTPS requests to an app's public hostname, once without a
+// region preference and once per region, and records status, latency and
+// the TLS certificate. Requests are unauthenticated and do not follow
+// redirects.
+func (c *Client) ProbeApp(ctx context.Context, appName string, req ProbeRequest) (*ProbeReport, error) {
+	app, err := c.GetApp(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	if app.Hostname == "" {
+		return nil, &FlyError{
+			StatusCode: http.StatusNotFound,
+			Code:       ErrorCodeNotFound,
+			Message:    fmt.Sprintf("app %s has no public hostname", appName),
+		}
+	}
+
+	regions := req.Regions
+	if len(regions) == 0 {
+		machines, err := c.machinesClient.ListMachines(ctx, appName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+		}
+		seen := make(map[string]bool)
+		for _, m := range machines {
+			if m.State != "destroyed" && !seen[m.Region] {
+				seen[m.Region] = true
+				regions = append(regions, m.Region)
+			}
+		}
+		sort.Strings(regions)
+	}
+
+	path := req.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	report := &ProbeReport{
+		AppName: appName,
+		URL:     "https://" + app.Hostname + path,
+		Results: make([]ProbeResult, len(regions)+1),
+	}
+
+	client := &http.Client{
+		Timeout: req.Timeout,
+		Transport: &http.Transport{
+			// A fresh connection per probe, so latency includes the handshake
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i, region := range append([]string{""}, regions...) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, state := probe(ctx, client, report.URL, region)
+			report.Results[i] = result
+
+			if state != nil && len(state.PeerCertificates) > 0 {
+				mu.Lock()
+				if report.Certificate == nil {
+					cert := state.PeerCertificates[0]
+					report.Certificate = &CertificateInfo{
+						Subject:  cert.Subject.CommonName,
+						Issuer:   cert.Issuer.CommonName,
+						DNSNames: cert.DNSNames,
+						NotAfter: cert.NotAfter,
+						DaysLeft: int(time.Until(cert.NotAfter).Hours() / 24),
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report, nil
+}
+
+// probe sends one request, preferring region if set
+func probe(ctx context.Context, client *http.Client, url, region string) (ProbeResult, *tls.ConnectionState) {
+	result := ProbeResult{Region: region}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	req.Header.Set("User-Agent", userAgent+" (probe)")
+	if region != "" {
+		req.Header.Set("Fly-Prefer-Region", region)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxProbeBody))
+
+	result.StatusCode = resp.StatusCode
+	result.Location = resp.Header.Get("Location")
+
+	// Fly's proxy suffixes the request ID with the edge region that handled
+	// the request, e.g. 01H...-iad
+	if id := resp.Header.Get("Fly-Request-Id"); id != "" {
+		if i := strings.LastIndex(id, "-"); i >= 0 {
+			result.Edge = id[i+1:]
+		}
+	}
+
+	return result, resp.TLS
+}
@@ -0,0 +1,84 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// AppProfile summarizes how an application is deployed, for comparing it
+// with another app. Environment variables and secrets are reported by name
+// only; secret digests tell whether two values are the same without
+// revealing them.
+type AppProfile struct {
+	AppName  string         `json:"appName"`
+	Machines int            `json:"machines"`
+	Regions  map[string]int `json:"regions"`
+	Sizes    map[string]int `json:"sizes"`
+	Images   map[string]int `json:"images"`
+	EnvVars  []string       `json:"envVars"`
+	Secrets  []Secret       `json:"secrets"`
+	Warnings []string       `json:"warnings,omitempty"`
+}
+
+// GetAppProfile collects the regions, machine sizes, images, environment
+// variable names and secrets of an application. Machine counts exclude
+// destroyed machines.
+func (c *Client) GetAppProfile(ctx context.Context, appName string) (*AppProfile, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	profile := &AppProfile{
+		AppName: appName,
+		Regions: make(map[string]int),
+		Sizes:   make(map[string]int),
+		Images:  make(map[string]int),
+		EnvVars: []string{},
+		Secrets: []Secret{},
+	}
+
+	env := make(map[string]bool)
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" {
+			continue
+		}
+		profile.Machines++
+		profile.Regions[m.Region]++
+
+		guest := m.Guest()
+		profile.Sizes[fmt.Sprintf("%s-cpu-%dx %dMB", guest.CPUKind, guest.CPUs, guest.MemoryMB)]++
+
+		image := m.ImageRef.Digest
+		if image == "" {
+			image = m.ImageRef.Repository + ":" + m.ImageRef.Tag
+		}
+		profile.Images[image]++
+
+		if vars, ok := m.Config["env"].(map[string]interface{}); ok {
+			for name := range vars {
+				env[name] = true
+			}
+		}
+	}
+
+	for name := range env {
+		profile.EnvVars = append(profile.EnvVars, name)
+	}
+	sort.Strings(profile.EnvVars)
+
+	secrets, err := c.GetSecrets(ctx, appName)
+	if err != nil {
+		c.logger.Warn().
+			Str("app_name", appName).
+			Err(err).
+			Msg("Failed to list secrets, excluding them from app profile")
+		profile.Warnings = append(profile.Warnings, "secrets could not be listed and are not compared")
+	} else {
+		sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+		profile.Secrets = secrets
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,259 @@
+package fly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// PrometheusClient queries Fly.io's hosted Prometheus API for app metrics
+type PrometheusClient struct {
+	httpClient *http.Client
+	baseURL    string
+	logger     *logger.Logger
+}
+
+// NewPrometheusClient creates a new Prometheus query client
+func NewPrometheusClient(cfg *config.FlyConfig, log *logger.Logger) *PrometheusClient {
+	return &PrometheusClient{
+		httpClient: newAPIHTTPClient("prometheus", cfg, tokenAuth, log),
+		baseURL:    cfg.PrometheusURL,
+		logger:     log,
+	}
+}
+
+// MetricPoint is a single sample of a metric series
+type MetricPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// MetricSeries is a labelled series of samples returned by a query
+type MetricSeries struct {
+	Labels map[string]string `json:"labels"`
+	Points []MetricPoint     `json:"points"`
+}
+
+// SeriesSummary holds summary statistics for a series
+type SeriesSummary struct {
+	Latest float64 `json:"latest"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Avg    float64 `json:"avg"`
+}
+
+// Summary computes summary statistics over the points of a series
+func (s *MetricSeries) Summary() SeriesSummary {
+	if len(s.Points) == 0 {
+		return SeriesSummary{}
+	}
+
+	summary := SeriesSummary{
+		Latest: s.Points[len(s.Points)-1].Value,
+		Min:    math.Inf(1),
+		Max:    math.Inf(-1),
+	}
+
+	var total float64
+	for _, p := range s.Points {
+		total += p.Value
+		summary.Min = math.Min(summary.Min, p.Value)
+		summary.Max = math.Max(summary.Max, p.Value)
+	}
+	summary.Avg = total / float64(len(s.Points))
+
+	return summary
+}
+
+// promResponse is the envelope returned by the Prometheus HTTP API
+type promResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"`
+			Value  []interface{}     `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryRange runs a PromQL range query against the organization's metrics
+func (c *PrometheusClient) QueryRange(ctx context.Context, orgSlug, query string, start, end time.Time, step time.Duration) ([]MetricSeries, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.Itoa(int(step.Seconds())))
+
+	return c.do(ctx, orgSlug, "query_range", params)
+}
+
+// Query runs an instant PromQL query against the organization's metrics
+func (c *PrometheusClient) Query(ctx context.Context, orgSlug, query string) ([]MetricSeries, error) {
+	params := url.Values{}
+	params.Set("query", query)
+
+	return c.do(ctx, orgSlug, "query", params)
+}
+
+// do performs a Prometheus API request and decodes the result
+func (c *PrometheusClient) do(ctx context.Context, orgSlug, endpoint string, params url.Values) ([]MetricSeries, error) {
+	if orgSlug == "" {
+		return nil, fmt.Errorf("organization is required to query metrics")
+	}
+
+	path := fmt.Sprintf("/%s/api/v1/%s", url.PathEscape(orgSlug), endpoint)
+	reqURL := c.baseURL + path + "?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics query failed: %w", newHTTPError(resp, body))
+	}
+
+	var result promResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if result.Status != "success" {
+		return nil, fmt.Errorf("metrics query failed (%s): %s", result.ErrorType, result.Error)
+	}
+
+	series := make([]MetricSeries, 0, len(result.Data.Result))
+	for _, r := range result.Data.Result {
+		s := MetricSeries{Labels: r.Metric}
+		if len(r.Value) == 2 {
+			if p, ok := parsePromSample(r.Value); ok {
+				s.Points = append(s.Points, p)
+			}
+		}
+		for _, v := range r.Values {
+			if p, ok := parsePromSample(v); ok {
+				s.Points = append(s.Points, p)
+			}
+		}
+		series = append(series, s)
+	}
+
+	return series, nil
+}
+
+// parsePromSample converts a [timestamp, "value"] pair into a MetricPoint
+func parsePromSample(sample []interface{}) (MetricPoint, bool) {
+	if len(sample) != 2 {
+		return MetricPoint{}, false
+	}
+
+	ts, ok := sample[0].(float64)
+	if !ok {
+		return MetricPoint{}, false
+	}
+
+	raw, ok := sample[1].(string)
+	if !ok {
+		return MetricPoint{}, false
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) {
+		return MetricPoint{}, false
+	}
+
+	sec, frac := math.Modf(ts)
+	return MetricPoint{
+		Time:  time.Unix(int64(sec), int64(frac*1e9)).UTC(),
+		Value: value,
+	}, true
+}
+
+// AppMetric describes a standard per-app metric backed by a PromQL query
+type AppMetric struct {
+	Name        string
+	Description string
+	Unit        string
+	query       string
+}
+
+// Query returns the PromQL expression for the given app
+func (m AppMetric) Query(appName string) string {
+	return fmt.Sprintf(m.query, strconv.Quote(appName))
+}
+
+// AppMetrics lists the standard metrics available for every Fly.io app
+var AppMetrics = []AppMetric{
+	{
+		Name:        "cpu",
+		Description: "CPU utilization averaged across machines",
+		Unit:        "%",
+		query:       `100 - avg(rate(fly_instance_cpu{app=%s, mode="idle"}[5m]))`,
+	},
+	{
+		Name:        "memory",
+		Description: "Memory in use averaged across machines",
+		Unit:        "MB",
+		query:       `avg(fly_instance_memory_mem_total{app=%[1]s} - fly_instance_memory_mem_available{app=%[1]s}) / 1048576`,
+	},
+	{
+		Name:        "memory_percent",
+		Description: "Memory utilization averaged across machines",
+		Unit:        "%",
+		query:       `100 * avg(1 - fly_instance_memory_mem_available{app=%[1]s} / fly_instance_memory_mem_total{app=%[1]s})`,
+	},
+	{
+		Name:        "http_requests",
+		Description: "HTTP requests served by the Fly proxy",
+		Unit:        "req/s",
+		query:       `sum(rate(fly_edge_http_responses_count{app=%s}[5m]))`,
+	},
+	{
+		Name:        "http_errors",
+		Description: "HTTP 5xx responses served by the Fly proxy",
+		Unit:        "req/s",
+		query:       `sum(rate(fly_edge_http_responses_count{app=%s, status=~"5.."}[5m]))`,
+	},
+	{
+		Name:        "latency_p95",
+		Description: "95th percentile HTTP response time at the edge",
+		Unit:        "ms",
+		query:       `1000 * histogram_quantile(0.95, sum(rate(fly_edge_http_response_time_seconds_bucket{app=%s}[5m])) by (le))`,
+	},
+}
+
+// LookupAppMetric returns the standard metric with the given name
+func LookupAppMetric(name string) (AppMetric, bool) {
+	for _, m := range AppMetrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return AppMetric{}, false
+}
@@ -0,0 +1,405 @@
+package fly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// maxResolvedTags caps the manifest lookups made for one app's images
+const maxResolvedTags = 20
+
+// manifestMediaTypes are the manifest formats accepted when resolving digests
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// RegistryClient talks to Fly.io's Docker registry (registry.fly.io) and,
+// anonymously, to public registries such as ghcr.io and Docker Hub
+type RegistryClient struct {
+	httpClient *http.Client
+	baseURL    string
+	logger     *logger.Logger
+
+	// public pulls from other registries without credentials
+	public *http.Client
+}
+
+// NewRegistryClient creates a new registry client
+func NewRegistryClient(cfg *config.FlyConfig, log *logger.Logger) *RegistryClient {
+	return &RegistryClient{
+		// The registry accepts the Fly.io API token as the basic auth password
+		httpClient: newAPIHTTPClient("registry", cfg, basicAuth, log),
+		baseURL:    cfg.RegistryURL,
+		logger:     log,
+		public: &http.Client{
+			Timeout:   time.Duration(cfg.Timeout) * time.Second,
+			Transport: sharedTransport(cfg.HTTP),
+		},
+	}
+}
+
+// ListTags returns the tags pushed to a repository
+func (c *RegistryClient) ListTags(ctx context.Context, repository string) ([]string, error) {
+	path := fmt.Sprintf("/v2/%s/tags/list", url.PathEscape(repository))
+
+	resp, body, err := c.do(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp, body)
+	}
+
+	var result struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Tags, nil
+}
+
+// ManifestDigest resolves a tag to its manifest digest
+func (c *RegistryClient) ManifestDigest(ctx context.Context, repository, tag string) (string, error) {
+	path := fmt.Sprintf("/v2/%s/manifests/%s", url.PathEscape(repository), url.PathEscape(tag))
+
+	resp, body, err := c.do(ctx, "HEAD", path, map[string]string{
+		"Accept": strings.Join(manifestMediaTypes, ", "),
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", newHTTPError(resp, body)
+	}
+
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// manifest is an image manifest or, with Manifests set, an index of the
+// manifests of each platform
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    *descriptor  `json:"config"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// descriptor points at a manifest or blob
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	Platform  *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant,omitempty"`
+	} `json:"platform,omitempty"`
+}
+
+// getManifest fetches a manifest by tag or digest and returns it with its
+// digest. An empty host is Fly.io's registry.
+func (c *RegistryClient) getManifest(ctx context.Context, host, repository, reference string) (*manifest, string, error) {
+	resp, body, err := c.pull(ctx, host, fmt.Sprintf("/v2/%s/manifests/%s", repository, reference), map[string]string{
+		"Accept": strings.Join(manifestMediaTypes, ", "),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", newHTTPError(resp, body)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, "", fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return &m, resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// getBlob fetches a blob, such as an image config, by digest. An empty host
+// is Fly.io's registry.
+func (c *RegistryClient) getBlob(ctx context.Context, host, repository, digest string) ([]byte, error) {
+	resp, body, err := c.pull(ctx, host, fmt.Sprintf("/v2/%s/blobs/%s", repository, digest), nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp, body)
+	}
+	return body, nil
+}
+
+// pull performs a GET against Fly.io's registry, or against another
+// registry anonymously, fetching the pull token it asks for if any
+func (c *RegistryClient) pull(ctx context.Context, host, path string, headers map[string]string) (*http.Response, []byte, error) {
+	if host == "" {
+		return c.do(ctx, "GET", path, headers)
+	}
+
+	resp, body, err := c.get(ctx, "https://"+host+path, headers)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, body, err
+	}
+
+	// Public images still need an anonymous token from the registry's
+	// auth service, e.g. ghcr.io/token or auth.docker.io
+	token, err := c.anonymousToken(ctx, resp.Header.Get("WWW-Authenticate"))
+	if err != nil || token == "" {
+		return resp, body, err
+	}
+	authorized := map[string]string{"Authorization": "Bearer " + token}
+	for k, v := range headers {
+		authorized[k] = v
+	}
+	return c.get(ctx, "https://"+host+path, authorized)
+}
+
+// anonymousToken answers a registry's Bearer challenge without credentials.
+// It returns an empty token when the challenge is not a Bearer one.
+func (c *RegistryClient) anonymousToken(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", nil
+	}
+
+	var realm string
+	query := url.Values{}
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		value = strings.Trim(value, `"`)
+		switch key {
+		case "realm":
+			realm = value
+		case "service", "scope":
+			query.Set(key, value)
+		}
+	}
+	if realm == "" {
+		return "", nil
+	}
+
+	resp, body, err := c.get(ctx, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", newHTTPError(resp, body)
+	}
+
+	var result struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode registry token: %w", err)
+	}
+	if result.Token != "" {
+		return result.Token, nil
+	}
+	return result.AccessToken, nil
+}
+
+// get performs an unauthenticated GET and reads the response body
+func (c *RegistryClient) get(ctx context.Context, rawURL string, headers map[string]string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.public.Do(req)
+	if err != nil {
+		return nil, nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp, body, nil
+}
+
+// do performs a registry request and reads the response body
+func (c *RegistryClient) do(ctx context.Context, method, path string, headers map[string]string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp, body, nil
+}
+
+// RegistryImage is a tagged image in an app's repository
+type RegistryImage struct {
+	Tag    string `json:"tag"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// MachineImage is the image a machine is running
+type MachineImage struct {
+	MachineID string `json:"machineId"`
+	Region    string `json:"region"`
+	State     string `json:"state"`
+	Image     string `json:"image"`
+	Digest    string `json:"digest"`
+	// Drift is set when the machine runs an app image other than the latest
+	Drift bool `json:"drift"`
+}
+
+// AppImages describes an app's pushed images and what its machines run
+type AppImages struct {
+	AppName    string          `json:"appName"`
+	Repository string          `json:"repository"`
+	Images     []RegistryImage `json:"images"`
+	TotalTags  int             `json:"totalTags"`
+	Latest     *RegistryImage  `json:"latest,omitempty"`
+	Machines   []MachineImage  `json:"machines"`
+	Warnings   []string        `json:"warnings,omitempty"`
+}
+
+// AppImage returns the reference of an image tag pushed to an app's
+// repository in the Fly.io registry and its digest, or an empty reference
+// when the tag has not been pushed
+func (c *Client) AppImage(ctx context.Context, appName, tag string) (string, string, error) {
+	digest, err := c.registryClient.ManifestDigest(ctx, appName, tag)
+	if err != nil {
+		if IsNotFound(err) {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("failed to look up image %s:%s: %w", appName, tag, err)
+	}
+	return c.AppImageRef(appName, tag), digest, nil
+}
+
+// AppImageRef returns the reference an image tag of an app has in the Fly.io
+// registry, whether or not it has been pushed
+func (c *Client) AppImageRef(appName, tag string) string {
+	return registryHost(c.config.RegistryURL) + "/" + appName + ":" + tag
+}
+
+// GetAppImages lists the images pushed for an app, newest first, and compares
+// the image each machine runs against the latest one
+func (c *Client) GetAppImages(ctx context.Context, appName string) (*AppImages, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	images := &AppImages{
+		AppName:    appName,
+		Repository: registryHost(c.config.RegistryURL) + "/" + appName,
+		Images:     []RegistryImage{},
+		Machines:   []MachineImage{},
+	}
+
+	tags, err := c.registryClient.ListTags(ctx, appName)
+	if err != nil {
+		if !IsNotFound(err) {
+			return nil, fmt.Errorf("failed to list images for app %s: %w", appName, err)
+		}
+		images.Warnings = append(images.Warnings, "No images have been pushed to the Fly.io registry for this app")
+	}
+
+	sortImageTags(tags)
+	images.TotalTags = len(tags)
+	if len(tags) > maxResolvedTags {
+		tags = tags[:maxResolvedTags]
+	}
+
+	for _, tag := range tags {
+		digest, err := c.registryClient.ManifestDigest(ctx, appName, tag)
+		if err != nil {
+			c.logger.Warn().
+				Str("app_name", appName).
+				Str("tag", tag).
+				Err(err).
+				Msg("Failed to resolve image digest")
+		}
+		images.Images = append(images.Images, RegistryImage{Tag: tag, Digest: digest})
+	}
+	if len(images.Images) > 0 {
+		images.Latest = &images.Images[0]
+	}
+
+	for _, m := range machines {
+		mi := MachineImage{
+			MachineID: m.ID,
+			Region:    m.Region,
+			State:     m.State,
+			Image:     m.ImageRef.Registry + "/" + m.ImageRef.Repository,
+			Digest:    m.ImageRef.Digest,
+		}
+		if m.ImageRef.Tag != "" {
+			mi.Image += ":" + m.ImageRef.Tag
+		}
+
+		// Only app images can be compared with the registry
+		fromAppRepo := m.ImageRef.Registry+"/"+m.ImageRef.Repository == images.Repository
+		if fromAppRepo && images.Latest != nil && images.Latest.Digest != "" {
+			mi.Drift = mi.Digest != images.Latest.Digest
+		}
+
+		images.Machines = append(images.Machines, mi)
+	}
+
+	return images, nil
+}
+
+// sortImageTags orders tags newest first. flyctl tags deploys as
+// deployment-<ULID>, which sort by time; other tags follow alphabetically.
+func sortImageTags(tags []string) {
+	sort.SliceStable(tags, func(i, j int) bool {
+		di := strings.HasPrefix(tags[i], "deployment-")
+		dj := strings.HasPrefix(tags[j], "deployment-")
+		if di != dj {
+			return di
+		}
+		if di {
+			return tags[i] > tags[j]
+		}
+		return tags[i] < tags[j]
+	})
+}
+
+// registryHost returns the host part of the registry URL
+func registryHost(registryURL string) string {
+	if u, err := url.Parse(registryURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "registry.fly.io"
+}
@@ -0,0 +1,102 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Release is one deployment of an application
+type Release struct {
+	Version     int       `json:"version"`
+	Status      string    `json:"status"`
+	Description string    `json:"description,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	User        string    `json:"user,omitempty"`
+	ImageRef    string    `json:"imageRef,omitempty"`
+	Stable      bool      `json:"stable"`
+	InProgress  bool      `json:"inProgress"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// GetReleases returns an application's most recent releases, newest first
+func (c *Client) GetReleases(ctx context.Context, appName string, limit int) ([]Release, error) {
+	start := time.Now()
+
+	releases, err := c.flyClient.GetAppReleasesMachines(ctx, appName, "", limit)
+	err = parseGraphQLError(err)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall(fmt.Sprintf("/apps/%s/releases", appName), "GET", getStatusCode(err), duration)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get releases for app %s: %w", appName, err)
+	}
+
+	result := make([]Release, 0, len(releases))
+	for _, r := range releases {
+		result = append(result, Release{
+			Version:     r.Version,
+			Status:      r.Status,
+			Description: r.Description,
+			Reason:      r.Reason,
+			User:        r.User.Email,
+			ImageRef:    r.ImageRef,
+			Stable:      r.Stable,
+			InProgress:  r.InProgress,
+			CreatedAt:   r.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// GetRecentLogs returns the most recent page of an application's log
+// output, oldest first
+func (c *Client) GetRecentLogs(ctx context.Context, appName string) ([]LogEntry, error) {
+	entries, _, err := c.GetLogsSince(ctx, appName, "", "", "")
+	return entries, err
+}
+
+// GetLogsSince returns an application's log lines following those of an
+// earlier call, oldest first, and the token to pass to the next call. With
+// token "" it returns the most recent page. Non-empty region and instance
+// limit the lines to that region or machine.
+func (c *Client) GetLogsSince(ctx context.Context, appName, token, region, instance string) ([]LogEntry, string, error) {
+	start := time.Now()
+
+	entries, nextToken, err := c.flyClient.GetAppLogs(ctx, appName, token, region, instance)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall(fmt.Sprintf("/apps/%s/logs", appName), "GET", getStatusCode(err), duration)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get logs for app %s: %w", appName, err)
+	}
+
+	result := make([]LogEntry, 0, len(entries))
+	for _, e := range entries {
+		timestamp, _ := time.Parse(time.RFC3339Nano, e.Timestamp)
+		entry := LogEntry{
+			Timestamp: timestamp,
+			Level:     e.Level,
+			Message:   e.Message,
+			Instance:  e.Instance,
+			Region:    e.Region,
+		}
+		if entry.Instance == "" {
+			entry.Instance = e.Meta.Instance
+		}
+		if entry.Region == "" {
+			entry.Region = e.Meta.Region
+		}
+		result = append(result, entry)
+	}
+
+	// The API answers with an empty token when there is nothing new
+	if nextToken == "" {
+		nextToken = token
+	}
+
+	return result, nextToken, nil
+}
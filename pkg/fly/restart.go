@@ -0,0 +1,382 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// Restart strategies
+const (
+	RestartAllAtOnce = "all_at_once"
+	RestartRolling   = "rolling"
+	RestartByRegion  = "region"
+)
+
+// RestartStrategies lists the supported restart strategies
+var RestartStrategies = []string{RestartAllAtOnce, RestartRolling, RestartByRegion}
+
+// Restart outcomes
+const (
+	RestartSucceeded = "succeeded"
+	RestartFailed    = "failed"
+)
+
+// Outcomes of restarting one machine
+const (
+	MachineRestarted = "restarted"
+	MachineFailed    = "failed"
+	MachineSkipped   = "skipped"
+)
+
+// RestartRequest describes a restart of an app's machines
+type RestartRequest struct {
+	Strategy string
+	// BatchSize is how many machines a rolling restart restarts at a time;
+	// BatchPercent sets it as a share of the machines instead
+	BatchSize    int
+	BatchPercent int
+	// HealthTimeout is how long restarted machines may take to start and
+	// pass their health checks
+	HealthTimeout time.Duration
+	// Filter selects the machines to restart; the zero filter selects all
+	Filter MachineFilter
+}
+
+// MachineStateUnhealthy selects started machines with a health check that
+// is not passing, in a MachineFilter's State
+const MachineStateUnhealthy = "unhealthy"
+
+// MachineFilter selects machines by their properties. Empty fields match
+// every machine.
+type MachineFilter struct {
+	MachineIDs []string `json:"machineIds,omitempty"`
+	Region     string   `json:"region,omitempty"`
+	// State is a machine state such as stopped, or MachineStateUnhealthy
+	State string `json:"state,omitempty"`
+	// ImageDigest matches machines whose image digest starts with it
+	ImageDigest string `json:"imageDigest,omitempty"`
+}
+
+// IsZero reports whether the filter selects every machine
+func (f MachineFilter) IsZero() bool {
+	return len(f.MachineIDs) == 0 && f.Region == "" && f.State == "" && f.ImageDigest == ""
+}
+
+// Matches reports whether a machine passes the filter
+func (f MachineFilter) Matches(m Machine) bool {
+	if len(f.MachineIDs) > 0 && !slices.Contains(f.MachineIDs, m.ID) {
+		return false
+	}
+	if f.Region != "" && m.Region != f.Region {
+		return false
+	}
+	if f.ImageDigest != "" && !strings.HasPrefix(m.ImageRef.Digest, f.ImageDigest) {
+		return false
+	}
+	switch f.State {
+	case "":
+	case MachineStateUnhealthy:
+		return m.State == "started" && len(failingChecks(m.Checks, time.Time{})) > 0
+	default:
+		return m.State == f.State
+	}
+	return true
+}
+
+// RestartPlan lists the batches of machines a restart will restart, in
+// order
+type RestartPlan struct {
+	AppName  string            `json:"appName"`
+	Strategy string            `json:"strategy"`
+	Filter   MachineFilter     `json:"filter"`
+	Batches  [][]RestartTarget `json:"batches"`
+}
+
+// RestartTarget is a machine a restart will restart
+type RestartTarget struct {
+	MachineID string `json:"machineId"`
+	Region    string `json:"region"`
+	State     string `json:"state"`
+	Image     string `json:"image"`
+	Digest    string `json:"digest,omitempty"`
+}
+
+// RestartedMachine records what a restart did to one machine and whether it
+// came back healthy
+type RestartedMachine struct {
+	MachineID string `json:"machineId"`
+	Region    string `json:"region"`
+	// Batch numbers the batch the machine was restarted in, from 1
+	Batch   int    `json:"batch"`
+	Action  string `json:"action"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RestartResult is the outcome of a restart
+type RestartResult struct {
+	AppName   string             `json:"appName"`
+	Strategy  string             `json:"strategy"`
+	Filter    *MachineFilter     `json:"filter,omitempty"`
+	Status    string             `json:"status"`
+	Machines  []RestartedMachine `json:"machines"`
+	Healthy   int                `json:"healthy"`
+	Unhealthy int                `json:"unhealthy"`
+	Skipped   int                `json:"skipped,omitempty"`
+	Duration  string             `json:"duration"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// PlanRestart validates a restart request and lists the batches of machines
+// it will restart
+func (c *Client) PlanRestart(ctx context.Context, appName string, req RestartRequest) (*RestartPlan, error) {
+	if !slices.Contains(RestartStrategies, req.Strategy) {
+		return nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("invalid strategy '%s' (expected %s)", req.Strategy, strings.Join(RestartStrategies, ", ")),
+		}
+	}
+
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	var targets []RestartTarget
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" || !req.Filter.Matches(m) {
+			continue
+		}
+		targets = append(targets, RestartTarget{
+			MachineID: m.ID,
+			Region:    m.Region,
+			State:     m.State,
+			Image:     m.ImageRef.String(),
+			Digest:    m.ImageRef.Digest,
+		})
+	}
+	if len(targets) == 0 {
+		message := fmt.Sprintf("app %s has no machines to restart", appName)
+		if !req.Filter.IsZero() {
+			message = fmt.Sprintf("no machines of app %s match the filter", appName)
+		}
+		return nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    message,
+		}
+	}
+
+	return &RestartPlan{
+		AppName:  appName,
+		Strategy: req.Strategy,
+		Filter:   req.Filter,
+		Batches:  restartBatches(targets, req),
+	}, nil
+}
+
+// restartBatches splits the machines into the batches of a strategy
+func restartBatches(targets []RestartTarget, req RestartRequest) [][]RestartTarget {
+	switch req.Strategy {
+	case RestartRolling:
+		size := req.BatchSize
+		if req.BatchPercent > 0 {
+			size = (len(targets)*req.BatchPercent + 99) / 100
+		}
+		size = max(size, 1)
+
+		var batches [][]RestartTarget
+		for start := 0; start < len(targets); start += size {
+			batches = append(batches, targets[start:min(start+size, len(targets))])
+		}
+		return batches
+	case RestartByRegion:
+		byRegion := make(map[string][]RestartTarget)
+		var regions []string
+		for _, t := range targets {
+			if _, ok := byRegion[t.Region]; !ok {
+				regions = append(regions, t.Region)
+			}
+			byRegion[t.Region] = append(byRegion[t.Region], t)
+		}
+		sort.Strings(regions)
+
+		batches := make([][]RestartTarget, 0, len(regions))
+		for _, region := range regions {
+			batches = append(batches, byRegion[region])
+		}
+		return batches
+	}
+	return [][]RestartTarget{targets}
+}
+
+// Restart restarts an app's machines with the requested strategy:
+//
+//   - all_at_once restarts every machine without waiting in between, then
+//     checks that each came back healthy
+//   - rolling restarts a batch of machines at a time, waiting for the batch
+//     to pass its health checks before moving on
+//   - region restarts one region at a time, like rolling
+//
+// A rolling or region restart stops at the first batch that does not come
+// back healthy and leaves the remaining machines alone. Failures are
+// reported in the result rather than as an error, so the caller can see
+// which machines were restarted and which are healthy.
+func (c *Client) Restart(ctx context.Context, appName string, req RestartRequest) (*RestartResult, error) {
+	plan, err := c.PlanRestart(ctx, appName, req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result := &RestartResult{
+		AppName:  appName,
+		Strategy: req.Strategy,
+		Status:   RestartSucceeded,
+		Machines: []RestartedMachine{},
+	}
+	if !req.Filter.IsZero() {
+		result.Filter = &req.Filter
+	}
+
+	total := 0
+	for _, batch := range plan.Batches {
+		total += len(batch)
+	}
+
+	for i, batch := range plan.Batches {
+		if err == nil {
+			err = ctx.Err()
+			if err != nil {
+				err = fmt.Errorf("restart cancelled after %d of %d machines: %w", len(result.Machines), total, context.Cause(ctx))
+			}
+		}
+		if err != nil {
+			for _, t := range batch {
+				result.Machines = append(result.Machines, RestartedMachine{MachineID: t.MachineID, Region: t.Region, Batch: i + 1, Action: MachineSkipped})
+			}
+			continue
+		}
+
+		// All at once checks the machines only once every one is restarted
+		wait := req.Strategy != RestartAllAtOnce
+		restarted := c.restartBatch(ctx, appName, batch, i+1, wait, req.HealthTimeout)
+		result.Machines = append(result.Machines, restarted...)
+		interfaces.ReportProgress(ctx, float64(len(result.Machines)), float64(total), fmt.Sprintf("restarted %d/%d machines", len(result.Machines), total))
+
+		if wait {
+			if unhealthy := unhealthyMachines(restarted); len(unhealthy) > 0 {
+				err = fmt.Errorf("batch %d of %d did not come back healthy (%s)", i+1, len(plan.Batches), strings.Join(unhealthy, ", "))
+				if i+1 < len(plan.Batches) {
+					err = fmt.Errorf("%w; the remaining machines were not restarted", err)
+				}
+			}
+		}
+	}
+
+	if req.Strategy == RestartAllAtOnce {
+		c.verifyRestarted(ctx, appName, result.Machines, start, req.HealthTimeout)
+		if unhealthy := unhealthyMachines(result.Machines); len(unhealthy) > 0 && err == nil {
+			err = fmt.Errorf("%d of %d machines did not come back healthy (%s)", len(unhealthy), total, strings.Join(unhealthy, ", "))
+		}
+	}
+
+	for _, m := range result.Machines {
+		switch {
+		case m.Action == MachineSkipped:
+			result.Skipped++
+		case m.Healthy:
+			result.Healthy++
+		default:
+			result.Unhealthy++
+		}
+	}
+	if err != nil {
+		result.Status = RestartFailed
+		result.Error = err.Error()
+	}
+	result.Duration = time.Since(start).Round(time.Second).String()
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("strategy", req.Strategy).
+		Str("status", result.Status).
+		Int("healthy", result.Healthy).
+		Int("unhealthy", result.Unhealthy).
+		Int("skipped", result.Skipped).
+		Msg("Restart finished")
+
+	return result, nil
+}
+
+// restartBatch restarts a batch of machines concurrently and, if wait is
+// set, waits for each of them to become healthy
+func (c *Client) restartBatch(ctx context.Context, appName string, batch []RestartTarget, number int, wait bool, healthTimeout time.Duration) []RestartedMachine {
+	restarted := make([]RestartedMachine, len(batch))
+	var wg sync.WaitGroup
+	for i, t := range batch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started := time.Now()
+			restarted[i] = RestartedMachine{MachineID: t.MachineID, Region: t.Region, Batch: number, Action: MachineRestarted}
+			if err := c.machinesClient.RestartMachine(ctx, appName, t.MachineID); err != nil {
+				restarted[i].Action = MachineFailed
+				restarted[i].Error = err.Error()
+				return
+			}
+			if !wait {
+				return
+			}
+			if err := c.waitForHealthy(ctx, appName, t.MachineID, started, healthTimeout); err != nil {
+				restarted[i].Error = err.Error()
+				return
+			}
+			restarted[i].Healthy = true
+		}()
+	}
+	wg.Wait()
+	return restarted
+}
+
+// verifyRestarted waits for every restarted machine to become healthy and
+// records which did
+func (c *Client) verifyRestarted(ctx context.Context, appName string, machines []RestartedMachine, since time.Time, healthTimeout time.Duration) {
+	var wg sync.WaitGroup
+	for i := range machines {
+		if machines[i].Action != MachineRestarted {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.waitForHealthy(ctx, appName, machines[i].MachineID, since, healthTimeout); err != nil {
+				machines[i].Error = err.Error()
+				return
+			}
+			machines[i].Healthy = true
+		}()
+	}
+	wg.Wait()
+}
+
+// unhealthyMachines returns the IDs of machines that were restarted, or
+// failed to be, and are not healthy
+func unhealthyMachines(machines []RestartedMachine) []string {
+	var unhealthy []string
+	for _, m := range machines {
+		if m.Action != MachineSkipped && !m.Healthy {
+			unhealthy = append(unhealthy, m.MachineID)
+		}
+	}
+	return unhealthy
+}
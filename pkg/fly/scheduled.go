@@ -0,0 +1,231 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ScheduleIntervals are the schedules the Machines API can run a machine on
+var ScheduleIntervals = []string{"hourly", "daily", "weekly", "monthly"}
+
+// ScheduledTask is a machine that the platform starts on a schedule, runs to
+// completion and leaves stopped until the next run
+type ScheduledTask struct {
+	MachineID    string     `json:"machineId"`
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"`
+	Region       string     `json:"region"`
+	State        string     `json:"state"`
+	Image        string     `json:"image"`
+	Command      []string   `json:"command,omitempty"`
+	LastRunAt    *time.Time `json:"lastRunAt,omitempty"`
+	LastExitCode *int       `json:"lastExitCode,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// ScheduledTaskRequest describes a new scheduled task. An empty Image runs
+// the image the app's machines run, and an empty Region places the task in
+// the region of the app's first machine.
+type ScheduledTaskRequest struct {
+	Name     string
+	Schedule string
+	Image    string
+	Command  []string
+	Region   string
+	Env      map[string]string
+	CPUKind  string
+	CPUs     int
+	MemoryMB int
+}
+
+// String returns the image reference, e.g. registry.fly.io/my-app:deployment-01H
+func (r ImageRef) String() string {
+	image := r.Repository
+	if r.Registry != "" {
+		image = r.Registry + "/" + image
+	}
+	if r.Tag != "" {
+		image += ":" + r.Tag
+	}
+	return image
+}
+
+// ListScheduledTasks lists an app's machines that run on a schedule
+func (c *Client) ListScheduledTasks(ctx context.Context, appName string) ([]ScheduledTask, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	tasks := []ScheduledTask{}
+	for _, m := range machines {
+		if machineSchedule(m) == "" || m.State == "destroyed" {
+			continue
+		}
+		tasks = append(tasks, scheduledTask(m))
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+
+	return tasks, nil
+}
+
+// CreateScheduledTask creates a machine that runs on a schedule. The machine
+// does not restart when its command exits, so a failing task waits for its
+// next scheduled run instead of looping.
+func (c *Client) CreateScheduledTask(ctx context.Context, appName string, req ScheduledTaskRequest) (*ScheduledTask, error) {
+	if !slices.Contains(ScheduleIntervals, req.Schedule) {
+		return nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("invalid schedule '%s' (expected hourly, daily, weekly or monthly)", req.Schedule),
+		}
+	}
+
+	image, region := req.Image, req.Region
+	if image == "" || region == "" {
+		machines, err := c.machinesClient.ListMachines(ctx, appName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+		}
+		for _, m := range machines {
+			if m.State == "destroyed" || machineSchedule(m) != "" {
+				continue
+			}
+			if image == "" {
+				image = m.ImageRef.String()
+			}
+			if region == "" {
+				region = m.Region
+			}
+			break
+		}
+		if image == "" {
+			return nil, &FlyError{
+				StatusCode: http.StatusBadRequest,
+				Code:       ErrorCodeInvalid,
+				Message:    fmt.Sprintf("app %s has no deployed machines to take the image from; specify an image", appName),
+			}
+		}
+	}
+
+	guest := defaultGuest()
+	if req.CPUKind != "" {
+		guest.CPUKind = req.CPUKind
+	}
+	if req.CPUs > 0 {
+		guest.CPUs = req.CPUs
+	}
+	if req.MemoryMB > 0 {
+		guest.MemoryMB = req.MemoryMB
+	}
+
+	machineConfig := map[string]interface{}{
+		"image":    image,
+		"schedule": req.Schedule,
+		"guest": map[string]interface{}{
+			"cpu_kind":  guest.CPUKind,
+			"cpus":      guest.CPUs,
+			"memory_mb": guest.MemoryMB,
+		},
+		"restart": map[string]interface{}{
+			"policy": "no",
+		},
+	}
+	if len(req.Command) > 0 {
+		machineConfig["init"] = map[string]interface{}{"cmd": req.Command}
+	}
+	if len(req.Env) > 0 {
+		machineConfig["env"] = req.Env
+	}
+
+	machine, err := c.machinesClient.CreateMachine(ctx, appName, CreateMachineRequest{
+		Name:   req.Name,
+		Region: region,
+		Config: machineConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduled task for app %s: %w", appName, err)
+	}
+
+	task := scheduledTask(*machine)
+	return &task, nil
+}
+
+// DeleteScheduledTask destroys a scheduled task's machine. Machines without a
+// schedule are refused, so an app's service machines cannot be removed this
+// way by mistake.
+func (c *Client) DeleteScheduledTask(ctx context.Context, appName, machineID string) (*ScheduledTask, error) {
+	machine, err := c.machinesClient.GetMachine(ctx, appName, machineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine %s: %w", machineID, err)
+	}
+
+	if machineSchedule(*machine) == "" {
+		return nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("machine %s is not a scheduled task", machineID),
+		}
+	}
+
+	if err := c.machinesClient.DestroyMachine(ctx, appName, machineID, true); err != nil {
+		return nil, fmt.Errorf("failed to delete scheduled task %s: %w", machineID, err)
+	}
+
+	task := scheduledTask(*machine)
+	return &task, nil
+}
+
+// machineSchedule returns the schedule a machine runs on, or "" if it has none
+func machineSchedule(m Machine) string {
+	schedule, _ := m.Config["schedule"].(string)
+	return schedule
+}
+
+// scheduledTask describes a scheduled machine, including its most recent exit
+func scheduledTask(m Machine) ScheduledTask {
+	task := ScheduledTask{
+		MachineID: m.ID,
+		Name:      m.Name,
+		Schedule:  machineSchedule(m),
+		Region:    m.Region,
+		State:     m.State,
+		Image:     m.ImageRef.String(),
+		CreatedAt: m.CreatedAt,
+	}
+	if image, ok := m.Config["image"].(string); ok && task.Image == "" {
+		task.Image = image
+	}
+	if init, ok := m.Config["init"].(map[string]interface{}); ok {
+		if cmd, ok := init["cmd"].([]interface{}); ok {
+			for _, part := range cmd {
+				if s, ok := part.(string); ok {
+					task.Command = append(task.Command, s)
+				}
+			}
+		}
+	}
+
+	var lastExit *MachineEvent
+	for i := range m.Events {
+		e := &m.Events[i]
+		if e.Type == "exit" && e.Request != nil && e.Request.ExitEvent != nil && (lastExit == nil || e.Timestamp > lastExit.Timestamp) {
+			lastExit = e
+		}
+	}
+	if lastExit != nil {
+		ranAt := time.UnixMilli(lastExit.Timestamp).UTC()
+		code := lastExit.Request.ExitEvent.ExitCode
+		task.LastRunAt = &ranAt
+		task.LastExitCode = &code
+	}
+
+	return task
+}
@@ -0,0 +1,263 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kinds of search hits
+const (
+	SearchApp      = "app"
+	SearchHostname = "hostname"
+	SearchMachine  = "machine"
+	SearchRegion   = "region"
+	SearchImage    = "image"
+)
+
+// SearchKinds lists the kinds of search hits, from the most to the least
+// specific; equally good hits are ranked in this order
+var SearchKinds = []string{SearchApp, SearchHostname, SearchMachine, SearchImage, SearchRegion}
+
+const (
+	// defaultSearchLimit is how many hits a search returns by default
+	defaultSearchLimit = 25
+	// searchConcurrency is how many apps are inspected at once
+	searchConcurrency = 8
+)
+
+// SearchRequest describes a search across the organization
+type SearchRequest struct {
+	Query string
+	// Kinds restricts the search to some kinds of hits; empty means all
+	Kinds []string
+	Limit int
+}
+
+// SearchHit is one resource matching a search
+type SearchHit struct {
+	Kind      string `json:"kind"`
+	AppName   string `json:"appName"`
+	Value     string `json:"value"`
+	MachineID string `json:"machineId,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Score     int    `json:"score"`
+}
+
+// SearchResults are the hits of a search, best first
+type SearchResults struct {
+	Query     string      `json:"query"`
+	Apps      int         `json:"apps"`
+	TotalHits int         `json:"totalHits"`
+	Hits      []SearchHit `json:"hits"`
+	// Truncated is set when lower ranked hits were dropped to fit the limit
+	Truncated bool     `json:"truncated,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// Search matches a query against the names and hostnames of the
+// organization's apps and the IDs, names, regions and images of their
+// machines. Matching ignores case; exact matches rank above prefixes and
+// prefixes above substrings. include decides which apps are visible to the
+// caller; apps whose machines cannot be listed are reported as warnings.
+func (c *Client) Search(ctx context.Context, req SearchRequest, include func(appName string) bool) (*SearchResults, error) {
+	query := strings.ToLower(strings.TrimSpace(req.Query))
+	if query == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	kinds := req.Kinds
+	if len(kinds) == 0 {
+		kinds = SearchKinds
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	apps, err := c.GetApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &SearchResults{
+		Query: req.Query,
+		Hits:  []SearchHit{},
+	}
+
+	var visible []App
+	for _, app := range apps {
+		if include == nil || include(app.Name) {
+			visible = append(visible, app)
+		}
+	}
+	results.Apps = len(visible)
+
+	for _, app := range visible {
+		if slices.Contains(kinds, SearchApp) {
+			if score := matchScore(app.Name, query); score > 0 {
+				results.Hits = append(results.Hits, SearchHit{Kind: SearchApp, AppName: app.Name, Value: app.Name, Detail: app.Status, Score: score})
+			}
+		}
+		if slices.Contains(kinds, SearchHostname) && app.Hostname != "" {
+			if score := matchScore(app.Hostname, query); score > 0 {
+				results.Hits = append(results.Hits, SearchHit{Kind: SearchHostname, AppName: app.Name, Value: app.Hostname, Score: score})
+			}
+		}
+	}
+
+	// Machines are only listed when a machine, image or region could match
+	if slices.ContainsFunc(kinds, func(kind string) bool { return kind != SearchApp && kind != SearchHostname }) {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, searchConcurrency)
+		for _, app := range visible {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				machines, err := c.machinesClient.ListMachines(ctx, app.Name)
+				hits := searchMachines(app.Name, machines, query, kinds)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					results.Warnings = append(results.Warnings, fmt.Sprintf("%s: machines unavailable", app.Name))
+				}
+				results.Hits = append(results.Hits, hits...)
+			}()
+		}
+		wg.Wait()
+	}
+
+	sort.Slice(results.Hits, func(i, j int) bool {
+		a, b := results.Hits[i], results.Hits[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Kind != b.Kind {
+			return slices.Index(SearchKinds, a.Kind) < slices.Index(SearchKinds, b.Kind)
+		}
+		if a.AppName != b.AppName {
+			return a.AppName < b.AppName
+		}
+		return a.Value < b.Value
+	})
+	sort.Strings(results.Warnings)
+	results.TotalHits = len(results.Hits)
+	if len(results.Hits) > limit {
+		results.Hits = results.Hits[:limit]
+		results.Truncated = true
+	}
+
+	c.logger.Debug().
+		Str("query", req.Query).
+		Int("apps", results.Apps).
+		Int("hits", results.TotalHits).
+		Msg("Searched organization")
+
+	return results, nil
+}
+
+// searchMachines matches a query against one app's machines. Regions and
+// images are reported once per app, with the number of machines using them.
+func searchMachines(appName string, machines []Machine, query string, kinds []string) []SearchHit {
+	var hits []SearchHit
+	regions := make(map[string]int)
+	images := make(map[string]int)
+	imageScores := make(map[string]int)
+
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" {
+			continue
+		}
+
+		if slices.Contains(kinds, SearchMachine) {
+			score, value := matchScore(m.ID, query), m.ID
+			if nameScore := matchScore(m.Name, query); nameScore > score {
+				score, value = nameScore, m.Name
+			}
+			if score > 0 {
+				hits = append(hits, SearchHit{Kind: SearchMachine, AppName: appName, Value: value, MachineID: m.ID, Detail: fmt.Sprintf("%s, %s", m.Region, m.State), Score: score})
+			}
+		}
+
+		if slices.Contains(kinds, SearchRegion) && matchScore(m.Region, query) > 0 {
+			regions[m.Region]++
+		}
+
+		if slices.Contains(kinds, SearchImage) {
+			image := imageName(m.ImageRef)
+			score := 0
+			for _, candidate := range []string{image, m.ImageRef.Repository + ":" + m.ImageRef.Tag, m.ImageRef.Digest} {
+				score = max(score, matchScore(candidate, query))
+			}
+			if score > 0 {
+				images[image]++
+				imageScores[image] = score
+			}
+		}
+	}
+
+	for region, count := range regions {
+		hits = append(hits, SearchHit{Kind: SearchRegion, AppName: appName, Value: region, Detail: machineCount(count), Score: matchScore(region, query)})
+	}
+	for image, count := range images {
+		hits = append(hits, SearchHit{Kind: SearchImage, AppName: appName, Value: image, Detail: machineCount(count), Score: imageScores[image]})
+	}
+	return hits
+}
+
+// matchScore rates how well a value matches a lowercase query: 100 for the
+// whole value, 75 for a prefix, 50 for the start of a word in it, 25 for
+// any other substring and 0 for no match
+func matchScore(value, query string) int {
+	value = strings.ToLower(value)
+	switch {
+	case value == "":
+		return 0
+	case value == query:
+		return 100
+	case strings.HasPrefix(value, query):
+		return 75
+	}
+
+	score := 0
+	for i := 0; i+len(query) <= len(value); i++ {
+		if !strings.HasPrefix(value[i:], query) {
+			continue
+		}
+		if strings.ContainsRune("/:-._@", rune(value[i-1])) {
+			return 50
+		}
+		score = 25
+	}
+	return score
+}
+
+// imageName returns the name of the image a machine runs
+func imageName(ref ImageRef) string {
+	name := ref.Repository
+	if ref.Registry != "" {
+		name = ref.Registry + "/" + name
+	}
+	if ref.Tag != "" {
+		name += ":" + ref.Tag
+	}
+	if name == "" {
+		name = ref.Digest
+	}
+	return name
+}
+
+// machineCount describes a number of machines
+func machineCount(n int) string {
+	if n == 1 {
+		return "1 machine"
+	}
+	return fmt.Sprintf("%d machines", n)
+}
@@ -0,0 +1,57 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SetSecrets sets secrets on an application. Machines pick up the new values
+// the next time they are restarted or deployed.
+func (c *Client) SetSecrets(ctx context.Context, appName string, secrets map[string]string) error {
+	start := time.Now()
+
+	_, err := c.flyClient.SetSecrets(ctx, appName, secrets)
+	err = parseGraphQLError(err)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall(fmt.Sprintf("/apps/%s/secrets", appName), "POST", getStatusCode(err), duration)
+
+	if err != nil {
+		return fmt.Errorf("failed to set secrets for app %s: %w", appName, err)
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Int("secret_count", len(secrets)).
+		Msg("Set app secrets")
+
+	return nil
+}
+
+// GetSecrets lists an application's secrets. Only names and digests of the
+// values are available; the values themselves cannot be read back.
+func (c *Client) GetSecrets(ctx context.Context, appName string) ([]Secret, error) {
+	start := time.Now()
+
+	secrets, err := c.flyClient.GetAppSecrets(ctx, appName)
+	err = parseGraphQLError(err)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall(fmt.Sprintf("/apps/%s/secrets", appName), "GET", getStatusCode(err), duration)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets for app %s: %w", appName, err)
+	}
+
+	result := make([]Secret, 0, len(secrets))
+	for _, s := range secrets {
+		result = append(result, Secret{
+			Name:      s.Name,
+			Digest:    s.Digest,
+			CreatedAt: s.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,177 @@
+package fly
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/superfly/fly-go"
+)
+
+// IP address types that route traffic to an app through the Fly proxy
+const (
+	IPSharedV4 = "shared_v4"
+	IPv4       = "v4"
+	IPv6       = "v6"
+	// IPFlycast is a private IPv6 address reachable only from the
+	// organization's network, as <app>.flycast
+	IPFlycast = "private_v6"
+)
+
+// IPAddress is an address allocated to an app
+type IPAddress struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Region  string `json:"region,omitempty"`
+}
+
+// ServiceMachineRequest describes a machine that serves HTTP through the Fly
+// proxy, with a health check on HealthPath
+type ServiceMachineRequest struct {
+	Name         string
+	Image        string
+	Region       string
+	Env          map[string]string
+	InternalPort int
+	HealthPath   string
+	// Files are written into the machine, keyed by path
+	Files map[string][]byte
+	// ForceHTTPS redirects plain HTTP to HTTPS; leave it off for apps only
+	// reached over Flycast, which speaks plain HTTP
+	ForceHTTPS bool
+	Guest      MachineGuest
+	// HealthTimeout is how long the machine may take to start and pass its
+	// health check
+	HealthTimeout time.Duration
+}
+
+// GetIPAddresses lists the addresses allocated to an app
+func (c *Client) GetIPAddresses(ctx context.Context, appName string) ([]IPAddress, error) {
+	start := time.Now()
+
+	ips, err := c.flyClient.GetIPAddresses(ctx, appName)
+	err = parseGraphQLError(err)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall(fmt.Sprintf("/apps/%s/ips", appName), "GET", getStatusCode(err), duration)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IP addresses for app %s: %w", appName, err)
+	}
+
+	result := make([]IPAddress, 0, len(ips))
+	for _, ip := range ips {
+		result = append(result, IPAddress{Address: ip.Address, Type: ip.Type, Region: ip.Region})
+	}
+	return result, nil
+}
+
+// AllocateIPAddress allocates an address of the given type to an app
+func (c *Client) AllocateIPAddress(ctx context.Context, appName, addrType string) (*IPAddress, error) {
+	start := time.Now()
+
+	var result *IPAddress
+	var err error
+	switch addrType {
+	case IPSharedV4:
+		var ip net.IP
+		if ip, err = c.flyClient.AllocateSharedIPAddress(ctx, appName); err == nil {
+			result = &IPAddress{Address: ip.String(), Type: IPSharedV4}
+		}
+	default:
+		var ip *fly.IPAddress
+		if ip, err = c.flyClient.AllocateIPAddress(ctx, appName, addrType, "", nil, ""); err == nil {
+			result = &IPAddress{Address: ip.Address, Type: ip.Type, Region: ip.Region}
+		}
+	}
+	err = parseGraphQLError(err)
+	duration := time.Since(start)
+
+	c.logger.LogFlyAPICall(fmt.Sprintf("/apps/%s/ips", appName), "POST", getStatusCode(err), duration)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate %s address for app %s: %w", addrType, appName, err)
+	}
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("type", addrType).
+		Str("address", result.Address).
+		Msg("Allocated IP address")
+
+	return result, nil
+}
+
+// CreateServiceMachine creates a machine serving HTTP on ports 80 and 443
+// and waits for it to pass its health check
+func (c *Client) CreateServiceMachine(ctx context.Context, appName string, req ServiceMachineRequest) (*Machine, error) {
+	guest := req.Guest
+	if guest.CPUs == 0 {
+		guest = defaultGuest()
+	}
+
+	machineConfig := map[string]interface{}{
+		"image": req.Image,
+		"env":   req.Env,
+		"guest": map[string]interface{}{
+			"cpu_kind":  guest.CPUKind,
+			"cpus":      guest.CPUs,
+			"memory_mb": guest.MemoryMB,
+		},
+		"restart": map[string]interface{}{
+			"policy": "always",
+		},
+		"services": []map[string]interface{}{
+			{
+				"protocol":      "tcp",
+				"internal_port": req.InternalPort,
+				"ports": []map[string]interface{}{
+					{"port": 80, "handlers": []string{"http"}, "force_https": req.ForceHTTPS},
+					{"port": 443, "handlers": []string{"tls", "http"}},
+				},
+			},
+		},
+		"checks": map[string]interface{}{
+			"health": map[string]interface{}{
+				"type":         "http",
+				"port":         req.InternalPort,
+				"method":       "GET",
+				"path":         req.HealthPath,
+				"interval":     "30s",
+				"timeout":      "5s",
+				"grace_period": "10s",
+			},
+		},
+	}
+
+	if len(req.Files) > 0 {
+		paths := slices.Sorted(maps.Keys(req.Files))
+		files := make([]map[string]interface{}, 0, len(paths))
+		for _, path := range paths {
+			files = append(files, map[string]interface{}{
+				"guest_path": path,
+				"raw_value":  base64.StdEncoding.EncodeToString(req.Files[path]),
+			})
+		}
+		machineConfig["files"] = files
+	}
+
+	started := time.Now()
+	machine, err := c.machinesClient.CreateMachine(ctx, appName, CreateMachineRequest{
+		Name:   req.Name,
+		Region: req.Region,
+		Config: machineConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create machine for app %s: %w", appName, err)
+	}
+
+	if err := c.waitForHealthy(ctx, appName, machine.ID, started, req.HealthTimeout); err != nil {
+		return machine, err
+	}
+	return machine, nil
+}
@@ -0,0 +1,260 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// memoryHeadroom is the share of memory kept free above the peak in use
+	memoryHeadroom = 1.3
+
+	// memoryUpsizeAt and memoryDownsizeAt are the peak memory utilizations,
+	// in percent, above which a machine gets more memory and below which it
+	// may get less
+	memoryUpsizeAt   = 85.0
+	memoryDownsizeAt = 50.0
+
+	// cpuUpsizeAt and cpuDownsizeAt are the peak CPU utilizations, in
+	// percent, above which a machine gets more CPUs and below which it may
+	// get fewer
+	cpuUpsizeAt   = 80.0
+	cpuDownsizeAt = 25.0
+)
+
+// Memory Fly.io allows per CPU of each kind, and the size memory is added in
+const (
+	sharedMinMemoryPerCPU      = 256
+	sharedMaxMemoryPerCPU      = 2048
+	performanceMinMemoryPerCPU = 2048
+	performanceMaxMemoryPerCPU = 8192
+	memoryStepMB               = 256
+)
+
+// sizingQueries are the per-machine PromQL queries sizing is based on: the
+// share of its CPUs a machine uses, and the memory it uses in MB
+var sizingQueries = map[string]string{
+	"cpu":    `100 - avg by (instance) (rate(fly_instance_cpu{app=%[1]s, mode="idle"}[5m]))`,
+	"memory": `max by (instance) (fly_instance_memory_mem_total{app=%[1]s} - fly_instance_memory_mem_available{app=%[1]s}) / 1048576`,
+}
+
+// MachineUsage is the CPU and memory a machine used over a window
+type MachineUsage struct {
+	CPUAvg       float64 `json:"cpuAvg"`
+	CPUPeak      float64 `json:"cpuPeak"`
+	MemoryAvgMB  float64 `json:"memoryAvgMb"`
+	MemoryPeakMB float64 `json:"memoryPeakMb"`
+}
+
+// MachineSizing compares a machine's size with its usage
+type MachineSizing struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Region  string        `json:"region"`
+	State   string        `json:"state"`
+	Current MachineGuest  `json:"current"`
+	Usage   *MachineUsage `json:"usage,omitempty"`
+	// Recommended is set when the machine should be resized
+	Recommended *MachineGuest `json:"recommended,omitempty"`
+	Reasons     []string      `json:"reasons,omitempty"`
+	// MonthlyDelta is the change in the machine's monthly cost, negative
+	// for savings, as if it ran all month
+	MonthlyDelta float64 `json:"monthlyDelta,omitempty"`
+}
+
+// SizingReport is the sizing recommendation for an application's machines
+type SizingReport struct {
+	AppName string `json:"appName"`
+	// Window is the span usage was measured over, as given, e.g. 7d
+	Window   string          `json:"window"`
+	Machines []MachineSizing `json:"machines"`
+	// Commands resize the machines: one for the whole app when every
+	// machine gets the same size, otherwise one per machine
+	Commands     []string `json:"commands,omitempty"`
+	MonthlyDelta float64  `json:"monthlyDelta"`
+}
+
+// RecommendSizing compares the CPU and memory each machine of an
+// application used over the window with its size, and recommends resizes
+// with their estimated cost. Machines without metrics keep their size.
+func (c *Client) RecommendSizing(ctx context.Context, appName string, window time.Duration) (*SizingReport, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	end := time.Now()
+	start := end.Add(-window)
+	step := (window / 60).Truncate(time.Second)
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+
+	usage := make(map[string]*MachineUsage)
+	for name, query := range sizingQueries {
+		series, err := c.QueryMetrics(ctx, "", fmt.Sprintf(query, strconv.Quote(appName)), start, end, step)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range series {
+			if app, ok := s.Labels["app"]; ok && app != appName {
+				continue
+			}
+			instance := s.Labels["instance"]
+			if instance == "" || len(s.Points) == 0 {
+				continue
+			}
+			u, ok := usage[instance]
+			if !ok {
+				u = &MachineUsage{}
+				usage[instance] = u
+			}
+			summary := s.Summary()
+			switch name {
+			case "cpu":
+				u.CPUAvg, u.CPUPeak = summary.Avg, summary.Max
+			case "memory":
+				u.MemoryAvgMB, u.MemoryPeakMB = summary.Avg, summary.Max
+			}
+		}
+	}
+
+	pricing := c.config.Pricing
+	report := &SizingReport{AppName: appName, Machines: []MachineSizing{}}
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" {
+			continue
+		}
+		sizing := MachineSizing{
+			ID:      m.ID,
+			Name:    m.Name,
+			Region:  m.Region,
+			State:   m.State,
+			Current: m.Guest(),
+			Usage:   usage[m.ID],
+		}
+		if sizing.Usage != nil {
+			if guest, reasons := RecommendGuest(sizing.Current, *sizing.Usage); guest != sizing.Current {
+				sizing.Recommended = &guest
+				sizing.Reasons = reasons
+				sizing.MonthlyDelta = EstimateMachineCost(pricing, guest) - EstimateMachineCost(pricing, sizing.Current)
+				report.MonthlyDelta += sizing.MonthlyDelta
+			}
+		}
+		report.Machines = append(report.Machines, sizing)
+	}
+	sort.SliceStable(report.Machines, func(i, j int) bool {
+		return report.Machines[i].ID < report.Machines[j].ID
+	})
+
+	report.Commands = resizeCommands(appName, report.Machines)
+	return report, nil
+}
+
+// RecommendGuest returns the size a machine should have for its usage, and
+// why it differs from current. Memory is sized to the peak in use plus
+// headroom; CPUs double or halve with the peak CPU utilization, within the
+// memory Fly.io allows per CPU.
+func RecommendGuest(current MachineGuest, usage MachineUsage) (MachineGuest, []string) {
+	guest := current
+	var reasons []string
+
+	if current.MemoryMB > 0 && usage.MemoryPeakMB > 0 {
+		peakPercent := 100 * usage.MemoryPeakMB / float64(current.MemoryMB)
+		target := int(math.Ceil(usage.MemoryPeakMB*memoryHeadroom/memoryStepMB)) * memoryStepMB
+		switch {
+		case peakPercent > memoryUpsizeAt && target > guest.MemoryMB:
+			guest.MemoryMB = target
+			reasons = append(reasons, fmt.Sprintf("memory peaked at %.0f MB of %d MB", usage.MemoryPeakMB, current.MemoryMB))
+		case peakPercent < memoryDownsizeAt && target < guest.MemoryMB:
+			guest.MemoryMB = target
+			reasons = append(reasons, fmt.Sprintf("memory peaked at only %.0f MB of %d MB", usage.MemoryPeakMB, current.MemoryMB))
+		}
+	}
+
+	maxCPUs := 8
+	if guest.CPUKind == "performance" {
+		maxCPUs = 16
+	}
+	switch {
+	case usage.CPUPeak > cpuUpsizeAt && guest.CPUs < maxCPUs:
+		guest.CPUs *= 2
+		reasons = append(reasons, fmt.Sprintf("CPU peaked at %.0f%% of %d CPU(s)", usage.CPUPeak, current.CPUs))
+	case usage.CPUPeak < cpuDownsizeAt && guest.CPUs > 1 && guest.MemoryMB <= maxMemory(guest.CPUKind, guest.CPUs/2):
+		guest.CPUs /= 2
+		reasons = append(reasons, fmt.Sprintf("CPU peaked at only %.0f%% of %d CPUs", usage.CPUPeak, current.CPUs))
+	}
+
+	// Memory beyond what the CPUs allow needs more CPUs
+	for guest.MemoryMB > maxMemory(guest.CPUKind, guest.CPUs) && guest.CPUs < maxCPUs {
+		guest.CPUs *= 2
+	}
+	guest.MemoryMB = max(min(guest.MemoryMB, maxMemory(guest.CPUKind, guest.CPUs)), minMemory(guest.CPUKind, guest.CPUs))
+
+	return guest, reasons
+}
+
+// minMemory returns the least memory, in MB, Fly.io allows with cpus CPUs
+func minMemory(kind string, cpus int) int {
+	if kind == "performance" {
+		return performanceMinMemoryPerCPU * cpus
+	}
+	return sharedMinMemoryPerCPU * cpus
+}
+
+// maxMemory returns the most memory, in MB, Fly.io allows with cpus CPUs
+func maxMemory(kind string, cpus int) int {
+	if kind == "performance" {
+		return performanceMaxMemoryPerCPU * cpus
+	}
+	return sharedMaxMemoryPerCPU * cpus
+}
+
+// VMSize returns the name of the preset a size is based on, e.g.
+// shared-cpu-2x or performance-4x
+func (g MachineGuest) VMSize() string {
+	if g.CPUKind == "performance" {
+		return fmt.Sprintf("performance-%dx", g.CPUs)
+	}
+	return fmt.Sprintf("shared-cpu-%dx", g.CPUs)
+}
+
+// resizeCommands returns the flyctl commands applying the recommended sizes
+func resizeCommands(appName string, machines []MachineSizing) []string {
+	var resized []MachineSizing
+	for _, m := range machines {
+		if m.Recommended != nil {
+			resized = append(resized, m)
+		}
+	}
+	if len(resized) == 0 {
+		return nil
+	}
+
+	uniform := len(resized) == len(machines)
+	for _, m := range resized {
+		if *m.Recommended != *resized[0].Recommended {
+			uniform = false
+		}
+	}
+	if uniform {
+		guest := resized[0].Recommended
+		return []string{fmt.Sprintf("flyctl scale vm %s --vm-memory %d -a %s", guest.VMSize(), guest.MemoryMB, appName)}
+	}
+
+	commands := make([]string, 0, len(resized))
+	for _, m := range resized {
+		commands = append(commands, strings.Join([]string{
+			"flyctl machine update", m.ID,
+			"--vm-size", m.Recommended.VMSize(),
+			"--vm-memory", strconv.Itoa(m.Recommended.MemoryMB),
+			"-a", appName, "--yes",
+		}, " "))
+	}
+	return commands
+}
@@ -0,0 +1,131 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// VolumeSnapshots is a volume together with its snapshots, newest first
+type VolumeSnapshots struct {
+	Volume    MachineVolume    `json:"volume"`
+	Snapshots []VolumeSnapshot `json:"snapshots"`
+}
+
+// RestoreSnapshotRequest describes restoring a snapshot into a new volume.
+// Empty fields default to the source volume's name, region and size.
+type RestoreSnapshotRequest struct {
+	VolumeID   string
+	SnapshotID string
+	Name       string
+	Region     string
+	SizeGB     int
+}
+
+// ListSnapshots retrieves the snapshots of an app's volumes. If volumeID is
+// set only that volume is included.
+func (c *Client) ListSnapshots(ctx context.Context, appName, volumeID string) ([]VolumeSnapshots, error) {
+	volumes, err := c.findVolumes(ctx, appName, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]VolumeSnapshots, 0, len(volumes))
+	for _, v := range volumes {
+		snapshots, err := c.machinesClient.ListVolumeSnapshots(ctx, appName, v.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list snapshots for volume %s: %w", v.ID, err)
+		}
+		sort.Slice(snapshots, func(i, j int) bool {
+			return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+		})
+		result = append(result, VolumeSnapshots{Volume: v, Snapshots: snapshots})
+	}
+
+	return result, nil
+}
+
+// RestoreSnapshot creates a new volume from a snapshot of an existing volume.
+// The source volume is left untouched.
+func (c *Client) RestoreSnapshot(ctx context.Context, appName string, req RestoreSnapshotRequest) (*MachineVolume, error) {
+	volumes, err := c.findVolumes(ctx, appName, req.VolumeID)
+	if err != nil {
+		return nil, err
+	}
+	source := volumes[0]
+
+	snapshots, err := c.machinesClient.ListVolumeSnapshots(ctx, appName, source.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots for volume %s: %w", source.ID, err)
+	}
+
+	found := false
+	for _, s := range snapshots {
+		if s.ID == req.SnapshotID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, &FlyError{
+			StatusCode: http.StatusNotFound,
+			Code:       ErrorCodeNotFound,
+			Message:    fmt.Sprintf("snapshot %s not found for volume %s", req.SnapshotID, source.ID),
+		}
+	}
+
+	input := CreateVolumeRequest{
+		Name:       req.Name,
+		Region:     req.Region,
+		SizeGB:     req.SizeGB,
+		Encrypted:  source.Encrypted,
+		SnapshotID: req.SnapshotID,
+	}
+	if input.Name == "" {
+		input.Name = source.Name
+	}
+	if input.Region == "" {
+		input.Region = source.Region
+	}
+	if input.SizeGB == 0 {
+		input.SizeGB = source.SizeGB
+	}
+	if input.SizeGB < source.SizeGB {
+		return nil, &FlyError{
+			StatusCode: http.StatusUnprocessableEntity,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("size must be at least the source volume's %d GB", source.SizeGB),
+		}
+	}
+
+	volume, err := c.machinesClient.CreateVolume(ctx, appName, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore snapshot %s: %w", req.SnapshotID, err)
+	}
+
+	return volume, nil
+}
+
+// findVolumes lists an app's volumes, or only the one with volumeID if set
+func (c *Client) findVolumes(ctx context.Context, appName, volumeID string) ([]MachineVolume, error) {
+	volumes, err := c.machinesClient.ListVolumes(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes for app %s: %w", appName, err)
+	}
+
+	if volumeID == "" {
+		return volumes, nil
+	}
+
+	for _, v := range volumes {
+		if v.ID == volumeID {
+			return []MachineVolume{v}, nil
+		}
+	}
+	return nil, &FlyError{
+		StatusCode: http.StatusNotFound,
+		Code:       ErrorCodeNotFound,
+		Message:    fmt.Sprintf("volume %s not found in app %s", volumeID, appName),
+	}
+}
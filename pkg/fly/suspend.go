@@ -0,0 +1,172 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// Outcomes of stopping or starting one machine of a suspend or resume
+const (
+	MachineStopped = "stopped"
+	MachineStarted = "started"
+)
+
+// SuspendedMachine records what a suspend or resume did to one machine
+type SuspendedMachine struct {
+	MachineID string `json:"machineId"`
+	Region    string `json:"region"`
+	Action    string `json:"action"`
+	// Monthly is the machine's estimated monthly compute cost while running
+	Monthly float64 `json:"monthly"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// SuspendResult is the outcome of suspending or resuming an app
+type SuspendResult struct {
+	AppName  string             `json:"appName"`
+	Machines []SuspendedMachine `json:"machines"`
+	// MonthlyCompute is the estimated monthly compute cost of the machines
+	// that were stopped or started: saved while the app is suspended, spent
+	// again once it is resumed
+	MonthlyCompute float64 `json:"monthlyCompute"`
+	Duration       string  `json:"duration"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// Changed returns the IDs of the machines the suspend or resume stopped or
+// started
+func (r *SuspendResult) Changed() []string {
+	var ids []string
+	for _, m := range r.Machines {
+		if m.Action == MachineStopped || m.Action == MachineStarted {
+			ids = append(ids, m.MachineID)
+		}
+	}
+	return ids
+}
+
+// SuspendApp stops every started machine of an app, so that it is no longer
+// billed for compute. Scheduled tasks are left alone. Machines that fail to
+// stop are reported in the result rather than as an error.
+func (c *Client) SuspendApp(ctx context.Context, appName string) (*SuspendResult, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	var targets []Machine
+	for _, m := range machines {
+		if m.State == "started" && machineSchedule(m) == "" {
+			targets = append(targets, m)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("app %s has no started machines to suspend", appName),
+		}
+	}
+
+	return c.changeMachines(ctx, appName, targets, MachineStopped, c.machinesClient.StopMachine), nil
+}
+
+// ResumeApp starts the stopped machines of an app again. When machineIDs is
+// set only those are started, e.g. the machines an earlier suspend stopped;
+// otherwise every stopped machine except scheduled tasks is.
+func (c *Client) ResumeApp(ctx context.Context, appName string, machineIDs []string) (*SuspendResult, error) {
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines for app %s: %w", appName, err)
+	}
+
+	var targets []Machine
+	for _, m := range machines {
+		if m.State != "stopped" && m.State != "suspended" {
+			continue
+		}
+		if len(machineIDs) > 0 && !slices.Contains(machineIDs, m.ID) {
+			continue
+		}
+		if len(machineIDs) == 0 && machineSchedule(m) != "" {
+			continue
+		}
+		targets = append(targets, m)
+	}
+	if len(targets) == 0 {
+		return nil, &FlyError{
+			StatusCode: http.StatusBadRequest,
+			Code:       ErrorCodeInvalid,
+			Message:    fmt.Sprintf("app %s has no stopped machines to resume", appName),
+		}
+	}
+
+	return c.changeMachines(ctx, appName, targets, MachineStarted, c.machinesClient.StartMachine), nil
+}
+
+// changeMachines stops or starts machines concurrently with change and
+// records the outcome as action
+func (c *Client) changeMachines(ctx context.Context, appName string, targets []Machine, action string, change func(ctx context.Context, appName, machineID string) error) *SuspendResult {
+	start := time.Now()
+	pricing := c.config.Pricing
+
+	result := &SuspendResult{
+		AppName:  appName,
+		Machines: make([]SuspendedMachine, len(targets)),
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	for i, m := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			changed := SuspendedMachine{
+				MachineID: m.ID,
+				Region:    m.Region,
+				Action:    action,
+				Monthly:   EstimateMachineCost(pricing, m.Guest()),
+			}
+			if err := change(ctx, appName, m.ID); err != nil {
+				changed.Action = MachineFailed
+				changed.Error = err.Error()
+			}
+			result.Machines[i] = changed
+
+			mu.Lock()
+			done++
+			interfaces.ReportProgress(ctx, float64(done), float64(len(targets)), fmt.Sprintf("%s %d/%d machines", action, done, len(targets)))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, m := range result.Machines {
+		if m.Action == MachineFailed {
+			failed++
+			continue
+		}
+		result.MonthlyCompute += m.Monthly
+	}
+	if failed > 0 {
+		result.Error = fmt.Sprintf("%d of %d machines could not be %s", failed, len(targets), action)
+	}
+	result.Duration = time.Since(start).Round(time.Second).String()
+
+	c.logger.Info().
+		Str("app_name", appName).
+		Str("action", action).
+		Int("machines", len(targets)).
+		Int("failed", failed).
+		Msg("Changed app machines")
+
+	return result
+}
@@ -0,0 +1,343 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kinds of dependencies between apps
+const (
+	// DependencyInternalDNS is an environment variable naming another app's
+	// .internal address
+	DependencyInternalDNS = "internal_dns"
+	// DependencyFlycast is an environment variable naming another app's
+	// .flycast address
+	DependencyFlycast = "flycast"
+	// DependencyPublicURL is an environment variable naming another app's
+	// fly.dev hostname
+	DependencyPublicURL = "public_url"
+	// DependencyPostgres is an attached Postgres cluster
+	DependencyPostgres = "postgres"
+)
+
+// Roles of apps in the topology
+const (
+	RoleApp      = "app"
+	RolePostgres = "postgres"
+)
+
+// topologyConcurrency is how many apps are inspected at once
+const topologyConcurrency = 8
+
+// appHostPattern matches hostnames that address a Fly.io app, capturing the
+// app name and the domain. The app is the label right before the domain,
+// so names such as top1.nearest.of.<app>.internal match too.
+var appHostPattern = regexp.MustCompile(`([a-z0-9][a-z0-9-]*)\.(internal|flycast|fly\.dev)\b`)
+
+// TopologyApp is an app in the dependency graph
+type TopologyApp struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Machines int    `json:"machines"`
+	Volumes  int    `json:"volumes"`
+	VolumeGB int    `json:"volumeGb"`
+	// DependsOn are the apps this app uses; Dependents use this app
+	DependsOn  []string `json:"dependsOn"`
+	Dependents []string `json:"dependents"`
+}
+
+// TopologyEdge is a dependency of one app on another
+type TopologyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	// Evidence names the environment variables or secrets the dependency
+	// was found in; values are never included
+	Evidence []string `json:"evidence"`
+	// Inferred is set for dependencies guessed from a secret name rather
+	// than read from a hostname
+	Inferred bool `json:"inferred,omitempty"`
+}
+
+// Topology is the dependency graph of an organization's apps
+type Topology struct {
+	Organization string         `json:"organization,omitempty"`
+	Apps         []TopologyApp  `json:"apps"`
+	Edges        []TopologyEdge `json:"edges"`
+	Warnings     []string       `json:"warnings,omitempty"`
+}
+
+// appTopology is what one app reveals about its dependencies
+type appTopology struct {
+	node     TopologyApp
+	hosts    map[string][]string // hostname references by env var
+	secrets  []string
+	warnings []string
+}
+
+// GetTopology infers the dependencies between the apps of the configured
+// organization: environment variables naming another app's .internal,
+// .flycast or fly.dev address, and Postgres clusters attached through a
+// DATABASE_URL secret. Secret values cannot be read, so an attachment is
+// only inferred when the organization has a single Postgres app. include
+// decides which apps are visible to the caller.
+func (c *Client) GetTopology(ctx context.Context, include func(appName string) bool) (*Topology, error) {
+	apps, err := c.GetApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, topologyConcurrency)
+	found := make(map[string]*appTopology)
+	for _, app := range apps {
+		if include != nil && !include(app.Name) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			at := c.appTopology(ctx, app.Name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			found[app.Name] = at
+		}()
+	}
+	wg.Wait()
+
+	topology := &Topology{
+		Organization: c.config.Organization,
+		Apps:         []TopologyApp{},
+		Edges:        []TopologyEdge{},
+	}
+
+	names := make([]string, 0, len(found))
+	var postgres []string
+	for name, at := range found {
+		names = append(names, name)
+		if at.node.Role == RolePostgres {
+			postgres = append(postgres, name)
+		}
+		topology.Warnings = append(topology.Warnings, at.warnings...)
+	}
+	sort.Strings(names)
+	sort.Strings(postgres)
+
+	edges := make(map[[3]string]*TopologyEdge)
+	addEdge := func(from, to, kind, evidence string, inferred bool) {
+		key := [3]string{from, to, kind}
+		edge, ok := edges[key]
+		if !ok {
+			edge = &TopologyEdge{From: from, To: to, Kind: kind, Inferred: inferred}
+			edges[key] = edge
+		}
+		if !slices.Contains(edge.Evidence, evidence) {
+			edge.Evidence = append(edge.Evidence, evidence)
+		}
+	}
+
+	for _, name := range names {
+		at := found[name]
+		for host, vars := range at.hosts {
+			target, kind := hostDependency(host)
+			if target == name {
+				continue
+			}
+			if _, ok := found[target]; !ok {
+				if kind != DependencyPublicURL {
+					topology.Warnings = append(topology.Warnings, fmt.Sprintf("%s: %s names %s, which is not a visible app", name, strings.Join(vars, ", "), host))
+				}
+				continue
+			}
+			if found[target].node.Role == RolePostgres {
+				kind = DependencyPostgres
+			}
+			for _, v := range vars {
+				addEdge(name, target, kind, "env "+v, false)
+			}
+		}
+
+		if at.node.Role == RolePostgres {
+			continue
+		}
+		for _, secret := range at.secrets {
+			if secret != "DATABASE_URL" && !strings.HasSuffix(secret, "_DATABASE_URL") {
+				continue
+			}
+			if slices.ContainsFunc(postgres, func(pg string) bool { return edges[[3]string{name, pg, DependencyPostgres}] != nil }) {
+				continue
+			}
+			switch len(postgres) {
+			case 0:
+				topology.Warnings = append(topology.Warnings, fmt.Sprintf("%s: secret %s names a database, but no Postgres app is visible", name, secret))
+			case 1:
+				addEdge(name, postgres[0], DependencyPostgres, "secret "+secret, true)
+			default:
+				topology.Warnings = append(topology.Warnings, fmt.Sprintf("%s: secret %s names a database on one of %s", name, secret, strings.Join(postgres, ", ")))
+			}
+		}
+	}
+
+	for _, edge := range edges {
+		sort.Strings(edge.Evidence)
+		topology.Edges = append(topology.Edges, *edge)
+	}
+	sort.Slice(topology.Edges, func(i, j int) bool {
+		a, b := topology.Edges[i], topology.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+
+	for _, name := range names {
+		node := found[name].node
+		node.DependsOn = []string{}
+		node.Dependents = []string{}
+		for _, edge := range topology.Edges {
+			if edge.From == name && !slices.Contains(node.DependsOn, edge.To) {
+				node.DependsOn = append(node.DependsOn, edge.To)
+			}
+			if edge.To == name && !slices.Contains(node.Dependents, edge.From) {
+				node.Dependents = append(node.Dependents, edge.From)
+			}
+		}
+		topology.Apps = append(topology.Apps, node)
+	}
+	sort.Strings(topology.Warnings)
+
+	c.logger.Debug().
+		Int("apps", len(topology.Apps)).
+		Int("edges", len(topology.Edges)).
+		Msg("Collected app topology")
+
+	return topology, nil
+}
+
+// appTopology collects one app's machines, volumes, secret names and the
+// app hostnames its environment variables name
+func (c *Client) appTopology(ctx context.Context, appName string) *appTopology {
+	at := &appTopology{
+		node:  TopologyApp{Name: appName, Role: RoleApp},
+		hosts: make(map[string][]string),
+	}
+
+	machines, err := c.machinesClient.ListMachines(ctx, appName)
+	if err != nil {
+		at.warnings = append(at.warnings, fmt.Sprintf("%s: machines unavailable", appName))
+	}
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" {
+			continue
+		}
+		at.node.Machines++
+		if isPostgresMachine(m) {
+			at.node.Role = RolePostgres
+		}
+
+		vars, _ := m.Config["env"].(map[string]interface{})
+		for name, value := range vars {
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			s = strings.ToLower(s)
+			for _, loc := range appHostPattern.FindAllStringIndex(s, -1) {
+				// Names such as _apps.internal are services, not apps
+				if loc[0] > 0 && s[loc[0]-1] == '_' {
+					continue
+				}
+				match := s[loc[0]:loc[1]]
+				if !slices.Contains(at.hosts[match], name) {
+					at.hosts[match] = append(at.hosts[match], name)
+				}
+			}
+		}
+	}
+	for _, vars := range at.hosts {
+		sort.Strings(vars)
+	}
+
+	volumes, err := c.machinesClient.ListVolumes(ctx, appName)
+	if err != nil {
+		at.warnings = append(at.warnings, fmt.Sprintf("%s: volumes unavailable", appName))
+	}
+	for _, v := range volumes {
+		if v.State == "destroyed" || v.State == "pending_destroy" {
+			continue
+		}
+		at.node.Volumes++
+		at.node.VolumeGB += v.SizeGB
+	}
+
+	secrets, err := c.GetSecrets(ctx, appName)
+	if err != nil {
+		at.warnings = append(at.warnings, fmt.Sprintf("%s: secrets unavailable", appName))
+	}
+	for _, s := range secrets {
+		at.secrets = append(at.secrets, s.Name)
+	}
+
+	return at
+}
+
+// hostDependency returns the app a hostname addresses and the kind of
+// dependency naming it is
+func hostDependency(host string) (appName, kind string) {
+	m := appHostPattern.FindStringSubmatch(host)
+	switch m[2] {
+	case "internal":
+		return m[1], DependencyInternalDNS
+	case "flycast":
+		return m[1], DependencyFlycast
+	}
+	return m[1], DependencyPublicURL
+}
+
+// isPostgresMachine reports whether a machine is part of a Fly.io Postgres
+// cluster, by its metadata or its image
+func isPostgresMachine(m Machine) bool {
+	if metadata, ok := m.Config["metadata"].(map[string]interface{}); ok && metadata["fly-managed-postgres"] == "true" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(m.ImageRef.Repository), "postgres")
+}
+
+// BlastRadius returns the apps that depend on an app directly or through
+// other apps, sorted by name
+func (t *Topology) BlastRadius(appName string) []string {
+	dependents := make(map[string][]string)
+	for _, edge := range t.Edges {
+		dependents[edge.To] = append(dependents[edge.To], edge.From)
+	}
+
+	seen := map[string]bool{appName: true}
+	var affected []string
+	queue := []string{appName}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[next] {
+			if !seen[dependent] {
+				seen[dependent] = true
+				affected = append(affected, dependent)
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	sort.Strings(affected)
+	return affected
+}
@@ -0,0 +1,289 @@
+package fly
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/internal/tracing"
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/tokens"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// userAgent identifies fly-mcp to every Fly.io API
+	userAgent = "fly-mcp/0.1.0"
+
+	// maxAttempts is the number of tries for a retryable request
+	maxAttempts = 3
+
+	// maxRetryDelay caps both backoff and server-requested Retry-After delays
+	maxRetryDelay = 5 * time.Second
+)
+
+// idempotentKey marks a request context as safe to retry even though the
+// request method is not GET or HEAD (e.g. a read-only GraphQL query)
+type idempotentKey struct{}
+
+// withIdempotent marks requests made with ctx as safe to retry
+func withIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// tokenKey carries the API token of the token profile a call acts with
+type tokenKey struct{}
+
+// WithToken makes Fly.io API requests made with ctx authenticate with token
+// instead of the configured API token
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// authScheme applies an API token to a request
+type authScheme func(req *http.Request, token string)
+
+// apiTransport is the http.RoundTripper shared by every Fly.io API client.
+// It authenticates requests, retries transient failures of idempotent
+// requests and logs and traces each call with Fly's request ID.
+type apiTransport struct {
+	base   http.RoundTripper
+	api    string
+	retry  bool
+	logger *logger.Logger
+
+	// token is applied with auth unless the request context carries a
+	// profile's token. It is empty for the GraphQL client, which fly-go
+	// authenticates itself.
+	token string
+	auth  authScheme
+
+	// logCalls records each call with LogFlyAPICall. It is off for the
+	// GraphQL client, whose callers log the logical operation instead.
+	logCalls bool
+
+	// timeout bounds each attempt, unless the request context sets its own
+	// with withRequestTimeout; 0 leaves only the client's overall timeout
+	timeout time.Duration
+}
+
+// tokenAuth authenticates with the API token, using the FlyV1 scheme for
+// macaroon tokens such as deploy tokens and bearer auth otherwise
+func tokenAuth(req *http.Request, token string) {
+	req.Header.Set("Authorization", fly.AuthorizationHeader(tokens.StripAuthorizationScheme(token)))
+}
+
+// basicAuth authenticates with the API token as the basic auth password,
+// as the Docker registry expects
+func basicAuth(req *http.Request, token string) {
+	req.SetBasicAuth("x", token)
+}
+
+// newAPIHTTPClient returns an HTTP client for a Fly.io REST API
+func newAPIHTTPClient(api string, cfg *config.FlyConfig, auth authScheme, log *logger.Logger) *http.Client {
+	return &http.Client{
+		Timeout: time.Duration(cfg.Timeout) * time.Second,
+		Transport: &apiTransport{
+			base:     baseTransport(api, cfg),
+			api:      api,
+			token:    cfg.APIToken,
+			auth:     auth,
+			retry:    true,
+			logger:   log,
+			logCalls: true,
+			timeout:  requestTimeout(cfg),
+		},
+	}
+}
+
+// baseTransport returns the transport that carries a client's requests:
+// the shared connection pool, or the cassette the config records to or
+// replays from
+func baseTransport(api string, cfg *config.FlyConfig) http.RoundTripper {
+	pool := sharedTransport(cfg.HTTP)
+	if c := cassetteFor(cfg); c != nil {
+		return &cassetteTransport{cassette: c, api: api, base: pool}
+	}
+	return pool
+}
+
+// requestTimeout returns the configured timeout of each request attempt
+func requestTimeout(cfg *config.FlyConfig) time.Duration {
+	return time.Duration(cfg.HTTP.RequestTimeout) * time.Second
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	ctx, span := tracing.Tracer().Start(req.Context(), "fly."+t.api+" "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("fly.api", t.api),
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	defer span.End()
+
+	// Never modify the caller's request
+	req = req.Clone(ctx)
+	token := t.token
+	if profileToken, ok := ctx.Value(tokenKey{}).(string); ok && profileToken != "" {
+		token = profileToken
+	}
+	if t.auth != nil && token != "" {
+		t.auth(req, token)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	attempts := 1
+	if t.retry && isIdempotent(req) {
+		attempts = maxAttempts
+	}
+
+	timeout := t.timeout
+	if override, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok {
+		timeout = override
+	}
+
+	var resp *http.Response
+	var err error
+	attempt := 0
+	for {
+		attempt++
+		resp, err = t.roundTrip(req, timeout)
+		if attempt >= attempts || !shouldRetry(req, resp, err) {
+			break
+		}
+
+		delay := retryDelay(attempt, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		t.logger.Debug().
+			Str("api", t.api).
+			Str("method", req.Method).
+			Str("path", req.URL.Path).
+			Int("attempt", attempt).
+			Dur("delay", delay).
+			Msg("Retrying Fly.io API request")
+
+		select {
+		case <-req.Context().Done():
+			tracing.RecordError(span, req.Context().Err())
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req.Body = body
+		}
+	}
+
+	t.record(span, req, resp, err, attempt, time.Since(start))
+	return resp, err
+}
+
+// roundTrip makes one attempt of a request, failing it after timeout so
+// that a stalled attempt can be retried
+func (t *apiTransport) roundTrip(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// record logs a finished call and annotates its span
+func (t *apiTransport) record(span trace.Span, req *http.Request, resp *http.Response, err error, attempts int, duration time.Duration) {
+	span.SetAttributes(attribute.Int("fly.attempts", attempts))
+	switch {
+	case err != nil:
+		tracing.RecordError(span, err)
+	case resp.StatusCode >= 400:
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	default:
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	if resp != nil {
+		if flyRequestID := resp.Header.Get(requestIDHeader); flyRequestID != "" {
+			span.SetAttributes(attribute.String("fly.request_id", flyRequestID))
+		}
+	}
+
+	path := req.URL.Path
+	if t.logCalls {
+		t.logger.LogFlyAPICall(path, req.Method, getStatusCodeFromResp(resp, err), duration)
+	}
+
+	event := t.logger.Debug().
+		Str("api", t.api).
+		Str("method", req.Method).
+		Str("path", path).
+		Int("attempts", attempts).
+		Dur("duration", duration)
+	if requestID, ok := req.Context().Value("request_id").(string); ok && requestID != "" {
+		event = event.Str("request_id", requestID)
+	}
+	if resp != nil {
+		event = event.Str("fly_request_id", resp.Header.Get(requestIDHeader))
+	}
+	event.Msg("Fly.io API request finished")
+}
+
+// isIdempotent reports whether a request may be sent more than once
+func isIdempotent(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+	return marked && (req.Body == nil || req.GetBody != nil)
+}
+
+// shouldRetry reports whether a failed attempt is worth repeating
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, ErrNotRecorded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before the next attempt, honouring the
+// server's Retry-After header when present
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, maxRetryDelay)
+		}
+	}
+
+	backoff := 200 * time.Millisecond << (attempt - 1)
+	jitter := time.Duration(rand.Int64N(int64(backoff / 2)))
+	return min(backoff+jitter, maxRetryDelay)
+}
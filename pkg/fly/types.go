@@ -1,6 +1,7 @@
 package fly
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/superfly/fly-go"
@@ -16,7 +17,28 @@ type App struct {
 	AppURL       string                 `json:"appUrl"`
 	Organization *fly.OrganizationBasic `json:"organization,omitempty"`
 	CreatedAt    *time.Time             `json:"createdAt,omitempty"`
-	UpdatedAt    *time.Time             `json:"updatedAt,omitempty"`
+	// UpdatedAt is when the current release was created
+	UpdatedAt       *time.Time `json:"updatedAt,omitempty"`
+	Network         string     `json:"network,omitempty"`
+	PlatformVersion string     `json:"platformVersion,omitempty"`
+	// Regions, CurrentRelease and Services are only set by GetApp
+	Regions        []string     `json:"regions,omitempty"`
+	CurrentRelease *Release     `json:"currentRelease,omitempty"`
+	Services       []AppService `json:"services,omitempty"`
+}
+
+// AppService is a service the app exposes through the Fly.io proxy
+type AppService struct {
+	Description  string           `json:"description,omitempty"`
+	Protocol     string           `json:"protocol"`
+	InternalPort int              `json:"internalPort"`
+	Ports        []AppServicePort `json:"ports"`
+}
+
+// AppServicePort is a public port of a service and its handlers
+type AppServicePort struct {
+	Port     int      `json:"port"`
+	Handlers []string `json:"handlers"`
 }
 
 // AppStatus represents the current status of an application
@@ -100,6 +122,36 @@ type MachineCheck struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// UnmarshalJSON accepts both the Machines API form (updated_at) and our own
+// camelCase form of a health check
+func (c *MachineCheck) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name           string    `json:"name"`
+		Status         string    `json:"status"`
+		Output         string    `json:"output"`
+		UpdatedAt      time.Time `json:"updatedAt"`
+		UpdatedAtSnake time.Time `json:"updated_at"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	c.Name = raw.Name
+	c.Status = raw.Status
+	c.Output = raw.Output
+	c.UpdatedAt = raw.UpdatedAt
+	if c.UpdatedAt.IsZero() {
+		c.UpdatedAt = raw.UpdatedAtSnake
+	}
+
+	return nil
+}
+
+// IsPassing reports whether the check is currently passing
+func (c *MachineCheck) IsPassing() bool {
+	return c.Status == "passing"
+}
+
 // Volume represents a Fly.io volume
 type Volume struct {
 	ID                string    `json:"id"`
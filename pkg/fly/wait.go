@@ -0,0 +1,207 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Conditions fly_wait can wait for
+const (
+	WaitAppHealthy         = "app_healthy"
+	WaitMachineState       = "machine_state"
+	WaitCertificateIssued  = "certificate_issued"
+	WaitDeploymentFinished = "deployment_finished"
+)
+
+// WaitConditions lists the conditions CheckCondition knows
+var WaitConditions = []string{WaitAppHealthy, WaitMachineState, WaitCertificateIssued, WaitDeploymentFinished}
+
+// WaitCondition is a state of an app to wait for
+type WaitCondition struct {
+	Kind    string `json:"condition"`
+	AppName string `json:"appName"`
+	// MachineID and State are the machine and state of machine_state
+	MachineID string `json:"machineId,omitempty"`
+	State     string `json:"state,omitempty"`
+	// Hostname is the certificate's hostname of certificate_issued
+	Hostname string `json:"hostname,omitempty"`
+	// Version is the release of deployment_finished, or 0 for the latest
+	Version int `json:"version,omitempty"`
+}
+
+// ConditionStatus is one observation of a condition
+type ConditionStatus struct {
+	Met bool `json:"met"`
+	// Failed is set when the condition can no longer be met, such as when
+	// the release failed or the machine was destroyed
+	Failed bool   `json:"failed"`
+	Detail string `json:"detail"`
+}
+
+// CheckCondition observes whether a condition holds right now
+func (c *Client) CheckCondition(ctx context.Context, cond WaitCondition) (*ConditionStatus, error) {
+	switch cond.Kind {
+	case WaitAppHealthy:
+		return c.checkAppHealthy(ctx, cond.AppName)
+	case WaitMachineState:
+		return c.checkMachineState(ctx, cond.AppName, cond.MachineID, cond.State)
+	case WaitCertificateIssued:
+		return c.checkCertificate(ctx, cond.AppName, cond.Hostname)
+	case WaitDeploymentFinished:
+		return c.checkDeployment(ctx, cond.AppName, cond.Version)
+	}
+	return nil, fmt.Errorf("unknown condition %q", cond.Kind)
+}
+
+// checkAppHealthy holds when the app has started machines and every health
+// check on them passes
+func (c *Client) checkAppHealthy(ctx context.Context, appName string) (*ConditionStatus, error) {
+	machines, err := c.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	total, started := 0, 0
+	var failing []string
+	for _, m := range machines {
+		if m.State == "destroyed" {
+			continue
+		}
+		total++
+		if m.State != "started" {
+			continue
+		}
+		started++
+		for _, check := range m.Checks {
+			if check.Status != "passing" {
+				failing = append(failing, fmt.Sprintf("%s on %s (%s)", check.Name, m.ID, check.Status))
+			}
+		}
+	}
+
+	status := &ConditionStatus{Detail: fmt.Sprintf("%d of %d machine(s) started", started, total)}
+	switch {
+	case total == 0:
+		status.Detail = "the app has no machines"
+	case started == 0:
+		status.Detail += "; waiting for a machine to start"
+	case len(failing) > 0:
+		status.Detail += fmt.Sprintf("; %d check(s) not passing: %s", len(failing), strings.Join(failing, ", "))
+	default:
+		status.Met = true
+		status.Detail += "; all health checks passing"
+	}
+	return status, nil
+}
+
+// checkMachineState holds when the machine is in the state. A destroyed
+// machine can reach no other state.
+func (c *Client) checkMachineState(ctx context.Context, appName, machineID, state string) (*ConditionStatus, error) {
+	machines, err := c.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range machines {
+		if m.ID != machineID {
+			continue
+		}
+		status := &ConditionStatus{
+			Met:    m.State == state,
+			Detail: fmt.Sprintf("machine %s is %s", machineID, m.State),
+		}
+		if !status.Met && m.State == "destroyed" {
+			status.Failed = true
+		}
+		return status, nil
+	}
+
+	if state == "destroyed" {
+		return &ConditionStatus{Met: true, Detail: fmt.Sprintf("machine %s is gone", machineID)}, nil
+	}
+	return &ConditionStatus{Failed: true, Detail: fmt.Sprintf("machine %s not found in app %s", machineID, appName)}, nil
+}
+
+// checkCertificate holds when the app's certificate for the hostname is
+// issued
+func (c *Client) checkCertificate(ctx context.Context, appName, hostname string) (*ConditionStatus, error) {
+	var certs struct {
+		Certificates struct {
+			Nodes []appCertificate `json:"nodes"`
+		} `json:"certificates"`
+	}
+
+	batch := c.newGraphQLBatch()
+	batch.Add("certs", `app(name: $appName) { certificates { nodes { hostname clientStatus isApex dnsValidationHostname dnsValidationTarget } } }`,
+		map[string]batchVar{"appName": {Type: "String!", Value: appName}}, &certs)
+	if err := batch.Run(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get certificates for app %s: %w", appName, err)
+	}
+	if err := batch.Err("certs"); err != nil {
+		return nil, fmt.Errorf("failed to get certificates for app %s: %w", appName, err)
+	}
+
+	for _, cert := range certs.Certificates.Nodes {
+		if !strings.EqualFold(cert.Hostname, hostname) {
+			continue
+		}
+		return &ConditionStatus{
+			Met:    cert.ClientStatus == "Ready",
+			Detail: fmt.Sprintf("certificate for %s is %s", cert.Hostname, cert.ClientStatus),
+		}, nil
+	}
+	return &ConditionStatus{
+		Failed: true,
+		Detail: fmt.Sprintf("app %s has no certificate for %s", appName, hostname),
+	}, nil
+}
+
+// checkDeployment holds when the release is no longer in progress. A release
+// that ended in failure can not finish successfully any more.
+func (c *Client) checkDeployment(ctx context.Context, appName string, version int) (*ConditionStatus, error) {
+	releases, err := c.GetReleases(ctx, appName, 25)
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return &ConditionStatus{Detail: fmt.Sprintf("app %s has no releases yet", appName)}, nil
+	}
+
+	release := releases[0]
+	if version > 0 {
+		found := false
+		for _, r := range releases {
+			if r.Version == version {
+				release, found = r, true
+				break
+			}
+		}
+		if !found {
+			if version > releases[0].Version {
+				return &ConditionStatus{Detail: fmt.Sprintf("release v%d has not started yet; latest is v%d", version, releases[0].Version)}, nil
+			}
+			return &ConditionStatus{Failed: true, Detail: fmt.Sprintf("release v%d not found among the recent releases", version)}, nil
+		}
+	}
+
+	status := &ConditionStatus{Detail: fmt.Sprintf("release v%d is %s", release.Version, release.Status)}
+	switch {
+	case release.InProgress:
+	case isFailedRelease(release.Status):
+		status.Failed = true
+	default:
+		status.Met = true
+	}
+	return status, nil
+}
+
+// isFailedRelease reports whether a release status means the deployment
+// did not go through
+func isFailedRelease(status string) bool {
+	switch strings.ToLower(status) {
+	case "failed", "cancelled", "canceled", "interrupted", "rolled_back":
+		return true
+	}
+	return false
+}
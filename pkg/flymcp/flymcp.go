@@ -0,0 +1,210 @@
+// Package flymcp embeds the fly-mcp MCP server in other Go programs. A
+// Server serves the MCP endpoint as an http.Handler that is mounted on the
+// program's own router:
+//
+//	cfg, err := config.Load()
+//	if err != nil {
+//		return err
+//	}
+//	srv, err := flymcp.NewBuilder(cfg).
+//		WithLogger(log).
+//		WithTools(myTool).
+//		WithPath("/fly/mcp").
+//		Build()
+//	if err != nil {
+//		return err
+//	}
+//	srv.Mount(mux)
+//
+// Call Shutdown before shutting down the program's HTTP server.
+package flymcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/mcp"
+	"github.com/rs/zerolog"
+)
+
+// DefaultPath is where the MCP endpoint is mounted unless WithPath says
+// otherwise
+const DefaultPath = "/mcp"
+
+// Mux is a router the MCP endpoint can be mounted on, such as
+// http.ServeMux
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Builder configures a Server
+type Builder struct {
+	config *config.Config
+	logger *zerolog.Logger
+	tools  []interfaces.Tool
+	path   string
+}
+
+// NewBuilder starts building a Server from a configuration, e.g. one from
+// config.Load or config.Defaults. The embedding program runs the HTTP
+// server, so most of the server section is ignored. Two parts still apply:
+// server.limits must be valid, and max_body_bytes bounds MCP request bodies;
+// server.proxy says whose Fly-Client-IP header names the client.
+func NewBuilder(cfg *config.Config) *Builder {
+	return &Builder{config: cfg, path: DefaultPath}
+}
+
+// WithLogger logs through l instead of the output configured in logging.
+// Fields named in logging.redact_keys are only masked in the configured
+// output, so l must not write tokens anywhere they should not end up.
+func (b *Builder) WithLogger(l zerolog.Logger) *Builder {
+	b.logger = &l
+	return b
+}
+
+// WithTools offers tools next to the built-in ones. Tools implementing
+// interfaces.PermissionedTool are subject to token permissions and
+// policies like the built-in tools, and mcp.tools applies to all of them.
+func (b *Builder) WithTools(tools ...interfaces.Tool) *Builder {
+	b.tools = append(b.tools, tools...)
+	return b
+}
+
+// WithPath sets the path Mount serves the MCP endpoint on
+func (b *Builder) WithPath(path string) *Builder {
+	b.path = path
+	return b
+}
+
+// Build resolves the Fly.io token, validates the configuration and creates
+// the Server
+func (b *Builder) Build() (*Server, error) {
+	if b.config == nil {
+		return nil, fmt.Errorf("configuration is required")
+	}
+	if !strings.HasPrefix(b.path, "/") {
+		return nil, fmt.Errorf("path %q must start with /", b.path)
+	}
+
+	if err := b.config.ResolveTokens(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to resolve Fly.io token: %w", err)
+	}
+	if err := b.config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	var log *logger.Logger
+	if b.logger != nil {
+		log = &logger.Logger{Logger: b.logger}
+	} else {
+		var err error
+		if log, err = logger.New(b.config.Logging); err != nil {
+			return nil, fmt.Errorf("failed to create logger: %w", err)
+		}
+	}
+
+	registry := metrics.NewRegistry()
+	registry.Register("fly_mcp_requests_total", metrics.KindCounter, "Total number of MCP requests")
+
+	handler, err := mcp.NewHandlerWithTools(b.config, log, registry, b.tools)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MCP handler: %w", err)
+	}
+
+	return &Server{
+		handler: handler,
+		logger:  log,
+		metrics: registry,
+		path:    b.path,
+	}, nil
+}
+
+// Server is an embedded fly-mcp MCP endpoint
+type Server struct {
+	handler *mcp.Handler
+	logger  *logger.Logger
+	metrics *metrics.Registry
+	path    string
+}
+
+// ServeHTTP serves the MCP endpoint: POST carries requests, GET opens the
+// notification stream and DELETE ends a session
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		start := time.Now()
+		s.metrics.Inc("fly_mcp_requests_total", nil)
+
+		if err := s.handler.HandleRequest(w, r); err != nil {
+			s.logger.Error().
+				Err(err).
+				Dur("duration", time.Since(start)).
+				Msg("MCP request failed")
+
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+	case http.MethodGet:
+		s.handler.StreamNotifications(w, r)
+	case http.MethodDelete:
+		s.handler.EndSession(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Mount serves the MCP endpoint on mux at the configured path
+func (s *Server) Mount(mux Mux) {
+	mux.Handle(s.path, s)
+}
+
+// Path returns the path Mount serves the MCP endpoint on
+func (s *Server) Path() string {
+	return s.path
+}
+
+// Handler returns the MCP handler, e.g. for its Fly.io client or its
+// readiness probe
+func (s *Server) Handler() *mcp.Handler {
+	return s.handler
+}
+
+// Tools returns the tool registry. Tools may be registered and unregistered
+// while the server runs.
+func (s *Server) Tools() *interfaces.ToolRegistry {
+	return s.handler.Tools()
+}
+
+// MetricsHandler serves the server's metrics in the Prometheus text format
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := s.metrics.WritePrometheus(w); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to write metrics response")
+		}
+	})
+}
+
+// Shutdown stops accepting tool calls, ends notification streams, waits for
+// in-flight mutating calls and delivers pending webhook notifications. Call
+// it before shutting down the HTTP server the endpoint is mounted on, which
+// would otherwise wait on the streams.
+func (s *Server) Shutdown(ctx context.Context) error {
+	drainErr := s.handler.Drain(ctx)
+
+	if err := s.handler.Close(ctx); err != nil {
+		s.logger.Warn().Err(err).Msg("Some webhook notifications, audit events or metrics were not delivered")
+	}
+
+	if drainErr != nil {
+		return fmt.Errorf("shutdown incomplete: %w", drainErr)
+	}
+	return nil
+}
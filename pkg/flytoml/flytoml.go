@@ -0,0 +1,335 @@
+package flytoml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+// Config represents the contents of a fly.toml deployment configuration
+type Config struct {
+	App           string            `toml:"app"`
+	PrimaryRegion string            `toml:"primary_region,omitempty"`
+	KillSignal    string            `toml:"kill_signal,omitempty"`
+	KillTimeout   interface{}       `toml:"kill_timeout,omitempty"` // seconds or duration string
+	SwapSizeMB    int               `toml:"swap_size_mb,omitempty"`
+	Build         *Build            `toml:"build,omitempty"`
+	Deploy        *Deploy           `toml:"deploy,omitempty"`
+	Env           map[string]string `toml:"env,omitempty"`
+	HTTPService   *HTTPService      `toml:"http_service,omitempty"`
+	Services      []Service         `toml:"services,omitempty"`
+	Mounts        []Mount           `toml:"mounts,omitempty"`
+	Checks        map[string]Check  `toml:"checks,omitempty"`
+	Metrics       *Metrics          `toml:"metrics,omitempty"`
+	VM            []VM              `toml:"vm,omitempty"`
+	Processes     map[string]string `toml:"processes,omitempty"`
+}
+
+// Build represents the [build] section
+type Build struct {
+	Dockerfile string            `toml:"dockerfile,omitempty"`
+	Image      string            `toml:"image,omitempty"`
+	Builder    string            `toml:"builder,omitempty"`
+	Args       map[string]string `toml:"args,omitempty"`
+}
+
+// Deploy represents the [deploy] section
+type Deploy struct {
+	Strategy       string `toml:"strategy,omitempty"`
+	ReleaseCommand string `toml:"release_command,omitempty"`
+	MaxUnavailable int    `toml:"max_unavailable,omitempty"`
+}
+
+// HTTPService represents the [http_service] shorthand section
+type HTTPService struct {
+	InternalPort       int          `toml:"internal_port"`
+	ForceHTTPS         bool         `toml:"force_https,omitempty"`
+	AutoStopMachines   string       `toml:"auto_stop_machines,omitempty"`
+	AutoStartMachines  *bool        `toml:"auto_start_machines,omitempty"`
+	MinMachinesRunning int          `toml:"min_machines_running,omitempty"`
+	Processes          []string     `toml:"processes,omitempty"`
+	Concurrency        *Concurrency `toml:"concurrency,omitempty"`
+	Checks             []HTTPCheck  `toml:"checks,omitempty"`
+}
+
+// Service represents a [[services]] entry
+type Service struct {
+	InternalPort       int          `toml:"internal_port"`
+	Protocol           string       `toml:"protocol"`
+	AutoStopMachines   string       `toml:"auto_stop_machines,omitempty"`
+	AutoStartMachines  *bool        `toml:"auto_start_machines,omitempty"`
+	MinMachinesRunning int          `toml:"min_machines_running,omitempty"`
+	Processes          []string     `toml:"processes,omitempty"`
+	Ports              []Port       `toml:"ports,omitempty"`
+	Concurrency        *Concurrency `toml:"concurrency,omitempty"`
+	TCPChecks          []TCPCheck   `toml:"tcp_checks,omitempty"`
+	HTTPChecks         []HTTPCheck  `toml:"http_checks,omitempty"`
+}
+
+// Port represents a [[services.ports]] entry
+type Port struct {
+	Port       int      `toml:"port"`
+	Handlers   []string `toml:"handlers,omitempty"`
+	ForceHTTPS bool     `toml:"force_https,omitempty"`
+}
+
+// Concurrency represents service concurrency limits
+type Concurrency struct {
+	Type      string `toml:"type,omitempty"`
+	SoftLimit int    `toml:"soft_limit,omitempty"`
+	HardLimit int    `toml:"hard_limit,omitempty"`
+}
+
+// TCPCheck represents a TCP health check on a service
+type TCPCheck struct {
+	Interval    string `toml:"interval,omitempty"`
+	Timeout     string `toml:"timeout,omitempty"`
+	GracePeriod string `toml:"grace_period,omitempty"`
+}
+
+// HTTPCheck represents an HTTP health check on a service
+type HTTPCheck struct {
+	Interval    string `toml:"interval,omitempty"`
+	Timeout     string `toml:"timeout,omitempty"`
+	GracePeriod string `toml:"grace_period,omitempty"`
+	Method      string `toml:"method,omitempty"`
+	Path        string `toml:"path,omitempty"`
+	Protocol    string `toml:"protocol,omitempty"`
+}
+
+// Mount represents a [[mounts]] entry
+type Mount struct {
+	Source      string `toml:"source"`
+	Destination string `toml:"destination"`
+}
+
+// Check represents a top-level [checks.<name>] machine check
+type Check struct {
+	Type        string `toml:"type"`
+	Port        int    `toml:"port,omitempty"`
+	Path        string `toml:"path,omitempty"`
+	Method      string `toml:"method,omitempty"`
+	Interval    string `toml:"interval,omitempty"`
+	Timeout     string `toml:"timeout,omitempty"`
+	GracePeriod string `toml:"grace_period,omitempty"`
+}
+
+// Metrics represents the [metrics] section
+type Metrics struct {
+	Port int    `toml:"port"`
+	Path string `toml:"path"`
+}
+
+// VM represents a [[vm]] sizing entry
+type VM struct {
+	Size     string      `toml:"size,omitempty"`
+	Memory   interface{} `toml:"memory,omitempty"` // megabytes or size string such as "1gb"
+	CPUKind  string      `toml:"cpu_kind,omitempty"`
+	CPUs     int         `toml:"cpus,omitempty"`
+	MemoryMB int         `toml:"memory_mb,omitempty"`
+}
+
+// ParseResult holds a parsed configuration together with any keys the
+// parser did not recognise
+type ParseResult struct {
+	Config      *Config
+	UnknownKeys []string
+}
+
+// Parse parses fly.toml content. Unknown keys do not cause an error; they
+// are reported in ParseResult.UnknownKeys so callers can surface them.
+func Parse(content []byte) (*ParseResult, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, fmt.Errorf("fly.toml content is empty")
+	}
+
+	var cfg Config
+	dec := toml.NewDecoder(bytes.NewReader(content))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(&cfg)
+	if err == nil {
+		return &ParseResult{Config: &cfg}, nil
+	}
+
+	var strictErr *toml.StrictMissingError
+	if !errors.As(err, &strictErr) {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, col := decodeErr.Position()
+			return nil, fmt.Errorf("invalid TOML at line %d, column %d: %s", row, col, decodeErr.Error())
+		}
+		return nil, fmt.Errorf("failed to parse fly.toml: %w", err)
+	}
+
+	// Re-decode leniently so known fields are still populated
+	cfg = Config{}
+	if err := toml.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse fly.toml: %w", err)
+	}
+
+	unknown := make([]string, 0, len(strictErr.Errors))
+	for _, e := range strictErr.Errors {
+		unknown = append(unknown, strings.Join(e.Key(), "."))
+	}
+	sort.Strings(unknown)
+
+	return &ParseResult{Config: &cfg, UnknownKeys: unknown}, nil
+}
+
+// Generate renders the configuration as fly.toml content
+func Generate(cfg *Config) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("# fly.toml app configuration file generated by fly-mcp\n")
+	buf.WriteString("#\n# See https://fly.io/docs/reference/configuration/ for information about this file.\n\n")
+
+	enc := toml.NewEncoder(&buf)
+	enc.SetIndentTables(true)
+	if err := enc.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to encode fly.toml: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// FromAppConfig converts an AppConfig into a fly.toml configuration
+func FromAppConfig(appCfg *fly.AppConfig) *Config {
+	cfg := &Config{
+		App:           appCfg.AppName,
+		PrimaryRegion: appCfg.PrimaryRegion,
+		Env:           appCfg.Env,
+	}
+
+	if appCfg.Build != nil {
+		cfg.Build = &Build{
+			Dockerfile: appCfg.Build.Dockerfile,
+			Image:      appCfg.Build.Image,
+			Args:       appCfg.Build.Args,
+		}
+	}
+
+	if appCfg.Deploy != nil {
+		cfg.Deploy = &Deploy{Strategy: appCfg.Deploy.Strategy}
+	}
+
+	if appCfg.Metrics != nil {
+		cfg.Metrics = &Metrics{Port: appCfg.Metrics.Port, Path: appCfg.Metrics.Path}
+	}
+
+	for _, m := range appCfg.Mounts {
+		cfg.Mounts = append(cfg.Mounts, Mount{Source: m.Source, Destination: m.Destination})
+	}
+
+	for _, svc := range appCfg.Services {
+		service := Service{
+			InternalPort: svc.InternalPort,
+			Protocol:     svc.Protocol,
+		}
+		if service.Protocol == "" {
+			service.Protocol = "tcp"
+		}
+		for _, p := range svc.Ports {
+			service.Ports = append(service.Ports, Port{Port: p.Port, Handlers: p.Handlers})
+		}
+		for _, c := range svc.Checks {
+			switch c.Type {
+			case "http":
+				service.HTTPChecks = append(service.HTTPChecks, HTTPCheck{
+					Interval: c.Interval,
+					Timeout:  c.Timeout,
+					Method:   c.Method,
+					Path:     c.Path,
+				})
+			default:
+				service.TCPChecks = append(service.TCPChecks, TCPCheck{
+					Interval: c.Interval,
+					Timeout:  c.Timeout,
+				})
+			}
+		}
+		cfg.Services = append(cfg.Services, service)
+	}
+
+	return cfg
+}
+
+// ToAppConfig converts a fly.toml configuration into an AppConfig
+func (c *Config) ToAppConfig() *fly.AppConfig {
+	appCfg := &fly.AppConfig{
+		AppName:       c.App,
+		PrimaryRegion: c.PrimaryRegion,
+		Env:           c.Env,
+	}
+
+	if c.Build != nil {
+		appCfg.Build = &fly.BuildConfig{
+			Dockerfile: c.Build.Dockerfile,
+			Image:      c.Build.Image,
+			Args:       c.Build.Args,
+		}
+	}
+
+	if c.Deploy != nil {
+		appCfg.Deploy = &fly.DeployConfig{Strategy: c.Deploy.Strategy}
+	}
+
+	if c.Metrics != nil {
+		appCfg.Metrics = &fly.MetricsConfig{Port: c.Metrics.Port, Path: c.Metrics.Path}
+	}
+
+	for _, m := range c.Mounts {
+		appCfg.Mounts = append(appCfg.Mounts, fly.MountConfig{Source: m.Source, Destination: m.Destination})
+	}
+
+	if c.HTTPService != nil {
+		svc := fly.ServiceConfig{
+			InternalPort: c.HTTPService.InternalPort,
+			Protocol:     "tcp",
+			Ports: []fly.PortConfig{
+				{Port: 80, Handlers: []string{"http"}},
+				{Port: 443, Handlers: []string{"tls", "http"}},
+			},
+		}
+		for _, hc := range c.HTTPService.Checks {
+			svc.Checks = append(svc.Checks, fly.CheckConfig{
+				Type:     "http",
+				Path:     hc.Path,
+				Interval: hc.Interval,
+				Timeout:  hc.Timeout,
+				Method:   hc.Method,
+			})
+		}
+		appCfg.Services = append(appCfg.Services, svc)
+	}
+
+	for _, s := range c.Services {
+		svc := fly.ServiceConfig{
+			InternalPort: s.InternalPort,
+			Protocol:     s.Protocol,
+		}
+		for _, p := range s.Ports {
+			svc.Ports = append(svc.Ports, fly.PortConfig{Port: p.Port, Handlers: p.Handlers})
+		}
+		for _, tc := range s.TCPChecks {
+			svc.Checks = append(svc.Checks, fly.CheckConfig{Type: "tcp", Interval: tc.Interval, Timeout: tc.Timeout})
+		}
+		for _, hc := range s.HTTPChecks {
+			svc.Checks = append(svc.Checks, fly.CheckConfig{
+				Type:     "http",
+				Path:     hc.Path,
+				Interval: hc.Interval,
+				Timeout:  hc.Timeout,
+				Method:   hc.Method,
+			})
+		}
+		appCfg.Services = append(appCfg.Services, svc)
+	}
+
+	return appCfg
+}
@@ -0,0 +1,273 @@
+package flytoml
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultProcessGroup is the process group of apps without [processes]
+const DefaultProcessGroup = "app"
+
+// vmSizePattern matches the names of machine size presets
+var vmSizePattern = regexp.MustCompile(`^(shared-cpu|performance)-(\d+)x$`)
+
+// ProcessGroups returns the process groups the configuration runs machines
+// in, sorted
+func (c *Config) ProcessGroups() []string {
+	if len(c.Processes) == 0 {
+		return []string{DefaultProcessGroup}
+	}
+	groups := make([]string, 0, len(c.Processes))
+	for group := range c.Processes {
+		groups = append(groups, group)
+	}
+	slices.Sort(groups)
+	return groups
+}
+
+// MachineConfig returns the machine config, in the Machines API's shape,
+// that deploying the configuration gives machines of a process group: env,
+// services, checks, mounts, metrics, size and command. Settings fly.toml
+// does not set, such as the image of a Dockerfile build, are left out.
+// Numbers are float64, as in decoded API responses.
+func (c *Config) MachineConfig(group string) map[string]interface{} {
+	env := make(map[string]interface{}, len(c.Env))
+	for key, value := range c.Env {
+		env[key] = value
+	}
+
+	services := []interface{}{}
+	if hs := c.HTTPService; hs != nil && inGroup(hs.Processes, group) {
+		service := map[string]interface{}{
+			"protocol":      "tcp",
+			"internal_port": float64(hs.InternalPort),
+			"ports": []interface{}{
+				map[string]interface{}{"port": float64(80), "handlers": []interface{}{"http"}, "force_https": hs.ForceHTTPS},
+				map[string]interface{}{"port": float64(443), "handlers": []interface{}{"tls", "http"}},
+			},
+		}
+		addAutoscale(service, hs.AutoStopMachines, hs.AutoStartMachines, hs.MinMachinesRunning)
+		addConcurrency(service, hs.Concurrency)
+		var checks []interface{}
+		for _, hc := range hs.Checks {
+			checks = append(checks, httpCheck(hc))
+		}
+		if len(checks) > 0 {
+			service["checks"] = checks
+		}
+		services = append(services, service)
+	}
+	for _, s := range c.Services {
+		if !inGroup(s.Processes, group) {
+			continue
+		}
+		protocol := s.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		service := map[string]interface{}{
+			"protocol":      protocol,
+			"internal_port": float64(s.InternalPort),
+		}
+		var ports []interface{}
+		for _, p := range s.Ports {
+			port := map[string]interface{}{"port": float64(p.Port), "handlers": stringList(p.Handlers)}
+			if p.ForceHTTPS {
+				port["force_https"] = true
+			}
+			ports = append(ports, port)
+		}
+		if len(ports) > 0 {
+			service["ports"] = ports
+		}
+		addAutoscale(service, s.AutoStopMachines, s.AutoStartMachines, s.MinMachinesRunning)
+		addConcurrency(service, s.Concurrency)
+		var checks []interface{}
+		for _, tc := range s.TCPChecks {
+			checks = append(checks, withoutEmpty(map[string]interface{}{
+				"type":         "tcp",
+				"interval":     tc.Interval,
+				"timeout":      tc.Timeout,
+				"grace_period": tc.GracePeriod,
+			}))
+		}
+		for _, hc := range s.HTTPChecks {
+			checks = append(checks, httpCheck(hc))
+		}
+		if len(checks) > 0 {
+			service["checks"] = checks
+		}
+		services = append(services, service)
+	}
+
+	checks := make(map[string]interface{}, len(c.Checks))
+	for name, check := range c.Checks {
+		machineCheck := withoutEmpty(map[string]interface{}{
+			"type":         check.Type,
+			"path":         check.Path,
+			"method":       check.Method,
+			"interval":     check.Interval,
+			"timeout":      check.Timeout,
+			"grace_period": check.GracePeriod,
+		})
+		if check.Port > 0 {
+			machineCheck["port"] = float64(check.Port)
+		}
+		checks[name] = machineCheck
+	}
+
+	mounts := []interface{}{}
+	for _, m := range c.Mounts {
+		mounts = append(mounts, map[string]interface{}{"path": m.Destination, "name": m.Source})
+	}
+
+	machineConfig := map[string]interface{}{
+		"env":      env,
+		"services": services,
+		"checks":   checks,
+		"mounts":   mounts,
+	}
+
+	if c.Metrics != nil {
+		machineConfig["metrics"] = map[string]interface{}{"port": float64(c.Metrics.Port), "path": c.Metrics.Path}
+	}
+	if c.Build != nil && c.Build.Image != "" {
+		machineConfig["image"] = c.Build.Image
+	}
+	if c.KillSignal != "" {
+		machineConfig["stop_config"] = map[string]interface{}{"signal": c.KillSignal}
+	}
+	if guest := c.guest(); len(guest) > 0 {
+		machineConfig["guest"] = guest
+	}
+	if command := c.Processes[group]; command != "" {
+		machineConfig["init"] = map[string]interface{}{"cmd": stringList(strings.Fields(command))}
+	}
+
+	return machineConfig
+}
+
+// guest returns the machine size the [[vm]] section sets: a preset such as
+// shared-cpu-2x, adjusted by memory, cpus and cpu_kind
+func (c *Config) guest() map[string]interface{} {
+	guest := make(map[string]interface{})
+	for _, vm := range c.VM {
+		if m := vmSizePattern.FindStringSubmatch(vm.Size); m != nil {
+			cpus, _ := strconv.Atoi(m[2])
+			kind, perCPU := "shared", 256
+			if m[1] == "performance" {
+				kind, perCPU = "performance", 2048
+			}
+			guest["cpu_kind"] = kind
+			guest["cpus"] = float64(cpus)
+			guest["memory_mb"] = float64(cpus * perCPU)
+		}
+		if vm.CPUKind != "" {
+			guest["cpu_kind"] = vm.CPUKind
+		}
+		if vm.CPUs > 0 {
+			guest["cpus"] = float64(vm.CPUs)
+		}
+		if vm.MemoryMB > 0 {
+			guest["memory_mb"] = float64(vm.MemoryMB)
+		}
+		if mb, ok := memoryMB(vm.Memory); ok {
+			guest["memory_mb"] = float64(mb)
+		}
+	}
+	return guest
+}
+
+// memoryMB reads a memory size given in megabytes or as a string such as
+// 512mb or 1gb
+func memoryMB(value interface{}) (int, bool) {
+	switch val := value.(type) {
+	case int64:
+		return int(val), true
+	case string:
+		m := memoryPattern.FindStringSubmatch(strings.TrimSpace(val))
+		if m == nil {
+			return 0, false
+		}
+		n, _ := strconv.Atoi(m[1])
+		if strings.EqualFold(m[2], "gb") {
+			n *= 1024
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// inGroup reports whether a service limited to processes runs in group
+func inGroup(processes []string, group string) bool {
+	return len(processes) == 0 || slices.Contains(processes, group)
+}
+
+// addAutoscale adds the autostop, autostart and min_machines_running
+// settings a service sets
+func addAutoscale(service map[string]interface{}, autoStop string, autoStart *bool, minRunning int) {
+	switch strings.ToLower(autoStop) {
+	case "":
+	case "true":
+		service["autostop"] = "stop"
+	case "false":
+		service["autostop"] = "off"
+	default:
+		service["autostop"] = strings.ToLower(autoStop)
+	}
+	if autoStart != nil {
+		service["autostart"] = *autoStart
+	}
+	if minRunning > 0 {
+		service["min_machines_running"] = float64(minRunning)
+	}
+}
+
+// addConcurrency adds a service's concurrency limits
+func addConcurrency(service map[string]interface{}, c *Concurrency) {
+	if c == nil {
+		return
+	}
+	concurrency := withoutEmpty(map[string]interface{}{"type": c.Type})
+	if c.SoftLimit > 0 {
+		concurrency["soft_limit"] = float64(c.SoftLimit)
+	}
+	if c.HardLimit > 0 {
+		concurrency["hard_limit"] = float64(c.HardLimit)
+	}
+	service["concurrency"] = concurrency
+}
+
+// httpCheck converts an HTTP service check to a machine check
+func httpCheck(hc HTTPCheck) map[string]interface{} {
+	return withoutEmpty(map[string]interface{}{
+		"type":         "http",
+		"method":       hc.Method,
+		"path":         hc.Path,
+		"protocol":     hc.Protocol,
+		"interval":     hc.Interval,
+		"timeout":      hc.Timeout,
+		"grace_period": hc.GracePeriod,
+	})
+}
+
+// withoutEmpty drops the empty strings of a map
+func withoutEmpty(m map[string]interface{}) map[string]interface{} {
+	for key, value := range m {
+		if value == "" {
+			delete(m, key)
+		}
+	}
+	return m
+}
+
+// stringList converts strings to a JSON-style list
+func stringList(values []string) []interface{} {
+	list := make([]interface{}, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return list
+}
@@ -0,0 +1,283 @@
+package flytoml
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity describes how serious a validation issue is
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single validation finding
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Field    string   `json:"field"`
+	Message  string   `json:"message"`
+}
+
+var (
+	appNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
+	regionPattern  = regexp.MustCompile(`^[a-z]{3}$`)
+	memoryPattern  = regexp.MustCompile(`^(?i)(\d+)\s*(mb|gb)?$`)
+
+	validKillSignals = []string{"SIGINT", "SIGTERM", "SIGQUIT", "SIGUSR1", "SIGUSR2", "SIGKILL", "SIGSTOP"}
+	validHandlers    = []string{"http", "tls", "proxy_proto", "pg_tls", "edge_http"}
+	validProtocols   = []string{"tcp", "udp"}
+	validStrategies  = []string{"rolling", "immediate", "canary", "bluegreen"}
+	validAutoStop    = []string{"off", "stop", "suspend", "true", "false"}
+	validCPUKinds    = []string{"shared", "performance"}
+	validCheckTypes  = []string{"http", "tcp"}
+)
+
+// Validate checks the configuration for errors and likely mistakes
+func (c *Config) Validate() []Issue {
+	v := &validator{}
+
+	if c.App == "" {
+		v.errorf("app", "app name is required")
+	} else if !appNamePattern.MatchString(c.App) {
+		v.errorf("app", "'%s' is not a valid app name (3-63 lowercase letters, numbers and dashes)", c.App)
+	}
+
+	if c.PrimaryRegion == "" {
+		v.warnf("primary_region", "no primary region set; machines will be placed in the region closest to the deployer")
+	} else if !regionPattern.MatchString(c.PrimaryRegion) {
+		v.errorf("primary_region", "'%s' is not a valid region code (expected three lowercase letters, e.g. iad)", c.PrimaryRegion)
+	}
+
+	if c.KillSignal != "" && !contains(validKillSignals, c.KillSignal) {
+		v.errorf("kill_signal", "'%s' is not supported; use one of %s", c.KillSignal, strings.Join(validKillSignals, ", "))
+	}
+
+	if c.KillTimeout != nil {
+		v.checkSeconds("kill_timeout", c.KillTimeout)
+	}
+
+	if c.Build != nil {
+		if c.Build.Dockerfile != "" && c.Build.Image != "" {
+			v.warnf("build", "both dockerfile and image are set; image takes precedence and the dockerfile is ignored")
+		}
+	}
+
+	if c.Deploy != nil && c.Deploy.Strategy != "" && !contains(validStrategies, c.Deploy.Strategy) {
+		v.errorf("deploy.strategy", "'%s' is not a valid strategy; use one of %s", c.Deploy.Strategy, strings.Join(validStrategies, ", "))
+	}
+
+	if c.HTTPService == nil && len(c.Services) == 0 {
+		v.warnf("services", "no [http_service] or [[services]] defined; the app will not receive public traffic")
+	}
+
+	if c.HTTPService != nil {
+		v.checkPort("http_service.internal_port", c.HTTPService.InternalPort)
+		v.checkAutoStop("http_service.auto_stop_machines", c.HTTPService.AutoStopMachines)
+		if c.HTTPService.MinMachinesRunning < 0 {
+			v.errorf("http_service.min_machines_running", "must not be negative")
+		}
+		for i, hc := range c.HTTPService.Checks {
+			v.checkHTTPCheck(fmt.Sprintf("http_service.checks[%d]", i), hc)
+		}
+	}
+
+	seenPorts := make(map[int]string)
+	for i, svc := range c.Services {
+		field := fmt.Sprintf("services[%d]", i)
+		v.checkPort(field+".internal_port", svc.InternalPort)
+		if !contains(validProtocols, svc.Protocol) {
+			v.errorf(field+".protocol", "'%s' is not a valid protocol; use tcp or udp", svc.Protocol)
+		}
+		v.checkAutoStop(field+".auto_stop_machines", svc.AutoStopMachines)
+		if svc.MinMachinesRunning < 0 {
+			v.errorf(field+".min_machines_running", "must not be negative")
+		}
+		if len(svc.Ports) == 0 {
+			v.warnf(field+".ports", "service has no public ports and will only be reachable over the private network")
+		}
+		for j, p := range svc.Ports {
+			portField := fmt.Sprintf("%s.ports[%d]", field, j)
+			v.checkPort(portField+".port", p.Port)
+			if other, ok := seenPorts[p.Port]; ok {
+				v.errorf(portField+".port", "port %d is already exposed by %s", p.Port, other)
+			} else {
+				seenPorts[p.Port] = portField
+			}
+			for _, h := range p.Handlers {
+				if !contains(validHandlers, h) {
+					v.errorf(portField+".handlers", "'%s' is not a valid handler; use one of %s", h, strings.Join(validHandlers, ", "))
+				}
+			}
+		}
+		for j, tc := range svc.TCPChecks {
+			checkField := fmt.Sprintf("%s.tcp_checks[%d]", field, j)
+			v.checkDuration(checkField+".interval", tc.Interval)
+			v.checkDuration(checkField+".timeout", tc.Timeout)
+			v.checkDuration(checkField+".grace_period", tc.GracePeriod)
+		}
+		for j, hc := range svc.HTTPChecks {
+			v.checkHTTPCheck(fmt.Sprintf("%s.http_checks[%d]", field, j), hc)
+		}
+	}
+
+	seenDestinations := make(map[string]bool)
+	for i, m := range c.Mounts {
+		field := fmt.Sprintf("mounts[%d]", i)
+		if m.Source == "" {
+			v.errorf(field+".source", "volume source name is required")
+		}
+		if !strings.HasPrefix(m.Destination, "/") {
+			v.errorf(field+".destination", "destination must be an absolute path")
+		}
+		if seenDestinations[m.Destination] {
+			v.errorf(field+".destination", "'%s' is mounted more than once", m.Destination)
+		}
+		seenDestinations[m.Destination] = true
+	}
+	if len(c.Mounts) > 0 {
+		v.warnf("mounts", "apps with volumes cannot use bluegreen deploys and each machine needs its own volume")
+	}
+
+	for name, check := range c.Checks {
+		field := "checks." + name
+		if !contains(validCheckTypes, check.Type) {
+			v.errorf(field+".type", "'%s' is not a valid check type; use http or tcp", check.Type)
+		}
+		if check.Port != 0 {
+			v.checkPort(field+".port", check.Port)
+		}
+		if check.Type == "http" && check.Path == "" {
+			v.errorf(field+".path", "http checks require a path")
+		}
+		v.checkDuration(field+".interval", check.Interval)
+		v.checkDuration(field+".timeout", check.Timeout)
+		v.checkDuration(field+".grace_period", check.GracePeriod)
+	}
+
+	if c.Metrics != nil {
+		v.checkPort("metrics.port", c.Metrics.Port)
+		if !strings.HasPrefix(c.Metrics.Path, "/") {
+			v.errorf("metrics.path", "metrics path must start with /")
+		}
+	}
+
+	for i, vm := range c.VM {
+		field := fmt.Sprintf("vm[%d]", i)
+		if vm.CPUKind != "" && !contains(validCPUKinds, vm.CPUKind) {
+			v.errorf(field+".cpu_kind", "'%s' is not a valid CPU kind; use shared or performance", vm.CPUKind)
+		}
+		if vm.CPUs < 0 {
+			v.errorf(field+".cpus", "must not be negative")
+		}
+		if vm.Memory != nil {
+			v.checkMemory(field+".memory", vm.Memory)
+		}
+	}
+
+	return v.issues
+}
+
+// HasErrors reports whether any issue has error severity
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// validator collects issues while walking a configuration
+type validator struct {
+	issues []Issue
+}
+
+func (v *validator) errorf(field, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{Severity: SeverityError, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(field, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{Severity: SeverityWarning, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) checkPort(field string, port int) {
+	if port <= 0 || port > 65535 {
+		v.errorf(field, "port %d is out of range (1-65535)", port)
+	}
+}
+
+func (v *validator) checkDuration(field, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		v.errorf(field, "'%s' is not a valid duration (e.g. 10s, 1m)", value)
+	}
+}
+
+func (v *validator) checkAutoStop(field, value string) {
+	if value != "" && !contains(validAutoStop, value) {
+		v.errorf(field, "'%s' is not valid; use one of %s", value, strings.Join(validAutoStop, ", "))
+	}
+}
+
+func (v *validator) checkHTTPCheck(field string, hc HTTPCheck) {
+	if hc.Path != "" && !strings.HasPrefix(hc.Path, "/") {
+		v.errorf(field+".path", "path must start with /")
+	}
+	v.checkDuration(field+".interval", hc.Interval)
+	v.checkDuration(field+".timeout", hc.Timeout)
+	v.checkDuration(field+".grace_period", hc.GracePeriod)
+}
+
+func (v *validator) checkSeconds(field string, value interface{}) {
+	switch val := value.(type) {
+	case int64:
+		if val < 0 {
+			v.errorf(field, "must not be negative")
+		}
+	case string:
+		v.checkDuration(field, val)
+	default:
+		v.errorf(field, "must be a number of seconds or a duration string")
+	}
+}
+
+func (v *validator) checkMemory(field string, value interface{}) {
+	switch val := value.(type) {
+	case int64:
+		if val < 256 {
+			v.errorf(field, "memory must be at least 256 MB")
+		}
+	case string:
+		m := memoryPattern.FindStringSubmatch(strings.TrimSpace(val))
+		if m == nil {
+			v.errorf(field, "'%s' is not a valid memory size (e.g. 512mb, 1gb)", val)
+			return
+		}
+		n, _ := strconv.Atoi(m[1])
+		if strings.EqualFold(m[2], "gb") {
+			n *= 1024
+		}
+		if n < 256 {
+			v.errorf(field, "memory must be at least 256 MB")
+		}
+	default:
+		v.errorf(field, "must be a number of megabytes or a size string")
+	}
+}
+
+// contains checks if a slice contains a string
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,409 @@
+// Package github is a small client for the parts of the GitHub API that
+// fly-mcp deploys from: resolving a repository ref to a commit and running
+// and tracking the Actions workflow that builds the commit's image.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/config"
+)
+
+// ErrNotConfigured is returned when no GitHub token is configured
+var ErrNotConfigured = errors.New("the GitHub integration is not configured; set integrations.github.token")
+
+// repoPattern matches owner/name repository references
+var repoPattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+
+// shaPattern matches full and abbreviated commit SHAs
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// Client talks to the GitHub REST API
+type Client struct {
+	httpClient *http.Client
+	config     config.GitHubConfig
+	logger     *logger.Logger
+}
+
+// NewClient creates a GitHub client. It returns nil when no token is
+// configured.
+func NewClient(cfg config.GitHubConfig, log *logger.Logger) *Client {
+	if cfg.Token == "" {
+		return nil
+	}
+	return &Client{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		config:     cfg,
+		logger:     log,
+	}
+}
+
+// Configured reports whether the client can be used
+func (c *Client) Configured() bool {
+	return c != nil
+}
+
+// BuildTimeout returns how long a build may take
+func (c *Client) BuildTimeout() time.Duration {
+	return time.Duration(c.config.BuildTimeout) * time.Second
+}
+
+// Workflow returns the build workflow file
+func (c *Client) Workflow() string {
+	return c.config.Workflow
+}
+
+// CheckRepository rejects malformed repository references and repositories
+// outside integrations.github.repositories
+func (c *Client) CheckRepository(repo string) error {
+	if !repoPattern.MatchString(repo) {
+		return fmt.Errorf("invalid repository %q: use owner/name", repo)
+	}
+	if len(c.config.Repositories) == 0 {
+		return nil
+	}
+	for _, pattern := range c.config.Repositories {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(repo)); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("repository %s is not in integrations.github.repositories", repo)
+}
+
+// Commit is a commit a ref resolved to
+type Commit struct {
+	Repo string `json:"repo"`
+	// Ref is the branch, tag or SHA that was resolved
+	Ref     string    `json:"ref"`
+	SHA     string    `json:"sha"`
+	Message string    `json:"message"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	URL     string    `json:"url"`
+}
+
+// ShortSHA returns the commit's abbreviated SHA
+func (c *Commit) ShortSHA() string {
+	if len(c.SHA) > 7 {
+		return c.SHA[:7]
+	}
+	return c.SHA
+}
+
+// Subject returns the first line of the commit message
+func (c *Commit) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return subject
+}
+
+// ResolveRef resolves a branch, tag or SHA of a repository to a commit. An
+// empty ref is the default branch.
+func (c *Client) ResolveRef(ctx context.Context, repo, ref string) (*Commit, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	var commit struct {
+		SHA     string `json:"sha"`
+		HTMLURL string `json:"html_url"`
+		Commit  struct {
+			Message string `json:"message"`
+			Author  struct {
+				Name string    `json:"name"`
+				Date time.Time `json:"date"`
+			} `json:"author"`
+		} `json:"commit"`
+	}
+	if err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/commits/%s", repo, url.PathEscape(ref)), nil, &commit); err != nil {
+		return nil, fmt.Errorf("failed to resolve %s@%s: %w", repo, ref, err)
+	}
+
+	return &Commit{
+		Repo:    repo,
+		Ref:     ref,
+		SHA:     commit.SHA,
+		Message: commit.Commit.Message,
+		Author:  commit.Commit.Author.Name,
+		Date:    commit.Commit.Author.Date,
+		URL:     commit.HTMLURL,
+	}, nil
+}
+
+// defaultBranch returns a repository's default branch
+func (c *Client) defaultBranch(ctx context.Context, repo string) (string, error) {
+	var repository struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.do(ctx, "GET", "/repos/"+repo, nil, &repository); err != nil {
+		return "", fmt.Errorf("failed to get repository %s: %w", repo, err)
+	}
+	return repository.DefaultBranch, nil
+}
+
+// Build statuses of a workflow run
+const (
+	RunQueued     = "queued"
+	RunInProgress = "in_progress"
+	RunCompleted  = "completed"
+)
+
+// WorkflowRun is a run of the build workflow
+type WorkflowRun struct {
+	ID         int64     `json:"id"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion,omitempty"`
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// Succeeded reports whether the run completed successfully
+func (r *WorkflowRun) Succeeded() bool {
+	return r.Status == RunCompleted && r.Conclusion == "success"
+}
+
+// workflowRun is a run as the API returns it
+type workflowRun struct {
+	ID           int64     `json:"id"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	DisplayTitle string    `json:"display_title"`
+	HTMLURL      string    `json:"html_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// run converts the API's run
+func (r workflowRun) run() *WorkflowRun {
+	return &WorkflowRun{
+		ID:         r.ID,
+		Status:     r.Status,
+		Conclusion: r.Conclusion,
+		Title:      r.DisplayTitle,
+		URL:        r.HTMLURL,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+}
+
+// BuildRequest asks the build workflow to build and push a commit's image
+// with Fly.io's remote builders
+type BuildRequest struct {
+	Commit  *Commit
+	AppName string
+	// ImageLabel is the tag the image is pushed to registry.fly.io/<app>
+	// under
+	ImageLabel string
+}
+
+// Build is a tracked image build
+type Build struct {
+	Workflow string       `json:"workflow"`
+	Run      *WorkflowRun `json:"run,omitempty"`
+	Duration string       `json:"duration"`
+}
+
+// Build runs the build workflow for a commit and waits for it to finish.
+// The workflow is dispatched with the app, image_label and sha inputs and
+// must push registry.fly.io/<app>:<image_label>, e.g. with `flyctl deploy
+// --build-only --push --image-label`. Progress is reported through report.
+// The build is returned along with an error when it fails.
+func (c *Client) Build(ctx context.Context, req BuildRequest, report func(status string)) (*Build, error) {
+	start := time.Now()
+	repo := req.Commit.Repo
+	workflow := c.Workflow()
+	build := &Build{Workflow: workflow}
+
+	// Workflows can only be dispatched on a branch or tag; a SHA is built
+	// from the default branch's workflow, which checks out the sha input
+	dispatchRef := req.Commit.Ref
+	if dispatchRef == "HEAD" || shaPattern.MatchString(dispatchRef) {
+		branch, err := c.defaultBranch(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		dispatchRef = branch
+	}
+
+	dispatched := time.Now().Add(-5 * time.Second)
+	err := c.do(ctx, "POST", fmt.Sprintf("/repos/%s/actions/workflows/%s/dispatches", repo, url.PathEscape(workflow)), map[string]interface{}{
+		"ref": dispatchRef,
+		"inputs": map[string]string{
+			"app":         req.AppName,
+			"image_label": req.ImageLabel,
+			"sha":         req.Commit.SHA,
+		},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start workflow %s in %s: %w", workflow, repo, err)
+	}
+
+	c.logger.Info().
+		Str("repo", repo).
+		Str("workflow", workflow).
+		Str("sha", req.Commit.SHA).
+		Str("image_label", req.ImageLabel).
+		Msg("Dispatched build workflow")
+
+	timeout := c.BuildTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	poll := time.Duration(c.config.PollInterval) * time.Second
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	lastStatus := ""
+	for {
+		if build.Run == nil {
+			build.Run, err = c.findRun(ctx, repo, workflow, req.ImageLabel, dispatched)
+		} else {
+			build.Run, err = c.getRun(ctx, repo, build.Run.ID)
+		}
+		if err != nil {
+			c.logger.Warn().Err(err).Str("repo", repo).Msg("Failed to check build workflow, retrying")
+		}
+
+		status := RunQueued
+		if build.Run != nil {
+			status = build.Run.Status
+		}
+		if status != lastStatus {
+			lastStatus = status
+			report(status)
+		}
+		if build.Run != nil && build.Run.Status == RunCompleted {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			build.Duration = time.Since(start).Round(time.Second).String()
+			if build.Run == nil {
+				return build, fmt.Errorf("workflow %s did not start a run within %s", workflow, timeout)
+			}
+			return build, fmt.Errorf("build did not finish within %s; it is still %s at %s", timeout, build.Run.Status, build.Run.URL)
+		case <-ticker.C:
+		}
+	}
+
+	build.Duration = time.Since(start).Round(time.Second).String()
+	if !build.Run.Succeeded() {
+		return build, fmt.Errorf("build %s: see %s", build.Run.Conclusion, build.Run.URL)
+	}
+	return build, nil
+}
+
+// findRun finds the run a dispatch started: the run whose title names the
+// image label, or else the oldest dispatched run since then
+func (c *Client) findRun(ctx context.Context, repo, workflow, label string, since time.Time) (*WorkflowRun, error) {
+	query := url.Values{}
+	query.Set("event", "workflow_dispatch")
+	query.Set("created", ">="+since.UTC().Format(time.RFC3339))
+	query.Set("per_page", "20")
+
+	var runs struct {
+		WorkflowRuns []workflowRun `json:"workflow_runs"`
+	}
+	if err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/actions/workflows/%s/runs?%s", repo, url.PathEscape(workflow), query.Encode()), nil, &runs); err != nil {
+		return nil, err
+	}
+
+	var oldest *workflowRun
+	for i, run := range runs.WorkflowRuns {
+		if strings.Contains(run.DisplayTitle, label) {
+			return run.run(), nil
+		}
+		if oldest == nil || run.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = &runs.WorkflowRuns[i]
+		}
+	}
+	if oldest == nil {
+		return nil, nil
+	}
+	return oldest.run(), nil
+}
+
+// getRun retrieves a workflow run
+func (c *Client) getRun(ctx context.Context, repo string, id int64) (*WorkflowRun, error) {
+	var run workflowRun
+	if err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/actions/runs/%d", repo, id), nil, &run); err != nil {
+		return nil, err
+	}
+	return run.run(), nil
+}
+
+// Error is a failed GitHub API request
+type Error struct {
+	StatusCode int    `json:"statusCode"`
+	Message    string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("GitHub API: %s (status %d)", e.Message, e.StatusCode)
+}
+
+// do sends a request and decodes the response into out, if given
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.config.APIURL, "/")+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return &Error{StatusCode: resp.StatusCode, Message: apiErr.Message}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
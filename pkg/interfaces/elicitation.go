@@ -0,0 +1,54 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+)
+
+// Answers the user can give to an elicitation request
+const (
+	ElicitAccept  = "accept"
+	ElicitDecline = "decline"
+	ElicitCancel  = "cancel"
+)
+
+// ErrElicitationUnsupported is returned by Elicit when the client cannot be
+// asked for input, e.g. because it does not support elicitation
+var ErrElicitationUnsupported = errors.New("client cannot be asked for input")
+
+// ElicitResult is the user's answer to an elicitation request. Content holds
+// the values entered when Action is ElicitAccept.
+type ElicitResult struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// ElicitFunc asks the user for input through the client. schema is a flat
+// JSON schema object whose properties are strings, numbers, integers or
+// booleans.
+type ElicitFunc func(ctx context.Context, message string, schema map[string]interface{}) (ElicitResult, error)
+
+// elicitKey is the context key of the ElicitFunc
+type elicitKey struct{}
+
+// WithElicitation returns a context whose operations can ask the user for
+// input with fn
+func WithElicitation(ctx context.Context, fn ElicitFunc) context.Context {
+	return context.WithValue(ctx, elicitKey{}, fn)
+}
+
+// CanElicit reports whether the client of ctx can be asked for input
+func CanElicit(ctx context.Context) bool {
+	fn, ok := ctx.Value(elicitKey{}).(ElicitFunc)
+	return ok && fn != nil
+}
+
+// Elicit asks the user for input, or returns ErrElicitationUnsupported when
+// the client cannot be asked
+func Elicit(ctx context.Context, message string, schema map[string]interface{}) (ElicitResult, error) {
+	fn, ok := ctx.Value(elicitKey{}).(ElicitFunc)
+	if !ok || fn == nil {
+		return ElicitResult{}, ErrElicitationUnsupported
+	}
+	return fn(ctx, message, schema)
+}
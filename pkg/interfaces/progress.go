@@ -0,0 +1,46 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// ProgressFunc receives progress updates from a running tool. total is zero
+// when the amount of work is unknown.
+type ProgressFunc func(progress, total float64, message string)
+
+// progressKey is the context key of the ProgressFunc
+type progressKey struct{}
+
+// WithProgress returns a context whose operations report progress to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress reports progress to the caller if it asked for updates
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(progress, total, message)
+	}
+}
+
+// KeepAliveFunc lets the response to a running tool call stay open for d
+// from now, past the server's write timeout
+type KeepAliveFunc func(d time.Duration)
+
+// keepAliveKey is the context key of the KeepAliveFunc
+type keepAliveKey struct{}
+
+// WithKeepAlive returns a context whose operations can keep their response
+// open with fn
+func WithKeepAlive(ctx context.Context, fn KeepAliveFunc) context.Context {
+	return context.WithValue(ctx, keepAliveKey{}, fn)
+}
+
+// KeepAlive asks for the response to stay open for d from now, for tools
+// running longer than the server's write timeout
+func KeepAlive(ctx context.Context, d time.Duration) {
+	if fn, ok := ctx.Value(keepAliveKey{}).(KeepAliveFunc); ok && fn != nil {
+		fn(d)
+	}
+}
@@ -0,0 +1,136 @@
+package interfaces
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ToolRegistry holds the tools a server offers. Tools may be registered and
+// unregistered while the server runs; subscribers are told about each
+// change so clients can be sent notifications/tools/list_changed.
+type ToolRegistry struct {
+	mu          sync.RWMutex
+	tools       map[string]Tool
+	filter      func(name string) bool
+	subscribers []func()
+}
+
+// NewToolRegistry returns an empty registry
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]Tool)}
+}
+
+// Register adds a tool. A tool with the same name must be unregistered
+// first.
+func (r *ToolRegistry) Register(tool Tool) error {
+	name := tool.Name()
+	if name == "" {
+		return fmt.Errorf("tool name cannot be empty")
+	}
+
+	r.mu.Lock()
+	if _, exists := r.tools[name]; exists {
+		r.mu.Unlock()
+		return fmt.Errorf("tool %s is already registered", name)
+	}
+	r.tools[name] = tool
+	visible := r.visibleLocked(name)
+	r.mu.Unlock()
+
+	if visible {
+		r.changed()
+	}
+	return nil
+}
+
+// Unregister removes a tool, reporting whether it was registered
+func (r *ToolRegistry) Unregister(name string) bool {
+	r.mu.Lock()
+	_, exists := r.tools[name]
+	visible := exists && r.visibleLocked(name)
+	delete(r.tools, name)
+	r.mu.Unlock()
+
+	if visible {
+		r.changed()
+	}
+	return exists
+}
+
+// Get returns a tool by name. Tools the filter hides are not found.
+func (r *ToolRegistry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tool, ok := r.tools[name]
+	if !ok || !r.visibleLocked(name) {
+		return nil, false
+	}
+	return tool, true
+}
+
+// List returns the tools the filter lets through, sorted by name so that
+// paginated listings are stable
+func (r *ToolRegistry) List() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Tool, 0, len(r.tools))
+	for name, tool := range r.tools {
+		if r.visibleLocked(name) {
+			list = append(list, tool)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+	return list
+}
+
+// Names returns the names of every registered tool, including hidden ones
+func (r *ToolRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetFilter hides the tools for which allow returns false, e.g. those an
+// operator disabled. Hidden tools stay registered but are neither listed
+// nor returned by Get.
+func (r *ToolRegistry) SetFilter(allow func(name string) bool) {
+	r.mu.Lock()
+	r.filter = allow
+	r.mu.Unlock()
+
+	r.changed()
+}
+
+// Subscribe calls fn after every change to the listed tools
+func (r *ToolRegistry) Subscribe(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// visibleLocked reports whether the filter lets a tool through
+func (r *ToolRegistry) visibleLocked(name string) bool {
+	return r.filter == nil || r.filter(name)
+}
+
+// changed tells the subscribers the tool list changed
+func (r *ToolRegistry) changed() {
+	r.mu.RLock()
+	subscribers := append([]func(){}, r.subscribers...)
+	r.mu.RUnlock()
+
+	for _, fn := range subscribers {
+		fn()
+	}
+}
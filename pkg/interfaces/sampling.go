@@ -0,0 +1,52 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSamplingUnsupported is returned by Sample when the client cannot run
+// its model for the server
+var ErrSamplingUnsupported = errors.New("client does not support sampling")
+
+// SampleRequest asks the client's model to answer a prompt
+type SampleRequest struct {
+	SystemPrompt string
+	Prompt       string
+	// MaxTokens bounds the answer; zero uses the server's limit
+	MaxTokens int
+}
+
+// SampleResult is the answer of the client's model
+type SampleResult struct {
+	Text  string
+	Model string
+}
+
+// SampleFunc runs a prompt on the client's model
+type SampleFunc func(ctx context.Context, req SampleRequest) (SampleResult, error)
+
+// sampleKey is the context key of the SampleFunc
+type sampleKey struct{}
+
+// WithSampling returns a context whose operations can run prompts on the
+// client's model with fn
+func WithSampling(ctx context.Context, fn SampleFunc) context.Context {
+	return context.WithValue(ctx, sampleKey{}, fn)
+}
+
+// CanSample reports whether the client of ctx can run prompts on its model
+func CanSample(ctx context.Context) bool {
+	fn, ok := ctx.Value(sampleKey{}).(SampleFunc)
+	return ok && fn != nil
+}
+
+// Sample runs a prompt on the client's model, or returns
+// ErrSamplingUnsupported when the client cannot
+func Sample(ctx context.Context, req SampleRequest) (SampleResult, error) {
+	fn, ok := ctx.Value(sampleKey{}).(SampleFunc)
+	if !ok || fn == nil {
+		return SampleResult{}, ErrSamplingUnsupported
+	}
+	return fn(ctx, req)
+}
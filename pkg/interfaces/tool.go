@@ -2,8 +2,18 @@ package interfaces
 
 import (
 	"context"
+	"errors"
 )
 
+// ErrCancelled is the cancellation cause of a tool execution that the client
+// aborted with notifications/cancelled
+var ErrCancelled = errors.New("request cancelled by client")
+
+// ErrBudgetExhausted is the cancellation cause of a tool execution that ran
+// out of its time budget. Tools that work through many items can check for
+// it to return what they have so far.
+var ErrBudgetExhausted = errors.New("tool call ran out of time")
+
 // Tool represents an MCP tool that can be executed
 type Tool interface {
 	Name() string
@@ -12,15 +22,69 @@ type Tool interface {
 	Execute(ctx context.Context, args map[string]interface{}) (*ToolResult, error)
 }
 
+// PermissionedTool is implemented by tools that declare the permission they
+// check before executing, so callers can report which tools a user may invoke
+type PermissionedTool interface {
+	Tool
+	RequiredPermission() (action, resource string)
+}
+
+// ReadOnlyCallTool is implemented by tools whose permission allows changes
+// but that also have actions which only read, such as a status action
+type ReadOnlyCallTool interface {
+	Tool
+	ReadOnlyCall(args map[string]interface{}) bool
+}
+
 // ToolResult represents the result of a tool execution
 type ToolResult struct {
 	Content []ContentBlock `json:"content"`
-	IsError bool           `json:"isError,omitempty"`
+	// StructuredContent carries the result data as a JSON object so that
+	// clients can use it without parsing the text content
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
+	// Meta is protocol metadata about the call, such as its request ID
+	Meta map[string]interface{} `json:"_meta,omitempty"`
 }
 
-// ContentBlock represents a piece of content in a tool result
+// ContentBlock represents a piece of content in a tool result. Text blocks
+// set Text; resource links (type "resource_link") set URI and Name;
+// embedded resources (type "resource") set Resource.
 type ContentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-	Data string `json:"data,omitempty"`
+	Type        string            `json:"type"`
+	Text        string            `json:"text,omitempty"`
+	Data        string            `json:"data,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	MimeType    string            `json:"mimeType,omitempty"`
+	Resource    *ResourceContents `json:"resource,omitempty"`
+}
+
+// ResourceContents is the content of an embedded resource
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// ResourceLink returns a content block pointing at an MCP resource that the
+// client can read with resources/read
+func ResourceLink(uri, name, description string) ContentBlock {
+	return ContentBlock{
+		Type:        "resource_link",
+		URI:         uri,
+		Name:        name,
+		Description: description,
+		MimeType:    "application/json",
+	}
+}
+
+// EmbeddedResource returns a content block carrying a document, such as an
+// export file, in the result itself
+func EmbeddedResource(uri, mimeType, text string) ContentBlock {
+	return ContentBlock{
+		Type:     "resource",
+		Resource: &ResourceContents{URI: uri, MimeType: mimeType, Text: text},
+	}
 }
@@ -0,0 +1,426 @@
+// Package journal records the changes tools make to apps, with the state
+// before and after each one, and how to revert those that can be reverted.
+// fly_undo reads it to undo the last change of an app.
+package journal
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+// Kinds of operation
+const (
+	KindEnv       = "env"
+	KindDeploy    = "deploy"
+	KindAutoscale = "autoscale"
+	KindScale     = "scale"
+)
+
+// Revert is the change that reverts an operation: variables to set and
+// remove, an image to deploy, autoscaling settings to apply or a machine
+// count to restore
+type Revert struct {
+	EnvSet    map[string]string    `json:"envSet,omitempty"`
+	EnvUnset  []string             `json:"envUnset,omitempty"`
+	Image     string               `json:"image,omitempty"`
+	Autoscale *fly.AutoscaleUpdate `json:"autoscale,omitempty"`
+	Scale     *ScaleRevert         `json:"scale,omitempty"`
+}
+
+// ScaleRevert restores a machine count: by destroying the machines a scale
+// out created, or by cloning machines again up to Target after a scale in
+type ScaleRevert struct {
+	Region  string   `json:"region,omitempty"`
+	Target  int      `json:"target"`
+	Destroy []string `json:"destroy,omitempty"`
+}
+
+// Operation is one change a tool made to an app
+type Operation struct {
+	ID      string `json:"id"`
+	AppName string `json:"appName"`
+	Kind    string `json:"kind"`
+	Tool    string `json:"tool"`
+	User    string `json:"user"`
+	Summary string `json:"summary"`
+	// Status is succeeded, or failed for a change that stopped part way
+	Status string                 `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+	Before map[string]interface{} `json:"before"`
+	After  map[string]interface{} `json:"after"`
+	Revert *Revert                `json:"revert,omitempty"`
+	// Irreversible explains why an operation without a revert cannot be
+	// undone
+	Irreversible string `json:"irreversible,omitempty"`
+	// UndoOf is the operation an undo reverted, and UndoneBy the undo
+	// that reverted this one
+	UndoOf    string    `json:"undoOf,omitempty"`
+	UndoneBy  string    `json:"undoneBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Reversible reports whether fly_undo can revert the operation
+func (o Operation) Reversible() bool {
+	return o.Revert != nil
+}
+
+// Journal keeps the most recent operations in memory
+type Journal struct {
+	size int
+
+	mu   sync.Mutex
+	ops  []Operation
+	next int
+}
+
+// defaultSize is how many operations a journal keeps when no size is set
+const defaultSize = 200
+
+// New creates a journal that keeps the last size operations
+func New(size int) *Journal {
+	if size < 1 {
+		size = defaultSize
+	}
+	return &Journal{size: size}
+}
+
+// Record adds an operation, giving it an ID and time, and returns it
+func (j *Journal) Record(op Operation) Operation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.next++
+	op.ID = fmt.Sprintf("op-%d", j.next)
+	op.CreatedAt = time.Now()
+	j.ops = append([]Operation{op}, j.ops...)
+	if len(j.ops) > j.size {
+		j.ops = j.ops[:j.size]
+	}
+	return op
+}
+
+// Len returns the number of operations kept
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.ops)
+}
+
+// List returns the operations of an app, newest first
+func (j *Journal) List(appName string) []Operation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	ops := []Operation{}
+	for _, op := range j.ops {
+		if op.AppName == appName {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Get returns an operation by ID
+func (j *Journal) Get(id string) (Operation, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, op := range j.ops {
+		if op.ID == id {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+// Last returns the newest operation of an app that is neither an undo nor
+// undone, so that repeated undos walk back through the app's changes
+func (j *Journal) Last(appName string) (Operation, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, op := range j.ops {
+		if op.AppName == appName && op.UndoOf == "" && op.UndoneBy == "" {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+// MarkUndone records that an undo reverted an operation
+func (j *Journal) MarkUndone(id, undoID string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i := range j.ops {
+		if j.ops[i].ID == id {
+			j.ops[i].UndoneBy = undoID
+			return
+		}
+	}
+}
+
+// Later counts the changes to the app recorded after an operation that are
+// still in effect: undos and undone operations are left out
+func (j *Journal) Later(op Operation) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	n := 0
+	for _, o := range j.ops {
+		if o.ID == op.ID {
+			break
+		}
+		if o.AppName == op.AppName && o.UndoOf == "" && o.UndoneBy == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// EnvOperation describes an environment update. It returns nil when the
+// update changed no machine.
+func EnvOperation(result *fly.EnvUpdateResult) *Operation {
+	if len(result.Changes) == 0 || updatedMachines(result.Machines) == 0 {
+		return nil
+	}
+
+	op := &Operation{
+		AppName: result.AppName,
+		Kind:    KindEnv,
+		Status:  result.Status,
+		Error:   result.Error,
+		Before:  map[string]interface{}{},
+		After:   map[string]interface{}{},
+		Revert:  &Revert{EnvSet: map[string]string{}},
+	}
+	var names []string
+	for _, c := range result.Changes {
+		names = append(names, c.Change+" "+c.Name)
+		switch c.Change {
+		case fly.EnvAdded:
+			op.After[c.Name] = c.NewValue
+			op.Revert.EnvUnset = append(op.Revert.EnvUnset, c.Name)
+		case fly.EnvChanged:
+			op.Before[c.Name] = c.OldValue
+			op.After[c.Name] = c.NewValue
+			op.Revert.EnvSet[c.Name] = c.OldValue
+		case fly.EnvRemoved:
+			op.Before[c.Name] = c.OldValue
+			op.Revert.EnvSet[c.Name] = c.OldValue
+		}
+	}
+	op.Summary = "Environment: " + strings.Join(names, ", ")
+	return op
+}
+
+// DeployOperation describes a deploy. It returns nil when the deploy
+// changed no machine.
+func DeployOperation(result *fly.DeployResult) *Operation {
+	previous := make(map[string]int)
+	for _, m := range result.Machines {
+		if m.Action != "failed" && m.PreviousImage != "" {
+			previous[m.PreviousImage]++
+		}
+	}
+	if len(previous) == 0 && result.Status != fly.DeployRolledBack {
+		return nil
+	}
+
+	op := &Operation{
+		AppName: result.AppName,
+		Kind:    KindDeploy,
+		Status:  result.Status,
+		Error:   result.Error,
+		Summary: fmt.Sprintf("Deploy of %s (%s)", result.Image, result.Strategy),
+		Before:  map[string]interface{}{},
+		After:   map[string]interface{}{"image": result.Image},
+	}
+	images := make([]string, 0, len(previous))
+	for image := range previous {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	switch {
+	case result.Status == fly.DeployRolledBack:
+		op.Irreversible = "the health gate already rolled the machines back to their previous images"
+	case len(images) > 1:
+		op.Before["images"] = images
+		op.Irreversible = fmt.Sprintf("the machines ran different images before the deploy (%s); deploy the one you want with fly_deploy", strings.Join(images, ", "))
+	case images[0] == result.Image:
+		op.Before["image"] = images[0]
+		op.Irreversible = "the machines already ran this image before the deploy"
+	default:
+		op.Before["image"] = images[0]
+		op.Revert = &Revert{Image: images[0]}
+	}
+	return op
+}
+
+// AutoscaleOperation describes an autoscaling update. before is the policy
+// read just before the update, or nil if it could not be read. It returns
+// nil when the update changed no machine.
+func AutoscaleOperation(appName string, before *fly.AutoscalePolicy, update fly.AutoscaleUpdate, updated []string, err error) *Operation {
+	if len(updated) == 0 {
+		return nil
+	}
+
+	op := &Operation{
+		AppName: appName,
+		Kind:    KindAutoscale,
+		Status:  fly.DeploySucceeded,
+		Summary: "Autoscaling: " + describeAutoscale(update),
+		Before:  map[string]interface{}{},
+		After:   map[string]interface{}{},
+	}
+	if err != nil {
+		op.Status = fly.DeployFailed
+		op.Error = err.Error()
+	}
+	if update.AutoStop != nil {
+		op.After["autoStop"] = *update.AutoStop
+	}
+	if update.AutoStart != nil {
+		op.After["autoStart"] = *update.AutoStart
+	}
+	if update.MinMachinesRunning != nil {
+		op.After["minMachinesRunning"] = *update.MinMachinesRunning
+	}
+
+	if before == nil || len(before.Services) == 0 {
+		op.Irreversible = "the settings before the update could not be read"
+		return op
+	}
+
+	first := before.Services[0]
+	revert := fly.AutoscaleUpdate{}
+	var differing []string
+	for _, s := range before.Services[1:] {
+		if update.AutoStop != nil && s.AutoStop != first.AutoStop {
+			differing = append(differing, "autostop")
+		}
+		if update.AutoStart != nil && s.AutoStart != first.AutoStart {
+			differing = append(differing, "autostart")
+		}
+		if update.MinMachinesRunning != nil && s.MinMachinesRunning != first.MinMachinesRunning {
+			differing = append(differing, "min_machines_running")
+		}
+	}
+	if len(differing) > 0 {
+		slices.Sort(differing)
+		differing = slices.Compact(differing)
+		op.Irreversible = fmt.Sprintf("the services had different %s settings before the update", strings.Join(differing, " and "))
+		return op
+	}
+
+	if update.AutoStop != nil {
+		op.Before["autoStop"] = first.AutoStop
+		revert.AutoStop = &first.AutoStop
+	}
+	if update.AutoStart != nil {
+		op.Before["autoStart"] = first.AutoStart
+		revert.AutoStart = &first.AutoStart
+	}
+	if update.MinMachinesRunning != nil {
+		op.Before["minMachinesRunning"] = first.MinMachinesRunning
+		revert.MinMachinesRunning = &first.MinMachinesRunning
+	}
+	op.Revert = &Revert{Autoscale: &revert}
+	return op
+}
+
+// ScaleOperation describes a change of an app's machine count. It returns
+// nil when the scale created and destroyed no machine.
+func ScaleOperation(result *fly.ScaleResult) *Operation {
+	var created, withVolumes []string
+	for _, clone := range result.Created {
+		if clone.MachineID == "" {
+			continue
+		}
+		created = append(created, clone.MachineID)
+		if len(clone.Volumes) > 0 {
+			withVolumes = append(withVolumes, clone.MachineID)
+		}
+	}
+	if len(created) == 0 && len(result.Destroyed) == 0 {
+		return nil
+	}
+
+	where := ""
+	if result.Region != "" {
+		where = " in " + result.Region
+	}
+	after := result.Current + len(created) - len(result.Destroyed)
+	op := &Operation{
+		AppName: result.AppName,
+		Kind:    KindScale,
+		Status:  fly.DeploySucceeded,
+		Summary: fmt.Sprintf("Scale: %d → %d machines%s", result.Current, after, where),
+		Before:  map[string]interface{}{"machines": result.Current},
+		After:   map[string]interface{}{"machines": after},
+	}
+	if result.Error != "" {
+		op.Status = fly.DeployFailed
+		op.Error = result.Error
+	}
+
+	switch {
+	case len(created) > 0:
+		op.After["created"] = created
+		if len(withVolumes) > 0 {
+			op.Irreversible = fmt.Sprintf("the new machines %s mount volumes, whose data destroying them would lose", strings.Join(withVolumes, ", "))
+			return op
+		}
+		op.Revert = &Revert{Scale: &ScaleRevert{Region: result.Region, Target: result.Current, Destroy: created}}
+	default:
+		op.Before["destroyed"] = result.Destroyed
+		op.Revert = &Revert{Scale: &ScaleRevert{Region: result.Region, Target: result.Current}}
+	}
+	return op
+}
+
+// Permission returns the permission an operation of a kind needs, which
+// undoing it needs as well
+func Permission(kind string) (string, string) {
+	switch kind {
+	case KindEnv:
+		return "set", "env"
+	case KindDeploy:
+		return "deploy", "app"
+	default:
+		return "scale", "app"
+	}
+}
+
+// describeAutoscale lists the settings an autoscaling update sets
+func describeAutoscale(update fly.AutoscaleUpdate) string {
+	var parts []string
+	if update.AutoStop != nil {
+		parts = append(parts, "autostop "+*update.AutoStop)
+	}
+	if update.AutoStart != nil {
+		parts = append(parts, fmt.Sprintf("autostart %t", *update.AutoStart))
+	}
+	if update.MinMachinesRunning != nil {
+		parts = append(parts, fmt.Sprintf("min machines running %d", *update.MinMachinesRunning))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// updatedMachines counts the machines an update changed
+func updatedMachines(machines []fly.DeployedMachine) int {
+	n := 0
+	for _, m := range machines {
+		if m.Action != "failed" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,186 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// isBatch reports whether a request body is a JSON-RPC batch, an array of
+// requests
+func isBatch(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// handleBatch handles a JSON-RPC batch. The requests are handled
+// concurrently, up to mcp.batch.concurrency at a time, and answered with an
+// array of responses in the order of the requests. Notifications and
+// requests without an ID get no response; a batch of only those is answered
+// with 202 Accepted. initialize must be sent on its own, since it opens the
+// session the other requests join. Revision 2025-06-18 removed batching, so
+// clients speaking it or a later one are refused with Invalid Request.
+func (h *Handler) handleBatch(w http.ResponseWriter, r *http.Request, body []byte) error {
+	var messages []json.RawMessage
+	if err := json.Unmarshal(body, &messages); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to decode MCP batch request")
+		return h.sendError(w, codeParseError, "Parse error", nil)
+	}
+	if len(messages) == 0 {
+		return h.sendError(w, codeInvalidRequest, "Invalid Request", "empty batch")
+	}
+	if limit := h.config.MCP.Batch.MaxRequests; len(messages) > limit {
+		return h.sendError(w, codeInvalidRequest, "Invalid Request", fmt.Sprintf("batch of %d requests exceeds the limit of %d", len(messages), limit))
+	}
+
+	// Every request of the batch joins the session the client names
+	r, ok := h.resolveSession(w, r, &MCPRequest{})
+	if ok {
+		r, ok = h.resolveProtocolVersion(w, r)
+	}
+	if !ok {
+		return nil
+	}
+	if version := h.protocolVersion(r.Context()); supports(version, protocolNoBatching) {
+		return h.sendError(w, codeInvalidRequest, "Invalid Request", "protocol revision "+version+" does not support JSON-RPC batches")
+	}
+
+	h.logger.Debug().
+		Int("requests", len(messages)).
+		Msg("Handling MCP batch request")
+
+	responses := make([]*MCPResponse, len(messages))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, h.config.MCP.Batch.Concurrency)
+	for i, message := range messages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			responses[i] = h.handleBatchEntry(r, message, i+1)
+		}()
+	}
+	wg.Wait()
+
+	results := make([]*MCPResponse, 0, len(responses))
+	for _, response := range responses {
+		if response != nil {
+			results = append(results, response)
+		}
+	}
+	if len(results) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := writeMessage(w, results); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// handleBatchEntry handles one request of a batch and returns its response,
+// or nil if the request expects none. position numbers the request in the
+// batch, from 1. Progress notifications are not streamed for batched tool
+// calls.
+func (h *Handler) handleBatchEntry(r *http.Request, message json.RawMessage, position int) (response *MCPResponse) {
+	start := time.Now()
+
+	var req MCPRequest
+	if err := json.Unmarshal(message, &req); err != nil || req.Method == "" {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			Error:   &MCPError{Code: codeInvalidRequest, Message: "Invalid Request"},
+		}
+	}
+
+	r, requestID := h.withRequestID(r, position)
+	log := h.requestLogger(r.Context())
+	log.LogMCPRequest(req.Method, req.Params)
+
+	ctx, span := tracing.Tracer().Start(r.Context(), "mcp "+req.Method,
+		trace.WithAttributes(
+			attribute.String("mcp.method", req.Method),
+			attribute.String("mcp.request_id", requestID),
+			attribute.String("jsonrpc.request_id", fmt.Sprint(req.ID)),
+			attribute.Bool("jsonrpc.batch", true),
+		),
+	)
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	// Tool panics are recovered by executeTool; this keeps any other panic
+	// from taking the server down, since the batch runs outside the HTTP
+	// server's own recovery
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+
+		log.Error().
+			Str("method", req.Method).
+			Str("panic", fmt.Sprint(p)).
+			Str("stack", string(debug.Stack())).
+			Msg("MCP request panicked")
+
+		response = nil
+		if req.ID != nil {
+			response = &MCPResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   &MCPError{Code: codeInternalError, Message: "Internal error"},
+			}
+			annotateRequestID(response, requestID)
+		}
+	}()
+
+	if strings.HasPrefix(req.Method, "notifications/") {
+		h.handleNotification(r, &req)
+		log.LogMCPResponse(req.Method, true, time.Since(start))
+		return nil
+	}
+
+	if req.Method == "initialize" {
+		log.LogMCPResponse(req.Method, false, time.Since(start))
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &MCPError{
+				Code:    codeInvalidRequest,
+				Message: "Invalid Request",
+				Data:    "initialize must not be part of a batch",
+			},
+		}
+	}
+
+	response, err := h.dispatch(r, &req)
+	if err != nil {
+		tracing.RecordError(span, err)
+		log.LogMCPResponse(req.Method, false, time.Since(start))
+		response = errorResponse(&req, err)
+	} else {
+		log.LogMCPResponse(req.Method, true, time.Since(start))
+	}
+
+	if req.ID == nil {
+		return nil
+	}
+	annotateRequestID(response, requestID)
+	return response
+}
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// budgetGrace is how long a tool that keeps its response open may run past
+// the time it asked for, so that its own timeout ends the call first
+const budgetGrace = 10 * time.Second
+
+// toolBudget bounds the run time of one tool call. When it runs out, the
+// call's context is cancelled with interfaces.ErrBudgetExhausted.
+type toolBudget struct {
+	limit time.Duration
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	deadline time.Time
+}
+
+// toolTimeout returns the time budget of a call to a tool, or 0 for none
+func (h *Handler) toolTimeout(toolName string, mutating bool) time.Duration {
+	timeouts := h.config.MCP.ToolTimeouts
+	seconds := timeouts.Read
+	if mutating {
+		seconds = timeouts.Mutating
+	}
+	if override, ok := timeouts.Tools[toolName]; ok {
+		seconds = override
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// withBudget returns a context cancelled once limit has passed. Tools that
+// keep their response open with interfaces.KeepAlive extend the budget to
+// the time they ask for. The budget is nil when limit is 0.
+func withBudget(ctx context.Context, limit time.Duration) (context.Context, *toolBudget, context.CancelFunc) {
+	if limit <= 0 {
+		return ctx, nil, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	b := &toolBudget{limit: limit, deadline: time.Now().Add(limit)}
+	b.timer = time.AfterFunc(limit, func() {
+		cancel(fmt.Errorf("%w: budget of %s exhausted", interfaces.ErrBudgetExhausted, limit))
+	})
+
+	// Keep the response open as before, and the call running as long
+	parent := ctx
+	ctx = interfaces.WithKeepAlive(ctx, func(d time.Duration) {
+		interfaces.KeepAlive(parent, d)
+		b.extend(d + budgetGrace)
+	})
+
+	return ctx, b, func() {
+		b.timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// extend lets the call run for at least d from now
+func (b *toolBudget) extend(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	deadline := time.Now().Add(d)
+	if !deadline.After(b.deadline) {
+		return
+	}
+	// A timer that already fired has cancelled the call; it stays cancelled
+	if b.timer.Stop() {
+		b.deadline = deadline
+		b.timer.Reset(d)
+	}
+}
+
+// budgetExhaustedResult is the answer to a call stopped by its time budget:
+// what the tool returned, marked as possibly incomplete, or an error
+// explaining the stop when it returned nothing
+func budgetExhaustedResult(toolName string, limit time.Duration, result *interfaces.ToolResult) *interfaces.ToolResult {
+	if result == nil || len(result.Content) == 0 {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %s did not finish within its time budget of %s and was stopped before it had a result. Narrow the call, for example to fewer apps, or raise mcp.tool_timeouts for %s.", toolName, limit, toolName),
+			}},
+			IsError: true,
+		}
+	}
+
+	stopped := *result
+	stopped.Content = append(append([]interfaces.ContentBlock(nil), result.Content...), interfaces.ContentBlock{
+		Type: "text",
+		Text: fmt.Sprintf("[Stopped after the time budget of %s ran out: the result above may be incomplete. Narrow the call, or raise mcp.tool_timeouts for %s.]", limit, toolName),
+	})
+	return &stopped
+}
@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// errClientTimeout is returned when the client does not answer a request of
+// the server in time
+var errClientTimeout = errors.New("client did not answer in time")
+
+// clientResponse is a JSON-RPC response the client sends to a request of
+// the server
+type clientResponse struct {
+	ID     interface{}     `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *MCPError       `json:"error,omitempty"`
+}
+
+// pendingClientRequest is a request of the server waiting for the answer of
+// the client it was sent to
+type pendingClientRequest struct {
+	client string
+	answer chan clientResponse
+}
+
+// clientRequestStore routes the client's answers, such as those to
+// elicitation/create and sampling/createMessage, to the tool calls waiting
+// for them
+type clientRequestStore struct {
+	mu      sync.Mutex
+	pending map[string]pendingClientRequest
+}
+
+func newClientRequestStore() *clientRequestStore {
+	return &clientRequestStore{pending: make(map[string]pendingClientRequest)}
+}
+
+// len counts the requests waiting for the client's answer
+func (s *clientRequestStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// add registers a request and returns its ID and the channel receiving the
+// answer
+func (s *clientRequestStore) add(client string) (string, chan clientResponse) {
+	b := make([]byte, 8)
+	rand.Read(b)
+	id := "srv_" + hex.EncodeToString(b)
+	answer := make(chan clientResponse, 1)
+
+	s.mu.Lock()
+	s.pending[id] = pendingClientRequest{client: client, answer: answer}
+	s.mu.Unlock()
+	return id, answer
+}
+
+// remove forgets a request that was answered or given up on
+func (s *clientRequestStore) remove(id string) {
+	s.mu.Lock()
+	delete(s.pending, id)
+	s.mu.Unlock()
+}
+
+// deliver hands an answer to the request it belongs to. Answers from
+// another client than the one asked are refused.
+func (s *clientRequestStore) deliver(client string, response clientResponse) bool {
+	id, _ := response.ID.(string)
+
+	s.mu.Lock()
+	p, ok := s.pending[id]
+	if ok && p.client == client {
+		delete(s.pending, id)
+	}
+	s.mu.Unlock()
+
+	if !ok || p.client != client {
+		return false
+	}
+	p.answer <- response
+	return true
+}
+
+// requestClient sends a request to the client on the event stream of a
+// tool call and waits up to timeout for the result. The client answers
+// with a separate POST, which handleClientResponse routes back.
+func (h *Handler) requestClient(ctx context.Context, client string, stream *progressStream, method string, params interface{}, timeout time.Duration) (json.RawMessage, error) {
+	id, answer := h.clientRequests.add(client)
+	defer h.clientRequests.remove(id)
+
+	// The response stays open while the client answers
+	stream.extendDeadline(timeout + time.Duration(h.config.Server.WriteTimeout)*time.Second)
+
+	err := stream.send(MCPRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case response := <-answer:
+		if response.Error != nil {
+			return nil, fmt.Errorf("client failed %s request: %s", method, response.Error.Message)
+		}
+		return response.Result, nil
+	case <-timer.C:
+		return nil, errClientTimeout
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// handleClientResponse handles a JSON-RPC response from the client, the
+// answer to a request of the server
+func (h *Handler) handleClientResponse(r *http.Request, body []byte) {
+	var response clientResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		h.logger.Debug().Err(err).Msg("Failed to decode client response")
+		return
+	}
+
+	if !h.clientRequests.deliver(h.clientKey(r), response) {
+		h.logger.Debug().
+			Str("id", fmt.Sprint(response.ID)).
+			Msg("Client response matches no pending request")
+	}
+}
+
+// isClientResponse reports whether a message is a JSON-RPC response rather
+// than a request or notification
+func isClientResponse(body []byte) bool {
+	var message struct {
+		Method string          `json:"method"`
+		ID     interface{}     `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return false
+	}
+	return message.Method == "" && message.ID != nil && (message.Result != nil || message.Error != nil)
+}
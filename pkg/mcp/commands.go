@@ -0,0 +1,55 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// RunCommand runs a tool for a chat command, such as a Slack slash command,
+// the way tools/call would: with the caller's permissions, policies, rate
+// limits, confirmations and audit trail. The caller must be set on r with
+// auth.Manager.CreateAuditContext. Calls tools/call would answer with a
+// JSON-RPC error, such as rate-limited ones, return an error.
+func (h *Handler) RunCommand(r *http.Request, toolName string, arguments map[string]interface{}) (*interfaces.ToolResult, error) {
+	r, requestID := h.withRequestID(r, 0)
+	response, err := h.handleToolsCall(r, &MCPRequest{
+		JSONRPC: "2.0",
+		ID:      requestID,
+		Method:  "tools/call",
+		Params: map[string]interface{}{
+			"name":      toolName,
+			"arguments": arguments,
+		},
+	})
+
+	var result *interfaces.ToolResult
+	if response != nil {
+		result, _ = response.Result.(*interfaces.ToolResult)
+	}
+	outcome := "success"
+	switch {
+	case err != nil:
+		outcome = "rejected"
+	case result == nil:
+		err = fmt.Errorf("%s returned no result", toolName)
+		outcome = "failed"
+	case result.IsError:
+		outcome = "failed"
+	}
+	h.metrics.Inc("fly_mcp_commands_total", metrics.Labels{"tool": toolName, "result": outcome})
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CommandTools returns the tools chat commands may run for the caller in
+// ctx: those the Fly.io token can call
+func (h *Handler) CommandTools(ctx context.Context) []interfaces.Tool {
+	return h.availableTools(ctx)
+}
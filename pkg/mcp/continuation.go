@@ -0,0 +1,296 @@
+package mcp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// continuationArg is the tool argument that fetches the rest of a truncated
+// response
+const continuationArg = "continuation"
+
+// truncationReserve is the room kept in a truncated response for the note
+// explaining how to fetch the rest
+const truncationReserve = 512
+
+// pendingContinuation is the rest of a truncated response, waiting to be
+// fetched by the client it was sent to
+type pendingContinuation struct {
+	client    string
+	tool      string
+	text      string
+	isError   bool
+	expiresAt time.Time
+}
+
+// continuationStore holds the rest of truncated responses until they are
+// fetched or expire
+type continuationStore struct {
+	mu      sync.Mutex
+	pending map[string]pendingContinuation
+}
+
+func newContinuationStore() *continuationStore {
+	return &continuationStore{pending: make(map[string]pendingContinuation)}
+}
+
+// registerContinuationMetrics declares the counter of the responses
+// limitResponse truncates
+func registerContinuationMetrics(registry *metrics.Registry) {
+	registry.Register("fly_mcp_responses_truncated_total", metrics.KindCounter, "Tool responses truncated to the response size limit")
+}
+
+// len counts the responses waiting to be continued
+func (s *continuationStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// save stores the rest of a response and returns its continuation
+func (s *continuationStore) save(p pendingContinuation) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate continuation: %w", err)
+	}
+	token := "cont_" + hex.EncodeToString(b)
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for t, pending := range s.pending {
+		if now.After(pending.expiresAt) {
+			delete(s.pending, t)
+		}
+	}
+	s.pending[token] = p
+	return token, nil
+}
+
+// take returns and forgets the rest of a response. Continuations only work
+// for the client and tool they were issued to.
+func (s *continuationStore) take(token, client, tool string) (pendingContinuation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pending[token]
+	if !ok || p.client != client || p.tool != tool || time.Now().After(p.expiresAt) {
+		return pendingContinuation{}, false
+	}
+	delete(s.pending, token)
+	return p, true
+}
+
+// continuationTTL returns how long the rest of a truncated response is kept
+func (h *Handler) continuationTTL() time.Duration {
+	if h.config.MCP.ResponseLimits.ContinuationTTL <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(h.config.MCP.ResponseLimits.ContinuationTTL) * time.Second
+}
+
+// limitResponse truncates a tool result whose text exceeds the tool's
+// response limit. The rest is kept for the client to fetch with the
+// continuation the truncated text ends with.
+func (h *Handler) limitResponse(r *http.Request, toolName string, result *interfaces.ToolResult) *interfaces.ToolResult {
+	limit := h.config.MCP.ResponseLimits.Limit(toolName)
+	if result == nil || limit <= 0 {
+		return result
+	}
+
+	var texts []string
+	var others []interfaces.ContentBlock
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			texts = append(texts, block.Text)
+		} else {
+			others = append(others, block)
+		}
+	}
+	text := strings.Join(texts, "\n\n")
+	if len(text) <= limit {
+		return result
+	}
+
+	budget := max(limit-truncationReserve, limit/2, 1)
+	head, rest := splitResponse(text, budget)
+	if len(rest) >= len(text) {
+		// A limit too small for the repeated table header or code fence
+		// would hand out continuations that never get shorter
+		h.logger.Warn().
+			Str("tool", toolName).
+			Int("limit", limit).
+			Msg("Response limit too small to truncate the response; sending it whole")
+		return result
+	}
+	token, err := h.continuations.save(pendingContinuation{
+		client:    h.clientKey(r),
+		tool:      toolName,
+		text:      rest,
+		isError:   result.IsError,
+		expiresAt: time.Now().Add(h.continuationTTL()),
+	})
+	if err != nil {
+		h.logger.Error().
+			Err(err).
+			Str("tool", toolName).
+			Msg("Failed to truncate response")
+		return result
+	}
+
+	h.metrics.Inc("fly_mcp_responses_truncated_total", metrics.Labels{"tool": toolName})
+	h.logger.Debug().
+		Str("tool", toolName).
+		Int("size", len(text)).
+		Int("sent", len(head)).
+		Msg("Truncated tool response")
+
+	note := truncationNote(toolName, token, rest, h.continuationTTL())
+	if result.StructuredContent != nil {
+		note += " Structured content is not included in truncated responses."
+	}
+
+	content := []interfaces.ContentBlock{{Type: "text", Text: head + "\n\n" + note}}
+	return &interfaces.ToolResult{
+		Content: append(content, others...),
+		IsError: result.IsError,
+	}
+}
+
+// continueResponse answers a call passing a continuation with the next part
+// of the truncated response, without running the tool again
+func (h *Handler) continueResponse(r *http.Request, toolName, token string) *interfaces.ToolResult {
+	p, ok := h.continuations.take(token, h.clientKey(r), toolName)
+	if !ok {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: continuation %q is unknown, already used or expired. Call %s again without continuation to get a fresh response.", token, toolName),
+			}},
+			IsError: true,
+		}
+	}
+
+	return h.limitResponse(r, toolName, &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{Type: "text", Text: p.text}},
+		IsError: p.isError,
+	})
+}
+
+// splitResponse cuts text at about budget bytes. It prefers to cut between
+// paragraphs, then between lines, and keeps code blocks and tables readable
+// on both sides: an open code block is closed and reopened, and a table
+// cut in two repeats its header.
+func splitResponse(text string, budget int) (head, rest string) {
+	cut := min(budget, len(text))
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	if i := strings.LastIndex(text[:cut], "\n\n"); i >= cut/2 {
+		cut = i + 1
+	} else if i := strings.LastIndexByte(text[:cut], '\n'); i >= cut/2 {
+		cut = i + 1
+	}
+
+	head = strings.TrimRight(text[:cut], "\n")
+	rest = strings.TrimLeft(text[cut:], "\n")
+
+	if fence, open := openFence(head); open {
+		return head + "\n```", fence + "\n" + rest
+	}
+	if header := tableHeader(head); header != "" && strings.HasPrefix(rest, "|") {
+		rest = header + "\n" + rest
+	}
+	return head, rest
+}
+
+// openFence reports whether text ends inside a code block, and the line
+// that opened it
+func openFence(text string) (string, bool) {
+	fence, open := "", false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fence, open = strings.TrimSpace(line), !open
+		}
+	}
+	return fence, open
+}
+
+// tableHeader returns the header and rule rows of the markdown table text
+// ends in, or "" if it does not end in one
+func tableHeader(text string) string {
+	lines := strings.Split(text, "\n")
+	start := len(lines)
+	for start > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "|") {
+		start--
+	}
+	if len(lines)-start < 3 || !strings.Contains(lines[start+1], "---") {
+		return ""
+	}
+	return lines[start] + "\n" + lines[start+1]
+}
+
+// truncationNote summarizes what a truncated response left out and how to
+// fetch it
+func truncationNote(toolName, token, rest string, ttl time.Duration) string {
+	lines, items, rows := 0, 0, 0
+	inCode := false
+	for _, line := range strings.Split(rest, "\n") {
+		lines++
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inCode = !inCode
+		case inCode:
+		case strings.HasPrefix(trimmed, "|") && !strings.Contains(trimmed, "---"):
+			rows++
+		case strings.HasPrefix(trimmed, "- "):
+			items++
+		}
+	}
+
+	omitted := fmt.Sprintf("%d more lines (%.1f KB)", lines, float64(len(rest))/1024)
+	var parts []string
+	if items > 0 {
+		parts = append(parts, fmt.Sprintf("%d list items", items))
+	}
+	if rows > 0 {
+		parts = append(parts, fmt.Sprintf("%d table rows", rows))
+	}
+	if len(parts) > 0 {
+		omitted += " including " + strings.Join(parts, " and ")
+	}
+
+	expiry := fmt.Sprintf("%d seconds", int(ttl.Seconds()))
+	if ttl%time.Minute == 0 {
+		expiry = fmt.Sprintf("%d minutes", int(ttl.Minutes()))
+	}
+	return fmt.Sprintf("[Response truncated: %s omitted. Call %s with continuation %q to fetch the rest within %s.]",
+		omitted, toolName, token, expiry)
+}
+
+// continuationSchema adds the continuation argument to a tool's input schema
+func continuationSchema(schema map[string]interface{}) map[string]interface{} {
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return schema
+	}
+
+	extended := maps.Clone(schema)
+	extended["properties"] = maps.Clone(properties)
+	extended["properties"].(map[string]interface{})[continuationArg] = map[string]interface{}{
+		"type":        "string",
+		"description": "Continuation from the end of a truncated response; returns the next part of that response instead of running the tool again",
+	}
+	return extended
+}
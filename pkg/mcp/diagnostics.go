@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+// processStarted is when the process started, for the uptime of diagnostics
+var processStarted = time.Now()
+
+// Diagnostics is a snapshot of the server's runtime state, for diagnosing
+// performance issues of long-running deployments
+type Diagnostics struct {
+	CheckedAt     time.Time        `json:"checked_at"`
+	UptimeSeconds int64            `json:"uptime_seconds"`
+	Runtime       RuntimeStats     `json:"runtime"`
+	Calls         []DiagnosticCall `json:"calls"`
+	Sessions      SessionStats     `json:"sessions"`
+	Caches        map[string]int   `json:"caches"`
+	RateLimits    map[string]int   `json:"rate_limits,omitempty"`
+	Connections   fly.PoolStats    `json:"connections"`
+}
+
+// RuntimeStats describes the Go runtime: goroutines, memory and GC
+type RuntimeStats struct {
+	GoVersion    string `json:"go_version"`
+	Goroutines   int    `json:"goroutines"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	NumCPU       int    `json:"num_cpu"`
+	HeapAlloc    uint64 `json:"heap_alloc_bytes"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	Sys          uint64 `json:"sys_bytes"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalMs int64  `json:"gc_pause_total_ms"`
+}
+
+// DiagnosticCall is a tool call still running
+type DiagnosticCall struct {
+	Tool           string    `json:"tool"`
+	Mutating       bool      `json:"mutating"`
+	Started        time.Time `json:"started"`
+	RunningSeconds int64     `json:"running_seconds"`
+}
+
+// SessionStats counts the state kept for MCP clients
+type SessionStats struct {
+	Open           int `json:"open"`
+	Streams        int `json:"streams"`
+	Subscriptions  int `json:"subscriptions"`
+	ClientRequests int `json:"client_requests"`
+}
+
+// Diagnostics returns a snapshot of the runtime, the running calls, the
+// state kept in memory and the open Fly.io API connections
+func (h *Handler) Diagnostics() Diagnostics {
+	now := time.Now()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	d := Diagnostics{
+		CheckedAt:     now.UTC(),
+		UptimeSeconds: int64(now.Sub(processStarted).Seconds()),
+		Runtime: RuntimeStats{
+			GoVersion:    runtime.Version(),
+			Goroutines:   runtime.NumGoroutine(),
+			GOMAXPROCS:   runtime.GOMAXPROCS(0),
+			NumCPU:       runtime.NumCPU(),
+			HeapAlloc:    mem.HeapAlloc,
+			HeapObjects:  mem.HeapObjects,
+			Sys:          mem.Sys,
+			NumGC:        mem.NumGC,
+			PauseTotalMs: time.Duration(mem.PauseTotalNs).Milliseconds(),
+		},
+		Calls: []DiagnosticCall{},
+		Sessions: SessionStats{
+			Open:           h.sessions.Len(),
+			Streams:        h.streams.len(),
+			Subscriptions:  h.subscriptions.len(),
+			ClientRequests: h.clientRequests.len(),
+		},
+		Caches: map[string]int{
+			"continuations": h.continuations.len(),
+			"idempotency":   h.idempotency.len(),
+			"journal":       h.journal.Len(),
+			"approvals":     len(h.authManager.Approvals()),
+		},
+		Connections: fly.ConnectionStats(),
+	}
+
+	for _, call := range h.inflight.snapshot() {
+		d.Calls = append(d.Calls, DiagnosticCall{
+			Tool:           call.Tool,
+			Mutating:       call.Mutating,
+			Started:        call.Started.UTC(),
+			RunningSeconds: int64(now.Sub(call.Started).Seconds()),
+		})
+	}
+
+	if h.readLimiter != nil {
+		d.RateLimits = map[string]int{
+			"read":     h.readLimiter.Len(),
+			"mutating": h.mutatingLimiter.Len(),
+		}
+	}
+	return d
+}
@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/session"
+)
+
+// maxElicitedApps is the most app names offered to choose from when a call
+// leaves out app_name; with more apps the user types the name
+const maxElicitedApps = 100
+
+// canElicit reports whether a tools/call request can ask the user for
+// input: elicitation is enabled, and the client declared support for it on
+// initialize, speaks a revision that has it and accepts an event stream,
+// on which the request is sent
+func (h *Handler) canElicit(r *http.Request) bool {
+	if !h.config.MCP.Elicitation.Enabled || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return false
+	}
+	sess, ok := session.FromContext(r.Context())
+	return ok && sess.SupportsElicitation() && supports(h.protocolVersion(r.Context()), protocolElicitation)
+}
+
+// elicitor returns the function asking the user for input with
+// elicitation/create requests on stream
+func (h *Handler) elicitor(client string, stream *progressStream) interfaces.ElicitFunc {
+	timeout := time.Duration(h.config.MCP.Elicitation.Timeout) * time.Second
+
+	return func(ctx context.Context, message string, schema map[string]interface{}) (interfaces.ElicitResult, error) {
+		raw, err := h.requestClient(ctx, client, stream, "elicitation/create", map[string]interface{}{
+			"message":         message,
+			"requestedSchema": schema,
+		}, timeout)
+		if errors.Is(err, errClientTimeout) {
+			h.metrics.Inc("fly_mcp_elicitations_total", metrics.Labels{"result": "timeout"})
+			return interfaces.ElicitResult{}, err
+		}
+		if err != nil {
+			h.metrics.Inc("fly_mcp_elicitations_total", metrics.Labels{"result": "error"})
+			return interfaces.ElicitResult{}, err
+		}
+
+		var result interfaces.ElicitResult
+		if err := json.Unmarshal(raw, &result); err != nil {
+			h.metrics.Inc("fly_mcp_elicitations_total", metrics.Labels{"result": "error"})
+			return interfaces.ElicitResult{}, fmt.Errorf("failed to decode elicitation result: %w", err)
+		}
+		switch result.Action {
+		case interfaces.ElicitAccept, interfaces.ElicitDecline, interfaces.ElicitCancel:
+		default:
+			h.metrics.Inc("fly_mcp_elicitations_total", metrics.Labels{"result": "error"})
+			return interfaces.ElicitResult{}, fmt.Errorf("unknown elicitation action %q", result.Action)
+		}
+
+		h.metrics.Inc("fly_mcp_elicitations_total", metrics.Labels{"result": result.Action})
+		return result, nil
+	}
+}
+
+// elicitMissingArguments asks the user for required arguments a tool call
+// left out, and adds the answers to arguments. It returns a result ending
+// the call when the user declines; when the client cannot be asked, or does
+// not answer, the call goes ahead and the tool reports what is missing.
+func (h *Handler) elicitMissingArguments(ctx context.Context, tool interfaces.Tool, arguments map[string]interface{}) *interfaces.ToolResult {
+	if !interfaces.CanElicit(ctx) {
+		return nil
+	}
+
+	schema := tool.InputSchema()
+	properties, _ := schema["properties"].(map[string]interface{})
+	required, _ := schema["required"].([]string)
+
+	var missing []string
+	requested := make(map[string]interface{})
+	for _, name := range required {
+		if value, ok := arguments[name]; ok && value != "" {
+			continue
+		}
+		property, ok := elicitationProperty(properties[name])
+		if !ok {
+			// Only flat values can be asked for
+			return nil
+		}
+		missing = append(missing, name)
+		requested[name] = property
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if property, ok := requested["app_name"].(map[string]interface{}); ok {
+		h.offerAppNames(ctx, property)
+	}
+
+	message := fmt.Sprintf("%s needs %s to continue.", tool.Name(), joinNames(missing))
+	result, err := interfaces.Elicit(ctx, message, map[string]interface{}{
+		"type":       "object",
+		"properties": requested,
+		"required":   missing,
+	})
+	if err != nil {
+		if !errors.Is(err, interfaces.ErrElicitationUnsupported) {
+			h.requestLogger(ctx).Debug().
+				Err(err).
+				Str("tool", tool.Name()).
+				Msg("Elicitation of missing arguments failed")
+		}
+		return nil
+	}
+
+	if result.Action != interfaces.ElicitAccept {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("%s was not run: the user did not provide %s.", tool.Name(), joinNames(missing)),
+			}},
+			IsError: true,
+		}
+	}
+
+	for _, name := range missing {
+		if value, ok := result.Content[name]; ok {
+			arguments[name] = value
+		}
+	}
+	return nil
+}
+
+// elicitationProperty returns the schema of a tool argument in the form
+// elicitation/create accepts, which only allows strings, numbers, integers
+// and booleans
+func elicitationProperty(property interface{}) (map[string]interface{}, bool) {
+	schema, ok := property.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	switch schema["type"] {
+	case "string", "number", "integer", "boolean":
+	default:
+		return nil, false
+	}
+
+	requested := make(map[string]interface{})
+	for _, key := range []string{"type", "description", "enum", "minimum", "maximum", "default"} {
+		if value, ok := schema[key]; ok {
+			requested[key] = value
+		}
+	}
+	return requested, true
+}
+
+// offerAppNames turns the app_name property into a choice of the caller's
+// apps, when they may read them and there are not too many
+func (h *Handler) offerAppNames(ctx context.Context, property map[string]interface{}) {
+	userID, err := h.authManager.ExtractUserFromContext(ctx)
+	if err != nil || !h.authManager.IsAllowed(userID, "read", "app") {
+		return
+	}
+
+	apps, err := h.flyClient.GetApps(ctx)
+	if err != nil || len(apps) == 0 || len(apps) > maxElicitedApps {
+		return
+	}
+
+	names := make([]string, 0, len(apps))
+	for _, app := range apps {
+		names = append(names, app.Name)
+	}
+	property["enum"] = names
+}
+
+// joinNames lists argument names for a sentence: "a", "a and b", "a, b and c"
+func joinNames(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/tools"
+)
+
+// encodeBufferSize is how much of a message is buffered before it is
+// written to the client
+const encodeBufferSize = 32 * 1024
+
+// writeMessage writes a JSON-RPC message, or a batch of responses, to w as
+// compact JSON without a trailing newline. Tool results are streamed a
+// content block at a time, with their structured content written value by
+// value, so that a large result is sent as it is encoded instead of after
+// all of it is.
+func writeMessage(w io.Writer, message interface{}) error {
+	bw := bufio.NewWriterSize(w, encodeBufferSize)
+
+	var err error
+	switch message := message.(type) {
+	case *MCPResponse:
+		err = writeResponse(bw, message)
+	case []*MCPResponse:
+		bw.WriteString("[")
+		for i, response := range message {
+			if i > 0 {
+				bw.WriteString(",")
+			}
+			if err = writeResponse(bw, response); err != nil {
+				break
+			}
+		}
+		bw.WriteString("]")
+	default:
+		err = tools.EncodeJSON(bw, message, "")
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeResponse writes a response the way encoding/json would encode it
+func writeResponse(w *bufio.Writer, response *MCPResponse) error {
+	result, ok := response.Result.(*interfaces.ToolResult)
+	if !ok || result == nil {
+		return tools.EncodeJSON(w, response, "")
+	}
+
+	w.WriteString(`{"jsonrpc":`)
+	if err := tools.EncodeJSON(w, response.JSONRPC, ""); err != nil {
+		return err
+	}
+	if response.ID != nil {
+		w.WriteString(`,"id":`)
+		if err := tools.EncodeJSON(w, response.ID, ""); err != nil {
+			return err
+		}
+	}
+	w.WriteString(`,"result":`)
+	if err := writeToolResult(w, result); err != nil {
+		return err
+	}
+	if response.Error != nil {
+		w.WriteString(`,"error":`)
+		if err := tools.EncodeJSON(w, response.Error, ""); err != nil {
+			return err
+		}
+	}
+	_, err := w.WriteString("}")
+	return err
+}
+
+// writeToolResult writes a tool result field by field, matching its json
+// tags
+func writeToolResult(w *bufio.Writer, result *interfaces.ToolResult) error {
+	w.WriteString(`{"content":`)
+	if result.Content == nil {
+		w.WriteString("null")
+	} else {
+		w.WriteString("[")
+		for i, block := range result.Content {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			data, err := json.Marshal(block)
+			if err != nil {
+				return err
+			}
+			w.Write(data)
+		}
+		w.WriteString("]")
+	}
+
+	if result.StructuredContent != nil {
+		w.WriteString(`,"structuredContent":`)
+		if err := tools.EncodeJSON(w, result.StructuredContent, ""); err != nil {
+			return err
+		}
+	}
+	if result.IsError {
+		w.WriteString(`,"isError":true`)
+	}
+	if len(result.Meta) > 0 {
+		w.WriteString(`,"_meta":`)
+		if err := tools.EncodeJSON(w, result.Meta, ""); err != nil {
+			return err
+		}
+	}
+	_, err := w.WriteString("}")
+	return err
+}
@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// JSON-RPC error codes. Codes from -32000 to -32099 are left to servers;
+// -32002 is the one MCP uses for unknown resources.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+
+	// codeRateLimited rejects a call over the client's rate limit
+	codeRateLimited = -32000
+	// codeShuttingDown rejects new work once the server is draining
+	codeShuttingDown = -32001
+	// codeResourceNotFound answers resources/read for an unknown resource
+	codeResourceNotFound = -32002
+	// codePermissionDenied answers requests the caller's token, role or
+	// policy does not allow
+	codePermissionDenied = -32003
+	// codeRequestCancelled answers a call the client cancelled
+	codeRequestCancelled = -32800
+)
+
+// rpcError is an error reported to the client with a JSON-RPC code and a
+// data object describing it
+type rpcError struct {
+	code    int
+	message string
+	data    map[string]interface{}
+	err     error
+}
+
+func (e *rpcError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("%s: %v", e.message, e.err)
+	}
+	return e.message
+}
+
+func (e *rpcError) Unwrap() error {
+	return e.err
+}
+
+// newRPCError returns an error with the given code. data may be nil; the
+// underlying error, if any, is added to it as "error".
+func newRPCError(code int, message string, err error, data map[string]interface{}) *rpcError {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	return &rpcError{code: code, message: message, data: data, err: err}
+}
+
+// methodNotFound is the error for a method the server does not serve
+func methodNotFound(method string) *rpcError {
+	return newRPCError(codeMethodNotFound, "Method not found", nil, map[string]interface{}{"method": method})
+}
+
+// invalidParams is the error for a request whose params are missing or
+// malformed; param names the offending one
+func invalidParams(param, format string, args ...interface{}) *rpcError {
+	data := map[string]interface{}{"reason": fmt.Sprintf(format, args...)}
+	if param != "" {
+		data["param"] = param
+	}
+	return newRPCError(codeInvalidParams, "Invalid params", nil, data)
+}
+
+// permissionDenied is the error for a request the caller may not make
+func permissionDenied(err error, data map[string]interface{}) *rpcError {
+	return newRPCError(codePermissionDenied, "Permission denied", err, data)
+}
+
+// internalError is the error for a request the server failed to handle
+func internalError(err error, data map[string]interface{}) *rpcError {
+	return newRPCError(codeInternalError, "Internal error", err, data)
+}
+
+// errorResponse maps an error from handling a request to its JSON-RPC error
+// response. Errors without a code are internal errors.
+func errorResponse(req *MCPRequest, err error) *MCPResponse {
+	var rpcErr *rpcError
+	if !errors.As(err, &rpcErr) {
+		rpcErr = internalError(err, nil)
+	}
+
+	data := make(map[string]interface{}, len(rpcErr.data)+1)
+	for k, v := range rpcErr.data {
+		data[k] = v
+	}
+	data["method"] = req.Method
+
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Error: &MCPError{
+			Code:    rpcErr.code,
+			Message: rpcErr.message,
+			Data:    data,
+		},
+	}
+}
@@ -0,0 +1,32 @@
+package mcp
+
+import (
+	"maps"
+
+	"github.com/brannn/fly-mcp/pkg/tools"
+)
+
+// formatArg is the tool argument that selects the output format of a call
+const formatArg = "format"
+
+// formatSchema adds the format argument to a tool's input schema, so every
+// tool offers the same formats with the same description
+func formatSchema(schema map[string]interface{}, defaultFormat string) map[string]interface{} {
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return schema
+	}
+	if defaultFormat == "" {
+		defaultFormat = tools.FormatText
+	}
+
+	extended := maps.Clone(schema)
+	extended["properties"] = maps.Clone(properties)
+	extended["properties"].(map[string]interface{})[formatArg] = map[string]interface{}{
+		"type":        "string",
+		"description": "Output format: text for a readable report, json for the full result data, table for a compact tabular view",
+		"enum":        tools.OutputFormats,
+		"default":     defaultFormat,
+	}
+	return extended
+}
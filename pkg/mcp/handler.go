@@ -1,107 +1,440 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/brannn/fly-mcp/internal/audit"
 	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/internal/notify"
+	"github.com/brannn/fly-mcp/internal/ratelimit"
+	"github.com/brannn/fly-mcp/internal/tracing"
+	"github.com/brannn/fly-mcp/pkg/alerts"
 	"github.com/brannn/fly-mcp/pkg/auth"
 	"github.com/brannn/fly-mcp/pkg/config"
 	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/github"
 	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/journal"
+	"github.com/brannn/fly-mcp/pkg/pipeline"
+	"github.com/brannn/fly-mcp/pkg/reports"
+	"github.com/brannn/fly-mcp/pkg/session"
 	"github.com/brannn/fly-mcp/pkg/tools"
+	"github.com/brannn/fly-mcp/pkg/watcher"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Handler handles MCP protocol requests
 type Handler struct {
 	config      *config.Config
 	logger      *logger.Logger
-	tools       map[string]interfaces.Tool
+	tools       *interfaces.ToolRegistry
 	flyClient   *fly.Client
 	authManager *auth.Manager
+	inflight    *inflightTracker
+	sessions    *session.Store
+	notifier    *notify.Notifier
+	metrics     *metrics.Registry
+	readiness   *readinessProbe
+	streams     *notificationStreams
+
+	// auditExporter forwards audit events to the SIEM systems of
+	// audit.exporters
+	auditExporter *audit.Exporter
+
+	// remoteWrite pushes the metrics to a remote-write endpoint, nil when
+	// metrics.remote_write is disabled
+	remoteWrite *metrics.RemoteWriter
+
+	// subscriptions records the resources sessions subscribed to
+	subscriptions *resourceSubscriptions
+
+	// reporter collects scheduled status reports, nil when they are
+	// disabled
+	reporter *statusReporter
+
+	// watcher polls machine states, nil when it is disabled
+	watcher *watcher.Watcher
+
+	// alerts evaluates the alert rules, nil when none are configured
+	alerts *alerts.Engine
+
+	// continuations holds the rest of truncated tool responses
+	continuations *continuationStore
+
+	// idempotency holds the results of mutating calls made with an
+	// idempotency key
+	idempotency *idempotencyStore
+
+	// journal records the changes tools make, for fly_undo
+	journal *journal.Journal
+
+	// suspensions remembers the apps suspended with fly_suspend and
+	// resumes them on schedule
+	suspensions *tools.Suspensions
+
+	// pipeline keeps the events CI systems post to /hooks/ci, nil when
+	// integrations.ci is disabled
+	pipeline *pipeline.Store
+
+	// github resolves and builds the repositories fly_deploy deploys from,
+	// nil when the GitHub integration is not configured
+	github *github.Client
+
+	// clientRequests routes the client's answers to the calls that sent
+	// it requests
+	clientRequests *clientRequestStore
+
+	// proxies decides whose Fly-Client-IP header names the client
+	proxies *ratelimit.ProxyTrust
+
+	// Per-client tool call limits, nil when rate limiting is disabled
+	readLimiter     *ratelimit.KeyedLimiter
+	mutatingLimiter *ratelimit.KeyedLimiter
+}
+
+// NewHandler creates a new MCP handler. Programs embedding fly-mcp may pass
+// a nil logger, to log as cfg.Logging says, and a nil metrics registry.
+func NewHandler(cfg *config.Config, log *logger.Logger, registry *metrics.Registry) (*Handler, error) {
+	return NewHandlerWithTools(cfg, log, registry, nil)
 }
 
-// NewHandler creates a new MCP handler
-func NewHandler(cfg *config.Config, log *logger.Logger) (*Handler, error) {
+// NewHandlerWithTools creates an MCP handler offering extra tools next to
+// the built-in ones. mcp.tools applies to them as well.
+func NewHandlerWithTools(cfg *config.Config, log *logger.Logger, registry *metrics.Registry, extra []interfaces.Tool) (*Handler, error) {
+	if log == nil {
+		var err error
+		if log, err = logger.New(cfg.Logging); err != nil {
+			return nil, fmt.Errorf("failed to create logger: %w", err)
+		}
+	}
+	if registry == nil {
+		registry = metrics.NewRegistry()
+	}
+
 	// Create Fly.io client
 	flyClient, err := fly.NewClient(&cfg.Fly, log)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Fly.io client: %w", err)
 	}
 
+	proxies, err := ratelimit.NewProxyTrust(cfg.Server.Proxy.Fly, cfg.Server.Proxy.Trusted)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create authentication manager
 	authManager := auth.NewManager(cfg, log)
 
 	handler := &Handler{
 		config:      cfg,
 		logger:      log,
-		tools:       make(map[string]interfaces.Tool),
+		tools:       interfaces.NewToolRegistry(),
 		flyClient:   flyClient,
 		authManager: authManager,
+		proxies:     proxies,
+		inflight:    newInflightTracker(),
+		sessions:    session.NewStore(time.Duration(cfg.MCP.SessionTimeout) * time.Second),
+		notifier:    notify.New(cfg.Notifications, cfg.Environment, log, registry),
+		metrics:     registry,
+		readiness:   &readinessProbe{},
+		streams:     newNotificationStreams(),
+
+		continuations: newContinuationStore(),
+		idempotency:    newIdempotencyStore(),
+		journal:        journal.New(cfg.MCP.Journal.Size),
+		suspensions:    tools.NewSuspensions(flyClient, authManager, log),
+		github:         github.NewClient(cfg.Integrations.GitHub, log),
+		clientRequests: newClientRequestStore(),
+		subscriptions:  newResourceSubscriptions(),
+		auditExporter:  audit.New(cfg, log, registry),
+	}
+	authManager.SetApprovalNotifier(handler.notifyApproval)
+	authManager.SetAuditExporter(handler.auditExporter.Export)
+
+	registry.RegisterGaugeFunc("fly_mcp_sessions", "Open MCP sessions", func(set func(metrics.Labels, float64)) {
+		set(nil, float64(handler.sessions.Len()))
+	})
+	registerContinuationMetrics(registry)
+	registry.Register("fly_mcp_tool_panics_total", metrics.KindCounter, "Tool calls that panicked")
+	registry.Register("fly_mcp_tool_timeouts_total", metrics.KindCounter, "Tool calls stopped by their time budget")
+	registry.Register("fly_mcp_idempotent_replays_total", metrics.KindCounter, "Mutating tool calls answered with the result of an earlier call with the same idempotency key")
+	registry.Register("fly_mcp_requests_rejected_total", metrics.KindCounter, "MCP requests rejected before they were handled, by reason")
+	registry.Register("fly_mcp_elicitations_total", metrics.KindCounter, "Requests asking the user for input, by result")
+	registry.Register("fly_mcp_sampling_requests_total", metrics.KindCounter, "Prompts run on the client's model, by result")
+	registry.Register("fly_mcp_commands_total", metrics.KindCounter, "Tool calls run for chat commands, by tool and result")
+	registry.RegisterGaugeFunc("fly_mcp_resource_subscriptions", "Resource subscriptions of open sessions", func(set func(metrics.Labels, float64)) {
+		set(nil, float64(handler.subscriptions.len()))
+	})
+
+	if cfg.Security.RateLimitEnabled {
+		limits := cfg.Security.ToolRateLimits
+		handler.readLimiter = ratelimit.NewKeyedLimiter(limits.ReadRPS, limits.ReadBurst)
+		handler.mutatingLimiter = ratelimit.NewKeyedLimiter(limits.MutatingRPS, limits.MutatingBurst)
+
+		registry.RegisterGaugeFunc("fly_mcp_rate_limit_clients", "Clients currently tracked by each rate limiter", func(set func(metrics.Labels, float64)) {
+			set(metrics.Labels{"scope": "read"}, float64(handler.readLimiter.Len()))
+			set(metrics.Labels{"scope": "mutating"}, float64(handler.mutatingLimiter.Len()))
+		})
+	}
+
+	if len(cfg.MCP.Alerts.Rules) > 0 {
+		handler.alerts = alerts.New(cfg.MCP.Alerts, handler.notifyAlert)
+	}
+
+	if cfg.Metrics.RemoteWrite.Enabled {
+		handler.remoteWrite = metrics.NewRemoteWriter(cfg.Metrics.RemoteWrite, registry, log)
+	}
+
+	if cfg.Integrations.CI.Enabled {
+		handler.pipeline = pipeline.New(cfg.Integrations.CI.History)
+		registry.Register("fly_mcp_ci_events_total", metrics.KindCounter, "Build and deploy events posted by CI systems, by kind and status")
 	}
 
 	// Register tools
-	if err := handler.registerTools(); err != nil {
+	if err := handler.registerTools(extra); err != nil {
 		return nil, fmt.Errorf("failed to register tools: %w", err)
 	}
 
+	handler.startReporter()
+	handler.startWatcher()
+	handler.suspensions.Start()
+
 	return handler, nil
 }
 
-// HandleRequest handles an incoming MCP request
+// HandleRequest handles an incoming MCP request, or a JSON-RPC batch of them.
+// Bodies larger than server.limits.max_body_bytes are refused with 413, so
+// every entry point, the HTTP server's and an embedder's, is bounded.
 func (h *Handler) HandleRequest(w http.ResponseWriter, r *http.Request) error {
 	start := time.Now()
 
+	// Refuse bodies that announce they are too large before reading any of
+	// them, and stop reading bodies that turn out to be
+	limit := h.config.Server.Limits.MaxBodyBytes
+	if r.ContentLength > limit {
+		h.metrics.Inc("fly_mcp_requests_rejected_total", metrics.Labels{"reason": "body_too_large"})
+		h.logger.Warn().
+			Str("remote_addr", r.RemoteAddr).
+			Int64("content_length", r.ContentLength).
+			Int64("limit", limit).
+			Msg("MCP request body too large")
+		
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return nil
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	r, ok := h.identify(w, r)
+	if !ok {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		h.metrics.Inc("fly_mcp_requests_rejected_total", metrics.Labels{"reason": "body_too_large"})
+		h.logger.Warn().
+			Int64("limit", tooLarge.Limit).
+			Msg("MCP request body too large")
+		
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return nil
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to read MCP request")
+		return h.sendError(w, codeParseError, "Parse error", nil)
+	}
+	if isBatch(body) {
+		return h.handleBatch(w, r, body)
+	}
+
 	// Parse the MCP request
 	var req MCPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		h.logger.Error().Err(err).Msg("Failed to decode MCP request")
-		return h.sendError(w, -32700, "Parse error", nil)
+		return h.sendError(w, codeParseError, "Parse error", nil)
 	}
 
-	h.logger.LogMCPRequest(req.Method, req.Params)
+	r, requestID := h.withRequestID(r, 0)
+	log := h.requestLogger(r.Context())
+	log.LogMCPRequest(req.Method, req.Params)
 
-	// Handle the request based on method
-	var response *MCPResponse
-	var err error
+	ctx, span := tracing.Tracer().Start(r.Context(), "mcp "+req.Method,
+		trace.WithAttributes(
+			attribute.String("mcp.method", req.Method),
+			attribute.String("mcp.request_id", requestID),
+			attribute.String("jsonrpc.request_id", fmt.Sprint(req.ID)),
+		),
+	)
+	defer span.End()
+	r = r.WithContext(ctx)
 
-	switch req.Method {
-	case "initialize":
-		response, err = h.handleInitialize(&req)
-	case "tools/list":
-		response, err = h.handleToolsList(&req)
-	case "tools/call":
-		response, err = h.handleToolsCall(r, &req)
-	case "resources/list":
-		response, err = h.handleResourcesList(&req)
-	case "resources/read":
-		response, err = h.handleResourcesRead(&req)
-	default:
-		err = fmt.Errorf("unsupported method: %s", req.Method)
+	// Join the client's session, or open one on initialize
+	r, ok = h.resolveSession(w, r, &req)
+	if ok && req.Method != "initialize" {
+		r, ok = h.resolveProtocolVersion(w, r)
+	}
+	if !ok {
+		log.LogMCPResponse(req.Method, false, time.Since(start))
+		return nil
+	}
+
+	// Responses answer requests the server sent, such as elicitation/create
+	if isClientResponse(body) {
+		h.handleClientResponse(r, body)
+		w.WriteHeader(http.StatusAccepted)
+		return nil
 	}
 	
+	// Notifications get no JSON-RPC response
+	if strings.HasPrefix(req.Method, "notifications/") {
+		h.handleNotification(r, &req)
+		log.LogMCPResponse(req.Method, true, time.Since(start))
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+
+	// Stream progress notifications when the client asked for them, and
+	// requests to the client when the call may ask the user for input or
+	// run prompts on the client's model
+	var stream *progressStream
+	if req.Method == "tools/call" && req.ID != nil {
+		r = r.WithContext(interfaces.WithKeepAlive(r.Context(), h.keepAlive(w, log)))
+		if stream = newProgressStream(w, r, &req, h.protocolVersion(r.Context()), log); stream != nil {
+			r = r.WithContext(interfaces.WithProgress(r.Context(), stream.notify))
+		}
+		elicit, sample := h.canElicit(r), h.canSample(r)
+		if stream == nil && (elicit || sample) {
+			stream = newEventStream(w, log)
+		}
+		if elicit {
+			r = r.WithContext(interfaces.WithElicitation(r.Context(), h.elicitor(h.clientKey(r), stream)))
+		}
+		if sample {
+			r = r.WithContext(interfaces.WithSampling(r.Context(), h.sampler(h.clientKey(r), stream)))
+		}
+	}
+
+	response, err := h.dispatch(r, &req)
+	
 	duration := time.Since(start)
 	
 	if err != nil {
-		h.logger.LogMCPResponse(req.Method, false, duration)
-		return h.sendError(w, -32601, "Method not found", map[string]interface{}{
-			"method": req.Method,
-			"error":  err.Error(),
-		})
+		tracing.RecordError(span, err)
+		log.LogMCPResponse(req.Method, false, duration)
+		if req.ID == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return nil
+		}
+		failed := errorResponse(&req, err)
+		annotateRequestID(failed, requestID)
+		if stream != nil {
+			return stream.send(failed)
+		}
+		return h.sendResponse(w, failed)
 	}
 	
-	h.logger.LogMCPResponse(req.Method, true, duration)
+	log.LogMCPResponse(req.Method, true, duration)
+	annotateRequestID(response, requestID)
+	// A request without an ID is a notification: it runs, but the client
+	// does not expect a response
+	if req.ID == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+	if stream != nil {
+		return stream.send(response)
+	}
 	return h.sendResponse(w, response)
 }
 
-// handleInitialize handles the initialize request
-func (h *Handler) handleInitialize(req *MCPRequest) (*MCPResponse, error) {
+// dispatch handles a request based on its method
+func (h *Handler) dispatch(r *http.Request, req *MCPRequest) (*MCPResponse, error) {
+	switch req.Method {
+	case "initialize":
+		return h.handleInitialize(r, req)
+	case "tools/list":
+		return h.handleToolsList(r, req)
+	case "tools/call":
+		response, err := h.handleToolsCall(r, req)
+		if err != nil {
+			return nil, err
+		}
+		if result, ok := response.Result.(*interfaces.ToolResult); ok {
+			response.Result = adaptToolResult(h.protocolVersion(r.Context()), result)
+		}
+		return response, nil
+	case "resources/list":
+		return h.handleResourcesList(r, req)
+	case "resources/read":
+		return h.handleResourcesRead(r, req)
+	case "resources/subscribe", "resources/unsubscribe":
+		return h.handleResourcesSubscribe(r, req)
+	}
+	return nil, methodNotFound(req.Method)
+}
+
+// handleNotification handles a notifications/* message from the client
+func (h *Handler) handleNotification(r *http.Request, req *MCPRequest) {
+	if req.Method == "notifications/cancelled" {
+		h.handleCancelled(r, req)
+	}
+}
+
+// handleInitialize handles the initialize request. The client gets the
+// protocol revision it asked for if the server offers it, and the newest
+// offered otherwise; the session speaks that revision from then on.
+func (h *Handler) handleInitialize(r *http.Request, req *MCPRequest) (*MCPResponse, error) {
+	params, _ := req.Params.(map[string]interface{})
+	requested, _ := params["protocolVersion"].(string)
+	version := h.negotiateVersion(requested)
+	clientCapabilities, _ := params["capabilities"].(map[string]interface{})
+	_, elicitation := clientCapabilities["elicitation"]
+	_, sampling := clientCapabilities["sampling"]
+	if sess, ok := session.FromContext(r.Context()); ok {
+		sess.SetProtocolVersion(version)
+		sess.SetSupportsElicitation(elicitation)
+		sess.SetSupportsSampling(sampling)
+	}
+	
+	h.logger.Debug().
+		Str("requested", requested).
+		Str("negotiated", version).
+		Msg("Negotiated MCP protocol version")
+	
+	// list_changed notifications travel on the GET stream of the Streamable
+	// HTTP transport, which older clients do not open
+	capabilities := h.config.MCP.Capabilities
+	listChanged := supports(version, protocolStreamableHTTP)
 	result := map[string]interface{}{
-		"protocolVersion": h.config.MCP.Version,
-		"capabilities":    h.config.MCP.Capabilities,
-		"serverInfo":      h.config.MCP.ServerInfo,
+		"protocolVersion": version,
+		// Prompts are not served, so they are not advertised
+		"capabilities": ServerCapabilities{
+			Tools: &ToolsCapability{ListChanged: listChanged && capabilities.Tools.ListChanged},
+			Resources: &ResourcesCapability{
+				Subscribe:   capabilities.Resources.Subscribe,
+				ListChanged: listChanged && capabilities.Resources.ListChanged,
+			},
+		},
+		"serverInfo": ServerInfo{
+			Name:    h.config.MCP.ServerInfo.Name,
+			Version: h.config.MCP.ServerInfo.Version,
+		},
 	}
 	
 	return &MCPResponse{
@@ -111,20 +444,57 @@ func (h *Handler) handleInitialize(req *MCPRequest) (*MCPResponse, error) {
 	}, nil
 }
 
-// handleToolsList handles the tools/list request
-func (h *Handler) handleToolsList(req *MCPRequest) (*MCPResponse, error) {
-	tools := make([]map[string]interface{}, 0, len(h.tools))
+// handleToolsList handles the tools/list request. Tools that the Fly.io
+// token cannot support, such as mutating tools with a read-only token, are
+// left out.
+func (h *Handler) handleToolsList(r *http.Request, req *MCPRequest) (*MCPResponse, error) {
+	ctx, err := h.withProfile(r.Context(), "")
+	if err != nil {
+		return nil, permissionDenied(err, nil)
+	}
+	
+	registered := h.availableTools(ctx)
+	page, err := tools.Paginate(len(registered), h.config.MCP.PageSize, paramsCursor(req))
+	if err != nil {
+		return nil, invalidParams("cursor", "%v", err)
+	}
+	
+	_, hasSession := session.FromContext(ctx)
+	profiles := h.authManager.AvailableProfiles(ctx)
+	annotated := supports(h.protocolVersion(ctx), protocolToolAnnotations)
 	
-	for _, tool := range h.tools {
-		tools = append(tools, map[string]interface{}{
+	list := make([]map[string]interface{}, 0, page.End-page.Start)
+	for _, tool := range registered[page.Start:page.End] {
+		schema := tool.InputSchema()
+		if hasSession {
+			schema = sessionSchema(schema)
+		}
+		if _, ok := tool.(interfaces.PermissionedTool); ok {
+			schema = profileSchema(schema, profiles)
+		}
+		schema = formatSchema(schema, h.config.MCP.OutputFormat)
+		if isMutating(tool, nil) {
+			schema = idempotencySchema(schema)
+		}
+		if h.config.MCP.ResponseLimits.Limit(tool.Name()) > 0 {
+			schema = continuationSchema(schema)
+		}
+		entry := map[string]interface{}{
 			"name":        tool.Name(),
 			"description": tool.Description(),
-			"inputSchema": tool.InputSchema(),
-		})
+			"inputSchema": schema,
+		}
+		if annotations := toolAnnotations(tool); annotated && annotations != nil {
+			entry["annotations"] = annotations
+		}
+		list = append(list, entry)
 	}
 	
 	result := map[string]interface{}{
-		"tools": tools,
+		"tools": list,
+	}
+	if page.NextCursor != "" {
+		result["nextCursor"] = page.NextCursor
 	}
 	
 	return &MCPResponse{
@@ -139,12 +509,12 @@ func (h *Handler) handleToolsCall(r *http.Request, req *MCPRequest) (*MCPRespons
 	// Parse parameters
 	params, ok := req.Params.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("invalid parameters for tools/call")
+		return nil, invalidParams("", "params must be an object with the tool name and arguments")
 	}
 	
 	toolName, ok := params["name"].(string)
 	if !ok {
-		return nil, fmt.Errorf("tool name is required")
+		return nil, invalidParams("name", "tool name is required")
 	}
 	
 	arguments, ok := params["arguments"].(map[string]interface{})
@@ -153,34 +523,498 @@ func (h *Handler) handleToolsCall(r *http.Request, req *MCPRequest) (*MCPRespons
 	}
 	
 	// Find and execute the tool
-	tool, exists := h.tools[toolName]
+	tool, exists := h.tools.Get(toolName)
 	if !exists {
-		return nil, fmt.Errorf("tool not found: %s", toolName)
+		return nil, invalidParams("name", "unknown tool: %s", toolName)
+	}
+	
+	// Act with the token profile the call selects, or the caller's own
+	ctx, err := h.withProfile(r.Context(), stringArg(arguments, profileArg))
+	if err != nil {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result: &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Profile denied: %v", err),
+				}},
+				IsError: true,
+			},
+		}, nil
+	}
+	delete(arguments, profileArg)
+	
+	// Tools render output in the format the call selects, or the configured one
+	if format, ok := arguments[formatArg]; ok {
+		if name, _ := format.(string); !tools.ValidOutputFormat(name) {
+			return &MCPResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Result: &interfaces.ToolResult{
+					Content: []interfaces.ContentBlock{{
+						Type: "text",
+						Text: fmt.Sprintf("Error: unknown format %v; use text, json or table", format),
+					}},
+					IsError: true,
+				},
+			}, nil
+		}
 	}
 	
+	// A continuation fetches the rest of an earlier response instead of
+	// running the tool again
+	if token, _ := arguments[continuationArg].(string); token != "" {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  h.continueResponse(r, toolName, token),
+		}, nil
+	}
+	delete(arguments, continuationArg)
+	ctx = tools.WithOutputFormat(ctx, h.config.MCP.OutputFormat)
+	r = r.WithContext(ctx)
+	
+	idempotencyKey := stringArg(arguments, idempotencyKeyArg)
+	delete(arguments, idempotencyKeyArg)
+
+	// Fill in arguments the conversation already established
+	sess, hasSession := session.FromContext(r.Context())
+	if hasSession {
+		h.applySessionDefaults(sess, tool, arguments)
+	}
+
+	// Ask the user for required arguments that are still missing
+	if declined := h.elicitMissingArguments(r.Context(), tool, arguments); declined != nil {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  tools.ApplyOutputStyle(declined, h.config.MCP.OutputStyle),
+		}, nil
+	}
+
+	// Roles granted on some apps only apply to calls naming one of them
+	if appName := stringArg(arguments, "app_name"); appName != "" {
+		r = r.WithContext(auth.WithTargetApp(r.Context(), appName))
+	}
+
+	// Policy rules restrict which apps the tool may act on
+	if denied := h.checkPolicy(r.Context(), tool, arguments); denied != nil {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  tools.ApplyOutputStyle(denied, h.config.MCP.OutputStyle),
+		}, nil
+	}
+
+	// Read-only calls of tools that can change things, such as a status
+	// action, are not limited, drained or replayed as changes
+	mutating := isMutating(tool, arguments)
+
+	// A retried mutating call gets the result of the first one instead of
+	// running again
+	var idempotentResult *interfaces.ToolResult
+	if mutating && idempotencyKey != "" {
+		fingerprint, err := idempotencyFingerprint(arguments)
+		if err != nil {
+			return nil, internalError(err, map[string]interface{}{"tool": toolName})
+		}
+		key := h.clientKey(r) + "\x00" + toolName + "\x00" + idempotencyKey
+		call, earlier := h.idempotency.begin(key, fingerprint, h.idempotencyTTL())
+		if earlier {
+			return &MCPResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Result:  h.limitResponse(r, toolName, tools.ApplyOutputStyle(h.replayIdempotentCall(r.Context(), toolName, idempotencyKey, call, fingerprint), h.config.MCP.OutputStyle)),
+			}, nil
+		}
+		defer func() {
+			h.idempotency.finish(key, call, idempotentResult, h.idempotencyTTL())
+		}()
+	}
+
+	// Apply per-client limits, with mutating tools on a stricter budget
+	if limiter, scope := h.toolLimiter(mutating); limiter != nil {
+		client := h.clientKey(r)
+		if !limiter.Allow(client) {
+			h.metrics.Inc("fly_mcp_rate_limit_rejected_total", metrics.Labels{"scope": scope})
+			h.requestLogger(r.Context()).Warn().
+				Str("client", client).
+				Str("tool", toolName).
+				Str("scope", scope).
+				Msg("Tool rate limit exceeded")
+
+			return nil, newRPCError(codeRateLimited, "Rate limit exceeded", nil, map[string]interface{}{
+				"tool":  toolName,
+				"scope": scope,
+			})
+		}
+	}
+
+	// Refuse new work once shutdown has started
+	callID, ok := h.inflight.begin(toolName, mutating, h.requestKey(r, req.ID))
+	if !ok {
+		return nil, newRPCError(codeShuttingDown, "Server is shutting down", nil, map[string]interface{}{
+			"tool": toolName,
+		})
+	}
+	defer h.inflight.end(callID)
+
+	ctx, cancel := h.inflight.executionContext(r.Context(), callID, mutating)
+	defer cancel()
+
+	ctx, budget, stopBudget := withBudget(ctx, h.toolTimeout(toolName, mutating))
+	defer stopBudget()
+
+	ctx, span := tracing.Tracer().Start(ctx, "tool "+toolName,
+		trace.WithAttributes(
+			attribute.String("mcp.tool.name", toolName),
+			attribute.Bool("mcp.tool.mutating", mutating),
+		),
+	)
+	defer span.End()
+
 	start := time.Now()
-	result, err := tool.Execute(r.Context(), arguments)
+	result, err := h.executeTool(ctx, tool, arguments)
 	duration := time.Since(start)
+
+	switch {
+	case err != nil:
+		tracing.RecordError(span, err)
+	case result != nil && result.IsError:
+		span.SetStatus(codes.Error, "tool returned an error result")
+	}
 	
 	// Log tool execution
-	h.logger.LogToolExecution("unknown", toolName, duration, err)
+	userID, _ := h.authManager.ExtractUserFromContext(ctx)
+	h.requestLogger(ctx).LogToolExecution(userID, toolName, duration, err)
+	
+	if mutating {
+		h.notifyToolCall(ctx, tool, arguments, result, err, duration)
+	}
+	
+	if cause := context.Cause(ctx); errors.Is(cause, interfaces.ErrCancelled) {
+		return nil, newRPCError(codeRequestCancelled, "Request cancelled", nil, map[string]interface{}{
+			"tool":   toolName,
+			"reason": cause.Error(),
+		})
+	}
+	
+	// A call stopped by its time budget answers with what it has so far
+	if cause := context.Cause(ctx); budget != nil && errors.Is(cause, interfaces.ErrBudgetExhausted) {
+		h.metrics.Inc("fly_mcp_tool_timeouts_total", metrics.Labels{"tool": toolName})
+		h.requestLogger(ctx).Warn().
+			Err(err).
+			Str("tool", toolName).
+			Dur("budget", budget.limit).
+			Msg("Tool call ran out of time")
+		if err != nil {
+			result = nil
+		}
+		result, err = budgetExhaustedResult(toolName, budget.limit, result), nil
+	}
 	
 	if err != nil {
-		return nil, fmt.Errorf("tool execution failed: %w", err)
+		return nil, internalError(fmt.Errorf("tool execution failed: %w", err), map[string]interface{}{"tool": toolName})
 	}
 	
+	if hasSession && result != nil && !result.IsError {
+		h.recordSessionContext(sess, tool, arguments)
+	}
+	idempotentResult = result
+	
 	return &MCPResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
-		Result:  result,
+		Result:  h.limitResponse(r, toolName, tools.ApplyOutputStyle(result, h.config.MCP.OutputStyle)),
 	}, nil
 }
 
-// handleResourcesList handles the resources/list request
-func (h *Handler) handleResourcesList(req *MCPRequest) (*MCPResponse, error) {
-	// TODO: Implement resources listing
+// executeTool runs a tool. A panic in the tool fails the call with an error
+// result instead of taking the server down; the stack goes to the log.
+func (h *Handler) executeTool(ctx context.Context, tool interfaces.Tool, arguments map[string]interface{}) (result *interfaces.ToolResult, err error) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+
+		h.metrics.Inc("fly_mcp_tool_panics_total", metrics.Labels{"tool": tool.Name()})
+		h.requestLogger(ctx).Error().
+			Str("tool", tool.Name()).
+			Str("panic", fmt.Sprint(p)).
+			Str("stack", string(debug.Stack())).
+			Msg("Tool panicked")
+
+		result = &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %s failed unexpectedly and was stopped. The server logged the details; the call may have been partly carried out, so check the current state before retrying.", tool.Name()),
+			}},
+			IsError: true,
+		}
+		err = nil
+	}()
+
+	return tool.Execute(ctx, arguments)
+}
+
+// ApplyConfig applies the hot-reloadable parts of a new configuration:
+// permissions, roles, the exec allowlist, policies and tool rate limits. It returns the names of changed settings.
+func (h *Handler) ApplyConfig(old, cfg *config.Config) []string {
+	var changed []string
+
+	if !reflect.DeepEqual(old.Security.Permissions, cfg.Security.Permissions) {
+		h.authManager.SetPermissions(cfg.Security.Permissions)
+		changed = append(changed, "security.permissions")
+	}
+
+	if !reflect.DeepEqual(old.Security.Roles, cfg.Security.Roles) || !reflect.DeepEqual(old.Security.RoleBindings, cfg.Security.RoleBindings) {
+		h.authManager.SetRoles(cfg.Security.Roles, cfg.Security.RoleBindings)
+		changed = append(changed, "security.roles")
+	}
+
+	if !reflect.DeepEqual(old.Security.ExecAllowedCommands, cfg.Security.ExecAllowedCommands) {
+		h.authManager.SetExecAllowlist(cfg.Security.ExecAllowedCommands)
+		changed = append(changed, "security.exec_allowed_commands")
+	}
+
+	if !reflect.DeepEqual(old.Security.Policies, cfg.Security.Policies) {
+		h.authManager.SetPolicies(cfg.Security.Policies)
+		changed = append(changed, "security.policies")
+	}
+
+	if old.Security.ToolRateLimits != cfg.Security.ToolRateLimits && h.readLimiter != nil {
+		limits := cfg.Security.ToolRateLimits
+		h.readLimiter.SetLimit(limits.ReadRPS, limits.ReadBurst)
+		h.mutatingLimiter.SetLimit(limits.MutatingRPS, limits.MutatingBurst)
+		changed = append(changed, "security.tool_rate_limits")
+	}
+
+	return changed
+}
+
+// AuditConfigReload records a configuration reload in the audit log
+func (h *Handler) AuditConfigReload(source, result string, metadata map[string]interface{}) {
+	h.authManager.AuditLog(context.Background(), "system", "config_reload", source, result, metadata)
+}
+
+// checkPolicy evaluates the policy rules for a tool call. Read-only calls of
+// tools that can change things are evaluated as reads, so freezes and rules
+// on mutating actions leave them alone. It returns the result to send
+// instead of running the tool, or nil if the call may proceed.
+func (h *Handler) checkPolicy(ctx context.Context, tool interfaces.Tool, args map[string]interface{}) *interfaces.ToolResult {
+	pt, ok := tool.(interfaces.PermissionedTool)
+	if !ok {
+		return nil
+	}
+
+	action, _ := pt.RequiredPermission()
+	if !isMutating(tool, args) {
+		action = "read"
+	}
+	if err := h.authManager.EvaluatePolicy(ctx, action, stringArg(args, "app_name")); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Policy denied: %v", err),
+			}},
+			IsError: true,
+		}
+	}
+	return nil
+}
+
+// toolLimiter returns the limiter and metric scope for a tool call
+func (h *Handler) toolLimiter(mutating bool) (*ratelimit.KeyedLimiter, string) {
+	if mutating {
+		return h.mutatingLimiter, "mutating"
+	}
+	return h.readLimiter, "read"
+}
+
+// requestKey identifies a JSON-RPC request across HTTP requests, scoped to
+// the client so one client cannot cancel another's calls
+func (h *Handler) requestKey(r *http.Request, id interface{}) string {
+	return h.clientKey(r) + "/" + fmt.Sprint(id)
+}
+
+// handleCancelled handles notifications/cancelled by aborting the matching
+// tool call. Unknown or already finished requests are ignored.
+func (h *Handler) handleCancelled(r *http.Request, req *MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	requestID, ok := params["requestId"]
+	if !ok {
+		return
+	}
+	reason, _ := params["reason"].(string)
+
+	toolName, ok := h.inflight.cancel(h.requestKey(r, requestID), reason)
+	if !ok {
+		h.logger.Debug().
+			Interface("request_id", requestID).
+			Msg("Cancellation for unknown or finished request")
+		return
+	}
+
+	userID, _ := h.authManager.ExtractUserFromContext(r.Context())
+	h.logger.Info().
+		Str("user_id", userID).
+		Str("tool", toolName).
+		Interface("request_id", requestID).
+		Str("reason", reason).
+		Msg("Tool call cancelled by client")
+}
+
+// clientKey identifies the caller for rate limiting: the authenticated user
+// if known, otherwise the client address
+func (h *Handler) clientKey(r *http.Request) string {
+	if userID, err := h.authManager.ExtractUserFromContext(r.Context()); err == nil && userID != auth.AnonymousUser {
+		return "user:" + userID
+	}
+	return "ip:" + h.ClientIP(r)
+}
+
+// ClientIP returns the address of the client that sent r. Fly-Client-IP is
+// only believed from Fly's proxy or the proxies server.proxy trusts.
+func (h *Handler) ClientIP(r *http.Request) string {
+	return h.proxies.ClientIP(r)
+}
+
+// Drain stops accepting tool calls, ends notification streams, cancels
+// running read-only calls and waits until in-flight mutating calls finish or
+// ctx expires
+func (h *Handler) Drain(ctx context.Context) error {
+	h.inflight.startDraining()
+	h.streams.closeAll()
+
+	pending := h.inflight.snapshot()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	h.logger.Info().
+		Int("in_flight", len(pending)).
+		Msg("Waiting for in-flight tool executions to finish")
+
+	if err := h.inflight.wait(ctx); err != nil {
+		for _, call := range h.inflight.snapshot() {
+			h.logger.Error().
+				Str("tool", call.Tool).
+				Bool("mutating", call.Mutating).
+				Dur("running_for", time.Since(call.Started)).
+				Msg("Tool execution still running at shutdown; its changes may be partially applied")
+		}
+		return err
+	}
+
+	h.logger.Info().Msg("All in-flight tool executions finished")
+	return nil
+}
+
+// Close stops the status reporter, the machine watcher and scheduled
+// resumes, delivers pending webhook notifications and audit events and
+// pushes the metrics a last time. It is called after Drain, once no more
+// tool calls can start. Every part is closed even when an earlier one
+// fails; their errors are returned joined.
+func (h *Handler) Close(ctx context.Context) error {
+	h.suspensions.Close()
+
+	var errs []error
+	errs = append(errs, h.stopReporter(ctx))
+	if h.watcher != nil {
+		errs = append(errs, h.watcher.Close(ctx))
+	}
+	errs = append(errs, h.notifier.Close(ctx))
+	errs = append(errs, h.auditExporter.Close(ctx))
+	if h.remoteWrite != nil {
+		errs = append(errs, h.remoteWrite.Close(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// orgActivityWindow is how far back the organization activity feed reaches
+const orgActivityWindow = 24 * time.Hour
+
+// handleResourcesList handles the resources/list request. The organization
+// activity feed and every application are listed as fly:// resources;
+// callers without read access see none.
+func (h *Handler) handleResourcesList(r *http.Request, req *MCPRequest) (*MCPResponse, error) {
+	resources := []Resource{}
+	
+	ctx, err := h.withProfile(r.Context(), "")
+	if err != nil {
+		return nil, permissionDenied(err, nil)
+	}
+	if h.authManager.ValidateRequest(ctx, "read", "apps") == nil {
+		resources = append(resources, Resource{
+			URI:         tools.OrgActivityResourceURI,
+			Name:        "Organization activity",
+			Description: fmt.Sprintf("Releases, machine events and secret changes across all applications in the last %s, newest first", orgActivityWindow),
+			MimeType:    "application/json",
+		})
+		if h.reporter != nil {
+			resources = append(resources, Resource{
+				URI:         tools.ReportsLatestResourceURI,
+				Name:        "Latest status report",
+				Description: fmt.Sprintf("App statuses, failing health checks and incidents in the last %s, collected every %s", reports.IncidentWindow, h.reporter.interval),
+				MimeType:    "application/json",
+			})
+		}
+		if h.pipeline != nil {
+			for _, app := range h.pipeline.Apps() {
+				if h.authManager.EvaluatePolicy(ctx, "read", app) != nil {
+					continue
+				}
+				resources = append(resources, Resource{
+					URI:         tools.PipelineResourceURI(app),
+					Name:        app + " pipeline",
+					Description: fmt.Sprintf("Build and deploy events CI systems posted for %s, newest first", app),
+					MimeType:    "application/json",
+				})
+			}
+		}
+		if h.watcher != nil {
+			resources = append(resources, Resource{
+				URI:         tools.WatcherResourceURI,
+				Name:        "Machine watcher",
+				Description: fmt.Sprintf("Last known states of the watched machines and their recent changes, polled every %ds", h.config.MCP.Watcher.Interval),
+				MimeType:    "application/json",
+			})
+		}
+
+		apps, err := h.flyClient.GetApps(ctx)
+		if err != nil {
+			return nil, internalError(fmt.Errorf("failed to list applications: %w", err), nil)
+		}
+		sort.Slice(apps, func(i, j int) bool {
+			return apps[i].Name < apps[j].Name
+		})
+		
+		for _, app := range apps {
+			// Apps that a policy hides from the caller are not listed
+			if h.authManager.EvaluatePolicy(ctx, "read", app.Name) != nil {
+				continue
+			}
+			resources = append(resources, Resource{
+				URI:         tools.AppResourceURI(app.Name),
+				Name:        app.Name,
+				Description: fmt.Sprintf("Fly.io application %s (%s)", app.Name, app.Status),
+				MimeType:    "application/json",
+			})
+		}
+	}
+	
+	page, err := tools.Paginate(len(resources), h.config.MCP.PageSize, paramsCursor(req))
+	if err != nil {
+		return nil, invalidParams("cursor", "%v", err)
+	}
+	
 	result := map[string]interface{}{
-		"resources": []interface{}{},
+		"resources": resources[page.Start:page.End],
+	}
+	if page.NextCursor != "" {
+		result["nextCursor"] = page.NextCursor
 	}
 	
 	return &MCPResponse{
@@ -190,41 +1024,300 @@ func (h *Handler) handleResourcesList(req *MCPRequest) (*MCPResponse, error) {
 	}, nil
 }
 
-// handleResourcesRead handles the resources/read request
-func (h *Handler) handleResourcesRead(req *MCPRequest) (*MCPResponse, error) {
-	// TODO: Implement resource reading
-	return nil, fmt.Errorf("resources/read not implemented")
+// handleResourcesRead handles the resources/read request. It serves the
+// fly:// resources that tools link to in their results.
+func (h *Handler) handleResourcesRead(r *http.Request, req *MCPRequest) (*MCPResponse, error) {
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		return nil, invalidParams("", "params must be an object with the resource uri")
+	}
+	
+	uri, ok := params["uri"].(string)
+	if !ok || uri == "" {
+		return nil, invalidParams("uri", "resource uri is required")
+	}
+	
+	appName, kind, err := tools.ParseResourceURI(uri)
+	if err != nil {
+		return nil, newRPCError(codeResourceNotFound, "Resource not found", err, map[string]interface{}{"uri": uri})
+	}
+	
+	ctx, err := h.withProfile(r.Context(), "")
+	if err != nil {
+		return nil, permissionDenied(err, map[string]interface{}{"uri": uri})
+	}
+	if err := h.authorizeResource(ctx, appName); err != nil {
+		return nil, permissionDenied(err, map[string]interface{}{"uri": uri})
+	}
+	
+	var data interface{}
+	switch kind {
+	case tools.ResourceOrgActivity:
+		// Apps that a policy hides from the caller are left out of the feed
+		data, err = h.flyClient.GetOrgActivity(ctx, time.Now().Add(-orgActivityWindow), func(name string) bool {
+			return h.authManager.EvaluatePolicy(ctx, "read", name) == nil
+		})
+	case tools.ResourceReport:
+		data, err = h.latestReport(func(name string) bool {
+			return h.authManager.EvaluatePolicy(ctx, "read", name) == nil
+		})
+		if err != nil {
+			return nil, newRPCError(codeResourceNotFound, "Resource not found", err, map[string]interface{}{"uri": uri})
+		}
+	case tools.ResourceWatcher:
+		if h.watcher == nil {
+			err = fmt.Errorf("the machine watcher is disabled; set mcp.watcher.enabled to turn it on")
+			return nil, newRPCError(codeResourceNotFound, "Resource not found", err, map[string]interface{}{"uri": uri})
+		}
+		data = h.watcher.Snapshot(func(name string) bool {
+			return h.authManager.EvaluatePolicy(ctx, "read", name) == nil
+		})
+	case tools.ResourceApp:
+		data, err = h.flyClient.GetApp(ctx, appName)
+	case tools.ResourceMachines:
+		data, err = h.flyClient.ListMachines(ctx, appName)
+	case tools.ResourcePipeline:
+		if h.pipeline == nil {
+			err = fmt.Errorf("the CI webhook is disabled; set integrations.ci.enabled to turn it on")
+			return nil, newRPCError(codeResourceNotFound, "Resource not found", err, map[string]interface{}{"uri": uri})
+		}
+		data = h.pipeline.Pipeline(appName)
+	}
+	if fly.IsNotFound(err) {
+		return nil, newRPCError(codeResourceNotFound, "Resource not found", err, map[string]interface{}{"uri": uri})
+	}
+	if err != nil {
+		return nil, internalError(fmt.Errorf("failed to read %s: %w", uri, err), map[string]interface{}{"uri": uri})
+	}
+	
+	text, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, internalError(fmt.Errorf("failed to encode %s: %w", uri, err), map[string]interface{}{"uri": uri})
+	}
+	
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"contents": []map[string]interface{}{{
+				"uri":      uri,
+				"mimeType": "application/json",
+				"text":     string(text),
+			}},
+		},
+	}, nil
 }
 
-// registerTools registers all available tools
-func (h *Handler) registerTools() error {
+// authorizeResource checks that the caller may read a resource.
+// Organization resources need read access to all apps, app resources read
+// access to the app.
+func (h *Handler) authorizeResource(ctx context.Context, appName string) error {
+	if appName == "" {
+		return h.authManager.ValidateRequest(ctx, "read", "apps")
+	}
+	if err := h.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return err
+	}
+	return h.authManager.EvaluatePolicy(ctx, "read", appName)
+}
+
+// ToolProvider registers tools of its own on a new handler
+type ToolProvider func(h *Handler) error
+
+// toolProviders run after the built-in tools are registered
+var (
+	toolProvidersMu sync.Mutex
+	toolProviders   []ToolProvider
+)
+
+// RegisterToolProvider adds tools to every handler created afterwards, so
+// programs embedding fly-mcp can offer their own tools. Call it before
+// NewHandler, typically from an init function. mcp.tools applies to these
+// tools as it does to the built-in ones.
+func RegisterToolProvider(provider ToolProvider) {
+	toolProvidersMu.Lock()
+	defer toolProvidersMu.Unlock()
+	toolProviders = append(toolProviders, provider)
+}
+
+// Tools returns the handler's tool registry. Tools registered or
+// unregistered while the server runs are announced to clients with
+// notifications/tools/list_changed.
+func (h *Handler) Tools() *interfaces.ToolRegistry {
+	return h.tools
+}
+
+// FlyClient returns the Fly.io client, for tools registered from outside
+func (h *Handler) FlyClient() *fly.Client {
+	return h.flyClient
+}
+
+// AuthManager returns the authentication manager, so tools registered from
+// outside can check permissions and write audit logs like the built-in ones
+func (h *Handler) AuthManager() *auth.Manager {
+	return h.authManager
+}
+
+// registerTools registers the built-in tools, the extra tools and those of
+// the tool providers, then applies mcp.tools
+func (h *Handler) registerTools(extra []interfaces.Tool) error {
 	h.logger.Info().Msg("Registering MCP tools")
 
-	// Register ping tool for testing
-	h.tools["ping"] = &PingTool{logger: h.logger}
+	builtin := []interfaces.Tool{
+		// Ping tool for testing
+		&PingTool{logger: h.logger},
+
+		// Fly.io management tools
+		tools.NewListAppsTool(h.flyClient, h.authManager, h.logger),
+		tools.NewAppInfoTool(h.flyClient, h.authManager, h.logger),
+		tools.NewAppStatusTool(h.flyClient, h.authManager, h.logger),
+		tools.NewAppRestartTool(h.flyClient, h.authManager, h.logger),
+		tools.NewSuspendTool(h.flyClient, h.suspensions, h.authManager, h.logger),
+		tools.NewResumeTool(h.flyClient, h.suspensions, h.authManager, h.logger),
+		tools.NewAppScaleTool(h.flyClient, h.journal, h.authManager, h.logger),
+		tools.NewMachineCloneTool(h.flyClient, h.authManager, h.logger),
+		tools.NewMachineMetadataTool(h.flyClient, h.authManager, h.logger),
+		tools.NewAppCreateTool(h.flyClient, h.authManager, h.logger),
+		tools.NewAppDeleteTool(h.flyClient, h.authManager, h.logger),
+		tools.NewConfigValidateTool(h.authManager, h.logger),
+		tools.NewConfigGenerateTool(h.authManager, h.logger),
+		tools.NewHealthChecksTool(h.flyClient, h.authManager, h.logger),
+		tools.NewAppMetricsTool(h.flyClient, h.authManager, h.logger),
+		tools.NewAppCostsTool(h.flyClient, h.authManager, h.logger),
+		tools.NewAutoscaleTool(h.flyClient, h.journal, h.authManager, h.logger),
+		tools.NewNetworkTool(h.flyClient, h.authManager, h.logger),
+		tools.NewSnapshotsTool(h.flyClient, h.authManager, h.logger),
+		tools.NewMachineEventsTool(h.flyClient, h.authManager, h.logger),
+		tools.NewImagesTool(h.flyClient, h.authManager, h.logger),
+		tools.NewDoctorTool(h.flyClient, h.authManager, h.logger),
+		tools.NewCrashesTool(h.flyClient, h.authManager, h.logger),
+		tools.NewAlertsTool(h.alerts, h.authManager, h.logger),
+		tools.NewLogsTool(h.flyClient, h.authManager, h.logger,
+			time.Duration(h.config.MCP.LogTail.MaxDuration)*time.Second,
+			time.Duration(h.config.MCP.LogTail.PollInterval)*time.Second),
+		tools.NewWaitTool(h.flyClient, h.authManager, h.logger,
+			time.Duration(h.config.MCP.Wait.MaxTimeout)*time.Second,
+			time.Duration(h.config.MCP.Wait.PollInterval)*time.Second),
+		tools.NewUndoTool(h.flyClient, h.journal, h.authManager, h.logger),
+		tools.NewLogsSearchTool(h.flyClient, h.authManager, h.logger),
+		tools.NewBatchTool(h.flyClient, h.authManager, h.logger),
+		tools.NewDiffAppsTool(h.flyClient, h.authManager, h.logger),
+		tools.NewDriftTool(h.flyClient, h.authManager, h.logger),
+		tools.NewSearchTool(h.flyClient, h.authManager, h.logger),
+		tools.NewFleetStatusTool(h.flyClient, h.authManager, h.logger),
+		tools.NewExportInventoryTool(h.flyClient, h.authManager, h.logger),
+		tools.NewTopologyTool(h.flyClient, h.authManager, h.logger),
+		tools.NewScheduledTasksTool(h.flyClient, h.authManager, h.logger),
+		tools.NewProxyCheckTool(h.flyClient, h.authManager, h.logger),
+		tools.NewDNSTool(h.flyClient, h.authManager, h.logger),
+		tools.NewDeployTool(h.flyClient, h.journal, h.github, h.authManager, h.logger),
+		tools.NewEnvTool(h.flyClient, h.journal, h.authManager, h.logger),
+		tools.NewSessionTool(h.authManager, h.logger),
+		tools.NewSSHExecTool(h.flyClient, h.authManager, h.logger),
+		tools.NewWhoAmITool(h.flyClient, h.authManager, h.logger, h.tools.List),
+	}
+	for _, tool := range append(builtin, extra...) {
+		if err := h.tools.Register(tool); err != nil {
+			return err
+		}
+	}
 
-	// Register Fly.io management tools
-	h.tools["fly_list_apps"] = tools.NewListAppsTool(h.flyClient, h.authManager, h.logger)
-	h.tools["fly_app_info"] = tools.NewAppInfoTool(h.flyClient, h.authManager, h.logger)
-	h.tools["fly_status"] = tools.NewAppStatusTool(h.flyClient, h.authManager, h.logger)
-	h.tools["fly_restart"] = tools.NewAppRestartTool(h.flyClient, h.authManager, h.logger)
-	h.tools["fly_scale"] = tools.NewAppScaleTool(h.flyClient, h.authManager, h.logger)
+	toolProvidersMu.Lock()
+	providers := append([]ToolProvider{}, toolProviders...)
+	toolProvidersMu.Unlock()
+	for _, provider := range providers {
+		if err := provider(h); err != nil {
+			return fmt.Errorf("tool provider failed: %w", err)
+		}
+	}
+
+	if err := h.filterTools(h.config.MCP.Tools); err != nil {
+		return err
+	}
+
+	// Changes from here on are announced to clients
+	h.tools.Subscribe(h.notifyToolsChanged)
 
 	h.logger.Info().
-		Int("total_tools", len(h.tools)).
-		Strs("tool_names", h.getToolNames()).
+		Int("total_tools", len(h.tools.List())).
+		Strs("tool_names", h.tools.Names()).
 		Msg("Tools registered successfully")
 
 	return nil
 }
 
-// getToolNames returns a slice of registered tool names for logging
-func (h *Handler) getToolNames() []string {
-	names := make([]string, 0, len(h.tools))
-	for name := range h.tools {
-		names = append(names, name)
+// filterTools hides the tools mcp.tools leaves out, including tools
+// registered later. A pattern matching no tool is an error, since a
+// misspelled entry in disabled would otherwise leave the tool it meant to
+// disable running.
+func (h *Handler) filterTools(cfg config.MCPToolsConfig) error {
+	if len(cfg.Enabled) == 0 && len(cfg.Disabled) == 0 {
+		return nil
+	}
+
+	names := h.tools.Names()
+	for _, list := range []struct {
+		setting  string
+		patterns []string
+	}{
+		{"mcp.tools.enabled", cfg.Enabled},
+		{"mcp.tools.disabled", cfg.Disabled},
+	} {
+		for _, pattern := range list.patterns {
+			matched := false
+			for _, name := range names {
+				if ok, _ := path.Match(pattern, name); ok {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf("%s: %q matches no tool", list.setting, pattern)
+			}
+		}
+	}
+
+	h.tools.SetFilter(cfg.Allows)
+
+	var disabled []string
+	for _, name := range names {
+		if !cfg.Allows(name) {
+			disabled = append(disabled, name)
+		}
+	}
+	if len(disabled) > 0 {
+		h.logger.Info().
+			Strs("disabled_tools", disabled).
+			Msg("Tools disabled by configuration")
+	}
+	return nil
+}
+
+// availableTools returns the registered tools whose calls the Fly.io token
+// can make. Tools with read-only actions stay available for those actions.
+func (h *Handler) availableTools(ctx context.Context) []interfaces.Tool {
+	scope := h.authManager.TokenScope(ctx)
+	var available []interfaces.Tool
+	for _, tool := range h.tools.List() {
+		if pt, ok := tool.(interfaces.PermissionedTool); ok {
+			action, resource := pt.RequiredPermission()
+			if _, readable := tool.(interfaces.ReadOnlyCallTool); readable {
+				action = "read"
+			}
+			if scope.Permits(action, resource) != nil {
+				continue
+			}
+		}
+		available = append(available, tool)
 	}
-	return names
+	return available
+}
+
+// paramsCursor returns the pagination cursor of a list request
+func paramsCursor(req *MCPRequest) string {
+	params, _ := req.Params.(map[string]interface{})
+	cursor, _ := params["cursor"].(string)
+	return cursor
 }
 
 // sendResponse sends a successful MCP response
@@ -232,7 +1325,12 @@ func (h *Handler) sendResponse(w http.ResponseWriter, response *MCPResponse) err
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	
-	return json.NewEncoder(w).Encode(response)
+	// Large tool results are streamed to the client as they are encoded
+	if err := writeMessage(w, response); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
 }
 
 // sendError sends an MCP error response
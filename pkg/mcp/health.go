@@ -0,0 +1,252 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+const (
+	// readyTTL is how long a successful readiness probe is reused, and
+	// notReadyTTL how long a failed one is
+	readyTTL    = 30 * time.Second
+	notReadyTTL = 5 * time.Second
+	// probeTimeout bounds one Fly.io API probe
+	probeTimeout = 5 * time.Second
+
+	// After breakerThreshold probes in a row fail to reach the Fly.io API,
+	// probing stops for breakerCooldown so readiness checks do not add load
+	// to an API that is already struggling
+	breakerThreshold = 3
+	breakerCooldown  = time.Minute
+
+	// tokenExpiryWarning is how close to expiry a token is reported degraded
+	tokenExpiryWarning = 7 * 24 * time.Hour
+)
+
+// Component states
+const (
+	ComponentOK       = "ok"
+	ComponentDegraded = "degraded"
+	ComponentFailing  = "failing"
+	ComponentSkipped  = "skipped"
+)
+
+// Circuit breaker states
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half_open"
+)
+
+// HealthComponent is the state of one dependency of the server
+type HealthComponent struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Readiness reports whether the server can serve tool calls, i.e. whether
+// the Fly.io API is reachable with a valid token and the configured
+// organization
+type Readiness struct {
+	Ready      bool                       `json:"ready"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+	Components map[string]HealthComponent `json:"components"`
+}
+
+// readinessProbe caches Fly.io API probes and stops probing while the API
+// is unreachable
+type readinessProbe struct {
+	mu        sync.Mutex
+	last      *Readiness
+	expiresAt time.Time
+
+	failures  int
+	openUntil time.Time
+}
+
+// Readiness probes the Fly.io API, reusing a recent result
+func (h *Handler) Readiness(ctx context.Context) *Readiness {
+	p := h.readiness
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	cached := p.last != nil && now.Before(p.expiresAt)
+	breaker := breakerClosed
+	switch {
+	case now.Before(p.openUntil):
+		breaker = breakerOpen
+		cached = p.last != nil
+	case !p.openUntil.IsZero():
+		breaker = breakerHalfOpen
+	}
+
+	if !cached {
+		p.last = h.probeFlyAPI(ctx)
+		p.expiresAt = now.Add(readyTTL)
+		if !p.last.Ready {
+			p.expiresAt = now.Add(notReadyTTL)
+		}
+
+		if p.last.Components["fly_api"].Status == ComponentFailing {
+			p.failures++
+			if p.failures >= breakerThreshold {
+				p.openUntil = now.Add(breakerCooldown)
+				breaker = breakerOpen
+				h.logger.Warn().
+					Int("failures", p.failures).
+					Dur("cooldown", breakerCooldown).
+					Msg("Fly.io API unreachable, pausing readiness probes")
+			}
+		} else {
+			p.failures = 0
+			p.openUntil = time.Time{}
+			breaker = breakerClosed
+		}
+	}
+
+	// Copy so the cached result is not modified by callers
+	result := &Readiness{
+		Ready:      p.last.Ready,
+		CheckedAt:  p.last.CheckedAt,
+		Components: make(map[string]HealthComponent, len(p.last.Components)+2),
+	}
+	for name, c := range p.last.Components {
+		result.Components[name] = c
+	}
+
+	result.Components["probe_cache"] = HealthComponent{
+		Status: ComponentOK,
+		Details: map[string]interface{}{
+			"cached":      cached,
+			"age_seconds": int(now.Sub(p.last.CheckedAt).Seconds()),
+			"ttl_seconds": int(p.expiresAt.Sub(p.last.CheckedAt).Seconds()),
+		},
+	}
+
+	breakerComponent := HealthComponent{
+		Status: ComponentOK,
+		Details: map[string]interface{}{
+			"state":                breaker,
+			"consecutive_failures": p.failures,
+		},
+	}
+	if breaker == breakerOpen {
+		breakerComponent.Status = ComponentDegraded
+		breakerComponent.Message = "Fly.io API probes paused after repeated failures"
+		breakerComponent.Details["open_until"] = p.openUntil.UTC()
+	}
+	result.Components["circuit_breaker"] = breakerComponent
+
+	return result
+}
+
+// probeFlyAPI checks the API, the token and the organization in one
+// batched request
+func (h *Handler) probeFlyAPI(ctx context.Context) *Readiness {
+	// The result is shared, so a caller going away must not cut it short
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), probeTimeout)
+	defer cancel()
+
+	readiness := &Readiness{
+		CheckedAt:  time.Now().UTC(),
+		Components: make(map[string]HealthComponent),
+	}
+
+	start := time.Now()
+	identity, err := h.flyClient.GetIdentity(ctx)
+	latency := time.Since(start)
+
+	if flyErr, ok := fly.AsFlyError(err); ok && (flyErr.Code == fly.ErrorCodeUnauthorized || flyErr.Code == fly.ErrorCodeForbidden) {
+		// The API answered, it just refused the token
+		readiness.Components["fly_api"] = HealthComponent{
+			Status:  ComponentOK,
+			Details: map[string]interface{}{"latency_ms": latency.Milliseconds()},
+		}
+		readiness.Components["token"] = HealthComponent{Status: ComponentFailing, Message: flyErr.Message}
+		readiness.Components["organization"] = HealthComponent{Status: ComponentSkipped, Message: "token rejected"}
+		return readiness
+	}
+	if err != nil {
+		message := err.Error()
+		if flyErr, ok := fly.AsFlyError(err); ok {
+			message = flyErr.Message
+		}
+		readiness.Components["fly_api"] = HealthComponent{Status: ComponentFailing, Message: message}
+		readiness.Components["token"] = HealthComponent{Status: ComponentSkipped, Message: "Fly.io API unreachable"}
+		readiness.Components["organization"] = HealthComponent{Status: ComponentSkipped, Message: "Fly.io API unreachable"}
+		return readiness
+	}
+
+	readiness.Components["fly_api"] = HealthComponent{
+		Status:  ComponentOK,
+		Details: map[string]interface{}{"latency_ms": latency.Milliseconds()},
+	}
+	readiness.Components["token"] = h.tokenComponent(identity)
+	readiness.Components["organization"] = organizationComponent(identity, h.config.Fly.Organization)
+
+	readiness.Ready = true
+	for _, c := range readiness.Components {
+		if c.Status == ComponentFailing {
+			readiness.Ready = false
+		}
+	}
+	return readiness
+}
+
+// tokenComponent reports whether the API accepted the token and when it
+// expires
+func (h *Handler) tokenComponent(identity *fly.Identity) HealthComponent {
+	scope := h.authManager.TokenScope(context.Background())
+	component := HealthComponent{
+		Status:  ComponentOK,
+		Details: map[string]interface{}{"type": auth.TokenType(h.config.Fly.APIToken)},
+	}
+
+	// Org and deploy macaroons have no user; the organization check tells
+	// whether they work. Readiness is unauthenticated, so the user is not
+	// named.
+	if identity.UserErr != nil && (scope == nil || scope.Type != auth.TokenTypeMacaroon) {
+		component.Status = ComponentFailing
+		component.Message = identity.UserErr.Error()
+		return component
+	}
+
+	if scope != nil && !scope.ExpiresAt.IsZero() {
+		component.Details["expires_at"] = scope.ExpiresAt.UTC()
+		switch remaining := time.Until(scope.ExpiresAt); {
+		case remaining <= 0:
+			component.Status = ComponentFailing
+			component.Message = "token has expired"
+		case remaining < tokenExpiryWarning:
+			component.Status = ComponentDegraded
+			component.Message = fmt.Sprintf("token expires in %s", remaining.Round(time.Hour))
+		}
+	}
+	return component
+}
+
+// organizationComponent reports whether the token can access the
+// configured organization
+func organizationComponent(identity *fly.Identity, slug string) HealthComponent {
+	if slug == "" {
+		return HealthComponent{Status: ComponentSkipped, Message: "no organization configured"}
+	}
+	if identity.OrganizationErr != nil {
+		return HealthComponent{
+			Status:  ComponentFailing,
+			Message: identity.OrganizationErr.Error(),
+			Details: map[string]interface{}{"slug": slug},
+		}
+	}
+	return HealthComponent{
+		Status:  ComponentOK,
+		Details: map[string]interface{}{"slug": slug},
+	}
+}
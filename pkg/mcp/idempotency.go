@@ -0,0 +1,196 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/tools"
+)
+
+// idempotencyKeyArg is the argument of mutating tools that makes retries of
+// a call return its result instead of running it again
+const idempotencyKeyArg = "idempotency_key"
+
+// idempotentCall is a mutating call made with an idempotency key. done is
+// closed once result is set.
+type idempotentCall struct {
+	fingerprint string
+	result      *interfaces.ToolResult
+	done        chan struct{}
+	expiresAt   time.Time
+}
+
+// idempotencyStore holds the results of completed mutating calls by client,
+// tool and idempotency key until they expire
+type idempotencyStore struct {
+	mu    sync.Mutex
+	calls map[string]*idempotentCall
+}
+
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{calls: make(map[string]*idempotentCall)}
+}
+
+// len counts the remembered calls
+func (s *idempotencyStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.calls)
+}
+
+// begin claims a key for a call. It returns the earlier call made with the
+// key, running or completed, if there is one, and otherwise the new call
+// the caller must finish.
+func (s *idempotencyStore) begin(key, fingerprint string, ttl time.Duration) (call *idempotentCall, earlier bool) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, c := range s.calls {
+		if c.expiresAt.Before(now) && isClosed(c.done) {
+			delete(s.calls, k)
+		}
+	}
+	if c, ok := s.calls[key]; ok {
+		return c, true
+	}
+
+	call = &idempotentCall{
+		fingerprint: fingerprint,
+		done:        make(chan struct{}),
+		expiresAt:   now.Add(ttl),
+	}
+	s.calls[key] = call
+	return call, false
+}
+
+// finish records the result of a call begun with a key. Only completed
+// mutations are kept: an error result or a preview asking for confirmation
+// frees the key, so that a retry runs the tool again.
+func (s *idempotencyStore) finish(key string, call *idempotentCall, result *interfaces.ToolResult, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if result != nil {
+		// Keep a copy, as the response's metadata is set on the original
+		kept := *result
+		call.result = &kept
+	}
+	call.expiresAt = time.Now().Add(ttl)
+	if !completedMutation(result) {
+		delete(s.calls, key)
+	}
+	close(call.done)
+}
+
+// completedMutation reports whether a result is that of a mutation that was
+// carried out
+func completedMutation(result *interfaces.ToolResult) bool {
+	return result != nil && !result.IsError && !tools.IsConfirmationPreview(result)
+}
+
+// isClosed reports whether a channel is closed
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// idempotencyTTL returns how long the result of a mutating call made with
+// an idempotency key is kept
+func (h *Handler) idempotencyTTL() time.Duration {
+	if h.config.MCP.Idempotency.TTL <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(h.config.MCP.Idempotency.TTL) * time.Second
+}
+
+// idempotencyFingerprint identifies a call's arguments, so that a key
+// reused for a different operation is refused instead of replaying the
+// wrong result
+func idempotencyFingerprint(arguments map[string]interface{}) (string, error) {
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replayIdempotentCall answers a call with the result of the earlier call
+// made with the same key, waiting for it if it is still running. Calls
+// made with other arguments are refused.
+func (h *Handler) replayIdempotentCall(ctx context.Context, toolName, key string, call *idempotentCall, fingerprint string) *interfaces.ToolResult {
+	if call.fingerprint != fingerprint {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: idempotency_key %q was already used for a %s call with different arguments. Use a new key for a different operation.", key, toolName),
+			}},
+			IsError: true,
+		}
+	}
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: the earlier %s call with idempotency_key %q is still running. Retry with the same key to get its result.", toolName, key),
+			}},
+			IsError: true,
+		}
+	}
+	if call.result == nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: the earlier %s call with idempotency_key %q failed without a result. Check the current state before retrying with a new key.", toolName, key),
+			}},
+			IsError: true,
+		}
+	}
+
+	h.metrics.Inc("fly_mcp_idempotent_replays_total", metrics.Labels{"tool": toolName})
+	h.requestLogger(ctx).Info().
+		Str("tool", toolName).
+		Str("idempotency_key", key).
+		Msg("Replayed result of an earlier call")
+
+	replayed := *call.result
+	replayed.Meta = maps.Clone(call.result.Meta)
+	if replayed.Meta == nil {
+		replayed.Meta = make(map[string]interface{})
+	}
+	replayed.Meta["idempotentReplay"] = true
+	return &replayed
+}
+
+// idempotencySchema adds the idempotency_key argument to a mutating tool's
+// input schema
+func idempotencySchema(schema map[string]interface{}) map[string]interface{} {
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return schema
+	}
+
+	extended := maps.Clone(schema)
+	extended["properties"] = maps.Clone(properties)
+	extended["properties"].(map[string]interface{})[idempotencyKeyArg] = map[string]interface{}{
+		"type":        "string",
+		"description": "Unique key for this operation, e.g. a UUID. Retrying the call with the same key and arguments returns the first call's result instead of running the operation again.",
+	}
+	return extended
+}
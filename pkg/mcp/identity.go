@@ -0,0 +1,40 @@
+package mcp
+
+import (
+	"net/http"
+
+	"github.com/brannn/fly-mcp/internal/metrics"
+)
+
+// identify records on the request who the caller is, so permissions, rate
+// limits, sessions and audit events apply to that user. Requests the
+// embedding application already identified with
+// auth.Manager.CreateAuditContext keep their user. Callers that cannot be
+// identified are answered with 401 Unauthorized.
+func (h *Handler) identify(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if userID, ok := r.Context().Value("user_id").(string); ok && userID != "" {
+		return r, true
+	}
+
+	userID, source, err := h.authManager.IdentifyRequest(r)
+	if err != nil {
+		h.metrics.Inc("fly_mcp_requests_rejected_total", metrics.Labels{"reason": "unauthenticated"})
+		h.authManager.LogSecurityEvent(r.Context(), "authentication_failed", "unknown", "mcp", false, map[string]interface{}{
+			"source": source,
+			"client": h.ClientIP(r),
+			"error":  err.Error(),
+		})
+
+		w.Header().Set("WWW-Authenticate", `Bearer realm="fly-mcp"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return r, false
+	}
+
+	h.logger.Debug().
+		Str("user_id", userID).
+		Str("source", source).
+		Msg("Identified MCP caller")
+
+	requestID, _ := r.Context().Value(requestIDKey).(string)
+	return r.WithContext(h.authManager.CreateAuditContext(r.Context(), userID, requestID)), true
+}
@@ -0,0 +1,172 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// inflightCall is a tool execution that has not finished yet
+type inflightCall struct {
+	Tool     string
+	Mutating bool
+	Started  time.Time
+
+	// requestKey identifies the JSON-RPC request for cancellation
+	requestKey string
+	cancel     context.CancelCauseFunc
+}
+
+// inflightTracker tracks running tool executions so shutdown can stop
+// accepting new calls and wait for mutating ones to finish
+type inflightTracker struct {
+	mu       sync.Mutex
+	draining bool
+	nextID   uint64
+	calls    map[uint64]inflightCall
+	wg       sync.WaitGroup
+
+	// readCtx is cancelled when draining starts so read-only calls stop early
+	readCtx    context.Context
+	cancelRead context.CancelFunc
+}
+
+func newInflightTracker() *inflightTracker {
+	readCtx, cancelRead := context.WithCancel(context.Background())
+	return &inflightTracker{
+		calls:      make(map[uint64]inflightCall),
+		readCtx:    readCtx,
+		cancelRead: cancelRead,
+	}
+}
+
+// begin registers a tool execution. It returns false once draining has started.
+func (t *inflightTracker) begin(tool string, mutating bool, requestKey string) (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return 0, false
+	}
+
+	t.nextID++
+	t.calls[t.nextID] = inflightCall{Tool: tool, Mutating: mutating, Started: time.Now(), requestKey: requestKey}
+	t.wg.Add(1)
+	return t.nextID, true
+}
+
+// end marks a tool execution as finished
+func (t *inflightTracker) end(id uint64) {
+	t.mu.Lock()
+	delete(t.calls, id)
+	t.mu.Unlock()
+	t.wg.Done()
+}
+
+// executionContext derives the context call id runs with. Mutating calls are
+// detached from client disconnects so they are never abandoned half-applied;
+// read-only calls are cancelled as soon as draining starts. Either kind can
+// be cancelled explicitly by the client through cancel.
+func (t *inflightTracker) executionContext(ctx context.Context, id uint64, mutating bool) (context.Context, context.CancelFunc) {
+	if mutating {
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	t.mu.Lock()
+	if call, ok := t.calls[id]; ok {
+		call.cancel = cancel
+		t.calls[id] = call
+	}
+	t.mu.Unlock()
+
+	stop := func() bool { return true }
+	if !mutating {
+		stop = context.AfterFunc(t.readCtx, func() { cancel(context.Canceled) })
+	}
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// cancel aborts the running call for a request. It returns the tool name,
+// or false if no such call is running.
+func (t *inflightTracker) cancel(requestKey, reason string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, call := range t.calls {
+		if call.requestKey != requestKey || call.cancel == nil {
+			continue
+		}
+		cause := interfaces.ErrCancelled
+		if reason != "" {
+			cause = fmt.Errorf("%w: %s", interfaces.ErrCancelled, reason)
+		}
+		call.cancel(cause)
+		return call.Tool, true
+	}
+	return "", false
+}
+
+// startDraining rejects new calls and cancels running read-only calls
+func (t *inflightTracker) startDraining() {
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+	t.cancelRead()
+}
+
+// wait blocks until all calls finish or ctx is done
+func (t *inflightTracker) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for %d tool execution(s): %w", len(t.snapshot()), ctx.Err())
+	}
+}
+
+// snapshot returns the running calls, oldest first
+func (t *inflightTracker) snapshot() []inflightCall {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	calls := make([]inflightCall, 0, len(t.calls))
+	for _, call := range t.calls {
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].Started.Before(calls[j].Started)
+	})
+	return calls
+}
+
+// isMutating reports whether a tool call changes infrastructure, based on
+// the permission its tool declares. Calls a tool reports as read-only with
+// ReadOnlyCall, such as a status action, do not; with nil args it reports
+// whether any call of the tool may. Tools without a declared permission
+// (ping, fly_whoami) only report server state.
+func isMutating(tool interfaces.Tool, args map[string]interface{}) bool {
+	pt, ok := tool.(interfaces.PermissionedTool)
+	if !ok {
+		return false
+	}
+	if rt, ok := tool.(interfaces.ReadOnlyCallTool); ok && args != nil && rt.ReadOnlyCall(args) {
+		return false
+	}
+	action, _ := pt.RequiredPermission()
+	return action != "read"
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/notify"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/tools"
+)
+
+// maxNotificationMessage bounds the tool output quoted in a notification
+const maxNotificationMessage = 500
+
+// notifyToolCall tells the configured webhooks about a finished mutating
+// tool call. Read-only actions and previews that only issued a confirmation
+// token changed nothing and are not reported.
+func (h *Handler) notifyToolCall(ctx context.Context, tool interfaces.Tool, args map[string]interface{}, result *interfaces.ToolResult, err error, duration time.Duration) {
+	pt, ok := tool.(interfaces.PermissionedTool)
+	if !ok || !isMutating(tool, args) || tools.IsConfirmationPreview(result) {
+		return
+	}
+
+	action, resource := pt.RequiredPermission()
+	userID, _ := h.authManager.ExtractUserFromContext(ctx)
+	requestID, _ := ctx.Value(requestIDKey).(string)
+
+	event := notify.Event{
+		Tool:       tool.Name(),
+		Action:     action,
+		Resource:   resource,
+		AppName:    stringArg(args, "app_name"),
+		User:       userID,
+		Result:     "success",
+		DurationMS: duration.Milliseconds(),
+		RequestID:  requestID,
+	}
+
+	switch {
+	case errors.Is(context.Cause(ctx), interfaces.ErrCancelled):
+		event.Result = "cancelled"
+	case err != nil:
+		event.Result = "failed"
+		event.Message = err.Error()
+	case result != nil && result.IsError:
+		event.Result = "failed"
+		event.Message = resultText(result)
+	}
+
+	h.notifier.Notify(event)
+}
+
+// notifyApproval tells the configured webhooks about a tool call awaiting
+// approval, with signed links deciding it when they are configured
+func (h *Handler) notifyApproval(approval auth.Approval) {
+	event := notify.Event{
+		Tool:       approval.Tool,
+		AppName:    approval.AppName,
+		User:       approval.RequestedBy,
+		Result:     "pending_approval",
+		Message:    approval.Summary,
+		ApprovalID: approval.ID,
+		Timestamp:  approval.RequestedAt.UTC(),
+	}
+	if tool, ok := h.tools.Get(approval.Tool); ok {
+		if pt, ok := tool.(interfaces.PermissionedTool); ok {
+			event.Action, event.Resource = pt.RequiredPermission()
+		}
+	}
+	if approveURL, ok := h.authManager.ApprovalLink(approval, "approve"); ok {
+		event.ApproveURL = approveURL
+		event.DenyURL, _ = h.authManager.ApprovalLink(approval, "deny")
+	}
+
+	h.notifier.Notify(event)
+}
+
+// resultText returns the text of a tool result, shortened for notifications
+func resultText(result *interfaces.ToolResult) string {
+	var parts []string
+	for _, block := range result.Content {
+		if block.Type == "text" && block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+
+	text := strings.TrimSpace(strings.Join(parts, "\n"))
+	if runes := []rune(text); len(runes) > maxNotificationMessage {
+		text = string(runes[:maxNotificationMessage]) + "…"
+	}
+	return text
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/brannn/fly-mcp/internal/logger"
 	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/tools"
 )
 
 // PingTool is a simple tool for testing MCP functionality
@@ -14,6 +15,12 @@ type PingTool struct {
 	logger *logger.Logger
 }
 
+// pong is the structured result of the ping tool
+type pong struct {
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // Name returns the tool name
 func (t *PingTool) Name() string {
 	return "ping"
@@ -48,14 +55,15 @@ func (t *PingTool) Execute(ctx context.Context, args map[string]interface{}) (*i
 	}
 	
 	// Create response
-	response := fmt.Sprintf("Pong! %s\nTimestamp: %s", message, time.Now().UTC().Format(time.RFC3339))
+	now := time.Now().UTC()
+	response := fmt.Sprintf("Pong! %s\nTimestamp: %s", message, now.Format(time.RFC3339))
 	
 	t.logger.Debug().
 		Str("tool", "ping").
 		Str("message", message).
 		Msg("Ping tool executed")
 	
-	return &interfaces.ToolResult{
+	result := &interfaces.ToolResult{
 		Content: []interfaces.ContentBlock{
 			{
 				Type: "text",
@@ -63,5 +71,6 @@ func (t *PingTool) Execute(ctx context.Context, args map[string]interface{}) (*i
 			},
 		},
 		IsError: false,
-	}, nil
+	}
+	return tools.NewOutputFormatter(ctx, args).Render(result, "Pong", pong{Message: message, Timestamp: now}), nil
 }
@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/pkg/pipeline"
+	"github.com/brannn/fly-mcp/pkg/tools"
+)
+
+// ErrCIDisabled is returned for CI events when integrations.ci is disabled
+var ErrCIDisabled = errors.New("the CI webhook is disabled; set integrations.ci.enabled to turn it on")
+
+// RecordCIEvent stores a build or deploy event a CI system posted, audits
+// it and tells the sessions subscribed to the app's pipeline resource. Only
+// invalid events and a disabled webhook are errors.
+func (h *Handler) RecordCIEvent(ctx context.Context, event pipeline.Event) (pipeline.Event, error) {
+	if h.pipeline == nil {
+		return pipeline.Event{}, ErrCIDisabled
+	}
+
+	event, err := h.pipeline.Record(event)
+	if err != nil {
+		return pipeline.Event{}, err
+	}
+
+	h.metrics.Inc("fly_mcp_ci_events_total", metrics.Labels{"kind": event.Kind, "status": event.Status})
+	h.authManager.AuditLog(ctx, "ci", "ci_"+event.Kind, event.AppName, event.Status, map[string]interface{}{
+		"event_id": event.ID,
+		"source":   event.Source,
+		"pipeline": event.Pipeline,
+		"run_id":   event.RunID,
+		"commit":   event.Commit,
+		"image":    event.Image,
+		"url":      event.URL,
+	})
+	h.notifyResourceUpdated(tools.PipelineResourceURI(event.AppName))
+
+	return event, nil
+}
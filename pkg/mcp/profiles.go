@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"context"
+	"maps"
+
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+// profileArg is the tool argument that selects a token profile for a call
+const profileArg = "fly_profile"
+
+// withProfile returns a context whose Fly.io API calls act with the token
+// profile requested, or the caller's assigned profile when requested is "".
+// It fails when the caller may not use the requested profile.
+func (h *Handler) withProfile(ctx context.Context, requested string) (context.Context, error) {
+	name, err := h.authManager.ResolveProfile(ctx, requested)
+	if err != nil {
+		return ctx, err
+	}
+	if name == "" {
+		return ctx, nil
+	}
+
+	h.logger.Debug().
+		Str("profile", name).
+		Msg("Using Fly.io token profile")
+
+	ctx = auth.WithProfile(ctx, name)
+	return fly.WithToken(ctx, h.authManager.ProfileToken(name)), nil
+}
+
+// profileSchema adds the fly_profile argument to a tool's input schema,
+// listing the profiles the caller may select
+func profileSchema(schema map[string]interface{}, profiles []string) map[string]interface{} {
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok || len(profiles) == 0 {
+		return schema
+	}
+
+	extended := maps.Clone(schema)
+	extended["properties"] = maps.Clone(properties)
+	extended["properties"].(map[string]interface{})[profileArg] = map[string]interface{}{
+		"type":        "string",
+		"description": "Fly.io token profile to act with instead of your default one",
+		"enum":        profiles,
+	}
+	return extended
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// progressStream delivers notifications/progress and elicitation requests
+// for one tools/call as server-sent events, followed by the final response.
+// It is only used when the client accepts event streams and the request
+// carries a progress token or may ask the user for input.
+type progressStream struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	token  interface{}
+	logger *logger.Logger
+	opened bool
+	// messages is set when the client's protocol revision knows the
+	// message of progress notifications
+	messages bool
+}
+
+// newProgressStream returns a stream for the request, or nil if the client
+// did not ask for progress updates. version is the client's protocol
+// revision.
+func newProgressStream(w http.ResponseWriter, r *http.Request, req *MCPRequest, version string, log *logger.Logger) *progressStream {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return nil
+	}
+
+	params, _ := req.Params.(map[string]interface{})
+	meta, _ := params["_meta"].(map[string]interface{})
+	token, ok := meta["progressToken"]
+	if !ok || token == nil {
+		return nil
+	}
+
+	stream := newEventStream(w, log)
+	stream.token = token
+	stream.messages = supports(version, protocolProgressMessage)
+	return stream
+}
+
+// newEventStream returns a stream without a progress token, for requests
+// that only need it to ask the user for input
+func newEventStream(w http.ResponseWriter, log *logger.Logger) *progressStream {
+	return &progressStream{
+		w:      w,
+		rc:     http.NewResponseController(w),
+		logger: log,
+	}
+}
+
+// extendDeadline lets the response stay open for d from now, past the
+// server's write timeout
+func (s *progressStream) extendDeadline(d time.Duration) {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(d)); err != nil {
+		s.logger.Debug().Err(err).Msg("Failed to extend response deadline")
+	}
+}
+
+// keepAlive returns the function letting a tool keep the response to its
+// call open: for the time it asks for, plus the server's write timeout to
+// send the result
+func (h *Handler) keepAlive(w http.ResponseWriter, log *logger.Logger) interfaces.KeepAliveFunc {
+	rc := http.NewResponseController(w)
+	return func(d time.Duration) {
+		d += time.Duration(h.config.Server.WriteTimeout) * time.Second
+		if err := rc.SetWriteDeadline(time.Now().Add(d)); err != nil {
+			log.Debug().Err(err).Msg("Failed to extend response deadline")
+		}
+	}
+}
+
+// notify sends a progress notification. It matches interfaces.ProgressFunc.
+func (s *progressStream) notify(progress, total float64, message string) {
+	params := map[string]interface{}{
+		"progressToken": s.token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" && s.messages {
+		params["message"] = message
+	}
+
+	err := s.send(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/progress",
+		"params":  params,
+	})
+	if err != nil {
+		s.logger.Debug().Err(err).Msg("Failed to send progress notification")
+	}
+}
+
+// send writes one JSON-RPC message as an event and flushes it to the client
+func (s *progressStream) send(message interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.opened {
+		s.w.Header().Set("Content-Type", "text/event-stream")
+		s.w.Header().Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(http.StatusOK)
+		s.opened = true
+	}
+
+	// Compact JSON has no line breaks, so the message fits one data line
+	if _, err := io.WriteString(s.w, "event: message\ndata: "); err != nil {
+		return err
+	}
+	if err := writeMessage(s.w, message); err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if _, err := io.WriteString(s.w, "\n\n"); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
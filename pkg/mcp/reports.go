@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/internal/notify"
+	"github.com/brannn/fly-mcp/pkg/reports"
+	"github.com/brannn/fly-mcp/pkg/tools"
+)
+
+// reportTimeout bounds the collection of one status report
+const reportTimeout = 2 * time.Minute
+
+// statusReporter snapshots the organization's health in the background and
+// keeps the latest report for the fly://reports/latest resource
+type statusReporter struct {
+	interval time.Duration
+
+	mu     sync.RWMutex
+	latest *reports.Report
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startReporter starts the background reporter when mcp.reports is enabled.
+// The first report is collected right away.
+func (h *Handler) startReporter() {
+	cfg := h.config.MCP.Reports
+	if !cfg.Enabled {
+		return
+	}
+
+	h.metrics.Register("fly_mcp_status_reports_total", metrics.KindCounter, "Scheduled status reports by result")
+	h.reporter = &statusReporter{
+		interval: time.Duration(cfg.Interval) * time.Second,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go h.runReporter()
+}
+
+// runReporter collects a report every interval until the reporter stops
+func (h *Handler) runReporter() {
+	r := h.reporter
+	defer close(r.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		h.collectReport(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// collectReport snapshots the organization's health, keeps it as the latest
+// report and posts it to the webhooks when mcp.reports.webhook is set
+func (h *Handler) collectReport(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+
+	start := time.Now()
+	report, err := reports.Collect(ctx, h.flyClient, h.config.Fly.Organization)
+	if err != nil {
+		select {
+		case <-h.reporter.stop:
+			// Cancelled by shutdown
+		default:
+			h.metrics.Inc("fly_mcp_status_reports_total", metrics.Labels{"result": "failed"})
+			h.logger.Warn().Err(err).Msg("Failed to collect status report; keeping the previous one")
+		}
+		return
+	}
+
+	h.reporter.mu.Lock()
+	h.reporter.latest = report
+	h.reporter.mu.Unlock()
+	h.notifyResourceUpdated(tools.ReportsLatestResourceURI)
+
+	h.metrics.Inc("fly_mcp_status_reports_total", metrics.Labels{"result": "success"})
+	h.logger.Info().
+		Int("apps", report.Summary.Apps).
+		Int("failing_checks", report.Summary.FailingChecks).
+		Int("incidents", report.Summary.Incidents).
+		Dur("duration", time.Since(start)).
+		Msg("Collected status report")
+
+	if h.config.MCP.Reports.Webhook {
+		h.notifier.Notify(notify.Event{
+			Tool:      "status_report",
+			Action:    "report",
+			Resource:  "apps",
+			User:      "system",
+			Result:    "report",
+			Message:   report.Headline(),
+			Timestamp: report.GeneratedAt,
+			Report:    report,
+		})
+	}
+}
+
+// latestReport returns the latest status report, restricted to the apps
+// include accepts. It fails while reports are disabled or the first one is
+// still being collected.
+func (h *Handler) latestReport(include func(appName string) bool) (*reports.Report, error) {
+	if h.reporter == nil {
+		return nil, fmt.Errorf("status reports are disabled; set mcp.reports.enabled to turn them on")
+	}
+
+	h.reporter.mu.RLock()
+	latest := h.reporter.latest
+	h.reporter.mu.RUnlock()
+	if latest == nil {
+		return nil, fmt.Errorf("the first status report is still being collected")
+	}
+	return latest.Filter(include), nil
+}
+
+// stopReporter stops the background reporter, cancelling a report being
+// collected, and waits until it has stopped or ctx expires
+func (h *Handler) stopReporter(ctx context.Context) error {
+	r := h.reporter
+	if r == nil {
+		return nil
+	}
+
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("status reporter still running: %w", ctx.Err())
+	}
+}
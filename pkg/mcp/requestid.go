@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// requestIDKey is the context key of the request ID. The server's request
+// ID middleware, auth.Manager.CreateAuditContext and the Fly.io API
+// transport use the same key.
+const requestIDKey = "request_id"
+
+// withRequestID gives an MCP call its request ID. A call sent on its own
+// takes the ID of its HTTP request, so it matches the X-Request-ID response
+// header; calls in a batch append their position to it. Without an HTTP
+// request ID, as when fly-mcp is embedded, a new one is generated. Audit
+// events and Fly.io API call logs pick the ID up from the context.
+func (h *Handler) withRequestID(r *http.Request, position int) (*http.Request, string) {
+	id, _ := r.Context().Value(requestIDKey).(string)
+	if id == "" {
+		b := make([]byte, 8)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	if position > 0 {
+		id = fmt.Sprintf("%s-%d", id, position)
+	}
+
+	userID, _ := h.authManager.ExtractUserFromContext(r.Context())
+	return r.WithContext(h.authManager.CreateAuditContext(r.Context(), userID, id)), id
+}
+
+// requestLogger returns the handler's logger with the request ID of the
+// call in ctx, if it has one
+func (h *Handler) requestLogger(ctx context.Context) *logger.Logger {
+	if id, ok := ctx.Value(requestIDKey).(string); ok && id != "" {
+		return h.logger.WithContext(map[string]interface{}{requestIDKey: id})
+	}
+	return h.logger
+}
+
+// annotateRequestID adds the request ID to a response's metadata: _meta of
+// results and the data of errors
+func annotateRequestID(response *MCPResponse, id string) {
+	if response == nil {
+		return
+	}
+
+	if response.Error != nil {
+		data, ok := response.Error.Data.(map[string]interface{})
+		if !ok && response.Error.Data != nil {
+			return
+		}
+		data = maps.Clone(data)
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+		data["requestId"] = id
+		response.Error.Data = data
+		return
+	}
+
+	switch result := response.Result.(type) {
+	case *interfaces.ToolResult:
+		meta := maps.Clone(result.Meta)
+		if meta == nil {
+			meta = make(map[string]interface{})
+		}
+		meta["requestId"] = id
+		result.Meta = meta
+	case map[string]interface{}:
+		result["_meta"] = map[string]interface{}{"requestId": id}
+	}
+}
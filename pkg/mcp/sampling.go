@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/metrics"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/session"
+)
+
+// canSample reports whether a tools/call request can run prompts on the
+// client's model: sampling is enabled, and the client declared support for
+// it on initialize and accepts an event stream, on which the request is
+// sent
+func (h *Handler) canSample(r *http.Request) bool {
+	if !h.config.MCP.Sampling.Enabled || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return false
+	}
+	sess, ok := session.FromContext(r.Context())
+	return ok && sess.SupportsSampling()
+}
+
+// sampler returns the function running prompts on the client's model with
+// sampling/createMessage requests on stream. The server's prompts carry no
+// context of the conversation, and answers are bounded by
+// mcp.sampling.max_tokens.
+func (h *Handler) sampler(client string, stream *progressStream) interfaces.SampleFunc {
+	cfg := h.config.MCP.Sampling
+	timeout := time.Duration(cfg.Timeout) * time.Second
+
+	return func(ctx context.Context, req interfaces.SampleRequest) (interfaces.SampleResult, error) {
+		maxTokens := cfg.MaxTokens
+		if req.MaxTokens > 0 && req.MaxTokens < maxTokens {
+			maxTokens = req.MaxTokens
+		}
+
+		params := map[string]interface{}{
+			"messages": []map[string]interface{}{{
+				"role":    "user",
+				"content": map[string]interface{}{"type": "text", "text": req.Prompt},
+			}},
+			"includeContext": "none",
+			"maxTokens":      maxTokens,
+		}
+		if req.SystemPrompt != "" {
+			params["systemPrompt"] = req.SystemPrompt
+		}
+
+		start := time.Now()
+		raw, err := h.requestClient(ctx, client, stream, "sampling/createMessage", params, timeout)
+		if errors.Is(err, errClientTimeout) {
+			h.metrics.Inc("fly_mcp_sampling_requests_total", metrics.Labels{"result": "timeout"})
+			return interfaces.SampleResult{}, err
+		}
+		if err != nil {
+			h.metrics.Inc("fly_mcp_sampling_requests_total", metrics.Labels{"result": "error"})
+			return interfaces.SampleResult{}, err
+		}
+
+		var message struct {
+			Model   string `json:"model"`
+			Content struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		}
+		if err := json.Unmarshal(raw, &message); err != nil {
+			h.metrics.Inc("fly_mcp_sampling_requests_total", metrics.Labels{"result": "error"})
+			return interfaces.SampleResult{}, fmt.Errorf("failed to decode sampling result: %w", err)
+		}
+		if message.Content.Type != "text" || strings.TrimSpace(message.Content.Text) == "" {
+			h.metrics.Inc("fly_mcp_sampling_requests_total", metrics.Labels{"result": "error"})
+			return interfaces.SampleResult{}, fmt.Errorf("client answered sampling request without text")
+		}
+
+		h.metrics.Inc("fly_mcp_sampling_requests_total", metrics.Labels{"result": "success"})
+		h.requestLogger(ctx).Debug().
+			Str("model", message.Model).
+			Dur("duration", time.Since(start)).
+			Msg("Client model answered sampling request")
+
+		return interfaces.SampleResult{Text: message.Content.Text, Model: message.Model}, nil
+	}
+}
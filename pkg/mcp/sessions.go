@@ -0,0 +1,173 @@
+package mcp
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/session"
+)
+
+// resolveSession attaches the caller's session to the request. initialize
+// opens a new session and returns its ID in the response header; later
+// requests carrying the header join it. Requests without the header run
+// without session context. It reports false, after writing a 404, when the
+// session is unknown or expired, telling the client to initialize again.
+func (h *Handler) resolveSession(w http.ResponseWriter, r *http.Request, req *MCPRequest) (*http.Request, bool) {
+	if req.Method == "initialize" {
+		sess := h.sessions.Create(h.clientKey(r))
+		w.Header().Set(session.HeaderName, sess.ID())
+
+		h.logger.Debug().
+			Str("session_id", sess.ID()).
+			Msg("Opened MCP session")
+
+		return r.WithContext(session.WithSession(r.Context(), sess)), true
+	}
+
+	id := r.Header.Get(session.HeaderName)
+	if id == "" {
+		return r, true
+	}
+
+	sess, ok := h.lookupSession(r, id)
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return r, false
+	}
+	return r.WithContext(session.WithSession(r.Context(), sess)), true
+}
+
+// lookupSession returns an open session belonging to the caller. Sessions
+// opened by another client are treated as unknown.
+func (h *Handler) lookupSession(r *http.Request, id string) (*session.Session, bool) {
+	sess, ok := h.sessions.Get(id)
+	if !ok || sess.Owner() != h.clientKey(r) {
+		return nil, false
+	}
+	return sess, true
+}
+
+// EndSession handles DELETE requests, which close the caller's session
+func (h *Handler) EndSession(w http.ResponseWriter, r *http.Request) {
+	r, ok := h.identify(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.Header.Get(session.HeaderName)
+	if id == "" {
+		http.Error(w, "Missing "+session.HeaderName+" header", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := h.lookupSession(r, id); !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	h.sessions.Delete(id)
+	h.subscriptions.removeSession(id)
+
+	h.logger.Debug().
+		Str("session_id", id).
+		Msg("Closed MCP session")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applySessionDefaults fills in app_name and organization from the session
+// when a tool call omits them. Only tools that act on Fly.io resources take
+// part; tools such as fly_session manage the context themselves.
+func (h *Handler) applySessionDefaults(sess *session.Session, tool interfaces.Tool, args map[string]interface{}) {
+	if _, ok := tool.(interfaces.PermissionedTool); !ok {
+		return
+	}
+	schema := tool.InputSchema()
+
+	if requiresArg(schema, "app_name") && stringArg(args, "app_name") == "" {
+		if appName := sess.DefaultApp(); appName != "" {
+			args["app_name"] = appName
+			h.logger.Debug().
+				Str("tool", tool.Name()).
+				Str("app_name", appName).
+				Msg("Using session default app")
+		}
+	}
+
+	if hasProperty(schema, "organization") && stringArg(args, "organization") == "" {
+		if org := sess.Organization(); org != "" {
+			args["organization"] = org
+		}
+	}
+}
+
+// recordSessionContext remembers the app and organization of a successful
+// tool call, so follow-up calls can leave them out
+func (h *Handler) recordSessionContext(sess *session.Session, tool interfaces.Tool, args map[string]interface{}) {
+	pt, ok := tool.(interfaces.PermissionedTool)
+	if !ok {
+		return
+	}
+	schema := tool.InputSchema()
+	appName := stringArg(args, "app_name")
+
+	if stringArg(args, "confirmation_token") != "" {
+		if p := sess.PendingConfirmation(); p != nil && p.Tool == tool.Name() {
+			sess.ClearPendingConfirmation()
+		}
+	}
+
+	if action, resource := pt.RequiredPermission(); action == "delete" && resource == "app" {
+		// The app is gone, so it must not stay the default
+		if sess.DefaultApp() == appName {
+			sess.SetDefaultApp("")
+		}
+		return
+	}
+
+	if requiresArg(schema, "app_name") && appName != "" {
+		sess.SetDefaultApp(appName)
+	}
+	if hasProperty(schema, "organization") {
+		if org := stringArg(args, "organization"); org != "" {
+			sess.SetOrganization(org)
+		}
+	}
+}
+
+// sessionSchema returns a tool's input schema as advertised to clients with
+// a session: app_name becomes optional because the session supplies it
+func sessionSchema(schema map[string]interface{}) map[string]interface{} {
+	required, ok := schema["required"].([]string)
+	if !ok || !slices.Contains(required, "app_name") {
+		return schema
+	}
+
+	relaxed := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		relaxed[k] = v
+	}
+	relaxed["required"] = slices.DeleteFunc(slices.Clone(required), func(name string) bool {
+		return name == "app_name"
+	})
+	return relaxed
+}
+
+// requiresArg reports whether a schema lists name as required
+func requiresArg(schema map[string]interface{}, name string) bool {
+	required, _ := schema["required"].([]string)
+	return slices.Contains(required, name)
+}
+
+// hasProperty reports whether a schema declares the property name
+func hasProperty(schema map[string]interface{}, name string) bool {
+	properties, _ := schema["properties"].(map[string]interface{})
+	_, ok := properties[name]
+	return ok
+}
+
+// stringArg returns a string argument, or "" when it is missing
+func stringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
@@ -0,0 +1,194 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/session"
+)
+
+const (
+	// streamKeepalive is how often an idle notification stream sends a
+	// comment, so proxies do not close it
+	streamKeepalive = 30 * time.Second
+	// streamBuffer is how many notifications a slow client may fall behind
+	// before further ones are dropped for it
+	streamBuffer = 16
+)
+
+// notificationStreams fans server notifications out to the clients holding
+// a GET /mcp stream open. Each stream belongs to the session it was opened
+// in, if any.
+type notificationStreams struct {
+	mu      sync.Mutex
+	streams map[chan []byte]string
+	done    chan struct{}
+	closed  bool
+}
+
+func newNotificationStreams() *notificationStreams {
+	return &notificationStreams{
+		streams: make(map[chan []byte]string),
+		done:    make(chan struct{}),
+	}
+}
+
+// subscribe opens a stream for a session, or for no session when sessionID
+// is empty. It reports false once the handler is draining.
+func (n *notificationStreams) subscribe(sessionID string) (chan []byte, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return nil, false
+	}
+	ch := make(chan []byte, streamBuffer)
+	n.streams[ch] = sessionID
+	return ch, true
+}
+
+func (n *notificationStreams) unsubscribe(ch chan []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.streams, ch)
+}
+
+// len counts the open streams
+func (n *notificationStreams) len() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.streams)
+}
+
+// broadcast queues a message on every stream without waiting for clients
+func (n *notificationStreams) broadcast(message []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.streams {
+		select {
+		case ch <- message:
+		default:
+		}
+	}
+}
+
+// send queues a message on the streams of one session without waiting for
+// the client
+func (n *notificationStreams) send(sessionID string, message []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch, id := range n.streams {
+		if id != sessionID {
+			continue
+		}
+		select {
+		case ch <- message:
+		default:
+		}
+	}
+}
+
+// closeAll ends every stream, so shutting the HTTP server down does not
+// wait on them
+func (n *notificationStreams) closeAll() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.closed {
+		n.closed = true
+		close(n.done)
+	}
+}
+
+// notifyToolsChanged tells connected clients to list the tools again. It
+// only does so when the server advertises tools.listChanged.
+func (h *Handler) notifyToolsChanged() {
+	if !h.config.MCP.Capabilities.Tools.ListChanged {
+		return
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/tools/list_changed",
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to encode tools/list_changed notification")
+		return
+	}
+	h.streams.broadcast(data)
+
+	h.logger.Debug().Msg("Sent tools/list_changed notification")
+}
+
+// StreamNotifications handles GET requests on the MCP endpoint, which open a
+// server-sent event stream for notifications not tied to a request, such as
+// notifications/tools/list_changed and, for the session's subscriptions,
+// notifications/resources/updated
+func (h *Handler) StreamNotifications(w http.ResponseWriter, r *http.Request) {
+	r, ok := h.identify(w, r)
+	if !ok {
+		return
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "Accept must include text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	id := r.Header.Get(session.HeaderName)
+	if id != "" {
+		if _, ok := h.lookupSession(r, id); !ok {
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	ch, ok := h.streams.subscribe(id)
+	if !ok {
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.streams.unsubscribe(ch)
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.Debug().Err(err).Msg("Notification stream cannot be flushed")
+		return
+	}
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.streams.done:
+			return
+		case message := <-ch:
+			_, err = fmt.Fprintf(w, "event: message\ndata: %s\n\n", message)
+		case <-keepalive.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			h.logger.Debug().Err(err).Msg("Notification stream closed")
+			return
+		}
+	}
+}
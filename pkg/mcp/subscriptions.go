@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/brannn/fly-mcp/pkg/session"
+	"github.com/brannn/fly-mcp/pkg/tools"
+)
+
+// resourceSubscriptions records which sessions subscribed to which resource
+// URIs with resources/subscribe
+type resourceSubscriptions struct {
+	mu    sync.Mutex
+	byURI map[string]map[string]struct{}
+}
+
+func newResourceSubscriptions() *resourceSubscriptions {
+	return &resourceSubscriptions{byURI: make(map[string]map[string]struct{})}
+}
+
+func (s *resourceSubscriptions) add(uri, sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.byURI[uri] == nil {
+		s.byURI[uri] = make(map[string]struct{})
+	}
+	s.byURI[uri][sessionID] = struct{}{}
+}
+
+func (s *resourceSubscriptions) remove(uri, sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.byURI[uri], sessionID)
+	if len(s.byURI[uri]) == 0 {
+		delete(s.byURI, uri)
+	}
+}
+
+// removeSession drops every subscription of a session
+func (s *resourceSubscriptions) removeSession(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uri, sessions := range s.byURI {
+		delete(sessions, sessionID)
+		if len(sessions) == 0 {
+			delete(s.byURI, uri)
+		}
+	}
+}
+
+// sessions returns the sessions subscribed to a URI
+func (s *resourceSubscriptions) sessions(uri string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.byURI[uri]))
+	for id := range s.byURI[uri] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// len counts the subscriptions of all sessions
+func (s *resourceSubscriptions) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, sessions := range s.byURI {
+		n += len(sessions)
+	}
+	return n
+}
+
+// handleResourcesSubscribe handles resources/subscribe and
+// resources/unsubscribe. Subscriptions belong to the caller's session, whose
+// GET /mcp stream receives notifications/resources/updated when a
+// subscribed resource changes.
+func (h *Handler) handleResourcesSubscribe(r *http.Request, req *MCPRequest) (*MCPResponse, error) {
+	if !h.config.MCP.Capabilities.Resources.Subscribe {
+		return nil, methodNotFound(req.Method)
+	}
+
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		return nil, invalidParams("", "params must be an object with the resource uri")
+	}
+
+	uri, ok := params["uri"].(string)
+	if !ok || uri == "" {
+		return nil, invalidParams("uri", "resource uri is required")
+	}
+
+	sess, ok := session.FromContext(r.Context())
+	if !ok {
+		return nil, invalidParams("", "%s requires a session; send the %s header returned by initialize", req.Method, session.HeaderName)
+	}
+
+	if req.Method == "resources/unsubscribe" {
+		h.subscriptions.remove(uri, sess.ID())
+		return &MCPResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{}}, nil
+	}
+
+	appName, _, err := tools.ParseResourceURI(uri)
+	if err != nil {
+		return nil, newRPCError(codeResourceNotFound, "Resource not found", err, map[string]interface{}{"uri": uri})
+	}
+
+	ctx, err := h.withProfile(r.Context(), "")
+	if err != nil {
+		return nil, permissionDenied(err, map[string]interface{}{"uri": uri})
+	}
+	if err := h.authorizeResource(ctx, appName); err != nil {
+		return nil, permissionDenied(err, map[string]interface{}{"uri": uri})
+	}
+
+	h.subscriptions.add(uri, sess.ID())
+
+	h.logger.Debug().
+		Str("session_id", sess.ID()).
+		Str("uri", uri).
+		Msg("Subscribed to resource")
+
+	return &MCPResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{}}, nil
+}
+
+// notifyResourceUpdated tells the sessions subscribed to a resource that it
+// changed. Subscriptions of sessions that have since expired are dropped.
+func (h *Handler) notifyResourceUpdated(uri string) {
+	ids := h.subscriptions.sessions(uri)
+	if len(ids) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/resources/updated",
+		"params":  map[string]interface{}{"uri": uri},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to encode resources/updated notification")
+		return
+	}
+
+	for _, id := range ids {
+		if _, ok := h.sessions.Get(id); !ok {
+			h.subscriptions.removeSession(id)
+			continue
+		}
+		h.streams.send(id, data)
+	}
+
+	h.logger.Debug().
+		Str("uri", uri).
+		Int("sessions", len(ids)).
+		Msg("Sent resources/updated notification")
+}
@@ -94,8 +94,9 @@ type InitializeParams struct {
 
 // ClientCapabilities represents the capabilities of the MCP client
 type ClientCapabilities struct {
-	Roots    *RootsCapability    `json:"roots,omitempty"`
-	Sampling *SamplingCapability `json:"sampling,omitempty"`
+	Roots       *RootsCapability       `json:"roots,omitempty"`
+	Sampling    *SamplingCapability    `json:"sampling,omitempty"`
+	Elicitation *ElicitationCapability `json:"elicitation,omitempty"`
 }
 
 // RootsCapability represents roots-related capabilities
@@ -106,6 +107,9 @@ type RootsCapability struct {
 // SamplingCapability represents sampling-related capabilities
 type SamplingCapability struct{}
 
+// ElicitationCapability represents elicitation-related capabilities
+type ElicitationCapability struct{}
+
 // ClientInfo represents information about the MCP client
 type ClientInfo struct {
 	Name    string `json:"name"`
@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/session"
+)
+
+// protocolVersionHeader carries the negotiated protocol revision on the
+// requests a client sends after initialize
+const protocolVersionHeader = "MCP-Protocol-Version"
+
+// Protocol revisions that introduced features older revisions lack.
+// Revisions are dates, so they compare as strings.
+const (
+	// protocolStreamableHTTP introduced the Streamable HTTP transport, whose
+	// GET stream carries list_changed notifications
+	protocolStreamableHTTP = "2025-03-26"
+	// protocolToolAnnotations introduced tool annotations such as
+	// readOnlyHint
+	protocolToolAnnotations = "2025-03-26"
+	// protocolProgressMessage introduced the message of progress
+	// notifications
+	protocolProgressMessage = "2025-03-26"
+	// protocolStructuredContent introduced structuredContent and
+	// resource_link content blocks in tool results
+	protocolStructuredContent = "2025-06-18"
+	// protocolElicitation introduced elicitation/create, with which the
+	// server asks the client's user for input
+	protocolElicitation = "2025-06-18"
+	// protocolNoBatching removed JSON-RPC batching
+	protocolNoBatching = "2025-06-18"
+)
+
+type protocolVersionKey struct{}
+
+// supports reports whether a protocol revision includes a feature
+// introduced in since
+func supports(version, since string) bool {
+	return version >= since
+}
+
+// negotiateVersion returns the revision to speak with a client that asked
+// for requested on initialize: that revision if the server supports it,
+// otherwise the newest one offered
+func (h *Handler) negotiateVersion(requested string) string {
+	if h.offersVersion(requested) {
+		return requested
+	}
+	return h.config.MCP.Version
+}
+
+// offersVersion reports whether a revision is supported and not newer than
+// mcp.version
+func (h *Handler) offersVersion(version string) bool {
+	return slices.Contains(config.ProtocolVersions, version) && version <= h.config.MCP.Version
+}
+
+// resolveProtocolVersion records on the request the revision the client
+// speaks: the one its MCP-Protocol-Version header names, else the one
+// negotiated for its session, else the newest offered. A header naming a
+// revision that is not offered is answered with 400 Bad Request.
+func (h *Handler) resolveProtocolVersion(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	version := r.Header.Get(protocolVersionHeader)
+	switch {
+	case version != "":
+		if !h.offersVersion(version) {
+			http.Error(w, "Unsupported "+protocolVersionHeader+": "+version, http.StatusBadRequest)
+			return r, false
+		}
+	default:
+		if sess, ok := session.FromContext(r.Context()); ok {
+			version = sess.ProtocolVersion()
+		}
+		if version == "" {
+			version = h.config.MCP.Version
+		}
+	}
+	return r.WithContext(context.WithValue(r.Context(), protocolVersionKey{}, version)), true
+}
+
+// protocolVersion returns the revision recorded for a request, or the newest
+// offered
+func (h *Handler) protocolVersion(ctx context.Context) string {
+	if version, ok := ctx.Value(protocolVersionKey{}).(string); ok {
+		return version
+	}
+	return h.config.MCP.Version
+}
+
+// adaptToolResult removes from a tool result what the client's revision
+// does not know: structured content and resource links before 2025-06-18
+func adaptToolResult(version string, result *interfaces.ToolResult) *interfaces.ToolResult {
+	if result == nil || supports(version, protocolStructuredContent) {
+		return result
+	}
+
+	adapted := *result
+	adapted.StructuredContent = nil
+	adapted.Content = make([]interfaces.ContentBlock, 0, len(result.Content))
+	for _, block := range result.Content {
+		if block.Type != "resource_link" {
+			adapted.Content = append(adapted.Content, block)
+		}
+	}
+	return &adapted
+}
+
+// toolAnnotations returns the hints describing how a tool behaves. Tools
+// that act on Fly.io reach an open world; only those none of whose calls
+// change anything are read-only, since the hint covers every call.
+func toolAnnotations(tool interfaces.Tool) map[string]interface{} {
+	if _, ok := tool.(interfaces.PermissionedTool); !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"readOnlyHint":  !isMutating(tool, nil),
+		"openWorldHint": true,
+	}
+}
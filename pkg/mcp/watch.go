@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/notify"
+	"github.com/brannn/fly-mcp/pkg/alerts"
+	"github.com/brannn/fly-mcp/pkg/tools"
+	"github.com/brannn/fly-mcp/pkg/watcher"
+)
+
+// startWatcher starts the machine watcher when mcp.watcher is enabled
+func (h *Handler) startWatcher() {
+	if !h.config.MCP.Watcher.Enabled {
+		return
+	}
+	h.watcher = watcher.New(h.flyClient, h.config.MCP.Watcher, h.logger, h.onWatcherPoll)
+}
+
+// onWatcherPoll evaluates the alert rules after every watcher poll. When
+// the poll found transitions, it tells the sessions subscribed to the
+// watcher or to a changed app's machines, and posts the transitions to the
+// webhooks as alerts when mcp.watcher.webhook is set.
+func (h *Handler) onWatcherPoll(transitions []watcher.Transition) {
+	if h.alerts != nil {
+		h.alerts.Evaluate(h.watcher.Snapshot(func(string) bool { return true }), transitions, time.Now().UTC())
+	}
+	if len(transitions) == 0 {
+		return
+	}
+
+	h.notifyResourceUpdated(tools.WatcherResourceURI)
+
+	changed := make(map[string]bool)
+	for _, t := range transitions {
+		if !changed[t.AppName] {
+			changed[t.AppName] = true
+			h.notifyResourceUpdated(tools.MachinesResourceURI(t.AppName))
+		}
+
+		if h.config.MCP.Watcher.Webhook {
+			h.notifier.Notify(notify.Event{
+				Tool:      "machine_watcher",
+				Action:    t.Kind,
+				Resource:  "machine",
+				AppName:   t.AppName,
+				User:      "system",
+				Result:    "alert",
+				Message:   t.Message,
+				Timestamp: t.Time,
+			})
+		}
+	}
+}
+
+// notifyAlert posts an alert that fired or resolved to the webhooks
+func (h *Handler) notifyAlert(alert alerts.Alert) {
+	event := notify.Event{
+		Tool:     "alert_rules",
+		Action:   alert.Condition,
+		Resource: "app",
+		AppName:  alert.AppName,
+		User:     "system",
+		Result:   "alert",
+		Message:  fmt.Sprintf("%s alert %s: %s", alert.Severity, alert.Rule, alert.Message),
+	}
+	if alert.State == alerts.StateResolved {
+		event.Result = "resolved"
+		event.Message = fmt.Sprintf("Resolved %s alert %s: %s", alert.Severity, alert.Rule, alert.Message)
+	}
+
+	h.logger.Info().
+		Str("rule", alert.Rule).
+		Str("app_name", alert.AppName).
+		Str("state", alert.State).
+		Msg(event.Message)
+
+	h.notifier.Notify(event)
+}
@@ -0,0 +1,192 @@
+// Package pipeline keeps the build and deploy events CI systems post to the
+// server, so the assistant can line an app's CI history up with what its
+// machines did, e.g. that a CI deploy finished minutes before errors began.
+package pipeline
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Kinds of pipeline event
+const (
+	KindBuild  = "build"
+	KindTest   = "test"
+	KindDeploy = "deploy"
+)
+
+// Statuses of a pipeline event
+const (
+	StatusStarted   = "started"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Kinds lists the accepted event kinds
+var Kinds = []string{KindBuild, KindTest, KindDeploy}
+
+// Statuses lists the accepted event statuses
+var Statuses = []string{StatusStarted, StatusSucceeded, StatusFailed, StatusCancelled}
+
+// Event is a build, test or deploy step a CI system reported for an app
+type Event struct {
+	ID      string `json:"id"`
+	AppName string `json:"app_name"`
+	Kind    string `json:"kind"`
+	Status  string `json:"status"`
+	// Source names the CI system, e.g. github-actions or buildkite, and
+	// Pipeline the workflow or pipeline within it
+	Source   string `json:"source,omitempty"`
+	Pipeline string `json:"pipeline,omitempty"`
+	RunID    string `json:"run_id,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Ref      string `json:"ref,omitempty"`
+	Image    string `json:"image,omitempty"`
+	Actor    string `json:"actor,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// StartedAt and FinishedAt are when the step ran, as the CI system
+	// says; ReceivedAt is when the server got the event
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ReceivedAt time.Time  `json:"received_at"`
+}
+
+// Time returns when the step finished, or else started, or else when the
+// event was received
+func (e Event) Time() time.Time {
+	switch {
+	case e.FinishedAt != nil:
+		return *e.FinishedAt
+	case e.StartedAt != nil:
+		return *e.StartedAt
+	}
+	return e.ReceivedAt
+}
+
+// Validate rejects events without an app or with an unknown kind or status
+func (e Event) Validate() error {
+	if e.AppName == "" {
+		return fmt.Errorf("app_name is required")
+	}
+	if !slices.Contains(Kinds, e.Kind) {
+		return fmt.Errorf("kind must be one of %v", Kinds)
+	}
+	if !slices.Contains(Statuses, e.Status) {
+		return fmt.Errorf("status must be one of %v", Statuses)
+	}
+	if e.StartedAt != nil && e.FinishedAt != nil && e.FinishedAt.Before(*e.StartedAt) {
+		return fmt.Errorf("finished_at is before started_at")
+	}
+	return nil
+}
+
+// Pipeline is an app's recent CI history
+type Pipeline struct {
+	AppName string `json:"app_name"`
+	// LastBuild and LastDeploy are the newest build and deploy events,
+	// with how long ago they happened
+	LastBuild  *Summary `json:"last_build,omitempty"`
+	LastDeploy *Summary `json:"last_deploy,omitempty"`
+	// Events are newest first
+	Events []Event `json:"events"`
+}
+
+// Summary is the newest event of a kind
+type Summary struct {
+	Event
+	Ago string `json:"ago"`
+}
+
+// Store keeps the newest events of each app in memory
+type Store struct {
+	size int
+
+	mu     sync.Mutex
+	events map[string][]Event
+	next   int
+}
+
+// defaultSize is how many events per app a store keeps when no size is set
+const defaultSize = 50
+
+// New creates a store that keeps the last size events of each app
+func New(size int) *Store {
+	if size < 1 {
+		size = defaultSize
+	}
+	return &Store{size: size, events: make(map[string][]Event)}
+}
+
+// Record validates an event, gives it an ID and receipt time and adds it.
+// Events about the same run, kind and status replace each other, so CI
+// retries do not fill the history.
+func (s *Store) Record(e Event) (Event, error) {
+	if err := e.Validate(); err != nil {
+		return Event{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.next++
+	e.ID = fmt.Sprintf("ci-%d", s.next)
+	e.ReceivedAt = time.Now()
+
+	events := s.events[e.AppName]
+	if e.RunID != "" {
+		events = slices.DeleteFunc(events, func(old Event) bool {
+			return old.RunID == e.RunID && old.Kind == e.Kind && old.Status == e.Status
+		})
+	}
+	events = append([]Event{e}, events...)
+	if len(events) > s.size {
+		events = events[:s.size]
+	}
+	s.events[e.AppName] = events
+	return e, nil
+}
+
+// Apps returns the apps that have events, sorted
+func (s *Store) Apps() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	apps := make([]string, 0, len(s.events))
+	for app := range s.events {
+		apps = append(apps, app)
+	}
+	slices.Sort(apps)
+	return apps
+}
+
+// Pipeline returns an app's events, newest first, and its last build and
+// deploy
+func (s *Store) Pipeline(appName string) *Pipeline {
+	s.mu.Lock()
+	events := slices.Clone(s.events[appName])
+	s.mu.Unlock()
+
+	// CI systems may post late, so order by when the steps happened
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return b.Time().Compare(a.Time())
+	})
+
+	p := &Pipeline{AppName: appName, Events: events}
+	if p.Events == nil {
+		p.Events = []Event{}
+	}
+	now := time.Now()
+	for _, e := range events {
+		switch {
+		case e.Kind == KindBuild && p.LastBuild == nil:
+			p.LastBuild = &Summary{Event: e, Ago: now.Sub(e.Time()).Round(time.Second).String()}
+		case e.Kind == KindDeploy && p.LastDeploy == nil:
+			p.LastDeploy = &Summary{Event: e, Ago: now.Sub(e.Time()).Round(time.Second).String()}
+		}
+	}
+	return p
+}
@@ -0,0 +1,373 @@
+// Package reports snapshots the health of an organization's applications,
+// their statuses, failing health checks and recent incidents, into a status
+// report an assistant can read at the start of a session.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/doctor"
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+const (
+	// IncidentWindow is how far back machine events are read for incidents
+	IncidentWindow = 24 * time.Hour
+
+	// collectConcurrency is how many apps are inspected at once
+	collectConcurrency = 8
+)
+
+// App health states
+const (
+	HealthHealthy   = "healthy"
+	HealthDegraded  = "degraded"
+	HealthFailing   = "failing"
+	HealthSuspended = "suspended"
+)
+
+// Report is a snapshot of the health of an organization's applications
+type Report struct {
+	Organization string    `json:"organization,omitempty"`
+	GeneratedAt  time.Time `json:"generatedAt"`
+	// Window is how far back incidents are reported, e.g. 24h
+	Window        string         `json:"window"`
+	Summary       Summary        `json:"summary"`
+	Apps          []AppHealth    `json:"apps"`
+	FailingChecks []FailingCheck `json:"failingChecks"`
+	Incidents     []Incident     `json:"incidents"`
+	Warnings      []string       `json:"warnings,omitempty"`
+}
+
+// Summary counts the apps of a report by health, and the problems found
+type Summary struct {
+	Apps          int `json:"apps"`
+	Healthy       int `json:"healthy"`
+	Degraded      int `json:"degraded"`
+	Failing       int `json:"failing"`
+	Suspended     int `json:"suspended"`
+	Machines      int `json:"machines"`
+	Started       int `json:"started"`
+	FailingChecks int `json:"failingChecks"`
+	Incidents     int `json:"incidents"`
+}
+
+// AppHealth is the status of one application
+type AppHealth struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Deployed bool   `json:"deployed"`
+	// Health is healthy, degraded, failing or suspended
+	Health        string `json:"health"`
+	Machines      int    `json:"machines"`
+	Started       int    `json:"started"`
+	FailingChecks int    `json:"failingChecks"`
+	Incidents     int    `json:"incidents"`
+}
+
+// FailingCheck is a health check in critical state on a machine
+type FailingCheck struct {
+	AppName   string     `json:"appName"`
+	MachineID string     `json:"machineId"`
+	Region    string     `json:"region,omitempty"`
+	Check     string     `json:"check"`
+	Output    string     `json:"output,omitempty"`
+	Since     *time.Time `json:"since,omitempty"`
+}
+
+// Incident is a crash pattern a machine showed within the window
+type Incident struct {
+	AppName   string `json:"appName"`
+	MachineID string `json:"machineId"`
+	Region    string `json:"region,omitempty"`
+	// Kind is one of the doctor crash patterns: crash_loop, restart_storm,
+	// oom or crashed
+	Kind      string     `json:"kind"`
+	Ongoing   bool       `json:"ongoing,omitempty"`
+	Crashes   int        `json:"crashes"`
+	LastCrash *time.Time `json:"lastCrash,omitempty"`
+	Summary   string     `json:"summary"`
+}
+
+// Collect snapshots the health of the apps of an organization. Apps whose
+// machines or events cannot be read are reported as warnings.
+func Collect(ctx context.Context, client *fly.Client, organization string) (*Report, error) {
+	apps, err := client.GetApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	report := &Report{
+		Organization:  organization,
+		GeneratedAt:   now,
+		Window:        fmt.Sprintf("%dh", IncidentWindow/time.Hour),
+		Apps:          []AppHealth{},
+		FailingChecks: []FailingCheck{},
+		Incidents:     []Incident{},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, collectConcurrency)
+	for _, app := range apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			health, checks, incidents, warning := collectApp(ctx, client, app, now)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Apps = append(report.Apps, health)
+			report.FailingChecks = append(report.FailingChecks, checks...)
+			report.Incidents = append(report.Incidents, incidents...)
+			if warning != "" {
+				report.Warnings = append(report.Warnings, warning)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report.sort()
+	report.summarize()
+	return report, nil
+}
+
+// collectApp reads one app's machines and recent events
+func collectApp(ctx context.Context, client *fly.Client, app fly.App, now time.Time) (AppHealth, []FailingCheck, []Incident, string) {
+	health := AppHealth{Name: app.Name, Status: app.Status, Deployed: app.Deployed}
+	if app.Status == "suspended" {
+		health.Health = HealthSuspended
+		return health, nil, nil, ""
+	}
+
+	machines, err := client.ListMachines(ctx, app.Name)
+	if err != nil {
+		health.Health = HealthDegraded
+		return health, nil, nil, fmt.Sprintf("%s: machines unavailable", app.Name)
+	}
+
+	var checks []FailingCheck
+	var live []fly.Machine
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" {
+			continue
+		}
+		live = append(live, m)
+		if m.State == "started" {
+			health.Started++
+		}
+		for _, check := range m.Checks {
+			if check.Status != "critical" {
+				continue
+			}
+			failing := FailingCheck{
+				AppName:   app.Name,
+				MachineID: m.ID,
+				Region:    m.Region,
+				Check:     check.Name,
+				Output:    firstLine(check.Output),
+			}
+			if !check.UpdatedAt.IsZero() {
+				since := check.UpdatedAt.UTC()
+				failing.Since = &since
+			}
+			checks = append(checks, failing)
+		}
+	}
+	health.Machines = len(live)
+	health.FailingChecks = len(checks)
+
+	var warning string
+	var incidents []Incident
+	since := now.Add(-IncidentWindow)
+	events, err := client.GetMachineEvents(ctx, app.Name, fly.EventFilter{Since: since})
+	if err != nil {
+		warning = fmt.Sprintf("%s: machine events unavailable", app.Name)
+	} else {
+		incidents = incidentsOf(app.Name, doctor.AnalyzeCrashes(live, events, since, now))
+	}
+	health.Incidents = len(incidents)
+
+	ongoing := false
+	for _, incident := range incidents {
+		if incident.Ongoing {
+			ongoing = true
+		}
+	}
+	switch {
+	case ongoing || (health.Machines > 0 && health.Started == 0 && app.Deployed):
+		health.Health = HealthFailing
+	case len(checks) > 0 || len(incidents) > 0:
+		health.Health = HealthDegraded
+	default:
+		health.Health = HealthHealthy
+	}
+
+	return health, checks, incidents, warning
+}
+
+// incidentsOf turns the crash patterns of an app's machines into incidents,
+// the most severe pattern of each machine
+func incidentsOf(appName string, analysis *doctor.CrashAnalysis) []Incident {
+	var incidents []Incident
+	for _, m := range analysis.Machines {
+		if len(m.Patterns) == 0 {
+			continue
+		}
+		kind := m.Patterns[0]
+		summary := fmt.Sprintf("%d crash(es)", m.Crashes)
+		switch kind {
+		case doctor.PatternCrashLoop:
+			summary = fmt.Sprintf("crash loop, %d crashes", m.Crashes)
+		case doctor.PatternRestartStorm:
+			summary = fmt.Sprintf("restart storm, %d starts within 10m", m.PeakStarts)
+		case doctor.PatternOOM:
+			summary = fmt.Sprintf("%d out of memory kill(s)", m.OOMKills)
+		}
+		if len(m.Exits) > 0 {
+			summary += ": " + doctor.DescribeExits(m.Exits)
+		}
+		incidents = append(incidents, Incident{
+			AppName:   appName,
+			MachineID: m.MachineID,
+			Region:    m.Region,
+			Kind:      kind,
+			Ongoing:   m.Ongoing,
+			Crashes:   m.Crashes,
+			LastCrash: m.LastCrash,
+			Summary:   summary,
+		})
+	}
+	return incidents
+}
+
+// Filter returns the report restricted to the apps include accepts, so
+// callers see only the apps a policy lets them read
+func (r *Report) Filter(include func(appName string) bool) *Report {
+	filtered := &Report{
+		Organization:  r.Organization,
+		GeneratedAt:   r.GeneratedAt,
+		Window:        r.Window,
+		Apps:          []AppHealth{},
+		FailingChecks: []FailingCheck{},
+		Incidents:     []Incident{},
+	}
+	for _, app := range r.Apps {
+		if include(app.Name) {
+			filtered.Apps = append(filtered.Apps, app)
+		}
+	}
+	for _, check := range r.FailingChecks {
+		if include(check.AppName) {
+			filtered.FailingChecks = append(filtered.FailingChecks, check)
+		}
+	}
+	for _, incident := range r.Incidents {
+		if include(incident.AppName) {
+			filtered.Incidents = append(filtered.Incidents, incident)
+		}
+	}
+	for _, warning := range r.Warnings {
+		appName, _, _ := strings.Cut(warning, ":")
+		if include(appName) {
+			filtered.Warnings = append(filtered.Warnings, warning)
+		}
+	}
+	filtered.summarize()
+	return filtered
+}
+
+// Headline describes the report in a few lines: the app counts by health
+// and the apps that need attention
+func (r *Report) Headline() string {
+	s := r.Summary
+	text := fmt.Sprintf("%d apps: %d healthy, %d degraded, %d failing, %d suspended. %d failing check(s), %d incident(s) in the last %s.",
+		s.Apps, s.Healthy, s.Degraded, s.Failing, s.Suspended, s.FailingChecks, s.Incidents, r.Window)
+	for _, app := range r.Apps {
+		if app.Health != HealthFailing && app.Health != HealthDegraded {
+			continue
+		}
+		text += fmt.Sprintf("\n%s is %s", app.Name, app.Health)
+		var problems []string
+		if app.FailingChecks > 0 {
+			problems = append(problems, fmt.Sprintf("%d failing check(s)", app.FailingChecks))
+		}
+		if app.Incidents > 0 {
+			problems = append(problems, fmt.Sprintf("%d incident(s)", app.Incidents))
+		}
+		if app.Machines > 0 && app.Started == 0 {
+			problems = append(problems, "no machine started")
+		}
+		if len(problems) > 0 {
+			text += ": " + strings.Join(problems, ", ")
+		}
+	}
+	return text
+}
+
+// sort orders apps by name, failing checks by app and machine, and
+// incidents ongoing first, then by their last crash, newest first
+func (r *Report) sort() {
+	sort.Slice(r.Apps, func(i, j int) bool {
+		return r.Apps[i].Name < r.Apps[j].Name
+	})
+	sort.Slice(r.FailingChecks, func(i, j int) bool {
+		a, b := r.FailingChecks[i], r.FailingChecks[j]
+		if a.AppName != b.AppName {
+			return a.AppName < b.AppName
+		}
+		if a.MachineID != b.MachineID {
+			return a.MachineID < b.MachineID
+		}
+		return a.Check < b.Check
+	})
+	sort.Slice(r.Incidents, func(i, j int) bool {
+		a, b := r.Incidents[i], r.Incidents[j]
+		if a.Ongoing != b.Ongoing {
+			return a.Ongoing
+		}
+		if (a.LastCrash == nil) != (b.LastCrash == nil) {
+			return a.LastCrash != nil
+		}
+		if a.LastCrash != nil && !a.LastCrash.Equal(*b.LastCrash) {
+			return a.LastCrash.After(*b.LastCrash)
+		}
+		return a.AppName+a.MachineID < b.AppName+b.MachineID
+	})
+	sort.Strings(r.Warnings)
+}
+
+// summarize counts the report's apps and problems
+func (r *Report) summarize() {
+	s := Summary{Apps: len(r.Apps), FailingChecks: len(r.FailingChecks), Incidents: len(r.Incidents)}
+	for _, app := range r.Apps {
+		s.Machines += app.Machines
+		s.Started += app.Started
+		switch app.Health {
+		case HealthHealthy:
+			s.Healthy++
+		case HealthDegraded:
+			s.Degraded++
+		case HealthFailing:
+			s.Failing++
+		case HealthSuspended:
+			s.Suspended++
+		}
+	}
+	r.Summary = s
+}
+
+// firstLine returns the first line of a check's output
+func firstLine(output string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	return line
+}
@@ -0,0 +1,296 @@
+// Package session keeps per-client MCP session state, such as the app a
+// conversation is about, so tools can fill in arguments the user left out.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// HeaderName is the HTTP header carrying the session ID
+const HeaderName = "Mcp-Session-Id"
+
+// PendingConfirmation records a destructive tool call that was previewed but
+// not yet confirmed
+type PendingConfirmation struct {
+	Tool        string    `json:"tool"`
+	AppName     string    `json:"appName,omitempty"`
+	RequestedAt time.Time `json:"requestedAt"`
+}
+
+// State is a point-in-time copy of a session's context
+type State struct {
+	ID                  string               `json:"id"`
+	CreatedAt           time.Time            `json:"createdAt"`
+	DefaultApp          string               `json:"defaultApp,omitempty"`
+	Organization        string               `json:"organization,omitempty"`
+	ProtocolVersion     string               `json:"protocolVersion,omitempty"`
+	PendingConfirmation *PendingConfirmation `json:"pendingConfirmation,omitempty"`
+}
+
+// Session is the state of one MCP client connection
+type Session struct {
+	id        string
+	owner     string
+	createdAt time.Time
+
+	mu              sync.Mutex
+	lastSeen        time.Time
+	defaultApp      string
+	organization    string
+	protocolVersion string
+	elicitation     bool
+	sampling        bool
+	pending         *PendingConfirmation
+}
+
+// ID returns the session ID
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Owner returns the client that opened the session
+func (s *Session) Owner() string {
+	return s.owner
+}
+
+// DefaultApp returns the app used when a tool call omits app_name
+func (s *Session) DefaultApp() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.defaultApp
+}
+
+// SetDefaultApp sets the app used when a tool call omits app_name
+func (s *Session) SetDefaultApp(appName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultApp = appName
+}
+
+// Organization returns the active organization
+func (s *Session) Organization() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.organization
+}
+
+// SetOrganization sets the active organization
+func (s *Session) SetOrganization(orgSlug string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.organization = orgSlug
+}
+
+// ProtocolVersion returns the MCP protocol revision negotiated on initialize
+func (s *Session) ProtocolVersion() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.protocolVersion
+}
+
+// SetProtocolVersion records the MCP protocol revision negotiated on
+// initialize
+func (s *Session) SetProtocolVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocolVersion = version
+}
+
+// SupportsElicitation reports whether the client declared on initialize
+// that it can ask its user for input
+func (s *Session) SupportsElicitation() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.elicitation
+}
+
+// SetSupportsElicitation records whether the client can ask its user for
+// input
+func (s *Session) SetSupportsElicitation(supported bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.elicitation = supported
+}
+
+// SupportsSampling reports whether the client declared on initialize that
+// it can run prompts on its model for the server
+func (s *Session) SupportsSampling() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sampling
+}
+
+// SetSupportsSampling records whether the client can run prompts on its
+// model for the server
+func (s *Session) SetSupportsSampling(supported bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sampling = supported
+}
+
+// PendingConfirmation returns the destructive call awaiting confirmation
+func (s *Session) PendingConfirmation() *PendingConfirmation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// SetPendingConfirmation records a destructive call awaiting confirmation
+func (s *Session) SetPendingConfirmation(tool, appName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = &PendingConfirmation{Tool: tool, AppName: appName, RequestedAt: time.Now()}
+}
+
+// ClearPendingConfirmation forgets the call awaiting confirmation
+func (s *Session) ClearPendingConfirmation() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = nil
+}
+
+// Reset clears the session's context but keeps the session open
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultApp = ""
+	s.organization = ""
+	s.pending = nil
+}
+
+// State returns a copy of the session's context
+func (s *Session) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := State{
+		ID:              s.id,
+		CreatedAt:       s.createdAt,
+		DefaultApp:      s.defaultApp,
+		Organization:    s.organization,
+		ProtocolVersion: s.protocolVersion,
+	}
+	if s.pending != nil {
+		pending := *s.pending
+		state.PendingConfirmation = &pending
+	}
+	return state
+}
+
+// touch records activity on the session
+func (s *Session) touch(now time.Time) {
+	s.mu.Lock()
+	s.lastSeen = now
+	s.mu.Unlock()
+}
+
+// idleSince reports whether the session has been unused since cutoff
+func (s *Session) idleSince(cutoff time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSeen.Before(cutoff)
+}
+
+// Store holds the open sessions. Sessions unused for longer than the
+// timeout expire.
+type Store struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	timeout  time.Duration
+}
+
+// NewStore creates an empty session store
+func NewStore(timeout time.Duration) *Store {
+	return &Store{
+		sessions: make(map[string]*Session),
+		timeout:  timeout,
+	}
+}
+
+// Create opens a new session for the client identified by owner
+func (st *Store) Create(owner string) *Session {
+	now := time.Now()
+	s := &Session{id: newID(), owner: owner, createdAt: now, lastSeen: now}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.pruneLocked(now)
+	st.sessions[s.id] = s
+	return s
+}
+
+// Get returns an open session and marks it as used
+func (st *Store) Get(id string) (*Session, bool) {
+	now := time.Now()
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	s, ok := st.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	if st.timeout > 0 && s.idleSince(now.Add(-st.timeout)) {
+		delete(st.sessions, id)
+		return nil, false
+	}
+
+	s.touch(now)
+	return s, true
+}
+
+// Delete closes a session. It reports whether the session existed.
+func (st *Store) Delete(id string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	_, ok := st.sessions[id]
+	delete(st.sessions, id)
+	return ok
+}
+
+// Len returns the number of open sessions
+func (st *Store) Len() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return len(st.sessions)
+}
+
+// pruneLocked removes expired sessions
+func (st *Store) pruneLocked(now time.Time) {
+	if st.timeout <= 0 {
+		return
+	}
+	cutoff := now.Add(-st.timeout)
+	for id, s := range st.sessions {
+		if s.idleSince(cutoff) {
+			delete(st.sessions, id)
+		}
+	}
+}
+
+// newID returns a random, unguessable session ID
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// sessionKey is the context key of the current session
+type sessionKey struct{}
+
+// WithSession returns a context carrying the session
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// FromContext returns the session of the current request, if any
+func FromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return s, ok && s != nil
+}
@@ -0,0 +1,317 @@
+// Package slack turns Slack slash commands such as /fly status my-app into
+// tool calls, so teams can run tools from Slack without an assistant.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSkew is how old or far in the future a request's timestamp may be, so
+// captured requests cannot be replayed later
+const maxSkew = 5 * time.Minute
+
+// MaxTextLength is how much of a tool's output a reply carries; Slack
+// shortens longer messages anyway
+const MaxTextLength = 3900
+
+// Errors returned when a request does not come from Slack
+var (
+	ErrMissingSignature = errors.New("the request is not signed")
+	ErrStaleRequest     = errors.New("the request timestamp is too old or in the future")
+	ErrBadSignature     = errors.New("the request signature does not match")
+)
+
+// Verify checks that a request was signed by Slack with the app's signing
+// secret, as described in https://api.slack.com/authentication/verifying-requests-from-slack
+func Verify(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+	if timestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleRequest
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > maxSkew || skew < -maxSkew {
+		return ErrStaleRequest
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Command is a slash command Slack posted
+type Command struct {
+	// Command is the slash command, e.g. /fly, and Text what followed it
+	Command string
+	Text    string
+
+	UserID      string
+	UserName    string
+	TeamID      string
+	ChannelID   string
+	ResponseURL string
+}
+
+// unescape undoes the escaping Slack applies to command text
+var unescape = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+
+// ParseCommand reads a slash command from the form Slack posts
+func ParseCommand(body []byte) (*Command, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse slash command: %w", err)
+	}
+	return &Command{
+		Command:     form.Get("command"),
+		Text:        strings.TrimSpace(unescape.Replace(form.Get("text"))),
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		TeamID:      form.Get("team_id"),
+		ChannelID:   form.Get("channel_id"),
+		ResponseURL: form.Get("response_url"),
+	}, nil
+}
+
+// Invocation is the tool a command's text names and the arguments it gives:
+// "status my-app" or "restart my-app force=true". The first bare word after
+// the tool is its app.
+type Invocation struct {
+	Tool       string
+	Positional []string
+	Named      map[string]string
+}
+
+// Parse splits a command's text into the tool and its arguments. Values may
+// be quoted to contain spaces, e.g. command="ls -la".
+func Parse(text string) (*Invocation, error) {
+	words, err := split(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("no tool given")
+	}
+
+	inv := &Invocation{Tool: words[0], Named: make(map[string]string)}
+	for _, word := range words[1:] {
+		if key, value, ok := strings.Cut(word, "="); ok && key != "" {
+			inv.Named[key] = value
+			continue
+		}
+		inv.Positional = append(inv.Positional, word)
+	}
+	return inv, nil
+}
+
+// split breaks text into words at spaces outside double or single quotes
+func split(text string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	inWord := false
+	for _, r := range text {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'' || r == '“' || r == '”':
+			// Slack turns straight quotes into curly ones
+			quote = r
+			if r == '“' {
+				quote = '”'
+			}
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// Arguments converts an invocation's arguments to the types a tool's input
+// schema declares. The first positional argument is app_name.
+func (inv *Invocation) Arguments(schema map[string]interface{}) (map[string]interface{}, error) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	args := make(map[string]interface{}, len(inv.Named)+1)
+	switch {
+	case len(inv.Positional) > 1:
+		return nil, fmt.Errorf("unexpected %q; give other arguments as name=value", inv.Positional[1])
+	case len(inv.Positional) == 1:
+		if _, ok := properties["app_name"]; !ok {
+			return nil, fmt.Errorf("%s takes no app; give arguments as name=value", inv.Tool)
+		}
+		args["app_name"] = inv.Positional[0]
+	}
+
+	for name, raw := range inv.Named {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unknown argument %q; known arguments: %s", name, strings.Join(propertyNames(properties), ", "))
+		}
+		value, err := convert(property, raw)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", name, err)
+		}
+		args[name] = value
+	}
+	return args, nil
+}
+
+// convert parses a value as the type a schema property declares. Numbers
+// are float64, as they would be in a JSON tool call.
+func convert(property map[string]interface{}, raw string) (interface{}, error) {
+	kind, _ := property["type"].(string)
+	switch kind {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", raw)
+		}
+		return b, nil
+	case "array":
+		items, _ := property["items"].(map[string]interface{})
+		var values []interface{}
+		for _, part := range strings.Split(raw, ",") {
+			value, err := convert(items, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case "object":
+		var value map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("%q is not a JSON object", raw)
+		}
+		return value, nil
+	}
+	return raw, nil
+}
+
+// propertyNames returns the sorted names of schema properties
+func propertyNames(properties map[string]interface{}) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+var (
+	headingPattern = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	boldPattern    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	linkPattern    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// Markdown converts a tool's Markdown output to Slack's mrkdwn and shortens
+// it to MaxTextLength
+func Markdown(text string) string {
+	text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	text = headingPattern.ReplaceAllString(text, "*$1*")
+	text = boldPattern.ReplaceAllString(text, "*$1*")
+	text = linkPattern.ReplaceAllString(text, "<$2|$1>")
+
+	if len(text) > MaxTextLength {
+		cut := strings.LastIndex(text[:MaxTextLength], "\n")
+		if cut < MaxTextLength/2 {
+			cut = MaxTextLength
+		}
+		text = strings.ToValidUTF8(text[:cut], "") + "\n… output shortened; run the tool from an assistant for all of it"
+	}
+	return text
+}
+
+// Response types of a reply
+const (
+	// Ephemeral replies are only shown to the user who ran the command
+	Ephemeral = "ephemeral"
+	InChannel = "in_channel"
+)
+
+// Message is a reply to a slash command
+type Message struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+	// ReplaceOriginal replaces the "running" reply sent while a slow
+	// command ran
+	ReplaceOriginal bool `json:"replace_original,omitempty"`
+}
+
+// responseHost is where Slack's response URLs point; replies go nowhere else
+const responseHost = "hooks.slack.com"
+
+// Respond posts a reply to a command's response URL, for commands that
+// outlast the three seconds Slack waits for the first reply
+func Respond(ctx context.Context, client *http.Client, responseURL string, message Message) error {
+	u, err := url.Parse(responseURL)
+	if err != nil || u.Scheme != "https" || u.Host != responseHost {
+		return fmt.Errorf("refusing to reply to %q: not a Slack response URL", responseURL)
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode reply: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", responseURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "fly-mcp")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/alerts"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// AlertsTool implements the fly_alerts MCP tool
+type AlertsTool struct {
+	engine      *alerts.Engine
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// AlertsReport is the structured result of fly_alerts
+type AlertsReport struct {
+	Rules    []config.AlertRule `json:"rules"`
+	Active   []alerts.Alert     `json:"active"`
+	Resolved []alerts.Alert     `json:"resolved"`
+}
+
+// NewAlertsTool creates a new alerts tool. engine is nil when no alert rules
+// are configured.
+func NewAlertsTool(engine *alerts.Engine, authManager *auth.Manager, logger *logger.Logger) *AlertsTool {
+	return &AlertsTool{
+		engine:      engine,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *AlertsTool) Name() string {
+	return "fly_alerts"
+}
+
+// Description returns the tool description
+func (t *AlertsTool) Description() string {
+	return "List the alerts raised by the configured alert rules: active alerts, firing or pending until their condition held long enough, and recently resolved ones. Rules watch for conditions such as apps without started machines, failing or flapping health checks and out of memory kills."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *AlertsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show alerts of this application",
+			},
+			"state": map[string]interface{}{
+				"type":        "string",
+				"description": "Which alerts to show",
+				"enum":        []string{"active", "resolved", "all"},
+				"default":     "all",
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *AlertsTool) RequiredPermission() (string, string) {
+	return "read", "apps"
+}
+
+// Execute executes the alerts tool
+func (t *AlertsTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "apps"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	appName, _ := args["app_name"].(string)
+
+	state := "all"
+	if s, ok := args["state"].(string); ok && s != "" {
+		state = s
+	}
+	if state != "active" && state != "resolved" && state != "all" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: state must be active, resolved or all",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	if t.engine == nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "No alert rules are configured. Enable the machine watcher with `mcp.watcher.enabled` and add rules under `mcp.alerts.rules` to raise alerts.",
+			}},
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_alerts").
+		Str("app_name", appName).
+		Str("state", state).
+		Msg("Executing alerts tool")
+
+	// Apps that a policy hides from the caller are left out
+	include := func(name string) bool {
+		if appName != "" && name != appName {
+			return false
+		}
+		return t.authManager.EvaluatePolicy(ctx, "read", name) == nil
+	}
+
+	report := &AlertsReport{Rules: t.engine.Rules(), Active: []alerts.Alert{}, Resolved: []alerts.Alert{}}
+	if state != "resolved" {
+		report.Active = t.engine.Active(include)
+	}
+	if state != "active" {
+		report.Resolved = t.engine.Resolved(include)
+	}
+
+	t.authManager.AuditLog(ctx, userID, "list_alerts", appName, "success", map[string]interface{}{
+		"active":   len(report.Active),
+		"resolved": len(report.Resolved),
+	})
+
+	title := "Alerts"
+	var links []interfaces.ContentBlock
+	if appName != "" {
+		title = fmt.Sprintf("Alerts of application '%s'", appName)
+		links = appLinks(appName)
+	}
+	return out.Render(t.formatTextResponse(report, state), title, report, links...), nil
+}
+
+// formatTextResponse formats the alerts as human-readable text
+func (t *AlertsTool) formatTextResponse(report *AlertsReport, state string) *interfaces.ToolResult {
+	var response string
+
+	response += "# Alerts\n\n"
+
+	firing := 0
+	for _, a := range report.Active {
+		if a.State == alerts.StateFiring {
+			firing++
+		}
+	}
+
+	response += "## Summary\n"
+	response += fmt.Sprintf("- **Rules**: %d\n", len(report.Rules))
+	if state != "resolved" {
+		response += fmt.Sprintf("- **Firing**: %d\n", firing)
+		response += fmt.Sprintf("- **Pending**: %d\n", len(report.Active)-firing)
+	}
+	if state != "active" {
+		response += fmt.Sprintf("- **Recently resolved**: %d\n", len(report.Resolved))
+	}
+
+	if state != "resolved" {
+		response += "\n## Active\n"
+		if len(report.Active) == 0 {
+			response += "🟢 **No active alerts**\n"
+		} else {
+			response += "| State | Severity | Rule | App | Target | Since | Message |\n"
+			response += "|-------|----------|------|-----|--------|-------|---------|\n"
+			for _, a := range report.Active {
+				icon := "🔴"
+				if a.State == alerts.StatePending {
+					icon = "🟡"
+				}
+				response += fmt.Sprintf("| %s %s | %s | %s | %s | %s | %s | %s |\n",
+					icon, a.State, a.Severity, a.Rule, a.AppName, alertTarget(a), a.Since.Format("2006-01-02 15:04"), a.Message)
+			}
+		}
+	}
+
+	if state != "active" && len(report.Resolved) > 0 {
+		response += "\n## Recently Resolved\n"
+		response += "| Severity | Rule | App | Target | Fired | Resolved | Lasted |\n"
+		response += "|----------|------|-----|--------|-------|----------|--------|\n"
+		for _, a := range report.Resolved {
+			fired, lasted := "-", "-"
+			if a.FiredAt != nil {
+				fired = a.FiredAt.Format("2006-01-02 15:04")
+				lasted = a.ResolvedAt.Sub(*a.FiredAt).Round(time.Second).String()
+			}
+			response += fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %s |\n",
+				a.Severity, a.Rule, a.AppName, alertTarget(a), fired, a.ResolvedAt.Format("2006-01-02 15:04"), lasted)
+		}
+	}
+
+	if firing > 0 {
+		response += "\n## Suggested Actions\n"
+		response += "- Use `fly_doctor` on the affected apps to diagnose them\n"
+		response += "- Use `fly_crashes` for apps with crashes or out of memory kills\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// alertTarget names the machine or check an alert is about, or "-" for the
+// whole app
+func alertTarget(a alerts.Alert) string {
+	switch {
+	case a.Check != "":
+		return a.MachineID + " " + a.Check
+	case a.MachineID != "":
+		return a.MachineID
+	}
+	return "-"
+}
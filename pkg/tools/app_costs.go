@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// AppCostsTool implements the fly_costs MCP tool
+type AppCostsTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewAppCostsTool creates a new app costs tool
+func NewAppCostsTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *AppCostsTool {
+	return &AppCostsTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *AppCostsTool) Name() string {
+	return "fly_costs"
+}
+
+// Description returns the tool description
+func (t *AppCostsTool) Description() string {
+	return "Estimate the monthly cost of a Fly.io application from its machine sizes, volumes and dedicated IP addresses"
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *AppCostsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to estimate costs for",
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *AppCostsTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the app costs tool
+func (t *AppCostsTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_costs").
+		Str("app_name", appName).
+		Msg("Executing app costs tool")
+
+	estimate, err := t.flyClient.EstimateAppCost(ctx, appName)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "get_app_costs", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to estimate costs for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "get_app_costs", appName, "success", map[string]interface{}{
+		"monthly_total": estimate.Total,
+	})
+
+	return out.Render(t.formatTextResponse(estimate), fmt.Sprintf("Estimated costs for application '%s'", appName), estimate, appLinks(appName)...), nil
+}
+
+// formatTextResponse formats the cost estimate as human-readable text
+func (t *AppCostsTool) formatTextResponse(estimate *fly.CostEstimate) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Estimated Costs: %s\n\n", estimate.AppName)
+
+	response += "## Monthly Summary\n"
+	response += fmt.Sprintf("- **Compute**: $%.2f\n", estimate.ComputeTotal)
+	response += fmt.Sprintf("- **Volumes**: $%.2f\n", estimate.VolumeTotal)
+	response += fmt.Sprintf("- **Dedicated IPv4**: $%.2f (%d address(es))\n", estimate.IPTotal, estimate.DedicatedIPv4)
+	response += fmt.Sprintf("- **Total**: **$%.2f/month**\n", estimate.Total)
+
+	if len(estimate.Machines) > 0 {
+		response += "\n## Machines\n"
+		for _, m := range estimate.Machines {
+			size := fmt.Sprintf("%s-cpu-%dx, %d MB", m.Guest.CPUKind, m.Guest.CPUs, m.Guest.MemoryMB)
+			if m.Billed {
+				response += fmt.Sprintf("- 🟢 **%s** (%s, %s): $%.2f\n", m.ID, m.Region, size, m.Monthly)
+			} else {
+				response += fmt.Sprintf("- 🔴 **%s** (%s, %s): %s, not billed for compute\n", m.ID, m.Region, size, m.State)
+			}
+		}
+	}
+
+	if len(estimate.Volumes) > 0 {
+		response += "\n## Volumes\n"
+		for _, v := range estimate.Volumes {
+			response += fmt.Sprintf("- **%s** (%s, %d GB): $%.2f\n", v.Name, v.Region, v.SizeGB, v.Monthly)
+		}
+	}
+
+	if len(estimate.Warnings) > 0 {
+		response += "\n## Warnings\n"
+		for _, w := range estimate.Warnings {
+			response += fmt.Sprintf("- ⚠️ %s\n", w)
+		}
+	}
+
+	response += "\n## Notes\n"
+	response += "- Estimates assume machines run for the whole month and exclude bandwidth, GPUs and plan discounts\n"
+	response += "- Prices come from the server's pricing table (`fly.pricing` in config)\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// appNamePattern matches the app names accepted by Fly.io
+var appNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
+
+// AppCreateTool implements the fly_app_create MCP tool
+type AppCreateTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewAppCreateTool creates a new app create tool
+func NewAppCreateTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *AppCreateTool {
+	return &AppCreateTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *AppCreateTool) Name() string {
+	return "fly_app_create"
+}
+
+// Description returns the tool description
+func (t *AppCreateTool) Description() string {
+	return "Create a new Fly.io application in an organization with an optional primary region. The app is created empty; deploy an image to start machines."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *AppCreateTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to create (lowercase letters, numbers and dashes)",
+			},
+			"organization": map[string]interface{}{
+				"type":        "string",
+				"description": "Organization slug to create the app in (optional, uses configured org if not specified)",
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Primary region code for the app (e.g. iad, lhr, syd)",
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *AppCreateTool) RequiredPermission() (string, string) {
+	return "create", "app"
+}
+
+// Execute executes the app create tool
+func (t *AppCreateTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "create", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	if !appNamePattern.MatchString(appName) {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: '%s' is not a valid app name. Use 3-63 lowercase letters, numbers and dashes, starting and ending with a letter or number.", appName),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	organization := ""
+	if org, ok := args["organization"].(string); ok {
+		organization = org
+	}
+
+	region := ""
+	if r, ok := args["region"].(string); ok {
+		region = r
+	}
+
+	// Log the operation
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_app_create").
+		Str("app_name", appName).
+		Str("organization", organization).
+		Str("region", region).
+		Msg("Executing app create tool")
+
+	app, err := t.flyClient.CreateApp(ctx, appName, organization, region)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "create_app", appName, "failed", map[string]interface{}{
+			"error":        err.Error(),
+			"organization": organization,
+			"region":       region,
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **App Creation Failed**\n\nFailed to create app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Log successful operation
+	t.authManager.AuditLog(ctx, userID, "create_app", appName, "success", map[string]interface{}{
+		"app_id":       app.ID,
+		"organization": organization,
+		"region":       region,
+	})
+
+	out := NewOutputFormatter(ctx, args)
+
+	var response string
+
+	response += fmt.Sprintf("✅ **Application '%s' Created**\n\n", app.Name)
+
+	response += "## Details\n"
+	response += fmt.Sprintf("- **ID**: %s\n", app.ID)
+	response += fmt.Sprintf("- **Name**: %s\n", app.Name)
+	if app.Organization != nil {
+		response += fmt.Sprintf("- **Organization**: %s\n", app.Organization.Slug)
+	}
+	if region != "" {
+		response += fmt.Sprintf("- **Primary Region**: %s\n", region)
+	}
+	response += fmt.Sprintf("- **Created By**: %s\n", userID)
+
+	response += "\n## Next Steps\n"
+	response += "- The app has no machines yet; deploy an image to start serving traffic\n"
+	response += "- Use `fly_app_info` to inspect the new application\n"
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("Created application '%s'", app.Name), app, appLinks(app.Name)...), nil
+}
@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// AppDeleteTool implements the fly_app_delete MCP tool
+type AppDeleteTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewAppDeleteTool creates a new app delete tool
+func NewAppDeleteTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *AppDeleteTool {
+	return &AppDeleteTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *AppDeleteTool) Name() string {
+	return "fly_app_delete"
+}
+
+// Description returns the tool description
+func (t *AppDeleteTool) Description() string {
+	return "Permanently delete a Fly.io application including all machines, volumes, IPs and certificates. The first call previews the deletion and returns a confirmation token; call again with the token and the app name repeated in confirm_name to delete."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *AppDeleteTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to delete",
+			},
+			"confirmation_token": confirmationTokenProperty(),
+			"confirm_name": map[string]interface{}{
+				"type":        "string",
+				"description": "The application name typed again; must exactly match app_name (required with confirmation_token)",
+			},
+			"reason": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional reason for the deletion (for audit logging)",
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *AppDeleteTool) RequiredPermission() (string, string) {
+	return "delete", "app"
+}
+
+// Execute executes the app delete tool
+func (t *AppDeleteTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "delete", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	scope := map[string]interface{}{"app_name": appName}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		return t.preview(ctx, appName, scope), nil
+	}
+
+	// Checked before the token is spent, so a typo does not cost a preview
+	confirmName, _ := args["confirm_name"].(string)
+	if confirmName != appName {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: confirm_name must repeat the app name exactly ('%s')", appName),
+			}},
+			IsError: true,
+		}, nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_app_delete", token, scope); result != nil {
+		return result, nil
+	}
+
+	reason := ""
+	if r, ok := args["reason"].(string); ok {
+		reason = r
+	}
+
+	// Log the operation
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Warn().
+		Str("user_id", userID).
+		Str("tool", "fly_app_delete").
+		Str("app_name", appName).
+		Str("reason", reason).
+		Msg("Executing app delete tool")
+
+	// Make sure the app exists before attempting deletion so the error is clear
+	app, err := t.flyClient.GetApp(ctx, appName)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "delete_app", appName, "failed_lookup", map[string]interface{}{
+			"error":  err.Error(),
+			"reason": reason,
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to look up app '%s' before deletion: %s\n\nThe deletion was not performed.", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	if err := t.flyClient.DeleteApp(ctx, appName); err != nil {
+		t.authManager.AuditLog(ctx, userID, "delete_app", appName, "failed", map[string]interface{}{
+			"error":  err.Error(),
+			"reason": reason,
+			"app_id": app.ID,
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Delete Failed**\n\nFailed to delete app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Log successful operation
+	t.authManager.AuditLog(ctx, userID, "delete_app", appName, "success", map[string]interface{}{
+		"reason": reason,
+		"app_id": app.ID,
+	})
+
+	out := NewOutputFormatter(ctx, args)
+
+	var response string
+
+	response += fmt.Sprintf("🗑️ **Application '%s' Deleted**\n\n", appName)
+	response += "## Summary\n"
+	response += fmt.Sprintf("- **Application**: %s\n", appName)
+	response += fmt.Sprintf("- **ID**: %s\n", app.ID)
+	if reason != "" {
+		response += fmt.Sprintf("- **Reason**: %s\n", reason)
+	}
+	response += fmt.Sprintf("- **Deleted By**: %s\n", userID)
+	response += "\nAll machines, volumes, IP addresses and certificates for this app have been released.\n"
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("Deleted application '%s'", appName), app), nil
+}
+
+// preview describes the deletion and issues its confirmation token
+func (t *AppDeleteTool) preview(ctx context.Context, appName string, scope map[string]interface{}) *interfaces.ToolResult {
+	app, err := t.flyClient.GetApp(ctx, appName)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to look up app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}
+	}
+
+	summary := fmt.Sprintf("- **Application**: %s (currently %s)\n", app.Name, app.Status)
+	if app.Organization != nil {
+		summary += fmt.Sprintf("- **Organization**: %s\n", app.Organization.Slug)
+	}
+	summary += "- **Impact**: all machines, volumes, IP addresses and certificates are destroyed permanently; this cannot be undone"
+
+	return requestConfirmation(ctx, t.authManager, "fly_app_delete", "Delete", appName, scope, summary)
+}
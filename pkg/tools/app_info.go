@@ -2,8 +2,8 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/brannn/fly-mcp/internal/logger"
 	"github.com/brannn/fly-mcp/pkg/auth"
@@ -51,18 +51,17 @@ func (t *AppInfoTool) InputSchema() map[string]interface{} {
 				"description": "Include current status and machine information",
 				"default":     true,
 			},
-			"format": map[string]interface{}{
-				"type":        "string",
-				"description": "Response format (text or json)",
-				"enum":        []string{"text", "json"},
-				"default":     "text",
-			},
 		},
 		"required":             []string{"app_name"},
 		"additionalProperties": false,
 	}
 }
 
+// RequiredPermission returns the permission checked before execution
+func (t *AppInfoTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
 // Execute executes the app info tool
 func (t *AppInfoTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
 	// Validate permissions
@@ -93,10 +92,7 @@ func (t *AppInfoTool) Execute(ctx context.Context, args map[string]interface{})
 		includeStatus = status
 	}
 
-	format := "text"
-	if fmt, ok := args["format"].(string); ok {
-		format = fmt
-	}
+	out := NewOutputFormatter(ctx, args)
 
 	// Log the operation
 	userID, _ := t.authManager.ExtractUserFromContext(ctx)
@@ -105,7 +101,7 @@ func (t *AppInfoTool) Execute(ctx context.Context, args map[string]interface{})
 		Str("tool", "fly_app_info").
 		Str("app_name", appName).
 		Bool("include_status", includeStatus).
-		Str("format", format).
+		Str("format", out.Format).
 		Msg("Executing app info tool")
 
 	// Get app information from Fly.io
@@ -118,7 +114,7 @@ func (t *AppInfoTool) Execute(ctx context.Context, args map[string]interface{})
 		return &interfaces.ToolResult{
 			Content: []interfaces.ContentBlock{{
 				Type: "text",
-				Text: fmt.Sprintf("Failed to retrieve app information for '%s': %v", appName, err),
+				Text: fmt.Sprintf("Failed to retrieve app information for '%s': %s", appName, describeError(err)),
 			}},
 			IsError: true,
 		}, nil
@@ -141,44 +137,22 @@ func (t *AppInfoTool) Execute(ctx context.Context, args map[string]interface{})
 	// Log successful operation
 	t.authManager.AuditLog(ctx, userID, "get_app_info", appName, "success", map[string]interface{}{
 		"include_status": includeStatus,
-		"format":         format,
+		"format":         out.Format,
 	})
 
-	// Format response based on requested format
-	if format == "json" {
-		return t.formatJSONResponse(app, appStatus)
+	result, err := t.formatTextResponse(app, appStatus)
+	if err != nil {
+		return nil, err
 	}
 	
-	return t.formatTextResponse(app, appStatus)
+	info := appInfo{App: app, Status: appStatus}
+	return out.Render(result, fmt.Sprintf("Application information for '%s'", app.Name), info, appLinks(app.Name)...), nil
 }
 
-// formatJSONResponse formats the response as JSON
-func (t *AppInfoTool) formatJSONResponse(app *fly.App, status *fly.AppStatus) (*interfaces.ToolResult, error) {
-	response := map[string]interface{}{
-		"app": app,
-	}
-	
-	if status != nil {
-		response["status"] = status
-	}
-
-	jsonData, err := json.MarshalIndent(response, "", "  ")
-	if err != nil {
-		return &interfaces.ToolResult{
-			Content: []interfaces.ContentBlock{{
-				Type: "text",
-				Text: fmt.Sprintf("Error formatting JSON response: %v", err),
-			}},
-			IsError: true,
-		}, nil
-	}
-
-	return &interfaces.ToolResult{
-		Content: []interfaces.ContentBlock{{
-			Type: "text",
-			Text: fmt.Sprintf("Application information for '%s':\n\n```json\n%s\n```", app.Name, string(jsonData)),
-		}},
-	}, nil
+// appInfo is the result data of fly_app_info
+type appInfo struct {
+	App    *fly.App       `json:"app"`
+	Status *fly.AppStatus `json:"status,omitempty"`
 }
 
 // formatTextResponse formats the response as human-readable text
@@ -201,8 +175,49 @@ func (t *AppInfoTool) formatTextResponse(app *fly.App, status *fly.AppStatus) (*
 		response += fmt.Sprintf("- **Organization**: %s\n", app.Organization.Name)
 	}
 	
-	response += fmt.Sprintf("- **Created**: %s\n", app.CreatedAt.Format("2006-01-02 15:04:05 UTC"))
-	response += fmt.Sprintf("- **Updated**: %s\n", app.UpdatedAt.Format("2006-01-02 15:04:05 UTC"))
+	if app.CreatedAt != nil {
+		response += fmt.Sprintf("- **Created**: %s\n", app.CreatedAt.Format("2006-01-02 15:04:05 UTC"))
+	}
+	if app.UpdatedAt != nil {
+		response += fmt.Sprintf("- **Updated**: %s\n", app.UpdatedAt.Format("2006-01-02 15:04:05 UTC"))
+	}
+	if len(app.Regions) > 0 {
+		response += fmt.Sprintf("- **Regions**: %s\n", strings.Join(app.Regions, ", "))
+	}
+	
+	// Current release
+	if release := app.CurrentRelease; release != nil {
+		response += "\n## Current Release\n"
+		response += fmt.Sprintf("- **Version**: v%d\n", release.Version)
+		response += fmt.Sprintf("- **Status**: %s\n", release.Status)
+		if release.ImageRef != "" {
+			response += fmt.Sprintf("- **Image**: %s\n", release.ImageRef)
+		}
+		if release.Description != "" {
+			response += fmt.Sprintf("- **Description**: %s\n", release.Description)
+		}
+		if release.User != "" {
+			response += fmt.Sprintf("- **By**: %s\n", release.User)
+		}
+		if !release.CreatedAt.IsZero() {
+			response += fmt.Sprintf("- **Released**: %s\n", release.CreatedAt.Format("2006-01-02 15:04:05 UTC"))
+		}
+	}
+	
+	// Services
+	if len(app.Services) > 0 {
+		response += "\n## Services\n"
+		for _, svc := range app.Services {
+			var ports []string
+			for _, port := range svc.Ports {
+				ports = append(ports, fmt.Sprintf("%d (%s)", port.Port, strings.Join(port.Handlers, ", ")))
+			}
+			if len(ports) == 0 {
+				ports = append(ports, "no public ports")
+			}
+			response += fmt.Sprintf("- **%s %d**: %s\n", strings.ToUpper(svc.Protocol), svc.InternalPort, strings.Join(ports, ", "))
+		}
+	}
 	
 	// Status information
 	if status != nil {
@@ -244,7 +259,9 @@ func (t *AppInfoTool) formatTextResponse(app *fly.App, status *fly.AppStatus) (*
 	
 	// URLs and access
 	response += "\n## Access Information\n"
-	response += fmt.Sprintf("- **Primary URL**: https://%s\n", app.Hostname)
+	if app.Hostname != "" {
+		response += fmt.Sprintf("- **Primary URL**: https://%s\n", app.Hostname)
+	}
 	if app.AppURL != "" && app.AppURL != app.Hostname {
 		response += fmt.Sprintf("- **App URL**: %s\n", app.AppURL)
 	}
@@ -0,0 +1,327 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// defaultMetrics are queried when the caller does not select any
+var defaultMetrics = []string{"cpu", "memory", "http_requests"}
+
+// maxMetricsRange is the longest time range a caller may request
+const maxMetricsRange = 7 * 24 * time.Hour
+
+// AppMetricsTool implements the fly_metrics MCP tool
+type AppMetricsTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewAppMetricsTool creates a new app metrics tool
+func NewAppMetricsTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *AppMetricsTool {
+	return &AppMetricsTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *AppMetricsTool) Name() string {
+	return "fly_metrics"
+}
+
+// Description returns the tool description
+func (t *AppMetricsTool) Description() string {
+	return "Query CPU, memory, HTTP request rate, error rate and latency for a Fly.io application over a time range, returning summary statistics and optionally the raw series"
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *AppMetricsTool) InputSchema() map[string]interface{} {
+	names := make([]string, 0, len(fly.AppMetrics))
+	for _, m := range fly.AppMetrics {
+		names = append(names, m.Name)
+	}
+
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to query metrics for",
+			},
+			"metrics": map[string]interface{}{
+				"type":        "array",
+				"description": "Metrics to query (defaults to cpu, memory and http_requests)",
+				"items": map[string]interface{}{
+					"type": "string",
+					"enum": names,
+				},
+			},
+			"range": map[string]interface{}{
+				"type":        "string",
+				"description": "How far back to query, e.g. 15m, 1h, 24h or 7d",
+				"default":     "1h",
+			},
+			"include_series": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include the raw time series in the response",
+				"default":     false,
+			},
+			"organization": map[string]interface{}{
+				"type":        "string",
+				"description": "Organization slug that owns the app (defaults to the configured organization)",
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// appMetrics is the structured result of a metrics query
+type appMetrics struct {
+	AppName string         `json:"appName"`
+	Range   string         `json:"range"`
+	Start   time.Time      `json:"start"`
+	End     time.Time      `json:"end"`
+	Step    string         `json:"step"`
+	Metrics []metricResult `json:"metrics"`
+}
+
+// metricResult is the outcome of querying a single metric
+type metricResult struct {
+	Name    string             `json:"name"`
+	Unit    string             `json:"unit"`
+	Summary *fly.SeriesSummary `json:"summary,omitempty"`
+	Series  []fly.MetricSeries `json:"series,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *AppMetricsTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the app metrics tool
+func (t *AppMetricsTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	metricNames := defaultMetrics
+	if raw, ok := args["metrics"].([]interface{}); ok && len(raw) > 0 {
+		metricNames = make([]string, 0, len(raw))
+		for _, r := range raw {
+			name, _ := r.(string)
+			if _, ok := fly.LookupAppMetric(name); !ok {
+				return &interfaces.ToolResult{
+					Content: []interfaces.ContentBlock{{
+						Type: "text",
+						Text: fmt.Sprintf("Error: unknown metric '%v'", r),
+					}},
+					IsError: true,
+				}, nil
+			}
+			metricNames = append(metricNames, name)
+		}
+	}
+
+	rangeStr := "1h"
+	if r, ok := args["range"].(string); ok && r != "" {
+		rangeStr = r
+	}
+	window, err := parseMetricsRange(rangeStr)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	includeSeries, _ := args["include_series"].(bool)
+	orgSlug, _ := args["organization"].(string)
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_metrics").
+		Str("app_name", appName).
+		Strs("metrics", metricNames).
+		Str("range", rangeStr).
+		Msg("Executing app metrics tool")
+
+	end := time.Now().UTC()
+	start := end.Add(-window)
+	step := metricsStep(window)
+
+	results := make([]metricResult, 0, len(metricNames))
+	failed := 0
+	for _, name := range metricNames {
+		metric, _ := fly.LookupAppMetric(name)
+		result := metricResult{Name: metric.Name, Unit: metric.Unit}
+
+		series, err := t.flyClient.QueryMetrics(ctx, orgSlug, metric.Query(appName), start, end, step)
+		if err != nil {
+			failed++
+			result.Error = err.Error()
+		} else if len(series) > 0 {
+			summary := series[0].Summary()
+			result.Summary = &summary
+			if includeSeries {
+				result.Series = series
+			}
+		}
+		results = append(results, result)
+	}
+
+	if failed == len(results) {
+		t.authManager.AuditLog(ctx, userID, "get_app_metrics", appName, "failed", map[string]interface{}{
+			"error": results[0].Error,
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to retrieve metrics for app '%s': %s", appName, results[0].Error),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "get_app_metrics", appName, "success", map[string]interface{}{
+		"metrics": metricNames,
+		"range":   rangeStr,
+		"failed":  failed,
+	})
+
+	return out.Render(t.formatTextResponse(appName, rangeStr, results), fmt.Sprintf("Metrics for application '%s'", appName), appMetrics{
+		AppName: appName,
+		Range:   rangeStr,
+		Start:   start,
+		End:     end,
+		Step:    step.String(),
+		Metrics: results,
+	}, appLinks(appName)...), nil
+}
+
+// formatTextResponse formats metric results as human-readable text
+func (t *AppMetricsTool) formatTextResponse(appName, rangeStr string, results []metricResult) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Metrics: %s (last %s)\n\n", appName, rangeStr)
+
+	response += "## Summary\n"
+	response += "| Metric | Latest | Min | Avg | Max |\n"
+	response += "|--------|--------|-----|-----|-----|\n"
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			response += fmt.Sprintf("| %s | ❌ %s | | | |\n", r.Name, r.Error)
+		case r.Summary == nil:
+			response += fmt.Sprintf("| %s | no data | | | |\n", r.Name)
+		default:
+			response += fmt.Sprintf("| %s | %s | %s | %s | %s |\n", r.Name,
+				formatMetricValue(r.Summary.Latest, r.Unit),
+				formatMetricValue(r.Summary.Min, r.Unit),
+				formatMetricValue(r.Summary.Avg, r.Unit),
+				formatMetricValue(r.Summary.Max, r.Unit))
+		}
+	}
+
+	for _, r := range results {
+		for _, s := range r.Series {
+			response += fmt.Sprintf("\n## Series: %s\n", r.Name)
+			for _, p := range s.Points {
+				response += fmt.Sprintf("- %s: %s\n", p.Time.Format("2006-01-02 15:04:05 UTC"), formatMetricValue(p.Value, r.Unit))
+			}
+		}
+	}
+
+	response += "\n## Suggested Actions\n"
+	response += "- Use `fly_scale` if CPU or memory is consistently high\n"
+	response += "- Use `fly_checks` to inspect failing health checks when errors spike\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// parseMetricsRange parses a range such as 30m, 6h or 7d
+func parseMetricsRange(value string) (time.Duration, error) {
+	var window time.Duration
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid range '%s' (e.g. 15m, 1h, 24h, 7d)", value)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid range '%s' (e.g. 15m, 1h, 24h, 7d)", value)
+		}
+		window = d
+	}
+
+	if window < time.Minute || window > maxMetricsRange {
+		return 0, fmt.Errorf("range must be between 1m and 7d, got '%s'", value)
+	}
+	return window, nil
+}
+
+// metricsStep picks a query resolution giving roughly 60 points per series
+func metricsStep(window time.Duration) time.Duration {
+	step := (window / 60).Truncate(time.Second)
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+	return step
+}
+
+// formatMetricValue renders a value with its unit
+func formatMetricValue(value float64, unit string) string {
+	switch unit {
+	case "%":
+		return fmt.Sprintf("%.1f%%", value)
+	case "MB", "ms":
+		return fmt.Sprintf("%.0f %s", value, unit)
+	default:
+		return fmt.Sprintf("%.2f %s", value, unit)
+	}
+}
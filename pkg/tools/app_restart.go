@@ -3,6 +3,9 @@ package tools
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/brannn/fly-mcp/internal/logger"
 	"github.com/brannn/fly-mcp/pkg/auth"
@@ -33,33 +36,81 @@ func (t *AppRestartTool) Name() string {
 
 // Description returns the tool description
 func (t *AppRestartTool) Description() string {
-	return "Restart a Fly.io application by restarting all of its machines. This is useful for applying configuration changes or recovering from issues."
+	return "Restart a Fly.io application by restarting all of its machines. This is useful for applying configuration changes or recovering from issues. Filters on machine IDs, region, state (including unhealthy) and image digest restart only the matching machines, which the preview lists. The strategy chooses between restarting every machine at once (all_at_once), a batch at a time waiting for each batch to pass its health checks (rolling), or one region at a time (region); afterwards it reports which machines came back healthy. Optionally watches the machines' health checks afterwards and rolls back to the previous release's image if they keep failing. The first call previews the restart and returns a confirmation token; call again with the token to restart."
 }
 
 // InputSchema returns the JSON schema for the tool's input
 func (t *AppRestartTool) InputSchema() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"app_name": map[string]interface{}{
-				"type":        "string",
-				"description": "Name of the application to restart",
-			},
-			"confirm": map[string]interface{}{
-				"type":        "boolean",
-				"description": "Confirmation that you want to restart the application (required for safety)",
-				"default":     false,
-			},
-			"reason": map[string]interface{}{
-				"type":        "string",
-				"description": "Optional reason for the restart (for audit logging)",
-			},
+	properties := map[string]interface{}{
+		"app_name": map[string]interface{}{
+			"type":        "string",
+			"description": "Name of the application to restart",
+		},
+		"strategy": map[string]interface{}{
+			"type":        "string",
+			"description": "How to restart the machines: all_at_once restarts every machine without waiting in between, rolling restarts a batch at a time and waits for it to pass its health checks, region restarts one region at a time",
+			"enum":        fly.RestartStrategies,
+			"default":     fly.RestartAllAtOnce,
+		},
+		"batch_size": map[string]interface{}{
+			"type":        "integer",
+			"description": "Machines a rolling restart restarts at a time",
+			"default":     1,
+			"minimum":     1,
+		},
+		"batch_percent": map[string]interface{}{
+			"type":        "integer",
+			"description": "Percentage of the machines a rolling restart restarts at a time, instead of batch_size",
+			"minimum":     1,
+			"maximum":     100,
+		},
+		"machine_ids": map[string]interface{}{
+			"type":        "array",
+			"description": "Only restart these machines",
+			"items":       map[string]interface{}{"type": "string"},
+		},
+		"region": map[string]interface{}{
+			"type":        "string",
+			"description": "Only restart machines in this region, e.g. iad",
 		},
-		"required":             []string{"app_name", "confirm"},
+		"state": map[string]interface{}{
+			"type":        "string",
+			"description": "Only restart machines in this state, e.g. stopped, or unhealthy for started machines with a failing health check",
+		},
+		"image_digest": map[string]interface{}{
+			"type":        "string",
+			"description": "Only restart machines whose image digest starts with this, e.g. sha256:3f2a",
+		},
+		"health_timeout_seconds": map[string]interface{}{
+			"type":        "integer",
+			"description": "How long restarted machines may take to start and pass their health checks",
+			"default":     defaultHealthTimeout,
+			"minimum":     minHealthTimeout,
+			"maximum":     maxHealthTimeout,
+		},
+		"confirmation_token": confirmationTokenProperty(),
+		"reason": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional reason for the restart (for audit logging)",
+		},
+	}
+	for name, property := range healthGateProperties() {
+		properties[name] = property
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             []string{"app_name"},
 		"additionalProperties": false,
 	}
 }
 
+// RequiredPermission returns the permission checked before execution
+func (t *AppRestartTool) RequiredPermission() (string, string) {
+	return "restart", "app"
+}
+
 // Execute executes the app restart tool
 func (t *AppRestartTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
 	// Validate permissions
@@ -85,16 +136,73 @@ func (t *AppRestartTool) Execute(ctx context.Context, args map[string]interface{
 		}, nil
 	}
 
-	confirm, ok := args["confirm"].(bool)
-	if !ok || !confirm {
+	req := fly.RestartRequest{
+		Strategy:      fly.RestartAllAtOnce,
+		BatchSize:     1,
+		HealthTimeout: defaultHealthTimeout * time.Second,
+	}
+	if s, ok := args["strategy"].(string); ok && s != "" {
+		req.Strategy = s
+	}
+	if !slices.Contains(fly.RestartStrategies, req.Strategy) {
 		return &interfaces.ToolResult{
 			Content: []interfaces.ContentBlock{{
 				Type: "text",
-				Text: "⚠️ **Restart Confirmation Required**\n\nRestarting an application will cause temporary downtime. To proceed, you must set `confirm: true` in your request.\n\nExample:\n```json\n{\n  \"app_name\": \"" + appName + "\",\n  \"confirm\": true,\n  \"reason\": \"Applying configuration changes\"\n}\n```",
+				Text: fmt.Sprintf("Error: strategy must be one of %s", strings.Join(fly.RestartStrategies, ", ")),
 			}},
 			IsError: true,
 		}, nil
 	}
+	if v, ok := args["batch_size"].(float64); ok && v >= 1 {
+		req.BatchSize = int(v)
+	}
+	if v, ok := args["batch_percent"].(float64); ok && v >= 1 {
+		req.BatchPercent = min(int(v), 100)
+	}
+	if v, ok := args["health_timeout_seconds"].(float64); ok {
+		req.HealthTimeout = time.Duration(max(minHealthTimeout, min(int(v), maxHealthTimeout))) * time.Second
+	}
+	if raw, ok := args["machine_ids"].([]interface{}); ok {
+		for _, item := range raw {
+			if id, ok := item.(string); ok && id != "" {
+				req.Filter.MachineIDs = append(req.Filter.MachineIDs, id)
+			}
+		}
+	}
+	req.Filter.Region, _ = args["region"].(string)
+	req.Filter.State, _ = args["state"].(string)
+	req.Filter.ImageDigest, _ = args["image_digest"].(string)
+
+	// Rolling back changes the image the app runs, which is a deploy
+	gate := healthGateFromArgs(args)
+	if gate != nil && gate.Rollback {
+		if err := t.authManager.ValidateRequest(ctx, "deploy", "app"); err != nil {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Permission denied: %v (automatic rollback needs permission to deploy; set auto_rollback to false to restart without it)", err),
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	scope := map[string]interface{}{
+		"app_name":       appName,
+		"strategy":       req.Strategy,
+		"batch_size":     req.BatchSize,
+		"batch_percent":  req.BatchPercent,
+		"health_timeout": req.HealthTimeout.Seconds(),
+		"filter":         req.Filter,
+	}
+	healthGateScope(scope, gate)
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		return t.preview(ctx, appName, req, gate, scope), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_restart", token, scope); result != nil {
+		return result, nil
+	}
 
 	reason := ""
 	if r, ok := args["reason"].(string); ok {
@@ -108,6 +216,7 @@ func (t *AppRestartTool) Execute(ctx context.Context, args map[string]interface{
 		Str("tool", "fly_restart").
 		Str("app_name", appName).
 		Str("reason", reason).
+		Str("strategy", req.Strategy).
 		Msg("Executing app restart tool")
 
 	// Get current app status before restart
@@ -121,78 +230,264 @@ func (t *AppRestartTool) Execute(ctx context.Context, args map[string]interface{
 		return &interfaces.ToolResult{
 			Content: []interfaces.ContentBlock{{
 				Type: "text",
-				Text: fmt.Sprintf("Failed to check app status before restart for '%s': %v\n\nThe restart was not performed for safety reasons.", appName, err),
+				Text: fmt.Sprintf("Failed to check app status before restart for '%s': %s\n\nThe restart was not performed for safety reasons.", appName, describeError(err)),
 			}},
 			IsError: true,
 		}, nil
 	}
 
 	// Perform the restart
-	err = t.flyClient.RestartApp(ctx, appName)
+	restarted, err := t.flyClient.Restart(ctx, appName, req)
 	if err != nil {
 		t.authManager.AuditLog(ctx, userID, "restart_app", appName, "failed", map[string]interface{}{
-			"error":          err.Error(),
-			"reason":         reason,
+			"error":           err.Error(),
+			"reason":          reason,
+			"strategy":        req.Strategy,
 			"machines_before": statusBefore.MachineCount,
 		})
 		
 		return &interfaces.ToolResult{
 			Content: []interfaces.ContentBlock{{
 				Type: "text",
-				Text: fmt.Sprintf("❌ **Restart Failed**\n\nFailed to restart app '%s': %v\n\nThe application may still be in its previous state. You can check the status using `fly_status`.", appName, err),
+				Text: fmt.Sprintf("❌ **Restart Failed**\n\nFailed to restart app '%s': %s\n\nNo machines were restarted. You can check the status using `fly_status`.", appName, describeError(err)),
 			}},
 			IsError: true,
 		}, nil
 	}
 
-	// Log successful operation
-	t.authManager.AuditLog(ctx, userID, "restart_app", appName, "success", map[string]interface{}{
+	restart := restartResult{
+		RestartResult:     restarted,
+		StatusBefore:      statusBefore.Status,
+		MachinesRestarted: restarted.Healthy + restarted.Unhealthy,
+		Reason:            reason,
+	}
+	for _, m := range restarted.Machines {
+		if m.Action == fly.MachineFailed {
+			restart.MachinesRestarted--
+		}
+	}
+
+	t.authManager.AuditLog(ctx, userID, "restart_app", appName, restarted.Status, map[string]interface{}{
 		"reason":          reason,
+		"strategy":        req.Strategy,
 		"machines_before": statusBefore.MachineCount,
 		"status_before":   statusBefore.Status,
+		"healthy":         restarted.Healthy,
+		"unhealthy":       restarted.Unhealthy,
+		"skipped":         restarted.Skipped,
+		"error":           restarted.Error,
 	})
 
-	// Format success response
+	// The health gate watches machines that came back, for longer
+	if gate != nil && restarted.Status == fly.RestartSucceeded {
+		restart.HealthGate = t.runHealthGate(ctx, userID, appName, restarted, *gate)
+	}
+
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("app_name", appName).
+		Str("status", restarted.Status).
+		Int("healthy", restarted.Healthy).
+		Int("unhealthy", restarted.Unhealthy).
+		Msg("Finished app restart")
+
+	response := t.formatTextResponse(restart, userID, statusBefore.Hostname)
+	return NewOutputFormatter(ctx, args).Render(response, fmt.Sprintf("Restart of application '%s'", appName), restart, appLinks(appName)...), nil
+}
+
+// restartResult is the structured result of a restart
+type restartResult struct {
+	*fly.RestartResult
+	StatusBefore      string                `json:"statusBefore"`
+	MachinesRestarted int                   `json:"machinesRestarted"`
+	Reason            string                `json:"reason,omitempty"`
+	HealthGate        *fly.HealthGateResult `json:"healthGate,omitempty"`
+}
+
+// formatTextResponse formats the restart result as human-readable text
+func (t *AppRestartTool) formatTextResponse(restart restartResult, userID, hostname string) *interfaces.ToolResult {
 	var response string
-	
-	response += fmt.Sprintf("✅ **Application '%s' Restart Initiated**\n\n", appName)
-	
+
+	isError := restart.Status != fly.RestartSucceeded
+	if isError {
+		response += fmt.Sprintf("❌ **Restart of '%s' Failed**\n\n", restart.AppName)
+	} else {
+		response += fmt.Sprintf("✅ **Application '%s' Restarted**\n\n", restart.AppName)
+	}
+
 	response += "## Restart Summary\n"
-	response += fmt.Sprintf("- **Application**: %s\n", appName)
-	response += fmt.Sprintf("- **Status Before**: %s\n", statusBefore.Status)
-	response += fmt.Sprintf("- **Machines Restarted**: %d\n", statusBefore.MachineCount)
-	if reason != "" {
-		response += fmt.Sprintf("- **Reason**: %s\n", reason)
+	response += fmt.Sprintf("- **Application**: %s\n", restart.AppName)
+	response += fmt.Sprintf("- **Status Before**: %s\n", restart.StatusBefore)
+	response += fmt.Sprintf("- **Strategy**: %s\n", restart.Strategy)
+	if restart.Filter != nil {
+		response += fmt.Sprintf("- **Filter**: %s\n", describeMachineFilter(*restart.Filter))
+	}
+	response += fmt.Sprintf("- **Machines Restarted**: %d\n", restart.MachinesRestarted)
+	response += fmt.Sprintf("- **Healthy Afterwards**: %d of %d\n", restart.Healthy, restart.Healthy+restart.Unhealthy)
+	if restart.Skipped > 0 {
+		response += fmt.Sprintf("- **Not Restarted**: %d\n", restart.Skipped)
+	}
+	response += fmt.Sprintf("- **Duration**: %s\n", restart.Duration)
+	if restart.Reason != "" {
+		response += fmt.Sprintf("- **Reason**: %s\n", restart.Reason)
 	}
 	response += fmt.Sprintf("- **Initiated By**: %s\n", userID)
-	
-	response += "\n## What Happens Next\n"
-	response += "1. 🔄 All machines are being restarted\n"
-	response += "2. ⏱️ There may be brief downtime during the restart\n"
-	response += "3. 🟢 Machines will come back online automatically\n"
-	response += "4. 🌐 Traffic will resume once machines are healthy\n"
-	
-	response += "\n## Monitoring the Restart\n"
-	response += "- Use `fly_status` to check the current status\n"
-	response += "- Use `fly_logs` to monitor the restart process\n"
-	response += "- The restart typically completes within 1-2 minutes\n"
-	
-	if statusBefore.Hostname != "" {
-		response += fmt.Sprintf("\n## Access\n")
-		response += fmt.Sprintf("- **URL**: https://%s\n", statusBefore.Hostname)
-		response += "- The application should be accessible at this URL once the restart completes\n"
+	if restart.Error != "" {
+		response += fmt.Sprintf("- **Error**: %s\n", restart.Error)
 	}
 
-	t.logger.Info().
-		Str("user_id", userID).
-		Str("app_name", appName).
-		Int("machine_count", statusBefore.MachineCount).
-		Msg("Successfully initiated app restart")
+	response += "\n## Verification\n"
+	response += "| Machine | Region | Batch | Result | Healthy |\n"
+	response += "|---------|--------|-------|--------|---------|\n"
+	for _, m := range restart.Machines {
+		healthy := "-"
+		if m.Action != fly.MachineSkipped {
+			healthy = "🔴 no"
+			if m.Healthy {
+				healthy = "🟢 yes"
+			}
+		}
+		outcome := m.Action
+		if m.Error != "" {
+			outcome += ": " + truncateOutput(m.Error, 120)
+		}
+		response += fmt.Sprintf("| `%s` | %s | %d | %s | %s |\n", m.MachineID, m.Region, m.Batch, outcome, healthy)
+	}
+
+	if restart.HealthGate != nil {
+		response += formatHealthGate(restart.HealthGate)
+		isError = isError || restart.HealthGate.Decision != fly.GatePassed
+	}
+
+	response += "\n## Next Steps\n"
+	if isError {
+		response += "- Use `fly_machine_events` on the unhealthy machines to see why they did not recover\n"
+		response += "- Use `fly_logs` to look for errors since the restart\n"
+	} else {
+		response += "- Use `fly_status` to check the current status\n"
+		response += "- Use `fly_logs` to confirm the application started cleanly\n"
+	}
+
+	if hostname != "" {
+		response += "\n## Access\n"
+		response += fmt.Sprintf("- **URL**: https://%s\n", hostname)
+	}
 
 	return &interfaces.ToolResult{
 		Content: []interfaces.ContentBlock{{
 			Type: "text",
 			Text: response,
 		}},
-	}, nil
+		IsError: isError,
+	}
+}
+
+// runHealthGate watches the restarted machines and records the gate's
+// decision in the audit log
+func (t *AppRestartTool) runHealthGate(ctx context.Context, userID, appName string, restarted *fly.RestartResult, gate fly.HealthGate) *fly.HealthGateResult {
+	var machineIDs []string
+	for _, m := range restarted.Machines {
+		if m.Action == fly.MachineRestarted {
+			machineIDs = append(machineIDs, m.MachineID)
+		}
+	}
+
+	result := t.flyClient.RunHealthGate(ctx, appName, machineIDs, gate, nil)
+
+	t.authManager.AuditLog(ctx, userID, "restart_health_gate", appName, result.Decision, map[string]interface{}{
+		"reason":         result.Reason,
+		"failure_rate":   result.FailureRate,
+		"threshold":      result.Threshold,
+		"rollback_image": result.RollbackImage,
+	})
+
+	return result
+}
+
+// preview describes the restart and issues its confirmation token
+func (t *AppRestartTool) preview(ctx context.Context, appName string, req fly.RestartRequest, gate *fly.HealthGate, scope map[string]interface{}) *interfaces.ToolResult {
+	status, err := t.flyClient.GetAppStatus(ctx, appName)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to get status for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}
+	}
+
+	plan, err := t.flyClient.PlanRestart(ctx, appName, req)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Cannot restart app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}
+	}
+
+	machines := 0
+	for _, batch := range plan.Batches {
+		machines += len(batch)
+	}
+
+	summary := fmt.Sprintf("- **Application**: %s (currently %s)\n", appName, status.Status)
+	switch req.Strategy {
+	case fly.RestartRolling:
+		summary += fmt.Sprintf("- **Plan**: restart %d machine(s) in %d batch(es), waiting up to %s for each batch to pass its health checks and stopping at the first that does not\n", machines, len(plan.Batches), req.HealthTimeout)
+	case fly.RestartByRegion:
+		summary += fmt.Sprintf("- **Plan**: restart %d machine(s) one region at a time (%d regions), waiting up to %s for each region to pass its health checks and stopping at the first that does not\n", machines, len(plan.Batches), req.HealthTimeout)
+	default:
+		summary += fmt.Sprintf("- **Plan**: restart all %d machine(s) at once, then wait up to %s for them to pass their health checks\n", machines, req.HealthTimeout)
+	}
+	summary += "- **Impact**: each machine is stopped and started again, so expect brief downtime"
+	if req.Strategy == fly.RestartAllAtOnce && machines > 1 {
+		summary += " while every machine is down at the same time"
+	}
+	if gate != nil {
+		rollback := "report the failure"
+		if gate.Rollback {
+			rollback = "roll the machines back to the image of the previous release"
+		}
+		summary += fmt.Sprintf("\n- **Health Gate**: watch the machines for %s and, if more than %.0f%% of health samples fail, %s", gate.Window, gate.Threshold*100, rollback)
+	}
+	if !req.Filter.IsZero() {
+		summary += fmt.Sprintf("\n- **Filter**: %s; only the machines below are restarted", describeMachineFilter(req.Filter))
+	}
+	summary += "\n\n| Machine | Region | State | Image | Batch |\n|---------|--------|-------|-------|-------|\n"
+	var machineIDs []string
+	for i, batch := range plan.Batches {
+		for _, m := range batch {
+			machineIDs = append(machineIDs, m.MachineID)
+			image := m.Image
+			if m.Digest != "" {
+				image += " (" + shortDigest(m.Digest) + ")"
+			}
+			summary += fmt.Sprintf("| `%s` | %s | %s | %s | %d |\n", m.MachineID, m.Region, m.State, image, i+1)
+		}
+	}
+	summary += formatLeaseWarning(t.flyClient.MachineLeases(ctx, appName, machineIDs))
+	summary = strings.TrimSuffix(summary, "\n")
+
+	return requestConfirmation(ctx, t.authManager, "fly_restart", "Restart", appName, scope, summary)
+}
+
+// describeMachineFilter describes the machines a filter selects
+func describeMachineFilter(filter fly.MachineFilter) string {
+	var parts []string
+	if len(filter.MachineIDs) > 0 {
+		parts = append(parts, "machines "+strings.Join(filter.MachineIDs, ", "))
+	}
+	if filter.Region != "" {
+		parts = append(parts, "region "+filter.Region)
+	}
+	if filter.State != "" {
+		parts = append(parts, "state "+filter.State)
+	}
+	if filter.ImageDigest != "" {
+		parts = append(parts, "image digest "+filter.ImageDigest+"…")
+	}
+	return strings.Join(parts, ", ")
 }
@@ -3,24 +3,29 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/brannn/fly-mcp/internal/logger"
 	"github.com/brannn/fly-mcp/pkg/auth"
 	"github.com/brannn/fly-mcp/pkg/fly"
 	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/journal"
 )
 
 // AppScaleTool implements the fly_scale MCP tool
 type AppScaleTool struct {
 	flyClient   *fly.Client
+	journal     *journal.Journal
 	authManager *auth.Manager
 	logger      *logger.Logger
 }
 
 // NewAppScaleTool creates a new app scale tool
-func NewAppScaleTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *AppScaleTool {
+func NewAppScaleTool(flyClient *fly.Client, ops *journal.Journal, authManager *auth.Manager, logger *logger.Logger) *AppScaleTool {
 	return &AppScaleTool{
 		flyClient:   flyClient,
+		journal:     ops,
 		authManager: authManager,
 		logger:      logger,
 	}
@@ -33,7 +38,7 @@ func (t *AppScaleTool) Name() string {
 
 // Description returns the tool description
 func (t *AppScaleTool) Description() string {
-	return "Scale a Fly.io application by showing current machine count and providing scaling recommendations. Note: Actual scaling requires manual intervention or deployment."
+	return "Scale a Fly.io application by showing current machine count and providing scaling recommendations. The recommend action sizes machines from their measured CPU and memory use, with resize commands and the estimated cost change, or compares machine counts when given target_count. The apply action changes the machine count to target_count, optionally in one region: it clones the newest running machine to scale out and stops, then destroys, machines without volumes to scale in; fly_undo reverts it. apply previews the change first and returns a confirmation token; call again with the token to scale."
 }
 
 // InputSchema returns the JSON schema for the tool's input
@@ -47,22 +52,50 @@ func (t *AppScaleTool) InputSchema() map[string]interface{} {
 			},
 			"action": map[string]interface{}{
 				"type":        "string",
-				"description": "Action to perform: 'status' to show current scale, 'recommend' for scaling recommendations",
-				"enum":        []string{"status", "recommend"},
+				"description": "Action to perform: 'status' to show current scale, 'recommend' for scaling recommendations, 'apply' to change the machine count to target_count",
+				"enum":        []string{"status", "recommend", "apply"},
 				"default":     "status",
 			},
 			"target_count": map[string]interface{}{
 				"type":        "integer",
-				"description": "Target number of machines (for recommendations)",
+				"description": "Target number of machines (for recommendations, and required for apply)",
 				"minimum":     0,
-				"maximum":     100,
+				"maximum":     maxScaleCount,
 			},
+			"range": map[string]interface{}{
+				"type":        "string",
+				"description": "How far back to measure CPU and memory use for sizing recommendations, e.g. 24h or 7d",
+				"default":     "7d",
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "For apply, count and add machines in this region only, e.g. ams",
+			},
+			"health_timeout_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "For apply, how long each new machine may take to start and pass its health checks",
+				"default":     defaultHealthTimeout,
+				"minimum":     minHealthTimeout,
+				"maximum":     maxHealthTimeout,
+			},
+			"confirmation_token": confirmationTokenProperty(),
 		},
 		"required":             []string{"app_name"},
 		"additionalProperties": false,
 	}
 }
 
+// RequiredPermission returns the permission checked before execution
+func (t *AppScaleTool) RequiredPermission() (string, string) {
+	return "scale", "app"
+}
+
+// ReadOnlyCall reports whether a call only reads; every action but apply does
+func (t *AppScaleTool) ReadOnlyCall(args map[string]interface{}) bool {
+	action, _ := args["action"].(string)
+	return action != "apply"
+}
+
 // Execute executes the app scale tool
 func (t *AppScaleTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
 	// Validate permissions
@@ -99,6 +132,21 @@ func (t *AppScaleTool) Execute(ctx context.Context, args map[string]interface{})
 		targetCount = &count
 	}
 
+	rangeArg := "7d"
+	if r, ok := args["range"].(string); ok && r != "" {
+		rangeArg = r
+	}
+	window, err := parseMetricsRange(rangeArg)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
 	// Log the operation
 	userID, _ := t.authManager.ExtractUserFromContext(ctx)
 	t.logger.Info().
@@ -108,6 +156,10 @@ func (t *AppScaleTool) Execute(ctx context.Context, args map[string]interface{})
 		Str("action", action).
 		Msg("Executing app scale tool")
 
+	if action == "apply" {
+		return t.applyScale(ctx, args, appName, targetCount)
+	}
+
 	// Get current app status with machine information
 	status, err := t.flyClient.GetAppStatus(ctx, appName)
 	if err != nil {
@@ -118,7 +170,7 @@ func (t *AppScaleTool) Execute(ctx context.Context, args map[string]interface{})
 		return &interfaces.ToolResult{
 			Content: []interfaces.ContentBlock{{
 				Type: "text",
-				Text: fmt.Sprintf("Failed to retrieve app status for '%s': %v", appName, err),
+				Text: fmt.Sprintf("Failed to retrieve app status for '%s': %s", appName, describeError(err)),
 			}},
 			IsError: true,
 		}, nil
@@ -131,23 +183,54 @@ func (t *AppScaleTool) Execute(ctx context.Context, args map[string]interface{})
 		"target_count":  targetCount,
 	})
 
+	out := NewOutputFormatter(ctx, args)
+
 	// Handle different actions
 	switch action {
 	case "status":
-		return t.formatStatusResponse(status)
+		result, err := t.formatStatusResponse(status)
+		if err != nil {
+			return nil, err
+		}
+		return out.Render(result, fmt.Sprintf("Scaling status for application '%s'", appName), status, appLinks(appName)...), nil
 	case "recommend":
-		return t.formatRecommendationResponse(status, targetCount)
+		if targetCount == nil {
+			return t.recommendSizing(ctx, out, appName, window, rangeArg)
+		}
+
+		var machineCost float64
+		if targetCount != nil {
+			machineCost = t.machineMonthlyCost(ctx, appName)
+		}
+		result, err := t.formatRecommendationResponse(status, targetCount, machineCost)
+		if err != nil {
+			return nil, err
+		}
+		return out.Render(result, fmt.Sprintf("Scaling recommendation for application '%s'", appName), scaleRecommendation{
+			AppName:            appName,
+			CurrentCount:       status.MachineCount,
+			TargetCount:        targetCount,
+			MachineMonthlyCost: machineCost,
+		}, appLinks(appName)...), nil
 	default:
 		return &interfaces.ToolResult{
 			Content: []interfaces.ContentBlock{{
 				Type: "text",
-				Text: fmt.Sprintf("Unknown action: %s. Use 'status' or 'recommend'", action),
+				Text: fmt.Sprintf("Unknown action: %s. Use 'status', 'recommend' or 'apply'", action),
 			}},
 			IsError: true,
 		}, nil
 	}
 }
 
+// scaleRecommendation is the structured result of the recommend action
+type scaleRecommendation struct {
+	AppName            string  `json:"appName"`
+	CurrentCount       int     `json:"currentCount"`
+	TargetCount        *int    `json:"targetCount,omitempty"`
+	MachineMonthlyCost float64 `json:"machineMonthlyCost,omitempty"`
+}
+
 // formatStatusResponse formats the current scaling status
 func (t *AppScaleTool) formatStatusResponse(status *fly.AppStatus) (*interfaces.ToolResult, error) {
 	var response string
@@ -216,9 +299,9 @@ func (t *AppScaleTool) formatStatusResponse(status *fly.AppStatus) (*interfaces.
 	// Scaling actions
 	response += "\n## Scaling Actions\n"
 	response += "To scale your application:\n"
-	response += "1. **Manual scaling**: Use `flyctl scale count <number>` in your terminal\n"
-	response += "2. **Check recommendations**: Use this tool with `action: recommend` and `target_count`\n"
-	response += "3. **Auto-scaling**: Configure auto-scaling in your fly.toml file\n"
+	response += "1. **Change the count**: Use this tool with `action: apply` and `target_count`, or `fly_machine_clone` to add one machine in a chosen region\n"
+	response += "2. **Right-size machines**: Use this tool with `action: recommend` for sizes based on measured CPU and memory, or with `target_count` to compare machine counts\n"
+	response += "3. **Auto-scaling**: Use `fly_autoscale` to let the proxy stop idle machines and start them on demand\n"
 	
 	response += "\n## Next Steps\n"
 	response += "- Use `fly_status` to monitor machine health\n"
@@ -234,31 +317,11 @@ func (t *AppScaleTool) formatStatusResponse(status *fly.AppStatus) (*interfaces.
 }
 
 // formatRecommendationResponse formats scaling recommendations
-func (t *AppScaleTool) formatRecommendationResponse(status *fly.AppStatus, targetCount *int) (*interfaces.ToolResult, error) {
+func (t *AppScaleTool) formatRecommendationResponse(status *fly.AppStatus, targetCount *int, machineCost float64) (*interfaces.ToolResult, error) {
 	var response string
 	
 	currentCount := status.MachineCount
 	
-	if targetCount == nil {
-		response += fmt.Sprintf("# Scaling Recommendations: %s\n\n", status.AppName)
-		response += "## General Recommendations\n"
-		response += fmt.Sprintf("- **Current machines**: %d\n", currentCount)
-		response += "\n**Recommended scaling based on use case:**\n"
-		response += "- **Development**: 1 machine\n"
-		response += "- **Staging**: 1-2 machines\n"
-		response += "- **Production (small)**: 2-3 machines\n"
-		response += "- **Production (medium)**: 3-5 machines\n"
-		response += "- **Production (large)**: 5+ machines\n"
-		response += "\nProvide `target_count` for specific scaling recommendations.\n"
-		
-		return &interfaces.ToolResult{
-			Content: []interfaces.ContentBlock{{
-				Type: "text",
-				Text: response,
-			}},
-		}, nil
-	}
-	
 	target := *targetCount
 	response += fmt.Sprintf("# Scaling Recommendation: %s\n\n", status.AppName)
 	response += fmt.Sprintf("**Current**: %d machines → **Target**: %d machines\n\n", currentCount, target)
@@ -273,14 +336,14 @@ func (t *AppScaleTool) formatRecommendationResponse(status *fly.AppStatus, targe
 		response += "- Better fault tolerance and availability\n"
 		response += "- Improved load distribution\n\n"
 		response += "**Considerations:**\n"
-		response += fmt.Sprintf("- Additional cost: ~$%d/month (estimated)\n", diff*15) // Rough estimate
+		response += fmt.Sprintf("- Additional cost: ~$%.2f/month (estimated, see `fly_costs`)\n", float64(diff)*machineCost)
 		response += "- Ensure your application can handle distributed load\n"
 		response += "- Monitor resource utilization after scaling\n"
 	} else {
 		diff := currentCount - target
 		response += fmt.Sprintf("📉 **Scale Down Recommendation** (-%d machines)\n\n", diff)
 		response += "**Benefits:**\n"
-		response += "- Reduced operational costs\n"
+		response += fmt.Sprintf("- Reduced operational costs: ~$%.2f/month (estimated, see `fly_costs`)\n", float64(diff)*machineCost)
 		response += "- Simplified management\n\n"
 		response += "**Considerations:**\n"
 		response += "- Ensure remaining capacity can handle peak load\n"
@@ -312,3 +375,284 @@ func (t *AppScaleTool) formatRecommendationResponse(status *fly.AppStatus, targe
 		}},
 	}, nil
 }
+
+// recommendSizing compares the machines' measured CPU and memory use with
+// their sizes and recommends resizes
+func (t *AppScaleTool) recommendSizing(ctx context.Context, out *OutputFormatter, appName string, window time.Duration, rangeArg string) (*interfaces.ToolResult, error) {
+	report, err := t.flyClient.RecommendSizing(ctx, appName, window)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to measure machine usage for '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+	report.Window = rangeArg
+
+	return out.Render(t.formatSizingResponse(report), fmt.Sprintf("Sizing recommendation for application '%s'", appName), report, appLinks(appName)...), nil
+}
+
+// formatSizingResponse formats machine sizing recommendations
+func (t *AppScaleTool) formatSizingResponse(report *fly.SizingReport) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Sizing Recommendations: %s (last %s)\n\n", report.AppName, report.Window)
+
+	if len(report.Machines) == 0 {
+		response += "⚠️ **No machines found** - App may need to be deployed first\n"
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: response,
+			}},
+		}
+	}
+
+	response += "## Machines\n"
+	response += "| Machine | Region | Size | CPU avg / peak | Memory avg / peak | Recommended | Cost change |\n"
+	response += "|---------|--------|------|----------------|-------------------|-------------|-------------|\n"
+	unmeasured := 0
+	for _, m := range report.Machines {
+		size := fmt.Sprintf("%s, %d MB", m.Current.VMSize(), m.Current.MemoryMB)
+		if m.Usage == nil {
+			unmeasured++
+			response += fmt.Sprintf("| %s | %s | %s | no data | no data | - | - |\n", m.ID, m.Region, size)
+			continue
+		}
+		usage := fmt.Sprintf("| %s | %s | %s | %.0f%% / %.0f%% | %.0f / %.0f MB |",
+			m.ID, m.Region, size, m.Usage.CPUAvg, m.Usage.CPUPeak, m.Usage.MemoryAvgMB, m.Usage.MemoryPeakMB)
+		if m.Recommended == nil {
+			response += usage + " ✅ keep | - |\n"
+			continue
+		}
+		change := fmt.Sprintf("+$%.2f/month", m.MonthlyDelta)
+		if m.MonthlyDelta < 0 {
+			change = fmt.Sprintf("-$%.2f/month", -m.MonthlyDelta)
+		}
+		response += usage + fmt.Sprintf(" %s, %d MB | %s |\n", m.Recommended.VMSize(), m.Recommended.MemoryMB, change)
+	}
+
+	if len(report.Commands) == 0 {
+		response += "\n✅ **Machine sizes match their usage**\n"
+	} else {
+		response += "\n## Why\n"
+		for _, m := range report.Machines {
+			if m.Recommended != nil {
+				response += fmt.Sprintf("- **%s**: %s\n", m.ID, strings.Join(m.Reasons, "; "))
+			}
+		}
+
+		response += "\n## Estimated Cost\n"
+		if report.MonthlyDelta < 0 {
+			response += fmt.Sprintf("📉 Saves ~$%.2f/month (estimated for machines running all month, see `fly_costs`)\n", -report.MonthlyDelta)
+		} else {
+			response += fmt.Sprintf("📈 Adds ~$%.2f/month (estimated for machines running all month, see `fly_costs`)\n", report.MonthlyDelta)
+		}
+
+		response += "\n## How to Resize\n"
+		response += "Run in your terminal:\n```bash\n" + strings.Join(report.Commands, "\n") + "\n```\n"
+		response += "Resizing restarts the machines. Use `fly_metrics` afterwards to confirm the new sizes fit.\n"
+	}
+
+	if unmeasured > 0 {
+		response += fmt.Sprintf("\n⚠️ %d machine(s) have no metrics in the window and keep their size.\n", unmeasured)
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// machineMonthlyCost estimates the monthly cost of one more machine sized like
+// the app's existing machines
+func (t *AppScaleTool) machineMonthlyCost(ctx context.Context, appName string) float64 {
+	pricing := t.flyClient.Pricing()
+
+	estimate, err := t.flyClient.EstimateAppCost(ctx, appName)
+	if err != nil {
+		t.logger.Warn().
+			Str("app_name", appName).
+			Err(err).
+			Msg("Failed to estimate machine cost, using smallest machine size")
+		return (&fly.CostEstimate{}).AverageMachineCost(pricing)
+	}
+	return estimate.AverageMachineCost(pricing)
+}
+
+// applyScale changes the app's machine count to the target, in two phases
+// like the other mutating tools
+func (t *AppScaleTool) applyScale(ctx context.Context, args map[string]interface{}, appName string, targetCount *int) (*interfaces.ToolResult, error) {
+	if targetCount == nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: target_count is required for the apply action",
+			}},
+			IsError: true,
+		}, nil
+	}
+	if *targetCount < 0 || *targetCount > maxScaleCount {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: target_count must be between 0 and %d", maxScaleCount),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	region, _ := args["region"].(string)
+	healthTimeout := defaultHealthTimeout * time.Second
+	if v, ok := args["health_timeout_seconds"].(float64); ok {
+		healthTimeout = time.Duration(max(minHealthTimeout, min(int(v), maxHealthTimeout))) * time.Second
+	}
+
+	plan, err := t.flyClient.PlanScale(ctx, appName, *targetCount, region)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to plan scaling of '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+	if plan.Current == plan.Target {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("✅ **No scaling needed** - '%s' already has %d machine(s)%s", appName, plan.Current, regionSuffix(region)),
+			}},
+		}, nil
+	}
+
+	// The plan is made again on confirmation; binding the machines it
+	// clones and destroys refuses the token if they changed since the preview
+	scope := map[string]interface{}{
+		"app_name":       appName,
+		"target_count":   *targetCount,
+		"region":         region,
+		"health_timeout": healthTimeout.Seconds(),
+		"source":         plan.Source,
+		"destroy":        plan.Destroy,
+	}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		machineCost := t.machineMonthlyCost(ctx, appName)
+
+		var summary string
+		summary += fmt.Sprintf("- **Machines%s**: %d → %d\n", regionSuffix(region), plan.Current, plan.Target)
+		if create := plan.Create(); create > 0 {
+			summary += fmt.Sprintf("- **Plan**: clone machine `%s` %d time(s), waiting up to %s for each clone to become healthy\n", plan.Source, create, healthTimeout)
+			summary += fmt.Sprintf("- **Cost**: about $%.2f/month more (estimated, see `fly_costs`)", float64(create)*machineCost)
+		} else {
+			summary += fmt.Sprintf("- **Plan**: stop, then destroy %s; machines with volumes are kept\n", strings.Join(codeList(plan.Destroy), ", "))
+			summary += fmt.Sprintf("- **Savings**: about $%.2f/month (estimated, see `fly_costs`)", float64(len(plan.Destroy))*machineCost)
+		}
+		return requestConfirmation(ctx, t.authManager, "fly_scale", "Scale", appName, scope, summary), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_scale", token, scope); result != nil {
+		return result, nil
+	}
+
+	result := t.flyClient.ApplyScale(ctx, plan, healthTimeout)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	outcome := "success"
+	if result.Error != "" {
+		outcome = "failed"
+	}
+	created := make([]string, 0, len(result.Created))
+	for _, clone := range result.Created {
+		created = append(created, clone.MachineID)
+	}
+	t.authManager.AuditLog(ctx, userID, "scale_app", appName, outcome, map[string]interface{}{
+		"region":    region,
+		"current":   plan.Current,
+		"target":    plan.Target,
+		"created":   created,
+		"destroyed": result.Destroyed,
+		"error":     result.Error,
+	})
+
+	response := t.formatApplyResponse(result)
+	response.Content[0].Text += recordOperation(t.journal, journal.ScaleOperation(result), "fly_scale", userID)
+
+	return NewOutputFormatter(ctx, args).Render(response, fmt.Sprintf("Scaling of application '%s'", appName), result, appLinks(appName)...), nil
+}
+
+// formatApplyResponse formats the outcome of the apply action
+func (t *AppScaleTool) formatApplyResponse(result *fly.ScaleResult) *interfaces.ToolResult {
+	var response string
+
+	if result.Error != "" {
+		response += fmt.Sprintf("❌ **Scaling of '%s' Stopped**\n\n", result.AppName)
+	} else {
+		response += fmt.Sprintf("✅ **Application '%s' Scaled**\n\n", result.AppName)
+	}
+
+	response += "## Scale Summary\n"
+	response += fmt.Sprintf("- **Machines%s**: %d → %d\n", regionSuffix(result.Region), result.Current, result.Current+len(result.Created)-len(result.Destroyed))
+	response += fmt.Sprintf("- **Duration**: %s\n", result.Duration)
+	if result.Error != "" {
+		response += fmt.Sprintf("- **Error**: %s\n", result.Error)
+	}
+
+	if len(result.Created) > 0 {
+		response += "\n## Created Machines\n"
+		response += "| Machine | Region | Healthy | Volumes |\n"
+		response += "|---------|--------|---------|---------|\n"
+		for _, clone := range result.Created {
+			volumes := "-"
+			if len(clone.Volumes) > 0 {
+				volumes = strings.Join(codeList(clone.Volumes), ", ")
+			}
+			response += fmt.Sprintf("| `%s` | %s | %t | %s |\n", clone.MachineID, clone.Region, clone.Healthy, volumes)
+		}
+	}
+	if len(result.Destroyed) > 0 {
+		response += "\n## Destroyed Machines\n"
+		for _, machineID := range result.Destroyed {
+			response += fmt.Sprintf("- `%s`\n", machineID)
+		}
+	}
+
+	response += "\n## Next Steps\n"
+	response += "- Use `fly_status` to check the machines' health\n"
+	if result.Error != "" {
+		response += "- Fix the error and run `apply` again; it continues from the current count\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: result.Error != "",
+	}
+}
+
+// maxScaleCount is the largest machine count apply scales to
+const maxScaleCount = 100
+
+// regionSuffix describes the region a count is limited to
+func regionSuffix(region string) string {
+	if region == "" {
+		return ""
+	}
+	return " in " + region
+}
+
+// codeList formats IDs as inline code
+func codeList(ids []string) []string {
+	formatted := make([]string, len(ids))
+	for i, id := range ids {
+		formatted[i] = "`" + id + "`"
+	}
+	return formatted
+}
@@ -2,7 +2,6 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/brannn/fly-mcp/internal/logger"
@@ -46,12 +45,6 @@ func (t *AppStatusTool) InputSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "Name of the application to check status for",
 			},
-			"format": map[string]interface{}{
-				"type":        "string",
-				"description": "Response format (text or json)",
-				"enum":        []string{"text", "json"},
-				"default":     "text",
-			},
 			"detailed": map[string]interface{}{
 				"type":        "boolean",
 				"description": "Include detailed machine information",
@@ -63,6 +56,11 @@ func (t *AppStatusTool) InputSchema() map[string]interface{} {
 	}
 }
 
+// RequiredPermission returns the permission checked before execution
+func (t *AppStatusTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
 // Execute executes the app status tool
 func (t *AppStatusTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
 	// Validate permissions
@@ -88,10 +86,7 @@ func (t *AppStatusTool) Execute(ctx context.Context, args map[string]interface{}
 		}, nil
 	}
 
-	format := "text"
-	if fmt, ok := args["format"].(string); ok {
-		format = fmt
-	}
+	out := NewOutputFormatter(ctx, args)
 
 	detailed := false
 	if det, ok := args["detailed"].(bool); ok {
@@ -104,7 +99,7 @@ func (t *AppStatusTool) Execute(ctx context.Context, args map[string]interface{}
 		Str("user_id", userID).
 		Str("tool", "fly_status").
 		Str("app_name", appName).
-		Str("format", format).
+		Str("format", out.Format).
 		Bool("detailed", detailed).
 		Msg("Executing app status tool")
 
@@ -118,7 +113,7 @@ func (t *AppStatusTool) Execute(ctx context.Context, args map[string]interface{}
 		return &interfaces.ToolResult{
 			Content: []interfaces.ContentBlock{{
 				Type: "text",
-				Text: fmt.Sprintf("Failed to retrieve status for app '%s': %v", appName, err),
+				Text: fmt.Sprintf("Failed to retrieve status for app '%s': %s", appName, describeError(err)),
 			}},
 			IsError: true,
 		}, nil
@@ -126,39 +121,18 @@ func (t *AppStatusTool) Execute(ctx context.Context, args map[string]interface{}
 
 	// Log successful operation
 	t.authManager.AuditLog(ctx, userID, "get_app_status", appName, "success", map[string]interface{}{
-		"format":        format,
+		"format":        out.Format,
 		"detailed":      detailed,
 		"machine_count": status.MachineCount,
 		"status":        status.Status,
 	})
 
-	// Format response based on requested format
-	if format == "json" {
-		return t.formatJSONResponse(status)
-	}
-	
-	return t.formatTextResponse(status, detailed)
-}
-
-// formatJSONResponse formats the response as JSON
-func (t *AppStatusTool) formatJSONResponse(status *fly.AppStatus) (*interfaces.ToolResult, error) {
-	jsonData, err := json.MarshalIndent(status, "", "  ")
+	result, err := t.formatTextResponse(status, detailed)
 	if err != nil {
-		return &interfaces.ToolResult{
-			Content: []interfaces.ContentBlock{{
-				Type: "text",
-				Text: fmt.Sprintf("Error formatting JSON response: %v", err),
-			}},
-			IsError: true,
-		}, nil
+		return nil, err
 	}
-
-	return &interfaces.ToolResult{
-		Content: []interfaces.ContentBlock{{
-			Type: "text",
-			Text: fmt.Sprintf("Status for application '%s':\n\n```json\n%s\n```", status.AppName, string(jsonData)),
-		}},
-	}, nil
+	
+	return out.Render(result, fmt.Sprintf("Status for application '%s'", appName), status, appLinks(appName)...), nil
 }
 
 // formatTextResponse formats the response as human-readable text
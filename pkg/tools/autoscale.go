@@ -0,0 +1,348 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/journal"
+)
+
+// AutoscaleTool implements the fly_autoscale MCP tool
+type AutoscaleTool struct {
+	flyClient   *fly.Client
+	journal     *journal.Journal
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewAutoscaleTool creates a new autoscale tool
+func NewAutoscaleTool(flyClient *fly.Client, ops *journal.Journal, authManager *auth.Manager, logger *logger.Logger) *AutoscaleTool {
+	return &AutoscaleTool{
+		flyClient:   flyClient,
+		journal:     ops,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *AutoscaleTool) Name() string {
+	return "fly_autoscale"
+}
+
+// Description returns the tool description
+func (t *AutoscaleTool) Description() string {
+	return "Inspect or change how the Fly Proxy automatically stops and starts an application's machines (autostop, autostart and min_machines_running), e.g. to let an idle app scale to zero."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *AutoscaleTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "Action to perform: 'status' to show the current settings, 'update' to change them",
+				"enum":        []string{"status", "update"},
+				"default":     "status",
+			},
+			"auto_stop": map[string]interface{}{
+				"type":        "string",
+				"description": "What the proxy does with idle machines: 'off', 'stop' or 'suspend' (for update)",
+				"enum":        []string{fly.AutoStopOff, fly.AutoStopStop, fly.AutoStopSuspend},
+			},
+			"auto_start": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Whether the proxy starts stopped machines when requests arrive (for update)",
+			},
+			"min_machines_running": map[string]interface{}{
+				"type":        "integer",
+				"description": "Machines kept running in the primary region when autostop is on; 0 allows scaling to zero (for update)",
+				"minimum":     0,
+				"maximum":     100,
+			},
+			"confirmation_token": confirmationTokenProperty(),
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *AutoscaleTool) RequiredPermission() (string, string) {
+	return "scale", "app"
+}
+
+// ReadOnlyCall reports whether a call only reads the current settings
+func (t *AutoscaleTool) ReadOnlyCall(args map[string]interface{}) bool {
+	action, _ := args["action"].(string)
+	return action != "update"
+}
+
+// Execute executes the autoscale tool
+func (t *AutoscaleTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "scale", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	action := "status"
+	if a, ok := args["action"].(string); ok {
+		action = a
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_autoscale").
+		Str("app_name", appName).
+		Str("action", action).
+		Msg("Executing autoscale tool")
+
+	switch action {
+	case "status":
+		return t.status(ctx, userID, appName, out)
+	case "update":
+		return t.update(ctx, userID, appName, args, out)
+	default:
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Unknown action: %s. Use 'status' or 'update'", action),
+			}},
+			IsError: true,
+		}, nil
+	}
+}
+
+// status reports the current autoscaling settings
+func (t *AutoscaleTool) status(ctx context.Context, userID, appName string, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	policy, err := t.flyClient.GetAutoscalePolicy(ctx, appName)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "autoscale_status", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to read autoscaling settings for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "autoscale_status", appName, "success", map[string]interface{}{
+		"machine_count": policy.MachineCount,
+	})
+
+	return out.Render(t.formatStatusResponse(policy), fmt.Sprintf("Autoscaling settings for application '%s'", appName), policy, appLinks(appName)...), nil
+}
+
+// update changes the autoscaling settings on every machine with services
+func (t *AutoscaleTool) update(ctx context.Context, userID, appName string, args map[string]interface{}, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	var update fly.AutoscaleUpdate
+	if v, ok := args["auto_stop"].(string); ok {
+		if v != fly.AutoStopOff && v != fly.AutoStopStop && v != fly.AutoStopSuspend {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: auto_stop must be 'off', 'stop' or 'suspend', got '%s'", v),
+				}},
+				IsError: true,
+			}, nil
+		}
+		update.AutoStop = &v
+	}
+	if v, ok := args["auto_start"].(bool); ok {
+		update.AutoStart = &v
+	}
+	if v, ok := args["min_machines_running"].(float64); ok {
+		count := int(v)
+		if count < 0 {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: "Error: min_machines_running must not be negative",
+				}},
+				IsError: true,
+			}, nil
+		}
+		update.MinMachinesRunning = &count
+	}
+
+	if update.IsEmpty() {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: update requires at least one of auto_stop, auto_start or min_machines_running",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	scope := map[string]interface{}{"app_name": appName, "update": update}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		summary := fmt.Sprintf("- **Application**: %s\n", appName)
+		if update.AutoStop != nil {
+			summary += fmt.Sprintf("- **Autostop**: %s\n", *update.AutoStop)
+		}
+		if update.AutoStart != nil {
+			summary += fmt.Sprintf("- **Autostart**: %t\n", *update.AutoStart)
+		}
+		if update.MinMachinesRunning != nil {
+			summary += fmt.Sprintf("- **Min machines running**: %d\n", *update.MinMachinesRunning)
+		}
+		summary += "- **Impact**: the config of every machine with services is rewritten, and running machines restart to pick it up"
+
+		return requestConfirmation(ctx, t.authManager, "fly_autoscale", "Update", appName, scope, summary), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_autoscale", token, scope); result != nil {
+		return result, nil
+	}
+
+	// The settings before the update are what fly_undo restores
+	before, err := t.flyClient.GetAutoscalePolicy(ctx, appName)
+	if err != nil {
+		t.logger.Warn().
+			Err(err).
+			Str("app_name", appName).
+			Msg("Failed to read autoscaling settings before the update")
+	}
+
+	updated, err := t.flyClient.UpdateAutoscalePolicy(ctx, appName, update)
+	note := recordOperation(t.journal, journal.AutoscaleOperation(appName, before, update, updated, err), "fly_autoscale", userID)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "autoscale_update", appName, "failed", map[string]interface{}{
+			"error":            err.Error(),
+			"update":           update,
+			"machines_updated": updated,
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Update Failed**\n\nFailed to update autoscaling settings for app '%s': %s\n\nMachines updated before the failure: %d. Use `fly_autoscale` with `action: status` to review the current settings.\n%s", appName, describeError(err), len(updated), note),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "autoscale_update", appName, "success", map[string]interface{}{
+		"update":           update,
+		"machines_updated": updated,
+	})
+
+	var response string
+
+	response += fmt.Sprintf("✅ **Autoscaling Updated: %s**\n\n", appName)
+	response += "## Changes\n"
+	if update.AutoStop != nil {
+		response += fmt.Sprintf("- **Autostop**: %s\n", *update.AutoStop)
+	}
+	if update.AutoStart != nil {
+		response += fmt.Sprintf("- **Autostart**: %t\n", *update.AutoStart)
+	}
+	if update.MinMachinesRunning != nil {
+		response += fmt.Sprintf("- **Min Machines Running**: %d\n", *update.MinMachinesRunning)
+	}
+	response += fmt.Sprintf("- **Machines Updated**: %d\n", len(updated))
+
+	if len(updated) == 0 {
+		response += "\n⚠️ No machines have services, so there was nothing to update. The proxy only stops and starts machines that serve traffic through `[[services]]` or `[http_service]`.\n"
+	}
+
+	response += "\n## Notes\n"
+	response += "- Update `fly.toml` to match, otherwise the next deploy restores the old settings\n"
+	if update.AutoStop != nil && *update.AutoStop != fly.AutoStopOff && (update.AutoStart == nil || !*update.AutoStart) {
+		response += "- ⚠️ Machines stopped by autostop are only started again when autostart is enabled\n"
+	}
+	response += note
+
+	result := &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+	return out.Render(result, fmt.Sprintf("Autoscaling update for application '%s'", appName), autoscaleUpdate{
+		Update:          update,
+		MachinesUpdated: updated,
+	}, appLinks(appName)...), nil
+}
+
+// autoscaleUpdate is the structured result of an autoscaling update
+type autoscaleUpdate struct {
+	Update          fly.AutoscaleUpdate `json:"update"`
+	MachinesUpdated []string            `json:"machinesUpdated"`
+}
+
+// formatStatusResponse formats the autoscaling settings as human-readable text
+func (t *AutoscaleTool) formatStatusResponse(policy *fly.AutoscalePolicy) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Autoscaling: %s\n\n", policy.AppName)
+
+	response += "## Summary\n"
+	response += fmt.Sprintf("- **Machines**: %d (%d running)\n", policy.MachineCount, policy.RunningMachines)
+	response += fmt.Sprintf("- **Max Machines**: %d (the proxy never creates machines, so the machine count is the ceiling)\n", policy.MachineCount)
+
+	if len(policy.Services) > 0 {
+		response += "\n## Services\n"
+		for _, s := range policy.Services {
+			icon := "🟢"
+			if s.State != "started" {
+				icon = "🔴"
+			}
+			response += fmt.Sprintf("- %s **%s** (%s) %s/%d: autostop=%s, autostart=%t, min_machines_running=%d\n",
+				icon, s.MachineID, s.Region, s.Protocol, s.InternalPort, s.AutoStop, s.AutoStart, s.MinMachinesRunning)
+		}
+	} else {
+		response += "\nNo machines have services, so the proxy does not stop or start any of them.\n"
+	}
+
+	if len(policy.MachinesWithoutServices) > 0 {
+		response += fmt.Sprintf("\n%d machine(s) without services are not managed by the proxy.\n", len(policy.MachinesWithoutServices))
+	}
+
+	response += "\n## Next Steps\n"
+	response += "- Scale to zero when idle: `action: update`, `auto_stop: stop`, `auto_start: true`, `min_machines_running: 0`\n"
+	response += "- Keep capacity warm: raise `min_machines_running`\n"
+	response += "- Add or remove machines with `fly_scale`\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
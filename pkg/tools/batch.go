@@ -0,0 +1,511 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+const (
+	// maxBatchApps caps the number of apps one batch call may target
+	maxBatchApps = 100
+
+	// defaultBatchConcurrency is the number of apps processed at once
+	defaultBatchConcurrency = 5
+	maxBatchConcurrency     = 10
+)
+
+// secretNamePattern matches valid secret names
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// batchOperation describes an operation fly_batch can apply to each app
+type batchOperation struct {
+	action   string
+	resource string
+	verb     string
+}
+
+// batchOperations maps each operation to the permission it requires per app
+var batchOperations = map[string]batchOperation{
+	"status":     {action: "read", resource: "app", verb: "checked"},
+	"restart":    {action: "restart", resource: "app", verb: "restarted"},
+	"secret_set": {action: "set", resource: "secret", verb: "updated"},
+}
+
+// BatchTool implements the fly_batch MCP tool
+type BatchTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewBatchTool creates a new batch operations tool
+func NewBatchTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *BatchTool {
+	return &BatchTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *BatchTool) Name() string {
+	return "fly_batch"
+}
+
+// Description returns the tool description
+func (t *BatchTool) Description() string {
+	return "Apply one operation (status check, restart or secret set) to many Fly.io applications at once, chosen by name or by a glob pattern such as 'payments-*'. Apps are processed concurrently and the result is a per-app matrix of successes and failures. Restarts and secret changes are previewed first and need the returned confirmation token."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *BatchTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"operation": map[string]interface{}{
+				"type":        "string",
+				"description": "Operation to apply to each app",
+				"enum":        []string{"status", "restart", "secret_set"},
+			},
+			"apps": map[string]interface{}{
+				"type":        "array",
+				"description": "Names of the apps to operate on (use this or pattern)",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+				"maxItems": maxBatchApps,
+			},
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Glob pattern selecting apps by name, e.g. 'payments-*' (use this or apps)",
+			},
+			"organization": map[string]interface{}{
+				"type":        "string",
+				"description": "Only match pattern against apps in this organization",
+			},
+			"secrets": map[string]interface{}{
+				"type":        "object",
+				"description": "Secrets to set on every app, as NAME: value (for secret_set)",
+				"additionalProperties": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"concurrency": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of apps processed at the same time",
+				"default":     defaultBatchConcurrency,
+				"minimum":     1,
+				"maximum":     maxBatchConcurrency,
+			},
+			"confirmation_token": confirmationTokenProperty(),
+		},
+		"required":             []string{"operation"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution. Each
+// operation additionally requires its own permission, and policies are
+// evaluated for every app.
+func (t *BatchTool) RequiredPermission() (string, string) {
+	return "batch", "apps"
+}
+
+// ReadOnlyCall reports whether a call only reads app status
+func (t *BatchTool) ReadOnlyCall(args map[string]interface{}) bool {
+	operation, _ := args["operation"].(string)
+	return operation == "status"
+}
+
+// batchResult is the outcome of the operation on one app
+type batchResult struct {
+	AppName    string `json:"appName"`
+	Result     string `json:"result"` // success, failed, denied or skipped
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+// batchReport is the outcome of a batch call
+type batchReport struct {
+	Operation string         `json:"operation"`
+	Apps      []batchResult  `json:"apps"`
+	Summary   map[string]int `json:"summary"`
+}
+
+// Execute executes the batch tool
+func (t *BatchTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "batch", "apps"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	operation, _ := args["operation"].(string)
+	op, ok := batchOperations[operation]
+	if !ok {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: operation must be one of 'status', 'restart' or 'secret_set'",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	if err := t.authManager.ValidateRequest(ctx, op.action, op.resource); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	var secrets map[string]string
+	if operation == "secret_set" {
+		var err error
+		if secrets, err = parseBatchSecrets(args["secrets"]); err != nil {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	concurrency := defaultBatchConcurrency
+	if c, ok := args["concurrency"].(float64); ok && c >= 1 {
+		concurrency = min(int(c), maxBatchConcurrency)
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	apps, err := t.resolveApps(ctx, args)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %s", describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	if operation != "status" {
+		scope := map[string]interface{}{
+			"operation": operation,
+			"apps":      apps,
+		}
+		if secrets != nil {
+			scope["secrets"] = secrets
+		}
+
+		token, _ := args["confirmation_token"].(string)
+		if token == "" {
+			return t.preview(ctx, operation, apps, secrets, scope), nil
+		}
+		if result := verifyConfirmation(ctx, t.authManager, "fly_batch", token, scope); result != nil {
+			return result, nil
+		}
+	}
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_batch").
+		Str("operation", operation).
+		Int("app_count", len(apps)).
+		Int("concurrency", concurrency).
+		Msg("Executing batch tool")
+
+	report := t.run(ctx, userID, operation, op, apps, secrets, concurrency)
+
+	return out.Render(t.formatTextResponse(report), fmt.Sprintf("Batch %s of %d app(s)", operation, len(apps)), report), nil
+}
+
+// resolveApps returns the sorted, de-duplicated apps a call targets
+func (t *BatchTool) resolveApps(ctx context.Context, args map[string]interface{}) ([]string, error) {
+	pattern, _ := args["pattern"].(string)
+	rawApps, _ := args["apps"].([]interface{})
+
+	if (pattern == "") == (len(rawApps) == 0) {
+		return nil, fmt.Errorf("exactly one of apps or pattern is required")
+	}
+
+	seen := make(map[string]bool)
+	var apps []string
+
+	if pattern != "" {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+		organization, _ := args["organization"].(string)
+
+		all, err := t.flyClient.GetApps(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, app := range all {
+			if organization != "" && (app.Organization == nil || app.Organization.Slug != organization) {
+				continue
+			}
+			if matched, _ := path.Match(pattern, app.Name); matched && !seen[app.Name] {
+				seen[app.Name] = true
+				apps = append(apps, app.Name)
+			}
+		}
+		if len(apps) == 0 {
+			return nil, fmt.Errorf("no apps match the pattern '%s'", pattern)
+		}
+	} else {
+		for _, raw := range rawApps {
+			name, ok := raw.(string)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("apps must be a list of non-empty app names")
+			}
+			if !seen[name] {
+				seen[name] = true
+				apps = append(apps, name)
+			}
+		}
+	}
+
+	if len(apps) > maxBatchApps {
+		return nil, fmt.Errorf("%d apps selected; a batch may target at most %d", len(apps), maxBatchApps)
+	}
+
+	sort.Strings(apps)
+	return apps, nil
+}
+
+// parseBatchSecrets validates the secrets argument of secret_set
+func parseBatchSecrets(raw interface{}) (map[string]string, error) {
+	values, ok := raw.(map[string]interface{})
+	if !ok || len(values) == 0 {
+		return nil, fmt.Errorf("secrets is required for secret_set and must map names to values")
+	}
+
+	secrets := make(map[string]string, len(values))
+	for name, value := range values {
+		if !secretNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid secret name '%s': use letters, digits and underscores", name)
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("secret '%s' must have a string value", name)
+		}
+		secrets[name] = s
+	}
+	return secrets, nil
+}
+
+// preview describes a mutating batch and issues its confirmation token. The
+// summary names the secrets being set but never their values.
+func (t *BatchTool) preview(ctx context.Context, operation string, apps []string, secrets map[string]string, scope map[string]interface{}) *interfaces.ToolResult {
+	op := batchOperations[operation]
+
+	var denied []string
+	for _, app := range apps {
+		if err := t.authManager.EvaluatePolicy(ctx, op.action, app); err != nil {
+			denied = append(denied, app)
+		}
+	}
+
+	summary := fmt.Sprintf("- **Operation**: %s\n", operation)
+	summary += fmt.Sprintf("- **Apps** (%d): %s\n", len(apps), strings.Join(apps, ", "))
+	if len(denied) > 0 {
+		summary += fmt.Sprintf("- **Skipped by policy** (%d): %s\n", len(denied), strings.Join(denied, ", "))
+	}
+
+	switch operation {
+	case "restart":
+		summary += "- **Impact**: every machine of each app is stopped and started again, so expect brief downtime per app"
+	case "secret_set":
+		names := make([]string, 0, len(secrets))
+		for name := range secrets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		summary += fmt.Sprintf("- **Secrets**: %s\n", strings.Join(names, ", "))
+		summary += "- **Impact**: existing values with these names are replaced; machines use the new values after their next restart or deploy"
+	}
+
+	return requestConfirmation(ctx, t.authManager, "fly_batch", "Batch "+operation, "", scope, summary)
+}
+
+// run applies the operation to every app, at most concurrency at a time
+func (t *BatchTool) run(ctx context.Context, userID, operation string, op batchOperation, apps []string, secrets map[string]string, concurrency int) *batchReport {
+	results := make([]batchResult, len(apps))
+
+	// Per-app operations must not report machine-level progress; the batch
+	// reports progress per app instead
+	appCtx := interfaces.WithProgress(ctx, nil)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	sem := make(chan struct{}, concurrency)
+
+	for i, app := range apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := t.apply(appCtx, operation, op, app, secrets)
+			results[i] = result
+
+			t.authManager.AuditLog(ctx, userID, "batch_"+operation, app, result.Result, map[string]interface{}{
+				"detail": result.Detail,
+			})
+
+			mu.Lock()
+			done++
+			interfaces.ReportProgress(ctx, float64(done), float64(len(apps)), fmt.Sprintf("%s %d/%d apps", op.verb, done, len(apps)))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	report := &batchReport{
+		Operation: operation,
+		Apps:      results,
+		Summary:   map[string]int{"success": 0, "failed": 0, "denied": 0, "skipped": 0},
+	}
+	for _, r := range results {
+		report.Summary[r.Result]++
+	}
+	return report
+}
+
+// apply runs the operation on one app
+func (t *BatchTool) apply(ctx context.Context, operation string, op batchOperation, app string, secrets map[string]string) batchResult {
+	start := time.Now()
+	result := batchResult{AppName: app}
+
+	if err := ctx.Err(); err != nil {
+		result.Result = "skipped"
+		result.Detail = "cancelled before the app was processed"
+		if errors.Is(context.Cause(ctx), interfaces.ErrBudgetExhausted) {
+			result.Detail = "time budget ran out before the app was processed"
+		}
+		return result
+	}
+
+	if err := t.authManager.EvaluatePolicy(ctx, op.action, app); err != nil {
+		result.Result = "denied"
+		result.Detail = err.Error()
+		return result
+	}
+
+	var err error
+	switch operation {
+	case "status":
+		var status *fly.AppStatus
+		if status, err = t.flyClient.GetAppStatus(ctx, app); err == nil {
+			result.Detail = describeBatchStatus(status)
+		}
+	case "restart":
+		if err = t.flyClient.RestartApp(ctx, app); err == nil {
+			result.Detail = "all machines restarted"
+		}
+	case "secret_set":
+		if err = t.flyClient.SetSecrets(ctx, app, secrets); err == nil {
+			result.Detail = fmt.Sprintf("%d secret(s) set; restart or deploy to apply", len(secrets))
+		}
+	}
+
+	result.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Result = "failed"
+		result.Detail = describeError(err)
+		return result
+	}
+	result.Result = "success"
+	return result
+}
+
+// describeBatchStatus summarizes an app's status in one line
+func describeBatchStatus(status *fly.AppStatus) string {
+	states := make([]string, 0, len(status.MachineStates))
+	for state, count := range status.MachineStates {
+		states = append(states, fmt.Sprintf("%d %s", count, state))
+	}
+	sort.Strings(states)
+
+	detail := fmt.Sprintf("%s, %d machine(s)", status.Status, status.MachineCount)
+	if len(states) > 0 {
+		detail += fmt.Sprintf(" (%s)", strings.Join(states, ", "))
+	}
+	return detail
+}
+
+// formatTextResponse formats the batch results as a matrix
+func (t *BatchTool) formatTextResponse(report *batchReport) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Batch %s: %d app(s)\n\n", report.Operation, len(report.Apps))
+
+	response += "## Summary\n"
+	response += fmt.Sprintf("- ✅ **Succeeded**: %d\n", report.Summary["success"])
+	response += fmt.Sprintf("- ❌ **Failed**: %d\n", report.Summary["failed"])
+	if report.Summary["denied"] > 0 {
+		response += fmt.Sprintf("- 🚫 **Denied by policy**: %d\n", report.Summary["denied"])
+	}
+	if report.Summary["skipped"] > 0 {
+		response += fmt.Sprintf("- ⏹️ **Skipped**: %d\n", report.Summary["skipped"])
+	}
+
+	response += "\n## Results\n"
+	response += "| App | Result | Detail |\n"
+	response += "|-----|--------|--------|\n"
+	for _, r := range report.Apps {
+		icon := "✅"
+		switch r.Result {
+		case "failed":
+			icon = "❌"
+		case "denied":
+			icon = "🚫"
+		case "skipped":
+			icon = "⏹️"
+		}
+		detail := strings.ReplaceAll(strings.ReplaceAll(r.Detail, "\n", " "), "|", "\\|")
+		response += fmt.Sprintf("| %s | %s %s | %s |\n", r.AppName, icon, r.Result, detail)
+	}
+
+	if report.Summary["failed"] > 0 {
+		response += "\n## Next Steps\n"
+		response += "- Use `fly_doctor` on the failed apps to find out why\n"
+		response += "- Retry with `apps` set to just the failed apps\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: report.Summary["success"] == 0 && report.Summary["failed"] > 0,
+	}
+}
@@ -0,0 +1,229 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/flytoml"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// ConfigGenerateTool implements the fly_config_generate MCP tool
+type ConfigGenerateTool struct {
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewConfigGenerateTool creates a new fly.toml generation tool
+func NewConfigGenerateTool(authManager *auth.Manager, logger *logger.Logger) *ConfigGenerateTool {
+	return &ConfigGenerateTool{
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *ConfigGenerateTool) Name() string {
+	return "fly_config_generate"
+}
+
+// Description returns the tool description
+func (t *ConfigGenerateTool) Description() string {
+	return "Generate a fly.toml deployment configuration from a few high-level settings such as app name, region, internal port and health check path"
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *ConfigGenerateTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"primary_region": map[string]interface{}{
+				"type":        "string",
+				"description": "Primary region code (e.g. iad, lhr, syd)",
+			},
+			"internal_port": map[string]interface{}{
+				"type":        "integer",
+				"description": "Port the application listens on inside the machine",
+				"default":     8080,
+			},
+			"image": map[string]interface{}{
+				"type":        "string",
+				"description": "Prebuilt image to deploy (omit to build from the Dockerfile)",
+			},
+			"dockerfile": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the Dockerfile to build",
+			},
+			"health_check_path": map[string]interface{}{
+				"type":        "string",
+				"description": "HTTP path for the health check (omit for a TCP check)",
+			},
+			"env": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Non-secret environment variables",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+			"volume": map[string]interface{}{
+				"type":        "string",
+				"description": "Volume to mount, as source:destination (e.g. data:/data)",
+			},
+			"deploy_strategy": map[string]interface{}{
+				"type":        "string",
+				"description": "Deployment strategy",
+				"enum":        []string{"rolling", "immediate", "canary", "bluegreen"},
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *ConfigGenerateTool) RequiredPermission() (string, string) {
+	return "read", "config"
+}
+
+// Execute executes the config generate tool
+func (t *ConfigGenerateTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "config"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	appCfg := &fly.AppConfig{AppName: appName}
+
+	if region, ok := args["primary_region"].(string); ok {
+		appCfg.PrimaryRegion = region
+	}
+
+	internalPort := 8080
+	if p, ok := args["internal_port"].(float64); ok {
+		internalPort = int(p)
+	}
+
+	image, _ := args["image"].(string)
+	dockerfile, _ := args["dockerfile"].(string)
+	if image != "" || dockerfile != "" {
+		appCfg.Build = &fly.BuildConfig{Image: image, Dockerfile: dockerfile}
+	}
+
+	if env, ok := args["env"].(map[string]interface{}); ok && len(env) > 0 {
+		appCfg.Env = make(map[string]string, len(env))
+		for k, v := range env {
+			appCfg.Env[k] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	if strategy, ok := args["deploy_strategy"].(string); ok && strategy != "" {
+		appCfg.Deploy = &fly.DeployConfig{Strategy: strategy}
+	}
+
+	if volume, ok := args["volume"].(string); ok && volume != "" {
+		source, destination, found := strings.Cut(volume, ":")
+		if !found {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: volume must be in source:destination form, got '%s'", volume),
+				}},
+				IsError: true,
+			}, nil
+		}
+		appCfg.Mounts = []fly.MountConfig{{Source: source, Destination: destination}}
+	}
+
+	check := fly.CheckConfig{Type: "tcp", Interval: "15s", Timeout: "2s"}
+	if path, ok := args["health_check_path"].(string); ok && path != "" {
+		check = fly.CheckConfig{Type: "http", Path: path, Method: "GET", Interval: "15s", Timeout: "5s"}
+	}
+
+	appCfg.Services = []fly.ServiceConfig{{
+		InternalPort: internalPort,
+		Protocol:     "tcp",
+		Ports: []fly.PortConfig{
+			{Port: 80, Handlers: []string{"http"}},
+			{Port: 443, Handlers: []string{"tls", "http"}},
+		},
+		Checks: []fly.CheckConfig{check},
+	}}
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_config_generate").
+		Str("app_name", appName).
+		Msg("Executing config generate tool")
+
+	cfg := flytoml.FromAppConfig(appCfg)
+	content, err := flytoml.Generate(cfg)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to generate fly.toml: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	issues := cfg.Validate()
+	out := NewOutputFormatter(ctx, args)
+
+	var response string
+	response += fmt.Sprintf("# fly.toml for %s\n\n", appName)
+	response += fmt.Sprintf("```toml\n%s```\n", string(content))
+
+	if len(issues) > 0 {
+		response += "\n## Review Before Deploying\n"
+		for _, issue := range issues {
+			response += fmt.Sprintf("- **%s** (%s): %s\n", issue.Field, issue.Severity, issue.Message)
+		}
+	}
+
+	response += "\n## Next Steps\n"
+	response += "- Save this as `fly.toml` in your project root\n"
+	response += "- Use `fly_config_validate` after making further edits\n"
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("fly.toml for '%s'", appName), generatedConfig{
+		AppName: appName,
+		Content: string(content),
+		Issues:  issues,
+	}), nil
+}
+
+// generatedConfig is the structured result of generating a fly.toml
+type generatedConfig struct {
+	AppName string          `json:"appName"`
+	Content string          `json:"content"`
+	Issues  []flytoml.Issue `json:"issues,omitempty"`
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/flytoml"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// ConfigValidateTool implements the fly_config_validate MCP tool
+type ConfigValidateTool struct {
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewConfigValidateTool creates a new fly.toml validation tool
+func NewConfigValidateTool(authManager *auth.Manager, logger *logger.Logger) *ConfigValidateTool {
+	return &ConfigValidateTool{
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *ConfigValidateTool) Name() string {
+	return "fly_config_validate"
+}
+
+// Description returns the tool description
+func (t *ConfigValidateTool) Description() string {
+	return "Parse and validate fly.toml content, reporting syntax errors, invalid values, unknown keys and common deployment mistakes"
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *ConfigValidateTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "Full text of the fly.toml file to validate",
+			},
+		},
+		"required":             []string{"content"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *ConfigValidateTool) RequiredPermission() (string, string) {
+	return "read", "config"
+}
+
+// Execute executes the config validate tool
+func (t *ConfigValidateTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "config"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	content, ok := args["content"].(string)
+	if !ok || content == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: content is required and must be the text of a fly.toml file",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_config_validate").
+		Int("content_length", len(content)).
+		Msg("Executing config validate tool")
+
+	parsed, err := flytoml.Parse([]byte(content))
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **fly.toml could not be parsed**\n\n%v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	issues := parsed.Config.Validate()
+	for _, key := range parsed.UnknownKeys {
+		issues = append(issues, flytoml.Issue{
+			Severity: flytoml.SeverityWarning,
+			Field:    key,
+			Message:  "unknown key; it is not recognised by fly-mcp and may be a typo",
+		})
+	}
+	valid := !flytoml.HasErrors(issues)
+
+	var response string
+	if valid {
+		response += fmt.Sprintf("✅ **fly.toml for '%s' is valid**\n\n", parsed.Config.App)
+	} else {
+		response += fmt.Sprintf("❌ **fly.toml for '%s' has errors**\n\n", parsed.Config.App)
+	}
+
+	if len(issues) == 0 {
+		response += "No issues found.\n"
+	} else {
+		response += "## Issues\n"
+		for _, issue := range issues {
+			icon := "⚠️"
+			if issue.Severity == flytoml.SeverityError {
+				icon = "❌"
+			}
+			response += fmt.Sprintf("- %s **%s**: %s\n", icon, issue.Field, issue.Message)
+		}
+	}
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: !valid,
+	}, fmt.Sprintf("Validation of fly.toml for '%s'", parsed.Config.App), configValidation{
+		Valid:  valid,
+		App:    parsed.Config.App,
+		Issues: issues,
+	}), nil
+}
+
+// configValidation is the structured result of validating a fly.toml
+type configValidation struct {
+	Valid  bool            `json:"valid"`
+	App    string          `json:"app"`
+	Issues []flytoml.Issue `json:"issues"`
+}
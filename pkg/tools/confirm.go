@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// confirmationTokenProperty is the input schema property through which
+// destructive tools receive the token issued by their preview
+func confirmationTokenProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "Token returned by a previous call without it, approving exactly this operation. Omit it to preview the change and get a token.",
+	}
+}
+
+// requestConfirmation previews a destructive operation instead of running
+// it. It issues a token bound to the caller, the tool and scope, and returns
+// the change summary the user should approve before the tool is called again
+// with the token. Because the token only comes from this preview, a caller
+// cannot approve an operation in the same call that performs it.
+func requestConfirmation(ctx context.Context, authManager *auth.Manager, toolName, title, appName string, scope map[string]interface{}, summary string) *interfaces.ToolResult {
+	confirmation, err := authManager.IssueConfirmation(ctx, toolName, scope, summary)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: failed to issue confirmation token: %v", err),
+			}},
+			IsError: true,
+		}
+	}
+
+	notePendingConfirmation(ctx, toolName, appName)
+
+	if authManager.RequiresApproval(toolName, appName) {
+		approval, err := authManager.RequestApproval(ctx, confirmation.Token, toolName, appName, summary)
+		if err != nil {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: failed to request approval: %v", err),
+				}},
+				IsError: true,
+			}
+		}
+		return approvalPending(toolName, title, confirmation.Token, approval)
+	}
+
+	var response string
+
+	response += fmt.Sprintf("⚠️ **%s Confirmation Required**\n\n", title)
+	response += "## Planned Change\n"
+	response += summary + "\n"
+
+	response += "\n## To Proceed\n"
+	response += "Nothing has been changed yet. Show this summary to the user and, once they approve it, call "
+	response += fmt.Sprintf("`%s` again with the same arguments plus:\n", toolName)
+	response += fmt.Sprintf("```json\n{\n  \"confirmation_token\": \"%s\"\n}\n```\n", confirmation.Token)
+	response += fmt.Sprintf("The token works once and expires in %s. Changing any other argument requires a new preview.\n", time.Until(confirmation.ExpiresAt).Round(time.Second))
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		StructuredContent: map[string]interface{}{
+			"confirmation_required": true,
+			"confirmation_token":    confirmation.Token,
+			"expires_at":            confirmation.ExpiresAt,
+			"summary":               summary,
+		},
+	}
+}
+
+// verifyConfirmation spends a confirmation token for the operation about to
+// run. It returns nil when the operation may proceed, or the error result to
+// return otherwise.
+func verifyConfirmation(ctx context.Context, authManager *auth.Manager, toolName, token string, scope map[string]interface{}) *interfaces.ToolResult {
+	err := authManager.ConsumeConfirmation(ctx, token, toolName, scope)
+	if err == nil {
+		return nil
+	}
+
+	var pendingErr *auth.ApprovalPendingError
+	if errors.As(err, &pendingErr) {
+		if approval, ok := authManager.GetApproval(pendingErr.ID); ok {
+			return approvalPending(toolName, "", token, &approval)
+		}
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: fmt.Sprintf("Error: %v. Call `%s` without `confirmation_token` to preview the change and get a new token.", err, toolName),
+		}},
+		IsError: true,
+	}
+}
+
+// approvalPending describes an operation waiting for approval. The caller
+// polls by calling the tool again with the same token, which runs the
+// operation once it is approved.
+func approvalPending(toolName, title, token string, approval *auth.Approval) *interfaces.ToolResult {
+	var response string
+
+	if title != "" {
+		response += fmt.Sprintf("⏳ **%s Approval Required**\n\n", title)
+		response += "## Planned Change\n"
+		response += approval.Summary + "\n\n"
+	} else {
+		response += "⏳ **Still Awaiting Approval**\n\n"
+	}
+
+	response += "## Approval\n"
+	response += fmt.Sprintf("- **Request**: %s\n", approval.ID)
+	response += fmt.Sprintf("- **Requested by**: %s, %s ago\n", approval.RequestedBy, formatAge(time.Since(approval.RequestedAt)))
+	response += fmt.Sprintf("- **Expires in**: %s\n", time.Until(approval.ExpiresAt).Round(time.Second))
+
+	response += "\n## To Proceed\n"
+	response += "Nothing has been changed yet. This operation needs approval by someone other than the caller. "
+	response += fmt.Sprintf("To check on it, call `%s` again with the same arguments plus:\n", toolName)
+	response += fmt.Sprintf("```json\n{\n  \"confirmation_token\": \"%s\"\n}\n```\n", token)
+	response += "Once approved, that call carries out the operation.\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		StructuredContent: map[string]interface{}{
+			"confirmation_required": true,
+			"confirmation_token":    token,
+			"approval_id":           approval.ID,
+			"approval_status":       approval.Status,
+			"expires_at":            approval.ExpiresAt,
+			"summary":               approval.Summary,
+		},
+	}
+}
+
+// IsConfirmationPreview reports whether a result is the preview of a
+// destructive operation rather than its outcome
+func IsConfirmationPreview(result *interfaces.ToolResult) bool {
+	if result == nil {
+		return false
+	}
+	data, ok := result.StructuredContent.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	required, _ := data["confirmation_required"].(bool)
+	return required
+}
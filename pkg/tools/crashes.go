@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/doctor"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// CrashesTool implements the fly_crashes MCP tool
+type CrashesTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewCrashesTool creates a new crash analysis tool
+func NewCrashesTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *CrashesTool {
+	return &CrashesTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *CrashesTool) Name() string {
+	return "fly_crashes"
+}
+
+// Description returns the tool description
+func (t *CrashesTool) Description() string {
+	return "Analyze the machine events of a Fly.io application over a lookback window for crash loops, out of memory kills and restart storms, with a per-machine breakdown of crashes, exit codes and restarts."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *CrashesTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Only analyze this machine",
+			},
+			"range": map[string]interface{}{
+				"type":        "string",
+				"description": "How far back to look, e.g. 1h, 24h, 7d",
+				"default":     "24h",
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *CrashesTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the crash analysis tool
+func (t *CrashesTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	machineID, _ := args["machine_id"].(string)
+
+	rangeArg := "24h"
+	if r, ok := args["range"].(string); ok && r != "" {
+		rangeArg = r
+	}
+	window, err := parseMetricsRange(rangeArg)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_crashes").
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Str("range", rangeArg).
+		Msg("Executing crashes tool")
+
+	analysis, err := doctor.CollectCrashes(ctx, t.flyClient, appName, machineID, window)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "analyze_crashes", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to analyze crashes of app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "analyze_crashes", appName, "success", map[string]interface{}{
+		"crashes":   analysis.Crashes,
+		"oom_kills": analysis.OOMKills,
+	})
+
+	return out.Render(t.formatTextResponse(analysis), fmt.Sprintf("Crashes of application '%s'", appName), analysis, appLinks(appName)...), nil
+}
+
+// formatTextResponse formats the crash analysis as human-readable text
+func (t *CrashesTool) formatTextResponse(analysis *doctor.CrashAnalysis) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Crashes: %s (last %s)\n\n", analysis.AppName, analysis.Window)
+
+	crashed := 0
+	for _, m := range analysis.Machines {
+		if m.Crashes > 0 {
+			crashed++
+		}
+	}
+
+	response += "## Summary\n"
+	response += fmt.Sprintf("- **Machines**: %d, %d with crashes\n", len(analysis.Machines), crashed)
+	response += fmt.Sprintf("- **Crashes**: %d\n", analysis.Crashes)
+	response += fmt.Sprintf("- **OOM kills**: %d\n", analysis.OOMKills)
+
+	for _, pattern := range []struct {
+		name, label string
+	}{
+		{doctor.PatternCrashLoop, "Crash loops"},
+		{doctor.PatternRestartStorm, "Restart storms"},
+		{doctor.PatternOOM, "Out of memory"},
+	} {
+		if affected := analysis.Affected(pattern.name); len(affected) > 0 {
+			ids := make([]string, 0, len(affected))
+			for _, m := range affected {
+				id := m.MachineID
+				if m.Ongoing {
+					id += " (ongoing)"
+				}
+				ids = append(ids, id)
+			}
+			response += fmt.Sprintf("- 🔴 **%s**: %s\n", pattern.label, strings.Join(ids, ", "))
+		}
+	}
+
+	if analysis.Crashes == 0 && len(analysis.Affected(doctor.PatternRestartStorm)) == 0 {
+		response += "\n🟢 **No crashes or restart storms in the window**\n"
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: response,
+			}},
+		}
+	}
+
+	response += "\n## Machines\n"
+	response += "| Machine | Region | State | Crashes | OOM | Starts (peak in 10m) | Last crash | Exits |\n"
+	response += "|---------|--------|-------|---------|-----|----------------------|------------|-------|\n"
+	for _, m := range analysis.Machines {
+		lastCrash := "-"
+		if m.LastCrash != nil {
+			lastCrash = m.LastCrash.UTC().Format("2006-01-02 15:04")
+		}
+		response += fmt.Sprintf("| %s | %s | %s | %d | %d | %d (%d) | %s | %s |\n",
+			m.MachineID, m.Region, m.State, m.Crashes, m.OOMKills, m.Starts, m.PeakStarts, lastCrash, doctor.DescribeExits(m.Exits))
+	}
+
+	response += "\n## Suggested Actions\n"
+	response += "- Use `fly_logs_search` around the last crash to find its cause\n"
+	if analysis.OOMKills > 0 {
+		response += "- Use `fly_metrics` with the memory metric, then `fly_scale` to give machines more memory\n"
+	}
+	response += "- Use `fly_doctor` for the full diagnosis of the app\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
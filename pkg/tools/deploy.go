@@ -0,0 +1,437 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/github"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/journal"
+)
+
+const (
+	// defaultHealthTimeout is how long each machine may take to become
+	// healthy during a deploy
+	defaultHealthTimeout = 120
+	minHealthTimeout     = 10
+	maxHealthTimeout     = 900
+)
+
+// DeployTool implements the fly_deploy MCP tool
+type DeployTool struct {
+	flyClient   *fly.Client
+	journal     *journal.Journal
+	github      *github.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewDeployTool creates a new deploy tool. gh may be nil when the GitHub
+// integration is not configured.
+func NewDeployTool(flyClient *fly.Client, ops *journal.Journal, gh *github.Client, authManager *auth.Manager, logger *logger.Logger) *DeployTool {
+	return &DeployTool{
+		flyClient:   flyClient,
+		journal:     ops,
+		github:      gh,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *DeployTool) Name() string {
+	return "fly_deploy"
+}
+
+// Description returns the tool description
+func (t *DeployTool) Description() string {
+	return "Deploy an already built image to a Fly.io application's machines with a chosen strategy: rolling (one machine at a time, each gated on its health checks), canary (one machine first, rolled back if unhealthy, then rolling), bluegreen (new machines alongside the old, which are destroyed once all new ones are healthy) or immediate (every machine at once, no health gates). Instead of an image, a GitHub repository and ref can be deployed: the commit is built with Fly.io's remote builders through the repository's build workflow, or its prebuilt image is pulled from GitHub Container Registry, and the build is tracked through to the machine rollout. Requires confirmation."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *DeployTool) InputSchema() map[string]interface{} {
+	properties := map[string]interface{}{
+		"app_name": map[string]interface{}{
+			"type":        "string",
+			"description": "Name of the application to deploy",
+		},
+		"image": map[string]interface{}{
+			"type":        "string",
+			"description": "Image to deploy, e.g. registry.fly.io/my-app:deployment-01H...; either image or github_repo is required",
+		},
+		"strategy": map[string]interface{}{
+			"type":        "string",
+			"description": "How to replace the running machines",
+			"enum":        fly.DeployStrategies,
+			"default":     fly.DeployRolling,
+		},
+		"health_timeout_seconds": map[string]interface{}{
+			"type":        "integer",
+			"description": "How long each machine may take to start and pass its health checks before the deploy stops",
+			"default":     defaultHealthTimeout,
+			"minimum":     minHealthTimeout,
+			"maximum":     maxHealthTimeout,
+		},
+		"confirmation_token": confirmationTokenProperty(),
+	}
+	for name, property := range healthGateProperties() {
+		properties[name] = property
+	}
+	for name, property := range githubSourceProperties() {
+		properties[name] = property
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *DeployTool) RequiredPermission() (string, string) {
+	return "deploy", "app"
+}
+
+// Execute executes the deploy tool
+func (t *DeployTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "deploy", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	req := fly.DeployRequest{
+		Strategy:      fly.DeployRolling,
+		HealthTimeout: defaultHealthTimeout * time.Second,
+	}
+	req.Image, _ = args["image"].(string)
+	source, err := t.resolveSource(ctx, appName, args)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Cannot deploy from GitHub: %s", describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+	if source != nil {
+		if req.Image != "" {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: "Error: give either image or github_repo, not both",
+				}},
+				IsError: true,
+			}, nil
+		}
+		req.Image = source.Image
+	}
+	if req.Image == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: image or github_repo is required. Use `fly_images` to see the images the app has run.",
+			}},
+			IsError: true,
+		}, nil
+	}
+	if s, ok := args["strategy"].(string); ok && s != "" {
+		req.Strategy = s
+	}
+	if v, ok := args["health_timeout_seconds"].(float64); ok {
+		req.HealthTimeout = time.Duration(max(minHealthTimeout, min(int(v), maxHealthTimeout))) * time.Second
+	}
+	req.Gate = healthGateFromArgs(args)
+
+	out := NewOutputFormatter(ctx, args)
+
+	scope := map[string]interface{}{
+		"app_name":       appName,
+		"image":          req.Image,
+		"strategy":       req.Strategy,
+		"health_timeout": req.HealthTimeout.Seconds(),
+	}
+	healthGateScope(scope, req.Gate)
+	if source != nil {
+		// The token is bound to the commit, so a branch that moves after
+		// the preview is not deployed unseen
+		scope["github_repo"] = source.Repo
+		scope["sha"] = source.Commit.SHA
+		scope["build"] = source.Build
+	}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		return t.preview(ctx, appName, req, source, scope), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_deploy", token, scope); result != nil {
+		return result, nil
+	}
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_deploy").
+		Str("app_name", appName).
+		Str("image", req.Image).
+		Str("strategy", req.Strategy).
+		Msg("Executing deploy tool")
+
+	if source != nil && source.NeedsBuild {
+		if err := t.build(ctx, appName, source); err != nil {
+			details := map[string]interface{}{
+				"image":       req.Image,
+				"github_repo": source.Repo,
+				"sha":         source.Commit.SHA,
+				"error":       err.Error(),
+			}
+			response := fmt.Sprintf("❌ **Build Failed**\n\nFailed to build %s@%s (`%s`) for app '%s': %s\n", source.Repo, source.Commit.Ref, source.Commit.ShortSHA(), appName, describeError(err))
+			if source.BuildRun != nil && source.BuildRun.Run != nil {
+				details["run_url"] = source.BuildRun.Run.URL
+				response += fmt.Sprintf("\nSee the workflow run for the build logs: %s\n", source.BuildRun.Run.URL)
+			}
+			response += "\nNo machines were changed."
+			t.authManager.AuditLog(ctx, userID, "deploy_app", appName, "failed", details)
+
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: response,
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	result, err := t.flyClient.Deploy(ctx, appName, req)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "deploy_app", appName, "failed", map[string]interface{}{
+			"image":    req.Image,
+			"strategy": req.Strategy,
+			"error":    err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Deploy Failed**\n\nFailed to deploy to app '%s': %s\n\nNo machines were changed.", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	details := map[string]interface{}{
+		"image":    req.Image,
+		"strategy": req.Strategy,
+		"machines": len(result.Machines),
+		"duration": result.Duration,
+		"error":    result.Error,
+	}
+	if result.HealthGate != nil {
+		details["health_gate"] = result.HealthGate.Decision
+		details["health_gate_reason"] = result.HealthGate.Reason
+		details["failure_rate"] = result.HealthGate.FailureRate
+	}
+	if source != nil {
+		details["github_repo"] = source.Repo
+		details["sha"] = source.Commit.SHA
+		details["built"] = source.BuildRun != nil
+	}
+	t.authManager.AuditLog(ctx, userID, "deploy_app", appName, result.Status, details)
+
+	response := t.formatTextResponse(result, source)
+	response.Content[0].Text += recordOperation(t.journal, journal.DeployOperation(result), "fly_deploy", userID)
+	return out.Render(response, fmt.Sprintf("Deploy of application '%s'", appName), deployReport{DeployResult: result, Source: source}, appLinks(appName)...), nil
+}
+
+// preview describes the deploy and issues its confirmation token
+func (t *DeployTool) preview(ctx context.Context, appName string, req fly.DeployRequest, source *deploySource, scope map[string]interface{}) *interfaces.ToolResult {
+	plan, err := t.flyClient.PlanDeploy(ctx, appName, req)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Cannot deploy to app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}
+	}
+
+	// A commit that is still to be built has no image to inspect yet
+	var image *fly.ImageInspection
+	var imageErr error
+	if source == nil || !source.NeedsBuild {
+		image, imageErr = t.flyClient.InspectDeployImage(ctx, appName, req.Image)
+		if imageErr == nil && !image.Exists {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Cannot deploy to app '%s': image `%s` does not exist in its registry. Check the tag; `fly_images` lists the images pushed for the app.", appName, req.Image),
+				}},
+				IsError: true,
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("- **Application**: %s\n", appName)
+	summary += fmt.Sprintf("- **Image**: %s\n", req.Image)
+	switch {
+	case imageErr != nil:
+		summary += fmt.Sprintf("- **Image Check**: could not inspect the image, so it is deployed unchecked: %s\n", describeError(imageErr))
+	case image != nil:
+		summary += formatImageInspection(image)
+	}
+	if source != nil {
+		summary += t.describeSource(source)
+	}
+	summary += fmt.Sprintf("- **Strategy**: %s\n", req.Strategy)
+
+	switch req.Strategy {
+	case fly.DeployImmediate:
+		summary += fmt.Sprintf("- **Plan**: update all %d machine(s) at once without waiting for health checks\n", len(plan.Machines))
+		summary += "- **Impact**: every machine restarts at the same time, so expect downtime and no automatic stop if the image is broken\n"
+	case fly.DeployCanary:
+		summary += fmt.Sprintf("- **Plan**: update one machine and wait up to %s for it to become healthy, rolling it back if it does not, then update the other %d one at a time\n", req.HealthTimeout, len(plan.Machines)-1)
+		summary += "- **Impact**: each machine restarts in turn; the deploy stops at the first unhealthy machine\n"
+	case fly.DeployBlueGreen:
+		summary += fmt.Sprintf("- **Plan**: create %d new machine(s) with the image, wait up to %s for all of them to become healthy, then destroy the %d existing machine(s)\n", len(plan.Machines), req.HealthTimeout, len(plan.Machines))
+		summary += "- **Impact**: no downtime, but the app briefly runs (and is billed for) twice as many machines; if any new machine is unhealthy they are all destroyed and the existing machines keep serving\n"
+	default:
+		summary += fmt.Sprintf("- **Plan**: update %d machine(s) one at a time, waiting up to %s for each to become healthy\n", len(plan.Machines), req.HealthTimeout)
+		summary += "- **Impact**: each machine restarts in turn; the deploy stops at the first unhealthy machine\n"
+	}
+
+	summary += "\n| Machine | Region | State | Current Image |\n"
+	summary += "|---------|--------|-------|---------------|\n"
+	for _, m := range plan.Machines {
+		summary += fmt.Sprintf("| `%s` | %s | %s | %s |\n", m.MachineID, m.Region, m.State, m.Image)
+	}
+	summary += formatLeaseWarning(t.flyClient.MachineLeases(ctx, appName, plan.MachineIDs()))
+	if image != nil && len(image.Warnings) > 0 {
+		summary += "\n⚠️ **Check the image**; it may not be the one you meant to deploy:\n"
+		for _, warning := range image.Warnings {
+			summary += fmt.Sprintf("- %s\n", warning)
+		}
+	}
+	if req.Gate != nil {
+		rollback := "report the failure without rolling back"
+		if req.Gate.Rollback {
+			rollback = "roll every changed machine back to its previous image"
+		}
+		summary += fmt.Sprintf("\n- **Health Gate**: watch the machines for %s after the rollout and, if more than %.0f%% of health samples fail, %s\n", req.Gate.Window, req.Gate.Threshold*100, rollback)
+	}
+	if len(plan.Skipped) > 0 {
+		summary += fmt.Sprintf("\nScheduled tasks keep their image: %s", strings.Join(plan.Skipped, ", "))
+	}
+
+	return requestConfirmation(ctx, t.authManager, "fly_deploy", "Deploy", appName, scope, strings.TrimSuffix(summary, "\n"))
+}
+
+// formatImageInspection describes the image a deploy rolls out, for previews
+func formatImageInspection(image *fly.ImageInspection) string {
+	details := []string{}
+	if len(image.Platforms) > 0 {
+		details = append(details, strings.Join(image.Platforms, ", "))
+	}
+	if image.Size > 0 {
+		details = append(details, formatBytes(image.Size))
+	}
+	if !image.Created.IsZero() {
+		details = append(details, "built "+image.Created.UTC().Format(time.RFC3339))
+	}
+
+	summary := fmt.Sprintf("- **Digest**: `%s`", image.Digest)
+	if len(details) > 0 {
+		summary += fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+	}
+	summary += "\n"
+	if len(image.ExposedPorts) > 0 {
+		summary += fmt.Sprintf("- **Exposes**: %s\n", strings.Join(image.ExposedPorts, ", "))
+	}
+	return summary
+}
+
+// formatTextResponse formats the deploy result as human-readable text
+func (t *DeployTool) formatTextResponse(result *fly.DeployResult, source *deploySource) *interfaces.ToolResult {
+	var response string
+
+	switch result.Status {
+	case fly.DeploySucceeded:
+		response += fmt.Sprintf("✅ **Deployed '%s'**\n\n", result.AppName)
+	case fly.DeployRolledBack:
+		response += fmt.Sprintf("⏪ **Deploy of '%s' Rolled Back**\n\n", result.AppName)
+	default:
+		response += fmt.Sprintf("❌ **Deploy of '%s' Failed**\n\n", result.AppName)
+	}
+
+	response += "## Deploy Summary\n"
+	response += fmt.Sprintf("- **Image**: %s\n", result.Image)
+	response += fmt.Sprintf("- **Strategy**: %s\n", result.Strategy)
+	response += fmt.Sprintf("- **Duration**: %s\n", result.Duration)
+	if result.Error != "" {
+		response += fmt.Sprintf("- **Error**: %s\n", result.Error)
+	}
+
+	response += "\n## Machines\n"
+	response += "| Machine | Region | Role | Result |\n"
+	response += "|---------|--------|------|--------|\n"
+	for _, m := range result.Machines {
+		role := m.Role
+		if role == "" {
+			role = "-"
+		}
+		outcome := m.Action
+		if m.Error != "" {
+			outcome += ": " + truncateOutput(m.Error, 120)
+		}
+		response += fmt.Sprintf("| `%s` | %s | %s | %s |\n", m.MachineID, m.Region, role, outcome)
+	}
+
+	if result.HealthGate != nil {
+		response += formatHealthGate(result.HealthGate)
+	}
+	if source != nil {
+		response += formatSource(source)
+	}
+
+	response += "\n## Next Steps\n"
+	if result.Status == fly.DeploySucceeded {
+		response += "- Use `fly_proxy_check` to confirm the app is reachable\n"
+		response += "- Use `fly_images` to confirm every machine runs the new image\n"
+	} else {
+		response += "- Use `fly_machine_events` on the failed machine to see why it did not become healthy\n"
+		response += "- Use `fly_images` to see which machines run which image; deploy the previous image to roll back\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: result.Status != fly.DeploySucceeded,
+	}
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/github"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// Where a GitHub deploy's image comes from
+const (
+	// BuildRemote builds the commit with Fly.io's remote builders through
+	// the repository's build workflow
+	BuildRemote = "remote"
+	// BuildGHCR deploys an image already pushed to GitHub Container Registry
+	BuildGHCR = "ghcr"
+)
+
+// deploySource is the GitHub commit a deploy is built from
+type deploySource struct {
+	Repo   string         `json:"repo"`
+	Build  string         `json:"build"`
+	Commit *github.Commit `json:"commit"`
+	Image  string         `json:"image"`
+	// NeedsBuild is set when the image has not been built yet
+	NeedsBuild bool `json:"needsBuild"`
+	// ImageLabel is the tag a remote build pushes the image under
+	ImageLabel string        `json:"imageLabel,omitempty"`
+	BuildRun   *github.Build `json:"buildRun,omitempty"`
+}
+
+// githubSourceProperties returns the schema properties that deploy a
+// GitHub repository instead of an image
+func githubSourceProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"github_repo": map[string]interface{}{
+			"type":        "string",
+			"description": "Deploy this GitHub repository (owner/name) instead of an image; needs integrations.github in the server config",
+		},
+		"github_ref": map[string]interface{}{
+			"type":        "string",
+			"description": "Branch, tag or commit SHA of github_repo to deploy; defaults to the default branch",
+		},
+		"build": map[string]interface{}{
+			"type":        "string",
+			"description": "For github_repo: 'remote' builds the commit with Fly.io's remote builders through the repository's build workflow (skipped if that commit was built before); 'ghcr' deploys the commit's image from GitHub Container Registry",
+			"enum":        []string{BuildRemote, BuildGHCR},
+			"default":     BuildRemote,
+		},
+		"image_tag": map[string]interface{}{
+			"type":        "string",
+			"description": "For build 'ghcr': the image tag to deploy; defaults to sha-<short commit SHA>",
+		},
+	}
+}
+
+// resolveSource resolves the GitHub repository and ref a deploy names to a
+// commit and the image built from it. It returns nil when the deploy names
+// an image instead.
+func (t *DeployTool) resolveSource(ctx context.Context, appName string, args map[string]interface{}) (*deploySource, error) {
+	repo, _ := args["github_repo"].(string)
+	if repo == "" {
+		return nil, nil
+	}
+	if !t.github.Configured() {
+		return nil, github.ErrNotConfigured
+	}
+	if err := t.github.CheckRepository(repo); err != nil {
+		return nil, err
+	}
+
+	source := &deploySource{Repo: repo, Build: BuildRemote}
+	if b, ok := args["build"].(string); ok && b != "" {
+		source.Build = b
+	}
+	if source.Build != BuildRemote && source.Build != BuildGHCR {
+		return nil, fmt.Errorf("unknown build %q: use %s or %s", source.Build, BuildRemote, BuildGHCR)
+	}
+
+	ref, _ := args["github_ref"].(string)
+	commit, err := t.github.ResolveRef(ctx, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	source.Commit = commit
+
+	if source.Build == BuildGHCR {
+		tag, _ := args["image_tag"].(string)
+		if tag == "" {
+			tag = "sha-" + commit.ShortSHA()
+		}
+		source.Image = fmt.Sprintf("ghcr.io/%s:%s", strings.ToLower(repo), tag)
+		return source, nil
+	}
+
+	// A commit is built once; deploying it again reuses the image
+	source.ImageLabel = "gh-" + commit.ShortSHA()
+	image, _, err := t.flyClient.AppImage(ctx, appName, source.ImageLabel)
+	if err != nil {
+		return nil, err
+	}
+	if image == "" {
+		image = t.flyClient.AppImageRef(appName, source.ImageLabel)
+		source.NeedsBuild = true
+	}
+	source.Image = image
+	return source, nil
+}
+
+// build runs the remote build of a deploy's commit, reporting its progress
+func (t *DeployTool) build(ctx context.Context, appName string, source *deploySource) error {
+	interfaces.KeepAlive(ctx, t.github.BuildTimeout())
+
+	build, err := t.github.Build(ctx, github.BuildRequest{
+		Commit:     source.Commit,
+		AppName:    appName,
+		ImageLabel: source.ImageLabel,
+	}, func(status string) {
+		interfaces.ReportProgress(ctx, 0, 0, fmt.Sprintf("build %s: %s", source.Commit.ShortSHA(), strings.ReplaceAll(status, "_", " ")))
+	})
+	source.BuildRun = build
+	if err != nil {
+		return err
+	}
+
+	// The build is only useful if it pushed the image the deploy expects
+	image, _, err := t.flyClient.AppImage(ctx, appName, source.ImageLabel)
+	if err != nil {
+		return err
+	}
+	if image == "" {
+		return fmt.Errorf("the build succeeded but did not push %s; the workflow must push the app's image with the image_label input as its tag", source.Image)
+	}
+	return nil
+}
+
+// describeSource summarizes where a deploy's image comes from, for previews
+func (t *DeployTool) describeSource(source *deploySource) string {
+	commit := source.Commit
+	summary := fmt.Sprintf("- **Source**: %s@%s → `%s` \"%s\" by %s\n", source.Repo, commit.Ref, commit.ShortSHA(), commit.Subject(), commit.Author)
+	switch {
+	case source.Build == BuildGHCR:
+		summary += "- **Build**: none; the image is pulled from GitHub Container Registry, which Fly.io must be able to read\n"
+	case source.NeedsBuild:
+		summary += fmt.Sprintf("- **Build**: run the `%s` workflow to build the commit with Fly.io's remote builders and push `%s`, waiting up to %s, then deploy it\n", t.github.Workflow(), source.Image, t.github.BuildTimeout())
+	default:
+		summary += "- **Build**: none; this commit was built before\n"
+	}
+	return summary
+}
+
+// formatSource describes where a deploy's image came from, for results
+func formatSource(source *deploySource) string {
+	commit := source.Commit
+	response := "\n## Source\n"
+	response += fmt.Sprintf("- **Repository**: %s@%s\n", source.Repo, commit.Ref)
+	response += fmt.Sprintf("- **Commit**: [`%s`](%s) %s\n", commit.ShortSHA(), commit.URL, commit.Subject())
+	if build := source.BuildRun; build != nil && build.Run != nil {
+		outcome := build.Run.Status
+		if build.Run.Conclusion != "" {
+			outcome = build.Run.Conclusion
+		}
+		response += fmt.Sprintf("- **Build**: [%s](%s) in %s\n", outcome, build.Run.URL, build.Duration)
+	}
+	return response
+}
+
+// deployReport is the structured result of a deploy
+type deployReport struct {
+	*fly.DeployResult
+	Source *deploySource `json:"source,omitempty"`
+}
@@ -0,0 +1,343 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// DiffAppsTool implements the fly_diff_apps MCP tool
+type DiffAppsTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewDiffAppsTool creates a new app comparison tool
+func NewDiffAppsTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *DiffAppsTool {
+	return &DiffAppsTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *DiffAppsTool) Name() string {
+	return "fly_diff_apps"
+}
+
+// Description returns the tool description
+func (t *DiffAppsTool) Description() string {
+	return "Compare two Fly.io applications, e.g. staging and production: machine sizes, regions, environment variable names, secrets (names, and whether their values match by digest) and images, highlighting every difference. Secret and environment values are never shown."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *DiffAppsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the first application, e.g. staging",
+			},
+			"other_app": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to compare it with, e.g. production",
+			},
+		},
+		"required":             []string{"app_name", "other_app"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *DiffAppsTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// diffSection is the comparison of one aspect of two apps
+type diffSection struct {
+	Name        string   `json:"name"`
+	Identical   bool     `json:"identical"`
+	Differences []string `json:"differences,omitempty"`
+}
+
+// appDiff is the comparison of two apps
+type appDiff struct {
+	Apps     [2]string          `json:"apps"`
+	Sections []diffSection      `json:"sections"`
+	Profiles [2]*fly.AppProfile `json:"profiles"`
+}
+
+// Execute executes the app comparison tool
+func (t *DiffAppsTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	otherApp, ok := args["other_app"].(string)
+	if !ok || otherApp == "" || otherApp == appName {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: other_app is required and must name a different application",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_diff_apps").
+		Str("app_name", appName).
+		Str("other_app", otherApp).
+		Msg("Executing diff apps tool")
+
+	// Policies are evaluated for app_name by the handler; the other app
+	// must be readable too
+	if err := t.authManager.EvaluatePolicy(ctx, "read", otherApp); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Policy denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	var profiles [2]*fly.AppProfile
+	for i, name := range []string{appName, otherApp} {
+		profile, err := t.flyClient.GetAppProfile(ctx, name)
+		if err != nil {
+			t.authManager.AuditLog(ctx, userID, "diff_apps", appName, "failed", map[string]interface{}{
+				"other_app": otherApp,
+				"error":     err.Error(),
+			})
+
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Failed to inspect app '%s': %s", name, describeError(err)),
+				}},
+				IsError: true,
+			}, nil
+		}
+		profiles[i] = profile
+	}
+
+	diff := compareApps(profiles[0], profiles[1])
+
+	differing := 0
+	for _, s := range diff.Sections {
+		if !s.Identical {
+			differing++
+		}
+	}
+	t.authManager.AuditLog(ctx, userID, "diff_apps", appName, "success", map[string]interface{}{
+		"other_app":          otherApp,
+		"differing_sections": differing,
+	})
+
+	return out.Render(t.formatTextResponse(diff), fmt.Sprintf("Comparison of '%s' and '%s'", appName, otherApp), diff, append(appLinks(appName), appLinks(otherApp)...)...), nil
+}
+
+// compareApps compares two app profiles section by section
+func compareApps(a, b *fly.AppProfile) *appDiff {
+	diff := &appDiff{
+		Apps:     [2]string{a.AppName, b.AppName},
+		Profiles: [2]*fly.AppProfile{a, b},
+	}
+
+	machines := diffSection{Name: "Machines", Identical: a.Machines == b.Machines}
+	if !machines.Identical {
+		machines.Differences = append(machines.Differences, fmt.Sprintf("%d machine(s) in %s, %d in %s", a.Machines, a.AppName, b.Machines, b.AppName))
+	}
+
+	diff.Sections = []diffSection{
+		machines,
+		diffCounts("Regions", a.AppName, b.AppName, a.Regions, b.Regions),
+		diffCounts("Machine Sizes", a.AppName, b.AppName, a.Sizes, b.Sizes),
+		diffNames("Environment Variables", a.AppName, b.AppName, a.EnvVars, b.EnvVars),
+		diffSecrets(a, b),
+		diffImages(a, b),
+	}
+	return diff
+}
+
+// diffCounts compares per-key machine counts, such as machines per region
+func diffCounts(name, appA, appB string, a, b map[string]int) diffSection {
+	section := diffSection{Name: name, Identical: true}
+	for _, key := range unionKeys(a, b) {
+		if a[key] != b[key] {
+			section.Identical = false
+			section.Differences = append(section.Differences, fmt.Sprintf("%s: %d machine(s) in %s, %d in %s", key, a[key], appA, b[key], appB))
+		}
+	}
+	return section
+}
+
+// diffNames compares two sets of names
+func diffNames(name, appA, appB string, a, b []string) diffSection {
+	section := diffSection{Name: name, Identical: true}
+
+	inA := make(map[string]bool, len(a))
+	for _, n := range a {
+		inA[n] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, n := range b {
+		inB[n] = true
+	}
+
+	for _, n := range a {
+		if !inB[n] {
+			section.Identical = false
+			section.Differences = append(section.Differences, fmt.Sprintf("%s: only in %s", n, appA))
+		}
+	}
+	for _, n := range b {
+		if !inA[n] {
+			section.Identical = false
+			section.Differences = append(section.Differences, fmt.Sprintf("%s: only in %s", n, appB))
+		}
+	}
+	return section
+}
+
+// diffSecrets compares secret names and, for names both apps have, whether
+// the values are the same
+func diffSecrets(a, b *fly.AppProfile) diffSection {
+	namesA := make([]string, 0, len(a.Secrets))
+	digestsA := make(map[string]string, len(a.Secrets))
+	for _, s := range a.Secrets {
+		namesA = append(namesA, s.Name)
+		digestsA[s.Name] = s.Digest
+	}
+	namesB := make([]string, 0, len(b.Secrets))
+	for _, s := range b.Secrets {
+		namesB = append(namesB, s.Name)
+	}
+
+	section := diffNames("Secrets", a.AppName, b.AppName, namesA, namesB)
+	for _, s := range b.Secrets {
+		if digest, ok := digestsA[s.Name]; ok && digest != s.Digest {
+			section.Identical = false
+			section.Differences = append(section.Differences, fmt.Sprintf("%s: different values", s.Name))
+		}
+	}
+	return section
+}
+
+// diffImages compares the images the apps run. Apps deploy to their own
+// repositories, so images are compared by digest.
+func diffImages(a, b *fly.AppProfile) diffSection {
+	section := diffSection{Name: "Images", Identical: true}
+	for _, image := range unionKeys(a.Images, b.Images) {
+		if a.Images[image] > 0 && b.Images[image] > 0 {
+			continue
+		}
+		section.Identical = false
+		owner := a.AppName
+		if a.Images[image] == 0 {
+			owner = b.AppName
+		}
+		section.Differences = append(section.Differences, fmt.Sprintf("%s: only in %s", shortDigest(image), owner))
+	}
+	return section
+}
+
+// unionKeys returns the keys of both maps, sorted
+func unionKeys(a, b map[string]int) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for _, m := range []map[string]int{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatTextResponse formats the comparison as human-readable text
+func (t *DiffAppsTool) formatTextResponse(diff *appDiff) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# App Comparison: %s vs %s\n\n", diff.Apps[0], diff.Apps[1])
+
+	response += "## Summary\n"
+	differing := 0
+	for _, s := range diff.Sections {
+		icon := "🟢 identical"
+		if !s.Identical {
+			icon = "🟠 different"
+			differing++
+		}
+		response += fmt.Sprintf("- **%s**: %s\n", s.Name, icon)
+	}
+
+	if differing == 0 {
+		response += "\n🟢 **No differences found**\n"
+	}
+
+	for _, s := range diff.Sections {
+		if s.Identical {
+			continue
+		}
+		response += fmt.Sprintf("\n## %s\n", s.Name)
+		for _, d := range s.Differences {
+			response += fmt.Sprintf("- %s\n", d)
+		}
+	}
+
+	var warnings []string
+	for _, p := range diff.Profiles {
+		for _, w := range p.Warnings {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", p.AppName, w))
+		}
+	}
+	if len(warnings) > 0 {
+		response += "\n## Warnings\n"
+		for _, w := range warnings {
+			response += fmt.Sprintf("- ⚠️ %s\n", w)
+		}
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// DNSTool implements the fly_dns MCP tool
+type DNSTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewDNSTool creates a new DNS verification tool
+func NewDNSTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *DNSTool {
+	return &DNSTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *DNSTool) Name() string {
+	return "fly_dns"
+}
+
+// Description returns the tool description
+func (t *DNSTool) Description() string {
+	return "Verify the DNS of a Fly.io application: resolves its fly.dev hostname and every custom domain it has a certificate for, checks that the A/AAAA/CNAME records point at the app's IP addresses, and lists the exact records to create for any domain that is misconfigured, including pending certificate validation records."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *DNSTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"domains": map[string]interface{}{
+				"type":        "array",
+				"description": "Additional domains to check, e.g. ones that do not have a certificate yet",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+				"maxItems": 20,
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *DNSTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the DNS verification tool
+func (t *DNSTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	var domains []string
+	if raw, ok := args["domains"].([]interface{}); ok {
+		for _, d := range raw {
+			if domain, ok := d.(string); ok && domain != "" {
+				domains = append(domains, domain)
+			}
+		}
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_dns").
+		Str("app_name", appName).
+		Int("domains", len(domains)).
+		Msg("Executing DNS tool")
+
+	report, err := t.flyClient.CheckAppDNS(ctx, appName, domains)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "check_dns", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to check DNS for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	misconfigured := 0
+	for _, d := range report.Domains {
+		if d.Status != fly.DomainOK {
+			misconfigured++
+		}
+	}
+	t.authManager.AuditLog(ctx, userID, "check_dns", appName, "success", map[string]interface{}{
+		"domains":       len(report.Domains),
+		"misconfigured": misconfigured,
+	})
+
+	return out.Render(t.formatTextResponse(report, misconfigured), fmt.Sprintf("DNS for application '%s'", appName), report, appLinks(appName)...), nil
+}
+
+// formatTextResponse formats the DNS report as human-readable text
+func (t *DNSTool) formatTextResponse(report *fly.DNSReport, misconfigured int) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# DNS: %s\n\n", report.AppName)
+
+	response += "## App Addresses\n"
+	ipv4 := "none"
+	if len(report.IPv4) > 0 {
+		ipv4 = strings.Join(report.IPv4, ", ")
+		if report.Shared {
+			ipv4 += " (shared)"
+		}
+	}
+	ipv6 := "none"
+	if len(report.IPv6) > 0 {
+		ipv6 = strings.Join(report.IPv6, ", ")
+	}
+	response += fmt.Sprintf("- **Hostname**: %s\n", report.Hostname)
+	response += fmt.Sprintf("- **IPv4**: %s\n", ipv4)
+	response += fmt.Sprintf("- **IPv6**: %s\n", ipv6)
+
+	if misconfigured == 0 {
+		response += "\n🟢 **Every hostname points at the app**\n"
+	} else {
+		response += fmt.Sprintf("\n🟠 **%d of %d hostname(s) need attention**\n", misconfigured, len(report.Domains))
+	}
+
+	response += "\n## Hostnames\n"
+	response += "| Hostname | Resolves To | Certificate | Status |\n"
+	response += "|----------|-------------|-------------|--------|\n"
+	for _, d := range report.Domains {
+		resolves := strings.Join(append(append([]string{}, d.IPv4...), d.IPv6...), ", ")
+		if d.CNAME != "" {
+			resolves = "CNAME " + d.CNAME
+		}
+		if resolves == "" {
+			resolves = "-"
+		}
+		cert := d.CertificateStatus
+		if cert == "" {
+			cert = "-"
+		}
+
+		status := "🟢 ok"
+		switch d.Status {
+		case fly.DomainMisconfigured:
+			status = "🟠 misconfigured"
+		case fly.DomainUnresolved:
+			status = "🔴 unresolved"
+		}
+		response += fmt.Sprintf("| %s | %s | %s | %s |\n", d.Hostname, resolves, cert, status)
+	}
+
+	for _, d := range report.Domains {
+		if d.Status == fly.DomainOK {
+			continue
+		}
+		response += fmt.Sprintf("\n## %s\n", d.Hostname)
+		for _, p := range d.Problems {
+			response += fmt.Sprintf("- %s\n", p)
+		}
+		if len(d.Records) > 0 {
+			response += "\nCreate these records at your DNS provider:\n"
+			response += "| Type | Name | Value |\n"
+			response += "|------|------|-------|\n"
+			for _, r := range d.Records {
+				response += fmt.Sprintf("| %s | %s | %s |\n", r.Type, r.Name, r.Value)
+			}
+		}
+	}
+
+	if len(report.Warnings) > 0 {
+		response += "\n## Warnings\n"
+		for _, w := range report.Warnings {
+			response += fmt.Sprintf("- ⚠️ %s\n", w)
+		}
+	}
+
+	if misconfigured > 0 {
+		response += "\nDNS changes can take a while to propagate; run `fly_dns` again after updating the records.\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
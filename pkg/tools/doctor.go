@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/doctor"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// DoctorTool implements the fly_doctor MCP tool
+type DoctorTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewDoctorTool creates a new diagnostic tool
+func NewDoctorTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *DoctorTool {
+	return &DoctorTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *DoctorTool) Name() string {
+	return "fly_doctor"
+}
+
+// Description returns the tool description
+func (t *DoctorTool) Description() string {
+	return "Diagnose a Fly.io application in one call: combines machine status, health checks, machine events, recent logs and release history into a findings report covering crash loops, OOM kills, failing checks, image mismatches, regions without a healthy machine and failed releases, each with a recommended next step."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *DoctorTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to diagnose",
+			},
+			"range": map[string]interface{}{
+				"type":        "string",
+				"description": "How far back to look for crashes and OOM kills, e.g. 1h, 24h, 7d",
+				"default":     "24h",
+			},
+			summarizeArg: summarizeProperty(),
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *DoctorTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the diagnostic tool
+func (t *DoctorTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	rangeArg := "24h"
+	if r, ok := args["range"].(string); ok && r != "" {
+		rangeArg = r
+	}
+	window, err := parseMetricsRange(rangeArg)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_doctor").
+		Str("app_name", appName).
+		Str("range", rangeArg).
+		Msg("Executing doctor tool")
+
+	report, err := doctor.Diagnose(ctx, t.flyClient, appName, window)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "diagnose_app", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to diagnose app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "diagnose_app", appName, "success", map[string]interface{}{
+		"critical": report.Count(doctor.SeverityCritical),
+		"warning":  report.Count(doctor.SeverityWarning),
+		"info":     report.Count(doctor.SeverityInfo),
+	})
+
+	result := out.Render(t.formatTextResponse(report), fmt.Sprintf("Diagnosis for application '%s'", appName), report, appLinks(appName)...)
+	return summarizeResult(ctx, args, "fly_doctor", fmt.Sprintf("Summarize this diagnosis of the Fly.io application %s into the findings that matter most and what to do first.", appName), result), nil
+}
+
+// formatTextResponse formats the findings report as human-readable text
+func (t *DoctorTool) formatTextResponse(report *doctor.Report) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Diagnosis: %s\n\n", report.AppName)
+
+	response += "## Summary\n"
+	response += fmt.Sprintf("- **Status**: %s\n", report.Status)
+	response += fmt.Sprintf("- **Machines**: %d\n", report.Machines)
+	response += fmt.Sprintf("- **Window**: last %s\n", report.Window)
+	response += fmt.Sprintf("- **Findings**: %d critical, %d warning, %d info\n",
+		report.Count(doctor.SeverityCritical), report.Count(doctor.SeverityWarning), report.Count(doctor.SeverityInfo))
+
+	if report.Healthy() {
+		response += "\n🟢 **No problems found**\n"
+	}
+
+	for _, f := range report.Findings {
+		icon := "🔵"
+		switch f.Severity {
+		case doctor.SeverityCritical:
+			icon = "🔴"
+		case doctor.SeverityWarning:
+			icon = "🟠"
+		}
+
+		response += fmt.Sprintf("\n## %s %s\n", icon, f.Title)
+		if f.Detail != "" {
+			response += f.Detail + "\n"
+		}
+		if len(f.Machines) > 0 {
+			response += fmt.Sprintf("- **Machines**: %s\n", strings.Join(f.Machines, ", "))
+		}
+		if f.Recommendation != "" {
+			response += fmt.Sprintf("- **Next step**: %s\n", f.Recommendation)
+		}
+	}
+
+	if len(report.Unavailable) > 0 {
+		response += "\n## Not Checked\n"
+		sources := make([]string, 0, len(report.Unavailable))
+		for source := range report.Unavailable {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		for _, source := range sources {
+			response += fmt.Sprintf("- ⚠️ **%s**: %s\n", source, report.Unavailable[source])
+		}
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
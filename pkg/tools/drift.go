@@ -0,0 +1,315 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/flytoml"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// DriftTool implements the fly_drift MCP tool
+type DriftTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewDriftTool creates a new drift detection tool
+func NewDriftTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *DriftTool {
+	return &DriftTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *DriftTool) Name() string {
+	return "fly_drift"
+}
+
+// Description returns the tool description
+func (t *DriftTool) Description() string {
+	return "Compare the live configuration of a Fly.io application's machines with the desired state kept in version control or IaC, a fly.toml and/or a machine config spec, and report every field that drifted, e.g. to check whether anyone hand-edited production. Env values are compared by digest and never shown."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *DriftTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to check",
+			},
+			"fly_toml": map[string]interface{}{
+				"type":        "string",
+				"description": "Full text of the fly.toml the app should be deployed with; its env, services, checks, mounts, metrics, [[vm]] size, kill_signal and process commands are compared",
+			},
+			"machine_spec": map[string]interface{}{
+				"type":        "object",
+				"description": "Machine config, in the Machines API's shape, every machine should have, e.g. {\"guest\": {\"memory_mb\": 1024}, \"restart\": {\"policy\": \"always\"}}; it is laid over the fly.toml when both are given",
+			},
+			"process_group": map[string]interface{}{
+				"type":        "string",
+				"description": "Only check machines of this process group",
+			},
+			"ignore": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Fields expected to differ, e.g. env.DEPLOYED_AT or services[*].concurrency; * matches any part of a name but a dot, and the fields below a listed one are ignored too",
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *DriftTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// driftReport is the structured result of a drift check
+type driftReport struct {
+	*fly.DriftReport
+	// Against names the desired state compared with
+	Against []string `json:"against"`
+}
+
+// Execute executes the drift detection tool
+func (t *DriftTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	desired, against, warnings, err := desiredState(args)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	opts := fly.DriftOptions{}
+	opts.ProcessGroup, _ = args["process_group"].(string)
+	if raw, ok := args["ignore"].([]interface{}); ok {
+		for _, item := range raw {
+			if pattern, ok := item.(string); ok && pattern != "" {
+				opts.Ignore = append(opts.Ignore, pattern)
+			}
+		}
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_drift").
+		Str("app_name", appName).
+		Strs("against", against).
+		Msg("Executing drift tool")
+
+	report, err := t.flyClient.DetectDrift(ctx, appName, desired, opts)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "drift", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to check drift of app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+	report.Warnings = append(warnings, report.Warnings...)
+
+	t.authManager.AuditLog(ctx, userID, "drift", appName, "success", map[string]interface{}{
+		"against":        against,
+		"machines":       len(report.Machines),
+		"drifted_fields": len(report.Drift),
+	})
+
+	result := driftReport{DriftReport: report, Against: against}
+	return out.Render(t.formatTextResponse(result), fmt.Sprintf("Drift of '%s'", appName), result, appLinks(appName)...), nil
+}
+
+// desiredState builds the desired machine config of each process group
+// from the fly.toml and machine spec of a call
+func desiredState(args map[string]interface{}) (map[string]map[string]interface{}, []string, []string, error) {
+	content, _ := args["fly_toml"].(string)
+
+	var spec map[string]interface{}
+	switch raw := args["machine_spec"].(type) {
+	case nil:
+	case map[string]interface{}:
+		spec = raw
+	case string:
+		if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+			return nil, nil, nil, fmt.Errorf("machine_spec is not a JSON object: %v", err)
+		}
+	default:
+		return nil, nil, nil, fmt.Errorf("machine_spec must be an object")
+	}
+
+	if content == "" && spec == nil {
+		return nil, nil, nil, fmt.Errorf("give the desired state as fly_toml, machine_spec or both")
+	}
+
+	desired := make(map[string]map[string]interface{})
+	var against, warnings []string
+	if content != "" {
+		parsed, err := flytoml.Parse([]byte(content))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("fly.toml could not be parsed: %v", err)
+		}
+		for _, key := range parsed.UnknownKeys {
+			warnings = append(warnings, fmt.Sprintf("fly.toml key %s is not recognised and was not compared", key))
+		}
+		if app := parsed.Config.App; app != "" && app != args["app_name"] {
+			warnings = append(warnings, fmt.Sprintf("The fly.toml is for app '%s'", app))
+		}
+		for _, group := range parsed.Config.ProcessGroups() {
+			desired[group] = parsed.Config.MachineConfig(group)
+		}
+		against = append(against, "fly.toml")
+	}
+
+	if spec != nil {
+		if len(desired) == 0 {
+			desired[""] = spec
+		}
+		for group, machineConfig := range desired {
+			desired[group] = overlay(machineConfig, spec)
+		}
+		against = append(against, "machine spec")
+	}
+	return desired, against, warnings, nil
+}
+
+// overlay returns base with the fields of top laid over it; nested objects
+// are merged and other values replaced
+func overlay(base, top map[string]interface{}) map[string]interface{} {
+	merged := maps.Clone(base)
+	for key, value := range top {
+		if child, ok := value.(map[string]interface{}); ok {
+			if under, ok := merged[key].(map[string]interface{}); ok {
+				merged[key] = overlay(under, child)
+				continue
+			}
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// formatDriftValue renders a desired or live value for a table cell
+func formatDriftValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "_not set_"
+	case string:
+		return fmt.Sprintf("`%s`", v)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return fmt.Sprintf("`%s`", raw)
+}
+
+// formatTextResponse formats the drift report as human-readable text
+func (t *DriftTool) formatTextResponse(result driftReport) *interfaces.ToolResult {
+	report := result.DriftReport
+	var response string
+
+	response += fmt.Sprintf("# Drift Report: %s\n\n", report.AppName)
+
+	response += "## Summary\n"
+	response += fmt.Sprintf("- **Compared**: %d machine(s) against the %s\n", len(report.Machines), strings.Join(result.Against, " and "))
+	response += fmt.Sprintf("- **Fields Checked**: %d per machine\n", report.Fields)
+	for _, id := range slices.Sorted(maps.Keys(report.Skipped)) {
+		response += fmt.Sprintf("- **Skipped**: `%s` (%s)\n", id, report.Skipped[id])
+	}
+
+	if len(report.Drift) == 0 {
+		response += "\n🟢 **No drift**: the machines match the desired state\n"
+	} else {
+		response += fmt.Sprintf("\n🟠 **%d field(s) drifted** from the desired state\n", len(report.Drift))
+
+		response += "\n## Drift\n"
+		response += "| Field | Desired | Live | Machines |\n"
+		response += "|-------|---------|------|----------|\n"
+		for _, d := range report.Drift {
+			for _, live := range d.Live {
+				machines := strings.Join(codeList(live.Machines), ", ")
+				if len(live.Machines) == len(report.Machines) && len(report.Machines) > 1 {
+					machines = fmt.Sprintf("all %d", len(live.Machines))
+				}
+				desiredValue, liveValue := formatDriftValue(d.Desired), formatDriftValue(live.Value)
+				// Whole entries, such as a service, are present or not
+				if d.Desired == "present" {
+					desiredValue = "present"
+				}
+				if live.Value == "present" {
+					liveValue = "present"
+				}
+				response += fmt.Sprintf("| `%s` | %s | %s | %s |\n", d.Field, desiredValue, liveValue, machines)
+			}
+		}
+	}
+
+	if len(report.Warnings) > 0 {
+		response += "\n## Warnings\n"
+		for _, w := range report.Warnings {
+			response += fmt.Sprintf("- ⚠️ %s\n", w)
+		}
+	}
+
+	if len(report.Drift) > 0 {
+		response += "\n## Next Steps\n"
+		response += "- Redeploy from the desired fly.toml (`flyctl deploy`) to undo hand edits, or update the desired state if a change should stay\n"
+		response += "- Use `fly_machine_events` to see when the drifted machines were last updated\n"
+		response += "- Pass fields that are expected to vary, e.g. `env.DEPLOYED_AT`, in `ignore`\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// EncodeJSON writes v to w as JSON, indenting nested values by indent when
+// it is not empty. The objects and lists of result data are written one
+// value at a time instead of being encoded as a whole first, so a large
+// result is never held in memory twice; other values are encoded as
+// encoding/json does. w should be buffered.
+func EncodeJSON(w io.Writer, v interface{}, indent string) error {
+	e := &jsonEncoder{w: w, indent: indent}
+	e.encode(v, 0)
+	return e.err
+}
+
+// jsonEncoder writes values to w, keeping the first write error
+type jsonEncoder struct {
+	w      io.Writer
+	indent string
+	err    error
+}
+
+// write writes s unless an earlier write failed
+func (e *jsonEncoder) write(s string) {
+	if e.err == nil {
+		_, e.err = io.WriteString(e.w, s)
+	}
+}
+
+// newline starts a line indented to depth
+func (e *jsonEncoder) newline(depth int) {
+	if e.indent != "" {
+		e.write("\n" + strings.Repeat(e.indent, depth))
+	}
+}
+
+// encode writes v, nested depth levels deep
+func (e *jsonEncoder) encode(v interface{}, depth int) {
+	if e.err != nil {
+		return
+	}
+
+	switch v := v.(type) {
+	case object:
+		if len(v) == 0 {
+			e.write("{}")
+			return
+		}
+		e.write("{")
+		for i, f := range v {
+			if i > 0 {
+				e.write(",")
+			}
+			e.newline(depth + 1)
+			e.encodeValue(f.name, depth+1)
+			e.write(":")
+			if e.indent != "" {
+				e.write(" ")
+			}
+			e.encode(f.value, depth+1)
+		}
+		e.newline(depth)
+		e.write("}")
+	case []interface{}:
+		if v == nil {
+			e.write("null")
+			return
+		}
+		if len(v) == 0 {
+			e.write("[]")
+			return
+		}
+		e.write("[")
+		for i, item := range v {
+			if i > 0 {
+				e.write(",")
+			}
+			e.newline(depth + 1)
+			e.encode(item, depth+1)
+		}
+		e.newline(depth)
+		e.write("]")
+	default:
+		e.encodeValue(v, depth)
+	}
+}
+
+// encodeValue writes a value with encoding/json
+func (e *jsonEncoder) encodeValue(v interface{}, depth int) {
+	var data []byte
+	var err error
+	if e.indent != "" {
+		data, err = json.MarshalIndent(v, strings.Repeat(e.indent, depth), e.indent)
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		if e.err == nil {
+			e.err = err
+		}
+		return
+	}
+	e.write(string(data))
+}
@@ -0,0 +1,393 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/journal"
+)
+
+// EnvTool implements the fly_env MCP tool
+type EnvTool struct {
+	flyClient   *fly.Client
+	journal     *journal.Journal
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewEnvTool creates a new environment variables tool
+func NewEnvTool(flyClient *fly.Client, ops *journal.Journal, authManager *auth.Manager, logger *logger.Logger) *EnvTool {
+	return &EnvTool{
+		flyClient:   flyClient,
+		journal:     ops,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *EnvTool) Name() string {
+	return "fly_env"
+}
+
+// Description returns the tool description
+func (t *EnvTool) Description() string {
+	return "View and change the non-secret environment variables of a Fly.io application's machines. 'list' shows the variables and flags ones that differ between machines; 'set' and 'unset' preview a diff of the change, then apply it with a rolling update that waits for each machine to become healthy. Use secrets for sensitive values."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *EnvTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "Action to perform",
+				"enum":        []string{"list", "set", "unset"},
+				"default":     "list",
+			},
+			"env": map[string]interface{}{
+				"type":        "object",
+				"description": "Variables to set, as name-value pairs (set only)",
+				"additionalProperties": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"names": map[string]interface{}{
+				"type":        "array",
+				"description": "Names of the variables to remove (unset only)",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"health_timeout_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "How long each machine may take to restart and pass its health checks before the update stops",
+				"default":     defaultHealthTimeout,
+				"minimum":     minHealthTimeout,
+				"maximum":     maxHealthTimeout,
+			},
+			"confirmation_token": confirmationTokenProperty(),
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *EnvTool) RequiredPermission() (string, string) {
+	return "set", "env"
+}
+
+// ReadOnlyCall reports whether a call only lists variables
+func (t *EnvTool) ReadOnlyCall(args map[string]interface{}) bool {
+	action, _ := args["action"].(string)
+	return action != "set" && action != "unset"
+}
+
+// Execute executes the environment variables tool
+func (t *EnvTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	action := "list"
+	if a, ok := args["action"].(string); ok {
+		action = a
+	}
+
+	// Listing only needs read access; set and unset restart machines
+	permAction, permResource := "read", "app"
+	if !t.ReadOnlyCall(args) {
+		permAction, permResource = t.RequiredPermission()
+	}
+	if err := t.authManager.ValidateRequest(ctx, permAction, permResource); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_env").
+		Str("app_name", appName).
+		Str("action", action).
+		Msg("Executing env tool")
+
+	switch action {
+	case "list":
+		return t.list(ctx, userID, appName, out)
+	case "set", "unset":
+		return t.update(ctx, userID, appName, action, args, out)
+	default:
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Unknown action: %s. Use 'list', 'set' or 'unset'", action),
+			}},
+			IsError: true,
+		}, nil
+	}
+}
+
+// list reports the app's environment variables
+func (t *EnvTool) list(ctx context.Context, userID, appName string, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	env, err := t.flyClient.GetAppEnv(ctx, appName)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "list_env", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to list environment variables for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "list_env", appName, "success", map[string]interface{}{
+		"var_count": len(env.Vars),
+	})
+
+	var response string
+
+	response += fmt.Sprintf("# Environment: %s\n\n", appName)
+
+	if len(env.Vars) == 0 {
+		response += fmt.Sprintf("None of the app's %d machine(s) set environment variables in their config.\n", env.Machines)
+	} else {
+		response += "| Name | Value | Machines |\n"
+		response += "|------|-------|----------|\n"
+		inconsistent := 0
+		for _, v := range env.Vars {
+			machines := fmt.Sprintf("%d/%d", v.Machines, env.Machines)
+			if v.Inconsistent {
+				machines = "⚠️ " + machines
+				inconsistent++
+			}
+			response += fmt.Sprintf("| %s | `%s` | %s |\n", v.Name, truncateOutput(v.Value, 80), machines)
+		}
+		if inconsistent > 0 {
+			response += fmt.Sprintf("\n⚠️ %d variable(s) are missing from some machines or have different values; use `action: set` to make them consistent.\n", inconsistent)
+		}
+	}
+
+	if len(env.ShadowedBySecrets) > 0 {
+		response += fmt.Sprintf("\nSecrets with the same name override these variables: %s\n", strings.Join(env.ShadowedBySecrets, ", "))
+	}
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("Environment variables for application '%s'", appName), env, appLinks(appName)...), nil
+}
+
+// update sets or removes variables after confirmation
+func (t *EnvTool) update(ctx context.Context, userID, appName, action string, args map[string]interface{}, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	update := fly.EnvUpdate{}
+	if action == "set" {
+		raw, _ := args["env"].(map[string]interface{})
+		update.Set = make(map[string]string, len(raw))
+		for name, value := range raw {
+			s, ok := value.(string)
+			if !ok {
+				return &interfaces.ToolResult{
+					Content: []interfaces.ContentBlock{{
+						Type: "text",
+						Text: fmt.Sprintf("Error: the value of %s must be a string", name),
+					}},
+					IsError: true,
+				}, nil
+			}
+			update.Set[name] = s
+		}
+	} else if raw, ok := args["names"].([]interface{}); ok {
+		for _, n := range raw {
+			if name, ok := n.(string); ok && name != "" {
+				update.Unset = append(update.Unset, name)
+			}
+		}
+		sort.Strings(update.Unset)
+	}
+
+	if len(update.Set) == 0 && len(update.Unset) == 0 {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: set requires env with at least one variable, and unset requires names",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	healthTimeout := defaultHealthTimeout * time.Second
+	if v, ok := args["health_timeout_seconds"].(float64); ok {
+		healthTimeout = time.Duration(max(minHealthTimeout, min(int(v), maxHealthTimeout))) * time.Second
+	}
+
+	// The scope binds the token to the exact variables and values previewed
+	scope := map[string]interface{}{
+		"app_name":       appName,
+		"action":         action,
+		"set":            update.Set,
+		"unset":          update.Unset,
+		"health_timeout": healthTimeout.Seconds(),
+	}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		return t.preview(ctx, appName, update, scope), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_env", token, scope); result != nil {
+		return result, nil
+	}
+
+	result, err := t.flyClient.UpdateAppEnv(ctx, appName, update, healthTimeout)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "update_env", appName, "failed", map[string]interface{}{
+			"action": action,
+			"error":  err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Update Failed**\n\nFailed to update environment variables for app '%s': %s\n\nNo machines were changed.", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	names := make([]string, 0, len(result.Changes))
+	for _, c := range result.Changes {
+		names = append(names, c.Name)
+	}
+	t.authManager.AuditLog(ctx, userID, "update_env", appName, result.Status, map[string]interface{}{
+		"action":   action,
+		"names":    names,
+		"machines": len(result.Machines),
+		"error":    result.Error,
+	})
+
+	var response string
+
+	switch {
+	case len(result.Changes) == 0:
+		response += "ℹ️ **Nothing to Change**\n\nEvery machine already has these values, so no machines were restarted.\n"
+	case result.Status == fly.DeploySucceeded:
+		response += fmt.Sprintf("✅ **Environment Updated**\n\n%d variable(s) changed on %d machine(s).\n", len(result.Changes), len(result.Machines))
+	default:
+		response += fmt.Sprintf("❌ **Update Stopped**\n\n%s\n", result.Error)
+	}
+
+	if len(result.Changes) > 0 {
+		response += "\n## Changes\n"
+		response += formatEnvDiff(result.Changes)
+
+		response += "\n## Machines\n"
+		response += "| Machine | Region | Result |\n"
+		response += "|---------|--------|--------|\n"
+		for _, m := range result.Machines {
+			outcome := m.Action
+			if m.Error != "" {
+				outcome += ": " + truncateOutput(m.Error, 120)
+			}
+			response += fmt.Sprintf("| `%s` | %s | %s |\n", m.MachineID, m.Region, outcome)
+		}
+	}
+
+	if result.Status != fly.DeploySucceeded {
+		response += "\nMachines after the failed one still have the old values. Fix the problem and run the same update again; machines that already have the new values are updated without changes.\n"
+	}
+	response += recordOperation(t.journal, journal.EnvOperation(result), "fly_env", userID)
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: result.Status != fly.DeploySucceeded,
+	}, fmt.Sprintf("Environment update for application '%s'", appName), result, appLinks(appName)...), nil
+}
+
+// preview shows the diff of an update and issues its confirmation token
+func (t *EnvTool) preview(ctx context.Context, appName string, update fly.EnvUpdate, scope map[string]interface{}) *interfaces.ToolResult {
+	plan, err := t.flyClient.PlanEnvUpdate(ctx, appName, update)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Cannot update environment variables for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}
+	}
+
+	if len(plan.Changes) == 0 {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("ℹ️ **Nothing to Change**\n\nEvery machine of app '%s' already has these values.", appName),
+			}},
+		}
+	}
+
+	summary := fmt.Sprintf("- **Application**: %s\n", appName)
+	summary += fmt.Sprintf("- **Machines**: %d, updated one at a time; each running machine restarts and must pass its health checks before the next\n", plan.Machines)
+	summary += "\n" + formatEnvDiff(plan.Changes)
+	if len(plan.ShadowedBySecrets) > 0 {
+		summary += fmt.Sprintf("\n⚠️ Secrets named %s override these variables, so the new values will not take effect until the secrets are removed.\n", strings.Join(plan.ShadowedBySecrets, ", "))
+	}
+
+	return requestConfirmation(ctx, t.authManager, "fly_env", "Environment Update", appName, scope, strings.TrimSuffix(summary, "\n"))
+}
+
+// formatEnvDiff formats variable changes as a diff table
+func formatEnvDiff(changes []fly.EnvDiffEntry) string {
+	var response string
+
+	response += "| Variable | Change | Old Value | New Value |\n"
+	response += "|----------|--------|-----------|-----------|\n"
+	for _, c := range changes {
+		oldValue, newValue := "-", "-"
+		if c.OldValue != "" {
+			oldValue = "`" + truncateOutput(c.OldValue, 60) + "`"
+		}
+		if c.NewValue != "" {
+			newValue = "`" + truncateOutput(c.NewValue, 60) + "`"
+		}
+		icon := map[string]string{fly.EnvAdded: "➕", fly.EnvChanged: "✏️", fly.EnvRemoved: "➖"}[c.Change]
+		response += fmt.Sprintf("| %s | %s %s | %s | %s |\n", c.Name, icon, c.Change, oldValue, newValue)
+	}
+
+	return response
+}
@@ -0,0 +1,22 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+// describeError renders an error for tool output. Fly.io API failures get a
+// short explanation of what went wrong and how to fix it.
+func describeError(err error) string {
+	flyErr, ok := fly.AsFlyError(err)
+	if !ok {
+		return err.Error()
+	}
+
+	message := err.Error()
+	if hint := flyErr.Hint(); hint != "" {
+		message += fmt.Sprintf("\n\n💡 %s", hint)
+	}
+	return message
+}
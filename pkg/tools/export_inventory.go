@@ -0,0 +1,252 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// inventoryURI prefixes the URIs of exported inventory files
+const inventoryURI = "fly://org/inventory"
+
+// ExportInventoryTool implements the fly_export_inventory MCP tool
+type ExportInventoryTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewExportInventoryTool creates a new inventory export tool
+func NewExportInventoryTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *ExportInventoryTool {
+	return &ExportInventoryTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *ExportInventoryTool) Name() string {
+	return "fly_export_inventory"
+}
+
+// Description returns the tool description
+func (t *ExportInventoryTool) Description() string {
+	return "Export a full snapshot of the organization's apps with their machines, volumes, IP addresses and certificates, for audits and capacity planning. The snapshot is attached as a JSON document or as CSV files (apps, machines, volumes, IP addresses, certificates), next to a summary of the totals."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *ExportInventoryTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"export_format": map[string]interface{}{
+				"type":        "string",
+				"description": "Format of the attached export: one JSON document or one CSV file per resource type",
+				"enum":        []string{"json", "csv"},
+				"default":     "json",
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *ExportInventoryTool) RequiredPermission() (string, string) {
+	return "read", "apps"
+}
+
+// Execute executes the inventory export tool
+func (t *ExportInventoryTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "apps"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	exportFormat := "json"
+	if f, ok := args["export_format"].(string); ok && f != "" {
+		exportFormat = f
+	}
+	if exportFormat != "json" && exportFormat != "csv" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: unknown export_format %q; use json or csv", exportFormat),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_export_inventory").
+		Str("export_format", exportFormat).
+		Msg("Executing export inventory tool")
+
+	// Apps that a policy hides from the caller are left out of the export
+	inventory, err := t.flyClient.GetInventory(ctx, func(name string) bool {
+		return t.authManager.EvaluatePolicy(ctx, "read", name) == nil
+	})
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "export_inventory", "apps", "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to collect inventory from Fly.io: %s", describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	var files []interfaces.ContentBlock
+	if exportFormat == "csv" {
+		files, err = inventoryCSV(inventory)
+	} else {
+		files, err = inventoryJSON(inventory)
+	}
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error formatting export: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "export_inventory", "apps", "success", map[string]interface{}{
+		"export_format": exportFormat,
+		"apps":          inventory.Totals.Apps,
+		"machines":      inventory.Totals.Machines,
+	})
+
+	return out.Render(t.formatTextResponse(inventory, files), fmt.Sprintf("Inventory of %d applications", inventory.Totals.Apps), inventory, files...), nil
+}
+
+// inventoryJSON exports an inventory as one JSON document, with the field
+// names of the structured content
+func inventoryJSON(inventory *fly.Inventory) ([]interfaces.ContentBlock, error) {
+	data, err := json.MarshalIndent(normalize(reflect.ValueOf(inventory)), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode inventory: %w", err)
+	}
+	return []interfaces.ContentBlock{
+		interfaces.EmbeddedResource(inventoryURI+".json", "application/json", string(data)),
+	}, nil
+}
+
+// inventoryCSV exports an inventory as one CSV file per resource type. Each
+// row starts with the app it belongs to.
+func inventoryCSV(inventory *fly.Inventory) ([]interfaces.ContentBlock, error) {
+	apps := [][]string{{"app", "status", "deployed", "hostname", "machines", "volumes", "ip_addresses", "certificates"}}
+	machines := [][]string{{"app", "id", "name", "state", "region", "cpu_kind", "cpus", "memory_mb", "image", "private_ip", "created_at"}}
+	volumes := [][]string{{"app", "id", "name", "state", "region", "size_gb", "encrypted", "attached_machine_id", "created_at"}}
+	ips := [][]string{{"app", "address", "type", "region"}}
+	certs := [][]string{{"app", "hostname", "status", "apex"}}
+
+	for _, app := range inventory.Apps {
+		apps = append(apps, []string{
+			app.Name, app.Status, strconv.FormatBool(app.Deployed), app.Hostname,
+			strconv.Itoa(len(app.Machines)), strconv.Itoa(len(app.Volumes)),
+			strconv.Itoa(len(app.IPAddresses)), strconv.Itoa(len(app.Certificates)),
+		})
+		for _, m := range app.Machines {
+			machines = append(machines, []string{
+				app.Name, m.ID, m.Name, m.State, m.Region, m.CPUKind,
+				strconv.Itoa(m.CPUs), strconv.Itoa(m.MemoryMB), m.Image, m.PrivateIP,
+				m.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		for _, v := range app.Volumes {
+			volumes = append(volumes, []string{
+				app.Name, v.ID, v.Name, v.State, v.Region, strconv.Itoa(v.SizeGB),
+				strconv.FormatBool(v.Encrypted), v.AttachedMachineID, v.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		for _, ip := range app.IPAddresses {
+			ips = append(ips, []string{app.Name, ip.Address, ip.Type, ip.Region})
+		}
+		for _, c := range app.Certificates {
+			certs = append(certs, []string{app.Name, c.Hostname, c.ClientStatus, strconv.FormatBool(c.IsApex)})
+		}
+	}
+
+	var files []interfaces.ContentBlock
+	for _, table := range []struct {
+		name string
+		rows [][]string
+	}{
+		{"apps", apps},
+		{"machines", machines},
+		{"volumes", volumes},
+		{"ip_addresses", ips},
+		{"certificates", certs},
+	} {
+		var buf bytes.Buffer
+		if err := csv.NewWriter(&buf).WriteAll(table.rows); err != nil {
+			return nil, fmt.Errorf("failed to write %s.csv: %w", table.name, err)
+		}
+		files = append(files, interfaces.EmbeddedResource(fmt.Sprintf("%s/%s.csv", inventoryURI, table.name), "text/csv", buf.String()))
+	}
+	return files, nil
+}
+
+// formatTextResponse summarizes the inventory and the attached files
+func (t *ExportInventoryTool) formatTextResponse(inventory *fly.Inventory, files []interfaces.ContentBlock) *interfaces.ToolResult {
+	var response string
+
+	response += "# Infrastructure Inventory\n\n"
+	if inventory.Organization != "" {
+		response += fmt.Sprintf("- **Organization**: %s\n", inventory.Organization)
+	}
+	response += fmt.Sprintf("- **Generated**: %s\n", inventory.GeneratedAt.Format("2006-01-02 15:04:05 UTC"))
+
+	totals := inventory.Totals
+	response += "\n## Totals\n"
+	response += fmt.Sprintf("- **Applications**: %d\n", totals.Apps)
+	response += fmt.Sprintf("- **Machines**: %d\n", totals.Machines)
+	response += fmt.Sprintf("- **Volumes**: %d (%d GB)\n", totals.Volumes, totals.VolumeGB)
+	response += fmt.Sprintf("- **IP Addresses**: %d\n", totals.IPAddresses)
+	response += fmt.Sprintf("- **Certificates**: %d\n", totals.Certificates)
+
+	response += "\n## Attached Files\n"
+	for _, f := range files {
+		response += fmt.Sprintf("- `%s` (%s, %.1f KB)\n", f.Resource.URI, f.Resource.MimeType, float64(len(f.Resource.Text))/1024)
+	}
+
+	if len(inventory.Warnings) > 0 {
+		response += "\n## Warnings\n"
+		for _, w := range inventory.Warnings {
+			response += fmt.Sprintf("- ⚠️ %s\n", w)
+		}
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// FleetStatusTool implements the fly_fleet_status MCP tool
+type FleetStatusTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewFleetStatusTool creates a new fleet status tool
+func NewFleetStatusTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *FleetStatusTool {
+	return &FleetStatusTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *FleetStatusTool) Name() string {
+	return "fly_fleet_status"
+}
+
+// Description returns the tool description
+func (t *FleetStatusTool) Description() string {
+	return "Check the status of all applications at once and list the unhealthy ones first: apps with failed machines or critical health checks, apps whose machines cannot be read, apps with warning checks, then stopped, empty and suspended apps. Use it to answer which apps need attention in one call instead of calling fly_status per app."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *FleetStatusTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Glob pattern selecting apps by name, e.g. 'payments-*'; all apps by default",
+			},
+			"problems_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Leave healthy apps out of the list",
+				"default":     false,
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *FleetStatusTool) RequiredPermission() (string, string) {
+	return "read", "apps"
+}
+
+// Execute executes the fleet status tool
+func (t *FleetStatusTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "apps"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	pattern, _ := args["pattern"].(string)
+	if pattern != "" {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: invalid pattern '%s': %v", pattern, err),
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+	problemsOnly, _ := args["problems_only"].(bool)
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_fleet_status").
+		Str("pattern", pattern).
+		Bool("problems_only", problemsOnly).
+		Msg("Executing fleet status tool")
+
+	// Apps that a policy hides from the caller are left out
+	fleet, err := t.flyClient.GetFleetStatus(ctx, func(name string) bool {
+		if pattern != "" {
+			if matched, _ := path.Match(pattern, name); !matched {
+				return false
+			}
+		}
+		return t.authManager.EvaluatePolicy(ctx, "read", name) == nil
+	})
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "fleet_status", "apps", "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to get fleet status from Fly.io: %s", describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "fleet_status", "apps", "success", map[string]interface{}{
+		"pattern":   pattern,
+		"apps":      len(fleet.Apps),
+		"unhealthy": fleet.Counts[fly.FleetUnhealthy],
+	})
+
+	total := len(fleet.Apps)
+	if problemsOnly {
+		apps := []fly.FleetApp{}
+		for _, app := range fleet.Apps {
+			if app.Health != fly.FleetHealthy {
+				apps = append(apps, app)
+			}
+		}
+		fleet.Apps = apps
+	}
+	return out.Render(t.formatTextResponse(fleet, pattern, total), fmt.Sprintf("Status of %d applications", total), fleet), nil
+}
+
+// formatTextResponse formats the fleet status as human-readable text. total
+// counts the apps checked, healthy ones included when they are left out of
+// the list.
+func (t *FleetStatusTool) formatTextResponse(fleet *fly.FleetStatus, pattern string, total int) *interfaces.ToolResult {
+	var response string
+
+	response += "# Fleet Status\n\n"
+	if fleet.Organization != "" {
+		response += fmt.Sprintf("- **Organization**: %s\n", fleet.Organization)
+	}
+	if pattern != "" {
+		response += fmt.Sprintf("- **Apps Matching**: `%s`\n", pattern)
+	}
+	response += fmt.Sprintf("- **Checked**: %s\n", fleet.GeneratedAt.Format("2006-01-02 15:04:05 UTC"))
+
+	response += "\n## Summary\n"
+	response += fmt.Sprintf("- **Applications**: %d\n", total)
+	for _, health := range []string{fly.FleetUnhealthy, fly.FleetUnknown, fly.FleetDegraded, fly.FleetStopped, fly.FleetEmpty, fly.FleetSuspended, fly.FleetHealthy} {
+		if n := fleet.Counts[health]; n > 0 {
+			response += fmt.Sprintf("- %s **%s**: %d\n", fleetIcon(health), health, n)
+		}
+	}
+
+	var attention, healthy []fly.FleetApp
+	for _, app := range fleet.Apps {
+		if app.Health == fly.FleetHealthy {
+			healthy = append(healthy, app)
+		} else {
+			attention = append(attention, app)
+		}
+	}
+
+	switch {
+	case total == 0:
+		response += "\nNo applications found.\n"
+	case len(fleet.Apps) == 0:
+		response += "\n🟢 **Every application is healthy**\n"
+	}
+
+	if len(attention) > 0 {
+		response += "\n## Needs Attention\n"
+		response += "| App | Health | Machines | Regions | Problems |\n"
+		response += "|-----|--------|----------|---------|----------|\n"
+		for _, app := range attention {
+			machines := "-"
+			if app.Health != fly.FleetSuspended && app.Health != fly.FleetUnknown {
+				machines = fmt.Sprintf("%d/%d started", app.Started, app.Machines)
+			}
+			regions := "-"
+			if len(app.Regions) > 0 {
+				regions = strings.Join(app.Regions, ", ")
+			}
+			problems := "-"
+			if len(app.Problems) > 0 {
+				problems = strings.Join(app.Problems, "; ")
+			}
+			response += fmt.Sprintf("| %s | %s %s | %s | %s | %s |\n", app.Name, fleetIcon(app.Health), app.Health, machines, regions, problems)
+		}
+	}
+
+	if len(healthy) > 0 {
+		names := make([]string, 0, len(healthy))
+		for _, app := range healthy {
+			names = append(names, fmt.Sprintf("%s (%d/%d)", app.Name, app.Started, app.Machines))
+		}
+		response += "\n## Healthy\n"
+		response += strings.Join(names, ", ") + "\n"
+	}
+
+	if fleet.Counts[fly.FleetUnhealthy]+fleet.Counts[fly.FleetDegraded] > 0 {
+		response += "\n## Suggested Actions\n"
+		response += "- Use `fly_doctor` on the unhealthy apps to diagnose them\n"
+		response += "- Use `fly_checks` to see why health checks fail\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// fleetIcon marks an app's health
+func fleetIcon(health string) string {
+	switch health {
+	case fly.FleetUnhealthy:
+		return "🔴"
+	case fly.FleetUnknown:
+		return "❓"
+	case fly.FleetDegraded:
+		return "🟡"
+	case fly.FleetStopped, fly.FleetSuspended:
+		return "⚪"
+	case fly.FleetEmpty:
+		return "⚫"
+	}
+	return "🟢"
+}
@@ -0,0 +1,456 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// Output formats a call can select with the format argument
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatTable = "table"
+)
+
+// OutputFormats lists the supported output formats
+var OutputFormats = []string{FormatText, FormatJSON, FormatTable}
+
+// maxCellWidth truncates long values in table output
+const maxCellWidth = 48
+
+// maxInlineJSON is the size of json output above which it is attached as an
+// embedded resource instead of a code fence in the text
+const maxInlineJSON = 16 * 1024
+
+// resultDataURI identifies json output attached as an embedded resource
+const resultDataURI = "fly://results/data.json"
+
+// outputFormatKey carries the output format of calls that do not select one
+type outputFormatKey struct{}
+
+// WithOutputFormat makes tools called with ctx use format unless the call
+// selects another
+func WithOutputFormat(ctx context.Context, format string) context.Context {
+	return context.WithValue(ctx, outputFormatKey{}, format)
+}
+
+// ValidOutputFormat reports whether format is a supported output format
+func ValidOutputFormat(format string) bool {
+	return slices.Contains(OutputFormats, format)
+}
+
+// OutputFormatter renders tool results in the format a call selects. Every
+// format is built from the same result data, so json and table output use
+// the same field names as structured content: camelCase for the fields of
+// result types, while map keys such as variable names are kept as they are.
+type OutputFormatter struct {
+	Format string
+}
+
+// NewOutputFormatter returns the formatter for a call: the format argument,
+// else the format set with WithOutputFormat, else text
+func NewOutputFormatter(ctx context.Context, args map[string]interface{}) *OutputFormatter {
+	format, _ := args["format"].(string)
+	if !ValidOutputFormat(format) {
+		format, _ = ctx.Value(outputFormatKey{}).(string)
+	}
+	if !ValidOutputFormat(format) {
+		format = FormatText
+	}
+	return &OutputFormatter{Format: format}
+}
+
+// Render returns a tool's result in the selected format. result carries the
+// text output and whether the call failed; data is the result data, which
+// is attached as structured content and rendered under title for the json
+// and table formats. links are appended to successful results.
+func (f *OutputFormatter) Render(result *interfaces.ToolResult, title string, data interface{}, links ...interfaces.ContentBlock) *interfaces.ToolResult {
+	tree := normalize(reflect.ValueOf(data))
+
+	var text string
+	var attached []interfaces.ContentBlock
+	switch f.Format {
+	case FormatJSON:
+		var encoded strings.Builder
+		if err := EncodeJSON(&encoded, tree, "  "); err != nil {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error formatting JSON response: %v", err),
+				}},
+				IsError: true,
+			}
+		}
+		// Large data goes in a resource of its own rather than a giant
+		// string of markdown
+		if encoded.Len() > maxInlineJSON {
+			text = fmt.Sprintf("%s: %.1f KB of JSON, attached as %s.", title, float64(encoded.Len())/1024, resultDataURI)
+			attached = append(attached, interfaces.EmbeddedResource(resultDataURI, "application/json", encoded.String()))
+		} else {
+			text = fmt.Sprintf("%s:\n\n```json\n%s\n```", title, encoded.String())
+		}
+	case FormatTable:
+		text = fmt.Sprintf("%s:\n\n```\n%s```", title, renderTable(tree))
+	}
+	if text != "" {
+		result.Content = append([]interfaces.ContentBlock{{
+			Type: "text",
+			Text: text,
+		}}, attached...)
+	}
+
+	return withStructuredContent(result, tree, links...)
+}
+
+// object is a JSON object that keeps its fields in order
+type object []field
+
+// field is a named value of an object
+type field struct {
+	name  string
+	value interface{}
+}
+
+// MarshalJSON implements json.Marshaler
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// normalize converts result data into objects, lists and scalars the way
+// encoding/json would encode it, but keeping struct fields in declaration
+// order and naming them in camelCase
+func normalize(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil
+	}
+
+	// Types with an encoding of their own, e.g. time.Time, keep it
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return normalize(v.Elem())
+	case reflect.Struct:
+		return normalizeStruct(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		out := make(object, 0, len(keys))
+		for _, key := range keys {
+			out = append(out, field{name: fmt.Sprint(key.Interface()), value: normalize(v.MapIndex(key))})
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = normalize(v.Index(i))
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// normalizeStruct converts a struct, honouring json tags and inlining
+// embedded structs as encoding/json does
+func normalizeStruct(v reflect.Value) object {
+	var out object
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		value := v.Field(i)
+
+		if sf.Anonymous && name == "" {
+			embedded := value
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				out = append(out, normalizeStruct(embedded)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if strings.Contains(options, "omitempty") && isEmptyValue(value) {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		out = append(out, field{name: camelCase(name), value: normalize(value)})
+	}
+	return out
+}
+
+// isEmptyValue reports whether omitempty drops a value
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// camelCase names a field in camelCase, e.g. machine_count as
+// machineCount and PrivateIP as privateIP
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		runes := []rune(part)
+		if i == 0 {
+			// Lower the leading capitals, keeping the last of a run that
+			// starts the next word (URLPath becomes urlPath)
+			n := 0
+			for n < len(runes) && unicode.IsUpper(runes[n]) {
+				n++
+			}
+			if n > 1 && n < len(runes) {
+				n--
+			}
+			for j := 0; j < n; j++ {
+				runes[j] = unicode.ToLower(runes[j])
+			}
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		parts[i] = string(runes)
+	}
+	return strings.Join(parts, "")
+}
+
+// renderTable renders normalized data compactly. Lists of objects become
+// aligned columns; other fields become "name: value" lines, with nested
+// objects flattened to dotted names.
+func renderTable(tree interface{}) string {
+	if list, ok := tree.([]interface{}); ok {
+		return renderRows(list)
+	}
+	obj, ok := tree.(object)
+	if !ok {
+		return cellValue(tree) + "\n"
+	}
+
+	var lines []field
+	var tables []field
+	var collect func(prefix string, obj object)
+	collect = func(prefix string, obj object) {
+		for _, f := range obj {
+			name := prefix + f.name
+			switch value := f.value.(type) {
+			case object:
+				collect(name+".", value)
+			case []interface{}:
+				if isRows(value) {
+					tables = append(tables, field{name: name, value: value})
+				} else {
+					lines = append(lines, field{name: name, value: value})
+				}
+			default:
+				lines = append(lines, field{name: name, value: value})
+			}
+		}
+	}
+	collect("", obj)
+
+	var buf bytes.Buffer
+	if len(lines) > 0 {
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		for _, line := range lines {
+			fmt.Fprintf(w, "%s:\t%s\n", line.name, cellValue(line.value))
+		}
+		w.Flush()
+	}
+	for _, table := range tables {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "%s:\n", table.name)
+		buf.WriteString(renderRows(table.value.([]interface{})))
+	}
+	return buf.String()
+}
+
+// isRows reports whether a list holds objects, to be rendered as rows
+func isRows(list []interface{}) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if _, ok := item.(object); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// renderRows renders a list as a table with a column for every field the
+// items have. Nested objects are flattened into dotted columns.
+func renderRows(list []interface{}) string {
+	if !isRows(list) {
+		return cellValue(list) + "\n"
+	}
+
+	var columns []string
+	rows := make([]map[string]interface{}, len(list))
+	for i, item := range list {
+		rows[i] = make(map[string]interface{})
+		var flatten func(prefix string, obj object)
+		flatten = func(prefix string, obj object) {
+			for _, f := range obj {
+				name := prefix + f.name
+				if nested, ok := f.value.(object); ok {
+					flatten(name+".", nested)
+					continue
+				}
+				if !slices.Contains(columns, name) {
+					columns = append(columns, name)
+				}
+				rows[i][name] = f.value
+			}
+		}
+		flatten("", item.(object))
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for j, column := range columns {
+			cells[j] = cellValue(row[column])
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// cellValue renders a value on one line. Lists of objects are summarised
+// by their length.
+func cellValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		s = v
+	case bool:
+		s = strconv.FormatBool(v)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case time.Time:
+		if v.IsZero() {
+			return "-"
+		}
+		s = v.Format(time.RFC3339)
+	case object:
+		parts := make([]string, len(v))
+		for i, f := range v {
+			parts[i] = f.name + "=" + cellValue(f.value)
+		}
+		s = strings.Join(parts, " ")
+	case []interface{}:
+		if isRows(v) {
+			s = fmt.Sprintf("%d items", len(v))
+			break
+		}
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = cellValue(item)
+		}
+		s = strings.Join(parts, ", ")
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = strings.Trim(string(encoded), `"`)
+		}
+	}
+
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return "-"
+	}
+	if runes := []rune(s); len(runes) > maxCellWidth {
+		s = string(runes[:maxCellWidth-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,365 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// maxCheckHistory is the number of status transitions kept per check
+const maxCheckHistory = 20
+
+// serviceCheckPattern matches the names Fly generates for service checks,
+// e.g. servicecheck-00-http-8080
+var serviceCheckPattern = regexp.MustCompile(`^servicecheck-\d+-([a-z]+)-(\d+)$`)
+
+// HealthChecksTool implements the fly_checks MCP tool
+type HealthChecksTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+	history     *checkHistory
+}
+
+// NewHealthChecksTool creates a new health checks tool
+func NewHealthChecksTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *HealthChecksTool {
+	return &HealthChecksTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+		history:     newCheckHistory(),
+	}
+}
+
+// Name returns the tool name
+func (t *HealthChecksTool) Name() string {
+	return "fly_checks"
+}
+
+// Description returns the tool description
+func (t *HealthChecksTool) Description() string {
+	return "List health checks for each machine of a Fly.io application with status, last output and recent failure history, plus a summary of failing services and ports"
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *HealthChecksTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to inspect",
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show checks for this machine",
+			},
+			"failing_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only show checks that are not passing",
+				"default":     false,
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// healthReport is the structured result of the health checks tool
+type healthReport struct {
+	AppName          string          `json:"appName"`
+	TotalChecks      int             `json:"totalChecks"`
+	FailingChecks    int             `json:"failingChecks"`
+	FailingByService map[string]int  `json:"failingByService"`
+	Machines         []machineChecks `json:"machines"`
+}
+
+// machineChecks groups the checks of one machine for reporting
+type machineChecks struct {
+	MachineID string             `json:"machineId"`
+	Name      string             `json:"name"`
+	Region    string             `json:"region"`
+	State     string             `json:"state"`
+	Checks    []checkWithHistory `json:"checks"`
+}
+
+// checkWithHistory is a check result plus its recorded transitions
+type checkWithHistory struct {
+	fly.MachineCheck
+	Service  string             `json:"service,omitempty"`
+	Port     string             `json:"port,omitempty"`
+	Failures []checkObservation `json:"recentFailures,omitempty"`
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *HealthChecksTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the health checks tool
+func (t *HealthChecksTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	machineFilter, _ := args["machine_id"].(string)
+	failingOnly, _ := args["failing_only"].(bool)
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_checks").
+		Str("app_name", appName).
+		Str("machine_id", machineFilter).
+		Bool("failing_only", failingOnly).
+		Msg("Executing health checks tool")
+
+	machines, err := t.flyClient.ListMachines(ctx, appName)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "get_health_checks", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to retrieve health checks for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	var report []machineChecks
+	failingByService := make(map[string]int)
+	totalChecks, failingChecks := 0, 0
+
+	for _, machine := range machines {
+		if machineFilter != "" && machine.ID != machineFilter {
+			continue
+		}
+
+		mc := machineChecks{
+			MachineID: machine.ID,
+			Name:      machine.Name,
+			Region:    machine.Region,
+			State:     machine.State,
+		}
+
+		for _, check := range machine.Checks {
+			t.history.record(machine.ID, check)
+			totalChecks++
+
+			service, port := describeCheck(check.Name)
+			if !check.IsPassing() {
+				failingChecks++
+				failingByService[serviceLabel(check.Name, service, port)]++
+			} else if failingOnly {
+				continue
+			}
+
+			mc.Checks = append(mc.Checks, checkWithHistory{
+				MachineCheck: check,
+				Service:      service,
+				Port:         port,
+				Failures:     t.history.failures(machine.ID, check.Name),
+			})
+		}
+
+		if failingOnly && len(mc.Checks) == 0 {
+			continue
+		}
+		report = append(report, mc)
+	}
+
+	t.authManager.AuditLog(ctx, userID, "get_health_checks", appName, "success", map[string]interface{}{
+		"machine_count":  len(report),
+		"check_count":    totalChecks,
+		"failing_checks": failingChecks,
+	})
+
+	return out.Render(t.formatTextResponse(appName, report, failingByService, totalChecks, failingChecks), fmt.Sprintf("Health checks for application '%s'", appName), healthReport{
+		AppName:          appName,
+		TotalChecks:      totalChecks,
+		FailingChecks:    failingChecks,
+		FailingByService: failingByService,
+		Machines:         report,
+	}, appLinks(appName)...), nil
+}
+
+// formatTextResponse formats the check report as human-readable text
+func (t *HealthChecksTool) formatTextResponse(appName string, report []machineChecks, failingByService map[string]int, totalChecks, failingChecks int) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Health Checks: %s\n\n", appName)
+
+	response += "## Summary\n"
+	response += fmt.Sprintf("- **Machines**: %d\n", len(report))
+	response += fmt.Sprintf("- **Checks**: %d\n", totalChecks)
+	response += fmt.Sprintf("- **Failing**: %d\n", failingChecks)
+
+	if totalChecks == 0 {
+		response += "\nℹ️ No health checks are configured for this app. Add `[[services.http_checks]]` or `[checks]` to fly.toml to enable them.\n"
+	} else if failingChecks == 0 {
+		response += "\n🟢 **All health checks are passing**\n"
+	} else {
+		response += "\n## Failing Services\n"
+		services := make([]string, 0, len(failingByService))
+		for service := range failingByService {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		for _, service := range services {
+			response += fmt.Sprintf("- 🔴 **%s**: failing on %d machine(s)\n", service, failingByService[service])
+		}
+	}
+
+	for _, mc := range report {
+		if len(mc.Checks) == 0 {
+			continue
+		}
+
+		response += fmt.Sprintf("\n## Machine %s (%s, %s)\n", mc.MachineID, mc.Region, mc.State)
+		for _, check := range mc.Checks {
+			icon := "⚪"
+			switch check.Status {
+			case "passing":
+				icon = "🟢"
+			case "warning":
+				icon = "🟡"
+			case "critical":
+				icon = "🔴"
+			}
+
+			response += fmt.Sprintf("- %s **%s**: %s", icon, check.Name, check.Status)
+			if !check.UpdatedAt.IsZero() {
+				response += fmt.Sprintf(" (updated %s)", check.UpdatedAt.Format("2006-01-02 15:04:05 UTC"))
+			}
+			response += "\n"
+
+			if check.Output != "" {
+				response += fmt.Sprintf("  - Output: `%s`\n", truncateOutput(check.Output, 200))
+			}
+			for _, failure := range check.Failures {
+				response += fmt.Sprintf("  - Failed at %s: %s\n", failure.ObservedAt.Format("15:04:05"), failure.Status)
+			}
+		}
+	}
+
+	response += "\n## Suggested Actions\n"
+	response += "- Use `fly_status` to check machine states\n"
+	response += "- Use `fly_restart` to restart machines with failing checks\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// describeCheck extracts the service protocol and port from a generated check name
+func describeCheck(name string) (service, port string) {
+	if m := serviceCheckPattern.FindStringSubmatch(name); m != nil {
+		return m[1], m[2]
+	}
+	return "", ""
+}
+
+// serviceLabel returns a label grouping checks by service and port
+func serviceLabel(name, service, port string) string {
+	if service == "" {
+		return name
+	}
+	return fmt.Sprintf("%s:%s", service, port)
+}
+
+// truncateOutput shortens check output for display
+func truncateOutput(output string, max int) string {
+	if len(output) <= max {
+		return output
+	}
+	return output[:max] + "…"
+}
+
+// checkObservation is a recorded status transition of a check
+type checkObservation struct {
+	Status     string    `json:"status"`
+	Output     string    `json:"output,omitempty"`
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// checkHistory keeps recent status transitions for checks across tool calls
+type checkHistory struct {
+	mu      sync.Mutex
+	entries map[string][]checkObservation
+}
+
+func newCheckHistory() *checkHistory {
+	return &checkHistory{entries: make(map[string][]checkObservation)}
+}
+
+// record stores the check status if it differs from the last observation
+func (h *checkHistory) record(machineID string, check fly.MachineCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := machineID + "/" + check.Name
+	entries := h.entries[key]
+	if len(entries) > 0 && entries[len(entries)-1].Status == check.Status {
+		return
+	}
+
+	observedAt := check.UpdatedAt
+	if observedAt.IsZero() {
+		observedAt = time.Now().UTC()
+	}
+
+	entries = append(entries, checkObservation{Status: check.Status, Output: check.Output, ObservedAt: observedAt})
+	if len(entries) > maxCheckHistory {
+		entries = entries[len(entries)-maxCheckHistory:]
+	}
+	h.entries[key] = entries
+}
+
+// failures returns the recorded non-passing observations for a check
+func (h *checkHistory) failures(machineID, checkName string) []checkObservation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var failures []checkObservation
+	for _, obs := range h.entries[machineID+"/"+checkName] {
+		if obs.Status != "passing" {
+			failures = append(failures, obs)
+		}
+	}
+	return failures
+}
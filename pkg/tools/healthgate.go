@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+const (
+	maxHealthWindow = 600
+	// defaultFailureThreshold is the percentage of failing health samples a
+	// health gate tolerates
+	defaultFailureThreshold = 20
+)
+
+// healthGateProperties are the input schema properties of tools that can
+// watch machines after changing them
+func healthGateProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"health_window_seconds": map[string]interface{}{
+			"type":        "integer",
+			"description": "After the change, watch the machines' health checks for this long (0 disables the health gate)",
+			"default":     0,
+			"minimum":     0,
+			"maximum":     maxHealthWindow,
+		},
+		"failure_threshold": map[string]interface{}{
+			"type":        "integer",
+			"description": "Percentage of failing health samples during the window above which the gate fails",
+			"default":     defaultFailureThreshold,
+			"minimum":     0,
+			"maximum":     100,
+		},
+		"auto_rollback": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Roll the machines back to their previous image when the gate fails",
+			"default":     true,
+		},
+	}
+}
+
+// healthGateFromArgs returns the health gate requested by a tool call, or
+// nil if none was
+func healthGateFromArgs(args map[string]interface{}) *fly.HealthGate {
+	window, _ := args["health_window_seconds"].(float64)
+	if window <= 0 {
+		return nil
+	}
+
+	gate := &fly.HealthGate{
+		Window:    time.Duration(min(int(window), maxHealthWindow)) * time.Second,
+		Threshold: defaultFailureThreshold / 100.0,
+		Rollback:  true,
+	}
+	if v, ok := args["failure_threshold"].(float64); ok && v >= 0 && v <= 100 {
+		gate.Threshold = v / 100
+	}
+	if v, ok := args["auto_rollback"].(bool); ok {
+		gate.Rollback = v
+	}
+	return gate
+}
+
+// healthGateScope adds a health gate to a confirmation scope, so a token
+// approved without automatic rollback cannot be used with it and vice versa
+func healthGateScope(scope map[string]interface{}, gate *fly.HealthGate) {
+	if gate == nil {
+		return
+	}
+	scope["health_window"] = gate.Window.Seconds()
+	scope["failure_threshold"] = gate.Threshold
+	scope["auto_rollback"] = gate.Rollback
+}
+
+// formatHealthGate formats the outcome of a health gate as a text section
+func formatHealthGate(gate *fly.HealthGateResult) string {
+	var response string
+
+	response += "\n## Health Gate\n"
+	switch gate.Decision {
+	case fly.GatePassed:
+		response += "🟢 **Passed**"
+	case fly.GateRolledBack:
+		response += "⏪ **Rolled Back**"
+	default:
+		response += "🔴 **Failed**"
+	}
+	response += fmt.Sprintf(": %s\n\n", gate.Reason)
+
+	response += fmt.Sprintf("- **Window**: %s\n", gate.Window)
+	response += fmt.Sprintf("- **Failing Samples**: %d of %d (%.0f%%, threshold %.0f%%)\n", gate.Failures, gate.Samples, gate.FailureRate*100, gate.Threshold*100)
+	if gate.RollbackImage != "" {
+		response += fmt.Sprintf("- **Rolled Back To**: %s\n", gate.RollbackImage)
+	}
+
+	if len(gate.Machines) > 0 {
+		response += "\n| Machine | Samples | Failures | Last State | Failing Checks |\n"
+		response += "|---------|---------|----------|------------|----------------|\n"
+		for _, m := range gate.Machines {
+			checks := "-"
+			if len(m.FailingChecks) > 0 {
+				checks = strings.Join(m.FailingChecks, ", ")
+			}
+			response += fmt.Sprintf("| `%s` | %d | %d | %s | %s |\n", m.MachineID, m.Samples, m.Failures, m.LastState, checks)
+		}
+	}
+
+	for _, m := range gate.Rollback {
+		if m.Error != "" {
+			response += fmt.Sprintf("- ❌ Rollback of `%s` failed: %s\n", m.MachineID, m.Error)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// ImagesTool implements the fly_images MCP tool
+type ImagesTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewImagesTool creates a new registry images tool
+func NewImagesTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *ImagesTool {
+	return &ImagesTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *ImagesTool) Name() string {
+	return "fly_images"
+}
+
+// Description returns the tool description
+func (t *ImagesTool) Description() string {
+	return "List the images pushed to registry.fly.io for a Fly.io application, show which image each machine is running, and flag machines that are not running the latest pushed image."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *ImagesTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *ImagesTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the images tool
+func (t *ImagesTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_images").
+		Str("app_name", appName).
+		Msg("Executing images tool")
+
+	images, err := t.flyClient.GetAppImages(ctx, appName)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "get_app_images", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to retrieve images for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "get_app_images", appName, "success", map[string]interface{}{
+		"image_count": images.TotalTags,
+	})
+
+	return out.Render(t.formatTextResponse(images), fmt.Sprintf("Images for application '%s'", appName), images, appLinks(appName)...), nil
+}
+
+// formatTextResponse formats the image details as human-readable text
+func (t *ImagesTool) formatTextResponse(images *fly.AppImages) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Images: %s\n\n", images.AppName)
+	response += fmt.Sprintf("- **Repository**: %s\n", images.Repository)
+	response += fmt.Sprintf("- **Tags Pushed**: %d\n", images.TotalTags)
+	if images.Latest != nil {
+		response += fmt.Sprintf("- **Latest**: `%s` (%s)\n", images.Latest.Tag, shortDigest(images.Latest.Digest))
+	}
+
+	drifted := 0
+	if len(images.Machines) > 0 {
+		response += "\n## Running Images\n"
+		for _, m := range images.Machines {
+			icon := "✅"
+			note := ""
+			if m.Drift {
+				icon = "⚠️"
+				note = " — **not the latest image**"
+				drifted++
+			}
+			response += fmt.Sprintf("- %s **%s** (%s, %s): `%s` (%s)%s\n", icon, m.MachineID, m.Region, m.State, m.Image, shortDigest(m.Digest), note)
+		}
+	}
+
+	if len(images.Images) > 0 {
+		response += "\n## Pushed Images (newest first)\n"
+		for _, img := range images.Images {
+			response += fmt.Sprintf("- `%s` (%s)\n", img.Tag, shortDigest(img.Digest))
+		}
+		if images.TotalTags > len(images.Images) {
+			response += fmt.Sprintf("- …and %d older tag(s)\n", images.TotalTags-len(images.Images))
+		}
+	}
+
+	if len(images.Warnings) > 0 {
+		response += "\n## Warnings\n"
+		for _, w := range images.Warnings {
+			response += fmt.Sprintf("- ⚠️ %s\n", w)
+		}
+	}
+
+	if drifted > 0 {
+		response += "\n## Drift Detected\n"
+		response += fmt.Sprintf("%d machine(s) are running an older image than the latest push. This usually means a deploy failed part way or machines were updated individually.\n", drifted)
+		response += "- Use `fly_machine_events` to see when the machines were last updated\n"
+		response += "- Redeploy to bring every machine onto the latest image\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// shortDigest abbreviates an image digest for display
+func shortDigest(digest string) string {
+	if digest == "" {
+		return "digest unknown"
+	}
+	if len(digest) > 19 {
+		return digest[:19]
+	}
+	return digest
+}
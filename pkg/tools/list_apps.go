@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/brannn/fly-mcp/internal/logger"
 	"github.com/brannn/fly-mcp/pkg/auth"
@@ -11,6 +12,18 @@ import (
 	"github.com/brannn/fly-mcp/pkg/interfaces"
 )
 
+// defaultAppsPageSize is the number of apps listed per page by default
+const defaultAppsPageSize = 50
+
+// appList is one page of the organization's applications
+type appList struct {
+	Apps       []fly.App `json:"apps"`
+	TotalCount int       `json:"totalCount"`
+	Filter     string    `json:"filter"`
+	PageSize   int       `json:"pageSize"`
+	NextCursor string    `json:"nextCursor"`
+}
+
 // ListAppsTool implements the fly_list_apps MCP tool
 type ListAppsTool struct {
 	flyClient   *fly.Client
@@ -34,7 +47,7 @@ func (t *ListAppsTool) Name() string {
 
 // Description returns the tool description
 func (t *ListAppsTool) Description() string {
-	return "List all applications in your Fly.io organization with their current status, deployment state, and basic information"
+	return "List all applications in your Fly.io organization with their current status, deployment state, and basic information. Results are paginated; pass the returned nextCursor as cursor to fetch the next page."
 }
 
 // InputSchema returns the JSON schema for the tool's input
@@ -56,11 +69,27 @@ func (t *ListAppsTool) InputSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "Organization slug to list apps from (optional, uses configured org if not specified)",
 			},
+			"page_size": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of apps to return",
+				"default":     defaultAppsPageSize,
+				"minimum":     1,
+				"maximum":     500,
+			},
+			"cursor": map[string]interface{}{
+				"type":        "string",
+				"description": "nextCursor from a previous call, to fetch the following page",
+			},
 		},
 		"additionalProperties": false,
 	}
 }
 
+// RequiredPermission returns the permission checked before execution
+func (t *ListAppsTool) RequiredPermission() (string, string) {
+	return "read", "apps"
+}
+
 // Execute executes the list apps tool
 func (t *ListAppsTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
 	// Validate permissions
@@ -90,6 +119,13 @@ func (t *ListAppsTool) Execute(ctx context.Context, args map[string]interface{})
 		organization = org
 	}
 
+	pageSize := defaultAppsPageSize
+	if size, ok := args["page_size"].(float64); ok && size >= 1 {
+		pageSize = int(size)
+	}
+
+	cursor, _ := args["cursor"].(string)
+
 	// Log the operation
 	userID, _ := t.authManager.ExtractUserFromContext(ctx)
 	t.logger.Info().
@@ -110,7 +146,7 @@ func (t *ListAppsTool) Execute(ctx context.Context, args map[string]interface{})
 		return &interfaces.ToolResult{
 			Content: []interfaces.ContentBlock{{
 				Type: "text",
-				Text: fmt.Sprintf("Failed to retrieve apps from Fly.io: %v", err),
+				Text: fmt.Sprintf("Failed to retrieve apps from Fly.io: %s", describeError(err)),
 			}},
 			IsError: true,
 		}, nil
@@ -127,41 +163,61 @@ func (t *ListAppsTool) Execute(ctx context.Context, args map[string]interface{})
 		apps = filteredApps
 	}
 
+	// Pages are taken from a stable order so cursors stay valid between calls
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].Name < apps[j].Name
+	})
+
+	totalCount := len(apps)
+	page, err := Paginate(totalCount, pageSize, cursor)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v. Omit cursor to start from the first page.", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+	apps = apps[page.Start:page.End]
+
 	// Log successful operation
 	t.authManager.AuditLog(ctx, userID, "list_apps", "apps", "success", map[string]interface{}{
-		"app_count":       len(apps),
+		"app_count":       totalCount,
 		"status_filter":   statusFilter,
 		"include_details": includeDetails,
 	})
 
+	list := appList{
+		Apps:       apps,
+		TotalCount: totalCount,
+		Filter:     statusFilter,
+		PageSize:   pageSize,
+		NextCursor: page.NextCursor,
+	}
+	out := NewOutputFormatter(ctx, args)
+
 	// Format response
-	if len(apps) == 0 {
+	if totalCount == 0 {
 		message := "No applications found"
 		if statusFilter != "" {
 			message = fmt.Sprintf("No applications found with status '%s'", statusFilter)
 		}
 		
-		return &interfaces.ToolResult{
+		return out.Render(&interfaces.ToolResult{
 			Content: []interfaces.ContentBlock{{
 				Type: "text",
 				Text: message,
 			}},
-		}, nil
+		}, message, list), nil
 	}
 
 	// Create response content
 	var responseText string
-	var responseData interface{}
 
 	if includeDetails {
 		// Detailed response with JSON data
-		responseData = map[string]interface{}{
-			"apps":        apps,
-			"total_count": len(apps),
-			"filter":      statusFilter,
-		}
-
-		jsonData, err := json.MarshalIndent(responseData, "", "  ")
+		jsonData, err := json.MarshalIndent(list, "", "  ")
 		if err != nil {
 			return &interfaces.ToolResult{
 				Content: []interfaces.ContentBlock{{
@@ -172,10 +228,14 @@ func (t *ListAppsTool) Execute(ctx context.Context, args map[string]interface{})
 			}, nil
 		}
 
-		responseText = fmt.Sprintf("Found %d applications:\n\n```json\n%s\n```", len(apps), string(jsonData))
+		responseText = fmt.Sprintf("Found %d applications:\n\n```json\n%s\n```", totalCount, string(jsonData))
 	} else {
 		// Simple text response
-		responseText = fmt.Sprintf("Found %d applications:\n\n", len(apps))
+		responseText = fmt.Sprintf("Found %d applications", totalCount)
+		if len(apps) < totalCount {
+			responseText += fmt.Sprintf(" (showing %d-%d)", page.Start+1, page.End)
+		}
+		responseText += ":\n\n"
 		
 		for i, app := range apps {
 			status := "🔴 stopped"
@@ -187,25 +247,43 @@ func (t *ListAppsTool) Execute(ctx context.Context, args map[string]interface{})
 				status = "🔵 deployed"
 			}
 
-			responseText += fmt.Sprintf("%d. **%s** (%s)\n", i+1, app.Name, status)
-			responseText += fmt.Sprintf("   - URL: %s\n", app.AppURL)
-			responseText += fmt.Sprintf("   - Hostname: %s\n", app.Hostname)
+			responseText += fmt.Sprintf("%d. **%s** (%s)\n", page.Start+i+1, app.Name, status)
+			if app.AppURL != "" {
+				responseText += fmt.Sprintf("   - URL: %s\n", app.AppURL)
+			}
+			if app.Hostname != "" {
+				responseText += fmt.Sprintf("   - Hostname: %s\n", app.Hostname)
+			}
 			if app.Organization != nil {
 				responseText += fmt.Sprintf("   - Organization: %s\n", app.Organization.Name)
 			}
-			responseText += fmt.Sprintf("   - Updated: %s\n\n", app.UpdatedAt.Format("2006-01-02 15:04:05"))
+			if app.UpdatedAt != nil {
+				responseText += fmt.Sprintf("   - Updated: %s\n", app.UpdatedAt.Format("2006-01-02 15:04:05"))
+			}
+			responseText += "\n"
 		}
 	}
 
+	if page.NextCursor != "" && !includeDetails {
+		responseText += fmt.Sprintf("More applications available. Call again with cursor `%s` for the next page.\n", page.NextCursor)
+	}
+
 	t.logger.Debug().
 		Str("user_id", userID).
 		Int("app_count", len(apps)).
 		Msg("Successfully listed apps")
 
-	return &interfaces.ToolResult{
+	links := make([]interfaces.ContentBlock, 0, len(apps))
+	for _, app := range apps {
+		links = append(links, interfaces.ResourceLink(AppResourceURI(app.Name), app.Name, fmt.Sprintf("Application %s", app.Name)))
+	}
+
+	result := &interfaces.ToolResult{
 		Content: []interfaces.ContentBlock{{
 			Type: "text",
 			Text: responseText,
 		}},
-	}, nil
+	}
+
+	return out.Render(result, fmt.Sprintf("Found %d applications", totalCount), list, links...), nil
 }
@@ -0,0 +1,466 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// defaultLogsLimit is the number of most recent log lines shown by default
+const defaultLogsLimit = 100
+
+// defaultFollowSeconds is how long follow mode runs by default
+const defaultFollowSeconds = 30
+
+// logLevels are the levels the level argument accepts, least severe first
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// LogsTool implements the fly_logs MCP tool
+type LogsTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+	// maxFollow bounds how long a call may follow the logs, and
+	// pollInterval is how often it fetches new lines meanwhile
+	maxFollow    time.Duration
+	pollInterval time.Duration
+}
+
+// NewLogsTool creates a new logs tool
+func NewLogsTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger, maxFollow, pollInterval time.Duration) *LogsTool {
+	return &LogsTool{
+		flyClient:    flyClient,
+		authManager:  authManager,
+		logger:       logger,
+		maxFollow:    maxFollow,
+		pollInterval: pollInterval,
+	}
+}
+
+// Name returns the tool name
+func (t *LogsTool) Name() string {
+	return "fly_logs"
+}
+
+// Description returns the tool description
+func (t *LogsTool) Description() string {
+	return "Show the most recent log lines of a Fly.io application, optionally filtered by region, machine, minimum level, text or regular expression. Pass follow to tail the logs instead: new matching lines are streamed as progress messages while the call runs, and returned when it ends. Pass summarize to get findings written by your model instead of hundreds of raw lines."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *LogsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show lines from this region, e.g. iad",
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show lines from this machine",
+			},
+			"level": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show lines of this level or more severe",
+				"enum":        logLevels,
+			},
+			"search": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show lines containing this text, ignoring case",
+			},
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show lines whose message matches this regular expression (RE2 syntax), e.g. (?i)timeout|5\\d\\d",
+			},
+			"follow": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Follow the logs for follow_seconds and show the lines that arrive meanwhile, streaming them as progress messages when the call has a progress token",
+				"default":     false,
+			},
+			"follow_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "How long to follow the logs",
+				"default":     defaultFollowSeconds,
+				"minimum":     1,
+				"maximum":     int(t.maxFollow / time.Second),
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of most recent lines to show",
+				"default":     defaultLogsLimit,
+				"minimum":     1,
+				"maximum":     1000,
+			},
+			summarizeArg: summarizeProperty(),
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *LogsTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the logs tool
+func (t *LogsTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	filter := logFilter{minLevel: -1}
+	filter.region, _ = args["region"].(string)
+	filter.machineID, _ = args["machine_id"].(string)
+	filter.search, _ = args["search"].(string)
+
+	if level, ok := args["level"].(string); ok && level != "" {
+		if filter.minLevel = logLevelRank(level); filter.minLevel < 0 {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unknown level %q. Valid levels: %v", level, logLevels),
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	if pattern, ok := args["pattern"].(string); ok && pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: invalid pattern: %v", err),
+				}},
+				IsError: true,
+			}, nil
+		}
+		filter.pattern = re
+	}
+
+	limit := defaultLogsLimit
+	if l, ok := args["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+
+	follow, _ := args["follow"].(bool)
+	var duration time.Duration
+	if follow {
+		duration = defaultFollowSeconds * time.Second
+		if s, ok := args["follow_seconds"].(float64); ok && s >= 1 {
+			duration = time.Duration(s) * time.Second
+		}
+		if duration > t.maxFollow {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: follow_seconds must be at most %d", int(t.maxFollow/time.Second)),
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_logs").
+		Str("app_name", appName).
+		Str("region", filter.region).
+		Str("machine_id", filter.machineID).
+		Bool("follow", follow).
+		Msg("Executing logs tool")
+
+	var matched []fly.LogEntry
+	var total int
+	var err error
+	if follow {
+		matched, total, err = t.follow(ctx, appName, filter, duration, limit)
+	} else {
+		matched, total, err = t.recent(ctx, appName, filter, limit)
+	}
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "get_logs", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to retrieve logs for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	details := map[string]interface{}{
+		"line_count": total,
+	}
+	if follow {
+		details["follow_seconds"] = int(duration / time.Second)
+	}
+	t.authManager.AuditLog(ctx, userID, "get_logs", appName, "success", details)
+
+	structured := map[string]interface{}{
+		"app_name": appName,
+		"total":    total,
+		"lines":    matched,
+	}
+	if follow {
+		structured["follow_seconds"] = int(duration / time.Second)
+	}
+
+	result := out.Render(t.formatTextResponse(appName, matched, total, duration), fmt.Sprintf("Logs of application '%s'", appName), structured, appLinks(appName)...)
+	return summarizeResult(ctx, args, "fly_logs", fmt.Sprintf("Summarize these log lines of the Fly.io application %s into findings.", appName), result), nil
+}
+
+// recent returns the latest limit matching lines of the app's most recent
+// output, and how many lines matched in all
+func (t *LogsTool) recent(ctx context.Context, appName string, filter logFilter, limit int) ([]fly.LogEntry, int, error) {
+	entries, _, err := t.flyClient.GetLogsSince(ctx, appName, "", filter.region, filter.machineID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matched := make([]fly.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if filter.match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+
+	// Keep the most recent lines
+	total := len(matched)
+	if len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+	return matched, total, nil
+}
+
+// follow polls the app's logs for duration and returns the latest limit
+// matching lines that arrived meanwhile, and how many matched in all. Each
+// poll reports the new lines as a progress message, with the seconds
+// followed as progress, and a last poll when the time is up catches the
+// lines since the one before. Following ends early when the call is cancelled;
+// failed polls are logged and retried on the next one.
+func (t *LogsTool) follow(ctx context.Context, appName string, filter logFilter, duration time.Duration, limit int) ([]fly.LogEntry, int, error) {
+	interfaces.KeepAlive(ctx, duration)
+
+	// Start after the existing output
+	existing, token, err := t.flyClient.GetLogsSince(ctx, appName, "", filter.region, filter.machineID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var newest time.Time
+	for _, entry := range existing {
+		if entry.Timestamp.After(newest) {
+			newest = entry.Timestamp
+		}
+	}
+
+	start := time.Now()
+	done := time.NewTimer(duration)
+	defer done.Stop()
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	var matched []fly.LogEntry
+	total := 0
+	for last := false; !last; {
+		select {
+		case <-ctx.Done():
+			return matched, total, nil
+		case <-done.C:
+			// Poll once more for the lines since the previous poll
+			last = true
+		case <-ticker.C:
+		}
+
+		entries, next, err := t.flyClient.GetLogsSince(ctx, appName, token, filter.region, filter.machineID)
+		if err != nil {
+			if ctx.Err() == nil {
+				t.logger.Warn().
+					Err(err).
+					Str("app_name", appName).
+					Msg("Failed to poll logs")
+			}
+			continue
+		}
+
+		var lines []string
+		for _, entry := range entries {
+			// Without a token to continue from, the API returns the latest
+			// page again
+			if token == "" && !entry.Timestamp.After(newest) {
+				continue
+			}
+			if entry.Timestamp.After(newest) {
+				newest = entry.Timestamp
+			}
+			if !filter.match(entry) {
+				continue
+			}
+			total++
+			matched = append(matched, entry)
+			lines = append(lines, formatLogLine(entry))
+		}
+		token = next
+
+		// Keep the most recent lines
+		if len(matched) > limit {
+			matched = matched[len(matched)-limit:]
+		}
+
+		elapsed := math.Round(time.Since(start).Seconds()*10) / 10
+		interfaces.ReportProgress(ctx, elapsed, duration.Seconds(), strings.Join(lines, "\n"))
+	}
+	return matched, total, nil
+}
+
+// formatTextResponse formats the log lines as human-readable text. followed
+// is how long the logs were followed, or zero for the most recent lines.
+func (t *LogsTool) formatTextResponse(appName string, entries []fly.LogEntry, total int, followed time.Duration) *interfaces.ToolResult {
+	var response string
+
+	if followed > 0 {
+		response += fmt.Sprintf("# Live Logs: %s\n\n", appName)
+	} else {
+		response += fmt.Sprintf("# Logs: %s\n\n", appName)
+	}
+
+	if len(entries) == 0 {
+		if followed > 0 {
+			response += fmt.Sprintf("No new log lines matched in %s.\n", followed)
+		} else {
+			response += "No log lines match.\n"
+		}
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: response,
+			}},
+		}
+	}
+
+	errorCount, warnCount := 0, 0
+	for _, e := range entries {
+		switch logLevelRank(e.Level) {
+		case 3:
+			errorCount++
+		case 2:
+			warnCount++
+		}
+	}
+
+	response += "## Summary\n"
+	if followed > 0 {
+		response += fmt.Sprintf("- **Followed**: %s\n", followed)
+	}
+	response += fmt.Sprintf("- **Lines**: %d", total)
+	if total > len(entries) {
+		response += fmt.Sprintf(" (showing the latest %d)", len(entries))
+	}
+	response += "\n"
+	response += fmt.Sprintf("- **Errors**: %d\n", errorCount)
+	response += fmt.Sprintf("- **Warnings**: %d\n", warnCount)
+
+	response += "\n## Lines\n```\n"
+	for _, e := range entries {
+		response += formatLogLine(e) + "\n"
+	}
+	response += "```\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// formatLogLine formats a log line as time, source, level and message
+func formatLogLine(e fly.LogEntry) string {
+	source := e.Region
+	if e.Instance != "" {
+		source += " " + e.Instance
+	}
+	return fmt.Sprintf("%s [%s] %s: %s",
+		e.Timestamp.UTC().Format("2006-01-02 15:04:05"), source, strings.ToLower(e.Level), e.Message)
+}
+
+// logFilter selects the log lines a call asked for
+type logFilter struct {
+	region    string
+	machineID string
+	// minLevel is the rank of the least severe level shown, or -1 for all
+	minLevel int
+	search   string
+	pattern  *regexp.Regexp
+}
+
+// match reports whether a log line passes the filter
+func (f logFilter) match(entry fly.LogEntry) bool {
+	switch {
+	case f.region != "" && entry.Region != f.region:
+	case f.machineID != "" && entry.Instance != f.machineID:
+	case f.minLevel >= 0 && logLevelRank(entry.Level) < f.minLevel:
+	case f.search != "" && !strings.Contains(strings.ToLower(entry.Message), strings.ToLower(f.search)):
+	case f.pattern != nil && !f.pattern.MatchString(entry.Message):
+	default:
+		return true
+	}
+	return false
+}
+
+// logLevelRank returns the position of a level in logLevels, counting
+// fatal and panic as error and warning as warn, or -1 for unknown levels
+func logLevelRank(level string) int {
+	switch level = strings.ToLower(level); level {
+	case "warning":
+		level = "warn"
+	case "fatal", "panic":
+		level = "error"
+	}
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,415 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// defaultSearchWindow is how far on each side of around a search looks by
+// default
+const defaultSearchWindow = 5 * time.Minute
+
+// defaultLogSearchLimit is the number of matches shown by default
+const defaultLogSearchLimit = 50
+
+// maxSearchContext bounds the context lines shown around each match
+const maxSearchContext = 20
+
+// LogsSearchTool implements the fly_logs_search MCP tool
+type LogsSearchTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewLogsSearchTool creates a new log search tool
+func NewLogsSearchTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *LogsSearchTool {
+	return &LogsSearchTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *LogsSearchTool) Name() string {
+	return "fly_logs_search"
+}
+
+// Description returns the tool description
+func (t *LogsSearchTool) Description() string {
+	return "Search the logs of a Fly.io application within a time window, such as a few minutes around an incident, for text or a regular expression, showing context lines around each match. Only lines the Fly.io logs API still retains can be found."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *LogsSearchTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"around": map[string]interface{}{
+				"type":        "string",
+				"description": "Search window centered on this time: an RFC 3339 timestamp such as 2025-01-02T15:04:05Z, or a duration ago such as 30m",
+			},
+			"window": map[string]interface{}{
+				"type":        "string",
+				"description": "How far before and after around to search, e.g. 2m or 15m",
+				"default":     "5m",
+			},
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "Start of the search window, instead of around: an RFC 3339 timestamp or a duration ago such as 1h",
+			},
+			"until": map[string]interface{}{
+				"type":        "string",
+				"description": "End of the search window, instead of around: an RFC 3339 timestamp or a duration ago. Defaults to now.",
+			},
+			"search": map[string]interface{}{
+				"type":        "string",
+				"description": "Match lines containing this text, ignoring case",
+			},
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Match lines whose message matches this regular expression (RE2 syntax)",
+			},
+			"level": map[string]interface{}{
+				"type":        "string",
+				"description": "Only match lines of this level or more severe",
+				"enum":        logLevels,
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Only search lines from this region, e.g. iad",
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Only search lines from this machine",
+			},
+			"context": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of lines to show before and after each match",
+				"default":     0,
+				"minimum":     0,
+				"maximum":     maxSearchContext,
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of matches to show, earliest first",
+				"default":     defaultLogSearchLimit,
+				"minimum":     1,
+				"maximum":     500,
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *LogsSearchTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// searchLine is a line of search output: a match, or context around one
+type searchLine struct {
+	fly.LogEntry
+	Match bool `json:"match"`
+}
+
+// logSearch is the result of a log search
+type logSearch struct {
+	AppName string    `json:"app_name"`
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+	// Matches counts every match in the window, Groups holds the shown ones
+	// with their context, a group per run of adjacent lines
+	Matches int            `json:"matches"`
+	Shown   int            `json:"shown"`
+	Groups  [][]searchLine `json:"groups"`
+	// Oldest and Newest bound the lines the logs API returned
+	Oldest *time.Time `json:"oldest,omitempty"`
+	Newest *time.Time `json:"newest,omitempty"`
+}
+
+// Execute executes the log search tool
+func (t *LogsSearchTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	since, until, err := searchWindow(args, time.Now().UTC())
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	filter := logFilter{minLevel: -1}
+	filter.region, _ = args["region"].(string)
+	filter.machineID, _ = args["machine_id"].(string)
+	filter.search, _ = args["search"].(string)
+
+	if level, ok := args["level"].(string); ok && level != "" {
+		if filter.minLevel = logLevelRank(level); filter.minLevel < 0 {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unknown level %q. Valid levels: %v", level, logLevels),
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	if pattern, ok := args["pattern"].(string); ok && pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: invalid pattern: %v", err),
+				}},
+				IsError: true,
+			}, nil
+		}
+		filter.pattern = re
+	}
+
+	contextLines := 0
+	if c, ok := args["context"].(float64); ok && c > 0 {
+		contextLines = min(int(c), maxSearchContext)
+	}
+
+	limit := defaultLogSearchLimit
+	if l, ok := args["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_logs_search").
+		Str("app_name", appName).
+		Time("since", since).
+		Time("until", until).
+		Msg("Executing log search tool")
+
+	entries, _, err := t.flyClient.GetLogsSince(ctx, appName, "", filter.region, filter.machineID)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "search_logs", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to search logs of app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	search := searchLogs(entries, filter, since, until, contextLines, limit)
+	search.AppName = appName
+
+	t.authManager.AuditLog(ctx, userID, "search_logs", appName, "success", map[string]interface{}{
+		"since":   since,
+		"until":   until,
+		"matches": search.Matches,
+	})
+
+	return out.Render(t.formatTextResponse(search), fmt.Sprintf("Log search in application '%s'", appName), search, appLinks(appName)...), nil
+}
+
+// searchWindow returns the time window a call asked for, from around and
+// window or from since and until
+func searchWindow(args map[string]interface{}, now time.Time) (time.Time, time.Time, error) {
+	around, _ := args["around"].(string)
+	sinceArg, _ := args["since"].(string)
+	untilArg, _ := args["until"].(string)
+
+	if around != "" {
+		if sinceArg != "" || untilArg != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("pass either around or since and until, not both")
+		}
+		center, err := parseLogTime(around, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid around: %w", err)
+		}
+		window := defaultSearchWindow
+		if w, ok := args["window"].(string); ok && w != "" {
+			if window, err = time.ParseDuration(w); err != nil || window <= 0 {
+				return time.Time{}, time.Time{}, fmt.Errorf("invalid window '%s' (e.g. 2m, 15m, 1h)", w)
+			}
+		}
+		return center.Add(-window), center.Add(window), nil
+	}
+
+	if sinceArg == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("pass around, e.g. the time of an incident, or since")
+	}
+	since, err := parseLogTime(sinceArg, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid since: %w", err)
+	}
+	until := now
+	if untilArg != "" {
+		if until, err = parseLogTime(untilArg, now); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid until: %w", err)
+		}
+	}
+	if !since.Before(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("since must be before until")
+	}
+	return since, until, nil
+}
+
+// parseLogTime parses an RFC 3339 timestamp, or a duration meaning that
+// long before now
+func parseLogTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("'%s' is neither an RFC 3339 timestamp nor a duration such as 30m", value)
+}
+
+// searchLogs finds the lines in [since, until] passing filter, and groups
+// the first limit of them with contextLines lines on each side. Context is
+// taken from all lines, so it may reach outside the window.
+func searchLogs(entries []fly.LogEntry, filter logFilter, since, until time.Time, contextLines, limit int) logSearch {
+	search := logSearch{Since: since, Until: until, Groups: [][]searchLine{}}
+
+	var matches []int
+	shown := make(map[int]bool)
+	for i, entry := range entries {
+		if search.Oldest == nil || entry.Timestamp.Before(*search.Oldest) {
+			search.Oldest = &entry.Timestamp
+		}
+		if search.Newest == nil || entry.Timestamp.After(*search.Newest) {
+			search.Newest = &entry.Timestamp
+		}
+		if entry.Timestamp.Before(since) || entry.Timestamp.After(until) || !filter.match(entry) {
+			continue
+		}
+		search.Matches++
+		if len(matches) < limit {
+			matches = append(matches, i)
+			shown[i] = true
+		}
+	}
+	search.Shown = len(matches)
+
+	// Merge the context of nearby matches into one group
+	end := -1
+	for _, i := range matches {
+		from := max(i-contextLines, 0)
+		if from <= end+1 && len(search.Groups) > 0 {
+			from = end + 1
+		} else {
+			search.Groups = append(search.Groups, nil)
+		}
+		to := min(i+contextLines, len(entries)-1)
+		group := &search.Groups[len(search.Groups)-1]
+		for j := from; j <= to; j++ {
+			*group = append(*group, searchLine{LogEntry: entries[j], Match: shown[j]})
+		}
+		end = to
+	}
+	return search
+}
+
+// formatTextResponse formats the search result as human-readable text
+func (t *LogsSearchTool) formatTextResponse(search logSearch) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Log Search: %s\n\n", search.AppName)
+
+	response += "## Summary\n"
+	response += fmt.Sprintf("- **Window**: %s to %s UTC\n",
+		search.Since.UTC().Format("2006-01-02 15:04:05"), search.Until.UTC().Format("2006-01-02 15:04:05"))
+	response += fmt.Sprintf("- **Matches**: %d", search.Matches)
+	if search.Matches > search.Shown {
+		response += fmt.Sprintf(" (showing the first %d)", search.Shown)
+	}
+	response += "\n"
+	if search.Oldest == nil {
+		response += "- **Available logs**: none\n"
+	} else {
+		response += fmt.Sprintf("- **Available logs**: %s to %s UTC\n",
+			search.Oldest.UTC().Format("2006-01-02 15:04:05"), search.Newest.UTC().Format("2006-01-02 15:04:05"))
+	}
+
+	if search.Oldest != nil && search.Since.Before(*search.Oldest) {
+		response += "\n⚠️ The window starts before the oldest line the Fly.io logs API still retains, so earlier lines could not be searched. Ship logs to a log store to search further back.\n"
+	}
+
+	if len(search.Groups) == 0 {
+		response += "\nNo log lines match in the window.\n"
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: response,
+			}},
+		}
+	}
+
+	response += "\n## Matches\n```\n"
+	for i, group := range search.Groups {
+		if i > 0 {
+			response += "--\n"
+		}
+		for _, line := range group {
+			marker := "  "
+			if line.Match {
+				marker = "> "
+			}
+			response += marker + formatLogLine(line.LogEntry) + "\n"
+		}
+	}
+	response += "```\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
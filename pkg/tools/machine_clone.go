@@ -0,0 +1,269 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// MachineCloneTool implements the fly_machine_clone MCP tool
+type MachineCloneTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewMachineCloneTool creates a new machine clone tool
+func NewMachineCloneTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *MachineCloneTool {
+	return &MachineCloneTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *MachineCloneTool) Name() string {
+	return "fly_machine_clone"
+}
+
+// Description returns the tool description
+func (t *MachineCloneTool) Description() string {
+	return "Clone a Fly.io machine: create a new machine with the same image, size, services, environment and checks as an existing one, optionally in another region, and wait for it to pass its health checks. This is how an app is scaled out with the Machines API. Machines with volumes get a new empty volume of the same size; data is not copied. The first call previews the clone and returns a confirmation token; call again with the token to create the machine."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *MachineCloneTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "ID of the machine to clone",
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Region for the new machine, e.g. ams; defaults to the source machine's region",
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional name for the new machine",
+			},
+			"health_timeout_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "How long the new machine may take to start and pass its health checks",
+				"default":     defaultHealthTimeout,
+				"minimum":     minHealthTimeout,
+				"maximum":     maxHealthTimeout,
+			},
+			"confirmation_token": confirmationTokenProperty(),
+		},
+		"required":             []string{"app_name", "machine_id"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *MachineCloneTool) RequiredPermission() (string, string) {
+	return "scale", "app"
+}
+
+// Execute executes the machine clone tool
+func (t *MachineCloneTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "scale", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+	machineID, ok := args["machine_id"].(string)
+	if !ok || machineID == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: machine_id is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	req := fly.CloneRequest{
+		MachineID:     machineID,
+		HealthTimeout: defaultHealthTimeout * time.Second,
+	}
+	req.Region, _ = args["region"].(string)
+	req.Name, _ = args["name"].(string)
+	if v, ok := args["health_timeout_seconds"].(float64); ok {
+		req.HealthTimeout = time.Duration(max(minHealthTimeout, min(int(v), maxHealthTimeout))) * time.Second
+	}
+
+	scope := map[string]interface{}{
+		"app_name":       appName,
+		"machine_id":     machineID,
+		"region":         req.Region,
+		"name":           req.Name,
+		"health_timeout": req.HealthTimeout.Seconds(),
+	}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		return t.preview(ctx, appName, req, scope), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_machine_clone", token, scope); result != nil {
+		return result, nil
+	}
+
+	// Log the operation
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_machine_clone").
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Str("region", req.Region).
+		Msg("Executing machine clone tool")
+
+	clone, err := t.flyClient.CloneMachine(ctx, appName, req)
+	if clone == nil {
+		t.authManager.AuditLog(ctx, userID, "clone_machine", appName, "failed", map[string]interface{}{
+			"machine_id": machineID,
+			"region":     req.Region,
+			"error":      err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Clone Failed**\n\nFailed to clone machine '%s' of app '%s': %s", machineID, appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	outcome := "success"
+	if err != nil {
+		outcome = "unhealthy"
+	}
+	t.authManager.AuditLog(ctx, userID, "clone_machine", appName, outcome, map[string]interface{}{
+		"machine_id": machineID,
+		"clone_id":   clone.MachineID,
+		"region":     clone.Region,
+		"volumes":    clone.Volumes,
+		"error":      clone.Error,
+	})
+
+	return NewOutputFormatter(ctx, args).Render(t.formatTextResponse(appName, clone), fmt.Sprintf("Clone of machine '%s'", machineID), clone, appLinks(appName)...), nil
+}
+
+// preview describes the clone and issues its confirmation token
+func (t *MachineCloneTool) preview(ctx context.Context, appName string, req fly.CloneRequest, scope map[string]interface{}) *interfaces.ToolResult {
+	machines, err := t.flyClient.ListMachines(ctx, appName)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to get machines for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}
+	}
+
+	var source *fly.Machine
+	for i := range machines {
+		if machines[i].ID == req.MachineID {
+			source = &machines[i]
+		}
+	}
+	if source == nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: app '%s' has no machine '%s'. Use `fly_status` to list its machines.", appName, req.MachineID),
+			}},
+			IsError: true,
+		}
+	}
+
+	region := req.Region
+	if region == "" {
+		region = source.Region
+	}
+	guest := source.Guest()
+	monthly := fly.EstimateMachineCost(t.flyClient.Pricing(), guest)
+
+	summary := fmt.Sprintf("- **Source**: `%s` in %s (%s)\n", source.ID, source.Region, source.State)
+	summary += fmt.Sprintf("- **New machine**: %s, %s with %d MB, image `%s`\n", region, guest.VMSize(), guest.MemoryMB, source.ImageRef.String())
+	if mounts, _ := source.Config["mounts"].([]interface{}); len(mounts) > 0 {
+		summary += fmt.Sprintf("- **Volumes**: %d new empty volume(s) of the same size in %s; data is not copied\n", len(mounts), region)
+	}
+	summary += fmt.Sprintf("- **Health check**: wait up to %s for the new machine to become healthy\n", req.HealthTimeout)
+	summary += fmt.Sprintf("- **Cost**: about $%.2f/month more while it runs (estimated, see `fly_costs`)", monthly)
+
+	return requestConfirmation(ctx, t.authManager, "fly_machine_clone", "Machine Clone", appName, scope, summary)
+}
+
+// formatTextResponse formats the clone as human-readable text
+func (t *MachineCloneTool) formatTextResponse(appName string, clone *fly.ClonedMachine) *interfaces.ToolResult {
+	var response string
+
+	if clone.Healthy {
+		response += fmt.Sprintf("✅ **Machine '%s' Cloned**\n\n", clone.SourceID)
+	} else {
+		response += fmt.Sprintf("⚠️ **Machine '%s' Cloned, Not Healthy**\n\n", clone.SourceID)
+	}
+
+	response += "## Clone Details\n"
+	response += fmt.Sprintf("- **Application**: %s\n", appName)
+	response += fmt.Sprintf("- **New Machine**: `%s`\n", clone.MachineID)
+	response += fmt.Sprintf("- **Region**: %s\n", clone.Region)
+	if clone.Image != "" {
+		response += fmt.Sprintf("- **Image**: `%s`\n", clone.Image)
+	}
+	for _, volume := range clone.Volumes {
+		response += fmt.Sprintf("- **New Volume**: `%s` (empty)\n", volume)
+	}
+	response += fmt.Sprintf("- **Healthy**: %t\n", clone.Healthy)
+	if clone.Error != "" {
+		response += fmt.Sprintf("- **Error**: %s\n", clone.Error)
+	}
+
+	response += "\n## Next Steps\n"
+	if clone.Healthy {
+		response += fmt.Sprintf("- Use `fly_status` to see the new machine serving '%s'\n", appName)
+	} else {
+		response += fmt.Sprintf("- Use `fly_logs` with machine_id `%s` to see why it is not healthy\n", clone.MachineID)
+		response += "- The machine was kept; destroy it with `flyctl machine destroy` if it is not needed\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: !clone.Healthy,
+	}
+}
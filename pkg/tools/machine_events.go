@@ -0,0 +1,284 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// defaultEventsLimit is the number of most recent events shown by default
+const defaultEventsLimit = 100
+
+// machineEventTypes are the event types the Machines API reports
+var machineEventTypes = []string{"launch", "start", "stop", "exit", "restart", "update", "destroy"}
+
+// MachineEventsTool implements the fly_machine_events MCP tool
+type MachineEventsTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewMachineEventsTool creates a new machine events tool
+func NewMachineEventsTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *MachineEventsTool {
+	return &MachineEventsTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *MachineEventsTool) Name() string {
+	return "fly_machine_events"
+}
+
+// Description returns the tool description
+func (t *MachineEventsTool) Description() string {
+	return "Show a chronological timeline of machine events (launches, starts, stops, exits with exit codes, OOM kills) for one machine or a whole Fly.io application. Useful for diagnosing crashes and restart loops."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *MachineEventsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show events for this machine (all machines if omitted)",
+			},
+			"range": map[string]interface{}{
+				"type":        "string",
+				"description": "How far back to look, e.g. 15m, 1h, 24h, 7d",
+				"default":     "24h",
+			},
+			"types": map[string]interface{}{
+				"type":        "array",
+				"description": "Only show these event types",
+				"items": map[string]interface{}{
+					"type": "string",
+					"enum": machineEventTypes,
+				},
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of most recent events to show",
+				"default":     defaultEventsLimit,
+				"minimum":     1,
+				"maximum":     1000,
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *MachineEventsTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the machine events tool
+func (t *MachineEventsTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	rangeArg := "24h"
+	if r, ok := args["range"].(string); ok && r != "" {
+		rangeArg = r
+	}
+	window, err := parseMetricsRange(rangeArg)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	filter := fly.EventFilter{Since: time.Now().Add(-window)}
+	filter.MachineID, _ = args["machine_id"].(string)
+	if raw, ok := args["types"].([]interface{}); ok {
+		for _, item := range raw {
+			eventType, ok := item.(string)
+			if !ok || !slices.Contains(machineEventTypes, eventType) {
+				return &interfaces.ToolResult{
+					Content: []interfaces.ContentBlock{{
+						Type: "text",
+						Text: fmt.Sprintf("Error: unknown event type %v. Valid types: %v", item, machineEventTypes),
+					}},
+					IsError: true,
+				}, nil
+			}
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+
+	limit := defaultEventsLimit
+	if l, ok := args["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_machine_events").
+		Str("app_name", appName).
+		Str("machine_id", filter.MachineID).
+		Str("range", rangeArg).
+		Msg("Executing machine events tool")
+
+	events, err := t.flyClient.GetMachineEvents(ctx, appName, filter)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "get_machine_events", appName, "failed", map[string]interface{}{
+			"machine_id": filter.MachineID,
+			"error":      err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to retrieve machine events for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "get_machine_events", appName, "success", map[string]interface{}{
+		"machine_id":  filter.MachineID,
+		"event_count": len(events),
+	})
+
+	// Keep the most recent events
+	total := len(events)
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+
+	structured := map[string]interface{}{
+		"app_name": appName,
+		"range":    rangeArg,
+		"total":    total,
+		"events":   events,
+	}
+
+	return out.Render(t.formatTextResponse(appName, rangeArg, filter.MachineID, events, total), fmt.Sprintf("Machine events for application '%s'", appName), structured, appLinks(appName)...), nil
+}
+
+// formatTextResponse formats the event timeline as human-readable text
+func (t *MachineEventsTool) formatTextResponse(appName, rangeArg, machineID string, events []fly.TimelineEvent, total int) *interfaces.ToolResult {
+	var response string
+
+	subject := appName
+	if machineID != "" {
+		subject = fmt.Sprintf("%s/%s", appName, machineID)
+	}
+	response += fmt.Sprintf("# Machine Events: %s (last %s)\n\n", subject, rangeArg)
+
+	if len(events) == 0 {
+		response += "No events in this time range.\n"
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: response,
+			}},
+		}
+	}
+
+	crashes, oomKills := 0, 0
+	for _, e := range events {
+		if e.IsCrash() {
+			crashes++
+		}
+		if e.OOMKilled {
+			oomKills++
+		}
+	}
+
+	response += "## Summary\n"
+	response += fmt.Sprintf("- **Events**: %d", total)
+	if total > len(events) {
+		response += fmt.Sprintf(" (showing the latest %d)", len(events))
+	}
+	response += "\n"
+	response += fmt.Sprintf("- **Unexpected Exits**: %d\n", crashes)
+	if oomKills > 0 {
+		response += fmt.Sprintf("- **OOM Kills**: %d — consider more memory (see `fly_metrics` with `memory_percent`)\n", oomKills)
+	}
+
+	response += "\n## Timeline\n"
+	for _, e := range events {
+		icon := "⚪"
+		switch {
+		case e.OOMKilled:
+			icon = "💥"
+		case e.IsCrash():
+			icon = "❌"
+		case e.Type == "start" || e.Type == "launch":
+			icon = "🟢"
+		case e.Type == "stop" || e.Type == "exit":
+			icon = "🔴"
+		case e.Type == "restart" || e.Type == "update":
+			icon = "🔄"
+		}
+
+		line := fmt.Sprintf("- %s `%s` **%s** %s", icon, e.Time.Format("2006-01-02 15:04:05"), e.Type, e.Status)
+		if machineID == "" {
+			line += fmt.Sprintf(" — %s (%s)", e.MachineID, e.Region)
+		}
+		if e.ExitCode != nil {
+			line += fmt.Sprintf(", exit code %d", *e.ExitCode)
+		}
+		if e.Signal != 0 {
+			line += fmt.Sprintf(", signal %d", e.Signal)
+		}
+		if e.OOMKilled {
+			line += ", **out of memory**"
+		}
+		if e.Source != "" {
+			line += fmt.Sprintf(" [%s]", e.Source)
+		}
+		response += line + "\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
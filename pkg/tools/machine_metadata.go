@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// defaultLeaseTTL is how long a lease is held unless the caller says
+const defaultLeaseTTL = 300
+
+// MachineMetadataTool implements the fly_machine_metadata MCP tool
+type MachineMetadataTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewMachineMetadataTool creates a new machine metadata tool
+func NewMachineMetadataTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *MachineMetadataTool {
+	return &MachineMetadataTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *MachineMetadataTool) Name() string {
+	return "fly_machine_metadata"
+}
+
+// Description returns the tool description
+func (t *MachineMetadataTool) Description() string {
+	return "Read and change a Fly.io machine's metadata and lease, to coordinate with flyctl, CI deploys and other users. 'get' shows the metadata keys and who holds a lease on the machine; 'set' and 'delete' change one metadata key without restarting the machine; 'acquire_lease' takes an exclusive lease so no one else can change the machine until it expires or is released, returning the nonce that 'release_lease' needs."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *MachineMetadataTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "ID of the machine",
+			},
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "Action to perform",
+				"enum":        []string{"get", "set", "delete", "acquire_lease", "release_lease"},
+				"default":     "get",
+			},
+			"key": map[string]interface{}{
+				"type":        "string",
+				"description": "Metadata key, for set and delete: up to 64 letters, digits, '_', '.' or '-'",
+				"pattern":     fly.MetadataKeyPattern.String(),
+			},
+			"value": map[string]interface{}{
+				"type":        "string",
+				"description": "Metadata value, for set",
+			},
+			"ttl_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "How long the lease is held, for acquire_lease",
+				"default":     defaultLeaseTTL,
+				"minimum":     1,
+				"maximum":     int(fly.MaxLeaseTTL.Seconds()),
+			},
+			"description": map[string]interface{}{
+				"type":        "string",
+				"description": "Why the lease is held, shown to others who find the machine leased, for acquire_lease",
+			},
+			"nonce": map[string]interface{}{
+				"type":        "string",
+				"description": "Nonce returned by acquire_lease, for release_lease",
+			},
+		},
+		"required":             []string{"app_name", "machine_id"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *MachineMetadataTool) RequiredPermission() (string, string) {
+	return "deploy", "app"
+}
+
+// ReadOnlyCall reports whether a call only reads; only get does
+func (t *MachineMetadataTool) ReadOnlyCall(args map[string]interface{}) bool {
+	action, _ := args["action"].(string)
+	return action == "" || action == "get"
+}
+
+// Execute executes the machine metadata tool
+func (t *MachineMetadataTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Reading the metadata only needs read access; changes need deploy
+	permAction, permResource := "read", "app"
+	if !t.ReadOnlyCall(args) {
+		permAction, permResource = t.RequiredPermission()
+	}
+	if err := t.authManager.ValidateRequest(ctx, permAction, permResource); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+	machineID, ok := args["machine_id"].(string)
+	if !ok || machineID == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: machine_id is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	action := "get"
+	if a, ok := args["action"].(string); ok && a != "" {
+		action = a
+	}
+	key, _ := args["key"].(string)
+	nonce, _ := args["nonce"].(string)
+	switch {
+	case (action == "set" || action == "delete") && key == "":
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: key is required for the %s action", action),
+			}},
+			IsError: true,
+		}, nil
+	case (action == "set" || action == "delete") && !fly.MetadataKeyPattern.MatchString(key):
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: invalid metadata key %q: use up to 64 letters, digits, '_', '.' or '-'", key),
+			}},
+			IsError: true,
+		}, nil
+	case action == "release_lease" && nonce == "":
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: nonce is required for the release_lease action; it is returned by acquire_lease",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Log the operation
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_machine_metadata").
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Str("action", action).
+		Msg("Executing machine metadata tool")
+
+	out := NewOutputFormatter(ctx, args)
+	title := fmt.Sprintf("Metadata of machine '%s'", machineID)
+
+	var err error
+	var lease *fly.MachineLease
+	details := map[string]interface{}{"machine_id": machineID}
+	switch action {
+	case "get":
+		coordination, err := t.flyClient.GetMachineCoordination(ctx, appName, machineID)
+		if err != nil {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Failed to get metadata of machine '%s': %s", machineID, describeError(err)),
+				}},
+				IsError: true,
+			}, nil
+		}
+		return out.Render(t.formatCoordination(coordination), title, coordination, appLinks(appName)...), nil
+	case "set":
+		value, _ := args["value"].(string)
+		details["key"] = key
+		err = t.flyClient.SetMachineMetadata(ctx, appName, machineID, key, value)
+	case "delete":
+		details["key"] = key
+		err = t.flyClient.DeleteMachineMetadata(ctx, appName, machineID, key)
+	case "acquire_lease":
+		ttl := defaultLeaseTTL * time.Second
+		if v, ok := args["ttl_seconds"].(float64); ok {
+			ttl = time.Duration(v) * time.Second
+		}
+		description, _ := args["description"].(string)
+		details["ttl"] = ttl.Seconds()
+		details["description"] = description
+		lease, err = t.flyClient.AcquireMachineLease(ctx, appName, machineID, ttl, description)
+	case "release_lease":
+		err = t.flyClient.ReleaseMachineLease(ctx, appName, machineID, nonce)
+	default:
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Unknown action: %s. Use 'get', 'set', 'delete', 'acquire_lease' or 'release_lease'", action),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	auditAction := "machine_metadata_" + action
+	if strings.HasSuffix(action, "_lease") {
+		auditAction = "machine_" + action
+	}
+	if err != nil {
+		details["error"] = err.Error()
+		t.authManager.AuditLog(ctx, userID, auditAction, appName, "failed", details)
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **%s Failed**\n\nMachine '%s' of app '%s': %s", actionTitle(action), machineID, appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+	t.authManager.AuditLog(ctx, userID, auditAction, appName, "success", details)
+
+	var response string
+	switch action {
+	case "set":
+		response = fmt.Sprintf("✅ **Metadata Set**\n\nSet `%s` on machine `%s`. The machine was not restarted.\n", key, machineID)
+	case "delete":
+		response = fmt.Sprintf("✅ **Metadata Deleted**\n\nRemoved `%s` from machine `%s`.\n", key, machineID)
+	case "acquire_lease":
+		response = fmt.Sprintf("🔒 **Lease Acquired**\n\nMachine `%s` is leased until %s.\n\n", machineID, lease.Expires().UTC().Format(time.RFC3339))
+		response += fmt.Sprintf("- **Nonce**: `%s`\n", lease.Nonce)
+		response += fmt.Sprintf("- **Owner**: %s\n", lease.Owner)
+		response += "\nflyctl, CI deploys and other fly-mcp users cannot change the machine until the lease expires. "
+		response += "Keep the nonce and release the lease with `release_lease` when you are done.\n"
+	case "release_lease":
+		response = fmt.Sprintf("🔓 **Lease Released**\n\nMachine `%s` can be changed by others again.\n", machineID)
+	}
+
+	result := &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+	data := map[string]interface{}{
+		"appName":   appName,
+		"machineId": machineID,
+		"action":    action,
+	}
+	if lease != nil {
+		data["lease"] = lease
+	}
+	return out.Render(result, title, data, appLinks(appName)...), nil
+}
+
+// formatCoordination formats a machine's metadata and lease
+func (t *MachineMetadataTool) formatCoordination(c *fly.MachineCoordination) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Machine %s (%s)\n\n", c.MachineID, c.AppName)
+
+	response += "## Lease\n"
+	if c.Lease == nil || c.Lease.Expires().Before(time.Now()) {
+		response += "🔓 No lease is held; anyone with access can change the machine\n"
+	} else {
+		response += fmt.Sprintf("🔒 **Leased by %s** until %s\n", c.Lease.Owner, c.Lease.Expires().UTC().Format(time.RFC3339))
+		if c.Lease.Description != "" {
+			response += fmt.Sprintf("- **Description**: %s\n", c.Lease.Description)
+		}
+		response += "- Changes to the machine fail until the lease expires or its holder releases it\n"
+	}
+
+	response += "\n## Metadata\n"
+	if len(c.Metadata) == 0 {
+		response += "No metadata keys are set\n"
+	} else {
+		response += "| Key | Value |\n"
+		response += "|-----|-------|\n"
+		// Values are free text; keep each on its row of the table
+		cell := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "|", "\\|")
+		for _, key := range slices.Sorted(maps.Keys(c.Metadata)) {
+			response += fmt.Sprintf("| `%s` | %s |\n", key, cell.Replace(c.Metadata[key]))
+		}
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// actionTitle turns an action such as acquire_lease into a title
+func actionTitle(action string) string {
+	words := strings.Split(action, "_")
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// formatLeaseWarning warns that machines a change is about to touch are
+// leased by someone else, such as a flyctl or CI deploy in progress
+func formatLeaseWarning(leases map[string]*fly.MachineLease) string {
+	if len(leases) == 0 {
+		return ""
+	}
+
+	warning := "\n⚠️ **Leased machines**: another deploy or tool may be changing them, and changes to them will fail until the lease is released or expires:\n"
+	for _, machineID := range slices.Sorted(maps.Keys(leases)) {
+		lease := leases[machineID]
+		warning += fmt.Sprintf("- `%s` by %s until %s", machineID, lease.Owner, lease.Expires().UTC().Format(time.RFC3339))
+		if lease.Description != "" {
+			warning += fmt.Sprintf(" (%s)", lease.Description)
+		}
+		warning += "\n"
+	}
+	return warning
+}
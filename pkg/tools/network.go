@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// NetworkTool implements the fly_network MCP tool
+type NetworkTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewNetworkTool creates a new private networking tool
+func NewNetworkTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *NetworkTool {
+	return &NetworkTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *NetworkTool) Name() string {
+	return "fly_network"
+}
+
+// Description returns the tool description
+func (t *NetworkTool) Description() string {
+	return "Show a Fly.io application's private networking (6PN): machine IPv6 addresses, .internal DNS names, Flycast addresses and which apps in the organization can reach it."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *NetworkTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *NetworkTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the network tool
+func (t *NetworkTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_network").
+		Str("app_name", appName).
+		Msg("Executing network tool")
+
+	network, err := t.flyClient.GetAppNetwork(ctx, appName)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "get_app_network", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to retrieve network details for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "get_app_network", appName, "success", map[string]interface{}{
+		"network": network.Network,
+	})
+
+	return out.Render(t.formatTextResponse(network), fmt.Sprintf("Private networking for application '%s'", appName), network, appLinks(appName)...), nil
+}
+
+// formatTextResponse formats the network details as human-readable text
+func (t *NetworkTool) formatTextResponse(network *fly.AppNetwork) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Private Networking: %s\n\n", network.AppName)
+
+	response += "## Overview\n"
+	if network.Organization != "" {
+		response += fmt.Sprintf("- **Organization**: %s\n", network.Organization)
+	}
+	response += fmt.Sprintf("- **Network**: %s\n", network.Network)
+	response += fmt.Sprintf("- **Machines**: %d\n", len(network.Machines))
+	for _, ip := range network.FlycastIPs {
+		response += fmt.Sprintf("- **Flycast**: %s (`%s.flycast`)\n", ip, network.AppName)
+	}
+
+	if len(network.Machines) > 0 {
+		response += "\n## Machine Addresses\n"
+		for _, m := range network.Machines {
+			icon := "🟢"
+			if m.State != "started" {
+				icon = "🔴"
+			}
+			response += fmt.Sprintf("- %s **%s** (%s, %s): `%s` — `%s`\n", icon, m.MachineID, m.Region, m.State, m.PrivateIP, m.DNSName)
+		}
+	}
+
+	response += "\n## .internal DNS Names\n"
+	for _, name := range network.DNSNames {
+		response += fmt.Sprintf("- `%s`: %s\n", name.Name, name.Description)
+	}
+
+	response += "\n## Reachable From\n"
+	if len(network.Reachable) == 0 {
+		response += fmt.Sprintf("No other apps share the `%s` network.\n", network.Network)
+	} else {
+		for _, peer := range network.Reachable {
+			response += fmt.Sprintf("- **%s** (%d machine(s))\n", peer.Name, peer.MachineCount)
+		}
+	}
+
+	if len(network.Isolated) > 0 {
+		response += "\n## Isolated Apps\n"
+		response += "These apps are in the organization but on another network and cannot reach this app over 6PN:\n"
+		for _, peer := range network.Isolated {
+			response += fmt.Sprintf("- **%s** (network `%s`)\n", peer.Name, peer.Network)
+		}
+	}
+
+	if len(network.Warnings) > 0 {
+		response += "\n## Warnings\n"
+		for _, w := range network.Warnings {
+			response += fmt.Sprintf("- ⚠️ %s\n", w)
+		}
+	}
+
+	response += "\n## Troubleshooting\n"
+	response += "- `.internal` names only resolve to started machines; stopped machines have no AAAA record\n"
+	response += "- Services must listen on IPv6 (`[::]` or `fly-local-6pn`), not just `0.0.0.0`, to accept 6PN traffic\n"
+	response += "- Use Flycast (`<app>.flycast`) instead of `.internal` when machines should be started on demand by the proxy\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
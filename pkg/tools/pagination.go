@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cursorPrefix versions the opaque cursor format
+const cursorPrefix = "offset:"
+
+// Page is one page of a list
+type Page struct {
+	Start      int
+	End        int
+	NextCursor string
+}
+
+// Paginate selects the page of a list of total items that starts at cursor.
+// An empty cursor starts at the beginning; NextCursor is empty on the last
+// page. Cursors are opaque to clients and only valid for the same list.
+func Paginate(total, pageSize int, cursor string) (Page, error) {
+	start := 0
+	if cursor != "" {
+		offset, err := decodeCursor(cursor)
+		if err != nil || offset > total {
+			return Page{}, fmt.Errorf("invalid cursor: %s", cursor)
+		}
+		start = offset
+	}
+
+	end := total
+	if pageSize > 0 && start+pageSize < total {
+		end = start + pageSize
+	}
+
+	page := Page{Start: start, End: end}
+	if end < total {
+		page.NextCursor = encodeCursor(end)
+	}
+	return page, nil
+}
+
+// encodeCursor returns the cursor of the item at offset
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset a cursor points at
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := strings.CutPrefix(string(raw), cursorPrefix)
+	if !ok {
+		return 0, fmt.Errorf("unknown cursor format")
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor offset")
+	}
+	return offset, nil
+}
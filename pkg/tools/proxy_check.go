@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+const (
+	// defaultProbeTimeout is the time each probe request may take
+	defaultProbeTimeout = 10
+	maxProbeTimeout     = 30
+
+	// certificateWarningDays is how close to expiry a certificate is flagged
+	certificateWarningDays = 14
+
+	// slowProbeMS is the latency above which a probe is flagged as slow
+	slowProbeMS = 1000
+)
+
+// ProxyCheckTool implements the fly_proxy_check MCP tool
+type ProxyCheckTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewProxyCheckTool creates a new synthetic probe tool
+func NewProxyCheckTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *ProxyCheckTool {
+	return &ProxyCheckTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *ProxyCheckTool) Name() string {
+	return "fly_proxy_check"
+}
+
+// Description returns the tool description
+func (t *ProxyCheckTool) Description() string {
+	return "Check whether a Fly.io application is reachable from the internet: sends HTTPS requests to its public hostname, once to the nearest region and once to each region it runs in (via Fly-Prefer-Region), and reports status codes, latency and TLS certificate expiry."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *ProxyCheckTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to probe",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to request, e.g. /healthz",
+				"default":     "/",
+			},
+			"regions": map[string]interface{}{
+				"type":        "array",
+				"description": "Regions to probe (defaults to every region the app has machines in)",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+				"maxItems": 20,
+			},
+			"timeout_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "Time each request may take",
+				"default":     defaultProbeTimeout,
+				"minimum":     1,
+				"maximum":     maxProbeTimeout,
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *ProxyCheckTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the probe tool
+func (t *ProxyCheckTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	req := fly.ProbeRequest{Path: "/", Timeout: defaultProbeTimeout * time.Second}
+	if p, ok := args["path"].(string); ok && p != "" {
+		req.Path = p
+	}
+	if v, ok := args["timeout_seconds"].(float64); ok && v >= 1 {
+		req.Timeout = time.Duration(min(int(v), maxProbeTimeout)) * time.Second
+	}
+	if raw, ok := args["regions"].([]interface{}); ok {
+		for _, r := range raw {
+			if region, ok := r.(string); ok && region != "" {
+				req.Regions = append(req.Regions, region)
+			}
+		}
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_proxy_check").
+		Str("app_name", appName).
+		Str("path", req.Path).
+		Msg("Executing proxy check tool")
+
+	report, err := t.flyClient.ProbeApp(ctx, appName, req)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "probe_app", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to probe app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	failed := 0
+	for _, r := range report.Results {
+		if !r.OK() {
+			failed++
+		}
+	}
+	t.authManager.AuditLog(ctx, userID, "probe_app", appName, "success", map[string]interface{}{
+		"url":    report.URL,
+		"probes": len(report.Results),
+		"failed": failed,
+	})
+
+	return out.Render(t.formatTextResponse(report, failed), fmt.Sprintf("Probe results for application '%s'", appName), report, appLinks(appName)...), nil
+}
+
+// formatTextResponse formats the probe results as human-readable text
+func (t *ProxyCheckTool) formatTextResponse(report *fly.ProbeReport, failed int) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Reachability: %s\n\n", report.AppName)
+	response += fmt.Sprintf("- **URL**: %s\n", report.URL)
+	response += fmt.Sprintf("- **Probes**: %d (%d failed)\n", len(report.Results), failed)
+
+	switch {
+	case failed == 0:
+		response += "\n🟢 **Reachable from every probed region**\n"
+	case failed == len(report.Results):
+		response += "\n🔴 **Not reachable**\n"
+	default:
+		response += "\n🟠 **Reachable only from some regions**\n"
+	}
+
+	response += "\n## Results\n"
+	response += "| Region | Edge | Status | Latency |\n"
+	response += "|--------|------|--------|---------|\n"
+	for _, r := range report.Results {
+		region := r.Region
+		if region == "" {
+			region = "nearest"
+		}
+		edge := r.Edge
+		if edge == "" {
+			edge = "-"
+		}
+
+		status := fmt.Sprintf("%d", r.StatusCode)
+		switch {
+		case r.Error != "":
+			status = "❌ " + truncateOutput(r.Error, 80)
+		case r.StatusCode >= 500:
+			status = "❌ " + status
+		case r.StatusCode >= 300 && r.StatusCode < 400 && r.Location != "":
+			status += " → " + r.Location
+		}
+
+		latency := fmt.Sprintf("%d ms", r.LatencyMS)
+		if r.LatencyMS > slowProbeMS {
+			latency = "🐢 " + latency
+		}
+		response += fmt.Sprintf("| %s | %s | %s | %s |\n", region, edge, status, latency)
+	}
+
+	if cert := report.Certificate; cert != nil {
+		response += "\n## TLS Certificate\n"
+		response += fmt.Sprintf("- **Subject**: %s\n", cert.Subject)
+		response += fmt.Sprintf("- **Issuer**: %s\n", cert.Issuer)
+		response += fmt.Sprintf("- **Expires**: %s (%d days)\n", cert.NotAfter.UTC().Format("2006-01-02"), cert.DaysLeft)
+		if cert.DaysLeft < certificateWarningDays {
+			response += "- ⚠️ The certificate expires soon. Fly.io renews managed certificates automatically; if it is not renewing, check the hostname's DNS records.\n"
+		}
+	}
+
+	if failed > 0 {
+		response += "\n## Suggested Actions\n"
+		response += "- Use `fly_doctor` to look for crashing or unhealthy machines\n"
+		response += "- Use `fly_checks` to see whether the machines pass their health checks\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// resourceScheme prefixes the URIs of resources served by fly-mcp
+const resourceScheme = "fly://apps/"
+
+// OrgActivityResourceURI is the resource URI of the organization's
+// activity feed
+const OrgActivityResourceURI = "fly://org/activity"
+
+// ReportsLatestResourceURI is the resource URI of the latest scheduled
+// status report
+const ReportsLatestResourceURI = "fly://reports/latest"
+
+// WatcherResourceURI is the resource URI of the machine watcher's states
+// and recent transitions
+const WatcherResourceURI = "fly://watcher/machines"
+
+// Resource kinds addressed by fly:// URIs
+const (
+	ResourceApp         = "app"
+	ResourceMachines    = "machines"
+	ResourcePipeline    = "pipeline"
+	ResourceOrgActivity = "org_activity"
+	ResourceReport      = "report"
+	ResourceWatcher     = "watcher"
+)
+
+// AppResourceURI returns the resource URI of an application
+func AppResourceURI(appName string) string {
+	return resourceScheme + url.PathEscape(appName)
+}
+
+// MachinesResourceURI returns the resource URI of an application's machines
+func MachinesResourceURI(appName string) string {
+	return AppResourceURI(appName) + "/machines"
+}
+
+// PipelineResourceURI returns the resource URI of the CI events posted
+// about an application
+func PipelineResourceURI(appName string) string {
+	return AppResourceURI(appName) + "/pipeline"
+}
+
+// ParseResourceURI splits a fly:// resource URI into the application name
+// and the kind of resource it addresses. Organization resources have no
+// application name.
+func ParseResourceURI(uri string) (appName, kind string, err error) {
+	switch uri {
+	case OrgActivityResourceURI:
+		return "", ResourceOrgActivity, nil
+	case ReportsLatestResourceURI:
+		return "", ResourceReport, nil
+	case WatcherResourceURI:
+		return "", ResourceWatcher, nil
+	}
+
+	rest, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return "", "", fmt.Errorf("unsupported resource URI: %s", uri)
+	}
+
+	parts := strings.Split(rest, "/")
+	appName, err = url.PathUnescape(parts[0])
+	if err != nil || appName == "" {
+		return "", "", fmt.Errorf("invalid application in resource URI: %s", uri)
+	}
+
+	switch {
+	case len(parts) == 1:
+		return appName, ResourceApp, nil
+	case len(parts) == 2 && parts[1] == "machines":
+		return appName, ResourceMachines, nil
+	case len(parts) == 2 && parts[1] == "pipeline":
+		return appName, ResourcePipeline, nil
+	}
+	return "", "", fmt.Errorf("unknown resource: %s", uri)
+}
+
+// appLinks returns resource links to an application and its machines
+func appLinks(appName string) []interfaces.ContentBlock {
+	return []interfaces.ContentBlock{
+		interfaces.ResourceLink(AppResourceURI(appName), appName, fmt.Sprintf("Application %s", appName)),
+		interfaces.ResourceLink(MachinesResourceURI(appName), appName+" machines", fmt.Sprintf("Machines of application %s", appName)),
+	}
+}
+
+// withStructuredContent attaches data to a successful result as structured
+// content, alongside the narrative text, and appends resource links
+func withStructuredContent(result *interfaces.ToolResult, data interface{}, links ...interfaces.ContentBlock) *interfaces.ToolResult {
+	if result == nil || result.IsError {
+		return result
+	}
+	result.StructuredContent = data
+	result.Content = append(result.Content, links...)
+	return result
+}
@@ -0,0 +1,434 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// ScheduledTasksTool implements the fly_scheduled_tasks MCP tool
+type ScheduledTasksTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewScheduledTasksTool creates a new scheduled tasks tool
+func NewScheduledTasksTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *ScheduledTasksTool {
+	return &ScheduledTasksTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *ScheduledTasksTool) Name() string {
+	return "fly_scheduled_tasks"
+}
+
+// Description returns the tool description
+func (t *ScheduledTasksTool) Description() string {
+	return "List, create or delete cron-like scheduled tasks of a Fly.io application. A task is a machine the platform runs hourly, daily, weekly or monthly, by default with the app's current image and a custom command. Deleting a task is previewed first and needs the returned confirmation token."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *ScheduledTasksTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "Action to perform: 'list' the tasks, 'create' a task or 'delete' one",
+				"enum":        []string{"list", "create", "delete"},
+				"default":     "list",
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the task's machine, e.g. nightly-cleanup (for create)",
+			},
+			"schedule": map[string]interface{}{
+				"type":        "string",
+				"description": "How often the task runs (for create)",
+				"enum":        fly.ScheduleIntervals,
+			},
+			"command": map[string]interface{}{
+				"type":        "array",
+				"description": "Command to run, e.g. [\"bin/rails\", \"cleanup:run\"] (for create; defaults to the image's command)",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"image": map[string]interface{}{
+				"type":        "string",
+				"description": "Image to run (for create; defaults to the image the app's machines run)",
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Region to run in (for create; defaults to the region of the app's first machine)",
+			},
+			"env": map[string]interface{}{
+				"type":        "object",
+				"description": "Environment variables for the task (for create)",
+				"additionalProperties": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"cpu_kind": map[string]interface{}{
+				"type":        "string",
+				"description": "CPU kind (for create)",
+				"enum":        []string{"shared", "performance"},
+				"default":     "shared",
+			},
+			"cpus": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of CPUs (for create)",
+				"minimum":     1,
+				"maximum":     16,
+				"default":     1,
+			},
+			"memory_mb": map[string]interface{}{
+				"type":        "integer",
+				"description": "Memory in MB (for create)",
+				"minimum":     256,
+				"maximum":     65536,
+				"default":     256,
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Machine of the task to delete (for delete)",
+			},
+			"confirmation_token": confirmationTokenProperty(),
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *ScheduledTasksTool) RequiredPermission() (string, string) {
+	return "schedule", "machine"
+}
+
+// ReadOnlyCall reports whether a call only lists tasks
+func (t *ScheduledTasksTool) ReadOnlyCall(args map[string]interface{}) bool {
+	action, _ := args["action"].(string)
+	return action != "create" && action != "delete"
+}
+
+// Execute executes the scheduled tasks tool
+func (t *ScheduledTasksTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	action := "list"
+	if a, ok := args["action"].(string); ok {
+		action = a
+	}
+
+	// Listing only needs read access; creating and deleting change machines
+	permAction, permResource := "read", "app"
+	if action == "create" || action == "delete" {
+		permAction, permResource = t.RequiredPermission()
+	}
+	if err := t.authManager.ValidateRequest(ctx, permAction, permResource); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_scheduled_tasks").
+		Str("app_name", appName).
+		Str("action", action).
+		Msg("Executing scheduled tasks tool")
+
+	switch action {
+	case "list":
+		return t.list(ctx, userID, appName, out)
+	case "create":
+		return t.create(ctx, userID, appName, args, out)
+	case "delete":
+		return t.delete(ctx, userID, appName, args)
+	default:
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Unknown action: %s. Use 'list', 'create' or 'delete'", action),
+			}},
+			IsError: true,
+		}, nil
+	}
+}
+
+// list reports the app's scheduled tasks
+func (t *ScheduledTasksTool) list(ctx context.Context, userID, appName string, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	tasks, err := t.flyClient.ListScheduledTasks(ctx, appName)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "list_scheduled_tasks", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to list scheduled tasks for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "list_scheduled_tasks", appName, "success", map[string]interface{}{
+		"task_count": len(tasks),
+	})
+
+	var response string
+
+	response += fmt.Sprintf("# Scheduled Tasks: %s\n\n", appName)
+
+	if len(tasks) == 0 {
+		response += "This app has no scheduled tasks. Use `action: create` with a `schedule` and `command` to add one.\n"
+	} else {
+		now := time.Now()
+		response += "| Task | Machine | Schedule | Region | State | Last Run | Exit |\n"
+		response += "|------|---------|----------|--------|-------|----------|------|\n"
+		for _, task := range tasks {
+			lastRun, exit := "never", "-"
+			if task.LastRunAt != nil {
+				lastRun = formatAge(now.Sub(*task.LastRunAt)) + " ago"
+			}
+			if task.LastExitCode != nil {
+				exit = fmt.Sprintf("%d", *task.LastExitCode)
+				if *task.LastExitCode != 0 {
+					exit = "❌ " + exit
+				}
+			}
+			response += fmt.Sprintf("| %s | `%s` | %s | %s | %s | %s | %s |\n",
+				task.Name, task.MachineID, task.Schedule, task.Region, task.State, lastRun, exit)
+		}
+
+		response += "\n## Commands\n"
+		for _, task := range tasks {
+			command := "(image default)"
+			if len(task.Command) > 0 {
+				command = "`" + strings.Join(task.Command, " ") + "`"
+			}
+			response += fmt.Sprintf("- **%s**: %s on `%s`\n", task.Name, command, task.Image)
+		}
+	}
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("Scheduled tasks for application '%s'", appName), map[string]interface{}{"tasks": tasks}, appLinks(appName)...), nil
+}
+
+// create adds a scheduled task
+func (t *ScheduledTasksTool) create(ctx context.Context, userID, appName string, args map[string]interface{}, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	req := fly.ScheduledTaskRequest{}
+	req.Name, _ = args["name"].(string)
+	req.Schedule, _ = args["schedule"].(string)
+	req.Image, _ = args["image"].(string)
+	req.Region, _ = args["region"].(string)
+	req.CPUKind, _ = args["cpu_kind"].(string)
+	if v, ok := args["cpus"].(float64); ok {
+		req.CPUs = int(v)
+	}
+	if v, ok := args["memory_mb"].(float64); ok {
+		req.MemoryMB = int(v)
+	}
+	if raw, ok := args["command"].([]interface{}); ok {
+		for _, part := range raw {
+			if s, ok := part.(string); ok {
+				req.Command = append(req.Command, s)
+			}
+		}
+	}
+	if raw, ok := args["env"].(map[string]interface{}); ok {
+		req.Env = make(map[string]string, len(raw))
+		for name, value := range raw {
+			if s, ok := value.(string); ok {
+				req.Env[name] = s
+			}
+		}
+	}
+
+	if req.Schedule == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: create requires a schedule (hourly, daily, weekly or monthly)",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	task, err := t.flyClient.CreateScheduledTask(ctx, appName, req)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "create_scheduled_task", appName, "failed", map[string]interface{}{
+			"name":     req.Name,
+			"schedule": req.Schedule,
+			"error":    err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Create Failed**\n\nFailed to create scheduled task for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "create_scheduled_task", appName, "success", map[string]interface{}{
+		"machine_id": task.MachineID,
+		"name":       task.Name,
+		"schedule":   task.Schedule,
+		"region":     task.Region,
+	})
+
+	var response string
+
+	response += "✅ **Scheduled Task Created**\n\n"
+	response += fmt.Sprintf("- **Name**: %s\n", task.Name)
+	response += fmt.Sprintf("- **Machine**: %s\n", task.MachineID)
+	response += fmt.Sprintf("- **Schedule**: %s\n", task.Schedule)
+	response += fmt.Sprintf("- **Region**: %s\n", task.Region)
+	response += fmt.Sprintf("- **Image**: %s\n", task.Image)
+	if len(task.Command) > 0 {
+		response += fmt.Sprintf("- **Command**: `%s`\n", strings.Join(task.Command, " "))
+	}
+
+	response += "\n## Next Steps\n"
+	response += "- The task runs on its schedule and is not restarted when the command exits\n"
+	response += "- Use `action: list` to see when it last ran and its exit code\n"
+	response += "- Use `fly_machine_events` with the machine ID to investigate failed runs\n"
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("Created scheduled task for application '%s'", appName), task, appLinks(appName)...), nil
+}
+
+// delete removes a scheduled task after confirmation
+func (t *ScheduledTasksTool) delete(ctx context.Context, userID, appName string, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	machineID, _ := args["machine_id"].(string)
+	if machineID == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: delete requires machine_id. Use `action: list` to find it.",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	scope := map[string]interface{}{"app_name": appName, "machine_id": machineID}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		return t.preview(ctx, appName, machineID, scope), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_scheduled_tasks", token, scope); result != nil {
+		return result, nil
+	}
+
+	task, err := t.flyClient.DeleteScheduledTask(ctx, appName, machineID)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "delete_scheduled_task", appName, "failed", map[string]interface{}{
+			"machine_id": machineID,
+			"error":      err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Delete Failed**\n\nFailed to delete scheduled task %s: %s", machineID, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "delete_scheduled_task", appName, "success", map[string]interface{}{
+		"machine_id": machineID,
+		"name":       task.Name,
+		"schedule":   task.Schedule,
+	})
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: fmt.Sprintf("✅ **Scheduled Task Deleted**\n\nThe %s task **%s** (`%s`) of app '%s' was destroyed and will not run again.", task.Schedule, task.Name, machineID, appName),
+		}},
+	}, nil
+}
+
+// preview describes the task about to be deleted and issues its confirmation
+// token
+func (t *ScheduledTasksTool) preview(ctx context.Context, appName, machineID string, scope map[string]interface{}) *interfaces.ToolResult {
+	tasks, err := t.flyClient.ListScheduledTasks(ctx, appName)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to list scheduled tasks for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}
+	}
+
+	var task *fly.ScheduledTask
+	for i := range tasks {
+		if tasks[i].MachineID == machineID {
+			task = &tasks[i]
+		}
+	}
+	if task == nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: machine %s is not a scheduled task of app '%s'. Use `action: list` to see the tasks.", machineID, appName),
+			}},
+			IsError: true,
+		}
+	}
+
+	summary := fmt.Sprintf("- **Application**: %s\n", appName)
+	summary += fmt.Sprintf("- **Task**: %s (`%s`), runs %s in %s\n", task.Name, task.MachineID, task.Schedule, task.Region)
+	if len(task.Command) > 0 {
+		summary += fmt.Sprintf("- **Command**: `%s`\n", strings.Join(task.Command, " "))
+	}
+	summary += "- **Impact**: the task's machine is destroyed and the task stops running"
+
+	return requestConfirmation(ctx, t.authManager, "fly_scheduled_tasks", "Delete Task", appName, scope, summary)
+}
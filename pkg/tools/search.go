@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+const (
+	// defaultSearchLimit is the number of hits returned by default
+	defaultSearchLimit = 25
+	// maxSearchLimit caps the number of hits a search can return
+	maxSearchLimit = 100
+)
+
+// SearchTool implements the fly_search MCP tool
+type SearchTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewSearchTool creates a new search tool
+func NewSearchTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *SearchTool {
+	return &SearchTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *SearchTool) Name() string {
+	return "fly_search"
+}
+
+// Description returns the tool description
+func (t *SearchTool) Description() string {
+	return "Search the organization's infrastructure in one call: matches a query against app names, hostnames, machine IDs and names, regions and image names (e.g. \"myimage:1.2\"), and returns ranked hits with links to the apps and machines found. Matching ignores case; exact matches rank first, then prefixes, then substrings."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *SearchTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to look for, e.g. an app name, a machine ID, a region such as ord or an image such as myimage:1.2",
+			},
+			"kinds": map[string]interface{}{
+				"type":        "array",
+				"description": "Kinds of resources to search (default: all)",
+				"items": map[string]interface{}{
+					"type": "string",
+					"enum": fly.SearchKinds,
+				},
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of hits to return",
+				"default":     defaultSearchLimit,
+				"minimum":     1,
+				"maximum":     maxSearchLimit,
+			},
+		},
+		"required":             []string{"query"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *SearchTool) RequiredPermission() (string, string) {
+	return "read", "apps"
+}
+
+// Execute executes the search tool
+func (t *SearchTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "apps"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	query, ok := args["query"].(string)
+	if !ok || query == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: query is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	var kinds []string
+	if list, ok := args["kinds"].([]interface{}); ok {
+		for _, item := range list {
+			kind, _ := item.(string)
+			if !slices.Contains(fly.SearchKinds, kind) {
+				return &interfaces.ToolResult{
+					Content: []interfaces.ContentBlock{{
+						Type: "text",
+						Text: fmt.Sprintf("Error: unknown kind %v; use one of %v", item, fly.SearchKinds),
+					}},
+					IsError: true,
+				}, nil
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	limit := defaultSearchLimit
+	if l, ok := args["limit"].(float64); ok && l >= 1 {
+		limit = min(int(l), maxSearchLimit)
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_search").
+		Str("query", query).
+		Strs("kinds", kinds).
+		Msg("Executing search tool")
+
+	// Apps that a policy hides from the caller are left out of the search
+	results, err := t.flyClient.Search(ctx, fly.SearchRequest{Query: query, Kinds: kinds, Limit: limit}, func(name string) bool {
+		return t.authManager.EvaluatePolicy(ctx, "read", name) == nil
+	})
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "search", "apps", "failed", map[string]interface{}{
+			"query": query,
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to search Fly.io: %s", describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "search", "apps", "success", map[string]interface{}{
+		"query": query,
+		"hits":  results.TotalHits,
+	})
+
+	return out.Render(t.formatTextResponse(results), fmt.Sprintf("%d matches for %q", results.TotalHits, query), results, searchLinks(results.Hits)...), nil
+}
+
+// searchLinks returns resource links to the apps found, and to the machines
+// of apps with machine hits
+func searchLinks(hits []fly.SearchHit) []interfaces.ContentBlock {
+	var links []interfaces.ContentBlock
+	seen := make(map[string]bool)
+	for _, hit := range hits {
+		if !seen[hit.AppName] {
+			seen[hit.AppName] = true
+			links = append(links, interfaces.ResourceLink(AppResourceURI(hit.AppName), hit.AppName, fmt.Sprintf("Application %s", hit.AppName)))
+		}
+		if hit.Kind == fly.SearchMachine && !seen[hit.AppName+"/machines"] {
+			seen[hit.AppName+"/machines"] = true
+			links = append(links, interfaces.ResourceLink(MachinesResourceURI(hit.AppName), hit.AppName+" machines", fmt.Sprintf("Machines of application %s", hit.AppName)))
+		}
+	}
+	return links
+}
+
+// formatTextResponse formats the search results as human-readable text
+func (t *SearchTool) formatTextResponse(results *fly.SearchResults) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Search: %s\n\n", results.Query)
+
+	if results.TotalHits == 0 {
+		response += fmt.Sprintf("No matches in %d application(s).\n", results.Apps)
+	} else {
+		response += fmt.Sprintf("Found %d match(es) in %d application(s)", results.TotalHits, results.Apps)
+		if results.Truncated {
+			response += fmt.Sprintf(", showing the best %d", len(results.Hits))
+		}
+		response += ":\n\n"
+	}
+
+	for i, hit := range results.Hits {
+		line := fmt.Sprintf("%d. **%s** `%s`", i+1, hit.Kind, hit.Value)
+		if hit.Kind != fly.SearchApp {
+			line += fmt.Sprintf(" in app **%s**", hit.AppName)
+		}
+		if hit.Kind == fly.SearchMachine && hit.Value != hit.MachineID {
+			line += fmt.Sprintf(" (machine %s)", hit.MachineID)
+		}
+		if hit.Detail != "" {
+			line += fmt.Sprintf(" — %s", hit.Detail)
+		}
+		response += line + "\n"
+	}
+
+	if results.Truncated {
+		response += "\nNarrow the query or pass kinds to see fewer, more relevant hits.\n"
+	}
+
+	if len(results.Warnings) > 0 {
+		response += "\n## Warnings\n"
+		for _, w := range results.Warnings {
+			response += fmt.Sprintf("- ⚠️ %s\n", w)
+		}
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
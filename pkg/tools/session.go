@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/session"
+)
+
+// SessionTool implements the fly_session MCP tool
+type SessionTool struct {
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewSessionTool creates a new session context tool
+func NewSessionTool(authManager *auth.Manager, logger *logger.Logger) *SessionTool {
+	return &SessionTool{
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *SessionTool) Name() string {
+	return "fly_session"
+}
+
+// Description returns the tool description
+func (t *SessionTool) Description() string {
+	return "Show, set or clear the session context: the default app used when app_name is omitted, the active organization, and any action awaiting confirmation"
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *SessionTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "Show the session context, set the default app or organization, or clear it",
+				"enum":        []string{"show", "set", "clear"},
+				"default":     "show",
+			},
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Default app for set",
+			},
+			"organization": map[string]interface{}{
+				"type":        "string",
+				"description": "Active organization slug for set",
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// Execute executes the session tool. It needs no permission of its own: it
+// only changes defaults, and every tool still checks its own permission.
+func (t *SessionTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	sess, ok := session.FromContext(ctx)
+	if !ok {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: no active session. Send the %s header returned by initialize to keep context between calls.", session.HeaderName),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	action := "show"
+	if a, ok := args["action"].(string); ok && a != "" {
+		action = a
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_session").
+		Str("action", action).
+		Msg("Executing session tool")
+
+	switch action {
+	case "show":
+	case "set":
+		appName, _ := args["app_name"].(string)
+		organization, _ := args["organization"].(string)
+		if appName == "" && organization == "" {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: "Error: set requires app_name or organization",
+				}},
+				IsError: true,
+			}, nil
+		}
+		if appName != "" {
+			sess.SetDefaultApp(appName)
+		}
+		if organization != "" {
+			sess.SetOrganization(organization)
+		}
+	case "clear":
+		sess.Reset()
+	default:
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: unknown action %q (expected show, set or clear)", action),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	state := sess.State()
+
+	return out.Render(t.formatTextResponse(&state), "Session context", state), nil
+}
+
+// formatTextResponse formats the session context as human-readable text
+func (t *SessionTool) formatTextResponse(state *session.State) *interfaces.ToolResult {
+	var response string
+
+	response += "# Session Context\n\n"
+	response += fmt.Sprintf("- **Session**: %s (started %s ago)\n", state.ID, formatAge(time.Since(state.CreatedAt)))
+
+	if state.DefaultApp != "" {
+		response += fmt.Sprintf("- **Default app**: %s\n", state.DefaultApp)
+	} else {
+		response += "- **Default app**: none\n"
+	}
+	if state.Organization != "" {
+		response += fmt.Sprintf("- **Organization**: %s\n", state.Organization)
+	} else {
+		response += "- **Organization**: none\n"
+	}
+	if state.ProtocolVersion != "" {
+		response += fmt.Sprintf("- **Protocol version**: %s\n", state.ProtocolVersion)
+	}
+
+	if p := state.PendingConfirmation; p != nil {
+		response += fmt.Sprintf("- ⚠️ **Awaiting confirmation**: `%s`", p.Tool)
+		if p.AppName != "" {
+			response += fmt.Sprintf(" on %s", p.AppName)
+		}
+		response += fmt.Sprintf(" (requested %s ago)\n", formatAge(time.Since(p.RequestedAt)))
+	}
+
+	response += "\nTools that need an app use the default app when `app_name` is omitted. It follows the last app you worked with.\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// notePendingConfirmation records in the caller's session that a destructive
+// tool call was previewed and awaits confirmation
+func notePendingConfirmation(ctx context.Context, toolName, appName string) {
+	if sess, ok := session.FromContext(ctx); ok {
+		sess.SetPendingConfirmation(toolName, appName)
+	}
+}
@@ -0,0 +1,339 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// SnapshotsTool implements the fly_snapshots MCP tool
+type SnapshotsTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewSnapshotsTool creates a new volume snapshots tool
+func NewSnapshotsTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *SnapshotsTool {
+	return &SnapshotsTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *SnapshotsTool) Name() string {
+	return "fly_snapshots"
+}
+
+// Description returns the tool description
+func (t *SnapshotsTool) Description() string {
+	return "List snapshots of a Fly.io application's volumes with their size and age, or restore a snapshot into a new volume. Restoring never modifies the source volume."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *SnapshotsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "Action to perform: 'list' to show snapshots, 'restore' to create a new volume from a snapshot",
+				"enum":        []string{"list", "restore"},
+				"default":     "list",
+			},
+			"volume_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Volume to list snapshots for (all volumes if omitted), or the source volume for restore",
+			},
+			"snapshot_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Snapshot to restore (for restore)",
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the new volume (defaults to the source volume's name)",
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Region of the new volume (defaults to the source volume's region)",
+			},
+			"size_gb": map[string]interface{}{
+				"type":        "integer",
+				"description": "Size of the new volume in GB (defaults to the source volume's size)",
+				"minimum":     1,
+				"maximum":     500,
+			},
+			"confirmation_token": confirmationTokenProperty(),
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *SnapshotsTool) RequiredPermission() (string, string) {
+	return "restore", "volume"
+}
+
+// ReadOnlyCall reports whether a call only lists snapshots
+func (t *SnapshotsTool) ReadOnlyCall(args map[string]interface{}) bool {
+	action, _ := args["action"].(string)
+	return action != "restore"
+}
+
+// Execute executes the snapshots tool
+func (t *SnapshotsTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	action := "list"
+	if a, ok := args["action"].(string); ok {
+		action = a
+	}
+
+	// Listing only needs read access; restoring creates a volume
+	permAction, permResource := "read", "app"
+	if action == "restore" {
+		permAction, permResource = t.RequiredPermission()
+	}
+	if err := t.authManager.ValidateRequest(ctx, permAction, permResource); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	volumeID, _ := args["volume_id"].(string)
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_snapshots").
+		Str("app_name", appName).
+		Str("action", action).
+		Str("volume_id", volumeID).
+		Msg("Executing snapshots tool")
+
+	switch action {
+	case "list":
+		return t.list(ctx, userID, appName, volumeID, out)
+	case "restore":
+		return t.restore(ctx, userID, appName, volumeID, args, out)
+	default:
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Unknown action: %s. Use 'list' or 'restore'", action),
+			}},
+			IsError: true,
+		}, nil
+	}
+}
+
+// list reports the snapshots of the app's volumes
+func (t *SnapshotsTool) list(ctx context.Context, userID, appName, volumeID string, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	volumes, err := t.flyClient.ListSnapshots(ctx, appName, volumeID)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "list_snapshots", appName, "failed", map[string]interface{}{
+			"volume_id": volumeID,
+			"error":     err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to list snapshots for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "list_snapshots", appName, "success", map[string]interface{}{
+		"volume_id":    volumeID,
+		"volume_count": len(volumes),
+	})
+
+	var response string
+
+	response += fmt.Sprintf("# Volume Snapshots: %s\n\n", appName)
+
+	if len(volumes) == 0 {
+		response += "This app has no volumes.\n"
+	}
+
+	now := time.Now()
+	for _, v := range volumes {
+		response += fmt.Sprintf("## %s (%s)\n", v.Volume.Name, v.Volume.ID)
+		response += fmt.Sprintf("- **Region**: %s\n", v.Volume.Region)
+		response += fmt.Sprintf("- **Size**: %d GB\n", v.Volume.SizeGB)
+		if v.Volume.AttachedMachineID != "" {
+			response += fmt.Sprintf("- **Attached To**: %s\n", v.Volume.AttachedMachineID)
+		}
+
+		if len(v.Snapshots) == 0 {
+			response += "\nNo snapshots yet. Fly.io takes daily snapshots of volumes automatically.\n\n"
+			continue
+		}
+
+		response += "\n| Snapshot | Created | Age | Size | Status |\n"
+		response += "|----------|---------|-----|------|--------|\n"
+		for _, s := range v.Snapshots {
+			response += fmt.Sprintf("| `%s` | %s | %s | %s | %s |\n",
+				s.ID, s.CreatedAt.UTC().Format("2006-01-02 15:04"), formatAge(now.Sub(s.CreatedAt)), formatBytes(s.Size), s.Status)
+		}
+		response += "\n"
+	}
+
+	response += "## Restoring\n"
+	response += "Use `action: restore` with `volume_id` and `snapshot_id` to create a new volume from a snapshot, then attach it to a machine with a `[mounts]` entry.\n"
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("Volume snapshots for application '%s'", appName), map[string]interface{}{"volumes": volumes}, appLinks(appName)...), nil
+}
+
+// restore creates a new volume from a snapshot
+func (t *SnapshotsTool) restore(ctx context.Context, userID, appName, volumeID string, args map[string]interface{}, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	snapshotID, _ := args["snapshot_id"].(string)
+	if volumeID == "" || snapshotID == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: restore requires volume_id and snapshot_id. Use `action: list` to find them.",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	req := fly.RestoreSnapshotRequest{
+		VolumeID:   volumeID,
+		SnapshotID: snapshotID,
+	}
+	req.Name, _ = args["name"].(string)
+	req.Region, _ = args["region"].(string)
+	if v, ok := args["size_gb"].(float64); ok {
+		req.SizeGB = int(v)
+	}
+
+	scope := map[string]interface{}{"app_name": appName, "restore": req}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		summary := fmt.Sprintf("- **Application**: %s\n", appName)
+		summary += fmt.Sprintf("- **Snapshot**: %s of volume %s\n", snapshotID, volumeID)
+		if req.Name != "" {
+			summary += fmt.Sprintf("- **New volume name**: %s\n", req.Name)
+		}
+		if req.Region != "" {
+			summary += fmt.Sprintf("- **Region**: %s\n", req.Region)
+		}
+		if req.SizeGB > 0 {
+			summary += fmt.Sprintf("- **Size**: %d GB\n", req.SizeGB)
+		}
+		summary += "- **Impact**: creates a new, billed volume; the source volume is not changed"
+
+		return requestConfirmation(ctx, t.authManager, "fly_snapshots", "Restore", appName, scope, summary), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_snapshots", token, scope); result != nil {
+		return result, nil
+	}
+
+	volume, err := t.flyClient.RestoreSnapshot(ctx, appName, req)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "restore_snapshot", appName, "failed", map[string]interface{}{
+			"volume_id":   volumeID,
+			"snapshot_id": snapshotID,
+			"error":       err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Restore Failed**\n\nFailed to restore snapshot %s: %s", snapshotID, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "restore_snapshot", appName, "success", map[string]interface{}{
+		"volume_id":     volumeID,
+		"snapshot_id":   snapshotID,
+		"new_volume_id": volume.ID,
+		"region":        volume.Region,
+		"size_gb":       volume.SizeGB,
+	})
+
+	var response string
+
+	response += "✅ **Snapshot Restored**\n\n"
+	response += "## New Volume\n"
+	response += fmt.Sprintf("- **ID**: %s\n", volume.ID)
+	response += fmt.Sprintf("- **Name**: %s\n", volume.Name)
+	response += fmt.Sprintf("- **Region**: %s\n", volume.Region)
+	response += fmt.Sprintf("- **Size**: %d GB\n", volume.SizeGB)
+	response += fmt.Sprintf("- **Restored From**: snapshot `%s` of volume `%s`\n", snapshotID, volumeID)
+
+	response += "\n## Next Steps\n"
+	response += "- The volume is not attached yet; mount it on a machine in the same region to use it\n"
+	response += "- The volume is billed from now on (see `fly_costs`); delete it when no longer needed\n"
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("Restored snapshot %s into a new volume", snapshotID), volume, appLinks(appName)...), nil
+}
+
+// formatAge renders a duration as a short age like "3d" or "5h"
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// formatBytes renders a byte count in human-readable units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,332 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+const (
+	// defaultExecTimeout and maxExecTimeout bound how long a command may run
+	defaultExecTimeout = 30
+	maxExecTimeout     = 300
+
+	// maxExecOutput is the most stdout or stderr returned to the client
+	maxExecOutput = 16 * 1024
+)
+
+// SSHExecTool implements the fly_ssh_exec MCP tool
+type SSHExecTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewSSHExecTool creates a new machine exec tool
+func NewSSHExecTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *SSHExecTool {
+	return &SSHExecTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *SSHExecTool) Name() string {
+	return "fly_ssh_exec"
+}
+
+// Description returns the tool description
+func (t *SSHExecTool) Description() string {
+	return "Run a command inside a Fly.io machine and return its stdout, stderr and exit code. Only commands on the server's allowlist (security.exec_allowed_commands) can be run, and every command is audit logged."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *SSHExecTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Machine to run the command on (defaults to the first started machine)",
+			},
+			"command": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Command and arguments, e.g. [\"df\", \"-h\"]. The command is not run through a shell, so pipes and redirects are not supported.",
+				"minItems":    1,
+			},
+			"timeout": map[string]interface{}{
+				"type":        "integer",
+				"description": "Seconds to wait for the command to finish",
+				"default":     defaultExecTimeout,
+				"minimum":     1,
+				"maximum":     maxExecTimeout,
+			},
+		},
+		"required":             []string{"app_name", "command"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *SSHExecTool) RequiredPermission() (string, string) {
+	return "exec", "machine"
+}
+
+// Execute executes the machine exec tool
+func (t *SSHExecTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "exec", "machine"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	command, err := parseExecCommand(args["command"])
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	timeout := defaultExecTimeout
+	if v, ok := args["timeout"].(float64); ok {
+		timeout = int(v)
+	}
+	if timeout < 1 || timeout > maxExecTimeout {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: timeout must be between 1 and %d seconds", maxExecTimeout),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	machineID, _ := args["machine_id"].(string)
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	commandLine := strings.Join(command, " ")
+
+	// Check the command against the allowlist before touching the machine
+	entry, allowed := t.authManager.AllowCommand(command)
+	if !allowed {
+		t.authManager.AuditLog(ctx, userID, "exec_command", appName, "denied", map[string]interface{}{
+			"machine_id": machineID,
+			"command":    commandLine,
+		})
+
+		response := fmt.Sprintf("Command not allowed: `%s`\n\n", commandLine)
+		if allowlist := t.authManager.ExecAllowlist(); len(allowlist) > 0 {
+			response += "Allowed commands:\n"
+			for _, c := range allowlist {
+				response += fmt.Sprintf("- `%s`\n", c)
+			}
+		} else {
+			response += "No commands are allowed. An operator must add entries to `security.exec_allowed_commands` in the server configuration."
+		}
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: response,
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_ssh_exec").
+		Str("app_name", appName).
+		Str("machine_id", machineID).
+		Str("command", commandLine).
+		Str("allowlist_entry", entry).
+		Msg("Executing machine exec tool")
+
+	if machineID == "" {
+		machineID, err = t.pickMachine(ctx, appName)
+		if err != nil {
+			t.authManager.AuditLog(ctx, userID, "exec_command", appName, "failed", map[string]interface{}{
+				"command": commandLine,
+				"error":   err.Error(),
+			})
+
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Failed to choose a machine for app '%s': %s", appName, describeError(err)),
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	start := time.Now()
+	result, err := t.flyClient.ExecMachine(ctx, appName, machineID, command, time.Duration(timeout)*time.Second)
+	duration := time.Since(start)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "exec_command", appName, "failed", map[string]interface{}{
+			"machine_id": machineID,
+			"command":    commandLine,
+			"error":      err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to run `%s` on machine %s: %s", commandLine, machineID, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "exec_command", appName, "success", map[string]interface{}{
+		"machine_id":  machineID,
+		"command":     commandLine,
+		"exit_code":   result.ExitCode,
+		"duration_ms": duration.Milliseconds(),
+	})
+
+	stdout, stdoutTruncated := capExecOutput(result.Stdout)
+	stderr, stderrTruncated := capExecOutput(result.Stderr)
+
+	var response string
+
+	status := "✅"
+	if result.ExitCode != 0 {
+		status = "❌"
+	}
+	response += fmt.Sprintf("%s **`%s`** on %s/%s\n\n", status, commandLine, appName, machineID)
+	response += fmt.Sprintf("- **Exit Code**: %d\n", result.ExitCode)
+	if result.ExitSignal != 0 {
+		response += fmt.Sprintf("- **Signal**: %d\n", result.ExitSignal)
+	}
+	response += fmt.Sprintf("- **Duration**: %s\n", duration.Round(time.Millisecond))
+
+	if stdout != "" {
+		response += fmt.Sprintf("\n## stdout\n```\n%s\n```\n", strings.TrimRight(stdout, "\n"))
+		if stdoutTruncated {
+			response += fmt.Sprintf("_Output truncated to %d KB._\n", maxExecOutput/1024)
+		}
+	}
+	if stderr != "" {
+		response += fmt.Sprintf("\n## stderr\n```\n%s\n```\n", strings.TrimRight(stderr, "\n"))
+		if stderrTruncated {
+			response += fmt.Sprintf("_Output truncated to %d KB._\n", maxExecOutput/1024)
+		}
+	}
+	if stdout == "" && stderr == "" {
+		response += "\n_The command produced no output._\n"
+	}
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("Command output from machine %s", machineID), execOutput{
+		AppName:         appName,
+		MachineID:       machineID,
+		Command:         command,
+		ExitCode:        result.ExitCode,
+		ExitSignal:      result.ExitSignal,
+		Stdout:          stdout,
+		Stderr:          stderr,
+		StdoutTruncated: stdoutTruncated,
+		StderrTruncated: stderrTruncated,
+		DurationMS:      duration.Milliseconds(),
+	}, appLinks(appName)...), nil
+}
+
+// execOutput is the structured result of a command run in a machine
+type execOutput struct {
+	AppName         string   `json:"appName"`
+	MachineID       string   `json:"machineId"`
+	Command         []string `json:"command"`
+	ExitCode        int      `json:"exitCode"`
+	ExitSignal      int      `json:"exitSignal"`
+	Stdout          string   `json:"stdout"`
+	Stderr          string   `json:"stderr"`
+	StdoutTruncated bool     `json:"stdoutTruncated"`
+	StderrTruncated bool     `json:"stderrTruncated"`
+	DurationMS      int64    `json:"durationMs"`
+}
+
+// pickMachine returns the first started machine of an app
+func (t *SSHExecTool) pickMachine(ctx context.Context, appName string) (string, error) {
+	machines, err := t.flyClient.ListMachines(ctx, appName)
+	if err != nil {
+		return "", err
+	}
+
+	for _, m := range machines {
+		if m.State == "started" {
+			return m.ID, nil
+		}
+	}
+	return "", fmt.Errorf("app has no started machines; start one or pass machine_id")
+}
+
+// parseExecCommand converts the command argument into an argv slice
+func parseExecCommand(raw interface{}) ([]string, error) {
+	items, ok := raw.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("command is required and must be a non-empty array of strings")
+	}
+
+	command := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("command must contain only strings")
+		}
+		command = append(command, s)
+	}
+	if strings.TrimSpace(command[0]) == "" {
+		return nil, fmt.Errorf("command must not start with an empty string")
+	}
+
+	return command, nil
+}
+
+// capExecOutput caps command output at maxExecOutput bytes
+func capExecOutput(s string) (string, bool) {
+	if len(s) <= maxExecOutput {
+		return s, false
+	}
+	return s[:maxExecOutput], true
+}
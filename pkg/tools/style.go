@@ -0,0 +1,248 @@
+package tools
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// Output styles control the decoration of text output
+const (
+	// StyleRich is markdown with emoji status markers
+	StyleRich = "rich"
+	// StylePlain is plain ASCII text: status emoji become tags such as
+	// [OK], markdown syntax is removed and tables are aligned in columns
+	StylePlain = "plain"
+	// StyleMinimal is plain text without heading underlines and without
+	// advisory sections such as next steps
+	StyleMinimal = "minimal"
+)
+
+// OutputStyles lists the supported output styles
+var OutputStyles = []string{StyleRich, StylePlain, StyleMinimal}
+
+// ValidOutputStyle reports whether style is a supported output style
+func ValidOutputStyle(style string) bool {
+	return slices.Contains(OutputStyles, style)
+}
+
+// advisorySections are the headings of sections that suggest what to do
+// next rather than report on the call; the minimal style drops them
+var advisorySections = []string{
+	"fixing denied tools",
+	"monitoring the restart",
+	"next steps",
+	"post-scaling checklist",
+	"quick actions",
+	"restoring",
+	"scaling actions",
+	"scaling recommendations",
+	"status interpretation",
+	"suggested actions",
+	"troubleshooting",
+	"what happens next",
+}
+
+// symbolReplacements spell out the emoji that carry meaning and the
+// typographic characters some terminals cannot show
+var symbolReplacements = strings.NewReplacer(
+	"✅", "[OK]",
+	"❌", "[FAIL]",
+	"⚠", "[WARN]",
+	"ℹ", "[INFO]",
+	"➕", "+",
+	"➖", "-",
+	"→", "->",
+	"—", "-",
+	"…", "...",
+)
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	boldPattern      = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	italicPattern    = regexp.MustCompile(`(^|\s)_([^_\s][^_]*)_([\s.,:;]|$)`)
+	tableRulePattern = regexp.MustCompile(`^\|[\s:|-]+\|$`)
+)
+
+// ApplyOutputStyle rewrites the text content of a tool result in an output
+// style. Structured content is left alone, and so is text inside code
+// blocks, such as command output, apart from the fences around it.
+func ApplyOutputStyle(result *interfaces.ToolResult, style string) *interfaces.ToolResult {
+	if result == nil || style == "" || style == StyleRich {
+		return result
+	}
+
+	content := make([]interfaces.ContentBlock, len(result.Content))
+	for i, block := range result.Content {
+		if block.Type == "text" {
+			block.Text = styleText(block.Text, style)
+		}
+		content[i] = block
+	}
+	styled := *result
+	styled.Content = content
+	return &styled
+}
+
+// styleText rewrites markdown text in the plain or minimal style
+func styleText(text, style string) string {
+	var out []string
+	var table [][]string
+	inCode := false
+	skipLevel := 0
+
+	flushTable := func() {
+		if len(table) > 0 {
+			out = append(out, alignTable(table)...)
+			table = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if skipLevel == 0 {
+				flushTable()
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			if skipLevel == 0 {
+				out = append(out, line)
+			}
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			if skipLevel > 0 && level > skipLevel {
+				continue
+			}
+			skipLevel = 0
+			flushTable()
+
+			title := styleInline(m[2])
+			if style == StyleMinimal && slices.Contains(advisorySections, strings.ToLower(title)) {
+				skipLevel = level
+				continue
+			}
+			out = append(out, styleHeading(title, level, style)...)
+			continue
+		}
+		if skipLevel > 0 {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "|") && strings.HasSuffix(trimmed, "|") && len(trimmed) > 1 {
+			if !tableRulePattern.MatchString(trimmed) {
+				table = append(table, tableCells(trimmed))
+			}
+			continue
+		}
+		flushTable()
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		out = append(out, indent+styleInline(line[len(indent):]))
+	}
+	flushTable()
+
+	if style == StyleMinimal {
+		out = collapseBlankLines(out)
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n") + trailingNewline(text)
+}
+
+// styleHeading renders a markdown heading as plain text
+func styleHeading(title string, level int, style string) []string {
+	if style == StyleMinimal {
+		if level == 1 {
+			return []string{title}
+		}
+		return []string{title + ":"}
+	}
+
+	underline := "-"
+	if level == 1 {
+		underline = "="
+	}
+	return []string{title, strings.Repeat(underline, len(title))}
+}
+
+// styleInline removes emoji and inline markdown from a line of text
+func styleInline(line string) string {
+	line = symbolReplacements.Replace(line)
+	line = strings.Map(func(r rune) rune {
+		if isEmoji(r) {
+			return -1
+		}
+		return r
+	}, line)
+
+	line = boldPattern.ReplaceAllString(line, "$1")
+	line = italicPattern.ReplaceAllString(line, "$1$2$3")
+	line = strings.ReplaceAll(line, "`", "")
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// isEmoji reports whether r is an emoji or a character used to compose one
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x2300 && r <= 0x23FF, // technical symbols such as ⏱
+		r >= 0x2600 && r <= 0x27BF,   // miscellaneous symbols and dingbats
+		r >= 0x2B00 && r <= 0x2BFF,   // arrows and shapes such as ⬆
+		r >= 0x1F000 && r <= 0x1FAFF, // pictographs
+		r == 0xFE0F, r == 0x200D:     // variation selector and joiner
+		return true
+	}
+	return false
+}
+
+// tableCells splits a markdown table row into styled cells
+func tableCells(row string) []string {
+	cells := strings.Split(strings.Trim(row, "|"), "|")
+	for i, cell := range cells {
+		cells[i] = styleInline(cell)
+	}
+	return cells
+}
+
+// alignTable renders table rows as aligned columns
+func alignTable(rows [][]string) []string {
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		w.Write([]byte(strings.Join(row, "\t") + "\n"))
+	}
+	w.Flush()
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return lines
+}
+
+// collapseBlankLines keeps at most one blank line in a row and drops
+// leading ones
+func collapseBlankLines(lines []string) []string {
+	out := lines[:0]
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" && (len(out) == 0 || strings.TrimSpace(out[len(out)-1]) == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// trailingNewline returns the newline text ends with, if any
+func trailingNewline(text string) string {
+	if strings.HasSuffix(text, "\n") {
+		return "\n"
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// summarizeArg is the tool argument asking for the output to be summarized
+// by the client's model
+const summarizeArg = "summarize"
+
+// maxSummaryInput bounds the output sent to the client's model. Longer
+// output is cut from the start, keeping the most recent lines.
+const maxSummaryInput = 60000
+
+// summarySystemPrompt instructs the client's model how to summarize
+const summarySystemPrompt = "You summarize the output of Fly.io operations tools for an engineer. Report findings, most important first: errors, crashes, warnings, recurring patterns and anomalies, with counts, machines, regions and times where they help. Be concise, use a markdown list, and do not invent details that are not in the output."
+
+// summarizeProperty returns the schema of the summarize argument
+func summarizeProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": "Return findings summarized by your model instead of the full output, when your client supports sampling",
+		"default":     false,
+	}
+}
+
+// summarizeResult replaces a tool's output by findings the client's model
+// wrote from it, when the call passed summarize. focus tells the model what
+// to look for. Without sampling, or when it fails, the full output is kept
+// with a note saying why.
+func summarizeResult(ctx context.Context, args map[string]interface{}, toolName, focus string, result *interfaces.ToolResult) *interfaces.ToolResult {
+	if summarize, _ := args[summarizeArg].(bool); !summarize || result == nil || result.IsError {
+		return result
+	}
+
+	var text strings.Builder
+	var links []interfaces.ContentBlock
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "resource_link":
+			links = append(links, block)
+		}
+	}
+	output := text.String()
+	if len(output) > maxSummaryInput {
+		output = output[len(output)-maxSummaryInput:]
+		if i := strings.IndexByte(output, '\n'); i >= 0 {
+			output = output[i+1:]
+		}
+	}
+
+	summary, err := interfaces.Sample(ctx, interfaces.SampleRequest{
+		SystemPrompt: summarySystemPrompt,
+		Prompt:       fmt.Sprintf("%s\n\nOutput of %s:\n\n%s", focus, toolName, output),
+	})
+	if err != nil {
+		note := fmt.Sprintf("_The output was not summarized: %v. The full output is shown._", err)
+		if errors.Is(err, interfaces.ErrSamplingUnsupported) {
+			note = "_The output was not summarized because your client does not support sampling. The full output is shown._"
+		}
+		result.Content = append(result.Content, interfaces.ContentBlock{
+			Type: "text",
+			Text: note,
+		})
+		return result
+	}
+
+	response := fmt.Sprintf("# Summary of %s\n\n", toolName)
+	response += strings.TrimSpace(summary.Text) + "\n\n"
+	by := "your model"
+	if summary.Model != "" {
+		by = summary.Model
+	}
+	response += fmt.Sprintf("_Summarized by %s. Call `%s` without `summarize` for the full output._\n", by, toolName)
+
+	return &interfaces.ToolResult{
+		Content: append([]interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}}, links...),
+	}
+}
@@ -0,0 +1,736 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+const (
+	// maxResumeAfter is the longest a suspend may schedule its resume for
+	maxResumeAfter = 30 * 24 * time.Hour
+	// autoResumeTimeout bounds a scheduled resume
+	autoResumeTimeout = 5 * time.Minute
+	// hoursPerMonth converts monthly prices to hourly ones
+	hoursPerMonth = 730
+)
+
+// suspensionMetadataKey is the machine metadata key under which fly_suspend
+// records a suspension on each machine it stopped
+const suspensionMetadataKey = "fly_mcp_suspension"
+
+// Suspension is an app suspended with fly_suspend
+type Suspension struct {
+	AppName     string    `json:"appName"`
+	MachineIDs  []string  `json:"machineIds"`
+	SuspendedAt time.Time `json:"suspendedAt"`
+	SuspendedBy string    `json:"suspendedBy"`
+	// Profile is the token profile of the suspending caller, which a
+	// scheduled resume acts with; "" for the configured fly.api_token
+	Profile string `json:"profile,omitempty"`
+	// ResumeAt is when the app is started again automatically, if scheduled
+	ResumeAt *time.Time `json:"resumeAt,omitempty"`
+
+	timer *time.Timer
+}
+
+// savedSuspension is a suspension as recorded in machine metadata; the
+// machines are the ones carrying it
+type savedSuspension struct {
+	SuspendedAt time.Time  `json:"suspendedAt"`
+	SuspendedBy string     `json:"suspendedBy"`
+	Profile     string     `json:"profile,omitempty"`
+	ResumeAt    *time.Time `json:"resumeAt,omitempty"`
+}
+
+// Suspensions remembers which machines fly_suspend stopped, so fly_resume
+// starts the same ones, and runs scheduled resumes. Suspensions are kept in
+// memory and in the metadata of the stopped machines, from which Start
+// restores them after a server restart.
+type Suspensions struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+
+	// ctx is cancelled by Close, stopping a restore in progress
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu   sync.Mutex
+	apps map[string]*Suspension
+}
+
+// NewSuspensions creates an empty suspension store
+func NewSuspensions(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *Suspensions {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Suspensions{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+		ctx:         ctx,
+		cancel:      cancel,
+		apps:        make(map[string]*Suspension),
+	}
+}
+
+// Start restores the suspensions recorded in machine metadata in the
+// background, scheduling their pending resumes again. Resumes that fell due
+// while the server was down run right away.
+func (s *Suspensions) Start() {
+	go func() {
+		restored, err := s.restore(s.ctx)
+		if err != nil && s.ctx.Err() == nil {
+			s.logger.Warn().Err(err).Msg("Failed to restore suspended apps")
+		}
+		if restored > 0 {
+			s.logger.Info().
+				Int("apps", restored).
+				Msg("Restored suspended apps")
+		}
+	}()
+}
+
+// restore scans the apps visible to the configured token and to every token
+// profile for machines carrying a suspension, and records them
+func (s *Suspensions) restore(ctx context.Context) (int, error) {
+	var errs []error
+	seen := make(map[string]bool)
+	restored := 0
+	for _, profile := range append([]string{""}, s.authManager.ProfileNames()...) {
+		scanCtx := s.profileContext(ctx, profile)
+		apps, err := s.flyClient.GetApps(scanCtx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, app := range apps {
+			if seen[app.Name] {
+				continue
+			}
+			seen[app.Name] = true
+
+			suspension, err := s.load(scanCtx, app.Name)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if suspension != nil {
+				s.record(suspension)
+				restored++
+			}
+		}
+		if ctx.Err() != nil {
+			return restored, ctx.Err()
+		}
+	}
+	return restored, errors.Join(errs...)
+}
+
+// load returns the suspension recorded on an app's stopped machines, or nil
+func (s *Suspensions) load(ctx context.Context, appName string) (*Suspension, error) {
+	machines, err := s.flyClient.ListMachines(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	var suspension *Suspension
+	for _, m := range machines {
+		if m.State != "stopped" && m.State != "suspended" {
+			continue
+		}
+		metadata, _ := m.Config["metadata"].(map[string]interface{})
+		value, ok := metadata[suspensionMetadataKey].(string)
+		if !ok {
+			continue
+		}
+		if suspension == nil {
+			var saved savedSuspension
+			if err := json.Unmarshal([]byte(value), &saved); err != nil {
+				return nil, fmt.Errorf("invalid suspension on machine %s of app %s: %w", m.ID, appName, err)
+			}
+			suspension = &Suspension{
+				AppName:     appName,
+				SuspendedAt: saved.SuspendedAt,
+				SuspendedBy: saved.SuspendedBy,
+				Profile:     saved.Profile,
+				ResumeAt:    saved.ResumeAt,
+			}
+		}
+		suspension.MachineIDs = append(suspension.MachineIDs, m.ID)
+	}
+	return suspension, nil
+}
+
+// save records a suspension in the metadata of its machines, so that it
+// survives a server restart
+func (s *Suspensions) save(ctx context.Context, suspension *Suspension) error {
+	value, err := json.Marshal(savedSuspension{
+		SuspendedAt: suspension.SuspendedAt,
+		SuspendedBy: suspension.SuspendedBy,
+		Profile:     suspension.Profile,
+		ResumeAt:    suspension.ResumeAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, id := range suspension.MachineIDs {
+		if err := s.flyClient.SetMachineMetadata(ctx, suspension.AppName, id, suspensionMetadataKey, string(value)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// clear removes a resumed suspension from the metadata of its machines
+func (s *Suspensions) clear(ctx context.Context, suspension *Suspension) {
+	for _, id := range suspension.MachineIDs {
+		if err := s.flyClient.DeleteMachineMetadata(ctx, suspension.AppName, id, suspensionMetadataKey); err != nil {
+			s.logger.Warn().
+				Err(err).
+				Str("app_name", suspension.AppName).
+				Str("machine_id", id).
+				Msg("Failed to clear suspension from machine metadata")
+		}
+	}
+}
+
+// profileContext returns a context acting with a token profile, or with the
+// configured fly.api_token for ""
+func (s *Suspensions) profileContext(ctx context.Context, profile string) context.Context {
+	if profile == "" {
+		return ctx
+	}
+	ctx = auth.WithProfile(ctx, profile)
+	return fly.WithToken(ctx, s.authManager.ProfileToken(profile))
+}
+
+// record remembers a suspension, replacing any earlier one of the app, and
+// schedules its resume
+func (s *Suspensions) record(suspension *Suspension) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if earlier, ok := s.apps[suspension.AppName]; ok && earlier.timer != nil {
+		earlier.timer.Stop()
+	}
+	if suspension.ResumeAt != nil && s.ctx.Err() == nil {
+		appName := suspension.AppName
+		suspension.timer = time.AfterFunc(time.Until(*suspension.ResumeAt), func() {
+			s.autoResume(appName)
+		})
+	}
+	s.apps[suspension.AppName] = suspension
+}
+
+// take returns and forgets an app's suspension, cancelling its scheduled
+// resume
+func (s *Suspensions) take(appName string) (*Suspension, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	suspension, ok := s.apps[appName]
+	if !ok {
+		return nil, false
+	}
+	if suspension.timer != nil {
+		suspension.timer.Stop()
+	}
+	delete(s.apps, appName)
+	return suspension, true
+}
+
+// autoResume starts an app's machines at its scheduled resume time, acting
+// with the token profile of the caller who suspended it
+func (s *Suspensions) autoResume(appName string) {
+	suspension, ok := s.take(appName)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, autoResumeTimeout)
+	defer cancel()
+	ctx = s.profileContext(ctx, suspension.Profile)
+
+	outcome := "success"
+	details := map[string]interface{}{
+		"scheduled_by": suspension.SuspendedBy,
+		"profile":      suspension.Profile,
+		"machines":     len(suspension.MachineIDs),
+	}
+	var result *fly.SuspendResult
+	var err error
+	if suspension.Profile != "" && s.authManager.ProfileToken(suspension.Profile) == "" {
+		err = fmt.Errorf("token profile %q is no longer configured", suspension.Profile)
+	} else {
+		result, err = s.flyClient.ResumeApp(ctx, appName, suspension.MachineIDs)
+	}
+	switch {
+	case err != nil:
+		outcome = "failed"
+		details["error"] = err.Error()
+	case result.Error != "":
+		outcome = "partial"
+		details["error"] = result.Error
+	}
+	if err == nil {
+		s.clear(ctx, suspension)
+	}
+	s.authManager.AuditLog(ctx, "system", "resume_app", appName, outcome, details)
+
+	s.logger.Info().
+		Str("app_name", appName).
+		Str("result", outcome).
+		Msg("Resumed suspended app on schedule")
+}
+
+// Close cancels the scheduled resumes and stops a restore in progress
+func (s *Suspensions) Close() {
+	s.cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, suspension := range s.apps {
+		if suspension.timer != nil {
+			suspension.timer.Stop()
+		}
+	}
+}
+
+// SuspendTool implements the fly_suspend MCP tool
+type SuspendTool struct {
+	flyClient   *fly.Client
+	suspensions *Suspensions
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewSuspendTool creates a new suspend tool
+func NewSuspendTool(flyClient *fly.Client, suspensions *Suspensions, authManager *auth.Manager, logger *logger.Logger) *SuspendTool {
+	return &SuspendTool{
+		flyClient:   flyClient,
+		suspensions: suspensions,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *SuspendTool) Name() string {
+	return "fly_suspend"
+}
+
+// Description returns the tool description
+func (t *SuspendTool) Description() string {
+	return "Suspend a Fly.io application to save money while it is not needed, such as a staging app overnight: stops every running machine, so they are no longer billed for CPU and memory, and reports the estimated savings. Volumes, IP addresses and configuration are kept. Optionally resumes the app automatically after resume_after_minutes. Use fly_resume to start it again. The first call previews the suspend and returns a confirmation token; call again with the token to suspend."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *SuspendTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to suspend",
+			},
+			"resume_after_minutes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Start the app again automatically after this many minutes (at most 30 days); omit to stay suspended until fly_resume",
+				"minimum":     1,
+				"maximum":     int(maxResumeAfter.Minutes()),
+			},
+			"reason": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional reason for the suspend (for audit logging)",
+			},
+			"confirmation_token": confirmationTokenProperty(),
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *SuspendTool) RequiredPermission() (string, string) {
+	return "restart", "app"
+}
+
+// Execute executes the suspend tool
+func (t *SuspendTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "restart", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	var resumeAfter time.Duration
+	if v, ok := args["resume_after_minutes"].(float64); ok {
+		resumeAfter = time.Duration(v) * time.Minute
+		if resumeAfter < time.Minute || resumeAfter > maxResumeAfter {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: "Error: resume_after_minutes must be between 1 and 43200 (30 days)",
+				}},
+				IsError: true,
+			}, nil
+		}
+	}
+	reason, _ := args["reason"].(string)
+
+	scope := map[string]interface{}{
+		"app_name":             appName,
+		"resume_after_minutes": resumeAfter.Minutes(),
+	}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		return t.preview(ctx, appName, resumeAfter, scope), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_suspend", token, scope); result != nil {
+		return result, nil
+	}
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_suspend").
+		Str("app_name", appName).
+		Str("reason", reason).
+		Dur("resume_after", resumeAfter).
+		Msg("Executing suspend tool")
+
+	result, err := t.flyClient.SuspendApp(ctx, appName)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "suspend_app", appName, "failed", map[string]interface{}{
+			"error":  err.Error(),
+			"reason": reason,
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Suspend Failed**\n\nFailed to suspend app '%s': %s\n\nNo machines were stopped.", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	suspension := &Suspension{
+		AppName:     appName,
+		MachineIDs:  result.Changed(),
+		SuspendedAt: time.Now(),
+		SuspendedBy: userID,
+		Profile:     auth.ProfileFromContext(ctx),
+	}
+	if resumeAfter > 0 && len(suspension.MachineIDs) > 0 {
+		resumeAt := suspension.SuspendedAt.Add(resumeAfter)
+		suspension.ResumeAt = &resumeAt
+	}
+	var saveErr error
+	if len(suspension.MachineIDs) > 0 {
+		saveErr = t.suspensions.save(ctx, suspension)
+		t.suspensions.record(suspension)
+	}
+	if saveErr != nil {
+		t.logger.Warn().
+			Err(saveErr).
+			Str("app_name", appName).
+			Msg("Failed to record suspension in machine metadata")
+	}
+
+	outcome := "success"
+	if result.Error != "" {
+		outcome = "partial"
+	}
+	t.authManager.AuditLog(ctx, userID, "suspend_app", appName, outcome, map[string]interface{}{
+		"reason":          reason,
+		"machines":        len(suspension.MachineIDs),
+		"monthly_savings": result.MonthlyCompute,
+		"resume_at":       suspension.ResumeAt,
+		"error":           result.Error,
+	})
+
+	report := suspendReport{SuspendResult: result, ResumeAt: suspension.ResumeAt}
+	if saveErr != nil {
+		report.Warning = fmt.Sprintf("the suspension could not be recorded on the machines, so it is lost if the server restarts before the app is resumed: %s", describeError(saveErr))
+	}
+	return NewOutputFormatter(ctx, args).Render(t.formatTextResponse(report), fmt.Sprintf("Suspend of application '%s'", appName), report, appLinks(appName)...), nil
+}
+
+// suspendReport is the structured result of a suspend
+type suspendReport struct {
+	*fly.SuspendResult
+	ResumeAt *time.Time `json:"resumeAt,omitempty"`
+	Warning  string     `json:"warning,omitempty"`
+}
+
+// preview describes the suspend and issues its confirmation token
+func (t *SuspendTool) preview(ctx context.Context, appName string, resumeAfter time.Duration, scope map[string]interface{}) *interfaces.ToolResult {
+	estimate, err := t.flyClient.EstimateAppCost(ctx, appName)
+	if err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to get machines for app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}
+	}
+
+	running := 0
+	var savings float64
+	for _, m := range estimate.Machines {
+		if m.State == "started" {
+			running++
+			savings += m.Monthly
+		}
+	}
+	if running == 0 {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("App '%s' has no started machines; it is already suspended. Use `fly_resume` to start it.", appName),
+			}},
+			IsError: true,
+		}
+	}
+
+	summary := fmt.Sprintf("- **Machines to stop**: up to %d running machine(s); scheduled tasks are left running\n", running)
+	summary += fmt.Sprintf("- **Estimated savings**: $%.2f/month of compute ($%.3f/hour) while suspended\n", savings, savings/hoursPerMonth)
+	summary += fmt.Sprintf("- **Still billed**: volumes and dedicated IPs ($%.2f/month)\n", estimate.VolumeTotal+estimate.IPTotal)
+	if resumeAfter > 0 {
+		summary += fmt.Sprintf("- **Auto-resume**: in %s, at %s\n", resumeAfter, time.Now().Add(resumeAfter).UTC().Format(time.RFC3339))
+	}
+	summary += "- **Impact**: the app does not serve requests until it is resumed, unless its machines are started on demand by the proxy"
+
+	return requestConfirmation(ctx, t.authManager, "fly_suspend", "Suspend", appName, scope, summary)
+}
+
+// formatTextResponse formats the suspend result as human-readable text
+func (t *SuspendTool) formatTextResponse(report suspendReport) *interfaces.ToolResult {
+	var response string
+
+	if report.Error != "" {
+		response += fmt.Sprintf("⚠️ **Application '%s' Partly Suspended**\n\n", report.AppName)
+	} else {
+		response += fmt.Sprintf("💤 **Application '%s' Suspended**\n\n", report.AppName)
+	}
+
+	response += "## Savings\n"
+	response += fmt.Sprintf("- **Machines Stopped**: %d\n", len(report.Changed()))
+	response += fmt.Sprintf("- **Estimated Savings**: $%.2f/month of compute ($%.3f/hour)\n", report.MonthlyCompute, report.MonthlyCompute/hoursPerMonth)
+	if report.ResumeAt != nil {
+		response += fmt.Sprintf("- **Auto-resume**: %s\n", report.ResumeAt.UTC().Format(time.RFC3339))
+	}
+	if report.Error != "" {
+		response += fmt.Sprintf("- **Error**: %s\n", report.Error)
+	}
+	if report.Warning != "" {
+		response += fmt.Sprintf("- **Warning**: %s\n", report.Warning)
+	}
+
+	response += formatSuspendedMachines(report.Machines)
+
+	response += "\n## Next Steps\n"
+	response += fmt.Sprintf("- Use `fly_resume` with app_name `%s` to start the app again\n", report.AppName)
+	response += "- Volumes and dedicated IP addresses are still billed while the app is suspended\n"
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: report.Error != "",
+	}
+}
+
+// ResumeTool implements the fly_resume MCP tool
+type ResumeTool struct {
+	flyClient   *fly.Client
+	suspensions *Suspensions
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewResumeTool creates a new resume tool
+func NewResumeTool(flyClient *fly.Client, suspensions *Suspensions, authManager *auth.Manager, logger *logger.Logger) *ResumeTool {
+	return &ResumeTool{
+		flyClient:   flyClient,
+		suspensions: suspensions,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *ResumeTool) Name() string {
+	return "fly_resume"
+}
+
+// Description returns the tool description
+func (t *ResumeTool) Description() string {
+	return "Resume a Fly.io application suspended with fly_suspend by starting the machines it stopped, cancelling any scheduled auto-resume. For apps this server did not suspend, every stopped machine except scheduled tasks is started."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *ResumeTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application to resume",
+			},
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *ResumeTool) RequiredPermission() (string, string) {
+	return "restart", "app"
+}
+
+// Execute executes the resume tool
+func (t *ResumeTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "restart", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_resume").
+		Str("app_name", appName).
+		Msg("Executing resume tool")
+
+	var machineIDs []string
+	suspension, suspended := t.suspensions.take(appName)
+	if suspended {
+		machineIDs = suspension.MachineIDs
+	}
+
+	result, err := t.flyClient.ResumeApp(ctx, appName, machineIDs)
+	if err != nil {
+		// Keep the suspension so a retry starts the same machines
+		if suspended {
+			t.suspensions.record(suspension)
+		}
+		t.authManager.AuditLog(ctx, userID, "resume_app", appName, "failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Resume Failed**\n\nFailed to resume app '%s': %s", appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	if suspended {
+		t.suspensions.clear(ctx, suspension)
+	}
+
+	outcome := "success"
+	if result.Error != "" {
+		outcome = "partial"
+	}
+	t.authManager.AuditLog(ctx, userID, "resume_app", appName, outcome, map[string]interface{}{
+		"machines": len(result.Changed()),
+		"error":    result.Error,
+	})
+
+	var response string
+	if result.Error != "" {
+		response += fmt.Sprintf("⚠️ **Application '%s' Partly Resumed**\n\n", appName)
+	} else {
+		response += fmt.Sprintf("▶️ **Application '%s' Resumed**\n\n", appName)
+	}
+	response += "## Resume Summary\n"
+	response += fmt.Sprintf("- **Machines Started**: %d\n", len(result.Changed()))
+	response += fmt.Sprintf("- **Compute Cost Again**: $%.2f/month\n", result.MonthlyCompute)
+	if suspended {
+		response += fmt.Sprintf("- **Suspended For**: %s, by %s\n", time.Since(suspension.SuspendedAt).Round(time.Minute), suspension.SuspendedBy)
+	}
+	if result.Error != "" {
+		response += fmt.Sprintf("- **Error**: %s\n", result.Error)
+	}
+	response += formatSuspendedMachines(result.Machines)
+
+	response += "\n## Next Steps\n"
+	response += fmt.Sprintf("- Use `fly_wait` with condition `app_healthy` to wait for '%s' to pass its health checks\n", appName)
+
+	text := &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: result.Error != "",
+	}
+	return NewOutputFormatter(ctx, args).Render(text, fmt.Sprintf("Resume of application '%s'", appName), result, appLinks(appName)...), nil
+}
+
+// formatSuspendedMachines formats the machines a suspend or resume changed
+// as a table section
+func formatSuspendedMachines(machines []fly.SuspendedMachine) string {
+	response := "\n## Machines\n"
+	response += "| Machine | Region | Result | Compute/Month |\n"
+	response += "|---------|--------|--------|---------------|\n"
+	for _, m := range machines {
+		outcome := m.Action
+		if m.Error != "" {
+			outcome += ": " + truncateOutput(m.Error, 120)
+		}
+		response += fmt.Sprintf("| `%s` | %s | %s | $%.2f |\n", m.MachineID, m.Region, outcome, m.Monthly)
+	}
+	return response
+}
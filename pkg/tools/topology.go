@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// TopologyTool implements the fly_topology MCP tool
+type TopologyTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// NewTopologyTool creates a new topology tool
+func NewTopologyTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger) *TopologyTool {
+	return &TopologyTool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *TopologyTool) Name() string {
+	return "fly_topology"
+}
+
+// Description returns the tool description
+func (t *TopologyTool) Description() string {
+	return "Map the dependencies between the organization's apps as a tree: environment variables naming another app's .internal, .flycast or fly.dev address, and attached Postgres clusters, with the volumes each app holds. Pass app_name to see its blast radius, every app that depends on it directly or indirectly, before restarting or changing it. Secret and environment values are never shown."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *TopologyTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Application whose blast radius to show (optional, shows the whole organization if not specified)",
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *TopologyTool) RequiredPermission() (string, string) {
+	return "read", "apps"
+}
+
+// topologyReport is the dependency graph, focused on one app when the call
+// names one
+type topologyReport struct {
+	*fly.Topology
+	AppName     string   `json:"appName,omitempty"`
+	BlastRadius []string `json:"blastRadius,omitempty"`
+}
+
+// Execute executes the topology tool
+func (t *TopologyTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "apps"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	appName, _ := args["app_name"].(string)
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_topology").
+		Str("app_name", appName).
+		Msg("Executing topology tool")
+
+	// Apps that a policy hides from the caller are left out of the graph
+	topology, err := t.flyClient.GetTopology(ctx, func(name string) bool {
+		return t.authManager.EvaluatePolicy(ctx, "read", name) == nil
+	})
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "topology", "apps", "failed", map[string]interface{}{
+			"app_name": appName,
+			"error":    err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to map app dependencies: %s", describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	report := &topologyReport{Topology: topology, AppName: appName}
+	if appName != "" {
+		if !slices.ContainsFunc(topology.Apps, func(a fly.TopologyApp) bool { return a.Name == appName }) {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: application '%s' was not found in the organization", appName),
+				}},
+				IsError: true,
+			}, nil
+		}
+		report.BlastRadius = topology.BlastRadius(appName)
+	}
+
+	t.authManager.AuditLog(ctx, userID, "topology", "apps", "success", map[string]interface{}{
+		"app_name": appName,
+		"apps":     len(topology.Apps),
+		"edges":    len(topology.Edges),
+	})
+
+	var links []interfaces.ContentBlock
+	if appName != "" {
+		links = appLinks(appName)
+	} else {
+		for _, app := range topology.Apps {
+			links = append(links, interfaces.ResourceLink(AppResourceURI(app.Name), app.Name, fmt.Sprintf("Application %s", app.Name)))
+		}
+	}
+
+	title := "App topology"
+	if appName != "" {
+		title = fmt.Sprintf("Blast radius of '%s'", appName)
+	}
+	return out.Render(t.formatTextResponse(report), title, report, links...), nil
+}
+
+// formatTextResponse formats the dependency graph as human-readable text
+func (t *TopologyTool) formatTextResponse(report *topologyReport) *interfaces.ToolResult {
+	var response string
+
+	apps := make(map[string]fly.TopologyApp, len(report.Apps))
+	for _, app := range report.Apps {
+		apps[app.Name] = app
+	}
+
+	if report.AppName == "" {
+		response += "# App Topology\n\n"
+		if report.Organization != "" {
+			response += fmt.Sprintf("- **Organization**: %s\n", report.Organization)
+		}
+		response += fmt.Sprintf("- **Applications**: %d\n", len(report.Apps))
+		response += fmt.Sprintf("- **Dependencies**: %d\n", len(report.Edges))
+
+		response += "\n## Dependency Tree\n"
+		response += "Each app is followed by the apps it depends on.\n\n"
+		response += "```\n"
+		printed := make(map[string]bool)
+		// Apps nothing depends on are the roots; apps only reachable
+		// through a cycle follow
+		for _, pass := range []bool{true, false} {
+			for _, app := range report.Apps {
+				if printed[app.Name] || (pass && len(app.Dependents) > 0) {
+					continue
+				}
+				response += dependencyTree(report.Topology, apps, app.Name, false, printed)
+			}
+		}
+		response += "```\n"
+	} else {
+		app := apps[report.AppName]
+		response += fmt.Sprintf("# Blast Radius: %s\n\n", report.AppName)
+		if len(report.BlastRadius) == 0 {
+			response += "🟢 **No other app depends on it**\n"
+		} else {
+			response += fmt.Sprintf("🟠 **%d app(s) depend on it**: %s\n", len(report.BlastRadius), strings.Join(report.BlastRadius, ", "))
+			response += "\n## Dependents\n"
+			response += "Each app is followed by the apps that depend on it.\n\n"
+			response += "```\n"
+			response += dependencyTree(report.Topology, apps, report.AppName, true, make(map[string]bool))
+			response += "```\n"
+		}
+
+		if len(app.DependsOn) > 0 {
+			response += "\n## Depends On\n"
+			for _, edge := range report.Edges {
+				if edge.From == report.AppName {
+					response += fmt.Sprintf("- **%s**: %s\n", edge.To, describeEdge(edge))
+				}
+			}
+		}
+		if app.Volumes > 0 {
+			response += fmt.Sprintf("\n⚠️ %s holds %d volume(s) (%d GB); their data is unavailable while the machines they are attached to restart.\n", report.AppName, app.Volumes, app.VolumeGB)
+		}
+	}
+
+	if len(report.Warnings) > 0 {
+		response += "\n## Warnings\n"
+		for _, w := range report.Warnings {
+			response += fmt.Sprintf("- ⚠️ %s\n", w)
+		}
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
+
+// dependencyTree renders an app and, below it, the apps it depends on, or
+// with reverse the apps that depend on it. An app on its own path is a
+// cycle and is not expanded again.
+func dependencyTree(topology *fly.Topology, apps map[string]fly.TopologyApp, root string, reverse bool, printed map[string]bool) string {
+	var sb strings.Builder
+	sb.WriteString(appLabel(apps[root]) + "\n")
+	printed[root] = true
+
+	var walk func(name, prefix string, path []string)
+	walk = func(name, prefix string, path []string) {
+		var edges []fly.TopologyEdge
+		for _, edge := range topology.Edges {
+			if (!reverse && edge.From == name) || (reverse && edge.To == name) {
+				edges = append(edges, edge)
+			}
+		}
+		for i, edge := range edges {
+			next := edge.To
+			if reverse {
+				next = edge.From
+			}
+			branch, indent := "├── ", "│   "
+			if i == len(edges)-1 {
+				branch, indent = "└── ", "    "
+			}
+
+			line := fmt.Sprintf("%s%s%s  (%s)", prefix, branch, appLabel(apps[next]), describeEdge(edge))
+			if slices.Contains(path, next) {
+				sb.WriteString(line + " [cycle]\n")
+				continue
+			}
+			sb.WriteString(line + "\n")
+			printed[next] = true
+			walk(next, prefix+indent, append(path, next))
+		}
+	}
+	walk(root, "", []string{root})
+	return sb.String()
+}
+
+// appLabel names an app in the tree with its role and volumes
+func appLabel(app fly.TopologyApp) string {
+	var tags []string
+	if app.Role != fly.RoleApp {
+		tags = append(tags, app.Role)
+	}
+	if app.Volumes > 0 {
+		tags = append(tags, fmt.Sprintf("%d volume(s), %d GB", app.Volumes, app.VolumeGB))
+	}
+	if len(tags) == 0 {
+		return app.Name
+	}
+	return fmt.Sprintf("%s [%s]", app.Name, strings.Join(tags, "; "))
+}
+
+// describeEdge explains how a dependency was found
+func describeEdge(edge fly.TopologyEdge) string {
+	kinds := map[string]string{
+		fly.DependencyInternalDNS: "internal DNS",
+		fly.DependencyFlycast:     "Flycast",
+		fly.DependencyPublicURL:   "public URL",
+		fly.DependencyPostgres:    "Postgres",
+	}
+	text := fmt.Sprintf("%s via %s", kinds[edge.Kind], strings.Join(edge.Evidence, ", "))
+	if edge.Inferred {
+		text += ", inferred"
+	}
+	return text
+}
@@ -0,0 +1,517 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+	"github.com/brannn/fly-mcp/pkg/journal"
+)
+
+// UndoTool implements the fly_undo MCP tool
+type UndoTool struct {
+	flyClient   *fly.Client
+	journal     *journal.Journal
+	authManager *auth.Manager
+	logger      *logger.Logger
+}
+
+// UndoHistory is the structured result of fly_undo's history action
+type UndoHistory struct {
+	AppName    string              `json:"appName"`
+	Operations []journal.Operation `json:"operations"`
+}
+
+// UndoResult is the structured result of an undo
+type UndoResult struct {
+	Reverted journal.Operation `json:"reverted"`
+	Undo     journal.Operation `json:"undo"`
+}
+
+// NewUndoTool creates a new undo tool
+func NewUndoTool(flyClient *fly.Client, ops *journal.Journal, authManager *auth.Manager, logger *logger.Logger) *UndoTool {
+	return &UndoTool{
+		flyClient:   flyClient,
+		journal:     ops,
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// Name returns the tool name
+func (t *UndoTool) Name() string {
+	return "fly_undo"
+}
+
+// Description returns the tool description
+func (t *UndoTool) Description() string {
+	return "Undo the last change made to an app through this server: an environment update, an image deploy, an autoscaling update or a change of the machine count. Each change is journaled with the state before and after it; calling fly_undo repeatedly walks back through an app's changes. Changes that cannot be reverted, such as a deploy to machines that ran different images, are reported with the reason. Use action 'history' to list the journal."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *UndoTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "Action to perform: 'undo' to revert a change, 'history' to list the app's journaled changes",
+				"enum":        []string{"undo", "history"},
+				"default":     "undo",
+			},
+			"operation_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Operation to undo, as listed by 'history'; the app's last change by default",
+			},
+			"confirmation_token": confirmationTokenProperty(),
+		},
+		"required":             []string{"app_name"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *UndoTool) RequiredPermission() (string, string) {
+	return "undo", "app"
+}
+
+// ReadOnlyCall reports whether a call only lists the journal
+func (t *UndoTool) ReadOnlyCall(args map[string]interface{}) bool {
+	action, _ := args["action"].(string)
+	return action == "history"
+}
+
+// Execute executes the undo tool
+func (t *UndoTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	action := "undo"
+	if a, ok := args["action"].(string); ok && a != "" {
+		action = a
+	}
+
+	// The history only needs read access; undoing also needs the permission
+	// of the change it reverts
+	permAction, permResource := "read", "app"
+	if !t.ReadOnlyCall(args) {
+		permAction, permResource = t.RequiredPermission()
+	}
+	if err := t.authManager.ValidateRequest(ctx, permAction, permResource); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_undo").
+		Str("app_name", appName).
+		Str("action", action).
+		Msg("Executing undo tool")
+
+	switch action {
+	case "history":
+		return t.history(ctx, userID, appName, out)
+	case "undo":
+		return t.undo(ctx, userID, appName, args, out)
+	default:
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Unknown action: %s. Use 'undo' or 'history'", action),
+			}},
+			IsError: true,
+		}, nil
+	}
+}
+
+// history lists the journaled changes of an app
+func (t *UndoTool) history(ctx context.Context, userID, appName string, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	history := &UndoHistory{AppName: appName, Operations: t.journal.List(appName)}
+
+	t.authManager.AuditLog(ctx, userID, "undo_history", appName, "success", map[string]interface{}{
+		"operations": len(history.Operations),
+	})
+
+	var response string
+
+	response += fmt.Sprintf("# Change Journal: %s\n\n", appName)
+	if len(history.Operations) == 0 {
+		response += "No changes to this app were made through this server since it started.\n"
+	} else {
+		response += "| Operation | Time | Tool | User | Change | Status | Undo |\n"
+		response += "|-----------|------|------|------|--------|--------|------|\n"
+		for _, op := range history.Operations {
+			response += fmt.Sprintf("| `%s` | %s | %s | %s | %s | %s | %s |\n",
+				op.ID, op.CreatedAt.Format("2006-01-02 15:04:05"), op.Tool, op.User, op.Summary, op.Status, undoState(op))
+		}
+		response += "\nThe journal is kept in memory, so changes made before the server started, or through other means, are not listed.\n"
+	}
+
+	return out.Render(&interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}, fmt.Sprintf("Change journal of application '%s'", appName), history, appLinks(appName)...), nil
+}
+
+// undo reverts a journaled change of an app after confirmation
+func (t *UndoTool) undo(ctx context.Context, userID, appName string, args map[string]interface{}, out *OutputFormatter) (*interfaces.ToolResult, error) {
+	var op journal.Operation
+	if id, _ := args["operation_id"].(string); id != "" {
+		found, ok := t.journal.Get(id)
+		if !ok || found.AppName != appName {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Error: operation %s not found for app '%s'. Use `fly_undo` with `action: history` to list its changes.", id, appName),
+				}},
+				IsError: true,
+			}, nil
+		}
+		op = found
+	} else {
+		last, ok := t.journal.Last(appName)
+		if !ok {
+			return &interfaces.ToolResult{
+				Content: []interfaces.ContentBlock{{
+					Type: "text",
+					Text: fmt.Sprintf("Nothing to undo: no changes to app '%s' made through this server are left to revert. Use `fly_undo` with `action: history` to see what was done.", appName),
+				}},
+			}, nil
+		}
+		op = last
+	}
+
+	if op.UndoneBy != "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Operation %s (%s) was already undone by %s.", op.ID, op.Summary, op.UndoneBy),
+			}},
+		}, nil
+	}
+	if !op.Reversible() {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: t.formatIrreversible(op),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Undoing a change is that change again, so it needs its permission too
+	permAction, permResource := journal.Permission(op.Kind)
+	if err := t.authManager.ValidateRequest(ctx, permAction, permResource); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	scope := map[string]interface{}{"app_name": appName, "operation_id": op.ID}
+	token, _ := args["confirmation_token"].(string)
+	if token == "" {
+		return requestConfirmation(ctx, t.authManager, "fly_undo", "Undo", appName, scope, t.formatPreview(op)), nil
+	}
+	if result := verifyConfirmation(ctx, t.authManager, "fly_undo", token, scope); result != nil {
+		return result, nil
+	}
+
+	undoOp, err := t.revert(ctx, op)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "undo", appName, "failed", map[string]interface{}{
+			"operation_id": op.ID,
+			"kind":         op.Kind,
+			"error":        err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("❌ **Undo Failed**\n\nFailed to undo %s on app '%s': %s\n\nNo machines were changed.", op.ID, appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	undoOp.Tool = "fly_undo"
+	undoOp.User = userID
+	undoOp.UndoOf = op.ID
+	recorded := t.journal.Record(*undoOp)
+	if recorded.Status == fly.DeploySucceeded {
+		t.journal.MarkUndone(op.ID, recorded.ID)
+	}
+
+	t.authManager.AuditLog(ctx, userID, "undo", appName, recorded.Status, map[string]interface{}{
+		"operation_id": op.ID,
+		"kind":         op.Kind,
+		"undo_id":      recorded.ID,
+	})
+
+	return out.Render(t.formatUndoResponse(op, recorded), fmt.Sprintf("Undo of %s on application '%s'", op.ID, appName), &UndoResult{
+		Reverted: op,
+		Undo:     recorded,
+	}, appLinks(appName)...), nil
+}
+
+// revert applies an operation's revert and describes the change it made as
+// an operation of its own. The error is only set when nothing was changed;
+// a revert that stopped part way is a failed operation.
+func (t *UndoTool) revert(ctx context.Context, op journal.Operation) (*journal.Operation, error) {
+	var undoOp *journal.Operation
+
+	switch {
+	case op.Revert.Scale != nil:
+		revert := op.Revert.Scale
+		var plan *fly.ScalePlan
+		var err error
+		if len(revert.Destroy) > 0 {
+			plan, err = t.flyClient.PlanScaleIn(ctx, op.AppName, revert.Region, revert.Destroy)
+		} else {
+			plan, err = t.flyClient.PlanScale(ctx, op.AppName, revert.Target, revert.Region)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if plan.Current != plan.Target {
+			result := t.flyClient.ApplyScale(ctx, plan, defaultHealthTimeout*time.Second)
+			if result.Error != "" && len(result.Created) == 0 && len(result.Destroyed) == 0 {
+				return nil, errors.New(result.Error)
+			}
+			undoOp = journal.ScaleOperation(result)
+		}
+	case op.Revert.Autoscale != nil:
+		before, err := t.flyClient.GetAutoscalePolicy(ctx, op.AppName)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := t.flyClient.UpdateAutoscalePolicy(ctx, op.AppName, *op.Revert.Autoscale)
+		if err != nil && len(updated) == 0 {
+			return nil, err
+		}
+		undoOp = journal.AutoscaleOperation(op.AppName, before, *op.Revert.Autoscale, updated, err)
+	case op.Revert.Image != "":
+		result, err := t.flyClient.Deploy(ctx, op.AppName, fly.DeployRequest{
+			Image:         op.Revert.Image,
+			Strategy:      fly.DeployRolling,
+			HealthTimeout: defaultHealthTimeout * time.Second,
+		})
+		if err != nil {
+			return nil, err
+		}
+		undoOp = journal.DeployOperation(result)
+	default:
+		update := fly.EnvUpdate{Unset: op.Revert.EnvUnset}
+		if len(op.Revert.EnvSet) > 0 {
+			update.Set = op.Revert.EnvSet
+		}
+		result, err := t.flyClient.UpdateAppEnv(ctx, op.AppName, update, defaultHealthTimeout*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		undoOp = journal.EnvOperation(result)
+	}
+
+	if undoOp == nil {
+		// The app was already back in its earlier state
+		undoOp = &journal.Operation{
+			AppName:      op.AppName,
+			Kind:         op.Kind,
+			Status:       fly.DeploySucceeded,
+			Summary:      "Nothing to change: the app already had the earlier state",
+			Irreversible: "it changed nothing",
+		}
+	}
+	return undoOp, nil
+}
+
+// formatIrreversible explains why an operation cannot be undone and which
+// earlier operations can
+func (t *UndoTool) formatIrreversible(op journal.Operation) string {
+	var response string
+
+	response += fmt.Sprintf("⛔ **Cannot Undo %s**\n\n", op.ID)
+	response += fmt.Sprintf("- **Change**: %s\n", op.Summary)
+	response += fmt.Sprintf("- **Made by**: %s with `%s` at %s\n", op.User, op.Tool, op.CreatedAt.Format("2006-01-02 15:04:05"))
+	response += fmt.Sprintf("- **Reason**: %s\n", op.Irreversible)
+
+	// The journal lists the newest operations first, so the ones after op
+	// came before it
+	var reversible []string
+	earlier := false
+	for _, o := range t.journal.List(op.AppName) {
+		if earlier && o.Reversible() && o.UndoneBy == "" && o.UndoOf == "" {
+			reversible = append(reversible, fmt.Sprintf("- `%s`: %s", o.ID, o.Summary))
+		}
+		earlier = earlier || o.ID == op.ID
+	}
+	if len(reversible) > 0 {
+		response += "\nEarlier changes that can be undone with `operation_id`:\n"
+		for _, line := range reversible {
+			response += line + "\n"
+		}
+	}
+
+	return response
+}
+
+// formatPreview describes what undoing an operation changes
+func (t *UndoTool) formatPreview(op journal.Operation) string {
+	summary := fmt.Sprintf("- **Application**: %s\n", op.AppName)
+	summary += fmt.Sprintf("- **Operation**: %s, %s\n", op.ID, op.Summary)
+	summary += fmt.Sprintf("- **Made by**: %s with `%s` at %s\n", op.User, op.Tool, op.CreatedAt.Format("2006-01-02 15:04:05"))
+
+	switch {
+	case op.Revert.Scale != nil && len(op.Revert.Scale.Destroy) > 0:
+		summary += fmt.Sprintf("- **Revert**: stop and destroy the machines the scale created: %s\n", strings.Join(codeList(op.Revert.Scale.Destroy), ", "))
+		summary += "- **Impact**: requests those machines serve move to the remaining ones\n"
+	case op.Revert.Scale != nil:
+		summary += fmt.Sprintf("- **Revert**: clone the newest running machine until the app has %d machine(s)%s again\n", op.Revert.Scale.Target, regionSuffix(op.Revert.Scale.Region))
+		summary += "- **Impact**: the destroyed machines cannot be restored; the new ones are copies of a remaining machine\n"
+	case op.Revert.Autoscale != nil:
+		summary += "- **Revert**: restore the autoscaling settings below on every machine with services\n"
+		summary += formatState(op.Before)
+		summary += "- **Impact**: running machines restart to pick up the settings\n"
+	case op.Revert.Image != "":
+		summary += fmt.Sprintf("- **Revert**: rolling deploy of the previous image %s\n", op.Revert.Image)
+		summary += "- **Impact**: each machine restarts in turn; the deploy stops at the first unhealthy machine\n"
+	default:
+		summary += "- **Revert**: restore the environment variables below\n"
+		for _, name := range op.Revert.EnvUnset {
+			summary += fmt.Sprintf("  - remove %s\n", name)
+		}
+		names := make([]string, 0, len(op.Revert.EnvSet))
+		for name := range op.Revert.EnvSet {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			summary += fmt.Sprintf("  - set %s to `%s`\n", name, truncateOutput(op.Revert.EnvSet[name], 60))
+		}
+		summary += "- **Impact**: each machine restarts in turn with the restored values\n"
+	}
+
+	if later := t.journal.Later(op); later > 0 {
+		summary += fmt.Sprintf("- ⚠️ **Warning**: %d later change(s) to this app are still in effect and may be overwritten\n", later)
+	}
+
+	return summary[:len(summary)-1]
+}
+
+// formatUndoResponse formats the result of an undo as human-readable text
+func (t *UndoTool) formatUndoResponse(op, undo journal.Operation) *interfaces.ToolResult {
+	var response string
+
+	if undo.Status == fly.DeploySucceeded {
+		response += fmt.Sprintf("⏪ **Undid %s on '%s'**\n\n", op.ID, op.AppName)
+	} else {
+		response += fmt.Sprintf("❌ **Undo of %s on '%s' Stopped**\n\n", op.ID, op.AppName)
+	}
+
+	response += "## Reverted Change\n"
+	response += fmt.Sprintf("- **Change**: %s\n", op.Summary)
+	response += fmt.Sprintf("- **Made by**: %s with `%s` at %s\n", op.User, op.Tool, op.CreatedAt.Format("2006-01-02 15:04:05"))
+
+	response += "\n## Undo\n"
+	response += fmt.Sprintf("- **Recorded as**: `%s`\n", undo.ID)
+	response += fmt.Sprintf("- **Change**: %s\n", undo.Summary)
+	response += fmt.Sprintf("- **Status**: %s\n", undo.Status)
+	if undo.Error != "" {
+		response += fmt.Sprintf("- **Error**: %s\n", undo.Error)
+	}
+
+	response += "\n## Next Steps\n"
+	if undo.Status == fly.DeploySucceeded {
+		response += "- Use `fly_status` to confirm the app is healthy\n"
+		response += "- Call `fly_undo` again to revert the change before this one\n"
+	} else {
+		response += "- Use `fly_status` and `fly_logs` to see why the machines did not become healthy\n"
+		response += "- Fix the problem and call `fly_undo` with the same `operation_id` again\n"
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: undo.Status != fly.DeploySucceeded,
+	}
+}
+
+// recordOperation records a change in the journal and returns a note on
+// how to undo it, or "" when the change did not need recording
+func recordOperation(ops *journal.Journal, op *journal.Operation, toolName, userID string) string {
+	if ops == nil || op == nil {
+		return ""
+	}
+
+	op.Tool = toolName
+	op.User = userID
+	recorded := ops.Record(*op)
+	if !recorded.Reversible() {
+		return fmt.Sprintf("\n📓 Journaled as `%s`. It cannot be undone: %s.\n", recorded.ID, recorded.Irreversible)
+	}
+	return fmt.Sprintf("\n📓 Journaled as `%s`; `fly_undo` on '%s' reverts it.\n", recorded.ID, recorded.AppName)
+}
+
+// undoState describes whether an operation can be, or was, undone
+func undoState(op journal.Operation) string {
+	switch {
+	case op.UndoOf != "":
+		return "undo of " + op.UndoOf
+	case op.UndoneBy != "":
+		return "undone by " + op.UndoneBy
+	case op.Reversible():
+		return "✅ possible"
+	}
+	return "⛔ " + op.Irreversible
+}
+
+// formatState lists journaled state as indented bullets
+func formatState(state map[string]interface{}) string {
+	keys := make([]string, 0, len(state))
+	for key := range state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var response string
+	for _, key := range keys {
+		response += fmt.Sprintf("  - %s: %v\n", key, state[key])
+	}
+	return response
+}
@@ -0,0 +1,326 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// defaultWaitSeconds is how long fly_wait waits by default
+const defaultWaitSeconds = 120
+
+// WaitTool implements the fly_wait MCP tool
+type WaitTool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+	// maxWait bounds how long a call may wait, and pollInterval is how
+	// often it checks the condition meanwhile
+	maxWait      time.Duration
+	pollInterval time.Duration
+}
+
+// WaitResult is the structured result of fly_wait
+type WaitResult struct {
+	fly.WaitCondition
+	// Outcome is met, failed, timeout or cancelled
+	Outcome string  `json:"outcome"`
+	Detail  string  `json:"detail"`
+	Checks  int     `json:"checks"`
+	Elapsed float64 `json:"elapsedSeconds"`
+}
+
+// NewWaitTool creates a new wait tool
+func NewWaitTool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger, maxWait, pollInterval time.Duration) *WaitTool {
+	return &WaitTool{
+		flyClient:    flyClient,
+		authManager:  authManager,
+		logger:       logger,
+		maxWait:      maxWait,
+		pollInterval: pollInterval,
+	}
+}
+
+// Name returns the tool name
+func (t *WaitTool) Name() string {
+	return "fly_wait"
+}
+
+// Description returns the tool description
+func (t *WaitTool) Description() string {
+	return "Wait, up to a timeout, until a condition holds: all started machines of an app passing their health checks, a machine reaching a state, a certificate being issued or a deployment finishing. The condition is polled on the server and its status streamed as progress messages, so a workflow can wait in one call instead of calling status tools repeatedly."
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *WaitTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"app_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the application",
+			},
+			"condition": map[string]interface{}{
+				"type":        "string",
+				"description": "What to wait for: app_healthy, machine_state (needs machine_id and state), certificate_issued (needs hostname) or deployment_finished",
+				"enum":        fly.WaitConditions,
+			},
+			"machine_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Machine to wait for with machine_state",
+			},
+			"state": map[string]interface{}{
+				"type":        "string",
+				"description": "State to wait for with machine_state",
+				"enum":        []string{"started", "stopped", "suspended", "destroyed"},
+			},
+			"hostname": map[string]interface{}{
+				"type":        "string",
+				"description": "Hostname of the certificate to wait for with certificate_issued",
+			},
+			"version": map[string]interface{}{
+				"type":        "integer",
+				"description": "Release to wait for with deployment_finished; the latest by default",
+				"minimum":     1,
+			},
+			"timeout_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "How long to wait before giving up",
+				"default":     defaultWaitSeconds,
+				"minimum":     1,
+				"maximum":     int(t.maxWait / time.Second),
+			},
+		},
+		"required":             []string{"app_name", "condition"},
+		"additionalProperties": false,
+	}
+}
+
+// RequiredPermission returns the permission checked before execution
+func (t *WaitTool) RequiredPermission() (string, string) {
+	return "read", "app"
+}
+
+// Execute executes the wait tool
+func (t *WaitTool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	// Validate permissions
+	if err := t.authManager.ValidateRequest(ctx, "read", "app"); err != nil {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Permission denied: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract and validate arguments
+	appName, ok := args["app_name"].(string)
+	if !ok || appName == "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: app_name is required and must be a non-empty string",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	cond := fly.WaitCondition{AppName: appName}
+	cond.Kind, _ = args["condition"].(string)
+	cond.MachineID, _ = args["machine_id"].(string)
+	cond.State, _ = args["state"].(string)
+	cond.Hostname, _ = args["hostname"].(string)
+	if v, ok := args["version"].(float64); ok && v >= 1 {
+		cond.Version = int(v)
+	}
+
+	if msg := validateWaitCondition(cond); msg != "" {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: "Error: " + msg,
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	timeout := defaultWaitSeconds * time.Second
+	if s, ok := args["timeout_seconds"].(float64); ok && s >= 1 {
+		timeout = time.Duration(s) * time.Second
+	}
+	if timeout > t.maxWait {
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: timeout_seconds must be at most %d", int(t.maxWait/time.Second)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_wait").
+		Str("app_name", appName).
+		Str("condition", cond.Kind).
+		Dur("timeout", timeout).
+		Msg("Executing wait tool")
+
+	result, err := t.wait(ctx, cond, timeout)
+	if err != nil {
+		t.authManager.AuditLog(ctx, userID, "wait", appName, "failed", map[string]interface{}{
+			"condition": cond.Kind,
+			"error":     err.Error(),
+		})
+
+		return &interfaces.ToolResult{
+			Content: []interfaces.ContentBlock{{
+				Type: "text",
+				Text: fmt.Sprintf("Failed to check %s of app '%s': %s", cond.Kind, appName, describeError(err)),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	t.authManager.AuditLog(ctx, userID, "wait", appName, "success", map[string]interface{}{
+		"condition": cond.Kind,
+		"outcome":   result.Outcome,
+		"elapsed":   result.Elapsed,
+	})
+
+	title := fmt.Sprintf("Wait for %s of application '%s'", cond.Kind, appName)
+	return out.Render(t.formatTextResponse(result), title, result, appLinks(appName)...), nil
+}
+
+// wait checks the condition every poll interval until it holds, can no
+// longer hold, the timeout passes or the call is cancelled. Failed checks
+// are logged and retried on the next one; an error is returned only if
+// the very first check fails, as it most likely names the wrong app.
+func (t *WaitTool) wait(ctx context.Context, cond fly.WaitCondition, timeout time.Duration) (*WaitResult, error) {
+	interfaces.KeepAlive(ctx, timeout)
+
+	result := &WaitResult{WaitCondition: cond}
+	start := time.Now()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := t.flyClient.CheckCondition(ctx, cond)
+		result.Elapsed = math.Round(time.Since(start).Seconds()*10) / 10
+		switch {
+		case err != nil && result.Checks == 0 && ctx.Err() == nil:
+			return nil, err
+		case err != nil:
+			if ctx.Err() == nil {
+				t.logger.Warn().
+					Err(err).
+					Str("app_name", cond.AppName).
+					Str("condition", cond.Kind).
+					Msg("Failed to check wait condition")
+			}
+		default:
+			result.Checks++
+			result.Detail = status.Detail
+			switch {
+			case status.Met:
+				result.Outcome = "met"
+				return result, nil
+			case status.Failed:
+				result.Outcome = "failed"
+				return result, nil
+			}
+			interfaces.ReportProgress(ctx, result.Elapsed, timeout.Seconds(), status.Detail)
+		}
+
+		select {
+		case <-ctx.Done():
+			result.Outcome = "cancelled"
+			return result, nil
+		case <-deadline.C:
+			result.Outcome = "timeout"
+			result.Elapsed = math.Round(time.Since(start).Seconds()*10) / 10
+			return result, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// validateWaitCondition returns what is wrong with a condition's arguments,
+// or "" if nothing is
+func validateWaitCondition(cond fly.WaitCondition) string {
+	switch {
+	case !slices.Contains(fly.WaitConditions, cond.Kind):
+		return fmt.Sprintf("condition must be one of %v", fly.WaitConditions)
+	case cond.Kind == fly.WaitMachineState && (cond.MachineID == "" || cond.State == ""):
+		return "machine_state needs machine_id and state"
+	case cond.Kind == fly.WaitCertificateIssued && cond.Hostname == "":
+		return "certificate_issued needs hostname"
+	}
+	return ""
+}
+
+// formatTextResponse formats the outcome of the wait as human-readable text
+func (t *WaitTool) formatTextResponse(result *WaitResult) *interfaces.ToolResult {
+	var response string
+
+	response += fmt.Sprintf("# Wait: %s\n\n", result.AppName)
+
+	icon := map[string]string{"met": "✅", "failed": "❌", "timeout": "⏱️", "cancelled": "⚪"}[result.Outcome]
+	switch result.Outcome {
+	case "met":
+		response += fmt.Sprintf("%s **%s met** after %.1fs\n", icon, result.Kind, result.Elapsed)
+	case "failed":
+		response += fmt.Sprintf("%s **%s can no longer be met** after %.1fs\n", icon, result.Kind, result.Elapsed)
+	case "timeout":
+		response += fmt.Sprintf("%s **Timed out** waiting for %s after %.1fs\n", icon, result.Kind, result.Elapsed)
+	default:
+		response += fmt.Sprintf("%s **Cancelled** waiting for %s after %.1fs\n", icon, result.Kind, result.Elapsed)
+	}
+
+	response += "\n## Last Status\n"
+	if result.Detail != "" {
+		response += fmt.Sprintf("- %s\n", result.Detail)
+	} else {
+		response += "- The condition could not be checked\n"
+	}
+	response += fmt.Sprintf("- **Checks**: %d\n", result.Checks)
+
+	if result.Outcome == "timeout" || result.Outcome == "failed" {
+		response += "\n## Suggested Actions\n"
+		switch result.Kind {
+		case fly.WaitAppHealthy:
+			response += "- Use `fly_checks` to see which checks fail and why\n"
+			response += "- Use `fly_logs` to look for startup errors\n"
+		case fly.WaitMachineState:
+			response += "- Use `fly_machine_events` to see what happened to the machine\n"
+		case fly.WaitCertificateIssued:
+			response += "- Use `fly_dns` to check the records the certificate needs\n"
+		case fly.WaitDeploymentFinished:
+			response += "- Use `fly_status` and `fly_logs` to see how the deployment is going\n"
+		}
+		if result.Outcome == "timeout" {
+			response += "- Call `fly_wait` again to keep waiting\n"
+		}
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+		IsError: result.Outcome == "failed",
+	}
+}
@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/auth"
+	"github.com/brannn/fly-mcp/pkg/fly"
+	"github.com/brannn/fly-mcp/pkg/interfaces"
+)
+
+// WhoAmITool implements the fly_whoami MCP tool
+type WhoAmITool struct {
+	flyClient   *fly.Client
+	authManager *auth.Manager
+	logger      *logger.Logger
+	listTools   func() []interfaces.Tool
+}
+
+// NewWhoAmITool creates a new identity and permission report tool.
+// listTools returns the currently registered tools.
+func NewWhoAmITool(flyClient *fly.Client, authManager *auth.Manager, logger *logger.Logger, listTools func() []interfaces.Tool) *WhoAmITool {
+	return &WhoAmITool{
+		flyClient:   flyClient,
+		authManager: authManager,
+		logger:      logger,
+		listTools:   listTools,
+	}
+}
+
+// Name returns the tool name
+func (t *WhoAmITool) Name() string {
+	return "fly_whoami"
+}
+
+// Description returns the tool description
+func (t *WhoAmITool) Description() string {
+	return "Report the Fly.io user and organization the server acts as, your own identity and permissions, and which tools you are allowed to call"
+}
+
+// InputSchema returns the JSON schema for the tool's input
+func (t *WhoAmITool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{},
+		"additionalProperties": false,
+	}
+}
+
+// toolAccess describes whether the caller may invoke a tool
+type toolAccess struct {
+	Name       string `json:"name"`
+	Permission string `json:"permission,omitempty"`
+	Allowed    bool   `json:"allowed"`
+	// TokenLimit explains why the Fly.io token cannot make the tool's calls
+	TokenLimit string `json:"tokenLimit,omitempty"`
+}
+
+// whoAmIReport is the full identity and permission report
+type whoAmIReport struct {
+	FlyUser           *fly.User         `json:"flyUser,omitempty"`
+	FlyUserError      string            `json:"flyUserError,omitempty"`
+	Organization      *fly.Organization `json:"organization,omitempty"`
+	OrganizationError string            `json:"organizationError,omitempty"`
+	Token             *auth.TokenScope  `json:"token"`
+	// Profile is the token profile the call acted with; "" is the
+	// configured api_token
+	Profile          string           `json:"profile,omitempty"`
+	Profiles         []string         `json:"profiles,omitempty"`
+	CallerID         string           `json:"callerId"`
+	Permissions      []string         `json:"permissions"`
+	PermissionSource string           `json:"permissionSource"`
+	Roles            []auth.RoleGrant `json:"roles,omitempty"`
+	Tools            []toolAccess     `json:"tools"`
+}
+
+// Execute executes the whoami tool. It needs no permission of its own so
+// that callers can always find out why other tools are denied.
+func (t *WhoAmITool) Execute(ctx context.Context, args map[string]interface{}) (*interfaces.ToolResult, error) {
+	out := NewOutputFormatter(ctx, args)
+
+	userID, _ := t.authManager.ExtractUserFromContext(ctx)
+	t.logger.Info().
+		Str("user_id", userID).
+		Str("tool", "fly_whoami").
+		Msg("Executing whoami tool")
+
+	report := whoAmIReport{
+		CallerID: userID,
+		Token:    t.authManager.TokenScope(ctx),
+		Profile:  auth.ProfileFromContext(ctx),
+		Profiles: t.authManager.AvailableProfiles(ctx),
+	}
+	report.Permissions, report.PermissionSource = t.authManager.EffectivePermissions(userID)
+	report.Roles = t.authManager.Roles(userID)
+
+	if identity, err := t.flyClient.GetIdentity(ctx); err != nil {
+		report.FlyUserError = err.Error()
+		report.OrganizationError = err.Error()
+	} else {
+		report.FlyUser = identity.User
+		report.Organization = identity.Organization
+		if identity.UserErr != nil {
+			report.FlyUserError = identity.UserErr.Error()
+		}
+		if identity.OrganizationErr != nil {
+			report.OrganizationError = identity.OrganizationErr.Error()
+		}
+	}
+
+	for _, tool := range t.listTools() {
+		access := toolAccess{Name: tool.Name(), Allowed: true}
+		if pt, ok := tool.(interfaces.PermissionedTool); ok {
+			action, resource := pt.RequiredPermission()
+			access.Permission = fmt.Sprintf("%s:%s", action, resource)
+			access.Allowed = t.authManager.IsAllowed(userID, action, resource)
+			if err := report.Token.Permits(action, resource); err != nil {
+				var tokenErr *auth.TokenError
+				if errors.As(err, &tokenErr) {
+					access.TokenLimit = tokenErr.Reason
+				}
+				access.Allowed = false
+			}
+		}
+		report.Tools = append(report.Tools, access)
+	}
+	sort.Slice(report.Tools, func(i, j int) bool {
+		return report.Tools[i].Name < report.Tools[j].Name
+	})
+
+	t.authManager.AuditLog(ctx, userID, "whoami", "self", "success", nil)
+
+	return out.Render(t.formatTextResponse(&report), "Caller identity and access", report), nil
+}
+
+// formatTextResponse formats the report as human-readable text
+func (t *WhoAmITool) formatTextResponse(report *whoAmIReport) *interfaces.ToolResult {
+	var response string
+
+	response += "# Who Am I\n\n"
+
+	response += "## Fly.io Account\n"
+	if report.FlyUser != nil {
+		response += fmt.Sprintf("- **User**: %s\n", report.FlyUser.Email)
+		if report.FlyUser.Name != "" {
+			response += fmt.Sprintf("- **Name**: %s\n", report.FlyUser.Name)
+		}
+	} else {
+		response += fmt.Sprintf("- ❌ **User**: %s\n", report.FlyUserError)
+	}
+	if report.Organization != nil {
+		response += fmt.Sprintf("- **Organization**: %s (%s)\n", report.Organization.Name, report.Organization.Slug)
+	} else {
+		response += fmt.Sprintf("- ⚠️ **Organization**: %s\n", report.OrganizationError)
+	}
+	if report.Profile != "" {
+		response += fmt.Sprintf("- **Token profile**: %s\n", report.Profile)
+	}
+	if len(report.Profiles) > 0 {
+		response += fmt.Sprintf("- **Selectable profiles**: %s (pass `fly_profile` to a tool)\n", strings.Join(report.Profiles, ", "))
+	}
+	if report.Token != nil {
+		response += fmt.Sprintf("- **Token**: %s\n", report.Token.Describe())
+		if len(report.Token.Caveats) > 0 {
+			response += fmt.Sprintf("- **Caveats**: %s\n", strings.Join(report.Token.Caveats, ", "))
+		}
+		if report.Token.Error != "" {
+			response += fmt.Sprintf("- ⚠️ **Caveats unreadable**: %s\n", report.Token.Error)
+		}
+	}
+
+	response += "\n## MCP Caller\n"
+	response += fmt.Sprintf("- **Identity**: %s\n", report.CallerID)
+	switch report.PermissionSource {
+	case "user":
+		response += "- **Permissions from**: user entry\n"
+	case "default":
+		response += "- **Permissions from**: default entry\n"
+	case "role":
+		response += "- **Permissions from**: roles\n"
+	case "":
+		if len(report.Roles) > 0 {
+			response += "- **Permissions from**: roles on some apps only\n"
+			break
+		}
+		fallthrough
+	default:
+		response += "- ⚠️ **No permissions configured** for this identity or as a default\n"
+	}
+	for _, p := range report.Permissions {
+		response += fmt.Sprintf("  - `%s`\n", p)
+	}
+	for _, grant := range report.Roles {
+		if len(grant.Apps) > 0 {
+			response += fmt.Sprintf("- **Role**: %s on %s\n", grant.Role, strings.Join(grant.Apps, ", "))
+		} else {
+			response += fmt.Sprintf("- **Role**: %s\n", grant.Role)
+		}
+	}
+
+	response += "\n## Tools\n"
+	denied, tokenLimited := 0, 0
+	for _, tool := range report.Tools {
+		icon := "✅"
+		switch {
+		case tool.TokenLimit != "":
+			icon = "🔒"
+			tokenLimited++
+		case !tool.Allowed:
+			icon = "🚫"
+			denied++
+		}
+		line := fmt.Sprintf("- %s `%s`", icon, tool.Name)
+		if tool.Permission != "" {
+			line += fmt.Sprintf(" (requires `%s`)", tool.Permission)
+		}
+		if tool.TokenLimit != "" {
+			line += fmt.Sprintf(": %s", tool.TokenLimit)
+		}
+		response += line + "\n"
+	}
+
+	if tokenLimited > 0 {
+		response += "\n## Token Restrictions\n"
+		response += "🔒 tools need more than the configured Fly.io token allows. Use a token with a wider scope, e.g. an org token from `fly tokens create org`, to enable them.\n"
+	}
+
+	if denied > 0 {
+		response += "\n## Fixing Denied Tools\n"
+		response += fmt.Sprintf("Add the required permissions for `%s` under `security.permissions` in the server config.\n", report.CallerID)
+	}
+
+	return &interfaces.ToolResult{
+		Content: []interfaces.ContentBlock{{
+			Type: "text",
+			Text: response,
+		}},
+	}
+}
@@ -0,0 +1,484 @@
+// Package watcher polls the machines of selected Fly.io apps in the
+// background, keeps their last known states and detects the changes between
+// polls: machines starting, stopping, appearing or disappearing, and health
+// checks changing status or flapping.
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brannn/fly-mcp/internal/logger"
+	"github.com/brannn/fly-mcp/pkg/config"
+	"github.com/brannn/fly-mcp/pkg/fly"
+)
+
+const (
+	// maxRecent is how many transitions are kept for the snapshot
+	maxRecent = 100
+
+	// pollConcurrency is how many apps are polled at once
+	pollConcurrency = 8
+
+	// pollTimeout bounds one poll of every watched app
+	pollTimeout = 2 * time.Minute
+)
+
+// Transition kinds
+const (
+	KindState     = "state"     // a machine's state changed, e.g. started to stopped
+	KindCheck     = "check"     // a health check's status changed
+	KindFlapping  = "flapping"  // a health check keeps changing status
+	KindCreated   = "created"   // a machine appeared
+	KindDestroyed = "destroyed" // a machine disappeared
+	KindCrash     = "crash"     // a machine's process exited on its own with an error
+	KindOOM       = "oom"       // a machine's process was killed out of memory
+)
+
+// MachineState is the last known state of a watched machine
+type MachineState struct {
+	AppName   string `json:"appName"`
+	MachineID string `json:"machineId"`
+	Name      string `json:"name,omitempty"`
+	Region    string `json:"region,omitempty"`
+	State     string `json:"state"`
+	// Checks maps each health check to its status
+	Checks map[string]string `json:"checks,omitempty"`
+	// Flapping lists the checks currently flapping
+	Flapping []string `json:"flapping,omitempty"`
+
+	// lastEvent is the time, in Unix milliseconds, of the newest machine
+	// event seen, so each exit is reported once
+	lastEvent int64
+}
+
+// Transition is a change detected between two polls
+type Transition struct {
+	Time      time.Time `json:"time"`
+	AppName   string    `json:"appName"`
+	MachineID string    `json:"machineId"`
+	Region    string    `json:"region,omitempty"`
+	Kind      string    `json:"kind"`
+	Check     string    `json:"check,omitempty"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// Snapshot is the watcher's view of the watched apps
+type Snapshot struct {
+	Apps     []string       `json:"apps"`
+	Interval string         `json:"interval"`
+	PolledAt *time.Time     `json:"polledAt,omitempty"`
+	Machines []MachineState `json:"machines"`
+	// Transitions holds the most recent changes, newest first
+	Transitions []Transition `json:"transitions"`
+}
+
+// AppMachines returns the machines of one app in the snapshot
+func (s *Snapshot) AppMachines(appName string) []MachineState {
+	var machines []MachineState
+	for _, m := range s.Machines {
+		if m.AppName == appName {
+			machines = append(machines, m)
+		}
+	}
+	return machines
+}
+
+// Watcher polls machine states and reports transitions
+type Watcher struct {
+	client *fly.Client
+	cfg    config.WatcherConfig
+	logger *logger.Logger
+	onPoll func([]Transition)
+
+	mu       sync.RWMutex
+	machines map[string]map[string]MachineState // by app, then machine ID
+	changes  map[string][]time.Time             // status changes by check
+	flapping map[string]bool
+	recent   []Transition
+	polledAt time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a watcher and starts polling. onPoll is called after every
+// poll with the transitions it found, possibly none.
+func New(client *fly.Client, cfg config.WatcherConfig, log *logger.Logger, onPoll func([]Transition)) *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		client:   client,
+		cfg:      cfg,
+		logger:   log,
+		onPoll:   onPoll,
+		machines: make(map[string]map[string]MachineState),
+		changes:  make(map[string][]time.Time),
+		flapping: make(map[string]bool),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go w.run(ctx)
+	return w
+}
+
+// Close stops polling and waits until a poll in progress ends or ctx expires
+func (w *Watcher) Close(ctx context.Context) error {
+	w.cancel()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("machine watcher still running: %w", ctx.Err())
+	}
+}
+
+// run polls every interval until ctx is cancelled. The first poll records
+// the states changes are detected against.
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(time.Duration(w.cfg.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		w.poll(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll reads the machines of every watched app and reports the transitions
+// found. Apps whose machines cannot be read keep their previous states.
+func (w *Watcher) poll(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, pollTimeout)
+	defer cancel()
+
+	apps, err := w.client.GetApps(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Warn().Err(err).Msg("Machine watcher failed to list apps")
+		}
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, pollConcurrency)
+	watched := make(map[string]bool)
+	var transitions []Transition
+	for _, app := range apps {
+		if !w.watches(app.Name) {
+			continue
+		}
+		watched[app.Name] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			machines, err := w.client.ListMachines(ctx, app.Name)
+			if err != nil {
+				if ctx.Err() == nil {
+					w.logger.Warn().Err(err).Str("app_name", app.Name).Msg("Machine watcher failed to list machines")
+				}
+				return
+			}
+			found := w.update(app.Name, machines, time.Now().UTC())
+
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, found...)
+		}()
+	}
+	wg.Wait()
+
+	w.mu.Lock()
+	// Apps that were deleted, or no longer match, stop being watched
+	for appName := range w.machines {
+		if !watched[appName] {
+			delete(w.machines, appName)
+		}
+	}
+	w.polledAt = time.Now().UTC()
+	sort.Slice(transitions, func(i, j int) bool {
+		if transitions[i].AppName != transitions[j].AppName {
+			return transitions[i].AppName < transitions[j].AppName
+		}
+		return transitions[i].MachineID < transitions[j].MachineID
+	})
+	for i := len(transitions) - 1; i >= 0; i-- {
+		w.recent = append([]Transition{transitions[i]}, w.recent...)
+	}
+	if len(w.recent) > maxRecent {
+		w.recent = w.recent[:maxRecent]
+	}
+	w.mu.Unlock()
+
+	if len(transitions) > 0 {
+		w.logger.Info().
+			Int("transitions", len(transitions)).
+			Msg("Machine watcher detected changes")
+	}
+	if w.onPoll != nil {
+		w.onPoll(transitions)
+	}
+}
+
+// watches reports whether an app is selected by mcp.watcher.apps
+func (w *Watcher) watches(appName string) bool {
+	if len(w.cfg.Apps) == 0 {
+		return true
+	}
+	for _, pattern := range w.cfg.Apps {
+		if ok, _ := path.Match(pattern, appName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// update records the machines of an app and returns the transitions since
+// its previous poll. The first poll of an app only records its states.
+func (w *Watcher) update(appName string, machines []fly.Machine, now time.Time) []Transition {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	previous, known := w.machines[appName]
+	current := make(map[string]MachineState, len(machines))
+	var transitions []Transition
+
+	for _, m := range machines {
+		if m.State == "destroyed" {
+			continue
+		}
+		state := MachineState{
+			AppName:   appName,
+			MachineID: m.ID,
+			Name:      m.Name,
+			Region:    m.Region,
+			State:     m.State,
+			Checks:    make(map[string]string, len(m.Checks)),
+		}
+		for _, check := range m.Checks {
+			state.Checks[check.Name] = check.Status
+		}
+		before, existed := previous[m.ID]
+		state.lastEvent = before.lastEvent
+		for _, e := range m.Events {
+			state.lastEvent = max(state.lastEvent, e.Timestamp)
+		}
+		current[m.ID] = state
+
+		if !known {
+			continue
+		}
+		transition := Transition{Time: now, AppName: appName, MachineID: m.ID, Region: m.Region}
+
+		if !existed {
+			transition.Kind, transition.To = KindCreated, m.State
+			transition.Message = fmt.Sprintf("Machine %s of %s appeared in %s (%s)", m.ID, appName, m.Region, m.State)
+			transitions = append(transitions, transition)
+			continue
+		}
+		if before.State != state.State {
+			transition.Kind, transition.From, transition.To = KindState, before.State, state.State
+			transition.Message = fmt.Sprintf("Machine %s of %s went from %s to %s", m.ID, appName, before.State, state.State)
+			transitions = append(transitions, transition)
+		}
+
+		transitions = append(transitions, exitTransitions(transition, m.Events, before.lastEvent)...)
+
+		names := make([]string, 0, len(state.Checks))
+		for name := range state.Checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			status := state.Checks[name]
+			was, ok := before.Checks[name]
+			if !ok || was == status {
+				continue
+			}
+
+			check := transition
+			check.Check, check.From, check.To = name, was, status
+			if flapping, changes := w.recordCheckChange(checkKey(appName, m.ID, name), now); flapping {
+				check.Kind = KindFlapping
+				check.Message = fmt.Sprintf("Check %s on machine %s of %s is flapping: %d status changes within %s", name, m.ID, appName, changes, time.Duration(w.cfg.FlapWindow)*time.Second)
+				transitions = append(transitions, check)
+			} else if !w.flapping[checkKey(appName, m.ID, name)] {
+				check.Kind = KindCheck
+				check.Message = fmt.Sprintf("Check %s on machine %s of %s went from %s to %s", name, m.ID, appName, was, status)
+				transitions = append(transitions, check)
+			}
+		}
+	}
+
+	if known {
+		ids := make([]string, 0, len(previous))
+		for id := range previous {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			if _, ok := current[id]; ok {
+				continue
+			}
+			before := previous[id]
+			transitions = append(transitions, Transition{
+				Time:      now,
+				AppName:   appName,
+				MachineID: id,
+				Region:    before.Region,
+				Kind:      KindDestroyed,
+				From:      before.State,
+				Message:   fmt.Sprintf("Machine %s of %s is gone (was %s)", id, appName, before.State),
+			})
+		}
+	}
+
+	w.expireFlapping(appName, now)
+	w.machines[appName] = current
+	return transitions
+}
+
+// exitTransitions returns a crash or OOM transition for each exit after
+// the given time that the machine did not ask for, oldest first
+func exitTransitions(base Transition, events []fly.MachineEvent, after int64) []Transition {
+	var exits []fly.MachineEvent
+	for _, e := range events {
+		if e.Timestamp <= after || e.Type != "exit" || e.Request == nil || e.Request.ExitEvent == nil {
+			continue
+		}
+		exit := e.Request.ExitEvent
+		if exit.RequestedStop || (!exit.OOMKilled && exit.ExitCode == 0) {
+			continue
+		}
+		exits = append(exits, e)
+	}
+	sort.Slice(exits, func(i, j int) bool {
+		return exits[i].Timestamp < exits[j].Timestamp
+	})
+
+	transitions := make([]Transition, 0, len(exits))
+	for _, e := range exits {
+		t := base
+		t.Time = time.UnixMilli(e.Timestamp).UTC()
+		if e.Request.ExitEvent.OOMKilled {
+			t.Kind = KindOOM
+			t.Message = fmt.Sprintf("Machine %s of %s was killed out of memory", base.MachineID, base.AppName)
+		} else {
+			t.Kind = KindCrash
+			t.Message = fmt.Sprintf("Machine %s of %s exited with code %d", base.MachineID, base.AppName, e.Request.ExitEvent.ExitCode)
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions
+}
+
+// recordCheckChange records a status change of a check. It reports whether
+// the check just started flapping, and how many changes it had within the
+// window.
+func (w *Watcher) recordCheckChange(key string, now time.Time) (bool, int) {
+	window := time.Duration(w.cfg.FlapWindow) * time.Second
+	changes := append(w.changes[key], now)
+	for len(changes) > 0 && now.Sub(changes[0]) > window {
+		changes = changes[1:]
+	}
+	w.changes[key] = changes
+
+	if len(changes) >= w.cfg.FlapThreshold && !w.flapping[key] {
+		w.flapping[key] = true
+		return true, len(changes)
+	}
+	return false, len(changes)
+}
+
+// expireFlapping forgets the changes of an app's checks that fell out of the
+// window. A flapping check whose status held for the whole window is stable
+// again and reports its changes once more.
+func (w *Watcher) expireFlapping(appName string, now time.Time) {
+	window := time.Duration(w.cfg.FlapWindow) * time.Second
+	prefix := appName + "/"
+	for key, changes := range w.changes {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		for len(changes) > 0 && now.Sub(changes[0]) > window {
+			changes = changes[1:]
+		}
+		if len(changes) == 0 {
+			delete(w.changes, key)
+			delete(w.flapping, key)
+			continue
+		}
+		w.changes[key] = changes
+	}
+}
+
+// Snapshot returns the watched machines and recent transitions of the apps
+// include accepts
+func (w *Watcher) Snapshot(include func(appName string) bool) *Snapshot {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	snapshot := &Snapshot{
+		Apps:        []string{},
+		Interval:    (time.Duration(w.cfg.Interval) * time.Second).String(),
+		Machines:    []MachineState{},
+		Transitions: []Transition{},
+	}
+	if !w.polledAt.IsZero() {
+		polledAt := w.polledAt
+		snapshot.PolledAt = &polledAt
+	}
+
+	for appName, machines := range w.machines {
+		if !include(appName) {
+			continue
+		}
+		snapshot.Apps = append(snapshot.Apps, appName)
+		for _, m := range machines {
+			for name := range m.Checks {
+				if w.flapping[checkKey(appName, m.MachineID, name)] {
+					m.Flapping = append(m.Flapping, name)
+				}
+			}
+			sort.Strings(m.Flapping)
+			snapshot.Machines = append(snapshot.Machines, m)
+		}
+	}
+	sort.Strings(snapshot.Apps)
+	sort.Slice(snapshot.Machines, func(i, j int) bool {
+		a, b := snapshot.Machines[i], snapshot.Machines[j]
+		if a.AppName != b.AppName {
+			return a.AppName < b.AppName
+		}
+		return a.MachineID < b.MachineID
+	})
+
+	for _, t := range w.recent {
+		if include(t.AppName) {
+			snapshot.Transitions = append(snapshot.Transitions, t)
+		}
+	}
+	return snapshot
+}
+
+// checkKey identifies a health check of a machine
+func checkKey(appName, machineID, check string) string {
+	return appName + "/" + machineID + "/" + check
+}
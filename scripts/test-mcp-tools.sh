@@ -155,7 +155,7 @@ main() {
     
     test_mcp_request "tools/call" '{"name": "fly_scale", "arguments": {"app_name": "test-app", "action": "status"}}' "App Scale Tool"
     
-    test_mcp_request "tools/call" '{"name": "fly_restart", "arguments": {"app_name": "test-app", "confirm": false}}' "App Restart Tool (without confirmation)"
+    test_mcp_request "tools/call" '{"name": "fly_restart", "arguments": {"app_name": "test-app"}}' "App Restart Tool (preview without confirmation token)"
     
     echo ""
     echo "🎉 Test suite completed!"